@@ -3,10 +3,15 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
 	"database/sql"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
+	"os"
+	"strings"
 	"time"
 
 	"github.com/hibiken/asynq"
@@ -15,8 +20,16 @@ import (
 	"go.uber.org/zap"
 	"golang.org/x/sync/errgroup"
 
+	"quoteservice/internal/alert"
+	"quoteservice/internal/api"
 	"quoteservice/internal/config"
+	"quoteservice/internal/events"
+	"quoteservice/internal/lifecycle"
+	"quoteservice/internal/lock"
+	"quoteservice/internal/logging/fields"
 	"quoteservice/internal/provider"
+	"quoteservice/internal/quota"
+	"quoteservice/internal/rediskey"
 	"quoteservice/internal/repository"
 	"quoteservice/internal/service"
 	"quoteservice/internal/worker"
@@ -24,26 +37,62 @@ import (
 
 // App holds all application dependencies and manages their lifecycle.
 type App struct {
-	cfg         *config.Config
-	logger      *zap.SugaredLogger
+	cfg    *config.Config
+	opts   Options
+	logger *zap.SugaredLogger
+	// instanceID identifies this process in worker logs, metrics and the updates it processes.
+	instanceID  string
 	db          *sql.DB
 	rdbCache    *redis.Client
 	rdbAsynq    *redis.Client
+	locker      *lock.Locker // Keeps singleton jobs to one instance; uses rdbCache.
 	asynqClient *asynq.Client
-	asynqServer *asynq.Server
-	asynqMux    *asynq.ServeMux
+	workerPool  *worker.Pool
+	workerTuner *workerTuner
+	asynqInsp   *asynq.Inspector
 	asynqMon    *asynqmon.HTTPHandler
 	httpServer  *http.Server
+	// internalServer serves the operational routes when server.internal_port is set.
+	internalServer *http.Server
+	quoteSigner    *api.QuoteSigner
+	quotaTracker   *quota.Tracker
+	lifecycle      *lifecycle.Emitter
+
+	rateProvider    *provider.ExchangeProviderFacade
+	providerWarmer  *worker.ProviderWarmer
+	quoteService    *service.QuoteService
+	defaultPairs    []service.Pair // api.default_pairs, served by GET /quotes/latest/defaults.
+	quoteBroker     *repository.PGNotifyBroker
+	streamingWorker *worker.StreamingWorker
+	retentionJob    *worker.RetentionJob
+	freshness       *worker.FreshnessCollector
+	archivedTasks   *worker.ArchivedTaskCollector
+	reconciler      *worker.Reconciler
 }
 
-// NewApp initializes all dependencies and returns a ready-to-run App.
-func NewApp(cfg *config.Config, logger *zap.SugaredLogger) (*App, error) {
+// Options carries command-line switches that are not part of the configuration.
+type Options struct {
+	// SkipChecksumVerify starts the app even if an applied migration was modified.
+	SkipChecksumVerify bool
+}
+
+// NewApp initializes all dependencies and returns a ready-to-run App. Every
+// dependency is tried and the startup report is logged before NewApp gives up, so
+// the error lists all broken ones.
+func NewApp(cfg *config.Config, logger *zap.SugaredLogger, opts Options) (*App, error) {
 	app := &App{
-		cfg:    cfg,
-		logger: logger,
+		cfg:        cfg,
+		opts:       opts,
+		logger:     logger,
+		instanceID: newInstanceID(),
+		lifecycle:  lifecycle.NewEmitter(logger),
 	}
+	app.lifecycle.Emit(lifecycle.ConfigLoaded, "port", cfg.Server.Port, "internal_port", cfg.Server.InternalPort,
+		fields.InstanceID(app.instanceID))
 
-	if err := app.initStorage(); err != nil {
+	report := app.checkDependencies(true)
+	report.log(logger)
+	if err := report.Err(); err != nil {
 		_ = app.close()
 		return nil, err
 	}
@@ -64,6 +113,11 @@ func (app *App) close() error {
 			errs = append(errs, fmt.Errorf("asynq client close: %w", err))
 		}
 	}
+	if app.asynqInsp != nil {
+		if err := app.asynqInsp.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("asynq inspector close: %w", err))
+		}
+	}
 	if app.asynqMon != nil {
 		if err := app.asynqMon.Close(); err != nil {
 			errs = append(errs, fmt.Errorf("asynqmon close: %w", err))
@@ -87,41 +141,11 @@ func (app *App) close() error {
 	return errors.Join(errs...)
 }
 
-func (app *App) initStorage() error {
-	db, err := repository.NewPostgresDB(&app.cfg.Database)
-	if err != nil {
-		return fmt.Errorf("connect to Postgres: %w", err)
-	}
-	app.db = db
-
-	if err := repository.RunMigrations(app.db, app.logger); err != nil {
-		return fmt.Errorf("run DB migrations: %w", err)
-	}
-
-	app.rdbCache = redis.NewClient(&redis.Options{
-		Addr: app.cfg.Redis.CacheAddr,
-	})
-	if err := app.rdbCache.Ping(context.Background()).Err(); err != nil {
-		return fmt.Errorf("connect to Redis (cache, %s): %w", app.cfg.Redis.CacheAddr, err)
-	}
-	app.logger.Infow("Connected to Redis cache", "addr", app.cfg.Redis.CacheAddr)
-
-	return nil
-}
-
 func (app *App) initServices() error {
 	redisOpt := asynq.RedisClientOpt{Addr: app.cfg.Redis.AsynqAddr}
 
-	app.rdbAsynq = redis.NewClient(&redis.Options{Addr: app.cfg.Redis.AsynqAddr})
 	app.asynqClient = asynq.NewClient(redisOpt)
-	app.asynqServer = asynq.NewServer(
-		redisOpt,
-		asynq.Config{
-			Concurrency:              app.cfg.Worker.Concurrency,
-			DelayedTaskCheckInterval: time.Duration(app.cfg.Worker.CheckIntervalSec) * time.Second,
-			TaskCheckInterval:        time.Duration(app.cfg.Worker.CheckIntervalSec) * time.Second,
-		},
-	)
+	app.asynqInsp = asynq.NewInspector(redisOpt)
 	if app.cfg.Server.ServeAsynqmon {
 		app.asynqMon = asynqmon.New(asynqmon.Options{
 			RootPath:     "/asynq",
@@ -130,46 +154,283 @@ func (app *App) initServices() error {
 	}
 	app.logger.Infow("Asynq configured", "addr", app.cfg.Redis.AsynqAddr)
 
-	rateProvider, err := newRateProvider(app.cfg, app.rdbCache)
-	if err != nil {
-		return err
-	}
+	rateProvider := app.rateProvider
 	quoteRepo := repository.NewPostgresQuoteRepository(app.db)
 	currencyValidator := service.NewValidator()
 	asynqEnqueuer := worker.NewAsynqEnqueuer(
 		app.asynqClient,
 		app.cfg.Worker.MaxRetry,
 		time.Duration(app.cfg.Worker.TimeoutSec)*time.Second,
+		time.Duration(app.cfg.Worker.EnqueueTimeoutMs)*time.Millisecond,
+		app.namespace(),
 	)
-	quoteService := service.NewQuoteService(
-		quoteRepo,
-		rateProvider,
-		currencyValidator,
-		asynqEnqueuer,
-		app.rdbCache,
+	app.quoteBroker = repository.NewPGNotifyBroker(
+		repository.NewNotifyListener(app.db, repository.QuotesUpdatedChannel),
 		app.logger,
-		app.cfg.Cache)
+	)
+	service.SetTimestampPrecision(app.cfg.Server.TimestampPrecision)
+
+	var rateMoves service.RateMoveObserver
+	if m := alert.NewRateMoveMonitor(app.cfg.Alerts, app.logger); m.Enabled() {
+		rateMoves = m
+	}
+	var serviceOpts []service.QuoteServiceOption
+	if app.cfg.Verification.Enabled {
+		verifier := service.NewSpreadVerifier(rateProvider.NamedProviders(), app.cfg.Verification, app.logger)
+		if verifier == nil {
+			app.logger.Infow("Provider spread verification needs at least two providers, leaving it off")
+		}
+		serviceOpts = append(serviceOpts, service.WithSpreadVerifier(verifier))
+	}
+	if app.cfg.Worker.MaxPending > 0 {
+		serviceOpts = append(serviceOpts, service.WithPendingLimit(app.cfg.Worker.MaxPending,
+			time.Duration(app.cfg.Worker.PendingCountCacheMs)*time.Millisecond))
+	}
+	var taskDurations *worker.TaskDurations
+	if ph := app.cfg.PollHint; ph.Enabled {
+		taskDurations = worker.NewTaskDurations(app.rdbAsynq, ph.Window, app.namespace())
+		serviceOpts = append(serviceOpts, service.WithPollEstimator(worker.NewPollHint(app.asynqInsp, taskDurations, worker.PollHintConfig{
+			Default:     time.Duration(ph.DefaultMs) * time.Millisecond,
+			Min:         time.Duration(ph.MinMs) * time.Millisecond,
+			Max:         time.Duration(ph.MaxMs) * time.Millisecond,
+			CacheFor:    time.Duration(ph.CacheMs) * time.Millisecond,
+			Concurrency: app.cfg.Worker.Concurrency,
+			Namespace:   app.namespace(),
+		}, app.logger)))
+	}
+	var webhookRepo repository.WebhookRepository
+	if app.cfg.Webhooks.Enabled() {
+		webhookRepo = repository.NewPostgresWebhookRepository(app.db)
+	}
+	app.quoteService = service.NewQuoteService(service.QuoteServiceDeps{
+		Repo:             quoteRepo,
+		Provider:         rateProvider,
+		Validator:        currencyValidator,
+		Enqueuer:         asynqEnqueuer,
+		Cache:            app.rdbCache,
+		Namespace:        app.namespace(),
+		Watcher:          app.quoteBroker,
+		RateMoves:        rateMoves,
+		Events:           newEventPublisher(&app.cfg.Events, app.rdbCache),
+		Pairs:            service.NewPairResolver(service.DefaultPairSettings(app.cfg), app.cfg.Pairs),
+		WebhookRepo:      webhookRepo,
+		WebhookSecretKey: []byte(app.cfg.Webhooks.SecretHashKey),
+		Logger:           app.logger,
+		CacheConfig:      app.cfg.Cache,
+		InstanceID:       app.instanceID,
+	}, serviceOpts...)
+
+	var err error
+	if app.defaultPairs, err = parseDefaultPairs(app.cfg.API.DefaultPairs, currencyValidator); err != nil {
+		return err
+	}
+
+	if app.cfg.Streaming.Enabled {
+		app.streamingWorker, err = newStreamingWorker(&app.cfg.Streaming, app.quoteService, app.logger)
+		if err != nil {
+			return err
+		}
+	}
 
-	app.asynqMux = asynq.NewServeMux()
-	app.asynqMux.HandleFunc(service.TaskTypeUpdateQuote, worker.NewQuoteUpdateHandler(quoteService, app.logger))
+	if rc := app.cfg.Retention; rc.Enabled {
+		archiver := repository.NewPostgresQuoteArchiver(app.db, rc.Mode)
+		tables := []worker.RetentionTable{
+			{Name: "quotes", MaxAge: time.Duration(rc.MaxAgeDays) * 24 * time.Hour, Batch: archiver.ArchiveBatch},
+		}
+		if rc.EventsMaxAgeDays > 0 {
+			tables = append(tables, worker.RetentionTable{
+				Name: "quote_status_events", MaxAge: time.Duration(rc.EventsMaxAgeDays) * 24 * time.Hour,
+				Batch: archiver.DeleteEventsBatch,
+			})
+		}
+		app.retentionJob = worker.NewRetentionJob(
+			tables,
+			rc.BatchSize,
+			time.Duration(rc.IntervalSec)*time.Second,
+			app.locker,
+			app.logger,
+		)
+	}
+
+	if fc := app.cfg.Freshness; fc.Enabled {
+		pairs := make([]repository.Pair, 0, len(fc.Pairs))
+		for _, p := range fc.Pairs {
+			pair, err := service.ParsePair(p)
+			if err != nil {
+				return fmt.Errorf("freshness.pairs: %q: %w", p, err)
+			}
+			pairs = append(pairs, pair)
+		}
+		app.freshness = worker.NewFreshnessCollector(quoteRepo, pairs,
+			time.Duration(fc.IntervalSec)*time.Second, app.logger)
+	}
+
+	if sec := app.cfg.Worker.ArchivedCheckIntervalSec; sec > 0 {
+		app.archivedTasks = worker.NewArchivedTaskCollector(app.asynqInsp, app.namespace(),
+			time.Duration(sec)*time.Second, app.logger)
+	}
+
+	rc := app.cfg.Reconcile
+	app.reconciler = worker.NewReconciler(quoteRepo, app.quoteService, app.asynqInsp, app.namespace(), worker.ReconcileConfig{
+		Grace:     time.Duration(rc.GraceSec) * time.Second,
+		GiveUp:    time.Duration(rc.GiveUpSec) * time.Second,
+		BatchSize: rc.BatchSize,
+	}, app.logger)
+
+	workerLog := app.logger.With(fields.InstanceID(app.instanceID))
+	asynqMux := asynq.NewServeMux()
+	asynqMux.Use(worker.LogTasks(workerLog), worker.CountTasks(app.instanceID), worker.Recover(app.quoteService, workerLog))
+	var updateHandler asynq.Handler = asynq.HandlerFunc(worker.NewQuoteUpdateHandler(app.quoteService, workerLog))
+	if taskDurations != nil {
+		updateHandler = worker.RecordDurations(updateHandler, taskDurations, workerLog)
+	}
+	asynqMux.Handle(service.TaskTypeUpdateQuote, updateHandler)
+	asynqMux.HandleFunc(worker.TaskTypeDiagnostics, worker.HandleDiagnosticsTask)
+	taskHandler := worker.WithFallback(asynqMux, worker.NewUnknownTaskHandler(app.cfg.Worker.DeferUnknownTasks, app.logger))
+
+	poolCfg := worker.PoolConfig{
+		Concurrency: app.cfg.Worker.Concurrency,
+		TaskTimeout: time.Duration(app.cfg.Worker.TimeoutSec) * time.Second,
+	}
+	var runtimeStore *worker.RuntimeConfigStore
+	if app.cfg.Worker.AllowRuntimeTuning {
+		runtimeStore = worker.NewRuntimeConfigStore(app.rdbAsynq, app.namespace())
+		stored, ok, err := runtimeStore.Load(context.Background())
+		switch {
+		case err != nil:
+			app.logger.Warnw("Ignoring stored worker runtime config", "error", err)
+		case ok:
+			app.logger.Infow("Using stored worker runtime config",
+				"concurrency", stored.Concurrency, "task_timeout", stored.TaskTimeout)
+			poolCfg = stored
+		}
+	}
+	app.workerPool = worker.NewPool(redisOpt, asynq.Config{
+		Queues:                   worker.Queues(app.namespace()),
+		DelayedTaskCheckInterval: time.Duration(app.cfg.Worker.CheckIntervalSec) * time.Second,
+		TaskCheckInterval:        time.Duration(app.cfg.Worker.CheckIntervalSec) * time.Second,
+		IsFailure:                worker.IsFailure,
+	}, poolCfg, taskHandler, asynqEnqueuer, app.logger)
+	if runtimeStore != nil {
+		app.workerTuner = &workerTuner{pool: app.workerPool, store: runtimeStore, logger: app.logger}
+	}
+
+	if app.cfg.Signing.Enabled {
+		signer, err := newQuoteSigner(app.cfg.Signing)
+		if err != nil {
+			return err
+		}
+		app.quoteSigner = signer
+		app.logger.Infow("Quote response signing enabled", "key_id", app.cfg.Signing.KeyID)
+	}
+
+	if app.cfg.Auth.Enabled && app.cfg.Auth.QuotaEnabled {
+		limits := make(map[string]int64, len(app.cfg.Auth.APIKeys))
+		for _, k := range app.cfg.Auth.APIKeys {
+			limits[k.Name] = k.MonthlyQuota
+		}
+		app.quotaTracker = quota.NewTracker(app.rdbCache, app.namespace(), limits)
+	}
 
-	app.initHTTP(quoteService)
+	app.initHTTP(app.quoteService)
 	return nil
 }
 
-func newRateProvider(cfg *config.Config, cache *redis.Client) (provider.RatesProvider, error) {
+// newQuoteSigner returns a signer with the secret of cfg.KeyID, read from its key file
+// if it has one. Surrounding whitespace in the file is ignored.
+func newQuoteSigner(cfg config.SigningConfig) (*api.QuoteSigner, error) {
+	secret := cfg.Keys[cfg.KeyID]
+	if path, ok := cfg.KeyFiles[cfg.KeyID]; ok {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("signing.key_files[%s]: %w", cfg.KeyID, err)
+		}
+		secret = strings.TrimSpace(string(data))
+		if secret == "" {
+			return nil, fmt.Errorf("signing.key_files[%s]: %s is empty", cfg.KeyID, path)
+		}
+	}
+	return api.NewQuoteSigner(cfg.KeyID, []byte(secret)), nil
+}
+
+func newEventPublisher(cfg *config.EventsConfig, cache *redis.Client) service.QuoteEventPublisher {
+	if cfg.Sink == config.EventSinkRedisStream {
+		return events.NewRedisStreamPublisher(cache, cfg.Stream, cfg.MaxLen)
+	}
+	return service.NopQuoteEventPublisher{}
+}
+
+// namespace returns the namespace of the service's Redis keys and asynq queues.
+func (app *App) namespace() rediskey.Namespace {
+	return rediskey.Namespace(app.cfg.Redis.Namespace)
+}
+
+// newProviderWarmer returns the warmer refilling a provider's cache after its circuit
+// closes, or nil when provider_warmup is disabled.
+func newProviderWarmer(cfg *config.Config, locker *lock.Locker, logger *zap.SugaredLogger) (*worker.ProviderWarmer, error) {
+	pw := cfg.ProviderWarmup
+	if !pw.Enabled {
+		return nil, nil
+	}
+	source, names := "provider_warmup.pairs", pw.Pairs
+	if len(names) == 0 {
+		source, names = "cache.warmup_pairs", cfg.Cache.WarmupPairs
+	}
+	pairs := make([]repository.Pair, 0, len(names))
+	for _, p := range names {
+		pair, err := service.ParsePair(p)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %q: %w", source, p, err)
+		}
+		pairs = append(pairs, pair)
+	}
+	return worker.NewProviderWarmer(locker, pairs, time.Duration(pw.DelayMs)*time.Millisecond, logger), nil
+}
+
+// newRateProvider builds the provider facade. When warmer is set, each provider's cache
+// is warmed through it whenever the provider's circuit closes.
+func newRateProvider(cfg *config.Config, cache *redis.Client, warmer *worker.ProviderWarmer) (*provider.ExchangeProviderFacade, error) {
 	ttl := time.Duration(cfg.Cache.ExchangeProviderPriceTTLSec) * time.Second
+	keys := rediskey.Namespace(cfg.Redis.Namespace)
+	withBreaker := func(name string, p provider.RatesProvider) provider.RatesProvider {
+		if cfg.CircuitBreaker.FailureThreshold == 0 {
+			return p
+		}
+		cb := provider.NewCircuitBreakerProvider(p, cfg.CircuitBreaker.FailureThreshold,
+			time.Duration(cfg.CircuitBreaker.OpenSec)*time.Second)
+		if warmer != nil {
+			cb.OnClose(func() { warmer.Trigger(name, p) })
+		}
+		return cb
+	}
 
-	var providers []provider.RatesProvider
+	transport, err := provider.NewTransport(provider.TransportConfig{
+		ProxyURL:           cfg.HTTPClient.ProxyURL,
+		CACertFile:         cfg.HTTPClient.CACertFile,
+		InsecureSkipVerify: cfg.HTTPClient.InsecureSkipVerify,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("http_client: %w", err)
+	}
+	withTransport := provider.WithTransport(transport)
+
+	var providers []provider.NamedProvider
 
 	if cfg.ExchangeRateHost.BaseURL != "" && cfg.ExchangeRateHost.APIKey != "" {
-		p := provider.NewExchangeRateHostProvider(cfg.ExchangeRateHost.BaseURL, cfg.ExchangeRateHost.APIKey, cfg.ExchangeRateHost.Timeout)
-		providers = append(providers, provider.NewCachedRatesProvider(p, cache, ttl, "exchangerate_host"))
+		p := provider.NewExchangeRateHostProvider(cfg.ExchangeRateHost.BaseURL, cfg.ExchangeRateHost.APIKey,
+			cfg.ExchangeRateHost.Timeout, withTransport)
+		providers = append(providers, provider.NamedProvider{
+			Name:     config.ProviderExchangeRateHost,
+			Provider: withBreaker(config.ProviderExchangeRateHost, provider.NewCachedRatesProvider(p, cache, ttl, config.ProviderExchangeRateHost, keys)),
+		})
 	}
 
 	if cfg.Frankfurter.BaseURL != "" {
-		p := provider.NewFrankfurterProvider(cfg.Frankfurter.BaseURL, cfg.Frankfurter.Timeout)
-		providers = append(providers, provider.NewCachedRatesProvider(p, cache, ttl, "frankfurter"))
+		p := provider.NewFrankfurterProvider(cfg.Frankfurter.BaseURL, cfg.Frankfurter.Timeout, withTransport)
+		providers = append(providers, provider.NamedProvider{
+			Name:     config.ProviderFrankfurter,
+			Provider: withBreaker(config.ProviderFrankfurter, provider.NewCachedRatesProvider(p, cache, ttl, config.ProviderFrankfurter, keys)),
+		})
 	}
 
 	if len(providers) == 0 {
@@ -177,11 +438,66 @@ func newRateProvider(cfg *config.Config, cache *redis.Client) (provider.RatesPro
 			"frankfurter requires base_url, exchangerate_host requires base_url and api_key")
 	}
 
-	if len(providers) == 1 {
-		return providers[0], nil
+	// The facade is kept even for a single provider so per-pair provider orders apply.
+	facade := provider.NewNamedExchangeProviderFacade(providers...)
+	ps := cfg.ProviderSelection
+	facade.UseScorer(provider.NewScorer(ps.Window, ps.ExplorePct/100), ps.Strategy == config.ProviderStrategyAdaptive)
+	return facade, nil
+}
+
+// instanceSuffixBytes is the number of random bytes appended to the hostname in an
+// instance ID, so processes sharing a hostname, e.g. after a restart, differ.
+const instanceSuffixBytes = 3
+
+// newInstanceID returns the ID of this process: the hostname, or "unknown" if it cannot
+// be read, and a short random hex suffix.
+func newInstanceID() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "unknown"
+	}
+	suffix := make([]byte, instanceSuffixBytes)
+	_, _ = rand.Read(suffix) // Never fails, see crypto/rand.Read.
+	return host + "-" + hex.EncodeToString(suffix)
+}
+
+// parseDefaultPairs parses api.default_pairs, keeping their order. Unlike the other pair
+// lists they are served as-is, so a pair with an unsupported currency fails startup
+// instead of every request.
+func parseDefaultPairs(names []string, v service.Validator) ([]service.Pair, error) {
+	pairs := make([]service.Pair, 0, len(names))
+	for _, p := range names {
+		pair, err := service.ParsePair(service.NormalizePair(p))
+		if err == nil {
+			err = errors.Join(v.Validate(pair.Base), v.Validate(pair.Quote))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("api.default_pairs: %q: %w", p, err)
+		}
+		pairs = append(pairs, pair)
+	}
+	return pairs, nil
+}
+
+func newStreamingWorker(cfg *config.StreamingProviderConfig, applier worker.RateApplier, logger *zap.SugaredLogger) (*worker.StreamingWorker, error) {
+	pairs := make([]provider.PairKey, 0, len(cfg.Pairs))
+	for _, p := range cfg.Pairs {
+		pair, err := service.ParsePair(p)
+		if err != nil {
+			return nil, fmt.Errorf("streaming_provider.pairs: %q: %w", p, err)
+		}
+		pairs = append(pairs, provider.PairKey{Base: pair.Base, Quote: pair.Quote})
 	}
 
-	return provider.NewExchangeProviderFacade(providers...), nil
+	maxBackoff := time.Duration(cfg.MaxBackoffMs) * time.Millisecond
+	wsProvider := provider.NewWebSocketProvider(provider.WebSocketConfig{
+		URL:                  cfg.URL,
+		InitialBackoff:       time.Duration(cfg.InitialBackoffMs) * time.Millisecond,
+		MaxBackoff:           maxBackoff,
+		MaxReconnectAttempts: cfg.MaxReconnectAttempts,
+	})
+	logger.Infow("Streaming provider configured", "url", cfg.URL, "pairs", len(pairs))
+	return worker.NewStreamingWorker(wsProvider, applier, pairs, maxBackoff, logger), nil
 }
 
 // Run starts the HTTP server and Asynq worker, blocking until the context is canceled.
@@ -190,22 +506,86 @@ func (app *App) Run(ctx context.Context) error {
 
 	g.Go(func() error {
 		app.logger.Infow("Starting Asynq worker server")
-		if err := app.asynqServer.Start(app.asynqMux); err != nil {
+		if err := app.workerPool.Start(); err != nil {
 			return fmt.Errorf("asynq worker failed to start: %w", err)
 		}
+		app.lifecycle.Emit(lifecycle.WorkerStarted)
 
 		<-ctx.Done()
 		return nil
 	})
 
 	g.Go(func() error {
-		app.logger.Infow("HTTP server listening", "port", app.cfg.Server.Port)
-		if err := app.httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
-			return fmt.Errorf("HTTP server error: %w", err)
+		err := app.locker.Do(ctx, "cache_warmup", 0, func(ctx context.Context) error {
+			app.quoteService.WarmCache(ctx, app.cfg.Cache.WarmupPairs)
+			return nil
+		})
+		if err != nil {
+			// Another instance is warming the shared cache, or Redis is down and there
+			// is nothing to warm; neither should keep this instance from serving.
+			app.logger.Infow("Skipping cache warmup", "error", err)
+			app.quoteService.SkipCacheWarmup()
 		}
 		return nil
 	})
 
+	g.Go(func() error {
+		// Retries LISTEN until ctx is cancelled; /readyz reports it while it is down.
+		return app.quoteBroker.Run(ctx)
+	})
+
+	if app.cfg.Reconcile.Enabled {
+		g.Go(func() error {
+			// Failures are logged; updates left PENDING are picked up by the next run.
+			// Instances starting together reconcile once.
+			_ = app.locker.Do(ctx, "reconcile", 0, func(ctx context.Context) error {
+				_, err := app.reconciler.Reconcile(ctx)
+				return err
+			})
+			return nil
+		})
+	}
+
+	if app.streamingWorker != nil {
+		g.Go(func() error {
+			return app.streamingWorker.Run(ctx)
+		})
+	}
+
+	if app.retentionJob != nil {
+		g.Go(func() error {
+			return app.retentionJob.Run(ctx)
+		})
+	}
+
+	if app.freshness != nil {
+		g.Go(func() error {
+			return app.freshness.Run(ctx)
+		})
+	}
+
+	if app.archivedTasks != nil {
+		g.Go(func() error {
+			return app.archivedTasks.Run(ctx)
+		})
+	}
+
+	if app.providerWarmer != nil {
+		g.Go(func() error {
+			return app.providerWarmer.Run(ctx)
+		})
+	}
+
+	g.Go(func() error {
+		return app.serveHTTP(app.httpServer, "public")
+	})
+
+	if app.internalServer != nil {
+		g.Go(func() error {
+			return app.serveHTTP(app.internalServer, "internal")
+		})
+	}
+
 	// Graceful shutdown: triggered by context cancellation (signal or component failure).
 	g.Go(func() error {
 		<-ctx.Done()
@@ -215,10 +595,24 @@ func (app *App) Run(ctx context.Context) error {
 	return g.Wait()
 }
 
+// serveHTTP binds srv's address and serves it until shutdown. The listener is bound
+// before http_listening is emitted, so the event means connections are accepted.
+func (app *App) serveHTTP(srv *http.Server, listener string) error {
+	ln, err := net.Listen("tcp", srv.Addr)
+	if err != nil {
+		return fmt.Errorf("%s HTTP server listen: %w", listener, err)
+	}
+	app.lifecycle.Emit(lifecycle.HTTPListening, "listener", listener, "addr", ln.Addr().String())
+	if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("%s HTTP server error: %w", listener, err)
+	}
+	return nil
+}
+
 // shutdown performs ordered teardown: HTTP server -> Asynq worker -> connections.
 // This ensures in-flight tasks finish before the DB and Redis connections close.
 func (app *App) shutdown() error {
-	app.logger.Infow("Shutting down server...")
+	app.lifecycle.Emit(lifecycle.Draining)
 
 	var errs []error
 
@@ -230,9 +624,15 @@ func (app *App) shutdown() error {
 		app.logger.Errorw("HTTP server shutdown error", "error", err)
 		errs = append(errs, fmt.Errorf("http shutdown: %w", err))
 	}
+	if app.internalServer != nil {
+		if err := app.internalServer.Shutdown(shutdownCtx); err != nil {
+			app.logger.Errorw("Internal HTTP server shutdown error", "error", err)
+			errs = append(errs, fmt.Errorf("internal http shutdown: %w", err))
+		}
+	}
 
 	// 2. Drain in-flight Asynq tasks
-	app.asynqServer.Shutdown()
+	app.workerPool.Shutdown()
 
 	// 3. Close connections (asynq client, Redis, database)
 	if err := app.close(); err != nil {
@@ -240,6 +640,6 @@ func (app *App) shutdown() error {
 		errs = append(errs, err)
 	}
 
-	app.logger.Infow("Shutdown complete")
+	app.lifecycle.Emit(lifecycle.ShutdownComplete)
 	return errors.Join(errs...)
 }
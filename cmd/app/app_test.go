@@ -0,0 +1,128 @@
+package main
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+
+	"quoteservice/internal/lifecycle"
+	"quoteservice/internal/service"
+	"quoteservice/internal/worker"
+)
+
+func TestApp_LifecycleEvents(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	logger := zap.New(core).Sugar()
+	pool := worker.NewPool(asynq.RedisClientOpt{}, asynq.Config{}, worker.PoolConfig{Concurrency: 1, TaskTimeout: time.Second},
+		asynq.HandlerFunc(nil), nil, logger,
+		worker.WithServerFactory(func(asynq.RedisConnOpt, asynq.Config) worker.Server { return &fakeServer{} }))
+	app := &App{
+		logger:     logger,
+		lifecycle:  lifecycle.NewEmitter(logger),
+		workerPool: pool,
+		httpServer: &http.Server{Addr: "127.0.0.1:0", Handler: http.NotFoundHandler()},
+	}
+	events := func() []string {
+		var names []string
+		for _, e := range logs.FilterMessage("Lifecycle event").All() {
+			names = append(names, e.ContextMap()["event"].(string))
+		}
+		return names
+	}
+
+	served := make(chan error, 1)
+	go func() { served <- app.serveHTTP(app.httpServer, "public") }()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(events()) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected http_listening to be emitted")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	listening := logs.FilterMessage("Lifecycle event").All()[0].ContextMap()
+	if listening["event"] != "http_listening" || listening["listener"] != "public" {
+		t.Fatalf("Unexpected first event %v", listening)
+	}
+	// The port is bound by the time the event is logged.
+	conn, err := net.Dial("tcp", listening["addr"].(string))
+	if err != nil {
+		t.Fatalf("Expected %v to accept connections: %v", listening["addr"], err)
+	}
+	_ = conn.Close()
+	if phase := app.lifecycle.State().Phase; phase != lifecycle.PhaseServing {
+		t.Errorf("Expected phase serving, got %s", phase)
+	}
+
+	if err := app.shutdown(); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+	if err := <-served; err != nil {
+		t.Errorf("Expected serveHTTP to return nil after shutdown, got %v", err)
+	}
+	want := []string{"http_listening", "draining", "shutdown_complete"}
+	if got := events(); !slices.Equal(got, want) {
+		t.Errorf("Expected events %v, got %v", want, got)
+	}
+	if phase := app.lifecycle.State().Phase; phase != lifecycle.PhaseStopped {
+		t.Errorf("Expected phase stopped, got %s", phase)
+	}
+}
+
+func TestApp_ServeHTTPListenFailure(t *testing.T) {
+	taken, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer taken.Close()
+
+	core, logs := observer.New(zap.InfoLevel)
+	app := &App{lifecycle: lifecycle.NewEmitter(zap.New(core).Sugar())}
+	if err := app.serveHTTP(&http.Server{Addr: taken.Addr().String()}, "public"); err == nil {
+		t.Fatal("Expected an error for a port in use")
+	}
+	if logs.Len() != 0 {
+		t.Errorf("Expected no http_listening for an unbound port, got %v", logs.All())
+	}
+}
+
+func TestParseDefaultPairs(t *testing.T) {
+	pairs, err := parseDefaultPairs([]string{"eur/usd", " GBP/JPY", "EUR/USD"}, service.NewValidator())
+	if err != nil {
+		t.Fatalf("parseDefaultPairs: %v", err)
+	}
+	want := []service.Pair{{Base: "EUR", Quote: "USD"}, {Base: "GBP", Quote: "JPY"}, {Base: "EUR", Quote: "USD"}}
+	if !slices.Equal(pairs, want) {
+		t.Errorf("Expected %v, got %v", want, pairs)
+	}
+
+	if _, err := parseDefaultPairs([]string{"EUR/USD", "EUR/XAU"}, service.NewValidator()); !errors.Is(err, service.ErrUnsupportedCurrency) {
+		t.Errorf("Expected ErrUnsupportedCurrency, got %v", err)
+	}
+	if _, err := parseDefaultPairs([]string{"EURUSD"}, service.NewValidator()); !errors.Is(err, service.ErrInvalidPairFormat) {
+		t.Errorf("Expected ErrInvalidPairFormat, got %v", err)
+	}
+}
+
+func TestNewInstanceID(t *testing.T) {
+	host, err := os.Hostname()
+	if err != nil {
+		t.Skipf("no hostname: %v", err)
+	}
+	a, b := newInstanceID(), newInstanceID()
+	if !strings.HasPrefix(a, host+"-") || len(a) != len(host)+1+2*instanceSuffixBytes {
+		t.Errorf("Expected %q followed by a %d-character suffix, got %q", host+"-", 2*instanceSuffixBytes, a)
+	}
+	if a == b {
+		t.Errorf("Expected two instance IDs to differ, got %q twice", a)
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+
+	"quoteservice/internal/api"
+	"quoteservice/internal/rediskey"
+	"quoteservice/internal/repository"
+	"quoteservice/internal/worker"
+)
+
+// diagnosticsTimeout bounds a whole GET /admin/selfcheck run.
+const diagnosticsTimeout = 5 * time.Second
+
+// diagnosticsSteps lists the steps of GET /admin/selfcheck. Each writes only
+// throwaway data: a rolled-back record, a temporary cache key and a deleted task.
+func (app *App) diagnosticsSteps() []api.SelfCheckStep {
+	return []api.SelfCheckStep{
+		{Name: "postgres_write", Run: func(ctx context.Context) error {
+			return repository.ProbeWrite(ctx, app.db)
+		}},
+		{Name: "redis_cache", Run: func(ctx context.Context) error {
+			return probeCache(ctx, app.rdbCache, app.namespace())
+		}},
+		{Name: "redis_asynq", Run: func(ctx context.Context) error {
+			return worker.ProbeQueue(ctx, app.asynqClient, app.asynqInsp, app.namespace())
+		}},
+	}
+}
+
+// probeCache writes a short-lived key, reads it back and deletes it. The TTL removes
+// the key should the delete fail.
+func probeCache(ctx context.Context, client *redis.Client, keys rediskey.Namespace) error {
+	key := keys.Key("diagnostics:" + uuid.New().String())
+	value := uuid.New().String()
+	if err := client.Set(ctx, key, value, time.Minute).Err(); err != nil {
+		return fmt.Errorf("write: %w", err)
+	}
+	got, err := client.Get(ctx, key).Result()
+	if err != nil {
+		return fmt.Errorf("read back: %w", err)
+	}
+	if got != value {
+		return fmt.Errorf("read back: got %q, want %q", got, value)
+	}
+	if err := client.Del(ctx, key).Err(); err != nil {
+		return fmt.Errorf("delete: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/hibiken/asynq"
+	"github.com/redis/go-redis/v9"
+
+	"quoteservice/internal/api"
+	"quoteservice/internal/config"
+)
+
+// TestApp_DiagnosticsSteps takes the dependencies down one by one and checks that each
+// failure shows up in its own step only. Postgres is never reachable here.
+func TestApp_DiagnosticsSteps(t *testing.T) {
+	db, err := sql.Open("pgx", "postgres://quotes@127.0.0.1:1/quotes?sslmode=disable&connect_timeout=1")
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	cacheRedis, asynqRedis := miniredis.RunT(t), miniredis.RunT(t)
+	rdbCache := redis.NewClient(&redis.Options{Addr: cacheRedis.Addr(), MaxRetries: -1})
+	t.Cleanup(func() { _ = rdbCache.Close() })
+	asynqOpt := asynq.RedisClientOpt{Addr: asynqRedis.Addr()}
+	app := &App{
+		cfg:         &config.Config{Redis: config.RedisConfig{Namespace: "staging"}},
+		db:          db,
+		rdbCache:    rdbCache,
+		asynqClient: asynq.NewClient(asynqOpt),
+		asynqInsp:   asynq.NewInspector(asynqOpt),
+	}
+	t.Cleanup(func() { _ = app.asynqClient.Close(); _ = app.asynqInsp.Close() })
+	selfCheck := api.HandleSelfCheck(diagnosticsTimeout, app.diagnosticsSteps()...)
+
+	expect := func(want map[string]string) {
+		t.Helper()
+		w := httptest.NewRecorder()
+		selfCheck(w, httptest.NewRequest(http.MethodGet, "/admin/selfcheck", nil))
+		if w.Code != http.StatusServiceUnavailable {
+			t.Errorf("expected 503, got %d", w.Code)
+		}
+		var resp api.SelfCheckResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if len(resp.Steps) != len(want) {
+			t.Fatalf("expected %d steps, got %+v", len(want), resp.Steps)
+		}
+		for _, s := range resp.Steps {
+			if s.Status != want[s.Name] {
+				t.Errorf("expected step %s %s, got %+v", s.Name, want[s.Name], s)
+			}
+			if (s.Error != "") != (s.Status == api.ComponentStatusError) {
+				t.Errorf("expected an error only on a failed step, got %+v", s)
+			}
+		}
+	}
+
+	expect(map[string]string{"postgres_write": "error", "redis_cache": "ok", "redis_asynq": "ok"})
+	if keys := cacheRedis.Keys(); len(keys) != 0 {
+		t.Errorf("expected no cache keys left behind, got %v", keys)
+	}
+	if n := len(asynqRedis.Keys()); n == 0 {
+		t.Fatal("expected the probe task to have reached the asynq Redis")
+	}
+
+	cacheRedis.Close()
+	expect(map[string]string{"postgres_write": "error", "redis_cache": "error", "redis_asynq": "ok"})
+
+	asynqRedis.Close()
+	start := time.Now()
+	expect(map[string]string{"postgres_write": "error", "redis_cache": "error", "redis_asynq": "error"})
+	if elapsed := time.Since(start); elapsed > diagnosticsTimeout+time.Second {
+		t.Errorf("expected the check bounded by its timeout, took %v", elapsed)
+	}
+}
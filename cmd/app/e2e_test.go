@@ -0,0 +1,406 @@
+//go:build integration
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+
+	"quoteservice/internal/api"
+	"quoteservice/internal/config"
+	"quoteservice/internal/testkit"
+	"quoteservice/internal/testkit/fakeprovider"
+)
+
+func TestMain(m *testing.M) {
+	testkit.Run(m)
+}
+
+// e2eEnv is a running App wired to the testkit Postgres/Redis and a fake Frankfurter.
+type e2eEnv struct {
+	baseURL string
+	fake    *fakeprovider.Server
+	db      *sql.DB
+	rdb     *redis.Client
+	stop    func() // Cancels the app and waits for Run to return; runs again at cleanup as a no-op.
+}
+
+func startApp(t *testing.T) *e2eEnv {
+	t.Helper()
+	return startAppWithLogger(t, zap.NewNop().Sugar())
+}
+
+func startAppWithLogger(t *testing.T, logger *zap.SugaredLogger) *e2eEnv {
+	t.Helper()
+	suite := testkit.Global()
+
+	fake := fakeprovider.New(fakeprovider.Frankfurter)
+	t.Cleanup(fake.Close)
+
+	port := freePort(t)
+	cfg := &config.Config{
+		Server: config.ServerConfig{Port: port},
+		Database: config.DatabaseConfig{
+			DSN:                suite.PostgresDSN(),
+			MaxOpenConns:       5,
+			MaxIdleConns:       2,
+			ConnMaxLifetimeSec: 60,
+		},
+		Redis: config.RedisConfig{
+			AsynqAddr: suite.RedisAddr(),
+			CacheAddr: suite.RedisAddr(),
+		},
+		Frankfurter: config.FrankfurterConfig{BaseURL: fake.URL(), Timeout: 2},
+		Worker: config.WorkerConfig{
+			Concurrency:      2,
+			MaxRetry:         0,
+			TimeoutSec:       10,
+			CheckIntervalSec: 1,
+		},
+		Cache: config.CacheConfig{
+			LatestPriceTTLSec:           600,
+			ExchangeProviderPriceTTLSec: 1,
+		},
+	}
+
+	app, err := NewApp(cfg, logger, Options{})
+	if err != nil {
+		t.Fatalf("NewApp: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- app.Run(ctx) }()
+	var once sync.Once
+	stop := func() {
+		once.Do(func() {
+			cancel()
+			select {
+			case err := <-done:
+				if err != nil {
+					t.Errorf("app.Run: %v", err)
+				}
+			case <-time.After(20 * time.Second):
+				t.Error("app did not shut down in time")
+			}
+		})
+	}
+	t.Cleanup(stop)
+
+	env := &e2eEnv{
+		baseURL: fmt.Sprintf("http://127.0.0.1:%d", port),
+		fake:    fake,
+		stop:    stop,
+	}
+	waitHTTP(t, env.baseURL+"/healthz")
+
+	env.db, err = sql.Open("pgx", suite.PostgresDSN())
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	t.Cleanup(func() { _ = env.db.Close() })
+	env.rdb = redis.NewClient(&redis.Options{Addr: suite.RedisAddr()})
+	t.Cleanup(func() { _ = env.rdb.Close() })
+
+	return env
+}
+
+func TestEndToEnd_QuoteUpdateFlow(t *testing.T) {
+	env := startApp(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	t.Run("success", func(t *testing.T) {
+		env.fake.SetRate("EUR", "USD", "1.0850")
+
+		id := env.requestUpdate(t, "EUR/USD")
+		res := env.waitTerminal(t, id)
+		if res.Status != "SUCCESS" {
+			t.Fatalf("expected SUCCESS, got %s (error: %v)", res.Status, res.Error)
+		}
+		if res.Price == nil || *res.Price != "1.0850" {
+			t.Fatalf("expected price 1.0850, got %v", res.Price)
+		}
+
+		var status, price string
+		err := env.db.QueryRowContext(ctx,
+			"SELECT status::text, price::text FROM quotes WHERE id=$1::uuid", id).Scan(&status, &price)
+		if err != nil {
+			t.Fatalf("query quote row: %v", err)
+		}
+		if status != "SUCCESS" || price != "1.0850" {
+			t.Fatalf("unexpected DB row: status=%s price=%s", status, price)
+		}
+
+		cached, err := env.rdb.HGetAll(ctx, "latest:{EUR:USD}").Result()
+		if err != nil {
+			t.Fatalf("read latest cache: %v", err)
+		}
+		if cached["price"] != "1.0850" || cached["updated_at"] == "" || cached["rate_timestamp"] == "" {
+			t.Fatalf("unexpected latest cache entry: %v", cached)
+		}
+
+		var latest api.LatestResponse
+		env.getJSON(t, "/quotes/latest?base=EUR&quote=USD", http.StatusOK, &latest)
+		if latest.Base != "EUR" || latest.Quote != "USD" || latest.Price != "1.0850" {
+			t.Fatalf("unexpected latest response: %+v", latest)
+		}
+	})
+
+	t.Run("extreme rates", func(t *testing.T) {
+		// BTC/JPY and IDR/BTC magnitudes on supported pairs: every digit the provider
+		// sent must reach the DB row, the latest cache and the API unchanged.
+		rates := []struct {
+			base, quote, rate string
+		}{
+			{"EUR", "JPY", "15234567.123456789012"},
+			{"JPY", "GBP", "0.000000001234567891"},
+		}
+		for _, r := range rates {
+			env.fake.SetRate(r.base, r.quote, r.rate)
+
+			id := env.requestUpdate(t, r.base+"/"+r.quote)
+			res := env.waitTerminal(t, id)
+			if res.Status != "SUCCESS" || res.Price == nil || *res.Price != r.rate {
+				t.Fatalf("expected SUCCESS at %s, got %s %v", r.rate, res.Status, res.Price)
+			}
+
+			var price string
+			if err := env.db.QueryRowContext(ctx, "SELECT price::text FROM quotes WHERE id=$1::uuid", id).Scan(&price); err != nil {
+				t.Fatalf("query quote row: %v", err)
+			}
+			if price != r.rate {
+				t.Fatalf("expected DB price %s, got %s", r.rate, price)
+			}
+			cached, err := env.rdb.HGet(ctx, "latest:{"+r.base+":"+r.quote+"}", "price").Result()
+			if err != nil || cached != r.rate {
+				t.Fatalf("expected cached price %s, got %q (%v)", r.rate, cached, err)
+			}
+
+			var latest api.LatestResponse
+			env.getJSON(t, "/quotes/latest?base="+r.base+"&quote="+r.quote, http.StatusOK, &latest)
+			if latest.Price != r.rate {
+				t.Fatalf("expected latest price %s, got %s", r.rate, latest.Price)
+			}
+		}
+	})
+
+	t.Run("provider failure", func(t *testing.T) {
+		env.fake.SetRate("GBP", "JPY", "182.50")
+		env.fake.SetFaults(fakeprovider.Faults{ErrorRate: 1})
+		defer env.fake.Reset()
+
+		id := env.requestUpdate(t, "GBP/JPY")
+		res := env.waitTerminal(t, id)
+		if res.Status != "FAILED" {
+			t.Fatalf("expected FAILED, got %s", res.Status)
+		}
+		if res.Error == nil || *res.Error == "" {
+			t.Fatal("expected error message on FAILED quote")
+		}
+		if res.Price != nil {
+			t.Fatalf("expected no price on FAILED quote, got %s", *res.Price)
+		}
+	})
+}
+
+func TestEndToEnd_FetchQuote(t *testing.T) {
+	env := startApp(t)
+
+	t.Run("completed in time", func(t *testing.T) {
+		env.fake.SetRate("EUR", "CHF", "0.9412")
+
+		resp := env.fetchQuote(t, "EUR/CHF", 5000)
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected 200, got %d", resp.StatusCode)
+		}
+		var res api.QuoteResponse
+		if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+			t.Fatalf("decode fetch response: %v", err)
+		}
+		if res.Status != "SUCCESS" || res.Price == nil || *res.Price != "0.9412" || res.UpdateID == "" {
+			t.Fatalf("expected SUCCESS at 0.9412 with an update_id, got %+v", res)
+		}
+	})
+
+	t.Run("timed out", func(t *testing.T) {
+		env.fake.SetRate("EUR", "SEK", "11.2380")
+		env.fake.SetFaults(fakeprovider.Faults{LatencyMs: 1500})
+		defer env.fake.Reset()
+
+		start := time.Now()
+		resp := env.fetchQuote(t, "EUR/SEK", 300)
+		defer resp.Body.Close()
+		if elapsed := time.Since(start); elapsed >= 1500*time.Millisecond {
+			t.Errorf("expected the response before the provider answered, took %v", elapsed)
+		}
+		if resp.StatusCode != http.StatusAccepted {
+			t.Fatalf("expected 202, got %d", resp.StatusCode)
+		}
+		var upd api.UpdateResponse
+		if err := json.NewDecoder(resp.Body).Decode(&upd); err != nil {
+			t.Fatalf("decode fetch response: %v", err)
+		}
+		if upd.UpdateID == "" {
+			t.Fatal("expected update_id in response")
+		}
+
+		// The update keeps running and can be polled as usual.
+		res := env.waitTerminal(t, upd.UpdateID)
+		if res.Status != "SUCCESS" || res.Price == nil || *res.Price != "11.2380" {
+			t.Fatalf("expected SUCCESS at 11.2380, got %s %v", res.Status, res.Price)
+		}
+	})
+}
+
+func (e *e2eEnv) fetchQuote(t *testing.T, pair string, timeoutMs int) *http.Response {
+	t.Helper()
+	body, _ := json.Marshal(api.FetchRequest{Pair: pair, TimeoutMs: timeoutMs})
+	resp, err := http.Post(e.baseURL+"/quotes/fetch", "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("POST /quotes/fetch: %v", err)
+	}
+	return resp
+}
+
+func (e *e2eEnv) requestUpdate(t *testing.T, pair string) string {
+	t.Helper()
+	body, _ := json.Marshal(api.UpdateRequest{Pair: pair})
+	resp, err := http.Post(e.baseURL+"/quotes/update", "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("POST /quotes/update: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d", resp.StatusCode)
+	}
+	var upd api.UpdateResponse
+	if err := json.NewDecoder(resp.Body).Decode(&upd); err != nil {
+		t.Fatalf("decode update response: %v", err)
+	}
+	if upd.UpdateID == "" {
+		t.Fatal("expected update_id in response")
+	}
+	return upd.UpdateID
+}
+
+// waitTerminal polls GET /quotes/{id} until the record is SUCCESS or FAILED.
+func (e *e2eEnv) waitTerminal(t *testing.T, id string) api.QuoteResponse {
+	t.Helper()
+	deadline := time.Now().Add(20 * time.Second)
+	for {
+		var res api.QuoteResponse
+		e.getJSON(t, "/quotes/"+id, http.StatusOK, &res)
+		if res.Status == "SUCCESS" || res.Status == "FAILED" {
+			return res
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("update %s did not reach a terminal status, last status %s", id, res.Status)
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+}
+
+func (e *e2eEnv) getJSON(t *testing.T, path string, wantStatus int, out any) {
+	t.Helper()
+	resp, err := http.Get(e.baseURL + path)
+	if err != nil {
+		t.Fatalf("GET %s: %v", path, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != wantStatus {
+		t.Fatalf("GET %s: expected %d, got %d", path, wantStatus, resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		t.Fatalf("GET %s: decode: %v", path, err)
+	}
+}
+
+// TestEndToEnd_LifecycleEvents checks the lifecycle events a real startup and shutdown
+// log. The worker and the HTTP listener start concurrently, so only their position
+// relative to the other events is fixed.
+func TestEndToEnd_LifecycleEvents(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	env := startAppWithLogger(t, zap.New(core).Sugar())
+
+	resp, err := http.Get(env.baseURL + "/healthz/details")
+	if err != nil {
+		t.Fatalf("GET /healthz/details: %v", err)
+	}
+	var details api.HealthDetailsResponse
+	err = json.NewDecoder(resp.Body).Decode(&details)
+	_ = resp.Body.Close()
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if details.Phase != "serving" {
+		t.Errorf("Expected phase serving, got %q", details.Phase)
+	}
+
+	env.stop()
+
+	var events []string
+	for _, e := range logs.FilterMessage("Lifecycle event").All() {
+		fields := e.ContextMap()
+		events = append(events, fields["event"].(string))
+		if fields["event"] == "migrations_applied" {
+			if _, ok := fields["count"]; !ok {
+				t.Errorf("Expected migrations_applied with a count, got %v", fields)
+			}
+		}
+	}
+	if len(events) != 6 {
+		t.Fatalf("Expected 6 lifecycle events, got %v", events)
+	}
+	if !slices.Equal(events[:2], []string{"config_loaded", "migrations_applied"}) ||
+		!slices.Equal(events[4:], []string{"draining", "shutdown_complete"}) {
+		t.Errorf("Unexpected event order %v", events)
+	}
+	started := slices.Clone(events[2:4])
+	slices.Sort(started)
+	if !slices.Equal(started, []string{"http_listening", "worker_started"}) {
+		t.Errorf("Expected http_listening and worker_started between startup and draining, got %v", events)
+	}
+}
+
+func freePort(t *testing.T) int {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("find free port: %v", err)
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+func waitHTTP(t *testing.T, url string) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		resp, err := http.Get(url)
+		if err == nil {
+			_ = resp.Body.Close()
+			if resp.StatusCode == http.StatusOK {
+				return
+			}
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("server at %s not ready: %v", url, err)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
@@ -2,32 +2,58 @@ package main
 
 import (
 	"context"
+	"flag"
+	"fmt"
 	"log"
+	"os"
 	"os/signal"
 	"syscall"
 
-	"go.uber.org/zap"
-
 	_ "quoteservice/internal/api/docs"
 	"quoteservice/internal/config"
+	"quoteservice/internal/logging"
 )
 
 func main() {
+	validateOnly := flag.Bool("validate-config", false, "load and validate the configuration, then exit")
+	check := flag.Bool("check", false,
+		"connect to every dependency without migrating or serving, log the startup report, then exit 0 if all are ok and 1 otherwise")
+	skipChecksumVerify := flag.Bool("skip-checksum-verify", false,
+		"start even if an applied migration no longer matches its recorded checksum (recovery only)")
+	flag.Parse()
+
 	cfg, err := config.LoadConfig()
+	if *validateOnly {
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid configuration:\n%v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("Configuration is valid")
+		return
+	}
 	if err != nil {
 		log.Fatalf("Failed to load config: %v", err)
 	}
 
-	zapLogger, err := zap.NewProduction()
+	zapLogger, err := logging.New(cfg.Logging)
 	if err != nil {
 		log.Fatalf("Failed to init logger: %v", err)
 	}
 	defer func() { _ = zapLogger.Sync() }()
 	sugar := zapLogger.Sugar()
+	opts := Options{SkipChecksumVerify: *skipChecksumVerify}
+
+	if *check {
+		if err := SelfCheck(cfg, sugar, opts); err != nil {
+			_ = zapLogger.Sync()
+			os.Exit(1)
+		}
+		return
+	}
 
 	sugar.Infow("Starting Currency Quotes Service", "port", cfg.Server.Port)
 
-	app, err := NewApp(cfg, sugar)
+	app, err := NewApp(cfg, sugar, opts)
 	if err != nil {
 		sugar.Fatalw("Failed to initialize app", "error", err)
 	}
@@ -1,43 +1,242 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	chimiddleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/redis/go-redis/v9"
 
 	"quoteservice/internal/api"
 	"quoteservice/internal/api/middleware"
+	"quoteservice/internal/config"
+	"quoteservice/internal/metrics"
+	"quoteservice/internal/repository"
 	"quoteservice/internal/service"
+	"quoteservice/internal/worker"
 )
 
+// initHTTP builds the public server and, when server.internal_port is set, the
+// internal one. The public port serves the quote API, probes and Swagger; the
+// operational routes (/metrics, /admin/*, asynqmon) move to the internal port so they
+// can be kept off the public network. Without an internal port everything shares the
+// public server.
 func (app *App) initHTTP(quoteService service.QuoteServiceInterface) {
-	r := chi.NewRouter()
-	r.Use(middleware.RequestIDMiddleware)
-	r.Use(middleware.RequestLoggingMiddleware(app.logger))
-	r.Use(chimiddleware.Recoverer)
+	errs := app.errorConfig()
+	public := app.newRouter()
+	public.Get("/healthz", api.HandleHealthz())
+	public.Get("/healthz/details", api.HandleHealthDetails(app.lifecycle))
+	public.Get("/readyz", api.HandleReadyz(app.readinessChecks()...))
 
-	r.Post("/quotes/update", api.HandleRequestUpdate(quoteService))
-	r.Get("/quotes/{update_id}", api.HandleGetQuoteByID(quoteService))
-	r.Get("/quotes/latest", api.HandleGetLatestQuote(quoteService))
-	r.Get("/healthz", api.HandleHealthz())
-	r.Get("/readyz", api.HandleReadyz(app.db, app.rdbCache, app.rdbAsynq))
+	public.Group(func(r chi.Router) {
+		app.useAuth(r)
+		r.With(app.requireScope(middleware.ScopeWrite)).Post("/quotes/update", api.HandleRequestUpdate(quoteService, app.grantsScope(middleware.ScopeAdmin), errs))
+		r.With(app.requireScope(middleware.ScopeWrite)).Post("/quotes/fetch", api.HandleFetchQuote(quoteService, app.quoteSigner, maxFetchWait, errs))
+		r.With(app.requireScope(middleware.ScopeRead)).Get("/quotes/{update_id}", api.HandleGetQuoteByID(quoteService, app.quoteSigner, errs))
+		r.With(app.requireScope(middleware.ScopeRead)).Get("/quotes/latest", api.HandleGetLatestQuote(quoteService, app.quoteSigner, app.cfg.API.QuoteSourceHeader, errs))
+		r.With(app.requireScope(middleware.ScopeRead)).Head("/quotes/latest", api.HandleGetLatestQuote(quoteService, app.quoteSigner, app.cfg.API.QuoteSourceHeader, errs))
+		r.With(app.requireScope(middleware.ScopeRead)).Get("/quotes/latest/defaults", api.HandleGetDefaultLatestQuotes(app.quoteService, app.defaultPairs, errs))
+		r.With(app.requireScope(middleware.ScopeRead)).Get("/quotes/history/at", api.HandleGetHistoricalQuote(quoteService, errs))
+		r.With(app.requireScope(middleware.ScopeRead)).Get("/quotes/compare", api.HandleCompareQuotes(quoteService, errs))
+		r.With(app.requireScope(middleware.ScopeRead)).Get("/quotes/stream", api.HandleQuoteStream(quoteService, sseHeartbeatInterval, errs))
+		r.With(app.requireScope(middleware.ScopeRead)).Get("/currencies", api.HandleListCurrencies())
+		r.With(app.requireScope(middleware.ScopeRead)).Get("/currencies/{code}", api.HandleGetCurrency())
+	})
 
 	if app.cfg.Server.ServeSwagger {
-		r.Get("/swagger/*", api.SwaggerUIHandler())
-		r.Get("/openapi.json", api.OpenAPISpecHandler())
+		public.Get("/swagger/index.html", api.SwaggerUIHandler())
+		public.Get("/swagger/doc.json", api.SwaggerSpecHandler(app.cfg.Server.SwaggerGzipSpec))
+		public.Get("/swagger/*", api.SwaggerAssetHandler())
+		public.Get("/openapi.json", api.OpenAPISpecHandler())
 	}
+
+	internal := public
+	if app.cfg.Server.InternalPort > 0 {
+		internal = app.newRouter()
+	}
+	app.operationalRoutes(internal, quoteService)
+
+	app.httpServer = newHTTPServer(app.cfg.Server.Port, public)
+	if internal != public {
+		app.internalServer = newHTTPServer(app.cfg.Server.InternalPort, internal)
+	}
+}
+
+// operationalRoutes registers the routes meant for operators rather than API clients.
+func (app *App) operationalRoutes(r chi.Router, quoteService service.QuoteServiceInterface) {
+	r.Handle("/metrics", metrics.Handler())
+	errs := app.errorConfig()
+
+	r.Group(func(r chi.Router) {
+		app.useAuth(r)
+		r.Use(app.requireScope(middleware.ScopeAdmin))
+		r.Get("/admin/queue/tasks", api.HandleListPairTasks(worker.NewPairTaskLister(app.asynqInsp, app.namespace()), quoteService, errs))
+		archived := worker.NewArchivedTasks(app.asynqInsp, app.quoteService, app.namespace(), app.logger)
+		r.Get("/admin/queue/archived", api.HandleListArchivedTasks(archived, quoteService, errs))
+		r.Post("/admin/queue/archived/{task_id}/retry", api.HandleRetryArchivedTask(archived, quoteService, errs))
+		r.Post("/admin/reconcile", api.HandleReconcile(app.reconciler))
+		r.Get("/admin/selfcheck", api.HandleSelfCheck(diagnosticsTimeout, app.diagnosticsSteps()...))
+		r.Get("/admin/reports/reliability", api.HandleReliabilityReport(repository.NewPostgresReliabilityReporter(app.db)))
+		if app.rateProvider != nil {
+			r.Get("/admin/providers", api.HandleProviderScores(app.rateProvider))
+		}
+		r.Post("/admin/worker/drain", api.HandleDrainWorker(app.workerPool))
+		r.Post("/admin/worker/resume", api.HandleResumeWorker(app.workerPool))
+		if app.workerTuner != nil {
+			r.Patch("/admin/worker-config", api.HandlePatchWorkerConfig(app.workerTuner))
+		}
+		if app.quotaTracker != nil {
+			r.Get("/admin/quotas", api.HandleListQuotas(app.quotaTracker))
+		}
+	})
+
 	if app.cfg.Server.ServeAsynqmon && app.asynqMon != nil {
 		r.Mount("/asynq", app.asynqMon)
 	}
+}
+
+// errorConfig returns the Retry-After hints handlers send with 503 responses.
+func (app *App) errorConfig() api.ErrorConfig {
+	return api.ErrorConfig{
+		QueueRetryAfterSec: app.cfg.Server.QueueRetryAfterSec,
+		// An open circuit lets a trial request through after open_sec.
+		ProviderRetryAfterSec: app.cfg.CircuitBreaker.OpenSec,
+	}
+}
+
+// newRouter returns a router with the middleware shared by both servers.
+func (app *App) newRouter() *chi.Mux {
+	r := chi.NewRouter()
+	r.Use(middleware.RequestIDMiddleware)
+	r.Use(middleware.RequestLoggingMiddleware(app.logger))
+	r.Use(chimiddleware.Recoverer)
+	return r
+}
+
+// useAuth adds API key authentication to r when it is enabled, and quota accounting
+// when quotas are.
+func (app *App) useAuth(r chi.Router) {
+	if app.cfg.Auth.Enabled {
+		r.Use(middleware.APIKeyMiddleware(apiKeys(app.cfg.Auth.APIKeys)))
+	}
+	if app.quotaTracker != nil {
+		r.Use(middleware.QuotaMiddleware(app.quotaTracker, app.logger))
+	}
+}
 
-	app.httpServer = &http.Server{
-		Addr:              fmt.Sprintf(":%d", app.cfg.Server.Port),
-		Handler:           r,
+func newHTTPServer(port int, h http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              fmt.Sprintf(":%d", port),
+		Handler:           h,
 		ReadHeaderTimeout: 5 * time.Second,
-		WriteTimeout:      15 * time.Second,
+		WriteTimeout:      httpWriteTimeout,
 		IdleTimeout:       60 * time.Second,
 	}
 }
+
+// Deep readiness checks are throttled so frequent probes do not add load.
+const (
+	deepCheckInterval = 10 * time.Second
+	deepCheckTimeout  = 2 * time.Second
+)
+
+// httpWriteTimeout bounds how long a handler of the HTTP servers may take to respond.
+const httpWriteTimeout = 15 * time.Second
+
+// maxFetchWait caps the wait of POST /quotes/fetch, leaving the rest of
+// httpWriteTimeout to poll the update a last time and write the response.
+const maxFetchWait = httpWriteTimeout - 2*time.Second
+
+// sseHeartbeatInterval keeps idle /quotes/stream connections open through proxies.
+const sseHeartbeatInterval = 15 * time.Second
+
+var (
+	errCacheWarming  = errors.New("latest-price cache warmup in progress")
+	errWorkerDrained = errors.New("worker is drained")
+	errNotListening  = errors.New("quote notification listener is not connected")
+)
+
+// readinessChecks lists the components /readyz verifies. The cache Redis is optional
+// because reads fall back to Postgres when it is unavailable, and so is the quote
+// notification listener, without which only /quotes/stream stops delivering updates.
+func (app *App) readinessChecks() []api.ReadinessCheck {
+	return []api.ReadinessCheck{
+		{Name: "postgres", Checker: api.ReadinessFunc(app.db.PingContext)},
+		{Name: "redis_cache", Checker: redisPing(app.rdbCache), Optional: true},
+		{Name: "redis_asynq", Checker: redisPing(app.rdbAsynq)},
+		{Name: "cache_warmup", Checker: api.ReadinessFunc(func(context.Context) error {
+			if !app.quoteService.IsReady() {
+				return errCacheWarming
+			}
+			return nil
+		})},
+		{Name: "worker", Checker: api.ReadinessFunc(app.checkWorkerActive)},
+		{Name: "quote_notifications", Optional: true, Checker: api.ReadinessFunc(func(context.Context) error {
+			if !app.quoteBroker.Listening() {
+				return errNotListening
+			}
+			return nil
+		})},
+		{Name: "postgres_schema", Deep: true, Checker: api.ThrottledChecker(api.ReadinessFunc(func(ctx context.Context) error {
+			if app.cfg.Database.SkipMigrations {
+				// schema_migrations is not maintained by the external tool.
+				return repository.VerifySchema(ctx, app.db)
+			}
+			return repository.CheckSchema(ctx, app.db)
+		}), deepCheckInterval, deepCheckTimeout)},
+		{Name: "asynq_queues", Deep: true, Checker: api.ThrottledChecker(api.ReadinessFunc(func(context.Context) error {
+			_, err := app.asynqInsp.Queues()
+			return err
+		}), deepCheckInterval, deepCheckTimeout)},
+	}
+}
+
+// checkWorkerActive fails while the worker pool is drained, so the orchestrator stops
+// routing to an instance that is being taken out of rotation.
+func (app *App) checkWorkerActive(context.Context) error {
+	if app.workerPool.Drained() {
+		return errWorkerDrained
+	}
+	return nil
+}
+
+func redisPing(client *redis.Client) api.ReadinessFunc {
+	return func(ctx context.Context) error {
+		return client.Ping(ctx).Err()
+	}
+}
+
+// requireScope enforces the given scope when API key auth is enabled and is a no-op otherwise.
+func (app *App) requireScope(scope string) func(http.Handler) http.Handler {
+	if !app.cfg.Auth.Enabled {
+		return func(next http.Handler) http.Handler { return next }
+	}
+	return middleware.ScopeMiddleware(scope)
+}
+
+// grantsScope returns a check for whether a request's API key has scope, which always
+// passes when API key auth is disabled.
+func (app *App) grantsScope(scope string) func(*http.Request) bool {
+	if !app.cfg.Auth.Enabled {
+		return func(*http.Request) bool { return true }
+	}
+	return func(r *http.Request) bool {
+		return middleware.HasScope(middleware.ScopesFromContext(r.Context()), scope)
+	}
+}
+
+func apiKeys(cfgKeys []config.APIKeyConfig) []middleware.APIKey {
+	keys := make([]middleware.APIKey, 0, len(cfgKeys))
+	for _, k := range cfgKeys {
+		key := middleware.APIKey{Key: k.Key, Name: k.Name, Scopes: k.Scopes}
+		if len(k.Pairs) > 0 || len(k.Bases) > 0 {
+			key.Access = &service.PairAccess{Pairs: k.Pairs, Bases: k.Bases}
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/hibiken/asynq"
+	"github.com/hibiken/asynqmon"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.uber.org/zap"
+
+	"quoteservice/internal/api"
+	"quoteservice/internal/config"
+	"quoteservice/internal/metrics"
+	"quoteservice/internal/worker"
+)
+
+// fakeServer records the calls a worker.Pool makes on its asynq server.
+type fakeServer struct {
+	started, stopped, shutdowns int
+}
+
+func (s *fakeServer) Start(asynq.Handler) error { s.started++; return nil }
+func (s *fakeServer) Stop()                     { s.stopped++ }
+func (s *fakeServer) Shutdown()                 { s.shutdowns++ }
+
+func TestApp_WorkerDrain(t *testing.T) {
+	var servers []*fakeServer
+	pool := worker.NewPool(asynq.RedisClientOpt{}, asynq.Config{},
+		worker.PoolConfig{Concurrency: 1, TaskTimeout: time.Second},
+		asynq.HandlerFunc(nil), nil, zap.NewNop().Sugar(),
+		worker.WithServerFactory(func(asynq.RedisConnOpt, asynq.Config) worker.Server {
+			s := &fakeServer{}
+			servers = append(servers, s)
+			return s
+		}))
+	app := &App{logger: zap.NewNop().Sugar(), workerPool: pool, httpServer: &http.Server{}}
+	if err := pool.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	post := func(h http.HandlerFunc) {
+		t.Helper()
+		w := httptest.NewRecorder()
+		h(w, httptest.NewRequest(http.MethodPost, "/", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", w.Code, w.Body)
+		}
+	}
+	readyz := api.HandleReadyz(api.ReadinessCheck{Name: "worker", Checker: api.ReadinessFunc(app.checkWorkerActive)})
+	expectReady := func(code int, drained float64) {
+		t.Helper()
+		w := httptest.NewRecorder()
+		readyz(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		if w.Code != code {
+			t.Errorf("expected /readyz %d, got %d", code, w.Code)
+		}
+		if got := testutil.ToFloat64(metrics.WorkerDrained); got != drained {
+			t.Errorf("expected drained gauge %v, got %v", drained, got)
+		}
+	}
+	drain, resume := api.HandleDrainWorker(pool), api.HandleResumeWorker(pool)
+
+	expectReady(http.StatusOK, 0)
+
+	post(drain)
+	post(drain)
+	if first := servers[0]; first.stopped != 1 || first.shutdowns != 0 {
+		t.Fatalf("expected the server stopped once and not shut down, got %+v", first)
+	}
+	expectReady(http.StatusServiceUnavailable, 1)
+
+	post(resume)
+	if len(servers) != 2 || servers[0].shutdowns != 1 || servers[1].started != 1 {
+		t.Fatalf("expected the drained server replaced by a started one, got %d servers, first %+v", len(servers), servers[0])
+	}
+	expectReady(http.StatusOK, 0)
+	post(resume)
+	if len(servers) != 2 {
+		t.Fatalf("expected resuming a running worker to keep its server, got %d servers", len(servers))
+	}
+
+	post(drain)
+	expectReady(http.StatusServiceUnavailable, 1)
+	if err := app.shutdown(); err != nil {
+		t.Fatalf("shutdown while drained: %v", err)
+	}
+	if s := servers[1]; s.stopped != 1 || s.shutdowns != 1 {
+		t.Errorf("expected the drained server shut down once, got %+v", s)
+	}
+	if err := pool.Resume(); err == nil {
+		t.Error("expected Resume after shutdown to fail")
+	}
+}
+
+func TestApp_InitHTTP_Listeners(t *testing.T) {
+	public := []string{"/healthz", "/healthz/details", "/readyz", "/quotes/latest", "/quotes/abc", "/currencies", "/swagger/index.html", "/swagger/doc.json", "/swagger/swagger-ui.css", "/openapi.json"}
+	operational := []string{"/metrics", "/admin/queue/tasks", "/admin/reconcile", "/admin/selfcheck", "/admin/worker/drain", "/admin/worker/resume", "/asynq/"}
+	newApp := func(internalPort int) *App {
+		cfg := &config.Config{Server: config.ServerConfig{Port: 8080, InternalPort: internalPort, ServeSwagger: true, ServeAsynqmon: true}}
+		pool := worker.NewPool(asynq.RedisClientOpt{}, asynq.Config{}, worker.PoolConfig{Concurrency: 1, TaskTimeout: time.Second},
+			asynq.HandlerFunc(nil), nil, zap.NewNop().Sugar(),
+			worker.WithServerFactory(func(asynq.RedisConnOpt, asynq.Config) worker.Server { return &fakeServer{} }))
+		app := &App{cfg: cfg, logger: zap.NewNop().Sugar(), workerPool: pool, asynqMon: asynqmon.New(asynqmon.Options{
+			RootPath: "/asynq", RedisConnOpt: asynq.RedisClientOpt{Addr: "localhost:0"},
+		})}
+		app.initHTTP(nil)
+		return app
+	}
+	// serves reports whether srv routes a request for path, whatever the method.
+	serves := func(srv *http.Server, path string) bool {
+		mux := srv.Handler.(chi.Routes)
+		for _, method := range []string{http.MethodGet, http.MethodPost} {
+			if mux.Match(chi.NewRouteContext(), method, path) {
+				return true
+			}
+		}
+		return false
+	}
+
+	t.Run("single listener", func(t *testing.T) {
+		app := newApp(0)
+		if app.internalServer != nil {
+			t.Fatal("expected no internal server without internal_port")
+		}
+		for _, path := range append(public, operational...) {
+			if !serves(app.httpServer, path) {
+				t.Errorf("expected %s on the public port", path)
+			}
+		}
+		if !app.httpServer.Handler.(chi.Routes).Match(chi.NewRouteContext(), http.MethodHead, "/quotes/latest") {
+			t.Error("expected HEAD /quotes/latest to be routed")
+		}
+	})
+
+	t.Run("internal listener", func(t *testing.T) {
+		app := newApp(9090)
+		if app.internalServer == nil || app.internalServer.Addr != ":9090" {
+			t.Fatalf("expected an internal server on :9090, got %+v", app.internalServer)
+		}
+		for _, path := range public {
+			if !serves(app.httpServer, path) {
+				t.Errorf("expected %s on the public port", path)
+			}
+			if serves(app.internalServer, path) {
+				t.Errorf("expected %s off the internal port", path)
+			}
+		}
+		for _, path := range operational {
+			if serves(app.httpServer, path) {
+				t.Errorf("expected %s off the public port", path)
+			}
+			if !serves(app.internalServer, path) {
+				t.Errorf("expected %s on the internal port", path)
+			}
+		}
+
+		if err := app.shutdown(); err != nil {
+			t.Fatalf("shutdown: %v", err)
+		}
+		for name, srv := range map[string]*http.Server{"public": app.httpServer, "internal": app.internalServer} {
+			if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+				t.Errorf("expected the %s server shut down, got %v", name, err)
+			}
+		}
+	})
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	"quoteservice/internal/config"
+	"quoteservice/internal/lifecycle"
+	"quoteservice/internal/lock"
+	"quoteservice/internal/repository"
+)
+
+// selfCheckTimeout bounds each Redis ping of the startup self-check.
+const selfCheckTimeout = 5 * time.Second
+
+// startupCheck initializes or verifies one dependency.
+type startupCheck struct {
+	name string
+	run  func() error
+}
+
+// componentResult is the outcome of one startupCheck.
+type componentResult struct {
+	Name string
+	Err  error
+}
+
+// startupReport lists the outcome of every startup check, in the order they ran.
+type startupReport []componentResult
+
+// runStartupChecks runs every check, including those after a failed one, so a broken
+// environment is reported in full instead of one error at a time.
+func runStartupChecks(checks []startupCheck) startupReport {
+	report := make(startupReport, 0, len(checks))
+	for _, c := range checks {
+		report = append(report, componentResult{Name: c.name, Err: c.run()})
+	}
+	return report
+}
+
+// Err joins the failures of the report, each prefixed with its component, or returns
+// nil if every check passed.
+func (r startupReport) Err() error {
+	var errs []error
+	for _, c := range r {
+		if c.Err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", c.Name, c.Err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// log writes one entry per component and a summary.
+func (r startupReport) log(logger *zap.SugaredLogger) {
+	var failed []string
+	for _, c := range r {
+		if c.Err != nil {
+			failed = append(failed, c.Name)
+			logger.Errorw("Startup check", "component", c.Name, "status", "error", "error", c.Err)
+			continue
+		}
+		logger.Infow("Startup check", "component", c.Name, "status", "ok")
+	}
+	if len(failed) > 0 {
+		logger.Errorw("Startup self-check failed", "failed", strings.Join(failed, ","), "checked", len(r))
+		return
+	}
+	logger.Infow("Startup self-check passed", "checked", len(r))
+}
+
+// errSkipped marks a check that could not run because a dependency it needs failed.
+var errSkipped = errors.New("skipped")
+
+// checkDependencies connects to every dependency and records the connections on app.
+// Migrations are applied when applyMigrations is set and only verified otherwise.
+func (app *App) checkDependencies(applyMigrations bool) startupReport {
+	return runStartupChecks([]startupCheck{
+		{name: "postgres", run: app.connectPostgres},
+		{name: "migrations", run: func() error { return app.checkMigrations(applyMigrations) }},
+		{name: "redis_cache", run: func() error {
+			app.rdbCache = redis.NewClient(&redis.Options{Addr: app.cfg.Redis.CacheAddr})
+			app.locker = lock.NewLocker(app.rdbCache, app.namespace(), lock.DefaultTTL, app.logger)
+			return pingRedis(app.rdbCache, app.cfg.Redis.CacheAddr)
+		}},
+		{name: "redis_asynq", run: func() error {
+			app.rdbAsynq = redis.NewClient(&redis.Options{Addr: app.cfg.Redis.AsynqAddr})
+			return pingRedis(app.rdbAsynq, app.cfg.Redis.AsynqAddr)
+		}},
+		{name: "providers", run: func() (err error) {
+			if app.cfg.HTTPClient.InsecureSkipVerify {
+				app.logger.Warnw("TLS verification of exchange rate providers is disabled; never use http_client.insecure_skip_verify in production")
+			}
+			if app.providerWarmer, err = newProviderWarmer(app.cfg, app.locker, app.logger); err != nil {
+				return err
+			}
+			app.rateProvider, err = newRateProvider(app.cfg, app.rdbCache, app.providerWarmer)
+			return err
+		}},
+	})
+}
+
+func (app *App) connectPostgres() error {
+	db, err := repository.NewPostgresDB(&app.cfg.Database)
+	if err != nil {
+		return err
+	}
+	app.db = db
+	return nil
+}
+
+func (app *App) checkMigrations(apply bool) error {
+	if app.db == nil {
+		return fmt.Errorf("%w: postgres unavailable", errSkipped)
+	}
+	if app.cfg.Database.SkipMigrations {
+		app.logger.Infow("Migrations are skipped, verifying the schema")
+		ctx, cancel := context.WithTimeout(context.Background(), selfCheckTimeout)
+		defer cancel()
+		return repository.VerifySchema(ctx, app.db)
+	}
+	opts := repository.MigrationOptions{SkipChecksumVerify: app.opts.SkipChecksumVerify}
+	if apply {
+		applied, err := repository.RunMigrations(app.db, app.logger, opts)
+		if err != nil {
+			return err
+		}
+		app.lifecycle.Emit(lifecycle.MigrationsApplied, "count", applied)
+		return nil
+	}
+	pending, err := repository.PendingMigrations(context.Background(), app.db, opts)
+	if err != nil {
+		return err
+	}
+	if len(pending) > 0 {
+		app.logger.Infow("Migrations will be applied at startup", "pending", pending)
+	}
+	return nil
+}
+
+func pingRedis(client *redis.Client, addr string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), selfCheckTimeout)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		return fmt.Errorf("ping %s: %w", addr, err)
+	}
+	return nil
+}
+
+// SelfCheck connects to every dependency of cfg without applying migrations or
+// starting any server, logs the startup report and returns its failures.
+func SelfCheck(cfg *config.Config, logger *zap.SugaredLogger, opts Options) error {
+	app := &App{cfg: cfg, opts: opts, logger: logger}
+	report := app.checkDependencies(false)
+	report.log(logger)
+	if err := app.close(); err != nil {
+		logger.Warnw("Self-check cleanup errors", "error", err)
+	}
+	return report.Err()
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRunStartupChecks_CollectsEveryFailure(t *testing.T) {
+	pgErr := errors.New("connection refused")
+	redisErr := errors.New("i/o timeout")
+	var ran []string
+	check := func(name string, err error) startupCheck {
+		return startupCheck{name: name, run: func() error {
+			ran = append(ran, name)
+			return err
+		}}
+	}
+
+	report := runStartupChecks([]startupCheck{
+		check("postgres", pgErr),
+		check("migrations", nil),
+		check("redis_cache", redisErr),
+		check("providers", nil),
+	})
+
+	if strings.Join(ran, ",") != "postgres,migrations,redis_cache,providers" {
+		t.Fatalf("expected every check to run in order, ran %v", ran)
+	}
+	if len(report) != 4 || report[1].Err != nil || report[3].Err != nil {
+		t.Fatalf("unexpected report %+v", report)
+	}
+
+	err := report.Err()
+	if !errors.Is(err, pgErr) || !errors.Is(err, redisErr) {
+		t.Fatalf("expected both failures in %v", err)
+	}
+	for _, want := range []string{"postgres: connection refused", "redis_cache: i/o timeout"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in %q", want, err)
+		}
+	}
+}
+
+func TestStartupReport_AllOK(t *testing.T) {
+	report := runStartupChecks([]startupCheck{
+		{name: "postgres", run: func() error { return nil }},
+		{name: "redis_asynq", run: func() error { return nil }},
+	})
+	if err := report.Err(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func TestCheckMigrations_SkippedWithoutPostgres(t *testing.T) {
+	app := &App{}
+	if err := app.checkMigrations(true); !errors.Is(err, errSkipped) {
+		t.Fatalf("expected errSkipped, got %v", err)
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"quoteservice/internal/api"
+	"quoteservice/internal/worker"
+)
+
+// workerTuner applies PATCH /admin/worker-config: it restarts the worker pool with the
+// new settings and persists them for the next start.
+type workerTuner struct {
+	pool   *worker.Pool
+	store  *worker.RuntimeConfigStore
+	logger *zap.SugaredLogger
+
+	mu sync.Mutex // Keeps the persisted settings in the order they were applied.
+}
+
+var _ api.WorkerTuner = (*workerTuner)(nil)
+
+func (t *workerTuner) WorkerConfig() (int, time.Duration) {
+	cfg := t.pool.Config()
+	return cfg.Concurrency, cfg.TaskTimeout
+}
+
+func (t *workerTuner) TuneWorker(ctx context.Context, concurrency int, taskTimeout time.Duration) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	cfg := worker.PoolConfig{Concurrency: concurrency, TaskTimeout: taskTimeout}
+	if err := t.pool.Reconfigure(cfg); err != nil {
+		t.logger.Errorw("Failed to reconfigure worker pool", "error", err)
+		return fmt.Errorf("reconfigure worker pool: %w", err)
+	}
+	// The request may have timed out while tasks drained; the new settings are live,
+	// so persist them regardless.
+	if err := t.store.Save(context.WithoutCancel(ctx), cfg); err != nil {
+		t.logger.Errorw("Worker config applied but not persisted", "error", err)
+		return err
+	}
+	t.logger.Infow("Worker config changed", "concurrency", concurrency, "task_timeout", taskTimeout)
+	return nil
+}
@@ -0,0 +1,19 @@
+// Package main prints the JSON Schema for the service configuration file.
+package main
+
+import (
+	"log"
+	"os"
+
+	"quoteservice/internal/config"
+)
+
+func main() {
+	schema, err := config.GenerateJSONSchema()
+	if err != nil {
+		log.Fatalf("Failed to generate config schema: %v", err)
+	}
+	if _, err := os.Stdout.Write(append(schema, '\n')); err != nil {
+		log.Fatalf("Failed to write config schema: %v", err)
+	}
+}
@@ -0,0 +1,49 @@
+// Command smoketest verifies a deployed quote service: it checks health and readiness,
+// requests an update for a pair and waits for it to finish, reads the latest quote,
+// and prints a report. It exits 1 if any check failed and 2 on invalid flags.
+//
+//	go run ./cmd/smoketest -url https://quotes.example.com -pair EUR/USD
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+func main() {
+	os.Exit(run())
+}
+
+func run() int {
+	var cfg config
+	flag.StringVar(&cfg.baseURL, "url", "http://localhost:8080", "base URL of the service")
+	flag.StringVar(&cfg.apiKey, "api-key", os.Getenv("SMOKETEST_API_KEY"),
+		"API key sent in X-API-Key, needs the read and write scopes (default $SMOKETEST_API_KEY)")
+	flag.StringVar(&cfg.pair, "pair", "EUR/USD", "pair to request an update for and read the latest quote of")
+	flag.BoolVar(&cfg.skipUpdate, "skip-update", false,
+		"do not request an update, only read: for read-only environments or keys without the write scope")
+	flag.DurationVar(&cfg.budget, "budget", 30*time.Second, "how long to wait for the requested update to finish")
+	flag.DurationVar(&cfg.pollInterval, "poll-interval", 500*time.Millisecond, "interval between polls of the update")
+	flag.DurationVar(&cfg.timeout, "timeout", 10*time.Second, "timeout of each HTTP request")
+	flag.Parse()
+
+	r, err := newRunner(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "smoketest: %v\n", err)
+		return 2
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	report := r.run(ctx)
+	report.write(os.Stdout, cfg.baseURL)
+	if report.failed() {
+		return 1
+	}
+	return 0
+}
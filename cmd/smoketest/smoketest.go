@@ -0,0 +1,353 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"quoteservice/pkg/client"
+)
+
+// config holds the smoke test's flags.
+type config struct {
+	baseURL      string
+	apiKey       string
+	pair         string
+	skipUpdate   bool
+	budget       time.Duration // Bounds polling the requested update.
+	pollInterval time.Duration
+	timeout      time.Duration // Bounds each HTTP request.
+}
+
+// runner runs the smoke test's checks against one service.
+type runner struct {
+	cfg         config
+	baseURL     *url.URL
+	base, quote string
+	httpClient  *http.Client
+}
+
+func newRunner(cfg config) (*runner, error) {
+	u, err := url.Parse(strings.TrimRight(cfg.baseURL, "/"))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid -url %q: want an http or https URL", cfg.baseURL)
+	}
+	base, quote, ok := strings.Cut(strings.ToUpper(strings.TrimSpace(cfg.pair)), "/")
+	if !ok || base == "" || quote == "" {
+		return nil, fmt.Errorf("invalid -pair %q: want BASE/QUOTE", cfg.pair)
+	}
+	if cfg.budget <= 0 || cfg.pollInterval <= 0 || cfg.timeout <= 0 {
+		return nil, errors.New("-budget, -poll-interval and -timeout must be positive")
+	}
+	return &runner{
+		cfg:        cfg,
+		baseURL:    u,
+		base:       base,
+		quote:      quote,
+		httpClient: &http.Client{Timeout: cfg.timeout},
+	}, nil
+}
+
+// errSkipped marks a check that did not run, because of -skip-update or because a
+// check it depends on failed. A skipped check does not fail the smoke test.
+var errSkipped = errors.New("skipped")
+
+// check is one step of the smoke test. run returns what it saw worth reporting.
+type check struct {
+	name string
+	run  func(ctx context.Context) (string, error)
+}
+
+// result is the outcome of one check.
+type result struct {
+	Name    string
+	Detail  string
+	Err     error
+	Skipped bool
+	Elapsed time.Duration
+}
+
+// report lists the outcome of every check, in the order they ran.
+type report []result
+
+// run runs every check, including those after a failed one, so a broken deploy is
+// reported in full. Only polling the update depends on an earlier check.
+func (r *runner) run(ctx context.Context) report {
+	var updateID string
+	checks := []check{
+		{"healthz", r.checkHealthz},
+		{"readyz", r.checkReadyz},
+		{"request_update", func(ctx context.Context) (string, error) {
+			if r.cfg.skipUpdate {
+				return "", fmt.Errorf("%w: -skip-update", errSkipped)
+			}
+			id, detail, err := r.requestUpdate(ctx)
+			updateID = id
+			return detail, err
+		}},
+		{"poll_update", func(ctx context.Context) (string, error) {
+			switch {
+			case r.cfg.skipUpdate:
+				return "", fmt.Errorf("%w: -skip-update", errSkipped)
+			case updateID == "":
+				return "", fmt.Errorf("%w: no update requested", errSkipped)
+			}
+			return r.pollUpdate(ctx, updateID)
+		}},
+		{"latest", r.checkLatest},
+	}
+
+	rep := make(report, 0, len(checks))
+	for _, c := range checks {
+		start := time.Now()
+		detail, err := c.run(ctx)
+		res := result{Name: c.name, Detail: detail, Err: err, Elapsed: time.Since(start)}
+		if errors.Is(err, errSkipped) {
+			res.Detail, res.Err, res.Skipped = err.Error(), nil, true
+		}
+		rep = append(rep, res)
+	}
+	return rep
+}
+
+// failed reports whether any check failed.
+func (r report) failed() bool {
+	return slices.ContainsFunc(r, func(res result) bool { return res.Err != nil })
+}
+
+// write prints one line per check and a summary.
+func (r report) write(w io.Writer, target string) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	failed := 0
+	for _, res := range r {
+		status, detail := "PASS", res.Detail
+		switch {
+		case res.Err != nil:
+			status, detail = "FAIL", res.Err.Error()
+			failed++
+		case res.Skipped:
+			status = "SKIP"
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", status, res.Name, res.Elapsed.Round(time.Millisecond), detail)
+	}
+	_ = tw.Flush()
+	if failed > 0 {
+		_, _ = fmt.Fprintf(w, "Smoke test FAILED against %s: %d of %d checks failed\n", target, failed, len(r))
+		return
+	}
+	_, _ = fmt.Fprintf(w, "Smoke test passed against %s\n", target)
+}
+
+// readyResponse is the body of GET /readyz.
+type readyResponse struct {
+	Status     string `json:"status"`
+	Components map[string]struct {
+		Status string `json:"status"`
+		Error  string `json:"error"`
+	} `json:"components"`
+}
+
+func (r *runner) checkHealthz(ctx context.Context) (string, error) {
+	return "", r.do(ctx, http.MethodGet, "/healthz", nil, nil, http.StatusOK, nil)
+}
+
+// checkReadyz passes for a ready or a degraded service; the latter only lacks optional
+// components and still serves traffic.
+func (r *runner) checkReadyz(ctx context.Context) (string, error) {
+	var resp readyResponse
+	err := r.do(ctx, http.MethodGet, "/readyz", nil, nil, http.StatusOK, &resp)
+	var failing []string
+	for name, c := range resp.Components {
+		if c.Status != "ok" {
+			failing = append(failing, name+": "+c.Error)
+		}
+	}
+	slices.Sort(failing)
+	if err != nil {
+		if len(failing) > 0 {
+			err = fmt.Errorf("%w (%s)", err, strings.Join(failing, "; "))
+		}
+		return "", err
+	}
+	switch resp.Status {
+	case "ready":
+		return "ready", nil
+	case "degraded":
+		return "degraded (" + strings.Join(failing, "; ") + ")", nil
+	default:
+		return "", fmt.Errorf("unexpected status %q", resp.Status)
+	}
+}
+
+// requestUpdate requests an update of the pair and returns its ID.
+func (r *runner) requestUpdate(ctx context.Context) (id, detail string, err error) {
+	var resp client.UpdateResponse
+	body := client.UpdateRequest{Pair: r.base + "/" + r.quote}
+	if err := r.do(ctx, http.MethodPost, "/quotes/update", nil, body, http.StatusAccepted, &resp); err != nil {
+		return "", "", err
+	}
+	if resp.UpdateID == "" {
+		return "", "", errors.New("response has no update_id")
+	}
+	detail = "update_id " + resp.UpdateID
+	if resp.Reason != "" {
+		detail += ", reused: " + resp.Reason
+	}
+	return resp.UpdateID, detail, nil
+}
+
+// pollUpdate polls the update until it finishes or the budget runs out. It passes
+// only if the update succeeded with a valid quote.
+func (r *runner) pollUpdate(ctx context.Context, id string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.cfg.budget)
+	defer cancel()
+	ticker := time.NewTicker(r.cfg.pollInterval)
+	defer ticker.Stop()
+
+	status := "unknown"
+	for {
+		var resp client.QuoteResponse
+		err := r.do(ctx, http.MethodGet, "/quotes/"+url.PathEscape(id), nil, nil, http.StatusOK, &resp)
+		switch {
+		case ctx.Err() != nil:
+			return "", fmt.Errorf("update %s still %s after %v", id, status, r.cfg.budget)
+		case err != nil:
+			return "", err
+		}
+		status = resp.Status
+		switch resp.Status {
+		case client.StatusPending, client.StatusRunning:
+		case client.StatusSuccess:
+			if err := r.checkQuote(resp.Base, resp.Quote, deref(resp.Price), deref(resp.UpdatedAt)); err != nil {
+				return "", err
+			}
+			return "SUCCESS at " + *resp.Price, nil
+		case client.StatusFailed:
+			return "", fmt.Errorf("update %s FAILED: %s", id, deref(resp.Error))
+		default:
+			return "", fmt.Errorf("update %s has unexpected status %q", id, resp.Status)
+		}
+
+		select {
+		case <-ctx.Done():
+			return "", fmt.Errorf("update %s still %s after %v", id, status, r.cfg.budget)
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *runner) checkLatest(ctx context.Context) (string, error) {
+	var resp client.LatestResponse
+	query := url.Values{"base": {r.base}, "quote": {r.quote}}
+	if err := r.do(ctx, http.MethodGet, "/quotes/latest", query, nil, http.StatusOK, &resp); err != nil {
+		return "", err
+	}
+	if err := r.checkQuote(resp.Base, resp.Quote, resp.Price, resp.UpdatedAt); err != nil {
+		return "", err
+	}
+	return resp.Price + " updated at " + resp.UpdatedAt, nil
+}
+
+// checkQuote verifies the invariants of a quote returned for the pair.
+func (r *runner) checkQuote(base, quote, price, updatedAt string) error {
+	if base != r.base || quote != r.quote {
+		return fmt.Errorf("quote is for %s/%s, want %s/%s", base, quote, r.base, r.quote)
+	}
+	if err := positiveDecimal(price); err != nil {
+		return err
+	}
+	if _, err := time.Parse(time.RFC3339, updatedAt); err != nil {
+		return fmt.Errorf("updated_at %q is not an RFC3339 time", updatedAt)
+	}
+	return nil
+}
+
+// plainDecimal matches prices as the service formats them: digits with an optional
+// fraction, no sign or exponent.
+var plainDecimal = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?$`)
+
+// positiveDecimal returns an error unless price is a plain decimal above zero.
+func positiveDecimal(price string) error {
+	if price == "" {
+		return errors.New("price is missing")
+	}
+	if !plainDecimal.MatchString(price) {
+		return fmt.Errorf("price %q is not a decimal", price)
+	}
+	if r, _ := new(big.Rat).SetString(price); r.Sign() <= 0 {
+		return fmt.Errorf("price %q is not positive", price)
+	}
+	return nil
+}
+
+// do sends a request with the API key and decodes the JSON response into out, if not
+// nil. A status other than want is an error carrying the response's error message.
+func (r *runner) do(ctx context.Context, method, path string, query url.Values, body any, want int, out any) error {
+	u := r.baseURL.JoinPath(path)
+	u.RawQuery = query.Encode()
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("encode request: %w", err)
+		}
+		reqBody = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), reqBody)
+	if err != nil {
+		return fmt.Errorf("build request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if r.cfg.apiKey != "" {
+		req.Header.Set("X-API-Key", r.cfg.apiKey)
+	}
+
+	resp, err := r.httpClient.Do(req) // A *url.Error, naming the method and URL.
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("%s %s: read response: %w", method, path, err)
+	}
+
+	if resp.StatusCode != want {
+		err := fmt.Errorf("%s %s: HTTP %d, want %d", method, path, resp.StatusCode, want)
+		var apiErr client.ErrorResponse
+		if json.Unmarshal(data, &apiErr) == nil && apiErr.Error != "" {
+			err = fmt.Errorf("%w: %s (code %d)", err, apiErr.Error, apiErr.Code)
+		}
+		// Decode anyway: /readyz explains a 503 in its body.
+		if out != nil {
+			_ = json.Unmarshal(data, out)
+		}
+		return err
+	}
+	if out != nil {
+		if err := json.Unmarshal(data, out); err != nil {
+			return fmt.Errorf("%s %s: decode response: %w", method, path, err)
+		}
+	}
+	return nil
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
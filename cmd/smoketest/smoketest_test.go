@@ -0,0 +1,308 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeService simulates the quote service's endpoints the smoke test calls. Its zero
+// value, with a price set, is a healthy service whose updates finish on the second poll.
+type fakeService struct {
+	apiKey      string // Required in X-API-Key by /quotes endpoints when set.
+	readyCode   int    // 0 means 200.
+	readyBody   string
+	updateCode  int      // 0 means 202.
+	statuses    []string // Statuses of successive polls; the last one repeats.
+	updateError string
+	price       string
+	latestCode  int // 0 means 200.
+	latestBase  string
+
+	updates atomic.Int32
+	polls   atomic.Int32
+}
+
+func (f *fakeService) start(t *testing.T) *httptest.Server {
+	t.Helper()
+	if f.statuses == nil {
+		f.statuses = []string{"PENDING", "SUCCESS"}
+	}
+	writeJSON := func(w http.ResponseWriter, code int, v any) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		_ = json.NewEncoder(w).Encode(v)
+	}
+	orDefault := func(code, def int) int {
+		if code == 0 {
+			return def
+		}
+		return code
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("OK"))
+	})
+	mux.HandleFunc("GET /readyz", func(w http.ResponseWriter, _ *http.Request) {
+		body := f.readyBody
+		if body == "" {
+			body = `{"status":"ready","components":{"postgres":{"status":"ok"}}}`
+		}
+		w.WriteHeader(orDefault(f.readyCode, http.StatusOK))
+		_, _ = w.Write([]byte(body))
+	})
+	mux.HandleFunc("POST /quotes/update", func(w http.ResponseWriter, r *http.Request) {
+		f.updates.Add(1)
+		var req struct{ Pair string }
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Pair != "EUR/USD" {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": "bad pair", "code": 4001})
+			return
+		}
+		code := orDefault(f.updateCode, http.StatusAccepted)
+		if code != http.StatusAccepted {
+			writeJSON(w, code, map[string]any{"error": "Queue unavailable", "code": 5031})
+			return
+		}
+		writeJSON(w, code, map[string]any{"update_id": "upd-1"})
+	})
+	mux.HandleFunc("GET /quotes/{id}", func(w http.ResponseWriter, r *http.Request) {
+		n := int(f.polls.Add(1))
+		status := f.statuses[min(n, len(f.statuses))-1]
+		resp := map[string]any{"update_id": r.PathValue("id"), "base": "EUR", "quote": "USD", "status": status}
+		switch status {
+		case "SUCCESS":
+			resp["price"], resp["updated_at"] = f.price, "2025-12-01T10:15:30Z"
+		case "FAILED":
+			resp["error"] = f.updateError
+		}
+		writeJSON(w, http.StatusOK, resp)
+	})
+	mux.HandleFunc("GET /quotes/latest", func(w http.ResponseWriter, r *http.Request) {
+		if code := orDefault(f.latestCode, http.StatusOK); code != http.StatusOK {
+			writeJSON(w, code, map[string]any{"error": "No quote available for EUR/USD", "code": 4041})
+			return
+		}
+		base := f.latestBase
+		if base == "" {
+			base = r.URL.Query().Get("base")
+		}
+		writeJSON(w, http.StatusOK, map[string]any{
+			"base": base, "quote": r.URL.Query().Get("quote"), "price": f.price, "updated_at": "2025-12-01T10:15:30Z",
+		})
+	})
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if f.apiKey != "" && strings.HasPrefix(r.URL.Path, "/quotes") && r.Header.Get("X-API-Key") != f.apiKey {
+			writeJSON(w, http.StatusUnauthorized, map[string]any{"error": "Missing or invalid API key", "code": 4011})
+			return
+		}
+		mux.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func testConfig(url string) config {
+	return config{
+		baseURL:      url,
+		pair:         "eur/usd",
+		budget:       time.Second,
+		pollInterval: 5 * time.Millisecond,
+		timeout:      time.Second,
+	}
+}
+
+// runSmoke runs the smoke test and returns the report by check name.
+func runSmoke(t *testing.T, cfg config) (report, map[string]result) {
+	t.Helper()
+	r, err := newRunner(cfg)
+	if err != nil {
+		t.Fatalf("newRunner: %v", err)
+	}
+	rep := r.run(context.Background())
+	byName := make(map[string]result, len(rep))
+	for _, res := range rep {
+		byName[res.Name] = res
+	}
+	return rep, byName
+}
+
+func TestRun_Pass(t *testing.T) {
+	f := &fakeService{price: "1.0850", apiKey: "smoke-key"}
+	cfg := testConfig(f.start(t).URL)
+	cfg.apiKey = "smoke-key"
+
+	rep, results := runSmoke(t, cfg)
+	if rep.failed() {
+		var buf bytes.Buffer
+		rep.write(&buf, cfg.baseURL)
+		t.Fatalf("Expected every check to pass, got:\n%s", buf.String())
+	}
+	if len(rep) != 5 {
+		t.Errorf("Expected 5 checks, got %d", len(rep))
+	}
+	if got := results["poll_update"].Detail; got != "SUCCESS at 1.0850" {
+		t.Errorf("Expected the update's price in the report, got %q", got)
+	}
+	if got := f.polls.Load(); got != 2 {
+		t.Errorf("Expected 2 polls, got %d", got)
+	}
+}
+
+func TestRun_SkipUpdate(t *testing.T) {
+	f := &fakeService{price: "1.0850"}
+	cfg := testConfig(f.start(t).URL)
+	cfg.skipUpdate = true
+
+	rep, results := runSmoke(t, cfg)
+	if rep.failed() {
+		t.Errorf("Expected the read-only run to pass, got %+v", rep)
+	}
+	for _, name := range []string{"request_update", "poll_update"} {
+		if !results[name].Skipped {
+			t.Errorf("Expected %s to be skipped, got %+v", name, results[name])
+		}
+	}
+	if got := f.updates.Load(); got != 0 {
+		t.Errorf("Expected no update requested, got %d", got)
+	}
+}
+
+func TestRun_Fail(t *testing.T) {
+	tests := []struct {
+		name      string
+		svc       *fakeService
+		budget    time.Duration
+		wantFail  map[string]string // Failed check and a substring of its error.
+		wantSkips []string
+	}{
+		{
+			name:      "missing API key",
+			svc:       &fakeService{price: "1.0850", apiKey: "smoke-key"},
+			wantFail:  map[string]string{"request_update": "HTTP 401", "latest": "Missing or invalid API key (code 4011)"},
+			wantSkips: []string{"poll_update"},
+		},
+		{
+			name: "not ready",
+			svc: &fakeService{price: "1.0850", readyCode: http.StatusServiceUnavailable,
+				readyBody: `{"status":"degraded","components":{"postgres":{"status":"error","error":"connection refused"}}}`},
+			wantFail: map[string]string{"readyz": "postgres: connection refused"},
+		},
+		{
+			name:      "update rejected",
+			svc:       &fakeService{price: "1.0850", updateCode: http.StatusServiceUnavailable},
+			wantFail:  map[string]string{"request_update": "HTTP 503, want 202: Queue unavailable"},
+			wantSkips: []string{"poll_update"},
+		},
+		{
+			name: "update failed",
+			svc: &fakeService{price: "1.0850", statuses: []string{"RUNNING", "FAILED"},
+				updateError: "all providers unavailable"},
+			wantFail: map[string]string{"poll_update": "FAILED: all providers unavailable"},
+		},
+		{
+			name:     "update not finished within the budget",
+			svc:      &fakeService{price: "1.0850", statuses: []string{"RUNNING"}},
+			budget:   50 * time.Millisecond,
+			wantFail: map[string]string{"poll_update": "still RUNNING after 50ms"},
+		},
+		{
+			name:     "no latest quote",
+			svc:      &fakeService{price: "1.0850", latestCode: http.StatusNotFound},
+			wantFail: map[string]string{"latest": "HTTP 404"},
+		},
+		{
+			name:     "latest quote for another pair",
+			svc:      &fakeService{price: "1.0850", latestBase: "GBP"},
+			wantFail: map[string]string{"latest": "GBP/USD, want EUR/USD"},
+		},
+		{
+			name:     "price missing",
+			svc:      &fakeService{},
+			wantFail: map[string]string{"poll_update": "price is missing", "latest": "price is missing"},
+		},
+		{
+			name:     "price not positive",
+			svc:      &fakeService{price: "0.000"},
+			wantFail: map[string]string{"poll_update": "not positive", "latest": "not positive"},
+		},
+		{
+			name:     "price not a decimal",
+			svc:      &fakeService{price: "1.08e2"},
+			wantFail: map[string]string{"poll_update": "not a decimal", "latest": "not a decimal"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig(tt.svc.start(t).URL)
+			if tt.budget > 0 {
+				cfg.budget = tt.budget
+			}
+
+			rep, results := runSmoke(t, cfg)
+			if !rep.failed() {
+				t.Fatal("Expected the smoke test to fail")
+			}
+			for name, res := range results {
+				want, shouldFail := tt.wantFail[name]
+				switch {
+				case shouldFail && (res.Err == nil || !strings.Contains(res.Err.Error(), want)):
+					t.Errorf("Expected %s to fail with %q, got %v", name, want, res.Err)
+				case !shouldFail && res.Err != nil:
+					t.Errorf("Expected %s to pass, got %v", name, res.Err)
+				}
+			}
+			for _, name := range tt.wantSkips {
+				if !results[name].Skipped {
+					t.Errorf("Expected %s to be skipped, got %+v", name, results[name])
+				}
+			}
+
+			var buf bytes.Buffer
+			rep.write(&buf, cfg.baseURL)
+			if !strings.Contains(buf.String(), "Smoke test FAILED against "+cfg.baseURL) {
+				t.Errorf("Expected a failure summary, got:\n%s", buf.String())
+			}
+		})
+	}
+}
+
+func TestNewRunner_InvalidConfig(t *testing.T) {
+	valid := testConfig("http://localhost:8080")
+	tests := map[string]func(*config){
+		"relative URL":      func(c *config) { c.baseURL = "localhost:8080" },
+		"pair without base": func(c *config) { c.pair = "/USD" },
+		"pair without /":    func(c *config) { c.pair = "EURUSD" },
+		"zero budget":       func(c *config) { c.budget = 0 },
+	}
+	for name, mutate := range tests {
+		cfg := valid
+		mutate(&cfg)
+		if _, err := newRunner(cfg); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+	if _, err := newRunner(valid); err != nil {
+		t.Errorf("Expected the valid config to be accepted, got %v", err)
+	}
+}
+
+func TestPositiveDecimal(t *testing.T) {
+	for _, price := range []string{"1.0850", "18", "0.000000012"} {
+		if err := positiveDecimal(price); err != nil {
+			t.Errorf("positiveDecimal(%q) = %v, want nil", price, err)
+		}
+	}
+	for _, price := range []string{"", "0", "-1.5", "+1", "1e5", "1/2", "1.", "abc"} {
+		if err := positiveDecimal(price); err == nil {
+			t.Errorf("positiveDecimal(%q) = nil, want an error", price)
+		}
+	}
+}
@@ -0,0 +1,229 @@
+// Package alert delivers operational alerts to external channels (Slack, PagerDuty).
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"quoteservice/internal/config"
+)
+
+// Alert names accepted in config.AlertConfig.EnabledAlerts.
+const (
+	ProviderFailure = "provider_failure"
+	DLQOverflow     = "dlq_overflow"
+	QueueDepth      = "queue_depth"
+	StuckTasks      = "stuck_tasks"
+)
+
+// Severity values understood by all alerters.
+const (
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+// Alert is a single operational alert.
+type Alert struct {
+	Name     string
+	Severity string
+	Summary  string
+	Details  map[string]string
+}
+
+// Alerter delivers alerts to a channel.
+type Alerter interface {
+	Send(ctx context.Context, a Alert) error
+}
+
+// NoopAlerter drops every alert. Useful in tests and when no channel is configured.
+type NoopAlerter struct{}
+
+// Send implements Alerter.
+func (NoopAlerter) Send(context.Context, Alert) error { return nil }
+
+const defaultTimeout = 5 * time.Second
+
+// SlackAlerter posts alerts to a Slack incoming webhook.
+type SlackAlerter struct {
+	webhookURL string
+	client     *http.Client
+}
+
+// NewSlackAlerter creates a SlackAlerter. A nil client uses a default client with a 5s timeout.
+func NewSlackAlerter(webhookURL string, client *http.Client) *SlackAlerter {
+	if client == nil {
+		client = &http.Client{Timeout: defaultTimeout}
+	}
+	return &SlackAlerter{webhookURL: webhookURL, client: client}
+}
+
+// Send implements Alerter.
+func (s *SlackAlerter) Send(ctx context.Context, a Alert) error {
+	text := fmt.Sprintf("[%s] %s: %s", a.Severity, a.Name, a.Summary)
+	for k, v := range a.Details {
+		text += fmt.Sprintf("\n• %s: %s", k, v)
+	}
+	return postJSON(ctx, s.client, s.webhookURL, map[string]string{"text": text}, http.StatusOK)
+}
+
+// PagerDutyEventsURL is the PagerDuty Events API v2 endpoint.
+const PagerDutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+
+// PagerDutyAlerter triggers PagerDuty incidents via the Events API v2.
+type PagerDutyAlerter struct {
+	routingKey string
+	eventsURL  string
+	client     *http.Client
+}
+
+// NewPagerDutyAlerter creates a PagerDutyAlerter. An empty eventsURL uses PagerDutyEventsURL;
+// a nil client uses a default client with a 5s timeout.
+func NewPagerDutyAlerter(routingKey, eventsURL string, client *http.Client) *PagerDutyAlerter {
+	if eventsURL == "" {
+		eventsURL = PagerDutyEventsURL
+	}
+	if client == nil {
+		client = &http.Client{Timeout: defaultTimeout}
+	}
+	return &PagerDutyAlerter{routingKey: routingKey, eventsURL: eventsURL, client: client}
+}
+
+type pagerDutyEvent struct {
+	RoutingKey  string           `json:"routing_key"`
+	EventAction string           `json:"event_action"`
+	DedupKey    string           `json:"dedup_key"`
+	Payload     pagerDutyPayload `json:"payload"`
+}
+
+type pagerDutyPayload struct {
+	Summary       string            `json:"summary"`
+	Source        string            `json:"source"`
+	Severity      string            `json:"severity"`
+	CustomDetails map[string]string `json:"custom_details,omitempty"`
+}
+
+// Send implements Alerter.
+func (p *PagerDutyAlerter) Send(ctx context.Context, a Alert) error {
+	ev := pagerDutyEvent{
+		RoutingKey:  p.routingKey,
+		EventAction: "trigger",
+		DedupKey:    "quoteservice:" + a.Name,
+		Payload: pagerDutyPayload{
+			Summary:       a.Summary,
+			Source:        "quoteservice",
+			Severity:      a.Severity,
+			CustomDetails: a.Details,
+		},
+	}
+	return postJSON(ctx, p.client, p.eventsURL, ev, http.StatusAccepted)
+}
+
+// WebhookAlerter posts alerts as JSON to a generic HTTP endpoint.
+type WebhookAlerter struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookAlerter creates a WebhookAlerter. A nil client uses a default client with a 5s timeout.
+func NewWebhookAlerter(url string, client *http.Client) *WebhookAlerter {
+	if client == nil {
+		client = &http.Client{Timeout: defaultTimeout}
+	}
+	return &WebhookAlerter{url: url, client: client}
+}
+
+type webhookPayload struct {
+	Name     string            `json:"name"`
+	Severity string            `json:"severity"`
+	Summary  string            `json:"summary"`
+	Details  map[string]string `json:"details,omitempty"`
+}
+
+// Send implements Alerter.
+func (w *WebhookAlerter) Send(ctx context.Context, a Alert) error {
+	payload := webhookPayload{Name: a.Name, Severity: a.Severity, Summary: a.Summary, Details: a.Details}
+	return postJSON(ctx, w.client, w.url, payload, 0)
+}
+
+// CompositeAlerter delivers enabled alerts to every configured channel.
+type CompositeAlerter struct {
+	alerters []Alerter
+	enabled  map[string]struct{}
+}
+
+// NewCompositeAlerter creates a CompositeAlerter that only forwards alerts whose
+// name is in enabledAlerts.
+func NewCompositeAlerter(enabledAlerts []string, alerters ...Alerter) *CompositeAlerter {
+	enabled := make(map[string]struct{}, len(enabledAlerts))
+	for _, name := range enabledAlerts {
+		enabled[name] = struct{}{}
+	}
+	return &CompositeAlerter{alerters: alerters, enabled: enabled}
+}
+
+// Send implements Alerter. Delivery is attempted on every channel; errors are joined.
+func (c *CompositeAlerter) Send(ctx context.Context, a Alert) error {
+	if _, ok := c.enabled[a.Name]; !ok {
+		return nil
+	}
+	var errs []error
+	for _, al := range c.alerters {
+		if err := al.Send(ctx, a); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// NewFromConfig builds a CompositeAlerter over the channels configured in cfg.
+func NewFromConfig(cfg config.AlertConfig) *CompositeAlerter {
+	var alerters []Alerter
+	if cfg.SlackWebhookURL != "" {
+		alerters = append(alerters, NewSlackAlerter(cfg.SlackWebhookURL, nil))
+	}
+	if cfg.PagerDutyRoutingKey != "" {
+		alerters = append(alerters, NewPagerDutyAlerter(cfg.PagerDutyRoutingKey, "", nil))
+	}
+	return NewCompositeAlerter(cfg.EnabledAlerts, alerters...)
+}
+
+var (
+	_ Alerter = NoopAlerter{}
+	_ Alerter = (*SlackAlerter)(nil)
+	_ Alerter = (*PagerDutyAlerter)(nil)
+	_ Alerter = (*WebhookAlerter)(nil)
+	_ Alerter = (*CompositeAlerter)(nil)
+)
+
+// postJSON posts body as JSON and expects wantStatus in the response; 0 accepts any 2xx.
+func postJSON(ctx context.Context, client *http.Client, url string, body any, wantStatus int) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("encode alert: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("alert request creation failed: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("alert request failed: %w", err)
+	}
+	defer resp.Body.Close() //nolint:errcheck // best-effort close
+
+	if (wantStatus == 0 && (resp.StatusCode < 200 || resp.StatusCode > 299)) ||
+		(wantStatus != 0 && resp.StatusCode != wantStatus) {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("alert endpoint returned status %d: %s", resp.StatusCode, string(respBody))
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"quoteservice/internal/config"
+)
+
+var testAlert = Alert{
+	Name:     ProviderFailure,
+	Severity: SeverityCritical,
+	Summary:  "all providers failed for EUR/USD",
+	Details:  map[string]string{"pair": "EUR/USD"},
+}
+
+func TestSlackAlerter_Send(t *testing.T) {
+	var got map[string]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	err := NewSlackAlerter(srv.URL, srv.Client()).Send(context.Background(), testAlert)
+	require.NoError(t, err)
+	assert.Contains(t, got["text"], "[critical] provider_failure: all providers failed for EUR/USD")
+	assert.Contains(t, got["text"], "pair: EUR/USD")
+}
+
+func TestSlackAlerter_ErrorStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid_token", http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	err := NewSlackAlerter(srv.URL, srv.Client()).Send(context.Background(), testAlert)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "status 403")
+	assert.Contains(t, err.Error(), "invalid_token")
+}
+
+func TestPagerDutyAlerter_Send(t *testing.T) {
+	var got pagerDutyEvent
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	err := NewPagerDutyAlerter("routing-key", srv.URL, srv.Client()).Send(context.Background(), testAlert)
+	require.NoError(t, err)
+	assert.Equal(t, "routing-key", got.RoutingKey)
+	assert.Equal(t, "trigger", got.EventAction)
+	assert.Equal(t, "quoteservice:provider_failure", got.DedupKey)
+	assert.Equal(t, testAlert.Summary, got.Payload.Summary)
+	assert.Equal(t, SeverityCritical, got.Payload.Severity)
+	assert.Equal(t, "EUR/USD", got.Payload.CustomDetails["pair"])
+}
+
+func TestPagerDutyAlerter_ErrorStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"status":"invalid event"}`, http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	err := NewPagerDutyAlerter("routing-key", srv.URL, srv.Client()).Send(context.Background(), testAlert)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "status 400")
+}
+
+type recordingAlerter struct {
+	calls atomic.Int32
+	err   error
+}
+
+func (r *recordingAlerter) Send(context.Context, Alert) error {
+	r.calls.Add(1)
+	return r.err
+}
+
+func TestCompositeAlerter(t *testing.T) {
+	t.Run("delivers enabled alerts to all channels", func(t *testing.T) {
+		a1, a2 := &recordingAlerter{}, &recordingAlerter{}
+		c := NewCompositeAlerter([]string{ProviderFailure}, a1, a2)
+
+		require.NoError(t, c.Send(context.Background(), testAlert))
+		assert.EqualValues(t, 1, a1.calls.Load())
+		assert.EqualValues(t, 1, a2.calls.Load())
+	})
+
+	t.Run("drops alerts not in the whitelist", func(t *testing.T) {
+		a1 := &recordingAlerter{}
+		c := NewCompositeAlerter([]string{StuckTasks}, a1)
+
+		require.NoError(t, c.Send(context.Background(), testAlert))
+		assert.EqualValues(t, 0, a1.calls.Load())
+	})
+
+	t.Run("one failing channel does not block the others", func(t *testing.T) {
+		failing := &recordingAlerter{err: errors.New("slack down")}
+		ok := &recordingAlerter{}
+		c := NewCompositeAlerter([]string{ProviderFailure}, failing, ok)
+
+		err := c.Send(context.Background(), testAlert)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "slack down")
+		assert.EqualValues(t, 1, ok.calls.Load())
+	})
+}
+
+func TestNewFromConfig(t *testing.T) {
+	var slackCalls, pdCalls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/slack") {
+			slackCalls.Add(1)
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		pdCalls.Add(1)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	c := NewFromConfig(config.AlertConfig{
+		SlackWebhookURL: srv.URL + "/slack",
+		EnabledAlerts:   []string{ProviderFailure},
+	})
+	require.NoError(t, c.Send(context.Background(), testAlert))
+	assert.EqualValues(t, 1, slackCalls.Load())
+	assert.EqualValues(t, 0, pdCalls.Load())
+}
+
+func TestNoopAlerter(t *testing.T) {
+	assert.NoError(t, NoopAlerter{}.Send(context.Background(), testAlert))
+}
+
+func TestWebhookAlerter_Send(t *testing.T) {
+	var got webhookPayload
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	err := NewWebhookAlerter(srv.URL, srv.Client()).Send(context.Background(), testAlert)
+	require.NoError(t, err)
+	assert.Equal(t, webhookPayload{
+		Name:     ProviderFailure,
+		Severity: SeverityCritical,
+		Summary:  "all providers failed for EUR/USD",
+		Details:  map[string]string{"pair": "EUR/USD"},
+	}, got)
+}
+
+func TestWebhookAlerter_ErrorStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	err := NewWebhookAlerter(srv.URL, srv.Client()).Send(context.Background(), testAlert)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "502")
+}
@@ -0,0 +1,145 @@
+package alert
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"quoteservice/internal/config"
+	"quoteservice/internal/logging/fields"
+)
+
+// RateMove is the name of the alert raised when a pair moves more than its threshold
+// between consecutive successful updates.
+const RateMove = "rate_move"
+
+// RateMoveMonitor compares consecutive prices of a pair and raises a RateMove alert when
+// the relative change exceeds the pair's threshold. Alerts for a pair are suppressed for
+// the cooldown after one fires; the cooldown is tracked per process.
+type RateMoveMonitor struct {
+	defaultThreshold float64
+	pairThresholds   map[string]float64
+	cooldown         time.Duration
+	alerter          Alerter
+	log              *zap.SugaredLogger
+	now              func() time.Time
+
+	mu        sync.Mutex
+	lastFired map[string]time.Time
+}
+
+// NewRateMoveMonitor creates a RateMoveMonitor from cfg. Alerts are always logged at WARN
+// and additionally delivered to cfg.RateMoveWebhookURL when it is set.
+func NewRateMoveMonitor(cfg config.AlertConfig, logger *zap.SugaredLogger) *RateMoveMonitor {
+	var alerter Alerter = NoopAlerter{}
+	if cfg.RateMoveWebhookURL != "" {
+		alerter = NewWebhookAlerter(cfg.RateMoveWebhookURL, nil)
+	}
+	return newRateMoveMonitor(cfg, alerter, logger)
+}
+
+func newRateMoveMonitor(cfg config.AlertConfig, alerter Alerter, logger *zap.SugaredLogger) *RateMoveMonitor {
+	// Viper lower-cases map keys, so pair overrides are normalized here.
+	thresholds := make(map[string]float64, len(cfg.PairThresholds))
+	for pair, pct := range cfg.PairThresholds {
+		thresholds[strings.ToUpper(pair)] = pct
+	}
+	return &RateMoveMonitor{
+		defaultThreshold: cfg.ThresholdPct,
+		pairThresholds:   thresholds,
+		cooldown:         time.Duration(cfg.RateMoveCooldownSec) * time.Second,
+		alerter:          alerter,
+		log:              logger,
+		now:              time.Now,
+		lastFired:        make(map[string]time.Time),
+	}
+}
+
+// Enabled reports whether any threshold is configured.
+func (m *RateMoveMonitor) Enabled() bool {
+	return m.defaultThreshold > 0 || len(m.pairThresholds) > 0
+}
+
+// ObserveRateMove checks the move from oldPrice to newPrice. A previous price that is
+// empty, zero or unparsable is skipped, since no relative change can be computed.
+func (m *RateMoveMonitor) ObserveRateMove(ctx context.Context, base, quote, oldPrice, newPrice string, oldAt, newAt time.Time) {
+	pair := base + "/" + quote
+	threshold := m.threshold(pair)
+	if threshold <= 0 {
+		return
+	}
+
+	changePct, ok := percentChange(oldPrice, newPrice)
+	if !ok {
+		return
+	}
+	absChange := new(big.Rat).Abs(changePct)
+	if absChange.Cmp(new(big.Rat).SetFloat64(threshold)) <= 0 {
+		return
+	}
+	if !m.startCooldown(pair) {
+		return
+	}
+
+	pct := changePct.FloatString(4)
+	m.log.Warnw("Rate moved beyond threshold",
+		fields.Pair(base, quote), "old_price", oldPrice, "new_price", newPrice,
+		"change_pct", pct, "threshold_pct", threshold,
+		"old_timestamp", oldAt.UTC().Format(time.RFC3339), "new_timestamp", newAt.UTC().Format(time.RFC3339))
+
+	err := m.alerter.Send(ctx, Alert{
+		Name:     RateMove,
+		Severity: SeverityWarning,
+		Summary:  fmt.Sprintf("%s moved %s%% (threshold %g%%)", pair, pct, threshold),
+		Details: map[string]string{
+			"pair":          pair,
+			"old_price":     oldPrice,
+			"new_price":     newPrice,
+			"change_pct":    pct,
+			"old_timestamp": oldAt.UTC().Format(time.RFC3339),
+			"new_timestamp": newAt.UTC().Format(time.RFC3339),
+		},
+	})
+	if err != nil {
+		m.log.Errorw("Failed to deliver rate move alert", fields.Pair(base, quote), "error", err)
+	}
+}
+
+func (m *RateMoveMonitor) threshold(pair string) float64 {
+	if pct, ok := m.pairThresholds[pair]; ok {
+		return pct
+	}
+	return m.defaultThreshold
+}
+
+// startCooldown reports whether pair is outside its cooldown and, if so, starts a new one.
+func (m *RateMoveMonitor) startCooldown(pair string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	if last, ok := m.lastFired[pair]; ok && now.Sub(last) < m.cooldown {
+		return false
+	}
+	m.lastFired[pair] = now
+	return true
+}
+
+// percentChange returns (newPrice-oldPrice)/oldPrice*100 computed exactly.
+func percentChange(oldPrice, newPrice string) (*big.Rat, bool) {
+	oldRat, ok := new(big.Rat).SetString(oldPrice)
+	if !ok || oldRat.Sign() == 0 {
+		return nil, false
+	}
+	newRat, ok := new(big.Rat).SetString(newPrice)
+	if !ok {
+		return nil, false
+	}
+	change := new(big.Rat).Sub(newRat, oldRat)
+	change.Quo(change, oldRat)
+	return change.Mul(change, big.NewRat(100, 1)), true
+}
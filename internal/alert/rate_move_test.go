@@ -0,0 +1,156 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"quoteservice/internal/config"
+)
+
+// capturingAlerter captures every alert it is asked to send.
+type capturingAlerter struct {
+	mu     sync.Mutex
+	alerts []Alert
+}
+
+func (r *capturingAlerter) Send(_ context.Context, a Alert) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.alerts = append(r.alerts, a)
+	return nil
+}
+
+func (r *capturingAlerter) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.alerts)
+}
+
+var (
+	oldAt = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	newAt = oldAt.Add(time.Minute)
+)
+
+func TestRateMoveMonitor_Threshold(t *testing.T) {
+	tests := []struct {
+		name      string
+		oldPrice  string
+		newPrice  string
+		wantAlert bool
+		wantPct   string
+	}{
+		{"above threshold", "1.000000", "1.0150", true, "1.5000"},
+		{"drop above threshold", "2", "1.9", true, "-5.0000"},
+		{"exactly at threshold", "1.000000", "1.01", false, ""},
+		{"below threshold", "1.085000", "1.0851", false, ""},
+		{"tiny prices", "0.000010", "0.000011", true, "10.0000"},
+		{"previous zero", "0.000000", "1.2", false, ""},
+		{"previous missing", "", "1.2", false, ""},
+		{"previous unparsable", "n/a", "1.2", false, ""},
+		{"new unparsable", "1.2", "", false, ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := &capturingAlerter{}
+			m := newRateMoveMonitor(config.AlertConfig{ThresholdPct: 1}, rec, zap.NewNop().Sugar())
+
+			m.ObserveRateMove(context.Background(), "EUR", "USD", tc.oldPrice, tc.newPrice, oldAt, newAt)
+
+			if !tc.wantAlert {
+				assert.Zero(t, rec.count())
+				return
+			}
+			require.Equal(t, 1, rec.count())
+			a := rec.alerts[0]
+			assert.Equal(t, RateMove, a.Name)
+			assert.Equal(t, SeverityWarning, a.Severity)
+			assert.Equal(t, tc.wantPct, a.Details["change_pct"])
+			assert.Equal(t, "EUR/USD", a.Details["pair"])
+			assert.Equal(t, tc.oldPrice, a.Details["old_price"])
+			assert.Equal(t, tc.newPrice, a.Details["new_price"])
+		})
+	}
+}
+
+func TestRateMoveMonitor_PairOverride(t *testing.T) {
+	rec := &capturingAlerter{}
+	cfg := config.AlertConfig{
+		ThresholdPct: 5,
+		// Keys arrive lower-cased from viper.
+		PairThresholds: map[string]float64{"eur/usd": 0.5},
+	}
+	m := newRateMoveMonitor(cfg, rec, zap.NewNop().Sugar())
+
+	m.ObserveRateMove(context.Background(), "EUR", "USD", "1.00", "1.01", oldAt, newAt)
+	m.ObserveRateMove(context.Background(), "GBP", "USD", "1.00", "1.01", oldAt, newAt)
+
+	require.Equal(t, 1, rec.count())
+	assert.Equal(t, "EUR/USD", rec.alerts[0].Details["pair"])
+}
+
+func TestRateMoveMonitor_OnlyPairOverrides(t *testing.T) {
+	rec := &capturingAlerter{}
+	m := newRateMoveMonitor(config.AlertConfig{PairThresholds: map[string]float64{"EUR/USD": 1}}, rec, zap.NewNop().Sugar())
+	assert.True(t, m.Enabled())
+
+	m.ObserveRateMove(context.Background(), "GBP", "USD", "1", "2", oldAt, newAt)
+	assert.Zero(t, rec.count(), "pairs without a threshold must not alert")
+}
+
+func TestRateMoveMonitor_Cooldown(t *testing.T) {
+	rec := &capturingAlerter{}
+	m := newRateMoveMonitor(config.AlertConfig{ThresholdPct: 1, RateMoveCooldownSec: 60}, rec, zap.NewNop().Sugar())
+	now := oldAt
+	m.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	m.ObserveRateMove(ctx, "EUR", "USD", "1", "1.1", oldAt, newAt)
+	now = now.Add(30 * time.Second)
+	m.ObserveRateMove(ctx, "EUR", "USD", "1.1", "1.3", oldAt, newAt)
+	m.ObserveRateMove(ctx, "GBP", "USD", "1", "1.1", oldAt, newAt) // Cooldown is per pair.
+	assert.Equal(t, 2, rec.count())
+
+	now = now.Add(31 * time.Second)
+	m.ObserveRateMove(ctx, "EUR", "USD", "1.3", "1.5", oldAt, newAt)
+	assert.Equal(t, 3, rec.count())
+}
+
+func TestRateMoveMonitor_Disabled(t *testing.T) {
+	m := NewRateMoveMonitor(config.AlertConfig{}, zap.NewNop().Sugar())
+	assert.False(t, m.Enabled())
+}
+
+func TestRateMoveMonitor_WebhookDelivery(t *testing.T) {
+	var got webhookPayload
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	cfg := config.AlertConfig{ThresholdPct: 2, RateMoveWebhookURL: srv.URL}
+	m := NewRateMoveMonitor(cfg, zap.NewNop().Sugar())
+
+	m.ObserveRateMove(context.Background(), "USD", "JPY", "150.000000", "155.25", oldAt, newAt)
+
+	assert.Equal(t, RateMove, got.Name)
+	assert.Equal(t, map[string]string{
+		"pair":          "USD/JPY",
+		"old_price":     "150.000000",
+		"new_price":     "155.25",
+		"change_pct":    "3.5000",
+		"old_timestamp": "2026-03-01T12:00:00Z",
+		"new_timestamp": "2026-03-01T12:01:00Z",
+	}, got.Details)
+}
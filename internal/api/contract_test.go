@@ -0,0 +1,383 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+
+	"quoteservice/internal/api/docs"
+	"quoteservice/internal/api/middleware"
+	"quoteservice/internal/lifecycle"
+	"quoteservice/internal/provider"
+	"quoteservice/internal/quota"
+	"quoteservice/internal/service"
+	"quoteservice/internal/worker"
+)
+
+// contractCase is a request to one route and the model its swagger annotation declares
+// for the response status.
+type contractCase struct {
+	name    string
+	method  string
+	route   string // Swagger path, also used as the chi pattern.
+	handler http.Handler
+	target  string
+	body    string
+	apiKey  string
+	status  int
+	model   any
+}
+
+// contractCases returns a case for every JSON response of the API. Services return
+// fully populated results so that every omitempty field is emitted.
+func contractCases() []contractCase {
+	price, ts, errMsg, detail := "18.7543", "2025-12-01T10:15:30Z", "provider timeout", "18.754300"
+	svc := &mockQuoteService{
+		requestUpdateFunc: func(_ context.Context, pair string, opts service.UpdateOptions) (*service.UpdateRequestResult, error) {
+			switch {
+			case opts.Provider == "ecb":
+				return nil, &service.UnknownProviderError{Name: "ecb", Valid: []string{"frankfurter"}}
+			case pair == "GBP/USD":
+				return nil, service.ErrQueueFull
+			}
+			return &service.UpdateRequestResult{
+				UpdateID: "u1", Status: "SUCCESS",
+				Reason: service.ReuseReasonCooldownActive, CooldownRemaining: 42 * time.Second,
+				PollAfter: 2 * time.Second,
+			}, nil
+		},
+		fetchQuoteFunc: func(_ context.Context, pair string, _ time.Duration) (*service.QuoteResult, error) {
+			if pair == "GBP/USD" {
+				return &service.QuoteResult{ID: "u1", Status: "RUNNING", PollAfter: 2 * time.Second}, nil
+			}
+			return &service.QuoteResult{
+				ID: "u1", Base: "EUR", Quote: "MXN", Status: "SUCCESS", Price: &price, ErrorMsg: &errMsg,
+				UpdatedAt: &ts, RateTimestamp: &ts, Origin: "api",
+			}, nil
+		},
+		getQuoteResultFunc: func(_ context.Context, id string) (*service.QuoteResult, error) {
+			return &service.QuoteResult{
+				ID: id, Base: "EUR", Quote: "MXN", Status: "SUCCESS", Price: &price, ErrorMsg: &errMsg,
+				UpdatedAt: &ts, RateTimestamp: &ts, Origin: "api", InstanceID: "worker-1-a1b2c3",
+				Verification: &service.QuoteVerification{MinPrice: "18.74", MaxPrice: "18.76", SpreadPct: "0.1", Providers: 2},
+			}, nil
+		},
+		getStatusEventsFunc: func(context.Context, string) ([]service.QuoteStatusEvent, error) {
+			return []service.QuoteStatusEvent{
+				{Status: "RUNNING", At: ts, InstanceID: "worker-1-a1b2c3"},
+				{Status: "SUCCESS", At: ts, Detail: &detail},
+			}, nil
+		},
+		getLatestQuoteFunc: func(_ context.Context, pair service.Pair) (*service.QuoteResult, error) {
+			switch pair.Base {
+			case "GBP":
+				return nil, service.ErrNotFound
+			case "CHF":
+				return nil, service.ErrSchemaNotReady
+			}
+			return &service.QuoteResult{ID: "u1", Base: pair.Base, Quote: pair.Quote, Price: &price, UpdatedAt: &ts, RateTimestamp: &ts}, nil
+		},
+		getLatestQuotesFunc: func(_ context.Context, pairs []service.Pair) ([]service.LatestQuote, error) {
+			quotes := make([]service.LatestQuote, len(pairs))
+			for i, pair := range pairs {
+				quotes[i] = service.LatestQuote{Pair: pair}
+				if pair.Base != "GBP" {
+					quotes[i].Quote = &service.QuoteResult{Base: pair.Base, Quote: pair.Quote, Price: &price, UpdatedAt: &ts, RateTimestamp: &ts}
+				}
+			}
+			return quotes, nil
+		},
+		getLastAttemptFunc: func(context.Context, service.Pair) (*service.QuoteAttempt, error) {
+			return &service.QuoteAttempt{Status: "FAILED", ErrorMsg: &errMsg, AttemptAt: ts}, nil
+		},
+		getHistoricalFunc: func(_ context.Context, pair service.Pair, _ time.Time) (*service.QuoteResult, error) {
+			return &service.QuoteResult{Base: pair.Base, Quote: pair.Quote, Price: &price, UpdatedAt: &ts}, nil
+		},
+		compareQuotesFunc: func(_ context.Context, pair service.Pair, _, _ time.Time) (*service.QuoteComparison, error) {
+			if pair.Base == "GBP" {
+				return nil, &service.ComparisonNotFoundError{Missing: []string{"at"}}
+			}
+			return &service.QuoteComparison{
+				Base: pair.Base, Quote: pair.Quote, Change: "0", ChangePct: "0.0000",
+				At: &service.QuoteResult{Price: &price, UpdatedAt: &ts}, Vs: &service.QuoteResult{Price: &price, UpdatedAt: &ts},
+			}, nil
+		},
+	}
+	lister := &mockPairTaskLister{tasks: []worker.QueuedTask{{
+		ID: "t1", Queue: "default", State: "retry", Retried: 1, MaxRetry: 5,
+		NextProcessAt: time.Now(), LastErr: errMsg, LastFailedAt: time.Now(),
+		Payload: service.UpdateQuotePayload{UpdateID: "u1", Pair: service.Pair{Base: "EUR", Quote: "MXN"}, Provider: "frankfurter"},
+	}}}
+	failing := ReadinessFunc(func(context.Context) error { return fmt.Errorf("connection refused") })
+	auth := middleware.APIKeyMiddleware([]middleware.APIKey{{Key: "reader", Scopes: []string{middleware.ScopeRead}}})
+	scoped := func(scope string, h http.Handler) http.Handler {
+		return auth(middleware.ScopeMiddleware(scope)(h))
+	}
+
+	return []contractCase{
+		{name: "health details", method: http.MethodGet, route: "/healthz/details", target: "/healthz/details",
+			handler: HandleHealthDetails(staticLifecycle{Phase: lifecycle.PhaseServing, Events: []lifecycle.Record{{Event: lifecycle.ConfigLoaded}}}),
+			status:  http.StatusOK, model: HealthDetailsResponse{}},
+		{name: "ready", method: http.MethodGet, route: "/readyz", target: "/readyz",
+			handler: HandleReadyz(ReadinessCheck{Name: "cache", Checker: failing, Optional: true}),
+			status:  http.StatusOK, model: ReadyResponse{}},
+		{name: "not ready", method: http.MethodGet, route: "/readyz", target: "/readyz",
+			handler: HandleReadyz(ReadinessCheck{Name: "postgres", Checker: failing}),
+			status:  http.StatusServiceUnavailable, model: ReadyResponse{}},
+		{name: "update accepted", method: http.MethodPost, route: "/quotes/update", target: "/quotes/update",
+			handler: HandleRequestUpdate(svc, nil, ErrorConfig{}), body: `{"pair":"EUR/MXN"}`,
+			status: http.StatusAccepted, model: UpdateResponse{}},
+		{name: "update unknown provider", method: http.MethodPost, route: "/quotes/update", target: "/quotes/update",
+			handler: HandleRequestUpdate(svc, nil, ErrorConfig{}), body: `{"pair":"EUR/MXN","provider":"ecb"}`,
+			status: http.StatusBadRequest, model: UnknownProviderResponse{}},
+		{name: "update invalid body", method: http.MethodPost, route: "/quotes/update", target: "/quotes/update",
+			handler: HandleRequestUpdate(svc, nil, ErrorConfig{}), body: `{`,
+			status: http.StatusBadRequest, model: UnknownProviderResponse{}},
+		{name: "update queue full", method: http.MethodPost, route: "/quotes/update", target: "/quotes/update",
+			handler: HandleRequestUpdate(svc, nil, ErrorConfig{}), body: `{"pair":"GBP/USD"}`,
+			status: http.StatusServiceUnavailable, model: ErrorResponse{}},
+		{name: "update without scope", method: http.MethodPost, route: "/quotes/update", target: "/quotes/update",
+			handler: scoped(middleware.ScopeWrite, HandleRequestUpdate(svc, nil, ErrorConfig{})), body: `{"pair":"EUR/MXN"}`, apiKey: "reader",
+			status: http.StatusForbidden, model: ErrorResponse{}},
+		{name: "fetch finished", method: http.MethodPost, route: "/quotes/fetch", target: "/quotes/fetch",
+			handler: HandleFetchQuote(svc, nil, time.Second, ErrorConfig{}), body: `{"pair":"EUR/MXN","timeout_ms":3000}`,
+			status: http.StatusOK, model: QuoteResponse{}},
+		{name: "fetch timed out", method: http.MethodPost, route: "/quotes/fetch", target: "/quotes/fetch",
+			handler: HandleFetchQuote(svc, nil, time.Second, ErrorConfig{}), body: `{"pair":"GBP/USD","timeout_ms":3000}`,
+			status: http.StatusAccepted, model: UpdateResponse{}},
+		{name: "fetch invalid timeout", method: http.MethodPost, route: "/quotes/fetch", target: "/quotes/fetch",
+			handler: HandleFetchQuote(svc, nil, time.Second, ErrorConfig{}), body: `{"pair":"EUR/MXN","timeout_ms":0}`,
+			status: http.StatusBadRequest, model: ErrorResponse{}},
+		{name: "quote by id", method: http.MethodGet, route: "/quotes/{update_id}",
+			target:  "/quotes/123e4567-e89b-12d3-a456-426614174000?include_events=true&include_verification=true",
+			handler: HandleGetQuoteByID(svc, nil, ErrorConfig{}), status: http.StatusOK, model: QuoteResponse{}},
+		{name: "latest", method: http.MethodGet, route: "/quotes/latest", target: "/quotes/latest?base=EUR&quote=MXN",
+			handler: HandleGetLatestQuote(svc, nil, false, ErrorConfig{}), status: http.StatusOK, model: LatestResponse{}},
+		{name: "latest not found", method: http.MethodGet, route: "/quotes/latest",
+			target:  "/quotes/latest?base=GBP&quote=USD&include_last_attempt=true",
+			handler: HandleGetLatestQuote(svc, nil, false, ErrorConfig{}), status: http.StatusNotFound, model: LatestNotFoundResponse{}},
+		{name: "latest schema not ready", method: http.MethodGet, route: "/quotes/latest", target: "/quotes/latest?base=CHF&quote=USD",
+			handler: HandleGetLatestQuote(svc, nil, false, ErrorConfig{}), status: http.StatusServiceUnavailable, model: ErrorResponse{}},
+		{name: "latest without key", method: http.MethodGet, route: "/quotes/latest", target: "/quotes/latest?base=EUR&quote=MXN",
+			handler: auth(HandleGetLatestQuote(svc, nil, false, ErrorConfig{})), status: http.StatusUnauthorized, model: ErrorResponse{}},
+		{name: "latest defaults", method: http.MethodGet, route: "/quotes/latest/defaults", target: "/quotes/latest/defaults",
+			handler: HandleGetDefaultLatestQuotes(svc, []service.Pair{{Base: "EUR", Quote: "MXN"}, {Base: "GBP", Quote: "USD"}}, ErrorConfig{}),
+			status:  http.StatusOK, model: DefaultLatestResponse{}},
+		{name: "historical", method: http.MethodGet, route: "/quotes/history/at",
+			target:  "/quotes/history/at?base=EUR&quote=MXN&at=2025-12-01T12:00:00Z",
+			handler: HandleGetHistoricalQuote(svc, ErrorConfig{}), status: http.StatusOK, model: HistoricalResponse{}},
+		{name: "compare", method: http.MethodGet, route: "/quotes/compare",
+			target:  "/quotes/compare?base=EUR&quote=MXN&at=2025-01-02&vs=2025-06-02",
+			handler: HandleCompareQuotes(svc, ErrorConfig{}), status: http.StatusOK, model: CompareResponse{}},
+		{name: "compare not found", method: http.MethodGet, route: "/quotes/compare",
+			target:  "/quotes/compare?base=GBP&quote=USD&at=2025-01-02&vs=2025-06-02",
+			handler: HandleCompareQuotes(svc, ErrorConfig{}), status: http.StatusNotFound, model: CompareNotFoundResponse{}},
+		{name: "currencies", method: http.MethodGet, route: "/currencies", target: "/currencies",
+			handler: HandleListCurrencies(), status: http.StatusOK, model: CurrenciesResponse{}},
+		{name: "currency", method: http.MethodGet, route: "/currencies/{code}", target: "/currencies/JPY",
+			handler: HandleGetCurrency(), status: http.StatusOK, model: CurrencyResponse{}},
+		{name: "unknown currency", method: http.MethodGet, route: "/currencies/{code}", target: "/currencies/XXX",
+			handler: HandleGetCurrency(), status: http.StatusNotFound, model: ErrorResponse{}},
+		{name: "queued tasks", method: http.MethodGet, route: "/admin/queue/tasks", target: "/admin/queue/tasks?pair=EUR/MXN",
+			handler: HandleListPairTasks(lister, svc, ErrorConfig{}), status: http.StatusOK, model: PairTasksResponse{}},
+		{name: "archived tasks", method: http.MethodGet, route: "/admin/queue/archived", target: "/admin/queue/archived",
+			handler: HandleListArchivedTasks(&mockArchivedTaskManager{tasks: archivedTaskFixture()}, svc, ErrorConfig{}),
+			status:  http.StatusOK, model: ArchivedTasksResponse{}},
+		{name: "retry archived task", method: http.MethodPost, route: "/admin/queue/archived/{task_id}/retry",
+			target:  "/admin/queue/archived/u1/retry",
+			handler: HandleRetryArchivedTask(&mockArchivedTaskManager{tasks: archivedTaskFixture()}, svc, ErrorConfig{}),
+			status:  http.StatusOK, model: QueuedTaskResponse{}},
+		{name: "retry task not archived", method: http.MethodPost, route: "/admin/queue/archived/{task_id}/retry",
+			target:  "/admin/queue/archived/u1/retry",
+			handler: HandleRetryArchivedTask(&mockArchivedTaskManager{retryErr: worker.ErrTaskNotArchived}, svc, ErrorConfig{}),
+			status:  http.StatusConflict, model: UpdateInFlightResponse{}},
+		{name: "retry task of a pair in flight", method: http.MethodPost, route: "/admin/queue/archived/{task_id}/retry",
+			target: "/admin/queue/archived/u1/retry",
+			handler: HandleRetryArchivedTask(&mockArchivedTaskManager{
+				retryErr: &service.UpdateInFlightError{UpdateID: "u1", InFlightID: "u2"}}, svc, ErrorConfig{}),
+
+			status: http.StatusConflict, model: UpdateInFlightResponse{}},
+		{name: "quotas", method: http.MethodGet, route: "/admin/quotas", target: "/admin/quotas",
+			handler: HandleListQuotas(mockQuotaTracker{usage: []quota.Usage{{Key: "desk", Limit: 100, Used: 1, Reset: time.Now()}}}),
+			status:  http.StatusOK, model: QuotasResponse{}},
+		{name: "quota exceeded", method: http.MethodGet, route: "/quotes/latest", target: "/quotes/latest?base=EUR&quote=MXN",
+			handler: auth(middleware.QuotaMiddleware(mockQuotaTracker{}, zap.NewNop().Sugar())(HandleGetLatestQuote(svc, nil, false, ErrorConfig{}))),
+			apiKey:  "reader", status: http.StatusTooManyRequests, model: QuotaExceededResponse{}},
+		{name: "reconcile", method: http.MethodPost, route: "/admin/reconcile", target: "/admin/reconcile",
+			handler: HandleReconcile(mockPendingReconciler{summary: worker.ReconcileSummary{Checked: 1, Queued: 1}}),
+			status:  http.StatusOK, model: ReconcileResponse{}},
+		{name: "reliability report", method: http.MethodGet, route: "/admin/reports/reliability",
+			target:  "/admin/reports/reliability?from=2025-12-01&to=2025-12-08",
+			handler: HandleReliabilityReport(&mockReliabilityReporter{rows: reliabilityFixture()}), status: http.StatusOK, model: ReliabilityReportResponse{}},
+		{name: "reliability report invalid range", method: http.MethodGet, route: "/admin/reports/reliability",
+			target:  "/admin/reports/reliability?from=2025-12-08&to=2025-12-01",
+			handler: HandleReliabilityReport(&mockReliabilityReporter{}), status: http.StatusBadRequest, model: ErrorResponse{}},
+		{name: "selfcheck", method: http.MethodGet, route: "/admin/selfcheck", target: "/admin/selfcheck",
+			handler: HandleSelfCheck(time.Second, SelfCheckStep{Name: "postgres_write", Run: func(context.Context) error { return nil }}),
+			status:  http.StatusOK, model: SelfCheckResponse{}},
+		{name: "selfcheck failed", method: http.MethodGet, route: "/admin/selfcheck", target: "/admin/selfcheck",
+			handler: HandleSelfCheck(time.Second, SelfCheckStep{Name: "redis_cache", Run: failing}),
+			status:  http.StatusServiceUnavailable, model: SelfCheckResponse{}},
+		{name: "provider scores", method: http.MethodGet, route: "/admin/providers", target: "/admin/providers",
+			handler: HandleProviderScores(&mockScoreReporter{adaptive: true, scores: []provider.ProviderScore{{Name: "frankfurter", SuccessRate: 0.5, Score: 0.5}}}),
+			status:  http.StatusOK, model: ProviderScoresResponse{}},
+		{name: "worker config", method: http.MethodPatch, route: "/admin/worker-config", target: "/admin/worker-config",
+			handler: HandlePatchWorkerConfig(&mockWorkerTuner{concurrency: 5, taskTimeout: time.Minute}), body: `{"concurrency":8}`,
+			status: http.StatusOK, model: WorkerConfigResponse{}},
+		{name: "worker config without admin scope", method: http.MethodPatch, route: "/admin/worker-config", target: "/admin/worker-config",
+			handler: scoped(middleware.ScopeAdmin, HandlePatchWorkerConfig(&mockWorkerTuner{})), body: `{"concurrency":8}`, apiKey: "reader",
+			status: http.StatusForbidden, model: ErrorResponse{}},
+		{name: "worker drain", method: http.MethodPost, route: "/admin/worker/drain", target: "/admin/worker/drain",
+			handler: HandleDrainWorker(mockWorkerDrainer{}), status: http.StatusOK, model: WorkerStateResponse{}},
+		{name: "worker resume", method: http.MethodPost, route: "/admin/worker/resume", target: "/admin/worker/resume",
+			handler: HandleResumeWorker(mockWorkerDrainer{}), status: http.StatusOK, model: WorkerStateResponse{}},
+		{name: "worker resume failed", method: http.MethodPost, route: "/admin/worker/resume", target: "/admin/worker/resume",
+			handler: HandleResumeWorker(mockWorkerDrainer{err: fmt.Errorf("worker pool is stopped")}),
+			status:  http.StatusInternalServerError, model: ErrorResponse{}},
+	}
+}
+
+// TestResponseContracts checks that every JSON response decodes into the model its
+// swagger annotation declares, without unknown fields, so a field written by a handler
+// but missing from the docs fails the test.
+func TestResponseContracts(t *testing.T) {
+	for _, tc := range contractCases() {
+		t.Run(tc.name, func(t *testing.T) {
+			r := chi.NewRouter()
+			r.Method(tc.method, tc.route, tc.handler)
+			req := httptest.NewRequest(tc.method, tc.target, strings.NewReader(tc.body))
+			if tc.apiKey != "" {
+				req.Header.Set("X-API-Key", tc.apiKey)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tc.status {
+				t.Fatalf("Expected status %d, got %d: %s", tc.status, w.Code, w.Body)
+			}
+			if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+				t.Errorf("Expected Content-Type application/json, got %q", ct)
+			}
+			dec := json.NewDecoder(bytes.NewReader(w.Body.Bytes()))
+			dec.DisallowUnknownFields()
+			if err := dec.Decode(reflect.New(reflect.TypeOf(tc.model)).Interface()); err != nil {
+				t.Fatalf("Response does not match %T: %v\n%s", tc.model, err, w.Body)
+			}
+			if _, err := dec.Token(); err != io.EOF {
+				t.Errorf("Expected a single JSON value, got trailing data: %s", w.Body)
+			}
+		})
+	}
+}
+
+// TestSwaggerMatchesModels checks that the generated spec declares each case's model
+// for its route and status, and that every declared definition lists exactly the JSON
+// fields of its Go type. It fails when the docs were not regenerated after a change.
+func TestSwaggerMatchesModels(t *testing.T) {
+	var spec struct {
+		Paths map[string]map[string]struct {
+			Responses map[string]struct {
+				Schema struct {
+					Ref string `json:"$ref"`
+				} `json:"schema"`
+			} `json:"responses"`
+		} `json:"paths"`
+		Definitions map[string]struct {
+			Properties map[string]json.RawMessage `json:"properties"`
+		} `json:"definitions"`
+	}
+	if err := json.Unmarshal([]byte(docs.SwaggerInfo.ReadDoc()), &spec); err != nil {
+		t.Fatalf("Failed to parse swagger spec: %v", err)
+	}
+
+	models := map[reflect.Type]bool{}
+	for _, tc := range contractCases() {
+		model := reflect.TypeOf(tc.model)
+		resp, ok := spec.Paths[tc.route][strings.ToLower(tc.method)].Responses[strconv.Itoa(tc.status)]
+		if !ok {
+			t.Errorf("%s %s: no %d response documented", tc.method, tc.route, tc.status)
+			continue
+		}
+		if want := "#/definitions/" + definitionName(model); resp.Schema.Ref != want {
+			t.Errorf("%s %s: %d documented as %q, handler writes %s", tc.method, tc.route, tc.status, resp.Schema.Ref, want)
+		}
+		collectModels(model, models)
+	}
+
+	for model := range models {
+		def, ok := spec.Definitions[definitionName(model)]
+		if !ok {
+			t.Errorf("%s: no swagger definition", model)
+			continue
+		}
+		documented := make([]string, 0, len(def.Properties))
+		for name := range def.Properties {
+			documented = append(documented, name)
+		}
+		slices.Sort(documented)
+		if fields := jsonFieldNames(model); !slices.Equal(fields, documented) {
+			t.Errorf("%s: swagger lists %v, Go type has %v", model, documented, fields)
+		}
+	}
+}
+
+func definitionName(t reflect.Type) string {
+	return "api." + t.Name()
+}
+
+// collectModels adds t and the api structs nested in its fields to models.
+func collectModels(t reflect.Type, models map[reflect.Type]bool) {
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Map {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || t.PkgPath() != reflect.TypeOf(ErrorResponse{}).PkgPath() || models[t] {
+		return
+	}
+	models[t] = true
+	for i := range t.NumField() {
+		collectModels(t.Field(i).Type, models)
+	}
+}
+
+func jsonFieldNames(t reflect.Type) []string {
+	var names []string
+	for i := range t.NumField() {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// TestMiddlewareErrorCodes checks that the auth and quota middlewares, which cannot import this
+// package, send the codes documented here.
+func TestMiddlewareErrorCodes(t *testing.T) {
+	if middleware.ErrCodeUnauthorized != ErrCodeUnauthorized || middleware.ErrCodeForbidden != ErrCodeForbidden {
+		t.Errorf("middleware codes %d/%d differ from %d/%d", middleware.ErrCodeUnauthorized, middleware.ErrCodeForbidden,
+			ErrCodeUnauthorized, ErrCodeForbidden)
+	}
+	if got, want := jsonFieldNames(reflect.TypeOf(middleware.ErrorResponse{})), jsonFieldNames(reflect.TypeOf(ErrorResponse{})); !slices.Equal(got, want) {
+		t.Errorf("middleware.ErrorResponse has fields %v, want %v", got, want)
+	}
+	if middleware.ErrCodeQuotaExceeded != ErrCodeQuotaExceeded {
+		t.Errorf("middleware quota code %d differs from %d", middleware.ErrCodeQuotaExceeded, ErrCodeQuotaExceeded)
+	}
+	if got, want := jsonFieldNames(reflect.TypeOf(middleware.QuotaExceededResponse{})), jsonFieldNames(reflect.TypeOf(QuotaExceededResponse{})); !slices.Equal(got, want) {
+		t.Errorf("middleware.QuotaExceededResponse has fields %v, want %v", got, want)
+	}
+}
@@ -15,78 +15,108 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/healthz": {
+        "/admin/providers": {
             "get": {
-                "description": "Always returns 200 OK if the service is running. Used for liveness probes.",
+                "description": "Returns each exchange rate provider's record over its last provider_selection.window calls made through the facade: the smoothed success rate, the median latency of the successful calls and the resulting score. With provider_selection.strategy adaptive, providers are tried best score first. Requires the admin scope.",
                 "produces": [
-                    "text/plain"
+                    "application/json"
                 ],
                 "tags": [
-                    "health"
+                    "admin"
                 ],
-                "summary": "Health check (liveness)",
+                "summary": "Show provider scores",
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "Provider scores",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/api.ProviderScoresResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid API key (when auth is enabled)",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key lacks the admin scope",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Monthly quota of the API key used up (when quotas are enabled)",
+                        "schema": {
+                            "$ref": "#/definitions/api.QuotaExceededResponse"
                         }
                     }
                 }
             }
         },
-        "/quotes/latest": {
+        "/admin/queue/archived": {
             "get": {
-                "description": "Returns the most recent successful quote for the given currency pair. Does NOT trigger a new fetch - only returns cached/stored data.",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Lists the Asynq update tasks archived after exhausting their retries or failing permanently, queue by queue from the highest priority, each with its decoded payload, last error and the status, origin and error code of its update record. When more tasks follow, next_cursor is set and a Link header (RFC 8288) gives the next page (rel=\"next\") and the first one (rel=\"first\"); the cursor is an offset, so tasks archived or retried meanwhile shift the pages. The count per queue is exported as quotesvc_worker_archived_tasks. Requires the admin scope.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "quotes"
+                    "admin"
                 ],
-                "summary": "Get latest quote for a currency pair",
+                "summary": "List archived update tasks",
                 "parameters": [
                     {
-                        "maxLength": 3,
-                        "minLength": 3,
-                        "type": "string",
-                        "description": "Base currency code (3 letters)",
-                        "name": "base",
-                        "in": "query",
-                        "required": true
+                        "maximum": 1000,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 100,
+                        "description": "Maximum number of tasks",
+                        "name": "limit",
+                        "in": "query"
                     },
                     {
-                        "maxLength": 3,
-                        "minLength": 3,
                         "type": "string",
-                        "description": "Quote currency code (3 letters)",
-                        "name": "quote",
-                        "in": "query",
-                        "required": true
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Latest quote found",
+                        "description": "Archived tasks",
                         "schema": {
-                            "$ref": "#/definitions/api.LatestResponse"
+                            "$ref": "#/definitions/api.ArchivedTasksResponse"
+                        },
+                        "headers": {
+                            "Link": {
+                                "type": "string",
+                                "description": "Next and first pages, when more tasks follow"
+                            }
                         }
                     },
                     "400": {
-                        "description": "Invalid currency code format",
+                        "description": "Invalid limit or cursor",
                         "schema": {
                             "$ref": "#/definitions/api.ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "No quote available for the given pair",
+                    "401": {
+                        "description": "Missing or invalid API key (when auth is enabled)",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key lacks the admin scope",
                         "schema": {
                             "$ref": "#/definitions/api.ErrorResponse"
                         }
                     },
+                    "429": {
+                        "description": "Monthly quota of the API key used up (when quotas are enabled)",
+                        "schema": {
+                            "$ref": "#/definitions/api.QuotaExceededResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal error",
                         "schema": {
@@ -96,43 +126,63 @@ const docTemplate = `{
                 }
             }
         },
-        "/quotes/update": {
+        "/admin/queue/archived/{task_id}/retry": {
             "post": {
-                "description": "Initiates an asynchronous update for a currency pair. Returns immediately with an update_id for tracking. Does not block on external fetch.",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Resets the task's update record to PENDING, clearing its error, and moves the archived task back to its queue, where it runs with a fresh retry budget. An update record that no longer exists is not reset; the task then finds nothing to do. Requires the admin scope.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "quotes"
+                    "admin"
                 ],
-                "summary": "Request asynchronous quote update",
+                "summary": "Retry an archived update task",
                 "parameters": [
                     {
-                        "description": "Currency pair in format XXX/YYY",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/api.UpdateRequest"
-                        }
+                        "type": "string",
+                        "example": "550e8400-e29b-41d4-a716-446655440000",
+                        "description": "Task ID",
+                        "name": "task_id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
-                    "202": {
-                        "description": "Update request accepted",
+                    "200": {
+                        "description": "Requeued task",
                         "schema": {
-                            "$ref": "#/definitions/api.UpdateResponse"
+                            "$ref": "#/definitions/api.QueuedTaskResponse"
                         }
                     },
-                    "400": {
-                        "description": "Invalid currency code format",
+                    "401": {
+                        "description": "Missing or invalid API key (when auth is enabled)",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key lacks the admin scope",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "No update task with this ID",
                         "schema": {
                             "$ref": "#/definitions/api.ErrorResponse"
                         }
                     },
+                    "409": {
+                        "description": "Task is not archived, its update already completed or changed meanwhile, or another update of the pair is in flight (named by update_id)",
+                        "schema": {
+                            "$ref": "#/definitions/api.UpdateInFlightResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Monthly quota of the API key used up (when quotas are enabled)",
+                        "schema": {
+                            "$ref": "#/definitions/api.QuotaExceededResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal error",
                         "schema": {
@@ -142,48 +192,89 @@ const docTemplate = `{
                 }
             }
         },
-        "/quotes/{update_id}": {
+        "/admin/queue/tasks": {
             "get": {
-                "description": "Retrieves the status and result of a quote update request by its update_id. Returns price and timestamp when status is SUCCESS.",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Lists the pending, scheduled, retry and archived Asynq tasks whose payload is an update of the pair, each with the status, origin and error code of its update record. With origin or error_code set, only tasks whose record has that origin or error code are listed. Tasks being processed are not listed. Requires the admin scope.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "quotes"
+                    "admin"
                 ],
-                "summary": "Get quote update status and result by ID",
+                "summary": "List queued update tasks for a pair",
                 "parameters": [
                     {
                         "type": "string",
-                        "format": "uuid",
-                        "description": "Update ID (UUID)",
-                        "name": "update_id",
-                        "in": "path",
+                        "example": "EUR/MXN",
+                        "description": "Currency pair",
+                        "name": "pair",
+                        "in": "query",
                         "required": true
+                    },
+                    {
+                        "enum": [
+                            "api",
+                            "scheduler",
+                            "auto_refresh",
+                            "backfill",
+                            "retry",
+                            "stream"
+                        ],
+                        "type": "string",
+                        "description": "Only tasks whose update record has this origin",
+                        "name": "origin",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "timeout",
+                            "rate_limited",
+                            "auth",
+                            "provider_config",
+                            "unsupported_pair",
+                            "provider_unavailable",
+                            "bad_response",
+                            "invalid_request",
+                            "task_lost",
+                            "internal"
+                        ],
+                        "type": "string",
+                        "description": "Only tasks whose update record FAILED with this error code",
+                        "name": "error_code",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Quote found",
+                        "description": "Queued tasks",
                         "schema": {
-                            "$ref": "#/definitions/api.QuoteResponse"
+                            "$ref": "#/definitions/api.PairTasksResponse"
                         }
                     },
                     "400": {
-                        "description": "Invalid update_id format",
+                        "description": "Invalid currency pair, origin or error code",
                         "schema": {
                             "$ref": "#/definitions/api.ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Unknown update_id",
+                    "401": {
+                        "description": "Missing or invalid API key (when auth is enabled)",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key lacks the admin scope",
                         "schema": {
                             "$ref": "#/definitions/api.ErrorResponse"
                         }
                     },
+                    "429": {
+                        "description": "Monthly quota of the API key used up (when quotas are enabled)",
+                        "schema": {
+                            "$ref": "#/definitions/api.QuotaExceededResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal error",
                         "schema": {
@@ -193,83 +284,1977 @@ const docTemplate = `{
                 }
             }
         },
-        "/readyz": {
+        "/admin/quotas": {
             "get": {
-                "description": "Checks connectivity to critical dependencies (Postgres, cache Redis, and asynq Redis). Returns 200 only when all dependencies are reachable.",
+                "description": "Returns each configured API key's request count in the current calendar month (UTC), its monthly limit (0 for unlimited) and when the window resets. Available when auth.quota_enabled is set. Requires the admin scope.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "health"
+                    "admin"
                 ],
-                "summary": "Readiness check",
+                "summary": "List API key quota usage",
                 "responses": {
                     "200": {
-                        "description": "All dependencies ready",
+                        "description": "Usage per API key, sorted by name",
                         "schema": {
-                            "$ref": "#/definitions/api.ReadyResponse"
+                            "$ref": "#/definitions/api.QuotasResponse"
                         }
                     },
-                    "503": {
-                        "description": "At least one dependency unavailable",
+                    "401": {
+                        "description": "Missing or invalid API key (when auth is enabled)",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key lacks the admin scope",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Monthly quota of the API key used up (when quotas are enabled)",
+                        "schema": {
+                            "$ref": "#/definitions/api.QuotaExceededResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal error",
                         "schema": {
                             "$ref": "#/definitions/api.ErrorResponse"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "api.ErrorResponse": {
-            "type": "object",
-            "properties": {
-                "error": {
-                    "type": "string",
-                    "example": "Invalid currency code format"
+        },
+        "/admin/reconcile": {
+            "post": {
+                "description": "Looks up the task of every PENDING update older than reconcile.grace_sec (up to reconcile.batch_size, oldest first) in the update queues. Updates without a task are requeued, or marked FAILED if they are older than reconcile.give_up_sec. The same check runs at startup when reconcile.enabled is set. Requires the admin scope.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Requeue PENDING updates whose task was lost",
+                "responses": {
+                    "200": {
+                        "description": "Reconciliation summary",
+                        "schema": {
+                            "$ref": "#/definitions/api.ReconcileResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid API key (when auth is enabled)",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key lacks the admin scope",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Monthly quota of the API key used up (when quotas are enabled)",
+                        "schema": {
+                            "$ref": "#/definitions/api.QuotaExceededResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
                 }
             }
         },
-        "api.LatestResponse": {
-            "type": "object",
-            "properties": {
-                "base": {
-                    "type": "string",
-                    "example": "EUR"
-                },
-                "price": {
-                    "type": "string",
-                    "example": "18.7543"
-                },
-                "quote": {
-                    "type": "string",
-                    "example": "MXN"
-                },
-                "updated_at": {
-                    "type": "string",
-                    "example": "2025-12-01T10:15:30Z"
+        "/admin/reports/reliability": {
+            "get": {
+                "description": "Counts the updates that reached SUCCESS or FAILED in [from, to), archived ones included, per provider and pair, with the failure rate and mean fetch duration. Rows are sorted by provider, base and quote and streamed as they are read. With Accept: text/csv the report is a CSV file with a header row; whether it was truncated is then sent in the X-Report-Truncated trailer. Requires the admin scope.",
+                "produces": [
+                    "application/json",
+                    "text/csv"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Report provider reliability per pair",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "2025-12-01",
+                        "description": "Start of the range, inclusive: RFC3339 timestamp or YYYY-MM-DD date",
+                        "name": "from",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "2025-12-08",
+                        "description": "End of the range, exclusive: RFC3339 timestamp or YYYY-MM-DD date",
+                        "name": "to",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "maximum": 10000,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 1000,
+                        "description": "Maximum number of rows",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Report rows",
+                        "schema": {
+                            "$ref": "#/definitions/api.ReliabilityReportResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid from, to or limit",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid API key (when auth is enabled)",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key lacks the admin scope",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Monthly quota of the API key used up (when quotas are enabled)",
+                        "schema": {
+                            "$ref": "#/definitions/api.QuotaExceededResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Database schema not ready",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
                 }
             }
         },
-        "api.QuoteResponse": {
-            "type": "object",
-            "properties": {
-                "base": {
-                    "type": "string",
-                    "example": "EUR"
-                },
-                "error": {
-                    "type": "string",
-                    "example": "Failed to fetch from provider"
-                },
-                "price": {
-                    "type": "string",
-                    "example": "18.7543"
-                },
-                "quote": {
-                    "type": "string",
+        "/admin/selfcheck": {
+            "get": {
+                "description": "Runs every step at once and reports the latency and error of each: postgres_write inserts a throwaway quote record of the reserved pair XTS/XXX and reads it back in a transaction that is rolled back, redis_cache writes, reads and deletes a temporary key, and redis_asynq schedules a no-op task and deletes it. Steps are independent, so a failing one does not hide the others. The whole check is bounded by a few seconds; a step still running then is reported as failed. Requires the admin scope.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Run a synthetic end-to-end check",
+                "responses": {
+                    "200": {
+                        "description": "Every step passed",
+                        "schema": {
+                            "$ref": "#/definitions/api.SelfCheckResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid API key (when auth is enabled)",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key lacks the admin scope",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Monthly quota of the API key used up (when quotas are enabled)",
+                        "schema": {
+                            "$ref": "#/definitions/api.QuotaExceededResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "At least one step failed or timed out",
+                        "schema": {
+                            "$ref": "#/definitions/api.SelfCheckResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/worker-config": {
+            "patch": {
+                "description": "Drains in-flight tasks, restarts the worker pool with the new settings and persists them so they survive restarts. Only available when worker.allow_runtime_tuning is enabled; requires the admin scope.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Change worker concurrency and task timeout at runtime",
+                "parameters": [
+                    {
+                        "description": "Settings to change",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.WorkerConfigRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Settings in effect",
+                        "schema": {
+                            "$ref": "#/definitions/api.WorkerConfigResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid settings",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid API key (when auth is enabled)",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key lacks the admin scope",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Monthly quota of the API key used up (when quotas are enabled)",
+                        "schema": {
+                            "$ref": "#/definitions/api.QuotaExceededResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/worker/drain": {
+            "post": {
+                "description": "Lets in-flight tasks finish but fetches no new ones, and makes /readyz report the instance not ready so the orchestrator stops routing to it. The process keeps running; the state is not persisted and is cleared by a restart. Draining a drained worker does nothing. Requires the admin scope.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Stop the worker from fetching new tasks",
+                "responses": {
+                    "200": {
+                        "description": "Worker drained",
+                        "schema": {
+                            "$ref": "#/definitions/api.WorkerStateResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid API key (when auth is enabled)",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key lacks the admin scope",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Monthly quota of the API key used up (when quotas are enabled)",
+                        "schema": {
+                            "$ref": "#/definitions/api.QuotaExceededResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/worker/resume": {
+            "post": {
+                "description": "Waits for tasks still running since the drain, restarts the worker and makes the instance ready again. Resuming a worker that is not drained does nothing. Requires the admin scope.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Resume fetching tasks after a drain",
+                "responses": {
+                    "200": {
+                        "description": "Worker fetching tasks",
+                        "schema": {
+                            "$ref": "#/definitions/api.WorkerStateResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid API key (when auth is enabled)",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key lacks the admin scope",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Monthly quota of the API key used up (when quotas are enabled)",
+                        "schema": {
+                            "$ref": "#/definitions/api.QuotaExceededResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/currencies": {
+            "get": {
+                "description": "Returns every supported currency with its name, symbol and conventional number of decimal places, sorted by code.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "currencies"
+                ],
+                "summary": "List supported currencies",
+                "responses": {
+                    "200": {
+                        "description": "Supported currencies",
+                        "schema": {
+                            "$ref": "#/definitions/api.CurrenciesResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid API key (when auth is enabled)",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key lacks the read scope",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Monthly quota of the API key used up (when quotas are enabled)",
+                        "schema": {
+                            "$ref": "#/definitions/api.QuotaExceededResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/currencies/{code}": {
+            "get": {
+                "description": "Returns the name, symbol and conventional number of decimal places of a supported currency.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "currencies"
+                ],
+                "summary": "Get currency metadata",
+                "parameters": [
+                    {
+                        "maxLength": 3,
+                        "minLength": 3,
+                        "type": "string",
+                        "description": "Currency code (3 letters)",
+                        "name": "code",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Currency metadata",
+                        "schema": {
+                            "$ref": "#/definitions/api.CurrencyResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid currency code format",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid API key (when auth is enabled)",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key lacks the read scope",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Currency is not supported",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Monthly quota of the API key used up (when quotas are enabled)",
+                        "schema": {
+                            "$ref": "#/definitions/api.QuotaExceededResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/healthz": {
+            "get": {
+                "description": "Always returns 200 OK if the service is running. Used for liveness probes.",
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Health check (liveness)",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/healthz/details": {
+            "get": {
+                "description": "Returns the instance's lifecycle phase (starting until the HTTP listener is bound, then serving, draining once shutdown begins, stopped) and the lifecycle events emitted so far, which are also logged as \"Lifecycle event\" lines. Like /healthz it answers 200 while the process runs.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Lifecycle phase",
+                "responses": {
+                    "200": {
+                        "description": "Lifecycle phase and events",
+                        "schema": {
+                            "$ref": "#/definitions/api.HealthDetailsResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/quotes/compare": {
+            "get": {
+                "description": "Looks up the quote that was current at each of two points in time, like GET /quotes/history/at, and returns both with the change between them. change is the vs price minus the at price, exact to the more precise of the two; change_pct is the change relative to the at price in percent, rounded to 4 decimals. The as_of fields are the update times of the quotes used. at and vs are RFC3339 timestamps or YYYY-MM-DD dates, which mean midnight UTC; at must be before vs and neither may be in the future.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "quotes"
+                ],
+                "summary": "Compare a pair's quotes at two points in time",
+                "parameters": [
+                    {
+                        "maxLength": 3,
+                        "minLength": 3,
+                        "type": "string",
+                        "description": "Base currency code (3 letters)",
+                        "name": "base",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "maxLength": 3,
+                        "minLength": 3,
+                        "type": "string",
+                        "description": "Quote currency code (3 letters)",
+                        "name": "quote",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Earlier point in time (RFC3339 or YYYY-MM-DD)",
+                        "name": "at",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Later point in time (RFC3339 or YYYY-MM-DD)",
+                        "name": "vs",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Quotes at both points in time",
+                        "schema": {
+                            "$ref": "#/definitions/api.CompareResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid currency code or timestamp, at not before vs, or a point in the future",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid API key (when auth is enabled)",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key lacks the read scope or is not permitted to access the pair",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "No quote recorded before at, vs or both; missing names them",
+                        "schema": {
+                            "$ref": "#/definitions/api.CompareNotFoundResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Monthly quota of the API key used up (when quotas are enabled)",
+                        "schema": {
+                            "$ref": "#/definitions/api.QuotaExceededResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Database schema not ready",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/quotes/fetch": {
+            "post": {
+                "description": "Requests an update for a currency pair like POST /quotes/update and waits up to timeout_ms for it to finish. If it finishes in time, its result is returned with 200, whether it succeeded or failed. Otherwise the update keeps running and 202 returns its update_id, to be polled with GET /quotes/{update_id}. timeout_ms is capped a little below the server's write timeout, so the response is always written.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "quotes"
+                ],
+                "summary": "Fetch a quote synchronously with a deadline",
+                "parameters": [
+                    {
+                        "description": "Currency pair in format XXX/YYY and how long to wait",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.FetchRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Update finished in time",
+                        "schema": {
+                            "$ref": "#/definitions/api.QuoteResponse"
+                        },
+                        "headers": {
+                            "X-Quote-Signature": {
+                                "type": "string",
+                                "description": "Hex HMAC-SHA256 of base|quote|price|updated_at, empty for omitted fields; only sent when signing is enabled"
+                            },
+                            "X-Quote-Signature-Key-Id": {
+                                "type": "string",
+                                "description": "Id of the key that produced X-Quote-Signature"
+                            }
+                        }
+                    },
+                    "202": {
+                        "description": "Update still running; poll it by update_id",
+                        "schema": {
+                            "$ref": "#/definitions/api.UpdateResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid JSON, currency code format, unsupported currency or timeout_ms",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid API key (when auth is enabled)",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key lacks the write scope or is not permitted to access the pair",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Monthly quota of the API key used up (when quotas are enabled)",
+                        "schema": {
+                            "$ref": "#/definitions/api.QuotaExceededResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Task queue unavailable, or worker.max_pending updates are already PENDING (retry after the Retry-After header), or database schema not ready",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        },
+                        "headers": {
+                            "Retry-After": {
+                                "type": "integer",
+                                "description": "Seconds to wait before retrying"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/quotes/history/at": {
+            "get": {
+                "description": "Returns the most recent successful quote for the pair whose update time is at or before the given timestamp. The as_of field is the update time of the returned record, not the queried time.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "quotes"
+                ],
+                "summary": "Get the quote that was current at a point in time",
+                "parameters": [
+                    {
+                        "maxLength": 3,
+                        "minLength": 3,
+                        "type": "string",
+                        "description": "Base currency code (3 letters)",
+                        "name": "base",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "maxLength": 3,
+                        "minLength": 3,
+                        "type": "string",
+                        "description": "Quote currency code (3 letters)",
+                        "name": "quote",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "format": "date-time",
+                        "description": "Point in time (RFC3339)",
+                        "name": "at",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Quote current at the given time",
+                        "schema": {
+                            "$ref": "#/definitions/api.HistoricalResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid currency code or timestamp",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid API key (when auth is enabled)",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key lacks the read scope or is not permitted to access the pair",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "No quote recorded before the given time",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Monthly quota of the API key used up (when quotas are enabled)",
+                        "schema": {
+                            "$ref": "#/definitions/api.QuotaExceededResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Database schema not ready",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/quotes/latest": {
+            "get": {
+                "description": "Returns the most recent successful quote for the given currency pair. Does NOT trigger a new fetch - only returns cached/stored data. update_id names the update that stored the price, whether it is served from the cache or the database, for looking it up with GET /quotes/{update_id}. With include_last_attempt=true a 404 also reports the status, error and time of the pair's most recent update, if there was one. HEAD runs the same lookup and answers with the same status and headers but no body; include_last_attempt is ignored. Last-Modified is the quote's updated_at, and an If-Modified-Since at or after it yields 304 without a body. X-Quote-Source tells whether the quote was read from the Redis cache (redis) or the database (db), unless api.quote_source_header is off. derived is \"inverse\" when the price was not fetched but computed as the reciprocal of the inverse pair's cached rate (cache.inverse_enabled); such a price has no update_id.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "quotes"
+                ],
+                "summary": "Get latest quote for a currency pair",
+                "parameters": [
+                    {
+                        "maxLength": 3,
+                        "minLength": 3,
+                        "type": "string",
+                        "description": "Base currency code (3 letters)",
+                        "name": "base",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "maxLength": 3,
+                        "minLength": 3,
+                        "type": "string",
+                        "description": "Quote currency code (3 letters)",
+                        "name": "quote",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "On 404, describe the pair's most recent update",
+                        "name": "include_last_attempt",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "HTTP date; 304 if the quote was not updated after it",
+                        "name": "If-Modified-Since",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Latest quote found",
+                        "schema": {
+                            "$ref": "#/definitions/api.LatestResponse"
+                        },
+                        "headers": {
+                            "Cache-Control": {
+                                "type": "string",
+                                "description": "no-cache: revalidate before reuse"
+                            },
+                            "ETag": {
+                                "type": "string",
+                                "description": "Opaque validator of the quote"
+                            },
+                            "Last-Modified": {
+                                "type": "string",
+                                "description": "The quote's updated_at as an HTTP date"
+                            },
+                            "X-Quote-Signature": {
+                                "type": "string",
+                                "description": "Hex HMAC-SHA256 of base|quote|price|updated_at; only sent when signing is enabled"
+                            },
+                            "X-Quote-Signature-Key-Id": {
+                                "type": "string",
+                                "description": "Id of the key that produced X-Quote-Signature"
+                            },
+                            "X-Quote-Source": {
+                                "type": "string",
+                                "description": "Tier the quote was read from: redis or db; only sent when api.quote_source_header is on"
+                            }
+                        }
+                    },
+                    "304": {
+                        "description": "Quote not updated since If-Modified-Since"
+                    },
+                    "400": {
+                        "description": "Invalid currency code format or unsupported currency",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid API key (when auth is enabled)",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key lacks the read scope or is not permitted to access the pair",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "No quote available for the given pair",
+                        "schema": {
+                            "$ref": "#/definitions/api.LatestNotFoundResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Monthly quota of the API key used up (when quotas are enabled)",
+                        "schema": {
+                            "$ref": "#/definitions/api.QuotaExceededResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Database schema not ready",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "head": {
+                "description": "Returns the most recent successful quote for the given currency pair. Does NOT trigger a new fetch - only returns cached/stored data. update_id names the update that stored the price, whether it is served from the cache or the database, for looking it up with GET /quotes/{update_id}. With include_last_attempt=true a 404 also reports the status, error and time of the pair's most recent update, if there was one. HEAD runs the same lookup and answers with the same status and headers but no body; include_last_attempt is ignored. Last-Modified is the quote's updated_at, and an If-Modified-Since at or after it yields 304 without a body. X-Quote-Source tells whether the quote was read from the Redis cache (redis) or the database (db), unless api.quote_source_header is off. derived is \"inverse\" when the price was not fetched but computed as the reciprocal of the inverse pair's cached rate (cache.inverse_enabled); such a price has no update_id.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "quotes"
+                ],
+                "summary": "Get latest quote for a currency pair",
+                "parameters": [
+                    {
+                        "maxLength": 3,
+                        "minLength": 3,
+                        "type": "string",
+                        "description": "Base currency code (3 letters)",
+                        "name": "base",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "maxLength": 3,
+                        "minLength": 3,
+                        "type": "string",
+                        "description": "Quote currency code (3 letters)",
+                        "name": "quote",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "On 404, describe the pair's most recent update",
+                        "name": "include_last_attempt",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "HTTP date; 304 if the quote was not updated after it",
+                        "name": "If-Modified-Since",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Latest quote found",
+                        "schema": {
+                            "$ref": "#/definitions/api.LatestResponse"
+                        },
+                        "headers": {
+                            "Cache-Control": {
+                                "type": "string",
+                                "description": "no-cache: revalidate before reuse"
+                            },
+                            "ETag": {
+                                "type": "string",
+                                "description": "Opaque validator of the quote"
+                            },
+                            "Last-Modified": {
+                                "type": "string",
+                                "description": "The quote's updated_at as an HTTP date"
+                            },
+                            "X-Quote-Signature": {
+                                "type": "string",
+                                "description": "Hex HMAC-SHA256 of base|quote|price|updated_at; only sent when signing is enabled"
+                            },
+                            "X-Quote-Signature-Key-Id": {
+                                "type": "string",
+                                "description": "Id of the key that produced X-Quote-Signature"
+                            },
+                            "X-Quote-Source": {
+                                "type": "string",
+                                "description": "Tier the quote was read from: redis or db; only sent when api.quote_source_header is on"
+                            }
+                        }
+                    },
+                    "304": {
+                        "description": "Quote not updated since If-Modified-Since"
+                    },
+                    "400": {
+                        "description": "Invalid currency code format or unsupported currency",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid API key (when auth is enabled)",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key lacks the read scope or is not permitted to access the pair",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "No quote available for the given pair",
+                        "schema": {
+                            "$ref": "#/definitions/api.LatestNotFoundResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Monthly quota of the API key used up (when quotas are enabled)",
+                        "schema": {
+                            "$ref": "#/definitions/api.QuotaExceededResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Database schema not ready",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/quotes/latest/defaults": {
+            "get": {
+                "description": "Returns the most recent successful quote of every pair in api.default_pairs, in the configured order, reading the cache for all of them at once and the DB only for pairs the cache knows nothing about. Pairs without a successful quote are marked not_found; pairs the API key may not access are left out. derived is \"inverse\" for a price computed from the inverse pair's cached rate. Does NOT trigger a new fetch.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "quotes"
+                ],
+                "summary": "Get latest quotes for the default pairs",
+                "responses": {
+                    "200": {
+                        "description": "Latest quotes of the default pairs",
+                        "schema": {
+                            "$ref": "#/definitions/api.DefaultLatestResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid API key (when auth is enabled)",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key lacks the read scope",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Monthly quota of the API key used up (when quotas are enabled)",
+                        "schema": {
+                            "$ref": "#/definitions/api.QuotaExceededResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Database schema not ready",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/quotes/stream": {
+            "get": {
+                "description": "Opens a Server-Sent Events stream. An \"update\" event is pushed whenever a new rate for the pair is stored, a \"heartbeat\" event is sent periodically to keep the connection alive, and a \"done\" event is sent before the server closes the stream. Each event's data is a QuoteEventResponse.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "quotes"
+                ],
+                "summary": "Stream quote updates for a currency pair",
+                "parameters": [
+                    {
+                        "maxLength": 3,
+                        "minLength": 3,
+                        "type": "string",
+                        "description": "Base currency code (3 letters)",
+                        "name": "base",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "maxLength": 3,
+                        "minLength": 3,
+                        "type": "string",
+                        "description": "Quote currency code (3 letters)",
+                        "name": "quote",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Event stream",
+                        "schema": {
+                            "$ref": "#/definitions/api.QuoteEventResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid currency code format or unsupported currency",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid API key (when auth is enabled)",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key lacks the read scope or is not permitted to access the pair",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Monthly quota of the API key used up (when quotas are enabled)",
+                        "schema": {
+                            "$ref": "#/definitions/api.QuotaExceededResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Database schema not ready",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/quotes/update": {
+            "post": {
+                "description": "Initiates an asynchronous update for a currency pair. Returns immediately with an update_id for tracking. Does not block on external fetch. If an update for the pair is already in flight, or the pair's latest successful update is within its refresh cooldown, that update's id is returned and reason says which. An optional provider fetches the rate from that provider alone and skips the refresh cooldown; it requires the admin scope when API key auth is enabled. While the update is unfinished, poll_after_ms suggests when to poll its result.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "quotes"
+                ],
+                "summary": "Request asynchronous quote update",
+                "parameters": [
+                    {
+                        "description": "Currency pair in format XXX/YYY and optional provider",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.UpdateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Update request accepted",
+                        "schema": {
+                            "$ref": "#/definitions/api.UpdateResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid currency code format, unsupported currency or unknown provider; valid_providers is only set for an unknown provider",
+                        "schema": {
+                            "$ref": "#/definitions/api.UnknownProviderResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid API key (when auth is enabled)",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key lacks the write scope, provider override without the admin scope, or pair not permitted for the API key",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Monthly quota of the API key used up (when quotas are enabled)",
+                        "schema": {
+                            "$ref": "#/definitions/api.QuotaExceededResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Task queue unavailable, or worker.max_pending updates are already PENDING (retry after the Retry-After header), or database schema not ready",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        },
+                        "headers": {
+                            "Retry-After": {
+                                "type": "integer",
+                                "description": "Seconds to wait before retrying"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/quotes/{update_id}": {
+            "get": {
+                "description": "Retrieves the status and result of a quote update request by its update_id. Returns price and timestamp when status is SUCCESS. With include_events=true the response also lists when the update entered each status and the service instance that processed it. With include_verification=true a SUCCESS response includes the spread of the rates reported by all providers, if verification is enabled.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "quotes"
+                ],
+                "summary": "Get quote update status and result by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Update ID (UUID)",
+                        "name": "update_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Include the status timeline",
+                        "name": "include_events",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Include the spread across providers",
+                        "name": "include_verification",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Quote found",
+                        "schema": {
+                            "$ref": "#/definitions/api.QuoteResponse"
+                        },
+                        "headers": {
+                            "Retry-After": {
+                                "type": "integer",
+                                "description": "Seconds to wait before polling again; only sent while the update is PENDING or RUNNING"
+                            },
+                            "X-Quote-Signature": {
+                                "type": "string",
+                                "description": "Hex HMAC-SHA256 of base|quote|price|updated_at, empty for omitted fields; only sent when signing is enabled"
+                            },
+                            "X-Quote-Signature-Key-Id": {
+                                "type": "string",
+                                "description": "Id of the key that produced X-Quote-Signature"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid update_id format",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid API key (when auth is enabled)",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key lacks the read scope",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Unknown update_id, or an update for a pair not permitted for the API key",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Monthly quota of the API key used up (when quotas are enabled)",
+                        "schema": {
+                            "$ref": "#/definitions/api.QuotaExceededResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Database schema not ready",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/readyz": {
+            "get": {
+                "description": "Runs every readiness check (Postgres, cache Redis, asynq Redis, whether the worker is drained, the quote notification listener and, when required, cache warmup) and reports per-component status and latency. Returns 503 if any critical check fails. If only optional components (the cache Redis, the quote notification listener) fail, returns 200 with status \"degraded\". With deep=true it also verifies that all migrations are applied and the quotes table is readable, and that the asynq queues can be listed; deep results are cached for a few seconds.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Readiness check",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "Also run deep checks (schema and queue)",
+                        "name": "deep",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Ready, or degraded with only optional components failing",
+                        "schema": {
+                            "$ref": "#/definitions/api.ReadyResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "At least one critical component unavailable, cache warming up or worker drained",
+                        "schema": {
+                            "$ref": "#/definitions/api.ReadyResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "api.ArchivedTasksResponse": {
+            "type": "object",
+            "properties": {
+                "next_cursor": {
+                    "type": "string",
+                    "example": "100"
+                },
+                "tasks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.QueuedTaskResponse"
+                    }
+                }
+            }
+        },
+        "api.CompareNotFoundResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer",
+                    "example": 4041
+                },
+                "error": {
+                    "type": "string",
+                    "example": "No quote available for EUR/MXN at or before at=2025-01-02"
+                },
+                "missing": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "at"
+                    ]
+                }
+            }
+        },
+        "api.CompareResponse": {
+            "type": "object",
+            "properties": {
+                "at": {
+                    "$ref": "#/definitions/api.ComparisonPoint"
+                },
+                "base": {
+                    "type": "string",
+                    "example": "EUR"
+                },
+                "change": {
+                    "type": "string",
+                    "example": "-0.8302"
+                },
+                "change_pct": {
+                    "type": "string",
+                    "example": "-4.0013"
+                },
+                "quote": {
+                    "type": "string",
+                    "example": "MXN"
+                },
+                "vs": {
+                    "$ref": "#/definitions/api.ComparisonPoint"
+                }
+            }
+        },
+        "api.ComparisonPoint": {
+            "type": "object",
+            "properties": {
+                "as_of": {
+                    "type": "string",
+                    "example": "2025-01-01T21:04:12Z"
+                },
+                "price": {
+                    "type": "string",
+                    "example": "20.7481"
+                }
+            }
+        },
+        "api.ComponentStatus": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string",
+                    "example": "connection refused"
+                },
+                "latency_ms": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "ok",
+                        "error"
+                    ],
+                    "example": "ok"
+                }
+            }
+        },
+        "api.CurrenciesResponse": {
+            "type": "object",
+            "properties": {
+                "currencies": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.CurrencyResponse"
+                    }
+                }
+            }
+        },
+        "api.CurrencyResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "JPY"
+                },
+                "decimal_digits": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Japanese Yen"
+                },
+                "symbol": {
+                    "type": "string",
+                    "example": "¥"
+                }
+            }
+        },
+        "api.DefaultLatestEntry": {
+            "type": "object",
+            "properties": {
+                "base": {
+                    "type": "string",
+                    "example": "EUR"
+                },
+                "derived": {
+                    "type": "string",
+                    "example": "inverse"
+                },
+                "not_found": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "price": {
+                    "type": "string",
+                    "example": "18.7543"
+                },
+                "quote": {
+                    "type": "string",
+                    "example": "MXN"
+                },
+                "rate_timestamp": {
+                    "type": "string",
+                    "example": "2025-12-01T00:00:00Z"
+                },
+                "updated_at": {
+                    "type": "string",
+                    "example": "2025-12-01T10:15:30Z"
+                }
+            }
+        },
+        "api.DefaultLatestResponse": {
+            "type": "object",
+            "properties": {
+                "quotes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.DefaultLatestEntry"
+                    }
+                }
+            }
+        },
+        "api.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer",
+                    "example": 4001
+                },
+                "error": {
+                    "type": "string",
+                    "example": "Invalid currency code format"
+                }
+            }
+        },
+        "api.FetchRequest": {
+            "type": "object",
+            "properties": {
+                "pair": {
+                    "type": "string",
+                    "example": "EUR/MXN"
+                },
+                "timeout_ms": {
+                    "description": "TimeoutMs is how long to wait for the update to finish; longer waits are capped\nbelow the server's write timeout.",
+                    "type": "integer",
+                    "example": 3000
+                }
+            }
+        },
+        "api.HealthDetailsResponse": {
+            "type": "object",
+            "properties": {
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.LifecycleEventResponse"
+                    }
+                },
+                "phase": {
+                    "type": "string",
+                    "enum": [
+                        "starting",
+                        "serving",
+                        "draining",
+                        "stopped"
+                    ],
+                    "example": "serving"
+                },
+                "phase_since": {
+                    "type": "string",
+                    "example": "2025-12-01T10:15:30Z"
+                }
+            }
+        },
+        "api.HistoricalResponse": {
+            "type": "object",
+            "properties": {
+                "as_of": {
+                    "type": "string",
+                    "example": "2024-06-15T11:58:02Z"
+                },
+                "base": {
+                    "type": "string",
+                    "example": "EUR"
+                },
+                "price": {
+                    "type": "string",
+                    "example": "1.0850"
+                },
+                "quote": {
+                    "type": "string",
+                    "example": "USD"
+                }
+            }
+        },
+        "api.LatestNotFoundResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer",
+                    "example": 4041
+                },
+                "error": {
+                    "type": "string",
+                    "example": "No quote available for EUR/MXN"
+                },
+                "last_attempt_at": {
+                    "type": "string",
+                    "example": "2025-12-01T09:12:04Z"
+                },
+                "last_error": {
+                    "type": "string",
+                    "example": "unsupported currency pair"
+                },
+                "last_status": {
+                    "type": "string",
+                    "example": "FAILED"
+                }
+            }
+        },
+        "api.LatestResponse": {
+            "type": "object",
+            "properties": {
+                "base": {
+                    "type": "string",
+                    "example": "EUR"
+                },
+                "derived": {
+                    "description": "Derived is \"inverse\" when the price was computed from the inverse pair's cached\nrate instead of fetched; omitted for fetched prices.",
+                    "type": "string",
+                    "example": "inverse"
+                },
+                "price": {
+                    "type": "string",
+                    "example": "18.7543"
+                },
+                "quote": {
+                    "type": "string",
+                    "example": "MXN"
+                },
+                "rate_timestamp": {
+                    "type": "string",
+                    "example": "2025-12-01T00:00:00Z"
+                },
+                "update_id": {
+                    "description": "UpdateID is the update that stored the price, for GET /quotes/{update_id}; omitted\nfor a streamed price that arrived while an update of the pair was in flight.",
+                    "type": "string",
+                    "example": "123e4567-e89b-12d3-a456-426614174000"
+                },
+                "updated_at": {
+                    "type": "string",
+                    "example": "2025-12-01T10:15:30Z"
+                }
+            }
+        },
+        "api.LifecycleEventResponse": {
+            "type": "object",
+            "properties": {
+                "at": {
+                    "type": "string",
+                    "example": "2025-12-01T10:15:30Z"
+                },
+                "event": {
+                    "type": "string",
+                    "example": "http_listening"
+                }
+            }
+        },
+        "api.PairTasksResponse": {
+            "type": "object",
+            "properties": {
+                "pair": {
+                    "type": "string",
+                    "example": "EUR/MXN"
+                },
+                "tasks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.QueuedTaskResponse"
+                    }
+                }
+            }
+        },
+        "api.ProviderScoreResponse": {
+            "type": "object",
+            "properties": {
+                "calls": {
+                    "type": "integer",
+                    "example": 50
+                },
+                "name": {
+                    "type": "string",
+                    "example": "frankfurter"
+                },
+                "p50_latency_ms": {
+                    "type": "integer",
+                    "example": 180
+                },
+                "score": {
+                    "type": "number",
+                    "example": 0.81
+                },
+                "success_rate": {
+                    "description": "Smoothed: (successes+1) / (calls+2).",
+                    "type": "number",
+                    "example": 0.96
+                }
+            }
+        },
+        "api.ProviderScoresResponse": {
+            "type": "object",
+            "properties": {
+                "providers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.ProviderScoreResponse"
+                    }
+                },
+                "strategy": {
+                    "type": "string",
+                    "enum": [
+                        "fixed",
+                        "adaptive"
+                    ],
+                    "example": "adaptive"
+                }
+            }
+        },
+        "api.QueuedTaskResponse": {
+            "type": "object",
+            "properties": {
+                "last_error": {
+                    "type": "string",
+                    "example": "provider timeout"
+                },
+                "last_failed_at": {
+                    "type": "string",
+                    "example": "2025-12-01T10:15:30Z"
+                },
+                "max_retry": {
+                    "type": "integer",
+                    "example": 5
+                },
+                "next_retry_at": {
+                    "type": "string",
+                    "example": "2025-12-01T10:16:00Z"
+                },
+                "provider": {
+                    "type": "string",
+                    "example": "frankfurter"
+                },
+                "queue": {
+                    "type": "string",
+                    "example": "default"
+                },
+                "record_error_code": {
+                    "description": "Error code of a FAILED update record.",
+                    "type": "string",
+                    "example": "timeout"
+                },
+                "record_origin": {
+                    "type": "string",
+                    "example": "api"
+                },
+                "record_status": {
+                    "description": "Status and origin of the update record, omitted if the record no longer exists.",
+                    "type": "string",
+                    "example": "RUNNING"
+                },
+                "retried": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "state": {
+                    "type": "string",
+                    "enum": [
+                        "pending",
+                        "scheduled",
+                        "retry",
+                        "archived"
+                    ],
+                    "example": "retry"
+                },
+                "task_id": {
+                    "type": "string",
+                    "example": "6f1c2d4e-5a6b-4c7d-8e9f-0a1b2c3d4e5f"
+                },
+                "update_id": {
+                    "type": "string",
+                    "example": "550e8400-e29b-41d4-a716-446655440000"
+                }
+            }
+        },
+        "api.QuotaExceededResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer",
+                    "example": 4291
+                },
+                "error": {
+                    "type": "string",
+                    "example": "monthly quota exceeded"
+                },
+                "limit": {
+                    "type": "integer",
+                    "example": 100000
+                },
+                "reset_at": {
+                    "type": "string",
+                    "example": "2025-12-01T00:00:00Z"
+                }
+            }
+        },
+        "api.QuotaUsageResponse": {
+            "type": "object",
+            "properties": {
+                "key": {
+                    "type": "string",
+                    "example": "crypto-desk"
+                },
+                "limit": {
+                    "description": "0 means unlimited.",
+                    "type": "integer",
+                    "example": 100000
+                },
+                "remaining": {
+                    "description": "Omitted for unlimited keys.",
+                    "type": "integer",
+                    "example": 98766
+                },
+                "reset_at": {
+                    "type": "string",
+                    "example": "2025-12-01T00:00:00Z"
+                },
+                "used": {
+                    "type": "integer",
+                    "example": 1234
+                }
+            }
+        },
+        "api.QuotasResponse": {
+            "type": "object",
+            "properties": {
+                "quotas": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.QuotaUsageResponse"
+                    }
+                }
+            }
+        },
+        "api.QuoteEventResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/api.QuoteResponse"
+                },
+                "timestamp": {
+                    "type": "string",
+                    "example": "2025-12-01T10:15:30Z"
+                },
+                "type": {
+                    "type": "string",
+                    "enum": [
+                        "update",
+                        "heartbeat",
+                        "done"
+                    ],
+                    "example": "update"
+                }
+            }
+        },
+        "api.QuoteResponse": {
+            "type": "object",
+            "properties": {
+                "base": {
+                    "type": "string",
+                    "example": "EUR"
+                },
+                "error": {
+                    "type": "string",
+                    "example": "Failed to fetch from provider"
+                },
+                "error_code": {
+                    "description": "ErrorCode classifies Error; omitted for updates that failed before codes were recorded.",
+                    "type": "string",
+                    "enum": [
+                        "timeout",
+                        "rate_limited",
+                        "auth",
+                        "provider_config",
+                        "unsupported_pair",
+                        "provider_unavailable",
+                        "bad_response",
+                        "invalid_request",
+                        "task_lost",
+                        "internal"
+                    ],
+                    "example": "timeout"
+                },
+                "events": {
+                    "description": "Events is the status timeline, oldest first; only set with ?include_events=true.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.StatusEventResponse"
+                    }
+                },
+                "instance_id": {
+                    "description": "InstanceID is the service instance that last marked the update RUNNING; only set\nwith ?include_events=true, and omitted if it was not recorded.",
+                    "type": "string",
+                    "example": "quote-worker-7d9f-3fa2c1"
+                },
+                "origin": {
+                    "description": "Origin is the code path that created the update.",
+                    "type": "string",
+                    "enum": [
+                        "api",
+                        "scheduler",
+                        "auto_refresh",
+                        "backfill",
+                        "retry",
+                        "stream"
+                    ],
+                    "example": "api"
+                },
+                "price": {
+                    "type": "string",
+                    "example": "18.7543"
+                },
+                "quote": {
+                    "type": "string",
                     "example": "MXN"
                 },
+                "rate_timestamp": {
+                    "description": "RateTimestamp is when the provider observed the price; UpdatedAt is when it was stored.",
+                    "type": "string",
+                    "example": "2025-12-01T00:00:00Z"
+                },
                 "status": {
                     "type": "string",
                     "example": "SUCCESS"
@@ -281,35 +2266,322 @@ const docTemplate = `{
                 "updated_at": {
                     "type": "string",
                     "example": "2025-12-01T10:15:30Z"
+                },
+                "verification": {
+                    "description": "Verification is the spread across providers; only set with ?include_verification=true\nonce it has been recorded.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/api.VerificationResponse"
+                        }
+                    ]
                 }
             }
         },
         "api.ReadyResponse": {
             "type": "object",
             "properties": {
+                "components": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/api.ComponentStatus"
+                    }
+                },
                 "status": {
                     "type": "string",
+                    "enum": [
+                        "ready",
+                        "degraded"
+                    ],
                     "example": "ready"
                 }
             }
         },
+        "api.ReconcileResponse": {
+            "type": "object",
+            "properties": {
+                "checked": {
+                    "type": "integer",
+                    "example": 12
+                },
+                "errors": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "failed": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "queued": {
+                    "type": "integer",
+                    "example": 9
+                },
+                "requeued": {
+                    "type": "integer",
+                    "example": 2
+                }
+            }
+        },
+        "api.ReliabilityReportResponse": {
+            "type": "object",
+            "properties": {
+                "from": {
+                    "type": "string",
+                    "example": "2025-12-01T00:00:00Z"
+                },
+                "rows": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.ReliabilityRowResponse"
+                    }
+                },
+                "to": {
+                    "type": "string",
+                    "example": "2025-12-08T00:00:00Z"
+                },
+                "truncated": {
+                    "type": "boolean",
+                    "example": false
+                }
+            }
+        },
+        "api.ReliabilityRowResponse": {
+            "type": "object",
+            "properties": {
+                "avg_fetch_duration_ms": {
+                    "description": "Null when none of the updates recorded a fetch duration.",
+                    "type": "number",
+                    "example": 142.5
+                },
+                "base": {
+                    "type": "string",
+                    "example": "EUR"
+                },
+                "failed": {
+                    "type": "integer",
+                    "example": 20
+                },
+                "failure_rate": {
+                    "type": "number",
+                    "example": 0.0167
+                },
+                "provider": {
+                    "description": "Empty for updates without a recorded provider, such as streamed rates.",
+                    "type": "string",
+                    "example": "frankfurter"
+                },
+                "quote": {
+                    "type": "string",
+                    "example": "USD"
+                },
+                "success": {
+                    "type": "integer",
+                    "example": 1180
+                }
+            }
+        },
+        "api.SelfCheckResponse": {
+            "type": "object",
+            "properties": {
+                "duration_ms": {
+                    "type": "integer",
+                    "example": 14
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "ok",
+                        "error"
+                    ],
+                    "example": "ok"
+                },
+                "steps": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.SelfCheckStepResponse"
+                    }
+                }
+            }
+        },
+        "api.SelfCheckStepResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string",
+                    "example": "insert: connection refused"
+                },
+                "latency_ms": {
+                    "type": "integer",
+                    "example": 6
+                },
+                "name": {
+                    "type": "string",
+                    "example": "postgres_write"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "ok",
+                        "error"
+                    ],
+                    "example": "ok"
+                }
+            }
+        },
+        "api.StatusEventResponse": {
+            "type": "object",
+            "properties": {
+                "at": {
+                    "type": "string",
+                    "example": "2025-12-01T10:15:29Z"
+                },
+                "detail": {
+                    "type": "string",
+                    "example": "18.754300"
+                },
+                "instance_id": {
+                    "description": "InstanceID is the service instance that made a RUNNING transition.",
+                    "type": "string",
+                    "example": "quote-worker-7d9f-3fa2c1"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "RUNNING"
+                }
+            }
+        },
+        "api.UnknownProviderResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer",
+                    "example": 4003
+                },
+                "error": {
+                    "type": "string",
+                    "example": "unknown provider \"ecb\""
+                },
+                "valid_providers": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "exchangerate_host",
+                        "frankfurter"
+                    ]
+                }
+            }
+        },
+        "api.UpdateInFlightResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer",
+                    "example": 4091
+                },
+                "error": {
+                    "type": "string",
+                    "example": "another update of the pair is in flight"
+                },
+                "update_id": {
+                    "type": "string",
+                    "example": "123e4567-e89b-12d3-a456-426614174000"
+                }
+            }
+        },
         "api.UpdateRequest": {
             "type": "object",
             "properties": {
                 "pair": {
                     "type": "string",
                     "example": "EUR/MXN"
+                },
+                "provider": {
+                    "description": "Provider, if set, forces the update through the named provider only. Requires\nthe admin scope when API key auth is enabled.",
+                    "type": "string",
+                    "example": "frankfurter"
                 }
             }
         },
         "api.UpdateResponse": {
             "type": "object",
             "properties": {
+                "cooldown_remaining_sec": {
+                    "description": "CooldownRemainingSec is how long until a new update can be started; only set with\nreason \"cooldown_active\".",
+                    "type": "integer",
+                    "example": 42
+                },
+                "poll_after_ms": {
+                    "description": "PollAfterMs is how long to wait before polling GET /quotes/{update_id}, estimated\nfrom the queue depth; only set while the update is unfinished.",
+                    "type": "integer",
+                    "example": 2000
+                },
+                "reason": {
+                    "description": "Reason is set when an existing update was returned instead of a new one:\n\"pending_exists\" or \"cooldown_active\".",
+                    "type": "string",
+                    "example": "cooldown_active"
+                },
                 "update_id": {
                     "type": "string",
                     "example": "123e4567-e89b-12d3-a456-426614174000"
                 }
             }
+        },
+        "api.VerificationResponse": {
+            "type": "object",
+            "properties": {
+                "max_price": {
+                    "type": "string",
+                    "example": "18.7612"
+                },
+                "min_price": {
+                    "type": "string",
+                    "example": "18.7401"
+                },
+                "providers": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "spread_pct": {
+                    "type": "string",
+                    "example": "0.1126"
+                }
+            }
+        },
+        "api.WorkerConfigRequest": {
+            "type": "object",
+            "properties": {
+                "concurrency": {
+                    "type": "integer",
+                    "example": 5
+                },
+                "task_timeout_sec": {
+                    "type": "integer",
+                    "example": 60
+                }
+            }
+        },
+        "api.WorkerConfigResponse": {
+            "type": "object",
+            "properties": {
+                "concurrency": {
+                    "type": "integer",
+                    "example": 5
+                },
+                "task_timeout_sec": {
+                    "type": "integer",
+                    "example": 60
+                }
+            }
+        },
+        "api.WorkerStateResponse": {
+            "type": "object",
+            "properties": {
+                "drained": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
         }
     }
 }`
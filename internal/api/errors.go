@@ -0,0 +1,97 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"quoteservice/internal/service"
+)
+
+// DefaultQueueRetryAfter is the Retry-After hint (seconds) sent when the task queue
+// is unavailable or full and ErrorConfig.QueueRetryAfterSec is not set.
+const DefaultQueueRetryAfter = 5
+
+// DefaultProviderRetryAfter is the Retry-After hint (seconds) sent when no exchange
+// rate provider is available and ErrorConfig.ProviderRetryAfterSec is not set.
+const DefaultProviderRetryAfter = 30
+
+// ErrorConfig holds the settings handlers use when writing service errors. The zero
+// value sends the default Retry-After hints.
+type ErrorConfig struct {
+	// QueueRetryAfterSec is the Retry-After hint sent with 503 responses when the
+	// task queue is unavailable or full. Non-positive means DefaultQueueRetryAfter.
+	QueueRetryAfterSec int
+	// ProviderRetryAfterSec is the Retry-After hint sent with 503 responses when no
+	// exchange rate provider is available. Non-positive means DefaultProviderRetryAfter.
+	ProviderRetryAfterSec int
+}
+
+func (c ErrorConfig) queueRetryAfter() int {
+	if c.QueueRetryAfterSec <= 0 {
+		return DefaultQueueRetryAfter
+	}
+	return c.QueueRetryAfterSec
+}
+
+func (c ErrorConfig) providerRetryAfter() int {
+	if c.ProviderRetryAfterSec <= 0 {
+		return DefaultProviderRetryAfter
+	}
+	return c.ProviderRetryAfterSec
+}
+
+// writeServiceError maps a service error to an HTTP status and error body.
+// An *UnknownProviderError becomes 400 with an UnknownProviderResponse body, other
+// validation errors become 400 with the error message, ErrPairForbidden becomes 403,
+// ErrNotFound becomes 404 with notFoundMsg, ErrWebhookExists becomes 409, ErrInternalQueue and ErrQueueFull
+// become 503 with Retry-After, ErrProviderUnavailable becomes 503 with Retry-After and a
+// ProviderUnavailableResponse body, ErrSchemaNotReady becomes 503, and anything else is
+// a 500 without internal details. The body carries the matching errorToCode code, and
+// the Retry-After hints come from errs.
+func writeServiceError(w http.ResponseWriter, r *http.Request, errs ErrorConfig, err error, notFoundMsg string) {
+	status, body := serviceErrorResponse(w.Header(), errs, err, notFoundMsg)
+	writeJSON(w, r, status, body)
+}
+
+// serviceErrorResponse returns the status and body writeServiceError writes for err,
+// setting its headers, such as Retry-After, on h.
+func serviceErrorResponse(h http.Header, errs ErrorConfig, err error, notFoundMsg string) (int, any) {
+	code := errorToCode(err)
+	var unknownProvider *service.UnknownProviderError
+	switch {
+	case errors.As(err, &unknownProvider):
+		return http.StatusBadRequest, UnknownProviderResponse{
+			Error:          fmt.Sprintf("unknown provider %q", unknownProvider.Name),
+			Code:           code,
+			ValidProviders: unknownProvider.Valid,
+		}
+	case service.IsValidationError(err):
+		return http.StatusBadRequest, ErrorResponse{Error: err.Error(), Code: code}
+	case errors.Is(err, service.ErrPairForbidden):
+		return http.StatusForbidden, ErrorResponse{Error: "API key is not permitted to access this pair", Code: code}
+	case errors.Is(err, service.ErrNotFound):
+		return http.StatusNotFound, ErrorResponse{Error: notFoundMsg, Code: code}
+	case errors.Is(err, service.ErrWebhookExists):
+		return http.StatusConflict, ErrorResponse{Error: err.Error(), Code: code}
+	case errors.Is(err, service.ErrInternalQueue):
+		h.Set("Retry-After", strconv.Itoa(errs.queueRetryAfter()))
+		return http.StatusServiceUnavailable, ErrorResponse{Error: "Task queue unavailable, retry later", Code: code}
+	case errors.Is(err, service.ErrQueueFull):
+		h.Set("Retry-After", strconv.Itoa(errs.queueRetryAfter()))
+		return http.StatusServiceUnavailable, ErrorResponse{Error: "Task queue full, retry later", Code: code}
+	case errors.Is(err, service.ErrProviderUnavailable):
+		retryAfter := errs.providerRetryAfter()
+		h.Set("Retry-After", strconv.Itoa(retryAfter))
+		return http.StatusServiceUnavailable, ProviderUnavailableResponse{
+			Error:             "provider unavailable",
+			Code:              code,
+			RetryAfterSeconds: retryAfter,
+		}
+	case errors.Is(err, service.ErrSchemaNotReady):
+		return http.StatusServiceUnavailable, ErrorResponse{Error: "Database schema not ready", Code: code}
+	default:
+		return http.StatusInternalServerError, ErrorResponse{Error: "Internal error", Code: code}
+	}
+}
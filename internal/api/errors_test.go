@@ -0,0 +1,193 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"quoteservice/internal/service"
+)
+
+func TestServiceErrorMapping(t *testing.T) {
+	unsupported := fmt.Errorf("%w: ABC", service.ErrUnsupportedCurrency)
+
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantError  string
+		wantCode   int
+	}{
+		{"invalid pair format", service.ErrInvalidPairFormat, http.StatusBadRequest, "invalid currency code format", ErrCodeInvalidFormat},
+		{"unsupported currency", unsupported, http.StatusBadRequest, "unsupported currency: ABC", ErrCodeUnsupportedCurrency},
+		{"invalid update id", service.ErrInvalidUpdateID, http.StatusBadRequest, "invalid update_id", ErrCodeInvalidFormat},
+		{"conflict", service.ErrWebhookExists, http.StatusConflict, "webhook already registered", ErrCodeConflict},
+		{"queue unavailable", service.ErrInternalQueue, http.StatusServiceUnavailable, "Task queue unavailable, retry later", ErrCodeQueueUnavailable},
+		{"queue full", service.ErrQueueFull, http.StatusServiceUnavailable, "Task queue full, retry later", ErrCodeQueueFull},
+		{"schema not ready", service.ErrSchemaNotReady, http.StatusServiceUnavailable, "Database schema not ready", ErrCodeSchemaNotReady},
+		{"internal", service.ErrInternal, http.StatusInternalServerError, "Internal error", ErrCodeInternal},
+		{"unknown", errors.New("boom"), http.StatusInternalServerError, "Internal error", ErrCodeInternal},
+	}
+
+	svcReturning := func(err error) *mockQuoteService {
+		return &mockQuoteService{
+			requestUpdateFunc: func(ctx context.Context, pair string, _ service.UpdateOptions) (*service.UpdateRequestResult, error) {
+				return nil, err
+			},
+			getQuoteResultFunc: func(ctx context.Context, updateID string) (*service.QuoteResult, error) {
+				return nil, err
+			},
+			getLatestQuoteFunc: func(ctx context.Context, pair service.Pair) (*service.QuoteResult, error) {
+				return nil, err
+			},
+		}
+	}
+
+	handlers := []struct {
+		name    string
+		handler func(service.QuoteServiceInterface) http.HandlerFunc
+		request func() *http.Request
+	}{
+		{"update", func(svc service.QuoteServiceInterface) http.HandlerFunc {
+			return HandleRequestUpdate(svc, nil, ErrorConfig{})
+		}, func() *http.Request {
+			return httptest.NewRequest(http.MethodPost, "/quotes/update", bytes.NewBufferString(`{"pair":"ABC/USD"}`))
+		}},
+		{"by id", func(svc service.QuoteServiceInterface) http.HandlerFunc {
+			return HandleGetQuoteByID(svc, nil, ErrorConfig{})
+		}, func() *http.Request {
+			req := httptest.NewRequest(http.MethodGet, "/quotes/some-id", nil)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("update_id", "some-id")
+			return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+		}},
+		{"latest", func(svc service.QuoteServiceInterface) http.HandlerFunc {
+			return HandleGetLatestQuote(svc, nil, false, ErrorConfig{})
+		}, func() *http.Request {
+			return httptest.NewRequest(http.MethodGet, "/quotes/latest?base=ABC&quote=USD", nil)
+		}},
+	}
+
+	for _, h := range handlers {
+		for _, tc := range tests {
+			t.Run(h.name+" "+tc.name, func(t *testing.T) {
+				w := httptest.NewRecorder()
+				h.handler(svcReturning(tc.err)).ServeHTTP(w, h.request())
+
+				if w.Code != tc.wantStatus {
+					t.Fatalf("Expected status %d, got %d", tc.wantStatus, w.Code)
+				}
+				var resp ErrorResponse
+				if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+					t.Fatalf("Failed to decode response: %v", err)
+				}
+				if resp.Error != tc.wantError {
+					t.Errorf("Expected error %q, got %q", tc.wantError, resp.Error)
+				}
+				if resp.Code != tc.wantCode {
+					t.Errorf("Expected code %d, got %d", tc.wantCode, resp.Code)
+				}
+
+				// Only the queue errors are expected to clear up within the hint.
+				queueErr := tc.wantCode == ErrCodeQueueUnavailable || tc.wantCode == ErrCodeQueueFull
+				retryAfter := w.Header().Get("Retry-After")
+				if queueErr && retryAfter != "5" {
+					t.Errorf("Expected Retry-After 5, got %q", retryAfter)
+				}
+				if !queueErr && retryAfter != "" {
+					t.Errorf("Unexpected Retry-After %q", retryAfter)
+				}
+			})
+		}
+	}
+}
+
+func TestProviderUnavailableMapping(t *testing.T) {
+	errs := ErrorConfig{ProviderRetryAfterSec: 45}
+	err := fmt.Errorf("%w: all providers failed", service.ErrProviderUnavailable)
+	svc := &mockQuoteService{
+		requestUpdateFunc: func(ctx context.Context, pair string, _ service.UpdateOptions) (*service.UpdateRequestResult, error) {
+			return nil, err
+		},
+		getLatestQuoteFunc: func(ctx context.Context, pair service.Pair) (*service.QuoteResult, error) {
+			return nil, err
+		},
+	}
+
+	handlers := []struct {
+		name    string
+		handler http.HandlerFunc
+		request *http.Request
+	}{
+		{"update", HandleRequestUpdate(svc, nil, errs),
+			httptest.NewRequest(http.MethodPost, "/quotes/update", bytes.NewBufferString(`{"pair":"EUR/USD"}`))},
+		{"latest", HandleGetLatestQuote(svc, nil, false, errs),
+			httptest.NewRequest(http.MethodGet, "/quotes/latest?base=EUR&quote=USD", nil)},
+	}
+
+	for _, h := range handlers {
+		t.Run(h.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			h.handler.ServeHTTP(w, h.request)
+
+			if w.Code != http.StatusServiceUnavailable {
+				t.Fatalf("Expected status 503, got %d", w.Code)
+			}
+			if got := w.Header().Get("Retry-After"); got != "45" {
+				t.Errorf("Expected Retry-After 45, got %q", got)
+			}
+			var resp ProviderUnavailableResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			want := ProviderUnavailableResponse{Error: "provider unavailable", Code: ErrCodeProviderUnavailable, RetryAfterSeconds: 45}
+			if resp != want {
+				t.Errorf("Expected %+v, got %+v", want, resp)
+			}
+		})
+	}
+}
+
+func TestErrorToCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{service.ErrInvalidPairFormat, ErrCodeInvalidFormat},
+		{fmt.Errorf("%w: XYZ", service.ErrUnsupportedCurrency), ErrCodeUnsupportedCurrency},
+		{service.ErrInvalidWebhookURL, ErrCodeInvalidFormat},
+		{service.ErrNotFound, ErrCodeNotFound},
+		{service.ErrWebhookExists, ErrCodeConflict},
+		{service.ErrInternalQueue, ErrCodeQueueUnavailable},
+		{service.ErrQueueFull, ErrCodeQueueFull},
+		{service.ErrSchemaNotReady, ErrCodeSchemaNotReady},
+		{&service.UnknownProviderError{Name: "ecb"}, ErrCodeUnknownProvider},
+		{fmt.Errorf("%w: all providers failed", service.ErrProviderUnavailable), ErrCodeProviderUnavailable},
+		{service.ErrInternal, ErrCodeInternal},
+		{errors.New("boom"), ErrCodeInternal},
+	}
+	for _, tc := range tests {
+		if got := errorToCode(tc.err); got != tc.want {
+			t.Errorf("errorToCode(%v) = %d, want %d", tc.err, got, tc.want)
+		}
+	}
+}
+
+// assertErrorCode decodes an ErrorResponse from w and checks its code.
+func assertErrorCode(t *testing.T, w *httptest.ResponseRecorder, want int) {
+	t.Helper()
+	var resp ErrorResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode error response: %v", err)
+	}
+	if resp.Code != want {
+		t.Errorf("Expected code %d, got %d", want, resp.Code)
+	}
+}
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"quoteservice/internal/service"
+)
+
+// CurrencyResponse describes a supported currency for display purposes
+type CurrencyResponse struct {
+	Code          string `json:"code" example:"JPY"`
+	Name          string `json:"name" example:"Japanese Yen"`
+	Symbol        string `json:"symbol" example:"¥"`
+	DecimalDigits int    `json:"decimal_digits" example:"0"`
+}
+
+// CurrenciesResponse lists all supported currencies
+type CurrenciesResponse struct {
+	Currencies []CurrencyResponse `json:"currencies"`
+}
+
+// HandleListCurrencies godoc
+// @Summary List supported currencies
+// @Description Returns every supported currency with its name, symbol and conventional number of decimal places, sorted by code.
+// @Tags currencies
+// @Produce json
+// @Success 200 {object} CurrenciesResponse "Supported currencies"
+// @Failure 401 {object} ErrorResponse "Missing or invalid API key (when auth is enabled)"
+// @Failure 403 {object} ErrorResponse "API key lacks the read scope"
+// @Failure 429 {object} QuotaExceededResponse "Monthly quota of the API key used up (when quotas are enabled)"
+// @Router /currencies [get]
+func HandleListCurrencies() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		list := service.Currencies()
+		resp := CurrenciesResponse{Currencies: make([]CurrencyResponse, 0, len(list))}
+		for _, c := range list {
+			resp.Currencies = append(resp.Currencies, currencyResponse(c))
+		}
+		writeJSON(w, r, http.StatusOK, resp)
+	}
+}
+
+// HandleGetCurrency godoc
+// @Summary Get currency metadata
+// @Description Returns the name, symbol and conventional number of decimal places of a supported currency.
+// @Tags currencies
+// @Produce json
+// @Param code path string true "Currency code (3 letters)" minlength(3) maxlength(3)
+// @Success 200 {object} CurrencyResponse "Currency metadata"
+// @Failure 400 {object} ErrorResponse "Invalid currency code format"
+// @Failure 401 {object} ErrorResponse "Missing or invalid API key (when auth is enabled)"
+// @Failure 403 {object} ErrorResponse "API key lacks the read scope"
+// @Failure 404 {object} ErrorResponse "Currency is not supported"
+// @Failure 429 {object} QuotaExceededResponse "Monthly quota of the API key used up (when quotas are enabled)"
+// @Router /currencies/{code} [get]
+func HandleGetCurrency() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		code := service.NormalizeCode(chi.URLParam(r, "code"))
+		c, err := service.LookupCurrency(code)
+		if err != nil {
+			writeServiceError(w, r, ErrorConfig{}, err, "Currency "+code+" is not supported")
+			return
+		}
+		writeJSON(w, r, http.StatusOK, currencyResponse(c))
+	}
+}
+
+func currencyResponse(c service.Currency) CurrencyResponse {
+	return CurrencyResponse{Code: c.Code, Name: c.Name, Symbol: c.Symbol, DecimalDigits: c.DecimalDigits}
+}
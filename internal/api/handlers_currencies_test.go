@@ -0,0 +1,83 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestHandleListCurrencies(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/currencies", http.NoBody)
+	w := httptest.NewRecorder()
+
+	HandleListCurrencies().ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	var resp CurrenciesResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(resp.Currencies) == 0 {
+		t.Fatal("Expected a non-empty currency list")
+	}
+	for _, c := range resp.Currencies {
+		if c.Code == "JPY" && (c.Name != "Japanese Yen" || c.DecimalDigits != 0) {
+			t.Errorf("Unexpected JPY entry: %+v", c)
+		}
+	}
+}
+
+func TestHandleGetCurrency(t *testing.T) {
+	tests := []struct {
+		code       string
+		wantStatus int
+		wantError  string
+		wantCode   int
+	}{
+		{"usd", http.StatusOK, "", 0},
+		{"XYZ", http.StatusNotFound, "Currency XYZ is not supported", ErrCodeNotFound},
+		{"US", http.StatusBadRequest, "invalid currency code format", ErrCodeInvalidFormat},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.code, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/currencies/"+tc.code, http.NoBody)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("code", tc.code)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+			w := httptest.NewRecorder()
+
+			HandleGetCurrency().ServeHTTP(w, req)
+
+			if w.Code != tc.wantStatus {
+				t.Fatalf("Expected status %d, got %d", tc.wantStatus, w.Code)
+			}
+			if tc.wantStatus != http.StatusOK {
+				var resp ErrorResponse
+				if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+					t.Fatalf("Failed to decode response: %v", err)
+				}
+				if resp.Error != tc.wantError {
+					t.Errorf("Expected error %q, got %q", tc.wantError, resp.Error)
+				}
+				if resp.Code != tc.wantCode {
+					t.Errorf("Expected code %d, got %d", tc.wantCode, resp.Code)
+				}
+				return
+			}
+			var resp CurrencyResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if resp != (CurrencyResponse{Code: "USD", Name: "US Dollar", Symbol: "$", DecimalDigits: 2}) {
+				t.Errorf("Unexpected response: %+v", resp)
+			}
+		})
+	}
+}
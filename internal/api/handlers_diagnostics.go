@@ -0,0 +1,88 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// SelfCheckStep is a named step of the self-check run by HandleSelfCheck. Run must
+// leave no trace in real data.
+type SelfCheckStep struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+// SelfCheckResponse reports every self-check step, in the order they are configured
+type SelfCheckResponse struct {
+	Status     string                  `json:"status" example:"ok" enums:"ok,error"`
+	DurationMs int64                   `json:"duration_ms" example:"14"`
+	Steps      []SelfCheckStepResponse `json:"steps"`
+}
+
+// SelfCheckStepResponse is the outcome of a single self-check step
+type SelfCheckStepResponse struct {
+	Name      string `json:"name" example:"postgres_write"`
+	Status    string `json:"status" example:"ok" enums:"ok,error"`
+	LatencyMs int64  `json:"latency_ms" example:"6"`
+	Error     string `json:"error,omitempty" example:"insert: connection refused"`
+}
+
+// HandleSelfCheck godoc
+// @Summary Run a synthetic end-to-end check
+// @Description Runs every step at once and reports the latency and error of each: postgres_write inserts a throwaway quote record of the reserved pair XTS/XXX and reads it back in a transaction that is rolled back, redis_cache writes, reads and deletes a temporary key, and redis_asynq schedules a no-op task and deletes it. Steps are independent, so a failing one does not hide the others. The whole check is bounded by a few seconds; a step still running then is reported as failed. Requires the admin scope.
+// @Tags admin
+// @Produce json
+// @Success 200 {object} SelfCheckResponse "Every step passed"
+// @Failure 401 {object} ErrorResponse "Missing or invalid API key (when auth is enabled)"
+// @Failure 403 {object} ErrorResponse "API key lacks the admin scope"
+// @Failure 429 {object} QuotaExceededResponse "Monthly quota of the API key used up (when quotas are enabled)"
+// @Failure 503 {object} SelfCheckResponse "At least one step failed or timed out"
+// @Router /admin/selfcheck [get]
+func HandleSelfCheck(timeout time.Duration, steps ...SelfCheckStep) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		type result struct {
+			err     error
+			latency time.Duration
+		}
+		start := time.Now()
+		// Each step runs in its own goroutine so steps that ignore ctx still respect
+		// the timeout; the channels are buffered so a late step does not block.
+		done := make([]chan result, len(steps))
+		for i, s := range steps {
+			done[i] = make(chan result, 1)
+			go func() {
+				err := s.Run(ctx)
+				done[i] <- result{err: err, latency: time.Since(start)}
+			}()
+		}
+
+		resp := SelfCheckResponse{Status: ComponentStatusOK, Steps: make([]SelfCheckStepResponse, len(steps))}
+		code := http.StatusOK
+		for i, s := range steps {
+			var res result
+			select {
+			case res = <-done[i]:
+			default:
+				// Only wait for a step that has not finished yet, so one that timed
+				// out does not turn the finished steps after it into timeouts.
+				select {
+				case res = <-done[i]:
+				case <-ctx.Done():
+					res = result{err: ctx.Err(), latency: time.Since(start)}
+				}
+			}
+			step := SelfCheckStepResponse{Name: s.Name, Status: ComponentStatusOK, LatencyMs: res.latency.Milliseconds()}
+			if res.err != nil {
+				step.Status, step.Error = ComponentStatusError, res.err.Error()
+				resp.Status, code = ComponentStatusError, http.StatusServiceUnavailable
+			}
+			resp.Steps[i] = step
+		}
+		resp.DurationMs = time.Since(start).Milliseconds()
+		writeJSON(w, r, code, resp)
+	}
+}
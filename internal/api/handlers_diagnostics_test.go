@@ -0,0 +1,100 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func runSelfCheck(t *testing.T, timeout time.Duration, steps ...SelfCheckStep) (int, SelfCheckResponse) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	HandleSelfCheck(timeout, steps...).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/selfcheck", nil))
+	var resp SelfCheckResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	return w.Code, resp
+}
+
+func TestHandleSelfCheck_AllPass(t *testing.T) {
+	ok := func(context.Context) error { return nil }
+	code, resp := runSelfCheck(t, time.Second,
+		SelfCheckStep{Name: "postgres_write", Run: ok},
+		SelfCheckStep{Name: "redis_cache", Run: ok},
+	)
+	if code != http.StatusOK || resp.Status != ComponentStatusOK {
+		t.Fatalf("Expected 200 and status ok, got %d with %+v", code, resp)
+	}
+	if len(resp.Steps) != 2 || resp.Steps[0].Name != "postgres_write" || resp.Steps[1].Name != "redis_cache" {
+		t.Fatalf("Expected both steps in order, got %+v", resp.Steps)
+	}
+	for _, s := range resp.Steps {
+		if s.Status != ComponentStatusOK || s.Error != "" {
+			t.Errorf("Expected step %s ok, got %+v", s.Name, s)
+		}
+	}
+}
+
+func TestHandleSelfCheck_FailuresAreReportedPerStep(t *testing.T) {
+	var ran atomic.Int32
+	step := func(name string, err error) SelfCheckStep {
+		return SelfCheckStep{Name: name, Run: func(context.Context) error {
+			ran.Add(1)
+			return err
+		}}
+	}
+	code, resp := runSelfCheck(t, time.Second,
+		step("postgres_write", errors.New("insert: connection refused")),
+		step("redis_cache", nil),
+		step("redis_asynq", errors.New("enqueue: i/o timeout")),
+	)
+
+	if code != http.StatusServiceUnavailable || resp.Status != ComponentStatusError {
+		t.Fatalf("Expected 503 and status error, got %d with %+v", code, resp)
+	}
+	if n := ran.Load(); n != 3 {
+		t.Errorf("Expected every step to run, ran %d", n)
+	}
+	want := []SelfCheckStepResponse{
+		{Name: "postgres_write", Status: ComponentStatusError, Error: "insert: connection refused"},
+		{Name: "redis_cache", Status: ComponentStatusOK},
+		{Name: "redis_asynq", Status: ComponentStatusError, Error: "enqueue: i/o timeout"},
+	}
+	for i, w := range want {
+		got := resp.Steps[i]
+		if got.Name != w.Name || got.Status != w.Status || got.Error != w.Error {
+			t.Errorf("Expected step %+v, got %+v", w, got)
+		}
+	}
+}
+
+func TestHandleSelfCheck_Timeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	start := time.Now()
+	code, resp := runSelfCheck(t, 50*time.Millisecond,
+		// Ignores ctx, so only the handler's own timeout can end it.
+		SelfCheckStep{Name: "postgres_write", Run: func(context.Context) error { <-release; return nil }},
+		SelfCheckStep{Name: "redis_cache", Run: func(context.Context) error { return nil }},
+	)
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("Expected the check to end at its timeout, took %v", elapsed)
+	}
+	if code != http.StatusServiceUnavailable {
+		t.Fatalf("Expected 503, got %d", code)
+	}
+	if s := resp.Steps[0]; s.Status != ComponentStatusError || s.Error != context.DeadlineExceeded.Error() || s.LatencyMs < 50 {
+		t.Errorf("Expected the hanging step to time out, got %+v", s)
+	}
+	if s := resp.Steps[1]; s.Status != ComponentStatusOK {
+		t.Errorf("Expected the finished step after a timed-out one to pass, got %+v", s)
+	}
+}
@@ -1,15 +1,97 @@
 package api
 
 import (
-	"database/sql"
+	"context"
 	"net/http"
+	"strconv"
+	"sync"
+	"time"
 
-	"github.com/redis/go-redis/v9"
+	"quoteservice/internal/lifecycle"
+	"quoteservice/internal/service"
+)
+
+// Readiness and component status values reported by HandleReadyz.
+const (
+	ReadyStatusReady    = "ready"
+	ReadyStatusDegraded = "degraded"
+
+	ComponentStatusOK    = "ok"
+	ComponentStatusError = "error"
 )
 
 // ReadyResponse represents the readiness response
 type ReadyResponse struct {
-	Status string `json:"status" example:"ready"`
+	Status     string                     `json:"status" example:"ready" enums:"ready,degraded"`
+	Components map[string]ComponentStatus `json:"components,omitempty"`
+}
+
+// ComponentStatus is the outcome of a single readiness check
+type ComponentStatus struct {
+	Status    string `json:"status" example:"ok" enums:"ok,error"`
+	LatencyMs int64  `json:"latency_ms" example:"2"`
+	Error     string `json:"error,omitempty" example:"connection refused"`
+}
+
+// ReadinessChecker reports whether a dependency or component can serve traffic.
+type ReadinessChecker interface {
+	CheckReady(ctx context.Context) error
+}
+
+// ReadinessFunc adapts a function to ReadinessChecker.
+type ReadinessFunc func(ctx context.Context) error
+
+// CheckReady implements ReadinessChecker.
+func (f ReadinessFunc) CheckReady(ctx context.Context) error { return f(ctx) }
+
+// ReadinessCheck is a named check evaluated by HandleReadyz. A failing Optional check
+// marks the service degraded without failing readiness. Deep checks only run when the
+// probe asks for them with ?deep=true.
+type ReadinessCheck struct {
+	Name     string
+	Checker  ReadinessChecker
+	Optional bool
+	Deep     bool
+}
+
+// ThrottledChecker runs c at most once per interval, bounding each run by timeout, and
+// returns the previous result to calls in between. It keeps expensive deep checks from
+// adding load when probes hit them frequently.
+func ThrottledChecker(c ReadinessChecker, interval, timeout time.Duration) ReadinessChecker {
+	return &throttledChecker{checker: c, interval: interval, timeout: timeout}
+}
+
+type throttledChecker struct {
+	checker  ReadinessChecker
+	interval time.Duration
+	timeout  time.Duration
+
+	mu      sync.Mutex
+	lastRun time.Time
+	lastErr error
+}
+
+func (t *throttledChecker) CheckReady(ctx context.Context) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.lastRun.IsZero() && time.Since(t.lastRun) < t.interval {
+		return t.lastErr
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+
+	// Run in a goroutine so checkers that ignore ctx still respect the timeout.
+	done := make(chan error, 1)
+	go func() { done <- t.checker.CheckReady(ctx) }()
+	select {
+	case t.lastErr = <-done:
+	case <-ctx.Done():
+		t.lastErr = ctx.Err()
+	}
+	t.lastRun = time.Now()
+	return t.lastErr
 }
 
 // HandleHealthz godoc
@@ -26,35 +108,81 @@ func HandleHealthz() http.HandlerFunc {
 	}
 }
 
+// LifecycleReporter reports the instance's lifecycle phase; implemented by
+// *lifecycle.Emitter.
+type LifecycleReporter interface {
+	State() lifecycle.State
+}
+
+// HealthDetailsResponse is the instance's lifecycle phase and the lifecycle events
+// emitted so far
+type HealthDetailsResponse struct {
+	Phase      string                   `json:"phase" example:"serving" enums:"starting,serving,draining,stopped"`
+	PhaseSince string                   `json:"phase_since" example:"2025-12-01T10:15:30Z"`
+	Events     []LifecycleEventResponse `json:"events"`
+}
+
+// LifecycleEventResponse is a lifecycle event and when it was emitted
+type LifecycleEventResponse struct {
+	Event string `json:"event" example:"http_listening"`
+	At    string `json:"at" example:"2025-12-01T10:15:30Z"`
+}
+
+// HandleHealthDetails godoc
+// @Summary Lifecycle phase
+// @Description Returns the instance's lifecycle phase (starting until the HTTP listener is bound, then serving, draining once shutdown begins, stopped) and the lifecycle events emitted so far, which are also logged as "Lifecycle event" lines. Like /healthz it answers 200 while the process runs.
+// @Tags health
+// @Produce json
+// @Success 200 {object} HealthDetailsResponse "Lifecycle phase and events"
+// @Router /healthz/details [get]
+func HandleHealthDetails(reporter LifecycleReporter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		state := reporter.State()
+		resp := HealthDetailsResponse{
+			Phase:      string(state.Phase),
+			PhaseSince: service.FormatTimestamp(state.Since),
+			Events:     make([]LifecycleEventResponse, 0, len(state.Events)),
+		}
+		for _, e := range state.Events {
+			resp.Events = append(resp.Events, LifecycleEventResponse{Event: string(e.Event), At: service.FormatTimestamp(e.At)})
+		}
+		writeJSON(w, r, http.StatusOK, resp)
+	}
+}
+
 // HandleReadyz godoc
 // @Summary Readiness check
-// @Description Checks connectivity to critical dependencies (Postgres, cache Redis, and asynq Redis). Returns 200 only when all dependencies are reachable.
+// @Description Runs every readiness check (Postgres, cache Redis, asynq Redis, whether the worker is drained, the quote notification listener and, when required, cache warmup) and reports per-component status and latency. Returns 503 if any critical check fails. If only optional components (the cache Redis, the quote notification listener) fail, returns 200 with status "degraded". With deep=true it also verifies that all migrations are applied and the quotes table is readable, and that the asynq queues can be listed; deep results are cached for a few seconds.
 // @Tags health
 // @Produce json
-// @Success 200 {object} ReadyResponse "All dependencies ready"
-// @Failure 503 {object} ErrorResponse "At least one dependency unavailable"
+// @Param deep query bool false "Also run deep checks (schema and queue)"
+// @Success 200 {object} ReadyResponse "Ready, or degraded with only optional components failing"
+// @Failure 503 {object} ReadyResponse "At least one critical component unavailable, cache warming up or worker drained"
 // @Router /readyz [get]
-func HandleReadyz(db *sql.DB, cache, asynqRedis *redis.Client) http.HandlerFunc {
+func HandleReadyz(checks ...ReadinessCheck) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		if err := db.PingContext(r.Context()); err != nil {
-			writeJSON(w, http.StatusServiceUnavailable, ErrorResponse{Error: "DB not ready"})
-			return
-		}
+		deep, _ := strconv.ParseBool(r.URL.Query().Get("deep"))
+		resp := ReadyResponse{Status: ReadyStatusReady, Components: make(map[string]ComponentStatus, len(checks))}
+		code := http.StatusOK
 
-		if cache != nil {
-			if err := cache.Ping(r.Context()).Err(); err != nil {
-				writeJSON(w, http.StatusServiceUnavailable, ErrorResponse{Error: "Cache not ready"})
-				return
+		for _, c := range checks {
+			if c.Deep && !deep {
+				continue
 			}
-		}
-
-		if asynqRedis != nil {
-			if err := asynqRedis.Ping(r.Context()).Err(); err != nil {
-				writeJSON(w, http.StatusServiceUnavailable, ErrorResponse{Error: "Asynq Redis not ready"})
-				return
+			start := time.Now()
+			err := c.Checker.CheckReady(r.Context())
+			cs := ComponentStatus{Status: ComponentStatusOK, LatencyMs: time.Since(start).Milliseconds()}
+			if err != nil {
+				cs.Status = ComponentStatusError
+				cs.Error = err.Error()
+				resp.Status = ReadyStatusDegraded
+				if !c.Optional {
+					code = http.StatusServiceUnavailable
+				}
 			}
+			resp.Components[c.Name] = cs
 		}
 
-		writeJSON(w, http.StatusOK, map[string]string{"status": "ready"})
+		writeJSON(w, r, code, resp)
 	}
 }
@@ -0,0 +1,229 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"quoteservice/internal/lifecycle"
+)
+
+func TestHandleHealthz(t *testing.T) {
+	w := httptest.NewRecorder()
+	HandleHealthz().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", w.Code)
+	}
+	if w.Body.String() != "OK" {
+		t.Errorf("Expected body OK, got %q", w.Body.String())
+	}
+}
+
+type staticLifecycle lifecycle.State
+
+func (s staticLifecycle) State() lifecycle.State { return lifecycle.State(s) }
+
+func TestHandleHealthDetails(t *testing.T) {
+	at := time.Date(2025, 12, 1, 10, 15, 30, 0, time.UTC)
+	reporter := staticLifecycle{Phase: lifecycle.PhaseServing, Since: at, Events: []lifecycle.Record{
+		{Event: lifecycle.ConfigLoaded, At: at.Add(-time.Second)},
+		{Event: lifecycle.HTTPListening, At: at},
+	}}
+	w := httptest.NewRecorder()
+	HandleHealthDetails(reporter).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz/details", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	var resp HealthDetailsResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if resp.Phase != "serving" || resp.PhaseSince != "2025-12-01T10:15:30Z" {
+		t.Errorf("Unexpected phase %q since %q", resp.Phase, resp.PhaseSince)
+	}
+	if len(resp.Events) != 2 || resp.Events[0].Event != "config_loaded" || resp.Events[1].At != "2025-12-01T10:15:30Z" {
+		t.Errorf("Unexpected events %+v", resp.Events)
+	}
+}
+
+func TestHandleReadyz(t *testing.T) {
+	up := ReadinessFunc(func(context.Context) error { return nil })
+	down := ReadinessFunc(func(context.Context) error { return errors.New("connection refused") })
+
+	checks := func(db, cache, asynq ReadinessChecker) []ReadinessCheck {
+		return []ReadinessCheck{
+			{Name: "postgres", Checker: db},
+			{Name: "redis_cache", Checker: cache, Optional: true},
+			{Name: "redis_asynq", Checker: asynq},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		checks     []ReadinessCheck
+		wantCode   int
+		wantStatus string
+		wantFailed []string
+	}{
+		{"all ready", checks(up, up, up), http.StatusOK, ReadyStatusReady, nil},
+		{"no checks", nil, http.StatusOK, ReadyStatusReady, nil},
+		{"db down", checks(down, up, up), http.StatusServiceUnavailable, ReadyStatusDegraded, []string{"postgres"}},
+		{"cache down", checks(up, down, up), http.StatusOK, ReadyStatusDegraded, []string{"redis_cache"}},
+		{"asynq redis down", checks(up, up, down), http.StatusServiceUnavailable, ReadyStatusDegraded, []string{"redis_asynq"}},
+		{"db and cache down", checks(down, down, up), http.StatusServiceUnavailable, ReadyStatusDegraded, []string{"postgres", "redis_cache"}},
+		{"cache and asynq down", checks(up, down, down), http.StatusServiceUnavailable, ReadyStatusDegraded, []string{"redis_cache", "redis_asynq"}},
+		{"all down", checks(down, down, down), http.StatusServiceUnavailable, ReadyStatusDegraded, []string{"postgres", "redis_cache", "redis_asynq"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			HandleReadyz(tc.checks...).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+			if w.Code != tc.wantCode {
+				t.Fatalf("Expected status %d, got %d", tc.wantCode, w.Code)
+			}
+			var resp ReadyResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if resp.Status != tc.wantStatus {
+				t.Errorf("Expected status %q, got %q", tc.wantStatus, resp.Status)
+			}
+			if len(resp.Components) != len(tc.checks) {
+				t.Fatalf("Expected %d components, got %d", len(tc.checks), len(resp.Components))
+			}
+
+			failed := make(map[string]bool, len(tc.wantFailed))
+			for _, name := range tc.wantFailed {
+				failed[name] = true
+			}
+			for name, cs := range resp.Components {
+				if failed[name] {
+					if cs.Status != ComponentStatusError || cs.Error != "connection refused" {
+						t.Errorf("Expected %s to report error, got %+v", name, cs)
+					}
+					continue
+				}
+				if cs.Status != ComponentStatusOK || cs.Error != "" {
+					t.Errorf("Expected %s to be ok, got %+v", name, cs)
+				}
+			}
+		})
+	}
+}
+
+func TestHandleReadyz_MeasuresLatency(t *testing.T) {
+	slow := ReadinessFunc(func(context.Context) error {
+		time.Sleep(20 * time.Millisecond)
+		return nil
+	})
+
+	w := httptest.NewRecorder()
+	HandleReadyz(ReadinessCheck{Name: "postgres", Checker: slow}).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	var resp ReadyResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if got := resp.Components["postgres"].LatencyMs; got < 20 {
+		t.Errorf("Expected latency_ms >= 20, got %d", got)
+	}
+}
+
+func TestHandleReadyz_DeepChecks(t *testing.T) {
+	var deepRuns atomic.Int32
+	deep := ReadinessFunc(func(context.Context) error {
+		deepRuns.Add(1)
+		return errors.New("migrations not applied: 003_quotes_rate_timestamp.sql")
+	})
+	handler := HandleReadyz(
+		ReadinessCheck{Name: "postgres", Checker: ReadinessFunc(func(context.Context) error { return nil })},
+		ReadinessCheck{Name: "postgres_schema", Checker: deep, Deep: true},
+	)
+
+	tests := []struct {
+		query    string
+		wantCode int
+		wantDeep bool
+	}{
+		{"", http.StatusOK, false},
+		{"?deep=false", http.StatusOK, false},
+		{"?deep=bogus", http.StatusOK, false},
+		{"?deep=true", http.StatusServiceUnavailable, true},
+		{"?deep=1", http.StatusServiceUnavailable, true},
+	}
+	for _, tc := range tests {
+		t.Run("query"+tc.query, func(t *testing.T) {
+			deepRuns.Store(0)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz"+tc.query, nil))
+
+			if w.Code != tc.wantCode {
+				t.Fatalf("Expected status %d, got %d", tc.wantCode, w.Code)
+			}
+			var resp ReadyResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			_, reported := resp.Components["postgres_schema"]
+			if reported != tc.wantDeep || (deepRuns.Load() == 1) != tc.wantDeep {
+				t.Errorf("Expected deep check run=%v, got reported=%v runs=%d", tc.wantDeep, reported, deepRuns.Load())
+			}
+		})
+	}
+}
+
+func TestThrottledChecker(t *testing.T) {
+	t.Run("reuses result within interval", func(t *testing.T) {
+		var runs atomic.Int32
+		c := ThrottledChecker(ReadinessFunc(func(context.Context) error {
+			if runs.Add(1) == 1 {
+				return errors.New("first run fails")
+			}
+			return nil
+		}), 50*time.Millisecond, time.Second)
+
+		for range 3 {
+			if err := c.CheckReady(context.Background()); err == nil {
+				t.Fatal("Expected cached error from first run")
+			}
+		}
+		if runs.Load() != 1 {
+			t.Fatalf("Expected 1 run within interval, got %d", runs.Load())
+		}
+
+		time.Sleep(60 * time.Millisecond)
+		if err := c.CheckReady(context.Background()); err != nil {
+			t.Fatalf("Expected fresh successful run after interval, got %v", err)
+		}
+		if runs.Load() != 2 {
+			t.Fatalf("Expected 2 runs, got %d", runs.Load())
+		}
+	})
+
+	t.Run("times out checks that ignore ctx", func(t *testing.T) {
+		release := make(chan struct{})
+		defer close(release)
+		c := ThrottledChecker(ReadinessFunc(func(context.Context) error {
+			<-release
+			return nil
+		}), time.Minute, 20*time.Millisecond)
+
+		start := time.Now()
+		err := c.CheckReady(context.Background())
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("Expected DeadlineExceeded, got %v", err)
+		}
+		if time.Since(start) > time.Second {
+			t.Errorf("Expected timeout after ~20ms, took %v", time.Since(start))
+		}
+	})
+}
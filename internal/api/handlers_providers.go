@@ -0,0 +1,58 @@
+package api
+
+import (
+	"net/http"
+
+	"quoteservice/internal/provider"
+)
+
+// ProviderScoreReporter reports how the provider facade ranks its providers;
+// implemented by *provider.ExchangeProviderFacade.
+type ProviderScoreReporter interface {
+	Adaptive() bool
+	ProviderScores() []provider.ProviderScore
+}
+
+// ProviderScoresResponse lists the recent record of every provider, in the configured order
+type ProviderScoresResponse struct {
+	Strategy  string                  `json:"strategy" example:"adaptive" enums:"fixed,adaptive"`
+	Providers []ProviderScoreResponse `json:"providers"`
+}
+
+// ProviderScoreResponse is a provider's record over its recent calls
+type ProviderScoreResponse struct {
+	Name         string  `json:"name" example:"frankfurter"`
+	Calls        int     `json:"calls" example:"50"`
+	SuccessRate  float64 `json:"success_rate" example:"0.96"` // Smoothed: (successes+1) / (calls+2).
+	P50LatencyMs int64   `json:"p50_latency_ms" example:"180"`
+	Score        float64 `json:"score" example:"0.81"`
+}
+
+// HandleProviderScores godoc
+// @Summary Show provider scores
+// @Description Returns each exchange rate provider's record over its last provider_selection.window calls made through the facade: the smoothed success rate, the median latency of the successful calls and the resulting score. With provider_selection.strategy adaptive, providers are tried best score first. Requires the admin scope.
+// @Tags admin
+// @Produce json
+// @Success 200 {object} ProviderScoresResponse "Provider scores"
+// @Failure 401 {object} ErrorResponse "Missing or invalid API key (when auth is enabled)"
+// @Failure 403 {object} ErrorResponse "API key lacks the admin scope"
+// @Failure 429 {object} QuotaExceededResponse "Monthly quota of the API key used up (when quotas are enabled)"
+// @Router /admin/providers [get]
+func HandleProviderScores(reporter ProviderScoreReporter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		resp := ProviderScoresResponse{Strategy: "fixed", Providers: []ProviderScoreResponse{}}
+		if reporter.Adaptive() {
+			resp.Strategy = "adaptive"
+		}
+		for _, s := range reporter.ProviderScores() {
+			resp.Providers = append(resp.Providers, ProviderScoreResponse{
+				Name:         s.Name,
+				Calls:        s.Calls,
+				SuccessRate:  s.SuccessRate,
+				P50LatencyMs: s.P50Latency.Milliseconds(),
+				Score:        s.Score,
+			})
+		}
+		writeJSON(w, r, http.StatusOK, resp)
+	}
+}
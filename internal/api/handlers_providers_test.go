@@ -0,0 +1,54 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"quoteservice/internal/provider"
+)
+
+type mockScoreReporter struct {
+	adaptive bool
+	scores   []provider.ProviderScore
+}
+
+func (m *mockScoreReporter) Adaptive() bool                           { return m.adaptive }
+func (m *mockScoreReporter) ProviderScores() []provider.ProviderScore { return m.scores }
+
+func TestHandleProviderScores(t *testing.T) {
+	reporter := &mockScoreReporter{adaptive: true, scores: []provider.ProviderScore{
+		{Name: "frankfurter", Calls: 50, SuccessRate: 0.5, P50Latency: 1500 * time.Millisecond, Score: 0.2},
+		{Name: "openexchangerates", SuccessRate: 0.5, Score: 0.5},
+	}}
+	w := httptest.NewRecorder()
+	HandleProviderScores(reporter).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/providers", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", w.Code)
+	}
+	var resp ProviderScoresResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if resp.Strategy != "adaptive" || len(resp.Providers) != 2 {
+		t.Fatalf("Expected two providers with the adaptive strategy, got %+v", resp)
+	}
+	if p := resp.Providers[0]; p.Name != "frankfurter" || p.Calls != 50 || p.P50LatencyMs != 1500 || p.Score != 0.2 {
+		t.Errorf("Expected the frankfurter record, got %+v", p)
+	}
+	if p := resp.Providers[1]; p.Name != "openexchangerates" || p.Calls != 0 || p.Score != 0.5 {
+		t.Errorf("Expected an empty openexchangerates record, got %+v", p)
+	}
+}
+
+func TestHandleProviderScores_Fixed(t *testing.T) {
+	w := httptest.NewRecorder()
+	HandleProviderScores(&mockScoreReporter{}).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/providers", nil))
+
+	if body := w.Body.String(); body != `{"strategy":"fixed","providers":[]}`+"\n" {
+		t.Errorf("Expected the fixed strategy with no providers, got %s", body)
+	}
+}
@@ -0,0 +1,306 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"quoteservice/internal/service"
+	"quoteservice/internal/worker"
+)
+
+// PairTaskLister lists the queued update tasks of a pair.
+type PairTaskLister interface {
+	ListPairTasks(ctx context.Context, pair service.Pair) ([]worker.QueuedTask, error)
+}
+
+// QueuedTaskResponse describes an Asynq update task and the status of its update record
+type QueuedTaskResponse struct {
+	TaskID       string  `json:"task_id" example:"6f1c2d4e-5a6b-4c7d-8e9f-0a1b2c3d4e5f"`
+	Queue        string  `json:"queue" example:"default"`
+	State        string  `json:"state" example:"retry" enums:"pending,scheduled,retry,archived"`
+	UpdateID     string  `json:"update_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Provider     string  `json:"provider,omitempty" example:"frankfurter"`
+	Retried      int     `json:"retried" example:"2"`
+	MaxRetry     int     `json:"max_retry" example:"5"`
+	NextRetryAt  *string `json:"next_retry_at,omitempty" example:"2025-12-01T10:16:00Z"`
+	LastError    string  `json:"last_error,omitempty" example:"provider timeout"`
+	LastFailedAt *string `json:"last_failed_at,omitempty" example:"2025-12-01T10:15:30Z"`
+	// Status and origin of the update record, omitted if the record no longer exists.
+	RecordStatus string `json:"record_status,omitempty" example:"RUNNING"`
+	RecordOrigin string `json:"record_origin,omitempty" example:"api"`
+	// Error code of a FAILED update record.
+	RecordErrorCode string `json:"record_error_code,omitempty" example:"timeout"`
+}
+
+// PairTasksResponse lists the queued update tasks of a pair
+type PairTasksResponse struct {
+	Pair  string               `json:"pair" example:"EUR/MXN"`
+	Tasks []QueuedTaskResponse `json:"tasks"`
+}
+
+// HandleListPairTasks godoc
+// @Summary List queued update tasks for a pair
+// @Description Lists the pending, scheduled, retry and archived Asynq tasks whose payload is an update of the pair, each with the status, origin and error code of its update record. With origin or error_code set, only tasks whose record has that origin or error code are listed. Tasks being processed are not listed. Requires the admin scope.
+// @Tags admin
+// @Produce json
+// @Param pair query string true "Currency pair" example(EUR/MXN)
+// @Param origin query string false "Only tasks whose update record has this origin" Enums(api,scheduler,auto_refresh,backfill,retry,stream)
+// @Param error_code query string false "Only tasks whose update record FAILED with this error code" Enums(timeout,rate_limited,auth,provider_config,unsupported_pair,provider_unavailable,bad_response,invalid_request,task_lost,internal)
+// @Success 200 {object} PairTasksResponse "Queued tasks"
+// @Failure 400 {object} ErrorResponse "Invalid currency pair, origin or error code"
+// @Failure 401 {object} ErrorResponse "Missing or invalid API key (when auth is enabled)"
+// @Failure 403 {object} ErrorResponse "API key lacks the admin scope"
+// @Failure 429 {object} QuotaExceededResponse "Monthly quota of the API key used up (when quotas are enabled)"
+// @Failure 500 {object} ErrorResponse "Internal error"
+// @Router /admin/queue/tasks [get]
+func HandleListPairTasks(lister PairTaskLister, svc service.QuoteServiceInterface, errs ErrorConfig) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pair, err := service.ParsePair(service.NormalizePair(r.URL.Query().Get("pair")))
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, ErrCodeInvalidFormat, "pair must have the form BASE/QUOTE")
+			return
+		}
+		origin := r.URL.Query().Get("origin")
+		if origin != "" && !service.IsValidOrigin(origin) {
+			writeError(w, r, http.StatusBadRequest, ErrCodeInvalidFormat, "unknown origin "+origin)
+			return
+		}
+		errorCode := r.URL.Query().Get("error_code")
+		if errorCode != "" && !service.IsValidErrorCode(errorCode) {
+			writeError(w, r, http.StatusBadRequest, ErrCodeInvalidFormat, "unknown error_code "+errorCode)
+			return
+		}
+
+		tasks, err := lister.ListPairTasks(r.Context(), pair)
+		if err != nil {
+			writeError(w, r, http.StatusInternalServerError, ErrCodeInternal, "Internal error")
+			return
+		}
+
+		resp := PairTasksResponse{Pair: pair.String(), Tasks: make([]QueuedTaskResponse, 0, len(tasks))}
+		for _, t := range tasks {
+			task, err := queuedTaskResponse(r.Context(), svc, t)
+			if err != nil {
+				writeServiceError(w, r, errs, err, "")
+				return
+			}
+			if (origin != "" && task.RecordOrigin != origin) || (errorCode != "" && task.RecordErrorCode != errorCode) {
+				continue
+			}
+			resp.Tasks = append(resp.Tasks, task)
+		}
+		writeJSON(w, r, http.StatusOK, resp)
+	}
+}
+
+// queuedTaskResponse describes t with the status, origin and error code of its update
+// record, which are left empty if the record no longer exists.
+func queuedTaskResponse(ctx context.Context, svc service.QuoteServiceInterface, t worker.QueuedTask) (QueuedTaskResponse, error) {
+	task := QueuedTaskResponse{
+		TaskID:       t.ID,
+		Queue:        t.Queue,
+		State:        t.State,
+		UpdateID:     t.Payload.UpdateID,
+		Provider:     t.Payload.Provider,
+		Retried:      t.Retried,
+		MaxRetry:     t.MaxRetry,
+		LastError:    t.LastErr,
+		LastFailedAt: optionalTimestamp(t.LastFailedAt),
+	}
+	// Pending tasks report the current time as their next run.
+	if t.State == "scheduled" || t.State == "retry" {
+		task.NextRetryAt = optionalTimestamp(t.NextProcessAt)
+	}
+	record, err := svc.GetQuoteResult(ctx, t.Payload.UpdateID)
+	switch {
+	case err == nil:
+		task.RecordStatus, task.RecordOrigin, task.RecordErrorCode = record.Status, record.Origin, record.ErrorCode
+	case !errors.Is(err, service.ErrNotFound) && !errors.Is(err, service.ErrInvalidUpdateID):
+		return task, err
+	}
+	return task, nil
+}
+
+// Task limits of GET /admin/queue/archived.
+const (
+	DefaultArchivedTasksLimit = 100
+	MaxArchivedTasksLimit     = 1000
+)
+
+// ArchivedTaskManager lists and retries archived update tasks; implemented by
+// *worker.ArchivedTasks.
+type ArchivedTaskManager interface {
+	ListArchived(ctx context.Context, offset, limit int) ([]worker.QueuedTask, bool, error)
+	RetryArchived(ctx context.Context, taskID string) (worker.QueuedTask, error)
+}
+
+// ArchivedTasksResponse lists archived update tasks; next_cursor is set when more tasks
+// follow and is passed as cursor to get them
+type ArchivedTasksResponse struct {
+	Tasks      []QueuedTaskResponse `json:"tasks"`
+	NextCursor string               `json:"next_cursor,omitempty" example:"100"`
+}
+
+// HandleListArchivedTasks godoc
+// @Summary List archived update tasks
+// @Description Lists the Asynq update tasks archived after exhausting their retries or failing permanently, queue by queue from the highest priority, each with its decoded payload, last error and the status, origin and error code of its update record. When more tasks follow, next_cursor is set and a Link header (RFC 8288) gives the next page (rel="next") and the first one (rel="first"); the cursor is an offset, so tasks archived or retried meanwhile shift the pages. The count per queue is exported as quotesvc_worker_archived_tasks. Requires the admin scope.
+// @Tags admin
+// @Produce json
+// @Param limit query int false "Maximum number of tasks" default(100) minimum(1) maximum(1000)
+// @Param cursor query string false "next_cursor of the previous page"
+// @Success 200 {object} ArchivedTasksResponse "Archived tasks"
+// @Header 200 {string} Link "Next and first pages, when more tasks follow"
+// @Failure 400 {object} ErrorResponse "Invalid limit or cursor"
+// @Failure 401 {object} ErrorResponse "Missing or invalid API key (when auth is enabled)"
+// @Failure 403 {object} ErrorResponse "API key lacks the admin scope"
+// @Failure 429 {object} QuotaExceededResponse "Monthly quota of the API key used up (when quotas are enabled)"
+// @Failure 500 {object} ErrorResponse "Internal error"
+// @Router /admin/queue/archived [get]
+func HandleListArchivedTasks(tasks ArchivedTaskManager, svc service.QuoteServiceInterface, errs ErrorConfig) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		limit := DefaultArchivedTasksLimit
+		if v := r.URL.Query().Get("limit"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 || n > MaxArchivedTasksLimit {
+				writeError(w, r, http.StatusBadRequest, ErrCodeInvalidFormat,
+					"limit must be between 1 and "+strconv.Itoa(MaxArchivedTasksLimit))
+				return
+			}
+			limit = n
+		}
+		offset := 0
+		if v := r.URL.Query().Get("cursor"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				writeError(w, r, http.StatusBadRequest, ErrCodeInvalidFormat, "invalid cursor")
+				return
+			}
+			offset = n
+		}
+
+		archived, more, err := tasks.ListArchived(r.Context(), offset, limit)
+		if err != nil {
+			writeError(w, r, http.StatusInternalServerError, ErrCodeInternal, "Internal error")
+			return
+		}
+		resp := ArchivedTasksResponse{Tasks: make([]QueuedTaskResponse, 0, len(archived))}
+		for _, t := range archived {
+			task, err := queuedTaskResponse(r.Context(), svc, t)
+			if err != nil {
+				writeServiceError(w, r, errs, err, "")
+				return
+			}
+			resp.Tasks = append(resp.Tasks, task)
+		}
+		if more {
+			resp.NextCursor = strconv.Itoa(offset + len(archived))
+		}
+		writePaginatedJSON(w, r, http.StatusOK, resp, resp.NextCursor)
+	}
+}
+
+// HandleRetryArchivedTask godoc
+// @Summary Retry an archived update task
+// @Description Resets the task's update record to PENDING, clearing its error, and moves the archived task back to its queue, where it runs with a fresh retry budget. An update record that no longer exists is not reset; the task then finds nothing to do. Requires the admin scope.
+// @Tags admin
+// @Produce json
+// @Param task_id path string true "Task ID" example(550e8400-e29b-41d4-a716-446655440000)
+// @Success 200 {object} QueuedTaskResponse "Requeued task"
+// @Failure 401 {object} ErrorResponse "Missing or invalid API key (when auth is enabled)"
+// @Failure 403 {object} ErrorResponse "API key lacks the admin scope"
+// @Failure 404 {object} ErrorResponse "No update task with this ID"
+// @Failure 409 {object} UpdateInFlightResponse "Task is not archived, its update already completed or changed meanwhile, or another update of the pair is in flight (named by update_id)"
+// @Failure 429 {object} QuotaExceededResponse "Monthly quota of the API key used up (when quotas are enabled)"
+// @Failure 500 {object} ErrorResponse "Internal error"
+// @Router /admin/queue/archived/{task_id}/retry [post]
+func HandleRetryArchivedTask(tasks ArchivedTaskManager, svc service.QuoteServiceInterface, errs ErrorConfig) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		t, err := tasks.RetryArchived(r.Context(), chi.URLParam(r, "task_id"))
+		var inFlight *service.UpdateInFlightError
+		switch {
+		case errors.Is(err, worker.ErrTaskNotFound):
+			writeError(w, r, http.StatusNotFound, ErrCodeNotFound, "task not found")
+			return
+		case errors.Is(err, worker.ErrTaskNotArchived):
+			writeError(w, r, http.StatusConflict, ErrCodeConflict, "task is not archived")
+			return
+		case errors.As(err, &inFlight):
+			writeJSON(w, r, http.StatusConflict, UpdateInFlightResponse{
+				Error:    "another update of the pair is in flight",
+				Code:     ErrCodeConflict,
+				UpdateID: inFlight.InFlightID,
+			})
+			return
+		case errors.Is(err, service.ErrAlreadyCompleted):
+			writeError(w, r, http.StatusConflict, ErrCodeConflict, "update already completed")
+			return
+		case errors.Is(err, service.ErrUpdateConflict):
+			writeError(w, r, http.StatusConflict, ErrCodeConflict, "update changed while it was reset, retry later")
+			return
+		case err != nil:
+			writeServiceError(w, r, errs, err, "")
+			return
+		}
+		task, err := queuedTaskResponse(r.Context(), svc, t)
+		if err != nil {
+			writeServiceError(w, r, errs, err, "")
+			return
+		}
+		writeJSON(w, r, http.StatusOK, task)
+	}
+}
+
+func optionalTimestamp(t time.Time) *string {
+	if t.IsZero() {
+		return nil
+	}
+	s := service.FormatTimestamp(t)
+	return &s
+}
+
+// PendingReconciler checks that PENDING updates still have a queued task.
+type PendingReconciler interface {
+	Reconcile(ctx context.Context) (worker.ReconcileSummary, error)
+}
+
+// ReconcileResponse counts what a reconciliation run did with the PENDING updates it checked
+type ReconcileResponse struct {
+	Checked  int `json:"checked" example:"12"`
+	Queued   int `json:"queued" example:"9"`
+	Requeued int `json:"requeued" example:"2"`
+	Failed   int `json:"failed" example:"1"`
+	Errors   int `json:"errors" example:"0"`
+}
+
+// HandleReconcile godoc
+// @Summary Requeue PENDING updates whose task was lost
+// @Description Looks up the task of every PENDING update older than reconcile.grace_sec (up to reconcile.batch_size, oldest first) in the update queues. Updates without a task are requeued, or marked FAILED if they are older than reconcile.give_up_sec. The same check runs at startup when reconcile.enabled is set. Requires the admin scope.
+// @Tags admin
+// @Produce json
+// @Success 200 {object} ReconcileResponse "Reconciliation summary"
+// @Failure 401 {object} ErrorResponse "Missing or invalid API key (when auth is enabled)"
+// @Failure 403 {object} ErrorResponse "API key lacks the admin scope"
+// @Failure 429 {object} QuotaExceededResponse "Monthly quota of the API key used up (when quotas are enabled)"
+// @Failure 500 {object} ErrorResponse "Internal error"
+// @Router /admin/reconcile [post]
+func HandleReconcile(rec PendingReconciler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sum, err := rec.Reconcile(r.Context())
+		if err != nil {
+			writeError(w, r, http.StatusInternalServerError, ErrCodeInternal, "Internal error")
+			return
+		}
+		writeJSON(w, r, http.StatusOK, ReconcileResponse{
+			Checked:  sum.Checked,
+			Queued:   sum.Queued,
+			Requeued: sum.Requeued,
+			Failed:   sum.Failed,
+			Errors:   sum.Errors,
+		})
+	}
+}
@@ -0,0 +1,396 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"quoteservice/internal/service"
+	"quoteservice/internal/worker"
+)
+
+type mockPairTaskLister struct {
+	tasks []worker.QueuedTask
+	err   error
+	pair  string
+}
+
+func (m *mockPairTaskLister) ListPairTasks(_ context.Context, pair service.Pair) ([]worker.QueuedTask, error) {
+	m.pair = pair.String()
+	return m.tasks, m.err
+}
+
+func TestHandleListPairTasks(t *testing.T) {
+	list := func(lister PairTaskLister, svc service.QuoteServiceInterface, query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/admin/queue/tasks"+query, nil)
+		w := httptest.NewRecorder()
+		HandleListPairTasks(lister, svc, ErrorConfig{}).ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("tasks carry their record status", func(t *testing.T) {
+		retryAt := time.Date(2025, 12, 1, 10, 16, 0, 0, time.UTC)
+		failedAt := retryAt.Add(-30 * time.Second)
+		lister := &mockPairTaskLister{tasks: []worker.QueuedTask{
+			{
+				ID: "t1", Queue: "default", State: "retry", Retried: 2, MaxRetry: 5,
+				NextProcessAt: retryAt, LastErr: "provider timeout", LastFailedAt: failedAt,
+				Payload: service.UpdateQuotePayload{UpdateID: "u1", Pair: service.Pair{Base: "EUR", Quote: "MXN"}, Provider: "frankfurter"},
+			},
+			{
+				ID: "t2", Queue: "high", State: "pending", NextProcessAt: retryAt,
+				Payload: service.UpdateQuotePayload{UpdateID: "u2", Pair: service.Pair{Base: "EUR", Quote: "MXN"}},
+			},
+		}}
+		svc := &mockQuoteService{
+			getQuoteResultFunc: func(_ context.Context, id string) (*service.QuoteResult, error) {
+				if id == "u2" {
+					return nil, service.ErrNotFound
+				}
+				return &service.QuoteResult{ID: id, Status: "RUNNING", Origin: "api"}, nil
+			},
+		}
+
+		w := list(lister, svc, "?pair=eur/mxn")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body)
+		}
+		if lister.pair != "EUR/MXN" {
+			t.Errorf("Expected the normalized pair EUR/MXN, got %s", lister.pair)
+		}
+		var resp PairTasksResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if resp.Pair != "EUR/MXN" || len(resp.Tasks) != 2 {
+			t.Fatalf("Unexpected response %+v", resp)
+		}
+		retry := resp.Tasks[0]
+		if retry.TaskID != "t1" || retry.State != "retry" || retry.UpdateID != "u1" || retry.Provider != "frankfurter" ||
+			retry.Retried != 2 || retry.MaxRetry != 5 || retry.LastError != "provider timeout" ||
+			retry.RecordStatus != "RUNNING" || retry.RecordOrigin != "api" {
+			t.Errorf("Unexpected retry task %+v", retry)
+		}
+		if retry.NextRetryAt == nil || *retry.NextRetryAt != "2025-12-01T10:16:00Z" ||
+			retry.LastFailedAt == nil || *retry.LastFailedAt != "2025-12-01T10:15:30Z" {
+			t.Errorf("Unexpected retry timestamps %+v", retry)
+		}
+		if pending := resp.Tasks[1]; pending.RecordStatus != "" || pending.NextRetryAt != nil || pending.LastFailedAt != nil {
+			t.Errorf("Expected a pending task without record or retry details, got %+v", pending)
+		}
+	})
+
+	t.Run("origin filter", func(t *testing.T) {
+		lister := &mockPairTaskLister{tasks: []worker.QueuedTask{
+			{ID: "t1", State: "pending", Payload: service.UpdateQuotePayload{UpdateID: "u1", Pair: service.Pair{Base: "EUR", Quote: "MXN"}}},
+			{ID: "t2", State: "pending", Payload: service.UpdateQuotePayload{UpdateID: "u2", Pair: service.Pair{Base: "EUR", Quote: "MXN"}}},
+		}}
+		svc := &mockQuoteService{
+			getQuoteResultFunc: func(_ context.Context, id string) (*service.QuoteResult, error) {
+				origin := "api"
+				if id == "u2" {
+					origin = "stream"
+				}
+				return &service.QuoteResult{ID: id, Status: "PENDING", Origin: origin}, nil
+			},
+		}
+
+		w := list(lister, svc, "?pair=EUR/MXN&origin=stream")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body)
+		}
+		var resp PairTasksResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if len(resp.Tasks) != 1 || resp.Tasks[0].TaskID != "t2" {
+			t.Errorf("Expected only the streamed task t2, got %+v", resp.Tasks)
+		}
+	})
+
+	t.Run("error code filter", func(t *testing.T) {
+		lister := &mockPairTaskLister{tasks: []worker.QueuedTask{
+			{ID: "t1", State: "retry", Payload: service.UpdateQuotePayload{UpdateID: "u1", Pair: service.Pair{Base: "EUR", Quote: "MXN"}}},
+			{ID: "t2", State: "retry", Payload: service.UpdateQuotePayload{UpdateID: "u2", Pair: service.Pair{Base: "EUR", Quote: "MXN"}}},
+			{ID: "t3", State: "retry", Payload: service.UpdateQuotePayload{UpdateID: "u3", Pair: service.Pair{Base: "EUR", Quote: "MXN"}}},
+		}}
+		svc := &mockQuoteService{
+			getQuoteResultFunc: func(_ context.Context, id string) (*service.QuoteResult, error) {
+				switch id {
+				case "u1":
+					return &service.QuoteResult{ID: id, Status: "FAILED", ErrorCode: "rate_limited"}, nil
+				case "u2":
+					return &service.QuoteResult{ID: id, Status: "FAILED", ErrorCode: "timeout"}, nil
+				}
+				// Failed before error codes were recorded.
+				return &service.QuoteResult{ID: id, Status: "FAILED"}, nil
+			},
+		}
+
+		w := list(lister, svc, "?pair=EUR/MXN&error_code=timeout")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body)
+		}
+		var resp PairTasksResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if len(resp.Tasks) != 1 || resp.Tasks[0].TaskID != "t2" || resp.Tasks[0].RecordErrorCode != "timeout" {
+			t.Errorf("Expected only the timed-out task t2, got %+v", resp.Tasks)
+		}
+	})
+
+	t.Run("unknown error code returns 400", func(t *testing.T) {
+		w := list(&mockPairTaskLister{}, &mockQuoteService{}, "?pair=EUR/MXN&error_code=flaky")
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("Expected status 400, got %d", w.Code)
+		}
+		assertErrorCode(t, w, ErrCodeInvalidFormat)
+	})
+
+	t.Run("unknown origin returns 400", func(t *testing.T) {
+		w := list(&mockPairTaskLister{}, &mockQuoteService{}, "?pair=EUR/MXN&origin=cron")
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("Expected status 400, got %d", w.Code)
+		}
+		assertErrorCode(t, w, ErrCodeInvalidFormat)
+	})
+
+	t.Run("invalid pair returns 400", func(t *testing.T) {
+		w := list(&mockPairTaskLister{}, &mockQuoteService{}, "?pair=EURMXN")
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("Expected status 400, got %d", w.Code)
+		}
+		assertErrorCode(t, w, ErrCodeInvalidFormat)
+	})
+
+	t.Run("inspector failure returns 500", func(t *testing.T) {
+		w := list(&mockPairTaskLister{err: errors.New("redis down")}, &mockQuoteService{}, "?pair=EUR/MXN")
+		if w.Code != http.StatusInternalServerError {
+			t.Fatalf("Expected status 500, got %d", w.Code)
+		}
+		assertErrorCode(t, w, ErrCodeInternal)
+	})
+}
+
+type mockArchivedTaskManager struct {
+	tasks    []worker.QueuedTask
+	more     bool
+	listErr  error
+	retryErr error
+	offset   int
+	limit    int
+	retried  string
+}
+
+func (m *mockArchivedTaskManager) ListArchived(_ context.Context, offset, limit int) ([]worker.QueuedTask, bool, error) {
+	m.offset, m.limit = offset, limit
+	return m.tasks, m.more, m.listErr
+}
+
+func (m *mockArchivedTaskManager) RetryArchived(_ context.Context, taskID string) (worker.QueuedTask, error) {
+	m.retried = taskID
+	if m.retryErr != nil {
+		return worker.QueuedTask{}, m.retryErr
+	}
+	task := m.tasks[0]
+	task.State = "pending"
+	return task, nil
+}
+
+func archivedTaskFixture() []worker.QueuedTask {
+	return []worker.QueuedTask{{
+		ID: "u1", Queue: "default", State: "archived", Retried: 3, MaxRetry: 3,
+		LastErr: "provider timeout", LastFailedAt: time.Date(2025, 12, 1, 10, 15, 30, 0, time.UTC),
+		Payload: service.UpdateQuotePayload{UpdateID: "u1", Pair: service.Pair{Base: "EUR", Quote: "MXN"}},
+	}}
+}
+
+func TestHandleListArchivedTasks(t *testing.T) {
+	list := func(tasks ArchivedTaskManager, query string) *httptest.ResponseRecorder {
+		svc := &mockQuoteService{
+			getQuoteResultFunc: func(_ context.Context, id string) (*service.QuoteResult, error) {
+				return &service.QuoteResult{ID: id, Status: "FAILED", Origin: "api", ErrorCode: "timeout"}, nil
+			},
+		}
+		w := httptest.NewRecorder()
+		HandleListArchivedTasks(tasks, svc, ErrorConfig{}).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/queue/archived"+query, nil))
+		return w
+	}
+
+	m := &mockArchivedTaskManager{tasks: archivedTaskFixture()}
+	w := list(m, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body)
+	}
+	if m.limit != DefaultArchivedTasksLimit {
+		t.Errorf("Expected the default limit, got %d", m.limit)
+	}
+	var resp ArchivedTasksResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(resp.Tasks) != 1 {
+		t.Fatalf("Expected 1 task, got %+v", resp.Tasks)
+	}
+	if task := resp.Tasks[0]; task.TaskID != "u1" || task.State != "archived" || task.LastError != "provider timeout" ||
+		task.NextRetryAt != nil || task.RecordStatus != "FAILED" || task.RecordErrorCode != "timeout" {
+		t.Errorf("Unexpected task %+v", task)
+	}
+
+	if w := list(m, "?limit=5"); w.Code != http.StatusOK || m.limit != 5 {
+		t.Errorf("Expected limit 5 to be passed on, got status %d limit %d", w.Code, m.limit)
+	}
+	for _, query := range []string{"?limit=0", "?limit=1001", "?limit=all", "?cursor=-1", "?cursor=abc"} {
+		if w := list(m, query); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", query, w.Code)
+		}
+	}
+	if w := list(&mockArchivedTaskManager{listErr: errors.New("redis down")}, ""); w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status 500, got %d", w.Code)
+	}
+}
+
+func TestHandleListArchivedTasks_Pagination(t *testing.T) {
+	svc := &mockQuoteService{
+		getQuoteResultFunc: func(_ context.Context, id string) (*service.QuoteResult, error) {
+			return &service.QuoteResult{ID: id, Status: "FAILED", Origin: "api"}, nil
+		},
+	}
+	list := func(m *mockArchivedTaskManager, query string) (*httptest.ResponseRecorder, ArchivedTasksResponse) {
+		t.Helper()
+		w := httptest.NewRecorder()
+		HandleListArchivedTasks(m, svc, ErrorConfig{}).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/queue/archived"+query, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected status 200, got %d: %s", query, w.Code, w.Body)
+		}
+		var resp ArchivedTasksResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("%s: failed to decode response: %v", query, err)
+		}
+		return w, resp
+	}
+
+	// A page followed by more tasks links to the next one.
+	m := &mockArchivedTaskManager{tasks: archivedTaskFixture(), more: true}
+	w, resp := list(m, "?limit=1&cursor=4")
+	if m.offset != 4 || m.limit != 1 {
+		t.Errorf("Expected offset 4 and limit 1 to be passed on, got %d and %d", m.offset, m.limit)
+	}
+	if resp.NextCursor != "5" {
+		t.Errorf("Expected next_cursor 5, got %q", resp.NextCursor)
+	}
+	wantLink := `</admin/queue/archived?cursor=5&limit=1>; rel="next", </admin/queue/archived?limit=1>; rel="first"`
+	if got := w.Header().Get("Link"); got != wantLink {
+		t.Errorf("Expected Link %s, got %s", wantLink, got)
+	}
+
+	// The last page has neither.
+	m.more = false
+	if w, resp := list(m, "?limit=1&cursor=5"); resp.NextCursor != "" || w.Header().Get("Link") != "" {
+		t.Errorf("Expected no next_cursor or Link on the last page, got %q and %q", resp.NextCursor, w.Header().Get("Link"))
+	}
+}
+
+func TestHandleRetryArchivedTask(t *testing.T) {
+	retry := func(tasks ArchivedTaskManager) *httptest.ResponseRecorder {
+		svc := &mockQuoteService{
+			getQuoteResultFunc: func(_ context.Context, id string) (*service.QuoteResult, error) {
+				return &service.QuoteResult{ID: id, Status: "PENDING", Origin: "api"}, nil
+			},
+		}
+		r := chi.NewRouter()
+		r.Post("/admin/queue/archived/{task_id}/retry", HandleRetryArchivedTask(tasks, svc, ErrorConfig{}))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/admin/queue/archived/u1/retry", nil))
+		return w
+	}
+
+	m := &mockArchivedTaskManager{tasks: archivedTaskFixture()}
+	w := retry(m)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body)
+	}
+	if m.retried != "u1" {
+		t.Errorf("Expected task u1 to be retried, got %q", m.retried)
+	}
+	var resp QueuedTaskResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if resp.TaskID != "u1" || resp.State != "pending" || resp.RecordStatus != "PENDING" {
+		t.Errorf("Unexpected response %+v", resp)
+	}
+
+	for _, tc := range []struct {
+		err        error
+		wantStatus int
+		wantCode   int
+	}{
+		{worker.ErrTaskNotFound, http.StatusNotFound, ErrCodeNotFound},
+		{worker.ErrTaskNotArchived, http.StatusConflict, ErrCodeConflict},
+		{service.ErrAlreadyCompleted, http.StatusConflict, ErrCodeConflict},
+		{service.ErrUpdateConflict, http.StatusConflict, ErrCodeConflict},
+		{errors.New("redis down"), http.StatusInternalServerError, ErrCodeInternal},
+	} {
+		w := retry(&mockArchivedTaskManager{retryErr: tc.err})
+		if w.Code != tc.wantStatus {
+			t.Errorf("%v: expected status %d, got %d", tc.err, tc.wantStatus, w.Code)
+			continue
+		}
+		assertErrorCode(t, w, tc.wantCode)
+	}
+
+	w = retry(&mockArchivedTaskManager{retryErr: &service.UpdateInFlightError{UpdateID: "u1", InFlightID: "u2"}})
+	if w.Code != http.StatusConflict {
+		t.Fatalf("Expected status 409 for a pair in flight, got %d: %s", w.Code, w.Body)
+	}
+	var inFlight UpdateInFlightResponse
+	if err := json.NewDecoder(w.Body).Decode(&inFlight); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if inFlight.Code != ErrCodeConflict || inFlight.UpdateID != "u2" {
+		t.Errorf("Expected code %d naming update u2, got %+v", ErrCodeConflict, inFlight)
+	}
+}
+
+type mockPendingReconciler struct {
+	summary worker.ReconcileSummary
+	err     error
+}
+
+func (m mockPendingReconciler) Reconcile(context.Context) (worker.ReconcileSummary, error) {
+	return m.summary, m.err
+}
+
+func TestHandleReconcile(t *testing.T) {
+	reconcile := func(rec PendingReconciler) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		HandleReconcile(rec).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/admin/reconcile", nil))
+		return w
+	}
+
+	w := reconcile(mockPendingReconciler{summary: worker.ReconcileSummary{Checked: 4, Queued: 1, Requeued: 2, Failed: 1}})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	var resp ReconcileResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if resp != (ReconcileResponse{Checked: 4, Queued: 1, Requeued: 2, Failed: 1}) {
+		t.Errorf("Unexpected summary %+v", resp)
+	}
+
+	w = reconcile(mockPendingReconciler{err: errors.New("db down")})
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("Expected status 500, got %d", w.Code)
+	}
+	assertErrorCode(t, w, ErrCodeInternal)
+}
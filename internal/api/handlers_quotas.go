@@ -0,0 +1,60 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"quoteservice/internal/quota"
+	"quoteservice/internal/service"
+)
+
+// QuotaReporter reports the monthly usage of every API key; implemented by
+// *quota.Tracker.
+type QuotaReporter interface {
+	Usage(ctx context.Context) ([]quota.Usage, error)
+}
+
+// QuotaUsageResponse is an API key's usage in the current monthly window
+type QuotaUsageResponse struct {
+	Key       string `json:"key" example:"crypto-desk"`
+	Limit     int64  `json:"limit" example:"100000"` // 0 means unlimited.
+	Used      int64  `json:"used" example:"1234"`
+	Remaining *int64 `json:"remaining,omitempty" example:"98766"` // Omitted for unlimited keys.
+	ResetAt   string `json:"reset_at" example:"2025-12-01T00:00:00Z"`
+}
+
+// QuotasResponse lists the usage of every API key
+type QuotasResponse struct {
+	Quotas []QuotaUsageResponse `json:"quotas"`
+}
+
+// HandleListQuotas godoc
+// @Summary List API key quota usage
+// @Description Returns each configured API key's request count in the current calendar month (UTC), its monthly limit (0 for unlimited) and when the window resets. Available when auth.quota_enabled is set. Requires the admin scope.
+// @Tags admin
+// @Produce json
+// @Success 200 {object} QuotasResponse "Usage per API key, sorted by name"
+// @Failure 401 {object} ErrorResponse "Missing or invalid API key (when auth is enabled)"
+// @Failure 403 {object} ErrorResponse "API key lacks the admin scope"
+// @Failure 429 {object} QuotaExceededResponse "Monthly quota of the API key used up (when quotas are enabled)"
+// @Failure 500 {object} ErrorResponse "Internal error"
+// @Router /admin/quotas [get]
+func HandleListQuotas(reporter QuotaReporter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		usage, err := reporter.Usage(r.Context())
+		if err != nil {
+			writeError(w, r, http.StatusInternalServerError, ErrCodeInternal, "Internal error")
+			return
+		}
+		resp := QuotasResponse{Quotas: make([]QuotaUsageResponse, 0, len(usage))}
+		for _, u := range usage {
+			q := QuotaUsageResponse{Key: u.Key, Limit: u.Limit, Used: u.Used, ResetAt: service.FormatTimestamp(u.Reset)}
+			if u.Limit > 0 {
+				remaining := u.Remaining()
+				q.Remaining = &remaining
+			}
+			resp.Quotas = append(resp.Quotas, q)
+		}
+		writeJSON(w, r, http.StatusOK, resp)
+	}
+}
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"quoteservice/internal/quota"
+)
+
+// mockQuotaTracker reports usage and, as a middleware.QuotaConsumer, counts every
+// request as over the limit.
+type mockQuotaTracker struct {
+	usage []quota.Usage
+	err   error
+}
+
+func (m mockQuotaTracker) Usage(context.Context) ([]quota.Usage, error) {
+	return m.usage, m.err
+}
+
+func (m mockQuotaTracker) Consume(_ context.Context, key string) (quota.Usage, error) {
+	return quota.Usage{Key: key, Limit: 10, Used: 11, Reset: time.Now().Add(time.Hour)}, nil
+}
+
+func TestHandleListQuotas(t *testing.T) {
+	list := func(reporter QuotaReporter) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		HandleListQuotas(reporter).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/quotas", nil))
+		return w
+	}
+	reset := time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC)
+
+	w := list(mockQuotaTracker{usage: []quota.Usage{
+		{Key: "desk", Limit: 100, Used: 30, Reset: reset},
+		{Key: "ops", Used: 7, Reset: reset},
+	}})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	var resp QuotasResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(resp.Quotas) != 2 {
+		t.Fatalf("Expected 2 keys, got %+v", resp.Quotas)
+	}
+	if q := resp.Quotas[0]; q.Key != "desk" || q.Limit != 100 || q.Used != 30 || q.Remaining == nil || *q.Remaining != 70 ||
+		q.ResetAt != "2025-12-01T00:00:00Z" {
+		t.Errorf("Unexpected usage %+v", q)
+	}
+	if q := resp.Quotas[1]; q.Limit != 0 || q.Remaining != nil {
+		t.Errorf("Expected an unlimited key without remaining, got %+v", q)
+	}
+
+	w = list(mockQuotaTracker{err: errors.New("redis down")})
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("Expected status 500, got %d", w.Code)
+	}
+	assertErrorCode(t, w, ErrCodeInternal)
+}
@@ -1,24 +1,51 @@
 package api
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"math"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 
+	"quoteservice/internal/api/middleware"
 	"quoteservice/internal/service"
 )
 
 // UpdateRequest represents the request body for quote update
 type UpdateRequest struct {
 	Pair string `json:"pair" example:"EUR/MXN"`
+	// Provider, if set, forces the update through the named provider only. Requires
+	// the admin scope when API key auth is enabled.
+	Provider string `json:"provider,omitempty" example:"frankfurter"`
+}
+
+// FetchRequest represents the request body for a synchronous quote fetch
+type FetchRequest struct {
+	Pair string `json:"pair" example:"EUR/MXN"`
+	// TimeoutMs is how long to wait for the update to finish; longer waits are capped
+	// below the server's write timeout.
+	TimeoutMs int `json:"timeout_ms" example:"3000"`
 }
 
 // UpdateResponse represents the response for a quote update request
 type UpdateResponse struct {
 	UpdateID string `json:"update_id" example:"123e4567-e89b-12d3-a456-426614174000"`
+	// Reason is set when an existing update was returned instead of a new one:
+	// "pending_exists" or "cooldown_active".
+	Reason string `json:"reason,omitempty" example:"cooldown_active"`
+	// CooldownRemainingSec is how long until a new update can be started; only set with
+	// reason "cooldown_active".
+	CooldownRemainingSec int `json:"cooldown_remaining_sec,omitempty" example:"42"`
+	// PollAfterMs is how long to wait before polling GET /quotes/{update_id}, estimated
+	// from the queue depth; only set while the update is unfinished.
+	PollAfterMs int `json:"poll_after_ms,omitempty" example:"2000"`
 }
 
 // QuoteResponse represents the response for a quote by ID
@@ -29,146 +56,623 @@ type QuoteResponse struct {
 	Status    string  `json:"status" example:"SUCCESS"`
 	Price     *string `json:"price,omitempty" example:"18.7543"`
 	UpdatedAt *string `json:"updated_at,omitempty" example:"2025-12-01T10:15:30Z"`
-	Error     *string `json:"error,omitempty" example:"Failed to fetch from provider"`
+	// RateTimestamp is when the provider observed the price; UpdatedAt is when it was stored.
+	RateTimestamp *string `json:"rate_timestamp,omitempty" example:"2025-12-01T00:00:00Z"`
+	Error         *string `json:"error,omitempty" example:"Failed to fetch from provider"`
+	// ErrorCode classifies Error; omitted for updates that failed before codes were recorded.
+	ErrorCode string `json:"error_code,omitempty" example:"timeout" enums:"timeout,rate_limited,auth,provider_config,unsupported_pair,provider_unavailable,bad_response,invalid_request,task_lost,internal"`
+	// Origin is the code path that created the update.
+	Origin string `json:"origin,omitempty" example:"api" enums:"api,scheduler,auto_refresh,backfill,retry,stream"`
+	// Events is the status timeline, oldest first; only set with ?include_events=true.
+	Events []StatusEventResponse `json:"events,omitempty"`
+	// InstanceID is the service instance that last marked the update RUNNING; only set
+	// with ?include_events=true, and omitted if it was not recorded.
+	InstanceID string `json:"instance_id,omitempty" example:"quote-worker-7d9f-3fa2c1"`
+	// Verification is the spread across providers; only set with ?include_verification=true
+	// once it has been recorded.
+	Verification *VerificationResponse `json:"verification,omitempty"`
+}
+
+// VerificationResponse represents how far the providers' rates for an update diverged
+type VerificationResponse struct {
+	MinPrice  string `json:"min_price" example:"18.7401"`
+	MaxPrice  string `json:"max_price" example:"18.7612"`
+	SpreadPct string `json:"spread_pct" example:"0.1126"`
+	Providers int    `json:"providers" example:"2"`
+}
+
+// StatusEventResponse represents the moment a quote update entered a status
+type StatusEventResponse struct {
+	Status string  `json:"status" example:"RUNNING"`
+	At     string  `json:"at" example:"2025-12-01T10:15:29Z"`
+	Detail *string `json:"detail,omitempty" example:"18.754300"`
+	// InstanceID is the service instance that made a RUNNING transition.
+	InstanceID string `json:"instance_id,omitempty" example:"quote-worker-7d9f-3fa2c1"`
 }
 
 // LatestResponse represents the response for latest quote
 type LatestResponse struct {
-	Base      string `json:"base" example:"EUR"`
-	Quote     string `json:"quote" example:"MXN"`
-	Price     string `json:"price" example:"18.7543"`
-	UpdatedAt string `json:"updated_at" example:"2025-12-01T10:15:30Z"`
+	// UpdateID is the update that stored the price, for GET /quotes/{update_id}; omitted
+	// for a streamed price that arrived while an update of the pair was in flight.
+	UpdateID      string `json:"update_id,omitempty" example:"123e4567-e89b-12d3-a456-426614174000"`
+	Base          string `json:"base" example:"EUR"`
+	Quote         string `json:"quote" example:"MXN"`
+	Price         string `json:"price" example:"18.7543"`
+	UpdatedAt     string `json:"updated_at" example:"2025-12-01T10:15:30Z"`
+	RateTimestamp string `json:"rate_timestamp" example:"2025-12-01T00:00:00Z"`
+	// Derived is "inverse" when the price was computed from the inverse pair's cached
+	// rate instead of fetched; omitted for fetched prices.
+	Derived string `json:"derived,omitempty" example:"inverse"`
+}
+
+// LatestNotFoundResponse is the 404 body of GET /quotes/latest with
+// include_last_attempt=true. The last_* fields are omitted for a pair that has never
+// been updated.
+type LatestNotFoundResponse struct {
+	Error         string `json:"error" example:"No quote available for EUR/MXN"`
+	Code          int    `json:"code" example:"4041"`
+	LastStatus    string `json:"last_status,omitempty" example:"FAILED"`
+	LastError     string `json:"last_error,omitempty" example:"unsupported currency pair"`
+	LastAttemptAt string `json:"last_attempt_at,omitempty" example:"2025-12-01T09:12:04Z"`
+}
+
+// DefaultLatestResponse lists the latest quotes of the configured default pairs, in the
+// configured order.
+type DefaultLatestResponse struct {
+	Quotes []DefaultLatestEntry `json:"quotes"`
+}
+
+// DefaultLatestEntry is one pair of a DefaultLatestResponse. A pair without a
+// successful quote has only base, quote and not_found.
+type DefaultLatestEntry struct {
+	Base          string `json:"base" example:"EUR"`
+	Quote         string `json:"quote" example:"MXN"`
+	Price         string `json:"price,omitempty" example:"18.7543"`
+	UpdatedAt     string `json:"updated_at,omitempty" example:"2025-12-01T10:15:30Z"`
+	RateTimestamp string `json:"rate_timestamp,omitempty" example:"2025-12-01T00:00:00Z"`
+	Derived       string `json:"derived,omitempty" example:"inverse"`
+	NotFound      bool   `json:"not_found,omitempty" example:"false"`
+}
+
+// HistoricalResponse represents the quote that was current at a point in time
+type HistoricalResponse struct {
+	Base  string `json:"base" example:"EUR"`
+	Quote string `json:"quote" example:"USD"`
+	Price string `json:"price" example:"1.0850"`
+	AsOf  string `json:"as_of" example:"2024-06-15T11:58:02Z"`
+}
+
+// ComparisonPoint is the quote used for one side of a comparison
+type ComparisonPoint struct {
+	Price string `json:"price" example:"20.7481"`
+	AsOf  string `json:"as_of" example:"2025-01-01T21:04:12Z"`
+}
+
+// CompareResponse represents a pair's rate at two points in time and the change between them
+type CompareResponse struct {
+	Base      string          `json:"base" example:"EUR"`
+	Quote     string          `json:"quote" example:"MXN"`
+	At        ComparisonPoint `json:"at"`
+	Vs        ComparisonPoint `json:"vs"`
+	Change    string          `json:"change" example:"-0.8302"`
+	ChangePct string          `json:"change_pct" example:"-4.0013"`
+}
+
+// CompareNotFoundResponse is the 404 body of GET /quotes/compare. Missing names the
+// query params without a quote at or before them.
+type CompareNotFoundResponse struct {
+	Error   string   `json:"error" example:"No quote available for EUR/MXN at or before at=2025-01-02"`
+	Code    int      `json:"code" example:"4041"`
+	Missing []string `json:"missing" example:"at"`
 }
 
 // HandleRequestUpdate godoc
 // @Summary Request asynchronous quote update
-// @Description Initiates an asynchronous update for a currency pair. Returns immediately with an update_id for tracking. Does not block on external fetch.
+// @Description Initiates an asynchronous update for a currency pair. Returns immediately with an update_id for tracking. Does not block on external fetch. If an update for the pair is already in flight, or the pair's latest successful update is within its refresh cooldown, that update's id is returned and reason says which. An optional provider fetches the rate from that provider alone and skips the refresh cooldown; it requires the admin scope when API key auth is enabled. While the update is unfinished, poll_after_ms suggests when to poll its result.
 // @Tags quotes
 // @Accept json
 // @Produce json
-// @Param request body UpdateRequest true "Currency pair in format XXX/YYY"
+// @Param request body UpdateRequest true "Currency pair in format XXX/YYY and optional provider"
 // @Success 202 {object} UpdateResponse "Update request accepted"
-// @Failure 400 {object} ErrorResponse "Invalid currency code format"
+// @Failure 400 {object} UnknownProviderResponse "Invalid currency code format, unsupported currency or unknown provider; valid_providers is only set for an unknown provider"
+// @Failure 401 {object} ErrorResponse "Missing or invalid API key (when auth is enabled)"
+// @Failure 403 {object} ErrorResponse "API key lacks the write scope, provider override without the admin scope, or pair not permitted for the API key"
+// @Failure 429 {object} QuotaExceededResponse "Monthly quota of the API key used up (when quotas are enabled)"
 // @Failure 500 {object} ErrorResponse "Internal error"
+// @Failure 503 {object} ErrorResponse "Task queue unavailable, or worker.max_pending updates are already PENDING (retry after the Retry-After header), or database schema not ready"
+// @Header 503 {integer} Retry-After "Seconds to wait before retrying"
 // @Router /quotes/update [post]
-func HandleRequestUpdate(svc service.QuoteServiceInterface) http.HandlerFunc {
+//
+// canForceProvider reports whether the request may set provider; nil allows every
+// request.
+func HandleRequestUpdate(svc service.QuoteServiceInterface, canForceProvider func(*http.Request) bool, errs ErrorConfig) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		var req struct {
-			Pair string `json:"pair"`
-		}
+		var req UpdateRequest
 		dec := json.NewDecoder(r.Body)
 		if err := dec.Decode(&req); err != nil {
-			writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "invalid JSON"})
+			writeError(w, r, http.StatusBadRequest, ErrCodeInvalidFormat, "invalid JSON")
 			return
 		}
-		pair := strings.TrimSpace(req.Pair)
+		pair := service.NormalizePair(req.Pair)
 		if pair == "" {
-			writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "pair is required"})
+			writeError(w, r, http.StatusBadRequest, ErrCodeInvalidFormat, "pair is required")
 			return
 		}
-		updateID, _, err := svc.RequestQuoteUpdate(r.Context(), pair)
+		providerName := strings.TrimSpace(req.Provider)
+		if providerName != "" && canForceProvider != nil && !canForceProvider(r) {
+			writeError(w, r, http.StatusForbidden, ErrCodeForbidden, "provider requires the admin scope")
+			return
+		}
+		result, err := svc.RequestQuoteUpdate(r.Context(), pair, service.UpdateOptions{Provider: providerName})
 		if err != nil {
-			switch {
-			case errors.Is(err, service.ErrInvalidPairFormat),
-				errors.Is(err, service.ErrUnsupportedCurrency):
-				writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: err.Error()})
-			default:
-				writeJSON(w, http.StatusInternalServerError, ErrorResponse{Error: "Internal error"})
-			}
+			writeServiceError(w, r, errs, err, "Not found")
 			return
 		}
 
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusAccepted)
-		resp := UpdateResponse{UpdateID: updateID}
-		_ = json.NewEncoder(w).Encode(resp)
+		resp := UpdateResponse{
+			UpdateID:    result.UpdateID,
+			Reason:      string(result.Reason),
+			PollAfterMs: int(result.PollAfter.Milliseconds()),
+		}
+		if result.Reason == service.ReuseReasonCooldownActive {
+			// Round up so an active cooldown never reports 0 seconds.
+			resp.CooldownRemainingSec = int(math.Ceil(result.CooldownRemaining.Seconds()))
+		}
+		writeJSON(w, r, http.StatusAccepted, resp)
+	}
+}
+
+// HandleFetchQuote godoc
+// @Summary Fetch a quote synchronously with a deadline
+// @Description Requests an update for a currency pair like POST /quotes/update and waits up to timeout_ms for it to finish. If it finishes in time, its result is returned with 200, whether it succeeded or failed. Otherwise the update keeps running and 202 returns its update_id, to be polled with GET /quotes/{update_id}. timeout_ms is capped a little below the server's write timeout, so the response is always written.
+// @Tags quotes
+// @Accept json
+// @Produce json
+// @Param request body FetchRequest true "Currency pair in format XXX/YYY and how long to wait"
+// @Success 200 {object} QuoteResponse "Update finished in time"
+// @Success 202 {object} UpdateResponse "Update still running; poll it by update_id"
+// @Header 200 {string} X-Quote-Signature "Hex HMAC-SHA256 of base|quote|price|updated_at, empty for omitted fields; only sent when signing is enabled"
+// @Header 200 {string} X-Quote-Signature-Key-Id "Id of the key that produced X-Quote-Signature"
+// @Failure 400 {object} ErrorResponse "Invalid JSON, currency code format, unsupported currency or timeout_ms"
+// @Failure 401 {object} ErrorResponse "Missing or invalid API key (when auth is enabled)"
+// @Failure 403 {object} ErrorResponse "API key lacks the write scope or is not permitted to access the pair"
+// @Failure 429 {object} QuotaExceededResponse "Monthly quota of the API key used up (when quotas are enabled)"
+// @Failure 500 {object} ErrorResponse "Internal error"
+// @Failure 503 {object} ErrorResponse "Task queue unavailable, or worker.max_pending updates are already PENDING (retry after the Retry-After header), or database schema not ready"
+// @Header 503 {integer} Retry-After "Seconds to wait before retrying"
+// @Router /quotes/fetch [post]
+//
+// maxWait caps timeout_ms; it must leave time to write the response before the
+// server's WriteTimeout.
+func HandleFetchQuote(svc service.QuoteServiceInterface, signer *QuoteSigner, maxWait time.Duration, errs ErrorConfig) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req FetchRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, r, http.StatusBadRequest, ErrCodeInvalidFormat, "invalid JSON")
+			return
+		}
+		pair := service.NormalizePair(req.Pair)
+		if pair == "" {
+			writeError(w, r, http.StatusBadRequest, ErrCodeInvalidFormat, "pair is required")
+			return
+		}
+		if req.TimeoutMs <= 0 {
+			writeError(w, r, http.StatusBadRequest, ErrCodeInvalidFormat, "timeout_ms must be positive")
+			return
+		}
+		wait := min(time.Duration(req.TimeoutMs)*time.Millisecond, maxWait)
+
+		quote, err := svc.FetchQuote(r.Context(), pair, wait)
+		if err != nil {
+			writeServiceError(w, r, errs, err, "Not found")
+			return
+		}
+		if !quote.Finished() {
+			writeJSON(w, r, http.StatusAccepted, UpdateResponse{
+				UpdateID:    quote.ID,
+				PollAfterMs: int(quote.PollAfter.Milliseconds()),
+			})
+			return
+		}
+
+		resp := quoteResponse(quote)
+		signer.setHeaders(w.Header(), resp.Base, resp.Quote, derefStr(resp.Price), derefStr(resp.UpdatedAt))
+		writeJSON(w, r, http.StatusOK, resp)
 	}
 }
 
 // HandleGetQuoteByID godoc
 // @Summary Get quote update status and result by ID
-// @Description Retrieves the status and result of a quote update request by its update_id. Returns price and timestamp when status is SUCCESS.
+// @Description Retrieves the status and result of a quote update request by its update_id. Returns price and timestamp when status is SUCCESS. With include_events=true the response also lists when the update entered each status and the service instance that processed it. With include_verification=true a SUCCESS response includes the spread of the rates reported by all providers, if verification is enabled.
 // @Tags quotes
 // @Accept json
 // @Produce json
 // @Param update_id path string true "Update ID (UUID)" format(uuid)
+// @Param include_events query bool false "Include the status timeline"
+// @Param include_verification query bool false "Include the spread across providers"
 // @Success 200 {object} QuoteResponse "Quote found"
+// @Header 200 {integer} Retry-After "Seconds to wait before polling again; only sent while the update is PENDING or RUNNING"
+// @Header 200 {string} X-Quote-Signature "Hex HMAC-SHA256 of base|quote|price|updated_at, empty for omitted fields; only sent when signing is enabled"
+// @Header 200 {string} X-Quote-Signature-Key-Id "Id of the key that produced X-Quote-Signature"
 // @Failure 400 {object} ErrorResponse "Invalid update_id format"
-// @Failure 404 {object} ErrorResponse "Unknown update_id"
+// @Failure 401 {object} ErrorResponse "Missing or invalid API key (when auth is enabled)"
+// @Failure 403 {object} ErrorResponse "API key lacks the read scope"
+// @Failure 404 {object} ErrorResponse "Unknown update_id, or an update for a pair not permitted for the API key"
+// @Failure 429 {object} QuotaExceededResponse "Monthly quota of the API key used up (when quotas are enabled)"
 // @Failure 500 {object} ErrorResponse "Internal error"
+// @Failure 503 {object} ErrorResponse "Database schema not ready"
 // @Router /quotes/{update_id} [get]
-func HandleGetQuoteByID(svc service.QuoteServiceInterface) http.HandlerFunc {
+func HandleGetQuoteByID(svc service.QuoteServiceInterface, signer *QuoteSigner, errs ErrorConfig) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		updateID := chi.URLParam(r, "update_id")
 		if updateID == "" {
-			writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "update_id is required"})
+			writeError(w, r, http.StatusBadRequest, ErrCodeInvalidFormat, "update_id is required")
 			return
 		}
 
 		quote, err := svc.GetQuoteResult(r.Context(), updateID)
 		if err != nil {
-			switch {
-			case errors.Is(err, service.ErrInvalidUpdateID):
-				writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: err.Error()})
-			case errors.Is(err, service.ErrNotFound):
-				writeJSON(w, http.StatusNotFound, ErrorResponse{Error: "Unknown update_id"})
-			default:
-				writeJSON(w, http.StatusInternalServerError, ErrorResponse{Error: "Internal error"})
-			}
+			writeServiceError(w, r, errs, err, "Unknown update_id")
 			return
 		}
 
-		writeJSON(w, http.StatusOK, QuoteResponse{
-			UpdateID:  quote.ID,
-			Base:      quote.Base,
-			Quote:     quote.Quote,
-			Status:    quote.Status,
-			Price:     quote.Price,
-			UpdatedAt: quote.UpdatedAt,
-			Error:     quote.ErrorMsg,
-		})
+		resp := quoteResponse(quote)
+
+		if includeVerification, _ := strconv.ParseBool(r.URL.Query().Get("include_verification")); includeVerification && quote.Verification != nil {
+			v := quote.Verification
+			resp.Verification = &VerificationResponse{
+				MinPrice:  v.MinPrice,
+				MaxPrice:  v.MaxPrice,
+				SpreadPct: v.SpreadPct,
+				Providers: v.Providers,
+			}
+		}
+
+		if includeEvents, _ := strconv.ParseBool(r.URL.Query().Get("include_events")); includeEvents {
+			events, err := svc.GetStatusEvents(r.Context(), updateID)
+			if err != nil {
+				writeServiceError(w, r, errs, err, "Unknown update_id")
+				return
+			}
+			resp.Events = make([]StatusEventResponse, len(events))
+			for i, ev := range events {
+				resp.Events[i] = StatusEventResponse{Status: ev.Status, At: ev.At, Detail: ev.Detail, InstanceID: ev.InstanceID}
+			}
+			resp.InstanceID = quote.InstanceID
+		}
+
+		if quote.PollAfter > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(retryAfterSeconds(quote.PollAfter)))
+		}
+		signer.setHeaders(w.Header(), resp.Base, resp.Quote, derefStr(resp.Price), derefStr(resp.UpdatedAt))
+		writeJSON(w, r, http.StatusOK, resp)
+	}
+}
+
+func quoteResponse(quote *service.QuoteResult) QuoteResponse {
+	return QuoteResponse{
+		UpdateID:      quote.ID,
+		Base:          quote.Base,
+		Quote:         quote.Quote,
+		Status:        quote.Status,
+		Price:         quote.Price,
+		UpdatedAt:     quote.UpdatedAt,
+		RateTimestamp: quote.RateTimestamp,
+		Error:         quote.ErrorMsg,
+		ErrorCode:     quote.ErrorCode,
+		Origin:        quote.Origin,
 	}
 }
 
+// retryAfterSeconds rounds d up to whole seconds, as Retry-After requires, so a hint
+// below a second still asks the client to wait.
+func retryAfterSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// latestCacheControl lets clients and proxies keep a latest quote but makes them
+// revalidate it, e.g. with If-Modified-Since, before every use.
+const latestCacheControl = "no-cache"
+
+// quoteSourceHeader names the tier a latest quote was served from, one of the
+// service.ServedFrom* values; quoteSourceLogKey carries it in the access log.
+const (
+	quoteSourceHeader = "X-Quote-Source"
+	quoteSourceLogKey = "quote_source"
+)
+
 // HandleGetLatestQuote godoc
 // @Summary Get latest quote for a currency pair
-// @Description Returns the most recent successful quote for the given currency pair. Does NOT trigger a new fetch - only returns cached/stored data.
+// @Description Returns the most recent successful quote for the given currency pair. Does NOT trigger a new fetch - only returns cached/stored data. update_id names the update that stored the price, whether it is served from the cache or the database, for looking it up with GET /quotes/{update_id}. With include_last_attempt=true a 404 also reports the status, error and time of the pair's most recent update, if there was one. HEAD runs the same lookup and answers with the same status and headers but no body; include_last_attempt is ignored. Last-Modified is the quote's updated_at, and an If-Modified-Since at or after it yields 304 without a body. X-Quote-Source tells whether the quote was read from the Redis cache (redis) or the database (db), unless api.quote_source_header is off. derived is "inverse" when the price was not fetched but computed as the reciprocal of the inverse pair's cached rate (cache.inverse_enabled); such a price has no update_id.
 // @Tags quotes
 // @Accept json
 // @Produce json
 // @Param base query string true "Base currency code (3 letters)" minlength(3) maxlength(3)
 // @Param quote query string true "Quote currency code (3 letters)" minlength(3) maxlength(3)
+// @Param include_last_attempt query bool false "On 404, describe the pair's most recent update"
+// @Param If-Modified-Since header string false "HTTP date; 304 if the quote was not updated after it"
 // @Success 200 {object} LatestResponse "Latest quote found"
-// @Failure 400 {object} ErrorResponse "Invalid currency code format"
-// @Failure 404 {object} ErrorResponse "No quote available for the given pair"
+// @Header 200 {string} ETag "Opaque validator of the quote"
+// @Header 200 {string} Last-Modified "The quote's updated_at as an HTTP date"
+// @Header 200 {string} Cache-Control "no-cache: revalidate before reuse"
+// @Header 200 {string} X-Quote-Signature "Hex HMAC-SHA256 of base|quote|price|updated_at; only sent when signing is enabled"
+// @Header 200 {string} X-Quote-Signature-Key-Id "Id of the key that produced X-Quote-Signature"
+// @Header 200 {string} X-Quote-Source "Tier the quote was read from: redis or db; only sent when api.quote_source_header is on"
+// @Success 304 "Quote not updated since If-Modified-Since"
+// @Failure 400 {object} ErrorResponse "Invalid currency code format or unsupported currency"
+// @Failure 401 {object} ErrorResponse "Missing or invalid API key (when auth is enabled)"
+// @Failure 403 {object} ErrorResponse "API key lacks the read scope or is not permitted to access the pair"
+// @Failure 404 {object} LatestNotFoundResponse "No quote available for the given pair"
+// @Failure 429 {object} QuotaExceededResponse "Monthly quota of the API key used up (when quotas are enabled)"
 // @Failure 500 {object} ErrorResponse "Internal error"
+// @Failure 503 {object} ErrorResponse "Database schema not ready"
 // @Router /quotes/latest [get]
-func HandleGetLatestQuote(svc service.QuoteServiceInterface) http.HandlerFunc {
+// @Router /quotes/latest [head]
+//
+// With sourceHeader the response names the tier that served the quote in
+// X-Quote-Source; the access log records it either way.
+func HandleGetLatestQuote(svc service.QuoteServiceInterface, signer *QuoteSigner, sourceHeader bool, errs ErrorConfig) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		base := r.URL.Query().Get("base")
-		quote := r.URL.Query().Get("quote")
+		head := r.Method == http.MethodHead
+		base := service.NormalizeCode(r.URL.Query().Get("base"))
+		quote := service.NormalizeCode(r.URL.Query().Get("quote"))
 		if base == "" || quote == "" {
-			writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "base and quote query params are required"})
+			writeError(w, r, http.StatusBadRequest, ErrCodeInvalidFormat, "base and quote query params are required")
+			return
+		}
+		pair, err := service.NewPair(base, quote)
+		if err != nil {
+			writeServiceError(w, r, errs, err, "")
+			return
+		}
+		latest, err := svc.GetLatestQuote(r.Context(), pair)
+		if err != nil {
+			notFoundMsg := "No quote available for " + pair.String()
+			includeLastAttempt, _ := strconv.ParseBool(r.URL.Query().Get("include_last_attempt"))
+			if includeLastAttempt && !head && errors.Is(err, service.ErrNotFound) {
+				writeLatestNotFound(w, r, svc, pair, notFoundMsg)
+				return
+			}
+			writeServiceError(w, r, errs, err, notFoundMsg)
+			return
+		}
+
+		if latest.ServedFrom != "" {
+			middleware.AddAccessLogField(r.Context(), quoteSourceLogKey, latest.ServedFrom)
+			if sourceHeader {
+				w.Header().Set(quoteSourceHeader, latest.ServedFrom)
+			}
+		}
+
+		resp := LatestResponse{
+			UpdateID:      latest.ID,
+			Base:          latest.Base,
+			Quote:         latest.Quote,
+			Price:         derefStr(latest.Price),
+			UpdatedAt:     derefStr(latest.UpdatedAt),
+			RateTimestamp: derefStr(latest.RateTimestamp),
+			Derived:       latest.Derived,
+		}
+		lastModified := setLatestCacheHeaders(w.Header(), resp)
+		if notModifiedSince(r, lastModified) {
+			w.WriteHeader(http.StatusNotModified)
 			return
 		}
-		latest, err := svc.GetLatestQuote(r.Context(), base, quote)
+		signer.setHeaders(w.Header(), resp.Base, resp.Quote, resp.Price, resp.UpdatedAt)
+		writeJSON(w, r, http.StatusOK, resp)
+	}
+}
+
+// setLatestCacheHeaders sets the ETag, Last-Modified and Cache-Control headers of a
+// latest quote and returns its Last-Modified time, which is zero, and the header
+// unset, if updated_at does not parse.
+func setLatestCacheHeaders(h http.Header, resp LatestResponse) time.Time {
+	sum := sha256.Sum256([]byte(strings.Join([]string{resp.UpdateID, resp.Base, resp.Quote, resp.Price, resp.UpdatedAt, resp.RateTimestamp, resp.Derived}, "|")))
+	h.Set("ETag", `"`+hex.EncodeToString(sum[:8])+`"`)
+	h.Set("Cache-Control", latestCacheControl)
+	updatedAt, err := time.Parse(time.RFC3339Nano, resp.UpdatedAt)
+	if err != nil {
+		return time.Time{}
+	}
+	h.Set("Last-Modified", updatedAt.UTC().Format(http.TimeFormat))
+	return updatedAt
+}
+
+// notModifiedSince reports whether the request's If-Modified-Since is at or after
+// lastModified, compared at the one-second resolution of HTTP dates.
+func notModifiedSince(r *http.Request, lastModified time.Time) bool {
+	if lastModified.IsZero() {
+		return false
+	}
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+	return !lastModified.Truncate(time.Second).After(since)
+}
+
+// writeLatestNotFound writes a LatestNotFoundResponse. The last attempt is extra detail
+// on a 404, so failing to load it still yields a plain 404.
+func writeLatestNotFound(w http.ResponseWriter, r *http.Request, svc service.QuoteServiceInterface, pair service.Pair, msg string) {
+	resp := LatestNotFoundResponse{Error: msg, Code: ErrCodeNotFound}
+	if attempt, err := svc.GetLastAttempt(r.Context(), pair); err == nil {
+		resp.LastStatus = attempt.Status
+		resp.LastError = derefStr(attempt.ErrorMsg)
+		resp.LastAttemptAt = attempt.AttemptAt
+	}
+	writeJSON(w, r, http.StatusNotFound, resp)
+}
+
+// LatestQuotesReader reads the latest quotes of several pairs; implemented by
+// *service.QuoteService.
+type LatestQuotesReader interface {
+	GetLatestQuotes(ctx context.Context, pairs []service.Pair) ([]service.LatestQuote, error)
+}
+
+// HandleGetDefaultLatestQuotes godoc
+// @Summary Get latest quotes for the default pairs
+// @Description Returns the most recent successful quote of every pair in api.default_pairs, in the configured order, reading the cache for all of them at once and the DB only for pairs the cache knows nothing about. Pairs without a successful quote are marked not_found; pairs the API key may not access are left out. derived is "inverse" for a price computed from the inverse pair's cached rate. Does NOT trigger a new fetch.
+// @Tags quotes
+// @Produce json
+// @Success 200 {object} DefaultLatestResponse "Latest quotes of the default pairs"
+// @Failure 401 {object} ErrorResponse "Missing or invalid API key (when auth is enabled)"
+// @Failure 403 {object} ErrorResponse "API key lacks the read scope"
+// @Failure 429 {object} QuotaExceededResponse "Monthly quota of the API key used up (when quotas are enabled)"
+// @Failure 500 {object} ErrorResponse "Internal error"
+// @Failure 503 {object} ErrorResponse "Database schema not ready"
+// @Router /quotes/latest/defaults [get]
+func HandleGetDefaultLatestQuotes(svc LatestQuotesReader, pairs []service.Pair, errs ErrorConfig) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		quotes, err := svc.GetLatestQuotes(r.Context(), pairs)
 		if err != nil {
-			switch {
-			case errors.Is(err, service.ErrInvalidPairFormat):
-				writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: err.Error()})
-			case errors.Is(err, service.ErrNotFound):
-				writeJSON(w, http.StatusNotFound, ErrorResponse{Error: "No quote available for " + strings.ToUpper(base) + "/" + strings.ToUpper(quote)})
-			default:
-				writeJSON(w, http.StatusInternalServerError, ErrorResponse{Error: "Internal error"})
+			writeServiceError(w, r, errs, err, "")
+			return
+		}
+
+		resp := DefaultLatestResponse{Quotes: make([]DefaultLatestEntry, len(quotes))}
+		for i, q := range quotes {
+			entry := DefaultLatestEntry{Base: q.Pair.Base, Quote: q.Pair.Quote, NotFound: q.Quote == nil}
+			if q.Quote != nil {
+				entry.Price = derefStr(q.Quote.Price)
+				entry.UpdatedAt = derefStr(q.Quote.UpdatedAt)
+				entry.RateTimestamp = derefStr(q.Quote.RateTimestamp)
+				entry.Derived = q.Quote.Derived
 			}
+			resp.Quotes[i] = entry
+		}
+		writeJSON(w, r, http.StatusOK, resp)
+	}
+}
+
+// HandleGetHistoricalQuote godoc
+// @Summary Get the quote that was current at a point in time
+// @Description Returns the most recent successful quote for the pair whose update time is at or before the given timestamp. The as_of field is the update time of the returned record, not the queried time.
+// @Tags quotes
+// @Accept json
+// @Produce json
+// @Param base query string true "Base currency code (3 letters)" minlength(3) maxlength(3)
+// @Param quote query string true "Quote currency code (3 letters)" minlength(3) maxlength(3)
+// @Param at query string true "Point in time (RFC3339)" format(date-time)
+// @Success 200 {object} HistoricalResponse "Quote current at the given time"
+// @Failure 400 {object} ErrorResponse "Invalid currency code or timestamp"
+// @Failure 401 {object} ErrorResponse "Missing or invalid API key (when auth is enabled)"
+// @Failure 403 {object} ErrorResponse "API key lacks the read scope or is not permitted to access the pair"
+// @Failure 404 {object} ErrorResponse "No quote recorded before the given time"
+// @Failure 429 {object} QuotaExceededResponse "Monthly quota of the API key used up (when quotas are enabled)"
+// @Failure 500 {object} ErrorResponse "Internal error"
+// @Failure 503 {object} ErrorResponse "Database schema not ready"
+// @Router /quotes/history/at [get]
+func HandleGetHistoricalQuote(svc service.QuoteServiceInterface, errs ErrorConfig) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		base := service.NormalizeCode(r.URL.Query().Get("base"))
+		quote := service.NormalizeCode(r.URL.Query().Get("quote"))
+		atParam := r.URL.Query().Get("at")
+		if base == "" || quote == "" || atParam == "" {
+			writeError(w, r, http.StatusBadRequest, ErrCodeInvalidFormat, "base, quote and at query params are required")
+			return
+		}
+		at, err := time.Parse(time.RFC3339, atParam)
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, ErrCodeInvalidFormat, "at must be an RFC3339 timestamp")
+			return
+		}
+		pair, err := service.NewPair(base, quote)
+		if err != nil {
+			writeServiceError(w, r, errs, err, "")
+			return
+		}
+
+		res, err := svc.GetHistoricalRate(r.Context(), pair, at)
+		if err != nil {
+			writeServiceError(w, r, errs, err, "No quote available for "+pair.String()+" at "+atParam)
+			return
+		}
+
+		writeJSON(w, r, http.StatusOK, HistoricalResponse{
+			Base:  res.Base,
+			Quote: res.Quote,
+			Price: derefStr(res.Price),
+			AsOf:  derefStr(res.UpdatedAt),
+		})
+	}
+}
+
+// HandleCompareQuotes godoc
+// @Summary Compare a pair's quotes at two points in time
+// @Description Looks up the quote that was current at each of two points in time, like GET /quotes/history/at, and returns both with the change between them. change is the vs price minus the at price, exact to the more precise of the two; change_pct is the change relative to the at price in percent, rounded to 4 decimals. The as_of fields are the update times of the quotes used. at and vs are RFC3339 timestamps or YYYY-MM-DD dates, which mean midnight UTC; at must be before vs and neither may be in the future.
+// @Tags quotes
+// @Accept json
+// @Produce json
+// @Param base query string true "Base currency code (3 letters)" minlength(3) maxlength(3)
+// @Param quote query string true "Quote currency code (3 letters)" minlength(3) maxlength(3)
+// @Param at query string true "Earlier point in time (RFC3339 or YYYY-MM-DD)"
+// @Param vs query string true "Later point in time (RFC3339 or YYYY-MM-DD)"
+// @Success 200 {object} CompareResponse "Quotes at both points in time"
+// @Failure 400 {object} ErrorResponse "Invalid currency code or timestamp, at not before vs, or a point in the future"
+// @Failure 401 {object} ErrorResponse "Missing or invalid API key (when auth is enabled)"
+// @Failure 403 {object} ErrorResponse "API key lacks the read scope or is not permitted to access the pair"
+// @Failure 404 {object} CompareNotFoundResponse "No quote recorded before at, vs or both; missing names them"
+// @Failure 429 {object} QuotaExceededResponse "Monthly quota of the API key used up (when quotas are enabled)"
+// @Failure 500 {object} ErrorResponse "Internal error"
+// @Failure 503 {object} ErrorResponse "Database schema not ready"
+// @Router /quotes/compare [get]
+func HandleCompareQuotes(svc service.QuoteServiceInterface, errs ErrorConfig) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		base, quote := service.NormalizeCode(query.Get("base")), service.NormalizeCode(query.Get("quote"))
+		atParam, vsParam := query.Get("at"), query.Get("vs")
+		if base == "" || quote == "" || atParam == "" || vsParam == "" {
+			writeError(w, r, http.StatusBadRequest, ErrCodeInvalidFormat, "base, quote, at and vs query params are required")
+			return
+		}
+		at, atErr := parseTimeParam(atParam)
+		vs, vsErr := parseTimeParam(vsParam)
+		if atErr != nil || vsErr != nil {
+			writeError(w, r, http.StatusBadRequest, ErrCodeInvalidFormat, "at and vs must be RFC3339 timestamps or YYYY-MM-DD dates")
+			return
+		}
+		pair, err := service.NewPair(base, quote)
+		if err != nil {
+			writeServiceError(w, r, errs, err, "")
 			return
 		}
 
-		writeJSON(w, http.StatusOK, LatestResponse{
-			Base:      latest.Base,
-			Quote:     latest.Quote,
-			Price:     derefStr(latest.Price),
-			UpdatedAt: derefStr(latest.UpdatedAt),
+		cmp, err := svc.CompareQuotes(r.Context(), pair, at, vs)
+		var notFound *service.ComparisonNotFoundError
+		if errors.As(err, &notFound) {
+			params := map[string]string{"at": atParam, "vs": vsParam}
+			sides := make([]string, len(notFound.Missing))
+			for i, side := range notFound.Missing {
+				sides[i] = side + "=" + params[side]
+			}
+			writeJSON(w, r, http.StatusNotFound, CompareNotFoundResponse{
+				Error:   "No quote available for " + pair.String() + " at or before " + strings.Join(sides, " and "),
+				Code:    ErrCodeNotFound,
+				Missing: notFound.Missing,
+			})
+			return
+		}
+		if err != nil {
+			writeServiceError(w, r, errs, err, "")
+			return
+		}
+
+		writeJSON(w, r, http.StatusOK, CompareResponse{
+			Base:      cmp.Base,
+			Quote:     cmp.Quote,
+			At:        ComparisonPoint{Price: derefStr(cmp.At.Price), AsOf: derefStr(cmp.At.UpdatedAt)},
+			Vs:        ComparisonPoint{Price: derefStr(cmp.Vs.Price), AsOf: derefStr(cmp.Vs.UpdatedAt)},
+			Change:    cmp.Change,
+			ChangePct: cmp.ChangePct,
 		})
 	}
 }
+
+// parseTimeParam parses a query param holding an RFC3339 timestamp or a YYYY-MM-DD
+// date, which means midnight UTC.
+func parseTimeParam(v string) (time.Time, error) {
+	if t, err := time.Parse(time.DateOnly, v); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, v)
+}
@@ -4,20 +4,32 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/alicebob/miniredis/v2"
 	"github.com/go-chi/chi/v5"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
 
+	"quoteservice/internal/api/middleware"
+	"quoteservice/internal/config"
+	"quoteservice/internal/repository"
 	"quoteservice/internal/service"
 )
 
 func TestHandleRequestUpdate(t *testing.T) {
 	t.Run("valid pair returns 202", func(t *testing.T) {
 		svc := &mockQuoteService{
-			requestUpdateFunc: func(ctx context.Context, pair string) (string, string, error) {
-				return "test-uuid-123", "PENDING", nil
+			requestUpdateFunc: func(ctx context.Context, pair string, _ service.UpdateOptions) (*service.UpdateRequestResult, error) {
+				return &service.UpdateRequestResult{UpdateID: "test-uuid-123", Status: "PENDING"}, nil
 			},
 		}
 
@@ -25,7 +37,7 @@ func TestHandleRequestUpdate(t *testing.T) {
 		req := httptest.NewRequest(http.MethodPost, "/quotes/update", body)
 		w := httptest.NewRecorder()
 
-		handler := HandleRequestUpdate(svc)
+		handler := HandleRequestUpdate(svc, nil, ErrorConfig{})
 		handler.ServeHTTP(w, req)
 
 		if w.Code != http.StatusAccepted {
@@ -42,10 +54,124 @@ func TestHandleRequestUpdate(t *testing.T) {
 		}
 	})
 
+	t.Run("reused update reports the reason", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			result service.UpdateRequestResult
+			want   UpdateResponse
+		}{
+			{
+				name:   "new update omits reason",
+				result: service.UpdateRequestResult{UpdateID: "new-id", Status: "PENDING"},
+				want:   UpdateResponse{UpdateID: "new-id"},
+			},
+			{
+				name: "pending exists",
+				result: service.UpdateRequestResult{UpdateID: "pending-id", Status: "PENDING",
+					Reason: service.ReuseReasonPendingExists},
+				want: UpdateResponse{UpdateID: "pending-id", Reason: "pending_exists"},
+			},
+			{
+				name: "cooldown active rounds remaining seconds up",
+				result: service.UpdateRequestResult{UpdateID: "recent-id", Status: "SUCCESS",
+					Reason: service.ReuseReasonCooldownActive, CooldownRemaining: 41200 * time.Millisecond},
+				want: UpdateResponse{UpdateID: "recent-id", Reason: "cooldown_active", CooldownRemainingSec: 42},
+			},
+			{
+				name:   "poll hint in milliseconds",
+				result: service.UpdateRequestResult{UpdateID: "new-id", Status: "PENDING", PollAfter: 2500 * time.Millisecond},
+				want:   UpdateResponse{UpdateID: "new-id", PollAfterMs: 2500},
+			},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				svc := &mockQuoteService{
+					requestUpdateFunc: func(context.Context, string, service.UpdateOptions) (*service.UpdateRequestResult, error) {
+						return &tt.result, nil
+					},
+				}
+				req := httptest.NewRequest(http.MethodPost, "/quotes/update", bytes.NewBufferString(`{"pair":"EUR/MXN"}`))
+				w := httptest.NewRecorder()
+				HandleRequestUpdate(svc, nil, ErrorConfig{}).ServeHTTP(w, req)
+
+				if w.Code != http.StatusAccepted {
+					t.Fatalf("Expected status 202, got %d", w.Code)
+				}
+				var resp UpdateResponse
+				if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+					t.Fatalf("Failed to decode response: %v", err)
+				}
+				if resp != tt.want {
+					t.Errorf("Expected %+v, got %+v", tt.want, resp)
+				}
+			})
+		}
+	})
+
+	t.Run("queue unavailable returns 503 and retry succeeds", func(t *testing.T) {
+		queueDown := true
+		svc := &mockQuoteService{
+			requestUpdateFunc: func(ctx context.Context, pair string, _ service.UpdateOptions) (*service.UpdateRequestResult, error) {
+				if queueDown {
+					return nil, service.ErrInternalQueue
+				}
+				return &service.UpdateRequestResult{UpdateID: "test-uuid-456", Status: "PENDING"}, nil
+			},
+		}
+		handler := HandleRequestUpdate(svc, nil, ErrorConfig{QueueRetryAfterSec: 30})
+
+		req := httptest.NewRequest(http.MethodPost, "/quotes/update", bytes.NewBufferString(`{"pair":"EUR/MXN"}`))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusServiceUnavailable {
+			t.Fatalf("Expected status 503, got %d", w.Code)
+		}
+		if got := w.Header().Get("Retry-After"); got != "30" {
+			t.Errorf("Expected Retry-After 30, got %q", got)
+		}
+		assertErrorCode(t, w, ErrCodeQueueUnavailable)
+
+		queueDown = false
+		req = httptest.NewRequest(http.MethodPost, "/quotes/update", bytes.NewBufferString(`{"pair":"EUR/MXN"}`))
+		w = httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusAccepted {
+			t.Fatalf("Expected status 202 after recovery, got %d", w.Code)
+		}
+		var resp UpdateResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if resp.UpdateID != "test-uuid-456" {
+			t.Errorf("Expected update_id 'test-uuid-456', got %s", resp.UpdateID)
+		}
+	})
+
+	t.Run("queue full returns 503 with Retry-After", func(t *testing.T) {
+		svc := &mockQuoteService{
+			requestUpdateFunc: func(context.Context, string, service.UpdateOptions) (*service.UpdateRequestResult, error) {
+				return nil, service.ErrQueueFull
+			},
+		}
+		req := httptest.NewRequest(http.MethodPost, "/quotes/update", bytes.NewBufferString(`{"pair":"EUR/MXN"}`))
+		w := httptest.NewRecorder()
+		HandleRequestUpdate(svc, nil, ErrorConfig{QueueRetryAfterSec: 12}).ServeHTTP(w, req)
+
+		if w.Code != http.StatusServiceUnavailable {
+			t.Fatalf("Expected status 503, got %d", w.Code)
+		}
+		if got := w.Header().Get("Retry-After"); got != "12" {
+			t.Errorf("Expected Retry-After 12, got %q", got)
+		}
+		assertErrorCode(t, w, ErrCodeQueueFull)
+	})
+
 	t.Run("invalid pair format returns 400", func(t *testing.T) {
 		svc := &mockQuoteService{
-			requestUpdateFunc: func(ctx context.Context, pair string) (string, string, error) {
-				return "", "", service.ErrInvalidPairFormat
+			requestUpdateFunc: func(ctx context.Context, pair string, _ service.UpdateOptions) (*service.UpdateRequestResult, error) {
+				return nil, service.ErrInvalidPairFormat
 			},
 		}
 
@@ -53,7 +179,7 @@ func TestHandleRequestUpdate(t *testing.T) {
 		req := httptest.NewRequest(http.MethodPost, "/quotes/update", body)
 		w := httptest.NewRecorder()
 
-		handler := HandleRequestUpdate(svc)
+		handler := HandleRequestUpdate(svc, nil, ErrorConfig{})
 		handler.ServeHTTP(w, req)
 
 		if w.Code != http.StatusBadRequest {
@@ -69,6 +195,9 @@ func TestHandleRequestUpdate(t *testing.T) {
 		if resp.Error != expectedError {
 			t.Errorf("Expected error '%s', got '%s'", expectedError, resp.Error)
 		}
+		if resp.Code != ErrCodeInvalidFormat {
+			t.Errorf("Expected code %d, got %d", ErrCodeInvalidFormat, resp.Code)
+		}
 	})
 
 	t.Run("missing pair returns 400", func(t *testing.T) {
@@ -78,12 +207,70 @@ func TestHandleRequestUpdate(t *testing.T) {
 		req := httptest.NewRequest(http.MethodPost, "/quotes/update", body)
 		w := httptest.NewRecorder()
 
-		handler := HandleRequestUpdate(svc)
+		handler := HandleRequestUpdate(svc, nil, ErrorConfig{})
 		handler.ServeHTTP(w, req)
 
 		if w.Code != http.StatusBadRequest {
 			t.Errorf("Expected status 400, got %d", w.Code)
 		}
+		assertErrorCode(t, w, ErrCodeInvalidFormat)
+	})
+}
+
+func TestHandleRequestUpdate_Provider(t *testing.T) {
+	request := func() *http.Request {
+		return httptest.NewRequest(http.MethodPost, "/quotes/update",
+			bytes.NewBufferString(`{"pair":"EUR/MXN","provider":"frankfurter"}`))
+	}
+
+	t.Run("provider is passed to the service", func(t *testing.T) {
+		var got service.UpdateOptions
+		svc := &mockQuoteService{
+			requestUpdateFunc: func(_ context.Context, _ string, opts service.UpdateOptions) (*service.UpdateRequestResult, error) {
+				got = opts
+				return &service.UpdateRequestResult{UpdateID: "test-uuid", Status: "PENDING"}, nil
+			},
+		}
+		w := httptest.NewRecorder()
+		HandleRequestUpdate(svc, func(*http.Request) bool { return true }, ErrorConfig{}).ServeHTTP(w, request())
+
+		if w.Code != http.StatusAccepted {
+			t.Fatalf("Expected status 202, got %d", w.Code)
+		}
+		if got.Provider != "frankfurter" {
+			t.Errorf("Expected provider frankfurter, got %q", got.Provider)
+		}
+	})
+
+	t.Run("without permission returns 403", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		HandleRequestUpdate(&mockQuoteService{}, func(*http.Request) bool { return false }, ErrorConfig{}).ServeHTTP(w, request())
+
+		if w.Code != http.StatusForbidden {
+			t.Fatalf("Expected status 403, got %d", w.Code)
+		}
+		assertErrorCode(t, w, ErrCodeForbidden)
+	})
+
+	t.Run("unknown provider returns 400 with the valid ones", func(t *testing.T) {
+		svc := &mockQuoteService{
+			requestUpdateFunc: func(context.Context, string, service.UpdateOptions) (*service.UpdateRequestResult, error) {
+				return nil, &service.UnknownProviderError{Name: "frankfurter", Valid: []string{"exchangerate_host"}}
+			},
+		}
+		w := httptest.NewRecorder()
+		HandleRequestUpdate(svc, nil, ErrorConfig{}).ServeHTTP(w, request())
+
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("Expected status 400, got %d", w.Code)
+		}
+		var resp UnknownProviderResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if resp.Code != ErrCodeUnknownProvider || !reflect.DeepEqual(resp.ValidProviders, []string{"exchangerate_host"}) {
+			t.Errorf("Unexpected response %+v", resp)
+		}
 	})
 }
 
@@ -95,7 +282,7 @@ func execGetQuoteByID(t *testing.T, svc service.QuoteServiceInterface, updateID
 	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
 	w := httptest.NewRecorder()
 
-	handler := HandleGetQuoteByID(svc)
+	handler := HandleGetQuoteByID(svc, nil, ErrorConfig{})
 	handler.ServeHTTP(w, req)
 
 	if w.Code != http.StatusOK {
@@ -109,6 +296,92 @@ func execGetQuoteByID(t *testing.T, svc service.QuoteServiceInterface, updateID
 	return resp
 }
 
+func TestHandleFetchQuote(t *testing.T) {
+	const maxWait = 13 * time.Second
+	price := "18.7543"
+	serve := func(svc *mockQuoteService, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		HandleFetchQuote(svc, nil, maxWait, ErrorConfig{}).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/quotes/fetch", bytes.NewBufferString(body)))
+		return w
+	}
+
+	t.Run("finished update returns 200 with the result", func(t *testing.T) {
+		for _, status := range []string{"SUCCESS", "FAILED"} {
+			svc := &mockQuoteService{
+				fetchQuoteFunc: func(context.Context, string, time.Duration) (*service.QuoteResult, error) {
+					return &service.QuoteResult{ID: "test-uuid", Base: "EUR", Quote: "MXN", Status: status, Price: &price}, nil
+				},
+			}
+			w := serve(svc, `{"pair":"EUR/MXN","timeout_ms":3000}`)
+			if w.Code != http.StatusOK {
+				t.Fatalf("%s: expected status 200, got %d", status, w.Code)
+			}
+			var resp QuoteResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if resp.UpdateID != "test-uuid" || resp.Status != status {
+				t.Errorf("Expected update test-uuid %s, got %+v", status, resp)
+			}
+		}
+	})
+
+	t.Run("unfinished update returns 202 with the update_id", func(t *testing.T) {
+		svc := &mockQuoteService{
+			fetchQuoteFunc: func(context.Context, string, time.Duration) (*service.QuoteResult, error) {
+				return &service.QuoteResult{ID: "test-uuid", Status: "RUNNING", PollAfter: 1500 * time.Millisecond}, nil
+			},
+		}
+		w := serve(svc, `{"pair":"EUR/MXN","timeout_ms":3000}`)
+		if w.Code != http.StatusAccepted {
+			t.Fatalf("Expected status 202, got %d", w.Code)
+		}
+		var resp UpdateResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if resp != (UpdateResponse{UpdateID: "test-uuid", PollAfterMs: 1500}) {
+			t.Errorf("Expected update_id test-uuid and poll_after_ms 1500, got %+v", resp)
+		}
+	})
+
+	t.Run("timeout_ms is capped", func(t *testing.T) {
+		for timeoutMs, want := range map[int]time.Duration{3000: 3 * time.Second, 60000: maxWait} {
+			var got time.Duration
+			svc := &mockQuoteService{
+				fetchQuoteFunc: func(_ context.Context, _ string, wait time.Duration) (*service.QuoteResult, error) {
+					got = wait
+					return &service.QuoteResult{ID: "test-uuid", Status: "PENDING"}, nil
+				},
+			}
+			serve(svc, fmt.Sprintf(`{"pair":"EUR/MXN","timeout_ms":%d}`, timeoutMs))
+			if got != want {
+				t.Errorf("timeout_ms %d: expected a wait of %v, got %v", timeoutMs, want, got)
+			}
+		}
+	})
+
+	t.Run("invalid request returns 400", func(t *testing.T) {
+		svc := &mockQuoteService{}
+		for _, body := range []string{`{`, `{"timeout_ms":3000}`, `{"pair":"EUR/MXN"}`, `{"pair":"EUR/MXN","timeout_ms":-1}`} {
+			if w := serve(svc, body); w.Code != http.StatusBadRequest {
+				t.Errorf("%s: expected status 400, got %d", body, w.Code)
+			}
+		}
+	})
+
+	t.Run("service error is mapped", func(t *testing.T) {
+		svc := &mockQuoteService{
+			fetchQuoteFunc: func(context.Context, string, time.Duration) (*service.QuoteResult, error) {
+				return nil, service.ErrQueueFull
+			},
+		}
+		if w := serve(svc, `{"pair":"EUR/MXN","timeout_ms":3000}`); w.Code != http.StatusServiceUnavailable {
+			t.Errorf("Expected status 503, got %d", w.Code)
+		}
+	})
+}
+
 func TestHandleGetQuoteByID(t *testing.T) {
 	t.Run("success status returns full quote", func(t *testing.T) {
 		price := "18.7543"
@@ -122,6 +395,7 @@ func TestHandleGetQuoteByID(t *testing.T) {
 					Status:    "SUCCESS",
 					Price:     &price,
 					UpdatedAt: &updatedAt,
+					Origin:    "api",
 				}, nil
 			},
 		}
@@ -137,6 +411,9 @@ func TestHandleGetQuoteByID(t *testing.T) {
 		if resp.UpdatedAt == nil {
 			t.Error("Expected updated_at to be present")
 		}
+		if resp.Origin != "api" {
+			t.Errorf("Expected origin api, got %q", resp.Origin)
+		}
 	})
 
 	t.Run("pending status returns no price", func(t *testing.T) {
@@ -161,6 +438,165 @@ func TestHandleGetQuoteByID(t *testing.T) {
 		}
 	})
 
+	t.Run("failed status returns the error and its code", func(t *testing.T) {
+		errMsg := "frankfurter API request failed: context deadline exceeded"
+		tests := []struct {
+			name     string
+			code     string
+			wantCode string
+		}{
+			{"classified", "timeout", "timeout"},
+			{"failed before codes were recorded", "", ""},
+		}
+		for _, tc := range tests {
+			t.Run(tc.name, func(t *testing.T) {
+				svc := &mockQuoteService{
+					getQuoteResultFunc: func(ctx context.Context, updateID string) (*service.QuoteResult, error) {
+						return &service.QuoteResult{ID: "test-uuid", Base: "EUR", Quote: "MXN", Status: "FAILED", ErrorMsg: &errMsg, ErrorCode: tc.code}, nil
+					},
+				}
+
+				resp := execGetQuoteByID(t, svc, "test-uuid")
+
+				if resp.Status != "FAILED" || resp.Error == nil || *resp.Error != errMsg {
+					t.Errorf("Expected FAILED with error %q, got %s with %v", errMsg, resp.Status, resp.Error)
+				}
+				if resp.ErrorCode != tc.wantCode {
+					t.Errorf("Expected error_code %q, got %q", tc.wantCode, resp.ErrorCode)
+				}
+			})
+		}
+	})
+
+	t.Run("Retry-After only while unfinished", func(t *testing.T) {
+		tests := []struct {
+			status    string
+			pollAfter time.Duration
+			want      string
+		}{
+			{"PENDING", 1500 * time.Millisecond, "2"},
+			{"RUNNING", 300 * time.Millisecond, "1"},
+			{"PENDING", 0, ""},
+			{"SUCCESS", 0, ""},
+		}
+		for _, tc := range tests {
+			svc := &mockQuoteService{
+				getQuoteResultFunc: func(ctx context.Context, updateID string) (*service.QuoteResult, error) {
+					return &service.QuoteResult{ID: updateID, Base: "EUR", Quote: "MXN", Status: tc.status, PollAfter: tc.pollAfter}, nil
+				},
+			}
+			req := httptest.NewRequest(http.MethodGet, "/quotes/test-uuid", nil)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("update_id", "test-uuid")
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+			w := httptest.NewRecorder()
+
+			HandleGetQuoteByID(svc, nil, ErrorConfig{}).ServeHTTP(w, req)
+
+			if got := w.Header().Get("Retry-After"); got != tc.want {
+				t.Errorf("%s with hint %v: expected Retry-After %q, got %q", tc.status, tc.pollAfter, tc.want, got)
+			}
+		}
+	})
+
+	t.Run("include_events returns the timeline", func(t *testing.T) {
+		price := "18.754300"
+		svc := &mockQuoteService{
+			getQuoteResultFunc: func(ctx context.Context, updateID string) (*service.QuoteResult, error) {
+				return &service.QuoteResult{ID: updateID, Base: "EUR", Quote: "MXN", Status: "SUCCESS", Price: &price,
+					InstanceID: "worker-1-a1b2c3"}, nil
+			},
+			getStatusEventsFunc: func(ctx context.Context, updateID string) ([]service.QuoteStatusEvent, error) {
+				return []service.QuoteStatusEvent{
+					{Status: "PENDING", At: "2025-12-01T10:15:28Z"},
+					{Status: "RUNNING", At: "2025-12-01T10:15:29Z", InstanceID: "worker-1-a1b2c3"},
+					{Status: "SUCCESS", At: "2025-12-01T10:15:30Z", Detail: &price},
+				}, nil
+			},
+		}
+
+		req := httptest.NewRequest(http.MethodGet, "/quotes/test-uuid?include_events=true", nil)
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("update_id", "test-uuid")
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+		w := httptest.NewRecorder()
+
+		HandleGetQuoteByID(svc, nil, ErrorConfig{}).ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+		var resp QuoteResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if len(resp.Events) != 3 {
+			t.Fatalf("Expected 3 events, got %+v", resp.Events)
+		}
+		for i, want := range []string{"PENDING", "RUNNING", "SUCCESS"} {
+			if resp.Events[i].Status != want {
+				t.Errorf("Event %d: expected %s, got %s", i, want, resp.Events[i].Status)
+			}
+		}
+		if resp.Events[2].Detail == nil || *resp.Events[2].Detail != price {
+			t.Errorf("Expected SUCCESS detail %s, got %v", price, resp.Events[2].Detail)
+		}
+		if resp.InstanceID != "worker-1-a1b2c3" || resp.Events[1].InstanceID != "worker-1-a1b2c3" || resp.Events[0].InstanceID != "" {
+			t.Errorf("Expected the instance on the response and the RUNNING event, got %q and %+v", resp.InstanceID, resp.Events)
+		}
+	})
+
+	t.Run("events omitted by default", func(t *testing.T) {
+		svc := &mockQuoteService{
+			getQuoteResultFunc: func(ctx context.Context, updateID string) (*service.QuoteResult, error) {
+				return &service.QuoteResult{ID: updateID, Base: "EUR", Quote: "MXN", Status: "RUNNING", InstanceID: "worker-1-a1b2c3"}, nil
+			},
+			getStatusEventsFunc: func(ctx context.Context, updateID string) ([]service.QuoteStatusEvent, error) {
+				t.Error("GetStatusEvents must not be called without include_events")
+				return nil, nil
+			},
+		}
+
+		resp := execGetQuoteByID(t, svc, "test-uuid")
+		if resp.Events != nil || resp.InstanceID != "" {
+			t.Errorf("Expected no events and no instance, got %+v", resp)
+		}
+	})
+
+	t.Run("include_verification adds the provider spread", func(t *testing.T) {
+		price := "18.700000"
+		verified := &service.QuoteResult{
+			ID: "test-uuid", Base: "EUR", Quote: "MXN", Status: "SUCCESS", Price: &price,
+			Verification: &service.QuoteVerification{MinPrice: "18.70", MaxPrice: "19.00", SpreadPct: "1.6043", Providers: 2},
+		}
+		svc := &mockQuoteService{
+			getQuoteResultFunc: func(ctx context.Context, updateID string) (*service.QuoteResult, error) {
+				return verified, nil
+			},
+		}
+
+		if resp := execGetQuoteByID(t, svc, "test-uuid"); resp.Verification != nil {
+			t.Errorf("Expected no verification without the query parameter, got %+v", resp.Verification)
+		}
+
+		req := httptest.NewRequest(http.MethodGet, "/quotes/test-uuid?include_verification=true", nil)
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("update_id", "test-uuid")
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+		w := httptest.NewRecorder()
+
+		HandleGetQuoteByID(svc, nil, ErrorConfig{}).ServeHTTP(w, req)
+
+		var resp QuoteResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		want := VerificationResponse{MinPrice: "18.70", MaxPrice: "19.00", SpreadPct: "1.6043", Providers: 2}
+		if resp.Verification == nil || *resp.Verification != want {
+			t.Errorf("Expected verification %+v, got %+v", want, resp.Verification)
+		}
+	})
+
 	t.Run("invalid UUID returns 400", func(t *testing.T) {
 		svc := &mockQuoteService{
 			getQuoteResultFunc: func(ctx context.Context, updateID string) (*service.QuoteResult, error) {
@@ -174,12 +610,13 @@ func TestHandleGetQuoteByID(t *testing.T) {
 		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
 		w := httptest.NewRecorder()
 
-		handler := HandleGetQuoteByID(svc)
+		handler := HandleGetQuoteByID(svc, nil, ErrorConfig{})
 		handler.ServeHTTP(w, req)
 
 		if w.Code != http.StatusBadRequest {
 			t.Errorf("Expected status 400, got %d", w.Code)
 		}
+		assertErrorCode(t, w, ErrCodeInvalidFormat)
 	})
 
 	t.Run("unknown ID returns 404", func(t *testing.T) {
@@ -195,7 +632,7 @@ func TestHandleGetQuoteByID(t *testing.T) {
 		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
 		w := httptest.NewRecorder()
 
-		handler := HandleGetQuoteByID(svc)
+		handler := HandleGetQuoteByID(svc, nil, ErrorConfig{})
 		handler.ServeHTTP(w, req)
 
 		if w.Code != http.StatusNotFound {
@@ -210,6 +647,9 @@ func TestHandleGetQuoteByID(t *testing.T) {
 		if resp.Error != "Unknown update_id" {
 			t.Errorf("Expected error 'Unknown update_id', got '%s'", resp.Error)
 		}
+		if resp.Code != ErrCodeNotFound {
+			t.Errorf("Expected code %d, got %d", ErrCodeNotFound, resp.Code)
+		}
 	})
 }
 
@@ -218,10 +658,10 @@ func TestHandleGetLatestQuote(t *testing.T) {
 		price := "18.7543"
 		updatedAt := "2025-12-01T10:15:30Z"
 		svc := &mockQuoteService{
-			getLatestQuoteFunc: func(ctx context.Context, base, quote string) (*service.QuoteResult, error) {
+			getLatestQuoteFunc: func(ctx context.Context, pair service.Pair) (*service.QuoteResult, error) {
 				return &service.QuoteResult{
-					Base:      base,
-					Quote:     quote,
+					Base:      pair.Base,
+					Quote:     pair.Quote,
 					Price:     &price,
 					UpdatedAt: &updatedAt,
 					Status:    "SUCCESS",
@@ -232,7 +672,7 @@ func TestHandleGetLatestQuote(t *testing.T) {
 		req := httptest.NewRequest(http.MethodGet, "/quotes/latest?base=EUR&quote=MXN", nil)
 		w := httptest.NewRecorder()
 
-		handler := HandleGetLatestQuote(svc)
+		handler := HandleGetLatestQuote(svc, nil, false, ErrorConfig{})
 		handler.ServeHTTP(w, req)
 
 		if w.Code != http.StatusOK {
@@ -258,17 +698,18 @@ func TestHandleGetLatestQuote(t *testing.T) {
 		req := httptest.NewRequest(http.MethodGet, "/quotes/latest", nil)
 		w := httptest.NewRecorder()
 
-		handler := HandleGetLatestQuote(svc)
+		handler := HandleGetLatestQuote(svc, nil, false, ErrorConfig{})
 		handler.ServeHTTP(w, req)
 
 		if w.Code != http.StatusBadRequest {
 			t.Errorf("Expected status 400, got %d", w.Code)
 		}
+		assertErrorCode(t, w, ErrCodeInvalidFormat)
 	})
 
 	t.Run("no quote available returns 404", func(t *testing.T) {
 		svc := &mockQuoteService{
-			getLatestQuoteFunc: func(ctx context.Context, base, quote string) (*service.QuoteResult, error) {
+			getLatestQuoteFunc: func(ctx context.Context, pair service.Pair) (*service.QuoteResult, error) {
 				return nil, service.ErrNotFound
 			},
 		}
@@ -276,7 +717,7 @@ func TestHandleGetLatestQuote(t *testing.T) {
 		req := httptest.NewRequest(http.MethodGet, "/quotes/latest?base=EUR&quote=MXN", nil)
 		w := httptest.NewRecorder()
 
-		handler := HandleGetLatestQuote(svc)
+		handler := HandleGetLatestQuote(svc, nil, false, ErrorConfig{})
 		handler.ServeHTTP(w, req)
 
 		if w.Code != http.StatusNotFound {
@@ -291,5 +732,711 @@ func TestHandleGetLatestQuote(t *testing.T) {
 		if resp.Error != "No quote available for EUR/MXN" {
 			t.Errorf("Expected specific error message, got '%s'", resp.Error)
 		}
+		if resp.Code != ErrCodeNotFound {
+			t.Errorf("Expected code %d, got %d", ErrCodeNotFound, resp.Code)
+		}
 	})
 }
+
+// latestFixture answers EUR with a quote updated at 10:15:30.250, GBP with ErrNotFound
+// and CHF with ErrSchemaNotReady.
+func latestFixture() *mockQuoteService {
+	price, updatedAt, rateTimestamp := "18.7543", "2025-12-01T10:15:30.250Z", "2025-12-01T00:00:00Z"
+	return &mockQuoteService{
+		getLatestQuoteFunc: func(_ context.Context, pair service.Pair) (*service.QuoteResult, error) {
+			switch pair.Base {
+			case "GBP":
+				return nil, service.ErrNotFound
+			case "CHF":
+				return nil, service.ErrSchemaNotReady
+			}
+			return &service.QuoteResult{Base: pair.Base, Quote: pair.Quote, Price: &price, UpdatedAt: &updatedAt, RateTimestamp: &rateTimestamp}, nil
+		},
+		getLastAttemptFunc: func(context.Context, service.Pair) (*service.QuoteAttempt, error) {
+			return nil, service.ErrNotFound
+		},
+	}
+}
+
+func TestHandleGetLatestQuote_Head(t *testing.T) {
+	handler := HandleGetLatestQuote(latestFixture(), NewQuoteSigner("2026-10", []byte("test-secret")), false, ErrorConfig{})
+	serve := func(method, query string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(method, "/quotes/latest"+query, nil))
+		return w
+	}
+
+	tests := []struct {
+		name   string
+		query  string
+		status int
+	}{
+		{"quote found", "?base=EUR&quote=MXN", http.StatusOK},
+		{"no quote", "?base=GBP&quote=USD&include_last_attempt=true", http.StatusNotFound},
+		{"missing params", "?base=EUR", http.StatusBadRequest},
+		{"invalid code", "?base=EU1&quote=MXN", http.StatusBadRequest},
+		{"schema not ready", "?base=CHF&quote=USD", http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			get, head := serve(http.MethodGet, tt.query), serve(http.MethodHead, tt.query)
+			if get.Code != tt.status || head.Code != tt.status {
+				t.Fatalf("Expected status %d for GET and HEAD, got %d and %d", tt.status, get.Code, head.Code)
+			}
+			if !reflect.DeepEqual(get.Header(), head.Header()) {
+				t.Errorf("Expected the GET headers %v for HEAD, got %v", get.Header(), head.Header())
+			}
+			if get.Body.Len() == 0 || head.Body.Len() != 0 {
+				t.Errorf("Expected a body for GET only, got %q and %q", get.Body, head.Body)
+			}
+		})
+	}
+
+	w := serve(http.MethodHead, "?base=EUR&quote=MXN")
+	for header, want := range map[string]string{
+		"Content-Type":  "application/json",
+		"Cache-Control": "no-cache",
+		"Last-Modified": "Mon, 01 Dec 2025 10:15:30 GMT",
+	} {
+		if got := w.Header().Get(header); got != want {
+			t.Errorf("Expected %s %q, got %q", header, want, got)
+		}
+	}
+	if etag := w.Header().Get("ETag"); len(etag) != 18 || etag[0] != '"' || etag[17] != '"' {
+		t.Errorf("Expected a quoted 16-digit ETag, got %q", etag)
+	}
+	if w.Header().Get(HeaderQuoteSignature) == "" {
+		t.Error("Expected HEAD to carry the quote signature")
+	}
+}
+
+func TestHandleGetLatestQuote_IfModifiedSince(t *testing.T) {
+	handler := HandleGetLatestQuote(latestFixture(), nil, false, ErrorConfig{})
+
+	tests := []struct {
+		name   string
+		since  string
+		status int
+	}{
+		{"same second as updated_at", "Mon, 01 Dec 2025 10:15:30 GMT", http.StatusNotModified},
+		{"after updated_at", "Tue, 02 Dec 2025 00:00:00 GMT", http.StatusNotModified},
+		{"before updated_at", "Mon, 01 Dec 2025 10:15:29 GMT", http.StatusOK},
+		{"malformed", "yesterday", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, method := range []string{http.MethodGet, http.MethodHead} {
+				r := httptest.NewRequest(method, "/quotes/latest?base=EUR&quote=MXN", nil)
+				r.Header.Set("If-Modified-Since", tt.since)
+				w := httptest.NewRecorder()
+				handler.ServeHTTP(w, r)
+
+				if w.Code != tt.status {
+					t.Errorf("%s: expected status %d, got %d", method, tt.status, w.Code)
+				}
+				if tt.status == http.StatusNotModified &&
+					(w.Body.Len() != 0 || w.Header().Get("ETag") == "" || w.Header().Get("Last-Modified") == "") {
+					t.Errorf("%s: expected a 304 with validators and no body, got headers %v body %q", method, w.Header(), w.Body)
+				}
+			}
+		})
+	}
+
+	// A missing quote is never "not modified".
+	r := httptest.NewRequest(http.MethodGet, "/quotes/latest?base=GBP&quote=USD", nil)
+	r.Header.Set("If-Modified-Since", "Tue, 02 Dec 2025 00:00:00 GMT")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for a missing quote, got %d", w.Code)
+	}
+}
+
+func TestHandleGetLatestQuote_IncludeLastAttempt(t *testing.T) {
+	notFound := func(context.Context, service.Pair) (*service.QuoteResult, error) {
+		return nil, service.ErrNotFound
+	}
+	errMsg := "unsupported currency pair"
+
+	tests := []struct {
+		name    string
+		query   string
+		attempt *service.QuoteAttempt
+		want    LatestNotFoundResponse
+	}{
+		{
+			name:  "recently failed pair",
+			query: "&include_last_attempt=true",
+			attempt: &service.QuoteAttempt{
+				Status: "FAILED", ErrorMsg: &errMsg, AttemptAt: "2025-12-01T09:12:04Z",
+			},
+			want: LatestNotFoundResponse{
+				Error: "No quote available for EUR/MXN", Code: ErrCodeNotFound,
+				LastStatus: "FAILED", LastError: errMsg, LastAttemptAt: "2025-12-01T09:12:04Z",
+			},
+		},
+		{
+			name:  "never attempted pair",
+			query: "&include_last_attempt=true",
+			want:  LatestNotFoundResponse{Error: "No quote available for EUR/MXN", Code: ErrCodeNotFound},
+		},
+		{
+			// getLastAttemptFunc is nil: calling it would panic.
+			name:  "flag not set skips the lookup",
+			query: "",
+			want:  LatestNotFoundResponse{Error: "No quote available for EUR/MXN", Code: ErrCodeNotFound},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &mockQuoteService{getLatestQuoteFunc: notFound}
+			if tt.query != "" {
+				svc.getLastAttemptFunc = func(context.Context, service.Pair) (*service.QuoteAttempt, error) {
+					if tt.attempt == nil {
+						return nil, service.ErrNotFound
+					}
+					return tt.attempt, nil
+				}
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/quotes/latest?base=EUR&quote=MXN"+tt.query, nil)
+			w := httptest.NewRecorder()
+			HandleGetLatestQuote(svc, nil, false, ErrorConfig{}).ServeHTTP(w, req)
+
+			if w.Code != http.StatusNotFound {
+				t.Fatalf("Expected status 404, got %d", w.Code)
+			}
+			var resp LatestNotFoundResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if resp != tt.want {
+				t.Errorf("Expected %+v, got %+v", tt.want, resp)
+			}
+		})
+	}
+}
+
+// latestRepo answers GetLatestSuccess from quotes, keyed by pair, and counts the reads.
+type latestRepo struct {
+	repository.QuoteRepository
+	quotes map[service.Pair]*repository.Quote
+	reads  []service.Pair
+}
+
+func (r *latestRepo) GetLatestSuccess(_ context.Context, pair service.Pair) (*repository.Quote, error) {
+	r.reads = append(r.reads, pair)
+	return r.quotes[pair], nil
+}
+
+func TestHandleGetLatestQuote_UpdateID(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	updatedAt := time.Date(2025, 12, 1, 10, 15, 30, 0, time.UTC)
+	price := "18.7543"
+	eurMXN := service.Pair{Base: "EUR", Quote: "MXN"}
+	repo := &latestRepo{quotes: map[service.Pair]*repository.Quote{
+		eurMXN: {ID: "123e4567-e89b-12d3-a456-426614174000", Base: "EUR", Quote: "MXN", Status: repository.StatusSuccess,
+			Price: &price, UpdatedAt: &updatedAt, RateTimestamp: &updatedAt},
+	}}
+	svc := service.NewQuoteService(service.QuoteServiceDeps{
+		Repo: repo, Validator: service.NewValidator(), Cache: rdb,
+		CacheConfig: config.CacheConfig{LatestPriceTTLSec: 60},
+	})
+	handler := HandleGetLatestQuote(svc, nil, false, ErrorConfig{})
+
+	// The first request reads the DB and caches the quote; the second is served from Redis.
+	for _, tier := range []string{"DB", "cache"} {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/quotes/latest?base=EUR&quote=MXN", nil))
+		var resp LatestResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("%s: failed to decode response: %v", tier, err)
+		}
+		if resp.UpdateID != "123e4567-e89b-12d3-a456-426614174000" || resp.Price != price {
+			t.Errorf("%s: expected the update_id of the stored quote, got %+v", tier, resp)
+		}
+	}
+	if len(repo.reads) != 1 {
+		t.Errorf("Expected one DB read, got %v", repo.reads)
+	}
+}
+
+func TestHandleGetLatestQuote_Derived(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	repo := &latestRepo{}
+	svc := service.NewQuoteService(service.QuoteServiceDeps{
+		Repo: repo, Validator: service.NewValidator(), Cache: rdb,
+		CacheConfig: config.CacheConfig{LatestPriceTTLSec: 60},
+	})
+	mr.HSet("latest:{EUR:USD}", "price", "1.085", "updated_at", "2025-12-01T10:00:00Z", "rate_timestamp", "2025-12-01T09:59:00Z")
+	mr.HSet("latest:{USD:EUR}", "price", "0.9216589862", "updated_at", "2025-12-01T10:00:00Z",
+		"rate_timestamp", "2025-12-01T09:59:00Z", "derived", "inverse")
+	handler := HandleGetLatestQuote(svc, nil, false, ErrorConfig{})
+
+	tests := []struct {
+		base, quote string
+		wantBody    string
+	}{
+		{"EUR", "USD", `{"base":"EUR","quote":"USD","price":"1.085","updated_at":"2025-12-01T10:00:00Z","rate_timestamp":"2025-12-01T09:59:00Z"}`},
+		{"USD", "EUR", `{"base":"USD","quote":"EUR","price":"0.9216589862","updated_at":"2025-12-01T10:00:00Z","rate_timestamp":"2025-12-01T09:59:00Z","derived":"inverse"}`},
+	}
+	etags := make(map[string]bool)
+	for _, tc := range tests {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/quotes/latest?base="+tc.base+"&quote="+tc.quote, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s/%s: expected status 200, got %d: %s", tc.base, tc.quote, w.Code, w.Body.String())
+		}
+		if got := strings.TrimSpace(w.Body.String()); got != tc.wantBody {
+			t.Errorf("%s/%s: expected body %s, got %s", tc.base, tc.quote, tc.wantBody, got)
+		}
+		etags[w.Header().Get("ETag")] = true
+	}
+	if len(etags) != 2 {
+		t.Errorf("Expected distinct ETags for the fetched and the derived price, got %v", etags)
+	}
+	if len(repo.reads) != 0 {
+		t.Errorf("Expected both prices to be served from the cache, got DB reads %v", repo.reads)
+	}
+}
+
+func TestHandleGetLatestQuote_SourceHeader(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	updatedAt := time.Date(2025, 12, 1, 10, 15, 30, 0, time.UTC)
+	price := "18.7543"
+	eurMXN := service.Pair{Base: "EUR", Quote: "MXN"}
+	repo := &latestRepo{quotes: map[service.Pair]*repository.Quote{
+		eurMXN: {ID: "123e4567-e89b-12d3-a456-426614174000", Base: "EUR", Quote: "MXN", Status: repository.StatusSuccess,
+			Price: &price, UpdatedAt: &updatedAt, RateTimestamp: &updatedAt},
+	}}
+	svc := service.NewQuoteService(service.QuoteServiceDeps{
+		Repo: repo, Validator: service.NewValidator(), Cache: rdb,
+		CacheConfig: config.CacheConfig{LatestPriceTTLSec: 60},
+	})
+	core, logs := observer.New(zap.InfoLevel)
+	get := func(handler http.Handler) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		middleware.RequestLoggingMiddleware(zap.New(core).Sugar())(handler).
+			ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/quotes/latest?base=EUR&quote=MXN", nil))
+		return w
+	}
+	loggedSource := func() any {
+		entries := logs.TakeAll()
+		if len(entries) != 1 {
+			t.Fatalf("Expected one access log line, got %v", entries)
+		}
+		return entries[0].ContextMap()[quoteSourceLogKey]
+	}
+
+	// The first request reads the DB and caches the quote; the second is served from Redis.
+	handler := HandleGetLatestQuote(svc, nil, true, ErrorConfig{})
+	for _, want := range []string{service.ServedFromDB, service.ServedFromRedis} {
+		w := get(handler)
+		if w.Code != http.StatusOK || w.Header().Get(quoteSourceHeader) != want {
+			t.Errorf("Expected status 200 and %s %q, got %d %q", quoteSourceHeader, want, w.Code, w.Header().Get(quoteSourceHeader))
+		}
+		if got := loggedSource(); got != want {
+			t.Errorf("Expected %s %q in the access log, got %v", quoteSourceLogKey, want, got)
+		}
+	}
+
+	// Disabled, the header is left out but the access log still names the tier.
+	w := get(HandleGetLatestQuote(svc, nil, false, ErrorConfig{}))
+	if w.Code != http.StatusOK || w.Header().Get(quoteSourceHeader) != "" {
+		t.Errorf("Expected no %s when disabled, got %d %q", quoteSourceHeader, w.Code, w.Header().Get(quoteSourceHeader))
+	}
+	if got := loggedSource(); got != service.ServedFromRedis {
+		t.Errorf("Expected %s %q in the access log, got %v", quoteSourceLogKey, service.ServedFromRedis, got)
+	}
+
+	// With the cache down the quote comes from the DB again.
+	mr.Close()
+	if w := get(handler); w.Header().Get(quoteSourceHeader) != service.ServedFromDB {
+		t.Errorf("Expected %s %q with the cache down, got %q", quoteSourceHeader, service.ServedFromDB, w.Header().Get(quoteSourceHeader))
+	}
+	_ = loggedSource()
+
+	// Errors name no tier.
+	mr.Restart()
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/quotes/latest?base=EUR&quote=USD", nil))
+	if w.Code != http.StatusNotFound || w.Header().Get(quoteSourceHeader) != "" {
+		t.Errorf("Expected a 404 without %s, got %d %q", quoteSourceHeader, w.Code, w.Header().Get(quoteSourceHeader))
+	}
+}
+
+func TestHandleGetDefaultLatestQuotes(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	updatedAt, rateTimestamp := time.Date(2025, 12, 1, 10, 15, 30, 0, time.UTC), time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC)
+	price := "18.7543"
+	eurMXN, eurUSD, gbpUSD := service.Pair{Base: "EUR", Quote: "MXN"}, service.Pair{Base: "EUR", Quote: "USD"}, service.Pair{Base: "GBP", Quote: "USD"}
+	repo := &latestRepo{quotes: map[service.Pair]*repository.Quote{
+		eurMXN: {Base: "EUR", Quote: "MXN", Status: repository.StatusSuccess, Price: &price, UpdatedAt: &updatedAt, RateTimestamp: &rateTimestamp},
+	}}
+	svc := service.NewQuoteService(service.QuoteServiceDeps{
+		Repo: repo, Validator: service.NewValidator(), Cache: rdb,
+		CacheConfig: config.CacheConfig{LatestPriceTTLSec: 60, NegativeCacheTTLSec: 30},
+	})
+	// EUR/USD is only in the cache, EUR/MXN only in the DB and GBP/USD nowhere.
+	mr.HSet("latest:{EUR:USD}", "price", "1.085", "updated_at", "2025-12-01T10:00:00Z", "rate_timestamp", "2025-12-01T09:59:00Z")
+
+	handler := HandleGetDefaultLatestQuotes(svc, []service.Pair{gbpUSD, eurUSD, eurMXN}, ErrorConfig{})
+	get := func() DefaultLatestResponse {
+		t.Helper()
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/quotes/latest/defaults", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var resp DefaultLatestResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return resp
+	}
+
+	want := []DefaultLatestEntry{
+		{Base: "GBP", Quote: "USD", NotFound: true},
+		{Base: "EUR", Quote: "USD", Price: "1.085", UpdatedAt: "2025-12-01T10:00:00Z", RateTimestamp: "2025-12-01T09:59:00Z"},
+		{Base: "EUR", Quote: "MXN", Price: price, UpdatedAt: "2025-12-01T10:15:30Z", RateTimestamp: "2025-12-01T00:00:00Z"},
+	}
+	if got := get().Quotes; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %+v, got %+v", want, got)
+	}
+	if !reflect.DeepEqual(repo.reads, []service.Pair{gbpUSD, eurMXN}) {
+		t.Errorf("Expected DB reads of the uncached pairs only, got %v", repo.reads)
+	}
+
+	// The DB answers, including the missing pair, are now cached.
+	repo.reads = nil
+	if got := get().Quotes; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %+v from the cache, got %+v", want, got)
+	}
+	if len(repo.reads) != 0 {
+		t.Errorf("Expected no DB reads, got %v", repo.reads)
+	}
+}
+
+func TestHandleGetDefaultLatestQuotes_Error(t *testing.T) {
+	svc := &mockQuoteService{getLatestQuotesFunc: func(context.Context, []service.Pair) ([]service.LatestQuote, error) {
+		return nil, service.ErrSchemaNotReady
+	}}
+	w := httptest.NewRecorder()
+	HandleGetDefaultLatestQuotes(svc, []service.Pair{{Base: "EUR", Quote: "MXN"}}, ErrorConfig{}).
+		ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/quotes/latest/defaults", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503, got %d", w.Code)
+	}
+}
+
+func TestHandleGetHistoricalQuote(t *testing.T) {
+	t.Run("returns record current at the given time", func(t *testing.T) {
+		price := "1.0850"
+		asOf := "2024-06-15T11:58:02Z"
+		var gotAt time.Time
+		svc := &mockQuoteService{
+			getHistoricalFunc: func(ctx context.Context, pair service.Pair, at time.Time) (*service.QuoteResult, error) {
+				gotAt = at
+				return &service.QuoteResult{Base: "EUR", Quote: "USD", Price: &price, UpdatedAt: &asOf, Status: "SUCCESS"}, nil
+			},
+		}
+
+		req := httptest.NewRequest(http.MethodGet, "/quotes/history/at?base=EUR&quote=USD&at=2024-06-15T12:00:00Z", nil)
+		w := httptest.NewRecorder()
+		HandleGetHistoricalQuote(svc, ErrorConfig{}).ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+		if want := time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC); !gotAt.Equal(want) {
+			t.Errorf("Expected at %v, got %v", want, gotAt)
+		}
+
+		var resp HistoricalResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if resp.Price != price {
+			t.Errorf("Expected price %s, got %s", price, resp.Price)
+		}
+		if resp.AsOf != asOf {
+			t.Errorf("Expected as_of %s (record time), got %s", asOf, resp.AsOf)
+		}
+	})
+
+	t.Run("invalid or missing params return 400", func(t *testing.T) {
+		svc := &mockQuoteService{}
+		for _, q := range []string{
+			"base=EUR&quote=USD",
+			"base=EUR&quote=USD&at=2024-06-15",
+			"base=EUR&quote=USD&at=yesterday",
+		} {
+			req := httptest.NewRequest(http.MethodGet, "/quotes/history/at?"+q, nil)
+			w := httptest.NewRecorder()
+			HandleGetHistoricalQuote(svc, ErrorConfig{}).ServeHTTP(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("%s: expected status 400, got %d", q, w.Code)
+			}
+			assertErrorCode(t, w, ErrCodeInvalidFormat)
+		}
+	})
+
+	t.Run("no record before time returns 404", func(t *testing.T) {
+		svc := &mockQuoteService{
+			getHistoricalFunc: func(ctx context.Context, pair service.Pair, at time.Time) (*service.QuoteResult, error) {
+				return nil, service.ErrNotFound
+			},
+		}
+
+		req := httptest.NewRequest(http.MethodGet, "/quotes/history/at?base=EUR&quote=USD&at=2020-01-01T00:00:00Z", nil)
+		w := httptest.NewRecorder()
+		HandleGetHistoricalQuote(svc, ErrorConfig{}).ServeHTTP(w, req)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", w.Code)
+		}
+		assertErrorCode(t, w, ErrCodeNotFound)
+	})
+}
+
+func TestHandleCompareQuotes(t *testing.T) {
+	atPrice, vsPrice := "20.7481", "19.9179"
+	atAsOf, vsAsOf := "2025-01-01T21:04:12Z", "2025-06-01T22:10:00Z"
+
+	t.Run("returns both quotes and the change", func(t *testing.T) {
+		var gotAt, gotVs time.Time
+		svc := &mockQuoteService{
+			compareQuotesFunc: func(ctx context.Context, pair service.Pair, at, vs time.Time) (*service.QuoteComparison, error) {
+				gotAt, gotVs = at, vs
+				return &service.QuoteComparison{
+					Base: "EUR", Quote: "MXN",
+					At:     &service.QuoteResult{Price: &atPrice, UpdatedAt: &atAsOf},
+					Vs:     &service.QuoteResult{Price: &vsPrice, UpdatedAt: &vsAsOf},
+					Change: "-0.8302", ChangePct: "-4.0013",
+				}, nil
+			},
+		}
+
+		req := httptest.NewRequest(http.MethodGet, "/quotes/compare?base=EUR&quote=MXN&at=2025-01-02&vs=2025-06-02T12:00:00Z", nil)
+		w := httptest.NewRecorder()
+		HandleCompareQuotes(svc, ErrorConfig{}).ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+		if want := time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC); !gotAt.Equal(want) {
+			t.Errorf("Expected a date to mean midnight UTC %v, got %v", want, gotAt)
+		}
+		if want := time.Date(2025, 6, 2, 12, 0, 0, 0, time.UTC); !gotVs.Equal(want) {
+			t.Errorf("Expected vs %v, got %v", want, gotVs)
+		}
+
+		var resp CompareResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		want := CompareResponse{
+			Base: "EUR", Quote: "MXN",
+			At:     ComparisonPoint{Price: atPrice, AsOf: atAsOf},
+			Vs:     ComparisonPoint{Price: vsPrice, AsOf: vsAsOf},
+			Change: "-0.8302", ChangePct: "-4.0013",
+		}
+		if resp != want {
+			t.Errorf("Expected %+v, got %+v", want, resp)
+		}
+	})
+
+	t.Run("invalid or missing params return 400", func(t *testing.T) {
+		svc := &mockQuoteService{}
+		for _, q := range []string{
+			"base=EUR&quote=MXN&at=2025-01-02",
+			"base=EUR&quote=MXN&at=2025-01-02&vs=June",
+			"base=EU&quote=MXN&at=2025-01-02&vs=2025-06-02",
+		} {
+			req := httptest.NewRequest(http.MethodGet, "/quotes/compare?"+q, nil)
+			w := httptest.NewRecorder()
+			HandleCompareQuotes(svc, ErrorConfig{}).ServeHTTP(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("%s: expected status 400, got %d", q, w.Code)
+			}
+			assertErrorCode(t, w, ErrCodeInvalidFormat)
+		}
+	})
+
+	t.Run("invalid range returns 400", func(t *testing.T) {
+		svc := &mockQuoteService{
+			compareQuotesFunc: func(ctx context.Context, pair service.Pair, at, vs time.Time) (*service.QuoteComparison, error) {
+				return nil, service.ErrInvalidComparisonRange
+			},
+		}
+
+		req := httptest.NewRequest(http.MethodGet, "/quotes/compare?base=EUR&quote=MXN&at=2025-06-02&vs=2025-01-02", nil)
+		w := httptest.NewRecorder()
+		HandleCompareQuotes(svc, ErrorConfig{}).ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", w.Code)
+		}
+		assertErrorCode(t, w, ErrCodeInvalidFormat)
+	})
+
+	t.Run("missing side returns 404 naming it", func(t *testing.T) {
+		svc := &mockQuoteService{
+			compareQuotesFunc: func(ctx context.Context, pair service.Pair, at, vs time.Time) (*service.QuoteComparison, error) {
+				return nil, &service.ComparisonNotFoundError{Missing: []string{"at"}}
+			},
+		}
+
+		req := httptest.NewRequest(http.MethodGet, "/quotes/compare?base=EUR&quote=MXN&at=2020-01-02&vs=2025-06-02", nil)
+		w := httptest.NewRecorder()
+		HandleCompareQuotes(svc, ErrorConfig{}).ServeHTTP(w, req)
+
+		if w.Code != http.StatusNotFound {
+			t.Fatalf("Expected status 404, got %d", w.Code)
+		}
+		var resp CompareNotFoundResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if resp.Code != ErrCodeNotFound || len(resp.Missing) != 1 || resp.Missing[0] != "at" {
+			t.Errorf("Expected code %d missing [at], got %+v", ErrCodeNotFound, resp)
+		}
+		if want := "No quote available for EUR/MXN at or before at=2020-01-02"; resp.Error != want {
+			t.Errorf("Expected error %q, got %q", want, resp.Error)
+		}
+	})
+}
+
+func TestQuoteHandlers_PairAccess(t *testing.T) {
+	// The mock enforces the caller's PairAccess on an EUR/USD record like the service does.
+	check := func(ctx context.Context, err error) error {
+		if !service.PairAccessFromContext(ctx).Allows(service.Pair{Base: "EUR", Quote: "USD"}) {
+			return err
+		}
+		return nil
+	}
+	result := &service.QuoteResult{ID: "eur-usd-id", Base: "EUR", Quote: "USD", Status: "SUCCESS"}
+	svc := &mockQuoteService{
+		requestUpdateFunc: func(ctx context.Context, _ string, _ service.UpdateOptions) (*service.UpdateRequestResult, error) {
+			if err := check(ctx, service.ErrPairForbidden); err != nil {
+				return nil, err
+			}
+			return &service.UpdateRequestResult{UpdateID: result.ID, Status: "PENDING"}, nil
+		},
+		getLatestQuoteFunc: func(ctx context.Context, _ service.Pair) (*service.QuoteResult, error) {
+			if err := check(ctx, service.ErrPairForbidden); err != nil {
+				return nil, err
+			}
+			return result, nil
+		},
+		getQuoteResultFunc: func(ctx context.Context, _ string) (*service.QuoteResult, error) {
+			if err := check(ctx, service.ErrNotFound); err != nil {
+				return nil, err
+			}
+			return result, nil
+		},
+	}
+
+	r := chi.NewRouter()
+	r.Use(middleware.APIKeyMiddleware([]middleware.APIKey{
+		{Key: "k-unscoped", Scopes: []string{middleware.ScopeAdmin}},
+		{Key: "k-allowed", Scopes: []string{middleware.ScopeAdmin}, Access: &service.PairAccess{Pairs: []string{"EUR/USD"}}},
+		{Key: "k-denied", Scopes: []string{middleware.ScopeAdmin}, Access: &service.PairAccess{Bases: []string{"GBP"}}},
+	}))
+	r.Post("/quotes/update", HandleRequestUpdate(svc, nil, ErrorConfig{}))
+	r.Get("/quotes/latest", HandleGetLatestQuote(svc, nil, false, ErrorConfig{}))
+	r.Get("/quotes/{update_id}", HandleGetQuoteByID(svc, nil, ErrorConfig{}))
+
+	routes := []struct {
+		method, path, body string
+	}{
+		{http.MethodPost, "/quotes/update", `{"pair":"EUR/USD"}`},
+		{http.MethodGet, "/quotes/latest?base=EUR&quote=USD", ""},
+		{http.MethodGet, "/quotes/eur-usd-id", ""},
+	}
+	// Expected status and error code per key for each route, in the order of routes above.
+	expected := map[string][3][2]int{
+		"k-unscoped": {{http.StatusAccepted, 0}, {http.StatusOK, 0}, {http.StatusOK, 0}},
+		"k-allowed":  {{http.StatusAccepted, 0}, {http.StatusOK, 0}, {http.StatusOK, 0}},
+		"k-denied": {
+			{http.StatusForbidden, ErrCodePairForbidden},
+			{http.StatusForbidden, ErrCodePairForbidden},
+			{http.StatusNotFound, ErrCodeNotFound},
+		},
+	}
+
+	for key, want := range expected {
+		for i, route := range routes {
+			t.Run(key+" "+route.method+" "+route.path, func(t *testing.T) {
+				req := httptest.NewRequest(route.method, route.path, bytes.NewBufferString(route.body))
+				req.Header.Set("X-API-Key", key)
+				w := httptest.NewRecorder()
+				r.ServeHTTP(w, req)
+
+				if w.Code != want[i][0] {
+					t.Fatalf("Expected status %d, got %d: %s", want[i][0], w.Code, w.Body)
+				}
+				if want[i][1] != 0 {
+					assertErrorCode(t, w, want[i][1])
+				}
+			})
+		}
+	}
+}
+
+func TestQuoteHandlers_NormalizeCodes(t *testing.T) {
+	var got []string
+	record := func(pair string) { got = append(got, pair) }
+	result := &service.QuoteResult{ID: "id", Base: "EUR", Quote: "MXN", Status: "SUCCESS"}
+	svc := &mockQuoteService{
+		requestUpdateFunc: func(_ context.Context, pair string, _ service.UpdateOptions) (*service.UpdateRequestResult, error) {
+			record(pair)
+			return &service.UpdateRequestResult{UpdateID: "id", Status: "PENDING"}, nil
+		},
+		fetchQuoteFunc: func(_ context.Context, pair string, _ time.Duration) (*service.QuoteResult, error) {
+			record(pair)
+			return result, nil
+		},
+		getLatestQuoteFunc: func(_ context.Context, pair service.Pair) (*service.QuoteResult, error) {
+			record(pair.String())
+			return result, nil
+		},
+		getHistoricalFunc: func(_ context.Context, pair service.Pair, _ time.Time) (*service.QuoteResult, error) {
+			record(pair.String())
+			return result, nil
+		},
+	}
+	r := chi.NewRouter()
+	r.Post("/quotes/update", HandleRequestUpdate(svc, nil, ErrorConfig{}))
+	r.Post("/quotes/fetch", HandleFetchQuote(svc, nil, time.Second, ErrorConfig{}))
+	r.Get("/quotes/latest", HandleGetLatestQuote(svc, nil, false, ErrorConfig{}))
+	r.Get("/quotes/history/at", HandleGetHistoricalQuote(svc, ErrorConfig{}))
+
+	for _, spelling := range [][2]string{{"eur", "mxn"}, {" EUR ", "MXN"}, {"EUR", "MXN"}} {
+		base, quote := spelling[0], spelling[1]
+		requests := []*http.Request{
+			httptest.NewRequest(http.MethodPost, "/quotes/update", bytes.NewBufferString(fmt.Sprintf(`{"pair":%q}`, base+"/"+quote))),
+			httptest.NewRequest(http.MethodPost, "/quotes/fetch", bytes.NewBufferString(fmt.Sprintf(`{"pair":%q,"timeout_ms":100}`, base+"/"+quote))),
+			httptest.NewRequest(http.MethodGet, "/quotes/latest?base="+url.QueryEscape(base)+"&quote="+url.QueryEscape(quote), nil),
+			httptest.NewRequest(http.MethodGet, "/quotes/history/at?base="+url.QueryEscape(base)+"&quote="+url.QueryEscape(quote)+"&at=2025-12-01T00:00:00Z", nil),
+		}
+		got = nil
+		for _, req := range requests {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code >= 300 {
+				t.Fatalf("%s %s: expected success, got %d: %s", req.Method, req.URL, w.Code, w.Body.String())
+			}
+		}
+		if want := []string{"EUR/MXN", "EUR/MXN", "EUR/MXN", "EUR/MXN"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("%q: expected the service to get %v, got %v", spelling, want, got)
+		}
+	}
+}
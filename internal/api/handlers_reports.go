@@ -0,0 +1,227 @@
+package api
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"quoteservice/internal/repository"
+	"quoteservice/internal/service"
+)
+
+// Row limits of GET /admin/reports/reliability.
+const (
+	DefaultReliabilityLimit = 1000
+	MaxReliabilityLimit     = 10000
+)
+
+// reliabilityFlushEvery is how many rows the report writes between flushes.
+const reliabilityFlushEvery = 100
+
+// ReliabilityReporter aggregates completed updates by provider and pair; implemented
+// by *repository.PostgresReliabilityReporter.
+type ReliabilityReporter interface {
+	ReliabilityReport(ctx context.Context, from, to time.Time, limit int, fn func(repository.ReliabilityRow) error) (bool, error)
+}
+
+// ReliabilityRowResponse is the outcome of one pair's updates through one provider
+type ReliabilityRowResponse struct {
+	// Empty for updates without a recorded provider, such as streamed rates.
+	Provider    string  `json:"provider" example:"frankfurter"`
+	Base        string  `json:"base" example:"EUR"`
+	Quote       string  `json:"quote" example:"USD"`
+	Success     int64   `json:"success" example:"1180"`
+	Failed      int64   `json:"failed" example:"20"`
+	FailureRate float64 `json:"failure_rate" example:"0.0167"`
+	// Null when none of the updates recorded a fetch duration.
+	AvgFetchDurationMs *float64 `json:"avg_fetch_duration_ms" example:"142.5"`
+}
+
+// ReliabilityReportResponse lists the outcome of the updates completed in [from, to)
+// by provider and pair; truncated is set when more rows than limit matched
+type ReliabilityReportResponse struct {
+	From      string                   `json:"from" example:"2025-12-01T00:00:00Z"`
+	To        string                   `json:"to" example:"2025-12-08T00:00:00Z"`
+	Rows      []ReliabilityRowResponse `json:"rows"`
+	Truncated bool                     `json:"truncated" example:"false"`
+}
+
+// HandleReliabilityReport godoc
+// @Summary Report provider reliability per pair
+// @Description Counts the updates that reached SUCCESS or FAILED in [from, to), archived ones included, per provider and pair, with the failure rate and mean fetch duration. Rows are sorted by provider, base and quote and streamed as they are read. With Accept: text/csv the report is a CSV file with a header row; whether it was truncated is then sent in the X-Report-Truncated trailer. Requires the admin scope.
+// @Tags admin
+// @Produce json
+// @Produce text/csv
+// @Param from query string true "Start of the range, inclusive: RFC3339 timestamp or YYYY-MM-DD date" example(2025-12-01)
+// @Param to query string true "End of the range, exclusive: RFC3339 timestamp or YYYY-MM-DD date" example(2025-12-08)
+// @Param limit query int false "Maximum number of rows" default(1000) minimum(1) maximum(10000)
+// @Success 200 {object} ReliabilityReportResponse "Report rows"
+// @Failure 400 {object} ErrorResponse "Invalid from, to or limit"
+// @Failure 401 {object} ErrorResponse "Missing or invalid API key (when auth is enabled)"
+// @Failure 403 {object} ErrorResponse "API key lacks the admin scope"
+// @Failure 429 {object} QuotaExceededResponse "Monthly quota of the API key used up (when quotas are enabled)"
+// @Failure 500 {object} ErrorResponse "Internal error"
+// @Failure 503 {object} ErrorResponse "Database schema not ready"
+// @Router /admin/reports/reliability [get]
+func HandleReliabilityReport(reporter ReliabilityReporter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		from, fromErr := parseTimeParam(q.Get("from"))
+		to, toErr := parseTimeParam(q.Get("to"))
+		if fromErr != nil || toErr != nil {
+			writeError(w, r, http.StatusBadRequest, ErrCodeInvalidFormat, "from and to must be RFC3339 timestamps or YYYY-MM-DD dates")
+			return
+		}
+		if !from.Before(to) {
+			writeError(w, r, http.StatusBadRequest, ErrCodeInvalidFormat, "from must be before to")
+			return
+		}
+		limit := DefaultReliabilityLimit
+		if v := q.Get("limit"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 || n > MaxReliabilityLimit {
+				writeError(w, r, http.StatusBadRequest, ErrCodeInvalidFormat,
+					"limit must be between 1 and "+strconv.Itoa(MaxReliabilityLimit))
+				return
+			}
+			limit = n
+		}
+
+		var out reportWriter
+		if negotiate(r, "application/json", "text/csv") == "text/csv" {
+			out = &csvReportWriter{w: w}
+		} else {
+			out = &jsonReportWriter{w: w, from: from, to: to}
+		}
+		rc := http.NewResponseController(w)
+		rows := 0
+		truncated, err := reporter.ReliabilityReport(r.Context(), from, to, limit, func(row repository.ReliabilityRow) error {
+			if rows == 0 {
+				out.begin()
+			}
+			if err := out.row(reliabilityRowResponse(row)); err != nil {
+				return err
+			}
+			if rows++; rows%reliabilityFlushEvery == 0 {
+				_ = rc.Flush() // A writer that cannot flush still gets the whole report.
+			}
+			return nil
+		})
+		switch {
+		case err != nil && rows == 0 && repository.IsSchemaError(err):
+			writeError(w, r, http.StatusServiceUnavailable, ErrCodeSchemaNotReady, "Database schema not ready")
+		case err != nil && rows == 0:
+			writeError(w, r, http.StatusInternalServerError, ErrCodeInternal, "Internal error")
+		case err != nil:
+			// The status is sent already; abort so the client sees a broken response
+			// rather than a complete-looking partial report.
+			panic(http.ErrAbortHandler)
+		default:
+			if rows == 0 {
+				out.begin()
+			}
+			out.end(truncated)
+		}
+	}
+}
+
+func reliabilityRowResponse(row repository.ReliabilityRow) ReliabilityRowResponse {
+	resp := ReliabilityRowResponse{
+		Provider:    row.Source,
+		Base:        row.Pair.Base,
+		Quote:       row.Pair.Quote,
+		Success:     row.Success,
+		Failed:      row.Failed,
+		FailureRate: math.Round(row.FailureRate()*1e4) / 1e4,
+	}
+	if row.AvgFetchMs != nil {
+		avg := math.Round(*row.AvgFetchMs*10) / 10
+		resp.AvgFetchDurationMs = &avg
+	}
+	return resp
+}
+
+// reportWriter streams a report in one format. begin sends the status and whatever
+// precedes the rows, end whatever follows them.
+type reportWriter interface {
+	begin()
+	row(ReliabilityRowResponse) error
+	end(truncated bool)
+}
+
+// jsonReportWriter writes a ReliabilityReportResponse one row at a time.
+type jsonReportWriter struct {
+	w        http.ResponseWriter
+	from, to time.Time
+	rows     int
+}
+
+func (j *jsonReportWriter) begin() {
+	j.w.Header().Set("Content-Type", "application/json")
+	j.w.WriteHeader(http.StatusOK)
+	from, _ := json.Marshal(service.FormatTimestamp(j.from))
+	to, _ := json.Marshal(service.FormatTimestamp(j.to))
+	_, _ = j.w.Write([]byte(`{"from":` + string(from) + `,"to":` + string(to) + `,"rows":[`))
+}
+
+func (j *jsonReportWriter) row(row ReliabilityRowResponse) error {
+	data, err := json.Marshal(row)
+	if err != nil {
+		return err
+	}
+	if j.rows > 0 {
+		data = append([]byte{','}, data...)
+	}
+	j.rows++
+	_, err = j.w.Write(data)
+	return err
+}
+
+func (j *jsonReportWriter) end(truncated bool) {
+	_, _ = j.w.Write([]byte(`],"truncated":` + strconv.FormatBool(truncated) + "}\n"))
+}
+
+// csvReportWriter writes a report as CSV with a header row.
+type csvReportWriter struct {
+	w  http.ResponseWriter
+	cw *csv.Writer
+}
+
+// reportTruncatedTrailer tells a CSV client whether the report was cut at the limit.
+const reportTruncatedTrailer = "X-Report-Truncated"
+
+func (c *csvReportWriter) begin() {
+	c.w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	c.w.Header().Set("Content-Disposition", `attachment; filename="reliability.csv"`)
+	c.w.Header().Set("Trailer", reportTruncatedTrailer)
+	c.w.WriteHeader(http.StatusOK)
+	c.cw = csv.NewWriter(c.w)
+	_ = c.cw.Write([]string{"provider", "base", "quote", "success", "failed", "failure_rate", "avg_fetch_duration_ms"})
+}
+
+func (c *csvReportWriter) row(row ReliabilityRowResponse) error {
+	avg := ""
+	if row.AvgFetchDurationMs != nil {
+		avg = strconv.FormatFloat(*row.AvgFetchDurationMs, 'f', -1, 64)
+	}
+	err := c.cw.Write([]string{
+		row.Provider, row.Base, row.Quote,
+		strconv.FormatInt(row.Success, 10), strconv.FormatInt(row.Failed, 10),
+		strconv.FormatFloat(row.FailureRate, 'f', -1, 64), avg,
+	})
+	if err != nil {
+		return err
+	}
+	// Hand the row to the ResponseWriter, so the handler's flushes reach the client.
+	c.cw.Flush()
+	return c.cw.Error()
+}
+
+func (c *csvReportWriter) end(truncated bool) {
+	c.cw.Flush()
+	c.w.Header().Set(reportTruncatedTrailer, strconv.FormatBool(truncated))
+}
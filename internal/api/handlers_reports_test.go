@@ -0,0 +1,170 @@
+package api
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"quoteservice/internal/repository"
+)
+
+// mockReliabilityReporter streams rows, stopping at the limit like Postgres, and then
+// fails with err if set.
+type mockReliabilityReporter struct {
+	rows []repository.ReliabilityRow
+	err  error
+
+	from, to time.Time
+	limit    int
+}
+
+func (m *mockReliabilityReporter) ReliabilityReport(_ context.Context, from, to time.Time, limit int, fn func(repository.ReliabilityRow) error) (bool, error) {
+	m.from, m.to, m.limit = from, to, limit
+	for i, row := range m.rows {
+		if i == limit {
+			return true, nil
+		}
+		if err := fn(row); err != nil {
+			return false, err
+		}
+	}
+	return false, m.err
+}
+
+func reliabilityFixture() []repository.ReliabilityRow {
+	avg := 142.54
+	return []repository.ReliabilityRow{
+		{Pair: repository.Pair{Base: "EUR", Quote: "USD"}, Success: 3},
+		{Source: "frankfurter", Pair: repository.Pair{Base: "EUR", Quote: "MXN"}, Success: 2, Failed: 1, AvgFetchMs: &avg},
+	}
+}
+
+func getReliabilityReport(reporter ReliabilityReporter, query, accept string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, "/admin/reports/reliability?"+query, nil)
+	if accept != "" {
+		r.Header.Set("Accept", accept)
+	}
+	w := httptest.NewRecorder()
+	HandleReliabilityReport(reporter).ServeHTTP(w, r)
+	return w
+}
+
+func TestHandleReliabilityReport_JSON(t *testing.T) {
+	reporter := &mockReliabilityReporter{rows: reliabilityFixture()}
+	w := getReliabilityReport(reporter, "from=2025-12-01&to=2025-12-08T12:00:00Z", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if reporter.limit != DefaultReliabilityLimit ||
+		!reporter.from.Equal(time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC)) ||
+		!reporter.to.Equal(time.Date(2025, 12, 8, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected query from %v to %v limit %d", reporter.from, reporter.to, reporter.limit)
+	}
+	var resp ReliabilityReportResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if resp.From != "2025-12-01T00:00:00Z" || resp.To != "2025-12-08T12:00:00Z" || resp.Truncated {
+		t.Errorf("Unexpected report %+v", resp)
+	}
+	if len(resp.Rows) != 2 {
+		t.Fatalf("Expected 2 rows, got %+v", resp.Rows)
+	}
+	if row := resp.Rows[0]; row.Provider != "" || row.Success != 3 || row.FailureRate != 0 || row.AvgFetchDurationMs != nil {
+		t.Errorf("Unexpected row without a provider %+v", row)
+	}
+	if row := resp.Rows[1]; row.Provider != "frankfurter" || row.Base != "EUR" || row.Quote != "MXN" || row.Failed != 1 ||
+		row.FailureRate != 0.3333 || row.AvgFetchDurationMs == nil || *row.AvgFetchDurationMs != 142.5 {
+		t.Errorf("Unexpected row %+v", row)
+	}
+}
+
+func TestHandleReliabilityReport_Truncated(t *testing.T) {
+	w := getReliabilityReport(&mockReliabilityReporter{rows: reliabilityFixture()}, "from=2025-12-01&to=2025-12-08&limit=1", "")
+	var resp ReliabilityReportResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(resp.Rows) != 1 || !resp.Truncated {
+		t.Errorf("Expected 1 row and truncated, got %+v", resp)
+	}
+}
+
+func TestHandleReliabilityReport_Empty(t *testing.T) {
+	w := getReliabilityReport(&mockReliabilityReporter{}, "from=2025-12-01&to=2025-12-08", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	if got := strings.TrimSpace(w.Body.String()); got != `{"from":"2025-12-01T00:00:00Z","to":"2025-12-08T00:00:00Z","rows":[],"truncated":false}` {
+		t.Errorf("Unexpected body %s", got)
+	}
+}
+
+func TestHandleReliabilityReport_CSV(t *testing.T) {
+	w := getReliabilityReport(&mockReliabilityReporter{rows: reliabilityFixture()}, "from=2025-12-01&to=2025-12-08&limit=1",
+		"text/csv, application/json;q=0.5")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "text/csv; charset=utf-8" {
+		t.Errorf("Expected a CSV content type, got %q", ct)
+	}
+	records, err := csv.NewReader(w.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("Failed to parse CSV: %v", err)
+	}
+	want := [][]string{
+		{"provider", "base", "quote", "success", "failed", "failure_rate", "avg_fetch_duration_ms"},
+		{"", "EUR", "USD", "3", "0", "0", ""},
+	}
+	if len(records) != len(want) || strings.Join(records[0], ",") != strings.Join(want[0], ",") ||
+		strings.Join(records[1], ",") != strings.Join(want[1], ",") {
+		t.Errorf("Expected %v, got %v", want, records)
+	}
+	if got := w.Result().Trailer.Get(reportTruncatedTrailer); got != "true" {
+		t.Errorf("Expected the truncated trailer, got %q", got)
+	}
+}
+
+func TestHandleReliabilityReport_InvalidParams(t *testing.T) {
+	for _, query := range []string{
+		"to=2025-12-08",
+		"from=yesterday&to=2025-12-08",
+		"from=2025-12-08&to=2025-12-08",
+		"from=2025-12-08&to=2025-12-01",
+		"from=2025-12-01&to=2025-12-08&limit=0",
+		"from=2025-12-01&to=2025-12-08&limit=10001",
+		"from=2025-12-01&to=2025-12-08&limit=ten",
+	} {
+		w := getReliabilityReport(&mockReliabilityReporter{}, query, "")
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", query, w.Code)
+		}
+	}
+}
+
+func TestHandleReliabilityReport_Error(t *testing.T) {
+	w := getReliabilityReport(&mockReliabilityReporter{err: errors.New("db down")}, "from=2025-12-01&to=2025-12-08", "")
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status 500 before any row, got %d", w.Code)
+	}
+
+	w = getReliabilityReport(&mockReliabilityReporter{err: &repository.SchemaError{MissingColumns: []string{"quotes.source"}}},
+		"from=2025-12-01&to=2025-12-08", "")
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503 for a schema that is not ready, got %d", w.Code)
+	}
+
+	defer func() {
+		if r := recover(); r != http.ErrAbortHandler {
+			t.Errorf("Expected an error after the first row to abort the response, got %v", r)
+		}
+	}()
+	getReliabilityReport(&mockReliabilityReporter{rows: reliabilityFixture(), err: errors.New("db down")}, "from=2025-12-01&to=2025-12-08", "")
+}
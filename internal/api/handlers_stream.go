@@ -0,0 +1,112 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"quoteservice/internal/service"
+)
+
+// QuoteEventResponse is the data payload of a /quotes/stream server-sent event
+type QuoteEventResponse struct {
+	Type      string         `json:"type" example:"update" enums:"update,heartbeat,done"`
+	Data      *QuoteResponse `json:"data,omitempty"`
+	Timestamp string         `json:"timestamp" example:"2025-12-01T10:15:30Z"`
+}
+
+// HandleQuoteStream godoc
+// @Summary Stream quote updates for a currency pair
+// @Description Opens a Server-Sent Events stream. An "update" event is pushed whenever a new rate for the pair is stored, a "heartbeat" event is sent periodically to keep the connection alive, and a "done" event is sent before the server closes the stream. Each event's data is a QuoteEventResponse.
+// @Tags quotes
+// @Produce text/event-stream
+// @Param base query string true "Base currency code (3 letters)" minlength(3) maxlength(3)
+// @Param quote query string true "Quote currency code (3 letters)" minlength(3) maxlength(3)
+// @Success 200 {object} QuoteEventResponse "Event stream"
+// @Failure 400 {object} ErrorResponse "Invalid currency code format or unsupported currency"
+// @Failure 401 {object} ErrorResponse "Missing or invalid API key (when auth is enabled)"
+// @Failure 403 {object} ErrorResponse "API key lacks the read scope or is not permitted to access the pair"
+// @Failure 429 {object} QuotaExceededResponse "Monthly quota of the API key used up (when quotas are enabled)"
+// @Failure 500 {object} ErrorResponse "Internal error"
+// @Failure 503 {object} ErrorResponse "Database schema not ready"
+// @Router /quotes/stream [get]
+func HandleQuoteStream(svc service.QuoteServiceInterface, heartbeat time.Duration, errs ErrorConfig) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		base := service.NormalizeCode(r.URL.Query().Get("base"))
+		quote := service.NormalizeCode(r.URL.Query().Get("quote"))
+		if base == "" || quote == "" {
+			writeError(w, r, http.StatusBadRequest, ErrCodeInvalidFormat, "base and quote query params are required")
+			return
+		}
+
+		pair, err := service.NewPair(base, quote)
+		if err != nil {
+			writeServiceError(w, r, errs, err, "")
+			return
+		}
+
+		events, err := svc.SubscribePair(r.Context(), pair)
+		if err != nil {
+			writeServiceError(w, r, errs, err, "No quote available for "+pair.String())
+			return
+		}
+
+		rc := http.NewResponseController(w)
+		// The stream outlives the server's WriteTimeout.
+		_ = rc.SetWriteDeadline(time.Time{})
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+		if err := rc.Flush(); err != nil {
+			return
+		}
+
+		ticker := time.NewTicker(heartbeat)
+		defer ticker.Stop()
+
+		for {
+			var ev service.QuoteEvent
+			select {
+			case <-r.Context().Done():
+				return
+			case e, ok := <-events:
+				if !ok {
+					_ = writeSSE(rc, w, service.QuoteEvent{Type: service.QuoteEventDone, Timestamp: time.Now().UTC()})
+					return
+				}
+				ev = e
+			case t := <-ticker.C:
+				ev = service.QuoteEvent{Type: service.QuoteEventHeartbeat, Timestamp: t.UTC()}
+			}
+			if err := writeSSE(rc, w, ev); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func writeSSE(rc *http.ResponseController, w http.ResponseWriter, ev service.QuoteEvent) error {
+	resp := QuoteEventResponse{Type: ev.Type, Timestamp: service.FormatTimestamp(ev.Timestamp)}
+	if ev.Type == service.QuoteEventUpdate {
+		resp.Data = &QuoteResponse{
+			Base:          ev.Data.Base,
+			Quote:         ev.Data.Quote,
+			Status:        ev.Data.Status,
+			Price:         ev.Data.Price,
+			UpdatedAt:     ev.Data.UpdatedAt,
+			RateTimestamp: ev.Data.RateTimestamp,
+		}
+	}
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.Type, data); err != nil {
+		return err
+	}
+	return rc.Flush()
+}
@@ -0,0 +1,203 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"quoteservice/internal/service"
+)
+
+type sseEvent struct {
+	name string
+	data QuoteEventResponse
+}
+
+// readSSE parses the next event from an SSE stream.
+func readSSE(t *testing.T, r *bufio.Reader) sseEvent {
+	t.Helper()
+	var ev sseEvent
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("read SSE stream: %v", err)
+		}
+		line = strings.TrimRight(line, "\n")
+		switch {
+		case line == "":
+			return ev
+		case strings.HasPrefix(line, "event: "):
+			ev.name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &ev.data); err != nil {
+				t.Fatalf("decode SSE data: %v", err)
+			}
+		}
+	}
+}
+
+func openStream(ctx context.Context, t *testing.T, client *http.Client, url string) (*http.Response, *bufio.Reader) {
+	t.Helper()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatalf("build request: %v", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("open stream: %v", err)
+	}
+	return resp, bufio.NewReader(resp.Body)
+}
+
+func TestHandleQuoteStream(t *testing.T) {
+	events := make(chan service.QuoteEvent)
+	gotPair := make(chan string, 1)
+	svc := &mockQuoteService{
+		subscribePairFunc: func(ctx context.Context, pair service.Pair) (<-chan service.QuoteEvent, error) {
+			gotPair <- pair.String()
+			return events, nil
+		},
+	}
+	srv := httptest.NewServer(HandleQuoteStream(svc, 20*time.Millisecond, ErrorConfig{}))
+	defer srv.Close()
+
+	resp, r := openStream(context.Background(), t, srv.Client(), srv.URL+"?base=eur&quote=usd")
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Expected Content-Type text/event-stream, got %q", ct)
+	}
+	if pair := <-gotPair; pair != "EUR/USD" {
+		t.Errorf("Expected SubscribePair for the normalized EUR/USD, got %s", pair)
+	}
+
+	if ev := readSSE(t, r); ev.name != service.QuoteEventHeartbeat || ev.data.Data != nil {
+		t.Errorf("Expected heartbeat without data, got %+v", ev)
+	}
+
+	price, ts := "1.085", "2025-12-01T10:15:30Z"
+	events <- service.QuoteEvent{
+		Type:      service.QuoteEventUpdate,
+		Data:      service.QuoteResult{Base: "EUR", Quote: "USD", Status: "SUCCESS", Price: &price, UpdatedAt: &ts},
+		Timestamp: time.Now(),
+	}
+	ev := readSSE(t, r)
+	for ev.name == service.QuoteEventHeartbeat {
+		ev = readSSE(t, r)
+	}
+	if ev.name != service.QuoteEventUpdate || ev.data.Type != service.QuoteEventUpdate {
+		t.Fatalf("Expected update event, got %+v", ev)
+	}
+	if ev.data.Data == nil || ev.data.Data.Price == nil || *ev.data.Data.Price != price {
+		t.Errorf("Expected update with price %s, got %+v", price, ev.data.Data)
+	}
+
+	close(events)
+	ev = readSSE(t, r)
+	for ev.name == service.QuoteEventHeartbeat {
+		ev = readSSE(t, r)
+	}
+	if ev.name != service.QuoteEventDone {
+		t.Errorf("Expected done event after channel close, got %+v", ev)
+	}
+	if _, err := r.ReadString('\n'); err == nil {
+		t.Error("Expected stream to be closed after done event")
+	}
+}
+
+func TestHandleQuoteStream_Errors(t *testing.T) {
+	svc := &mockQuoteService{
+		subscribePairFunc: func(ctx context.Context, pair service.Pair) (<-chan service.QuoteEvent, error) {
+			return nil, service.ErrInvalidPairFormat
+		},
+	}
+	handler := HandleQuoteStream(svc, time.Second, ErrorConfig{})
+
+	for _, tc := range []struct{ name, query string }{
+		{"missing params", "?base=EUR"},
+		{"invalid pair", "?base=E1R&quote=USD"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/quotes/stream"+tc.query, nil))
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("Expected status 400, got %d", w.Code)
+			}
+			assertErrorCode(t, w, ErrCodeInvalidFormat)
+		})
+	}
+}
+
+// TestHandleQuoteStream_NoGoroutineLeak opens 1000 concurrent streams, disconnects them
+// and checks that every handler goroutine exits.
+func TestHandleQuoteStream_NoGoroutineLeak(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping load test in short mode")
+	}
+	const streams = 1000
+
+	svc := &mockQuoteService{
+		subscribePairFunc: func(ctx context.Context, pair service.Pair) (<-chan service.QuoteEvent, error) {
+			events := make(chan service.QuoteEvent)
+			go func() {
+				<-ctx.Done()
+				close(events)
+			}()
+			return events, nil
+		},
+	}
+
+	baseline := runtime.NumGoroutine()
+
+	srv := httptest.NewServer(HandleQuoteStream(svc, 10*time.Millisecond, ErrorConfig{}))
+	transport := &http.Transport{MaxIdleConnsPerHost: streams}
+	client := &http.Client{Transport: transport}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	errs := make(chan string, streams)
+	for range streams {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"?base=EUR&quote=USD", nil)
+			resp, err := client.Do(req)
+			if err != nil {
+				errs <- err.Error()
+				return
+			}
+			defer resp.Body.Close()
+			line, err := bufio.NewReader(resp.Body).ReadString('\n')
+			if err != nil || !strings.HasPrefix(line, "event: ") {
+				errs <- "no event received"
+			}
+		}()
+	}
+	wg.Wait()
+	cancel()
+	close(errs)
+	for e := range errs {
+		t.Fatalf("stream failed: %s", e)
+	}
+
+	transport.CloseIdleConnections()
+	srv.Close()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for runtime.NumGoroutine() > baseline+5 {
+		if time.Now().After(deadline) {
+			t.Fatalf("goroutine leak: baseline %d, now %d", baseline, runtime.NumGoroutine())
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
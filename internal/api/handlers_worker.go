@@ -0,0 +1,144 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// maxWorkerConcurrency caps runtime concurrency changes to keep a typo from
+// exhausting DB connections.
+const maxWorkerConcurrency = 1000
+
+// WorkerConfigRequest is the body of PATCH /admin/worker-config. Omitted fields keep
+// their current value.
+type WorkerConfigRequest struct {
+	Concurrency    *int `json:"concurrency,omitempty" example:"5"`
+	TaskTimeoutSec *int `json:"task_timeout_sec,omitempty" example:"60"`
+}
+
+// WorkerConfigResponse represents the worker settings in effect.
+type WorkerConfigResponse struct {
+	Concurrency    int `json:"concurrency" example:"5"`
+	TaskTimeoutSec int `json:"task_timeout_sec" example:"60"`
+}
+
+// WorkerTuner reads and changes the settings of the running worker pool.
+type WorkerTuner interface {
+	WorkerConfig() (concurrency int, taskTimeout time.Duration)
+	// TuneWorker applies the settings once in-flight tasks have drained.
+	TuneWorker(ctx context.Context, concurrency int, taskTimeout time.Duration) error
+}
+
+// HandlePatchWorkerConfig godoc
+// @Summary Change worker concurrency and task timeout at runtime
+// @Description Drains in-flight tasks, restarts the worker pool with the new settings and persists them so they survive restarts. Only available when worker.allow_runtime_tuning is enabled; requires the admin scope.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body WorkerConfigRequest true "Settings to change"
+// @Success 200 {object} WorkerConfigResponse "Settings in effect"
+// @Failure 400 {object} ErrorResponse "Invalid settings"
+// @Failure 401 {object} ErrorResponse "Missing or invalid API key (when auth is enabled)"
+// @Failure 403 {object} ErrorResponse "API key lacks the admin scope"
+// @Failure 429 {object} QuotaExceededResponse "Monthly quota of the API key used up (when quotas are enabled)"
+// @Failure 500 {object} ErrorResponse "Internal error"
+// @Router /admin/worker-config [patch]
+func HandlePatchWorkerConfig(tuner WorkerTuner) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req WorkerConfigRequest
+		dec := json.NewDecoder(r.Body)
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&req); err != nil {
+			writeError(w, r, http.StatusBadRequest, ErrCodeInvalidFormat, "invalid JSON")
+			return
+		}
+		if req.Concurrency == nil && req.TaskTimeoutSec == nil {
+			writeError(w, r, http.StatusBadRequest, ErrCodeInvalidFormat, "concurrency or task_timeout_sec is required")
+			return
+		}
+
+		concurrency, taskTimeout := tuner.WorkerConfig()
+		if req.Concurrency != nil {
+			if *req.Concurrency <= 0 || *req.Concurrency > maxWorkerConcurrency {
+				writeError(w, r, http.StatusBadRequest, ErrCodeInvalidFormat, "concurrency must be between 1 and 1000")
+				return
+			}
+			concurrency = *req.Concurrency
+		}
+		if req.TaskTimeoutSec != nil {
+			if *req.TaskTimeoutSec <= 0 {
+				writeError(w, r, http.StatusBadRequest, ErrCodeInvalidFormat, "task_timeout_sec must be positive")
+				return
+			}
+			taskTimeout = time.Duration(*req.TaskTimeoutSec) * time.Second
+		}
+
+		if err := tuner.TuneWorker(r.Context(), concurrency, taskTimeout); err != nil {
+			writeError(w, r, http.StatusInternalServerError, ErrCodeInternal, "Internal error")
+			return
+		}
+
+		concurrency, taskTimeout = tuner.WorkerConfig()
+		writeJSON(w, r, http.StatusOK, WorkerConfigResponse{
+			Concurrency:    concurrency,
+			TaskTimeoutSec: int(taskTimeout / time.Second),
+		})
+	}
+}
+
+// WorkerStateResponse reports whether the worker pool fetches new tasks.
+type WorkerStateResponse struct {
+	Drained bool `json:"drained" example:"true"`
+}
+
+// WorkerDrainer stops and restarts task fetching on the worker pool.
+type WorkerDrainer interface {
+	// Drain stops fetching new tasks; in-flight tasks keep running.
+	Drain() error
+	// Resume starts fetching tasks again.
+	Resume() error
+}
+
+// HandleDrainWorker godoc
+// @Summary Stop the worker from fetching new tasks
+// @Description Lets in-flight tasks finish but fetches no new ones, and makes /readyz report the instance not ready so the orchestrator stops routing to it. The process keeps running; the state is not persisted and is cleared by a restart. Draining a drained worker does nothing. Requires the admin scope.
+// @Tags admin
+// @Produce json
+// @Success 200 {object} WorkerStateResponse "Worker drained"
+// @Failure 401 {object} ErrorResponse "Missing or invalid API key (when auth is enabled)"
+// @Failure 403 {object} ErrorResponse "API key lacks the admin scope"
+// @Failure 429 {object} QuotaExceededResponse "Monthly quota of the API key used up (when quotas are enabled)"
+// @Failure 500 {object} ErrorResponse "Internal error"
+// @Router /admin/worker/drain [post]
+func HandleDrainWorker(d WorkerDrainer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := d.Drain(); err != nil {
+			writeError(w, r, http.StatusInternalServerError, ErrCodeInternal, "Internal error")
+			return
+		}
+		writeJSON(w, r, http.StatusOK, WorkerStateResponse{Drained: true})
+	}
+}
+
+// HandleResumeWorker godoc
+// @Summary Resume fetching tasks after a drain
+// @Description Waits for tasks still running since the drain, restarts the worker and makes the instance ready again. Resuming a worker that is not drained does nothing. Requires the admin scope.
+// @Tags admin
+// @Produce json
+// @Success 200 {object} WorkerStateResponse "Worker fetching tasks"
+// @Failure 401 {object} ErrorResponse "Missing or invalid API key (when auth is enabled)"
+// @Failure 403 {object} ErrorResponse "API key lacks the admin scope"
+// @Failure 429 {object} QuotaExceededResponse "Monthly quota of the API key used up (when quotas are enabled)"
+// @Failure 500 {object} ErrorResponse "Internal error"
+// @Router /admin/worker/resume [post]
+func HandleResumeWorker(d WorkerDrainer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := d.Resume(); err != nil {
+			writeError(w, r, http.StatusInternalServerError, ErrCodeInternal, "Internal error")
+			return
+		}
+		writeJSON(w, r, http.StatusOK, WorkerStateResponse{Drained: false})
+	}
+}
@@ -0,0 +1,139 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// mockWorkerTuner implements WorkerTuner for testing.
+type mockWorkerTuner struct {
+	concurrency int
+	taskTimeout time.Duration
+	err         error
+	calls       int
+}
+
+func (m *mockWorkerTuner) WorkerConfig() (int, time.Duration) {
+	return m.concurrency, m.taskTimeout
+}
+
+func (m *mockWorkerTuner) TuneWorker(_ context.Context, concurrency int, taskTimeout time.Duration) error {
+	m.calls++
+	if m.err != nil {
+		return m.err
+	}
+	m.concurrency, m.taskTimeout = concurrency, taskTimeout
+	return nil
+}
+
+func TestHandlePatchWorkerConfig(t *testing.T) {
+	patch := func(tuner WorkerTuner, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPatch, "/admin/worker-config", bytes.NewBufferString(body))
+		w := httptest.NewRecorder()
+		HandlePatchWorkerConfig(tuner).ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("applies both fields", func(t *testing.T) {
+		tuner := &mockWorkerTuner{concurrency: 1, taskTimeout: 30 * time.Second}
+		w := patch(tuner, `{"concurrency":5,"task_timeout_sec":60}`)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+		var resp WorkerConfigResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if resp.Concurrency != 5 || resp.TaskTimeoutSec != 60 {
+			t.Errorf("Expected 5/60, got %+v", resp)
+		}
+	})
+
+	t.Run("omitted field keeps current value", func(t *testing.T) {
+		tuner := &mockWorkerTuner{concurrency: 1, taskTimeout: 30 * time.Second}
+		w := patch(tuner, `{"concurrency":3}`)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+		if tuner.concurrency != 3 || tuner.taskTimeout != 30*time.Second {
+			t.Errorf("Expected 3/30s, got %d/%s", tuner.concurrency, tuner.taskTimeout)
+		}
+	})
+
+	for _, tc := range []struct{ name, body string }{
+		{"invalid JSON", `{`},
+		{"unknown field", `{"queues":{"default":1}}`},
+		{"empty body", `{}`},
+		{"zero concurrency", `{"concurrency":0}`},
+		{"too much concurrency", `{"concurrency":100000}`},
+		{"negative timeout", `{"task_timeout_sec":-1}`},
+	} {
+		t.Run(tc.name+" returns 400", func(t *testing.T) {
+			tuner := &mockWorkerTuner{concurrency: 1, taskTimeout: 30 * time.Second}
+			w := patch(tuner, tc.body)
+
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("Expected status 400, got %d", w.Code)
+			}
+			assertErrorCode(t, w, ErrCodeInvalidFormat)
+			if tuner.calls != 0 {
+				t.Error("TuneWorker must not be called for invalid input")
+			}
+		})
+	}
+
+	t.Run("tuner failure returns 500", func(t *testing.T) {
+		tuner := &mockWorkerTuner{concurrency: 1, taskTimeout: 30 * time.Second, err: errors.New("redis down")}
+		w := patch(tuner, `{"concurrency":2}`)
+
+		if w.Code != http.StatusInternalServerError {
+			t.Fatalf("Expected status 500, got %d", w.Code)
+		}
+		assertErrorCode(t, w, ErrCodeInternal)
+	})
+}
+
+type mockWorkerDrainer struct{ err error }
+
+func (m mockWorkerDrainer) Drain() error  { return m.err }
+func (m mockWorkerDrainer) Resume() error { return m.err }
+
+func TestHandleDrainWorker(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		handler func(WorkerDrainer) http.HandlerFunc
+		drained bool
+	}{
+		{"drain", HandleDrainWorker, true},
+		{"resume", HandleResumeWorker, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			tc.handler(mockWorkerDrainer{})(w, httptest.NewRequest(http.MethodPost, "/admin/worker/"+tc.name, nil))
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status 200, got %d", w.Code)
+			}
+			var resp WorkerStateResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if resp.Drained != tc.drained {
+				t.Errorf("Expected drained=%v, got %v", tc.drained, resp.Drained)
+			}
+
+			w = httptest.NewRecorder()
+			tc.handler(mockWorkerDrainer{err: errors.New("worker pool is stopped")})(w, httptest.NewRequest(http.MethodPost, "/admin/worker/"+tc.name, nil))
+			if w.Code != http.StatusInternalServerError {
+				t.Errorf("Expected status 500 on error, got %d", w.Code)
+			}
+		})
+	}
+}
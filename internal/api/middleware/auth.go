@@ -0,0 +1,123 @@
+package middleware
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+
+	"quoteservice/internal/service"
+)
+
+// Scopes granted to API keys. ScopeAdmin implies every other scope.
+const (
+	ScopeRead  = "read"
+	ScopeWrite = "write"
+	ScopeAdmin = "admin"
+)
+
+const scopeKey contextKey = "scope"
+const keyNameKey contextKey = "api_key_name"
+const headerAPIKey = "X-API-Key"
+
+// Error codes sent by the middlewares, numbered like the api package's error codes.
+const (
+	ErrCodeUnauthorized = 4011
+	ErrCodeForbidden    = 4031
+)
+
+// ErrorResponse is the body of a rejected request; it has the shape of api.ErrorResponse.
+type ErrorResponse struct {
+	Error string `json:"error"`
+	Code  int    `json:"code"`
+}
+
+// APIKey describes a configured API key, the scopes it grants and the pairs it may
+// access. A nil Access allows every pair.
+type APIKey struct {
+	Key    string
+	Name   string
+	Scopes []string
+	Access *service.PairAccess
+}
+
+// APIKeyMiddleware authenticates requests by the X-API-Key header and stores
+// the key's scopes in the request context for ScopeMiddleware, its name for
+// QuotaMiddleware, and its pair access for the service.
+func APIKeyMiddleware(keys []APIKey) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			provided := r.Header.Get(headerAPIKey)
+			if provided == "" {
+				writeError(w, http.StatusUnauthorized, ErrCodeUnauthorized, "missing API key")
+				return
+			}
+			key, ok := lookupKey(keys, provided)
+			if !ok {
+				writeError(w, http.StatusUnauthorized, ErrCodeUnauthorized, "invalid API key")
+				return
+			}
+			ctx := WithScopes(r.Context(), key.Scopes)
+			ctx = context.WithValue(ctx, keyNameKey, key.Name)
+			ctx = service.WithPairAccess(ctx, key.Access)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// ScopeMiddleware rejects requests whose context scopes do not include
+// requiredScope with 403 Forbidden.
+func ScopeMiddleware(requiredScope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !HasScope(ScopesFromContext(r.Context()), requiredScope) {
+				writeError(w, http.StatusForbidden, ErrCodeForbidden, "insufficient scope")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// WithScopes returns a copy of ctx carrying the given scopes.
+func WithScopes(ctx context.Context, scopes []string) context.Context {
+	return context.WithValue(ctx, scopeKey, scopes)
+}
+
+// ScopesFromContext returns the scopes stored by APIKeyMiddleware, or nil.
+func ScopesFromContext(ctx context.Context) []string {
+	scopes, _ := ctx.Value(scopeKey).([]string)
+	return scopes
+}
+
+// KeyNameFromContext returns the name of the API key APIKeyMiddleware authenticated,
+// or "".
+func KeyNameFromContext(ctx context.Context) string {
+	name, _ := ctx.Value(keyNameKey).(string)
+	return name
+}
+
+// HasScope reports whether scopes grant required. ScopeAdmin grants everything.
+func HasScope(scopes []string, required string) bool {
+	for _, s := range scopes {
+		if s == required || s == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+func lookupKey(keys []APIKey, provided string) (APIKey, bool) {
+	for _, k := range keys {
+		if subtle.ConstantTimeCompare([]byte(k.Key), []byte(provided)) == 1 {
+			return k, true
+		}
+	}
+	return APIKey{}, false
+}
+
+func writeError(w http.ResponseWriter, status, code int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(ErrorResponse{Error: msg, Code: code})
+}
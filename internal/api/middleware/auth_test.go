@@ -0,0 +1,122 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newScopedMux(keys []APIKey) http.Handler {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /quotes/latest", ScopeMiddleware(ScopeRead)(ok))
+	mux.Handle("POST /quotes/update", ScopeMiddleware(ScopeWrite)(ok))
+	mux.Handle("GET /admin/stats", ScopeMiddleware(ScopeAdmin)(ok))
+	return APIKeyMiddleware(keys)(mux)
+}
+
+func TestScopeMiddleware_Combinations(t *testing.T) {
+	keys := []APIKey{
+		{Key: "k-read", Name: "reader", Scopes: []string{ScopeRead}},
+		{Key: "k-write", Name: "writer", Scopes: []string{ScopeWrite}},
+		{Key: "k-rw", Name: "read-write", Scopes: []string{ScopeRead, ScopeWrite}},
+		{Key: "k-admin", Name: "admin", Scopes: []string{ScopeAdmin}},
+		{Key: "k-none", Name: "no-scopes"},
+	}
+	handler := newScopedMux(keys)
+
+	routes := []struct {
+		method string
+		path   string
+	}{
+		{http.MethodGet, "/quotes/latest"},
+		{http.MethodPost, "/quotes/update"},
+		{http.MethodGet, "/admin/stats"},
+	}
+
+	// Expected status per key for each route, in the order of routes above.
+	expected := map[string][3]int{
+		"k-read":  {http.StatusOK, http.StatusForbidden, http.StatusForbidden},
+		"k-write": {http.StatusForbidden, http.StatusOK, http.StatusForbidden},
+		"k-rw":    {http.StatusOK, http.StatusOK, http.StatusForbidden},
+		"k-admin": {http.StatusOK, http.StatusOK, http.StatusOK},
+		"k-none":  {http.StatusForbidden, http.StatusForbidden, http.StatusForbidden},
+	}
+
+	for key, statuses := range expected {
+		for i, route := range routes {
+			t.Run(key+" "+route.method+" "+route.path, func(t *testing.T) {
+				req := httptest.NewRequest(route.method, route.path, nil)
+				req.Header.Set(headerAPIKey, key)
+				w := httptest.NewRecorder()
+				handler.ServeHTTP(w, req)
+
+				if w.Code != statuses[i] {
+					t.Fatalf("Expected status %d, got %d", statuses[i], w.Code)
+				}
+				if w.Code == http.StatusForbidden {
+					var body ErrorResponse
+					if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+						t.Fatalf("Failed to decode response: %v", err)
+					}
+					if body.Error != "insufficient scope" || body.Code != ErrCodeForbidden {
+						t.Errorf("Expected 'insufficient scope' with code %d, got %+v", ErrCodeForbidden, body)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestAPIKeyMiddleware_Unauthorized(t *testing.T) {
+	handler := newScopedMux([]APIKey{{Key: "secret", Scopes: []string{ScopeAdmin}}})
+
+	tests := []struct {
+		name    string
+		key     string
+		wantErr string
+	}{
+		{"missing key", "", "missing API key"},
+		{"unknown key", "wrong", "invalid API key"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/quotes/latest", nil)
+			if tc.key != "" {
+				req.Header.Set(headerAPIKey, tc.key)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != http.StatusUnauthorized {
+				t.Fatalf("Expected status 401, got %d", w.Code)
+			}
+			var body ErrorResponse
+			if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if body.Error != tc.wantErr || body.Code != ErrCodeUnauthorized {
+				t.Errorf("Expected error %q with code %d, got %+v", tc.wantErr, ErrCodeUnauthorized, body)
+			}
+		})
+	}
+}
+
+func TestScopeMiddleware_NoScopeInContext(t *testing.T) {
+	handler := ScopeMiddleware(ScopeRead)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Handler should not be called without scopes")
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/quotes/latest", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403, got %d", w.Code)
+	}
+}
@@ -1,4 +1,4 @@
-// Package middleware provides HTTP middleware for request ID tracking and logging.
+// Package middleware provides HTTP middleware for request ID tracking, logging, and API key authorization.
 package middleware
 
 import (
@@ -13,6 +13,8 @@ import (
 type contextKey string
 
 const requestIDKey contextKey = "request_id"
+const loggerKey contextKey = "logger"
+const accessLogKey contextKey = "access_log"
 const headerRequestID = "X-Request-Id"
 
 // RequestIDMiddleware ensures each request has a correlation ID
@@ -28,29 +30,55 @@ func RequestIDMiddleware(next http.Handler) http.Handler {
 	})
 }
 
-// RequestLoggingMiddleware logs each HTTP request and response details
+// RequestLoggingMiddleware logs each HTTP request and response details, and stores
+// logger, tagged with the request ID, for LoggerFromContext.
 func RequestLoggingMiddleware(logger *zap.SugaredLogger) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			start := time.Now()
+			reqID, _ := r.Context().Value(requestIDKey).(string)
+			extra := &accessLogFields{}
+			ctx := context.WithValue(r.Context(), loggerKey, logger.With("request_id", reqID))
+			ctx = context.WithValue(ctx, accessLogKey, extra)
 			ww := &responseWriter{ResponseWriter: w, status: 0, size: 0}
-			next.ServeHTTP(ww, r)
+			next.ServeHTTP(ww, r.WithContext(ctx))
 			duration := time.Since(start)
-			reqID, _ := r.Context().Value(requestIDKey).(string)
 			if ww.status == 0 {
 				ww.status = 200
 			}
-			logger.Infow("HTTP request",
+			logger.Infow("HTTP request", append([]any{
 				"request_id", reqID,
 				"method", r.Method,
 				"path", r.RequestURI,
 				"status", ww.status,
 				"duration_ms", duration.Milliseconds(),
-			)
+			}, extra.keysAndValues...)...)
 		})
 	}
 }
 
+// LoggerFromContext returns the request-scoped logger stored by
+// RequestLoggingMiddleware, or a no-op logger outside it.
+func LoggerFromContext(ctx context.Context) *zap.SugaredLogger {
+	if logger, ok := ctx.Value(loggerKey).(*zap.SugaredLogger); ok {
+		return logger
+	}
+	return zap.NewNop().Sugar()
+}
+
+// accessLogFields collects the fields handlers add to their request's access log line.
+type accessLogFields struct {
+	keysAndValues []any
+}
+
+// AddAccessLogField adds key and value to the "HTTP request" line RequestLoggingMiddleware
+// logs once the handler returns. Outside the middleware it does nothing.
+func AddAccessLogField(ctx context.Context, key string, value any) {
+	if extra, ok := ctx.Value(accessLogKey).(*accessLogFields); ok {
+		extra.keysAndValues = append(extra.keysAndValues, key, value)
+	}
+}
+
 // responseWriter is a wrapper to capture HTTP status and size
 type responseWriter struct {
 	http.ResponseWriter
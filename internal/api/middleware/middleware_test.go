@@ -1,12 +1,14 @@
 package middleware
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
 	"github.com/google/uuid"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
 )
 
 func TestRequestIDMiddleware(t *testing.T) {
@@ -75,6 +77,23 @@ func TestRequestLoggingMiddleware(t *testing.T) {
 	}
 }
 
+func TestAddAccessLogField(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	handler := RequestLoggingMiddleware(zap.New(core).Sugar())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		AddAccessLogField(r.Context(), "quote_source", "redis")
+		w.WriteHeader(http.StatusOK)
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/quotes/latest", nil))
+
+	entries := logs.FilterMessage("HTTP request").All()
+	if len(entries) != 1 || entries[0].ContextMap()["quote_source"] != "redis" || entries[0].ContextMap()["status"] != int64(200) {
+		t.Errorf("Expected the access log line to carry quote_source, got %v", logs.All())
+	}
+
+	// Outside the middleware the field is dropped.
+	AddAccessLogField(context.Background(), "quote_source", "db")
+}
+
 func TestResponseWriter(t *testing.T) {
 	w := httptest.NewRecorder()
 	rw := &responseWriter{ResponseWriter: w, status: 0, size: 0}
@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+
+	"quoteservice/internal/quota"
+	"quoteservice/internal/service"
+)
+
+// Quota headers sent with every request of an API key that has a monthly limit.
+const (
+	HeaderQuotaLimit     = "X-Quota-Limit"
+	HeaderQuotaRemaining = "X-Quota-Remaining"
+	HeaderQuotaReset     = "X-Quota-Reset" // Unix time the window resets at.
+)
+
+// ErrCodeQuotaExceeded is sent with 429 when an API key used up its monthly quota.
+const ErrCodeQuotaExceeded = 4291
+
+// QuotaExceededResponse is the 429 body sent by QuotaMiddleware; it has the shape of
+// api.QuotaExceededResponse.
+type QuotaExceededResponse struct {
+	Error   string `json:"error"`
+	Code    int    `json:"code"`
+	Limit   int64  `json:"limit"`
+	ResetAt string `json:"reset_at"`
+}
+
+// QuotaConsumer counts a request against an API key's quota; implemented by
+// *quota.Tracker.
+type QuotaConsumer interface {
+	Consume(ctx context.Context, key string) (quota.Usage, error)
+}
+
+// QuotaMiddleware counts each request against the quota of the API key that
+// APIKeyMiddleware authenticated, sets the quota headers and rejects requests over the
+// limit with 429. If the count fails, e.g. because Redis is down, the request is let
+// through without headers.
+func QuotaMiddleware(q QuotaConsumer, logger *zap.SugaredLogger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := KeyNameFromContext(r.Context())
+			usage, err := q.Consume(r.Context(), key)
+			if err != nil {
+				logger.Warnw("Quota check failed, allowing request", "api_key", key, "error", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+			if usage.Limit == 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h := w.Header()
+			h.Set(HeaderQuotaLimit, strconv.FormatInt(usage.Limit, 10))
+			h.Set(HeaderQuotaRemaining, strconv.FormatInt(usage.Remaining(), 10))
+			h.Set(HeaderQuotaReset, strconv.FormatInt(usage.Reset.Unix(), 10))
+			if usage.Exceeded() {
+				h.Set("Retry-After", strconv.Itoa(max(int(time.Until(usage.Reset).Seconds()), 1)))
+				h.Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusTooManyRequests)
+				_ = json.NewEncoder(w).Encode(QuotaExceededResponse{
+					Error:   "monthly quota exceeded",
+					Code:    ErrCodeQuotaExceeded,
+					Limit:   usage.Limit,
+					ResetAt: service.FormatTimestamp(usage.Reset),
+				})
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
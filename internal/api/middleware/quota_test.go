@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+
+	"quoteservice/internal/quota"
+)
+
+// fakeQuota counts requests per key against limits, or fails with err.
+type fakeQuota struct {
+	limits map[string]int64
+	used   map[string]int64
+	reset  time.Time
+	err    error
+}
+
+func (f *fakeQuota) Consume(_ context.Context, key string) (quota.Usage, error) {
+	if f.err != nil {
+		return quota.Usage{}, f.err
+	}
+	f.used[key]++
+	return quota.Usage{Key: key, Limit: f.limits[key], Used: f.used[key], Reset: f.reset}, nil
+}
+
+func TestQuotaMiddleware(t *testing.T) {
+	reset := time.Now().Add(time.Hour).Truncate(time.Second)
+	q := &fakeQuota{limits: map[string]int64{"desk": 2}, used: map[string]int64{}, reset: reset}
+	core, logs := observer.New(zap.WarnLevel)
+	ok := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusOK) })
+	handler := APIKeyMiddleware([]APIKey{
+		{Key: "desk-secret", Name: "desk", Scopes: []string{ScopeRead}},
+		{Key: "ops-secret", Name: "ops", Scopes: []string{ScopeAdmin}},
+	})(QuotaMiddleware(q, zap.New(core).Sugar())(ok))
+	get := func(key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/quotes/latest", nil)
+		req.Header.Set(headerAPIKey, key)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	for _, remaining := range []string{"1", "0"} {
+		w := get("desk-secret")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+		h := w.Header()
+		if h.Get(HeaderQuotaLimit) != "2" || h.Get(HeaderQuotaRemaining) != remaining ||
+			h.Get(HeaderQuotaReset) != strconv.FormatInt(reset.Unix(), 10) {
+			t.Errorf("Unexpected quota headers %v", h)
+		}
+	}
+
+	w := get("desk-secret")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected status 429, got %d", w.Code)
+	}
+	if w.Header().Get(HeaderQuotaRemaining) != "0" || w.Header().Get("Retry-After") == "" {
+		t.Errorf("Expected quota headers and Retry-After on 429, got %v", w.Header())
+	}
+	var body QuotaExceededResponse
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if body.Code != ErrCodeQuotaExceeded || body.Limit != 2 || body.ResetAt != reset.UTC().Format(time.RFC3339) {
+		t.Errorf("Unexpected body %+v", body)
+	}
+
+	// Keys without a limit are counted but get no headers.
+	w = get("ops-secret")
+	if w.Code != http.StatusOK || w.Header().Get(HeaderQuotaLimit) != "" {
+		t.Errorf("Expected an unlimited key let through without quota headers, got %d %v", w.Code, w.Header())
+	}
+	if q.used["ops"] != 1 {
+		t.Errorf("Expected the unlimited key counted, got %d", q.used["ops"])
+	}
+
+	q.err = errors.New("redis down")
+	w = get("desk-secret")
+	if w.Code != http.StatusOK || w.Header().Get(HeaderQuotaLimit) != "" {
+		t.Errorf("Expected the request let through without headers when Redis fails, got %d %v", w.Code, w.Header())
+	}
+	if logs.FilterMessage("Quota check failed, allowing request").Len() != 1 {
+		t.Error("Expected a warning when the quota check fails")
+	}
+}
@@ -2,29 +2,77 @@ package api
 
 import (
 	"context"
+	"time"
 
 	"quoteservice/internal/service"
 )
 
+var _ service.QuoteServiceInterface = (*mockQuoteService)(nil)
+
 // mockQuoteService implements service.QuoteServiceInterface for testing.
 type mockQuoteService struct {
-	requestUpdateFunc  func(ctx context.Context, pair string) (string, string, error)
-	getQuoteResultFunc func(ctx context.Context, updateID string) (*service.QuoteResult, error)
-	getLatestQuoteFunc func(ctx context.Context, base, quote string) (*service.QuoteResult, error)
+	requestUpdateFunc     func(ctx context.Context, pair string, opts service.UpdateOptions) (*service.UpdateRequestResult, error)
+	fetchQuoteFunc        func(ctx context.Context, pair string, wait time.Duration) (*service.QuoteResult, error)
+	getQuoteResultFunc    func(ctx context.Context, updateID string) (*service.QuoteResult, error)
+	getStatusEventsFunc   func(ctx context.Context, updateID string) ([]service.QuoteStatusEvent, error)
+	getLatestQuoteFunc    func(ctx context.Context, pair service.Pair) (*service.QuoteResult, error)
+	getLastAttemptFunc    func(ctx context.Context, pair service.Pair) (*service.QuoteAttempt, error)
+	getLatestQuotesFunc   func(ctx context.Context, pairs []service.Pair) ([]service.LatestQuote, error)
+	getHistoricalFunc     func(ctx context.Context, pair service.Pair, at time.Time) (*service.QuoteResult, error)
+	compareQuotesFunc     func(ctx context.Context, pair service.Pair, at, vs time.Time) (*service.QuoteComparison, error)
+	subscribePairFunc     func(ctx context.Context, pair service.Pair) (<-chan service.QuoteEvent, error)
+	registerWebhookFunc   func(ctx context.Context, pair, rawURL, secret string) (*service.Webhook, error)
+	deregisterWebhookFunc func(ctx context.Context, webhookID string) error
+}
+
+func (m *mockQuoteService) RequestQuoteUpdate(ctx context.Context, pair string, opts service.UpdateOptions) (*service.UpdateRequestResult, error) {
+	return m.requestUpdateFunc(ctx, pair, opts)
 }
 
-func (m *mockQuoteService) RequestQuoteUpdate(ctx context.Context, pair string) (string, string, error) {
-	return m.requestUpdateFunc(ctx, pair)
+func (m *mockQuoteService) FetchQuote(ctx context.Context, pair string, wait time.Duration) (*service.QuoteResult, error) {
+	return m.fetchQuoteFunc(ctx, pair, wait)
 }
 
 func (m *mockQuoteService) GetQuoteResult(ctx context.Context, updateID string) (*service.QuoteResult, error) {
 	return m.getQuoteResultFunc(ctx, updateID)
 }
 
-func (m *mockQuoteService) GetLatestQuote(ctx context.Context, base, quote string) (*service.QuoteResult, error) {
-	return m.getLatestQuoteFunc(ctx, base, quote)
+func (m *mockQuoteService) GetStatusEvents(ctx context.Context, updateID string) ([]service.QuoteStatusEvent, error) {
+	return m.getStatusEventsFunc(ctx, updateID)
+}
+
+func (m *mockQuoteService) GetLatestQuote(ctx context.Context, pair service.Pair) (*service.QuoteResult, error) {
+	return m.getLatestQuoteFunc(ctx, pair)
+}
+
+func (m *mockQuoteService) GetLatestQuotes(ctx context.Context, pairs []service.Pair) ([]service.LatestQuote, error) {
+	return m.getLatestQuotesFunc(ctx, pairs)
+}
+
+func (m *mockQuoteService) GetLastAttempt(ctx context.Context, pair service.Pair) (*service.QuoteAttempt, error) {
+	return m.getLastAttemptFunc(ctx, pair)
+}
+
+func (m *mockQuoteService) GetHistoricalRate(ctx context.Context, pair service.Pair, at time.Time) (*service.QuoteResult, error) {
+	return m.getHistoricalFunc(ctx, pair, at)
+}
+
+func (m *mockQuoteService) CompareQuotes(ctx context.Context, pair service.Pair, at, vs time.Time) (*service.QuoteComparison, error) {
+	return m.compareQuotesFunc(ctx, pair, at, vs)
+}
+
+func (m *mockQuoteService) SubscribePair(ctx context.Context, pair service.Pair) (<-chan service.QuoteEvent, error) {
+	return m.subscribePairFunc(ctx, pair)
+}
+
+func (m *mockQuoteService) RegisterWebhook(ctx context.Context, pair, rawURL, secret string) (*service.Webhook, error) {
+	return m.registerWebhookFunc(ctx, pair, rawURL, secret)
+}
+
+func (m *mockQuoteService) DeregisterWebhook(ctx context.Context, webhookID string) error {
+	return m.deregisterWebhookFunc(ctx, webhookID)
 }
 
-func (m *mockQuoteService) ProcessUpdate(_ context.Context, _, _, _ string) error {
+func (m *mockQuoteService) ProcessUpdate(context.Context, service.UpdateQuotePayload) error {
 	return nil // Not used in handler tests
 }
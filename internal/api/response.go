@@ -2,20 +2,220 @@
 package api
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"quoteservice/internal/api/middleware"
+	"quoteservice/internal/service"
+)
+
+// Machine-readable error codes sent in ErrorResponse.Code. The first three digits are
+// the HTTP status the code is returned with.
+const (
+	ErrCodeInvalidFormat       = 4001
+	ErrCodeUnsupportedCurrency = 4002
+	ErrCodeUnknownProvider     = 4003
+	ErrCodeUnauthorized        = 4011
+	ErrCodeForbidden           = 4031
+	ErrCodePairForbidden       = 4032
+	ErrCodeNotFound            = 4041
+	ErrCodeConflict            = 4091
+	ErrCodeQuotaExceeded       = 4291
+	ErrCodeInternal            = 5001
+	ErrCodeQueueUnavailable    = 5031
+	ErrCodeProviderUnavailable = 5032
+	ErrCodeQueueFull           = 5033
+	ErrCodeSchemaNotReady      = 5034
 )
 
 // ErrorResponse represents an error response
 type ErrorResponse struct {
 	Error string `json:"error" example:"Invalid currency code format"`
+	Code  int    `json:"code" example:"4001"`
+}
+
+// QuotaExceededResponse is the 429 body sent when the API key has used up its monthly
+// quota; reset_at is when the next window starts
+type QuotaExceededResponse struct {
+	Error   string `json:"error" example:"monthly quota exceeded"`
+	Code    int    `json:"code" example:"4291"`
+	Limit   int64  `json:"limit" example:"100000"`
+	ResetAt string `json:"reset_at" example:"2025-12-01T00:00:00Z"`
+}
+
+// ProviderUnavailableResponse is the 503 body sent when no exchange rate provider can
+// serve the request
+type ProviderUnavailableResponse struct {
+	Error             string `json:"error" example:"provider unavailable"`
+	Code              int    `json:"code" example:"5032"`
+	RetryAfterSeconds int    `json:"retry_after_seconds" example:"30"`
+}
+
+// UnknownProviderResponse is the 400 body sent when an update names a provider that is
+// not configured. Other 400s of POST /quotes/update are plain ErrorResponses, which
+// decode into it without valid_providers.
+type UnknownProviderResponse struct {
+	Error          string   `json:"error" example:"unknown provider \"ecb\""`
+	Code           int      `json:"code" example:"4003"`
+	ValidProviders []string `json:"valid_providers,omitempty" example:"exchangerate_host,frankfurter"`
 }
 
-// writeJSON writes a JSON response with the given status code.
-func writeJSON(w http.ResponseWriter, status int, data any) {
-	w.Header().Set("Content-Type", "application/json")
+// UpdateInFlightResponse is the 409 body sent when a FAILED update cannot be retried
+// because another update of its pair is PENDING or RUNNING; update_id names that update
+// and is omitted if it finished meanwhile. Other 409s of the retry endpoint are plain
+// ErrorResponses, which decode into it without update_id
+type UpdateInFlightResponse struct {
+	Error    string `json:"error" example:"another update of the pair is in flight"`
+	Code     int    `json:"code" example:"4091"`
+	UpdateID string `json:"update_id,omitempty" example:"123e4567-e89b-12d3-a456-426614174000"`
+}
+
+// writeError writes an ErrorResponse with the given status, code and message.
+func writeError(w http.ResponseWriter, r *http.Request, status, code int, msg string) {
+	writeJSON(w, r, status, ErrorResponse{Error: msg, Code: code})
+}
+
+// errorToCode maps a service error to its ErrorResponse code.
+func errorToCode(err error) int {
+	switch {
+	case errors.Is(err, service.ErrUnsupportedCurrency):
+		return ErrCodeUnsupportedCurrency
+	case errors.Is(err, service.ErrUnknownProvider):
+		return ErrCodeUnknownProvider
+	case service.IsValidationError(err):
+		return ErrCodeInvalidFormat
+	case errors.Is(err, service.ErrPairForbidden):
+		return ErrCodePairForbidden
+	case errors.Is(err, service.ErrNotFound):
+		return ErrCodeNotFound
+	case errors.Is(err, service.ErrWebhookExists):
+		return ErrCodeConflict
+	case errors.Is(err, service.ErrInternalQueue):
+		return ErrCodeQueueUnavailable
+	case errors.Is(err, service.ErrQueueFull):
+		return ErrCodeQueueFull
+	case errors.Is(err, service.ErrProviderUnavailable):
+		return ErrCodeProviderUnavailable
+	case errors.Is(err, service.ErrSchemaNotReady):
+		return ErrCodeSchemaNotReady
+	default:
+		return ErrCodeInternal
+	}
+}
+
+// encodeFailedBody is the 500 body writeJSON sends when data cannot be encoded. It is a
+// constant so that answering does not depend on the encoder that just failed.
+const encodeFailedBody = `{"error":"Internal error","code":5001}` + "\n"
+
+// writeJSON writes data as a JSON response with the given status code. data is
+// encoded in full before anything is written, so a value that fails to encode is
+// logged with the request's logger and answered with a 500 instead of a truncated
+// body behind a committed status. The response carries a Content-Length; a HEAD
+// request gets the same status and headers without the body.
+func writeJSON(w http.ResponseWriter, r *http.Request, status int, data any) {
+	log := middleware.LoggerFromContext(r.Context())
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(data); err != nil {
+		log.Errorw("Failed to encode JSON response", "status", status, "type", fmt.Sprintf("%T", data), "error", err)
+		buf.Reset()
+		buf.WriteString(encodeFailedBody)
+		status = http.StatusInternalServerError
+	}
+
+	h := w.Header()
+	h.Set("Content-Type", "application/json")
+	h.Set("Content-Length", strconv.Itoa(buf.Len()))
 	w.WriteHeader(status)
-	_ = json.NewEncoder(w).Encode(data)
+	if r.Method == http.MethodHead {
+		return
+	}
+	if _, err := buf.WriteTo(w); err != nil {
+		// Usually the client went away; the status is already sent.
+		log.Debugw("Failed to write JSON response", "status", status, "error", err)
+	}
+}
+
+// writePaginatedJSON writes data like writeJSON and, when nextCursor is non-empty,
+// adds an RFC 8288 Link header with rel="next" (the request URL with cursor set to
+// nextCursor) and rel="first" (the request URL without cursor). The final page,
+// where nextCursor is empty, gets no Link header.
+func writePaginatedJSON(w http.ResponseWriter, r *http.Request, status int, data any, nextCursor string) {
+	if nextCursor != "" {
+		w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="next", <%s>; rel="first"`,
+			withCursor(r.URL, nextCursor), withCursor(r.URL, "")))
+	}
+	writeJSON(w, r, status, data)
+}
+
+// withCursor returns a copy of u with the cursor query parameter set to cursor, or
+// removed when cursor is empty.
+func withCursor(u *url.URL, cursor string) string {
+	next := *u
+	q := next.Query()
+	if cursor == "" {
+		q.Del("cursor")
+	} else {
+		q.Set("cursor", cursor)
+	}
+	next.RawQuery = q.Encode()
+	return next.String()
+}
+
+// negotiate returns the media type in offers that the request's Accept header prefers:
+// the one with the highest q-value, taken from its most specific matching range, with
+// ties going to the earlier offer. Without an Accept header, or when the header
+// accepts none of offers, it returns offers[0]; the endpoints offering alternatives
+// would rather answer in their default format than with 406.
+func negotiate(r *http.Request, offers ...string) string {
+	best, bestQ := offers[0], 0.0
+	accept := r.Header.Values("Accept")
+	for _, offer := range offers {
+		q, specificity := 0.0, -1
+		for _, header := range accept {
+			for _, part := range strings.Split(header, ",") {
+				mediaRange, params, err := mime.ParseMediaType(part)
+				if err != nil {
+					continue
+				}
+				s := rangeSpecificity(mediaRange, offer)
+				if s <= specificity {
+					continue
+				}
+				specificity, q = s, 1
+				if v, ok := params["q"]; ok {
+					if q, err = strconv.ParseFloat(v, 64); err != nil {
+						q = 0
+					}
+				}
+			}
+		}
+		if q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+	return best
+}
+
+// rangeSpecificity returns how specifically mediaRange matches mediaType: 2 for the
+// type itself, 1 for type/*, 0 for */* and -1 for no match.
+func rangeSpecificity(mediaRange, mediaType string) int {
+	switch typ, _, _ := strings.Cut(mediaType, "/"); mediaRange {
+	case mediaType:
+		return 2
+	case typ + "/*":
+		return 1
+	case "*/*":
+		return 0
+	default:
+		return -1
+	}
 }
 
 // derefStr returns the string value of a pointer, or an empty string if nil.
@@ -0,0 +1,137 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+
+	"quoteservice/internal/api/middleware"
+)
+
+func TestWriteJSON(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, r, http.StatusCreated, ErrorResponse{Error: "created", Code: 1})
+	})
+	for _, method := range []string{http.MethodGet, http.MethodHead} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(method, "/", nil))
+
+		want := `{"error":"created","code":1}` + "\n"
+		if method == http.MethodHead {
+			want = ""
+		}
+		if rec.Code != http.StatusCreated || rec.Body.String() != want {
+			t.Errorf("%s: expected 201 with body %q, got %d %q", method, want, rec.Code, rec.Body.String())
+		}
+		if got := rec.Header().Get("Content-Length"); got != "29" {
+			t.Errorf("%s: expected Content-Length 29, got %q", method, got)
+		}
+	}
+}
+
+func TestWriteJSON_EncodeError(t *testing.T) {
+	core, logs := observer.New(zap.ErrorLevel)
+	handler := middleware.RequestIDMiddleware(middleware.RequestLoggingMiddleware(zap.New(core).Sugar())(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, r, http.StatusOK, struct {
+				Updates chan string `json:"updates"`
+			}{Updates: make(chan string)})
+		})))
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Request-Id", "req-1")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("Expected status 500, got %d", rec.Code)
+	}
+	if got := rec.Body.String(); got != encodeFailedBody {
+		t.Errorf("Expected the static error body, got %q", got)
+	}
+	if got := rec.Header().Get("Content-Length"); got != strconv.Itoa(len(encodeFailedBody)) {
+		t.Errorf("Expected Content-Length %d, got %q", len(encodeFailedBody), got)
+	}
+	entries := logs.All()
+	if len(entries) != 1 || entries[0].ContextMap()["request_id"] != "req-1" || entries[0].ContextMap()["status"] != int64(http.StatusOK) {
+		t.Errorf("Expected one error logged with the request ID, got %v", entries)
+	}
+}
+
+func TestWritePaginatedJSON_LinkHeader(t *testing.T) {
+	tests := []struct {
+		name       string
+		target     string
+		nextCursor string
+		wantLink   string
+	}{
+		{
+			name:       "first page",
+			target:     "/quotes?pair=EUR%2FUSD&limit=2",
+			nextCursor: "abc",
+			wantLink:   `</quotes?cursor=abc&limit=2&pair=EUR%2FUSD>; rel="next", </quotes?limit=2&pair=EUR%2FUSD>; rel="first"`,
+		},
+		{
+			name:       "middle page replaces cursor",
+			target:     "/quotes?cursor=abc&limit=2",
+			nextCursor: "def",
+			wantLink:   `</quotes?cursor=def&limit=2>; rel="next", </quotes?limit=2>; rel="first"`,
+		},
+		{
+			name:     "final page",
+			target:   "/quotes?cursor=def&limit=2",
+			wantLink: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				writePaginatedJSON(w, r, http.StatusOK, map[string]string{"next_cursor": tt.nextCursor}, tt.nextCursor)
+			})
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+			}
+			if got := rec.Header().Get("Content-Type"); got != "application/json" {
+				t.Errorf("Content-Type = %q, want application/json", got)
+			}
+			if got := rec.Header().Get("Link"); got != tt.wantLink {
+				t.Errorf("Link = %q, want %q", got, tt.wantLink)
+			}
+		})
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   string
+	}{
+		{"", "application/json"},
+		{"text/csv", "text/csv"},
+		{"Text/CSV; charset=utf-8", "text/csv"},
+		{"application/json, text/csv", "application/json"},
+		{"text/csv, application/json;q=0.9", "text/csv"},
+		{"application/json;q=0.5, text/*", "text/csv"},
+		{"*/*", "application/json"},
+		{"text/*;q=0.8, */*;q=0.1", "text/csv"},
+		{"text/*, text/csv;q=0", "application/json"},
+		{"text/html", "application/json"},
+		{"invalid;;, text/csv", "text/csv"},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		if tt.accept != "" {
+			r.Header.Set("Accept", tt.accept)
+		}
+		if got := negotiate(r, "application/json", "text/csv"); got != tt.want {
+			t.Errorf("Accept %q: expected %s, got %s", tt.accept, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+// Headers carrying the signature of a quote response.
+const (
+	HeaderQuoteSignature      = "X-Quote-Signature"
+	HeaderQuoteSignatureKeyID = "X-Quote-Signature-Key-Id"
+)
+
+// QuoteSigner signs quote responses so downstream systems can check that a rate came
+// from this service unchanged. The signature is the hex HMAC-SHA256 of
+// CanonicalQuote; pkg/client verifies it and must stay in sync.
+type QuoteSigner struct {
+	keyID string
+	key   []byte
+}
+
+// NewQuoteSigner returns a signer using key, announced to clients as keyID.
+func NewQuoteSigner(keyID string, key []byte) *QuoteSigner {
+	return &QuoteSigner{keyID: keyID, key: key}
+}
+
+// CanonicalQuote returns the string a quote signature covers: the response's base,
+// quote, price and updated_at exactly as sent, joined by "|". Fields a response omits
+// are empty. Changing it breaks every client's verification.
+func CanonicalQuote(base, quote, price, updatedAt string) string {
+	return strings.Join([]string{base, quote, price, updatedAt}, "|")
+}
+
+// Sign returns the hex HMAC-SHA256 of the canonical string of the fields.
+func (s *QuoteSigner) Sign(base, quote, price, updatedAt string) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(CanonicalQuote(base, quote, price, updatedAt)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// setHeaders signs the fields into h. A nil signer leaves responses unsigned.
+func (s *QuoteSigner) setHeaders(h http.Header, base, quote, price, updatedAt string) {
+	if s == nil {
+		return
+	}
+	h.Set(HeaderQuoteSignature, s.Sign(base, quote, price, updatedAt))
+	h.Set(HeaderQuoteSignatureKeyID, s.keyID)
+}
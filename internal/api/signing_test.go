@@ -0,0 +1,83 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"quoteservice/internal/service"
+)
+
+// TestQuoteSigner_Canonical pins the canonical string and signatures. Clients verify
+// these bytes, so a change here breaks every deployed verifier.
+func TestQuoteSigner_Canonical(t *testing.T) {
+	if got := CanonicalQuote("EUR", "MXN", "18.7543", "2025-12-01T10:15:30Z"); got != "EUR|MXN|18.7543|2025-12-01T10:15:30Z" {
+		t.Errorf("Unexpected canonical string %q", got)
+	}
+	if got := CanonicalQuote("EUR", "MXN", "", ""); got != "EUR|MXN||" {
+		t.Errorf("Unexpected canonical string without price %q", got)
+	}
+
+	signer := NewQuoteSigner("2026-10", []byte("test-secret"))
+	tests := []struct {
+		price, updatedAt, want string
+	}{
+		{"18.7543", "2025-12-01T10:15:30Z", "2e92dd5fa5f6aee2e7f065d820a8d50c2348b91a397ab4ded7d2c0ccf5217b46"},
+		{"", "", "863e5a8f5692a8e64e0686be1bfbeaac2681b903d7484c03ed4ca00948db7128"},
+	}
+	for _, tc := range tests {
+		if got := signer.Sign("EUR", "MXN", tc.price, tc.updatedAt); got != tc.want {
+			t.Errorf("Sign(%q, %q) = %s, want %s", tc.price, tc.updatedAt, got, tc.want)
+		}
+	}
+}
+
+func TestQuoteHandlers_Signing(t *testing.T) {
+	price, ts := "18.7543", "2025-12-01T10:15:30Z"
+	svc := &mockQuoteService{
+		getQuoteResultFunc: func(_ context.Context, id string) (*service.QuoteResult, error) {
+			if id == "pending" {
+				return &service.QuoteResult{ID: id, Base: "EUR", Quote: "MXN", Status: "PENDING"}, nil
+			}
+			return &service.QuoteResult{ID: id, Base: "EUR", Quote: "MXN", Status: "SUCCESS", Price: &price, UpdatedAt: &ts}, nil
+		},
+		getLatestQuoteFunc: func(_ context.Context, pair service.Pair) (*service.QuoteResult, error) {
+			return &service.QuoteResult{Base: pair.Base, Quote: pair.Quote, Price: &price, UpdatedAt: &ts}, nil
+		},
+	}
+	signer := NewQuoteSigner("2026-10", []byte("test-secret"))
+	route := func(s *QuoteSigner) http.Handler {
+		r := chi.NewRouter()
+		r.Get("/quotes/latest", HandleGetLatestQuote(svc, s, false, ErrorConfig{}))
+		r.Get("/quotes/{update_id}", HandleGetQuoteByID(svc, s, ErrorConfig{}))
+		return r
+	}
+
+	for _, tc := range []struct {
+		target, want string
+	}{
+		{"/quotes/latest?base=eur&quote=mxn", "2e92dd5fa5f6aee2e7f065d820a8d50c2348b91a397ab4ded7d2c0ccf5217b46"},
+		{"/quotes/done", "2e92dd5fa5f6aee2e7f065d820a8d50c2348b91a397ab4ded7d2c0ccf5217b46"},
+		{"/quotes/pending", "863e5a8f5692a8e64e0686be1bfbeaac2681b903d7484c03ed4ca00948db7128"},
+	} {
+		t.Run(tc.target, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			route(signer).ServeHTTP(w, httptest.NewRequest(http.MethodGet, tc.target, nil))
+			if got := w.Header().Get(HeaderQuoteSignature); got != tc.want {
+				t.Errorf("Expected signature %s, got %q", tc.want, got)
+			}
+			if got := w.Header().Get(HeaderQuoteSignatureKeyID); got != "2026-10" {
+				t.Errorf("Expected key id 2026-10, got %q", got)
+			}
+
+			w = httptest.NewRecorder()
+			route(nil).ServeHTTP(w, httptest.NewRequest(http.MethodGet, tc.target, nil))
+			if got := w.Header().Get(HeaderQuoteSignature); got != "" {
+				t.Errorf("Expected no signature without a signer, got %q", got)
+			}
+		})
+	}
+}
@@ -1,16 +1,118 @@
 package api
 
 import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/fs"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/go-chi/chi/v5"
+	swaggerFiles "github.com/swaggo/files/v2"
 	httpSwagger "github.com/swaggo/http-swagger/v2"
+	"github.com/swaggo/swag"
 )
 
-// SwaggerUIHandler returns a handler for Swagger UI
+// swaggerAssetCacheControl lets browsers keep the Swagger UI bundle for a week: it only
+// changes with the swaggo/files version the service is built with.
+const swaggerAssetCacheControl = "public, max-age=604800"
+
+// swaggerSpecCacheControl makes browsers revalidate the spec, which changes with every
+// build, by its ETag instead of downloading it again.
+const swaggerSpecCacheControl = "no-cache"
+
+// SwaggerUIHandler returns a handler for the Swagger UI page, index.html.
 func SwaggerUIHandler() http.HandlerFunc {
 	return httpSwagger.WrapHandler
 }
 
+// SwaggerSpecHandler returns a handler for the swagger spec, doc.json. The spec is read
+// once and served with an ETag of its hash, so a reload of the UI revalidates it with
+// If-None-Match and gets 304. With gzipSpec a gzipped copy, compressed once here, is
+// served to clients that accept gzip.
+func SwaggerSpecHandler(gzipSpec bool) http.HandlerFunc {
+	doc, err := swag.ReadDoc()
+	if err != nil {
+		return func(w http.ResponseWriter, r *http.Request) {
+			writeError(w, r, http.StatusInternalServerError, ErrCodeInternal, "Internal error")
+		}
+	}
+	spec := []byte(doc)
+	sum := sha256.Sum256(spec)
+	etag := hex.EncodeToString(sum[:8])
+
+	var gzipped []byte
+	if gzipSpec {
+		var buf bytes.Buffer
+		zw, _ := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+		_, _ = zw.Write(spec)
+		_ = zw.Close()
+		gzipped = buf.Bytes()
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		h := w.Header()
+		h.Set("Content-Type", "application/json; charset=utf-8")
+		h.Set("Cache-Control", swaggerSpecCacheControl)
+		body := spec
+		h.Set("ETag", `"`+etag+`"`)
+		if gzipped != nil {
+			h.Add("Vary", "Accept-Encoding")
+			if acceptsGzip(r) {
+				body = gzipped
+				h.Set("Content-Encoding", "gzip")
+				// Each encoding is its own representation with its own validator.
+				h.Set("ETag", `"`+etag+`-gzip"`)
+			}
+		}
+		http.ServeContent(w, r, "doc.json", time.Time{}, bytes.NewReader(body))
+	}
+}
+
+// SwaggerAssetHandler returns a handler for the static Swagger UI files under the route's
+// wildcard, served with a long-lived Cache-Control. An empty path redirects to the UI
+// page; names that are not files of the bundle get 404.
+func SwaggerAssetHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := chi.URLParam(r, "*")
+		if name == "" {
+			http.Redirect(w, r, "index.html", http.StatusMovedPermanently)
+			return
+		}
+		if info, err := fs.Stat(swaggerFiles.FS, name); err != nil || info.IsDir() {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Cache-Control", swaggerAssetCacheControl)
+		http.ServeFileFS(w, r, swaggerFiles.FS, name)
+	}
+}
+
+// acceptsGzip reports whether the request's Accept-Encoding allows gzip, by name or by
+// "*", with a non-zero q-value.
+func acceptsGzip(r *http.Request) bool {
+	for _, header := range r.Header.Values("Accept-Encoding") {
+		for _, part := range strings.Split(header, ",") {
+			coding, params, _ := strings.Cut(part, ";")
+			if coding = strings.TrimSpace(coding); coding != "gzip" && coding != "*" {
+				continue
+			}
+			q := 1.0
+			if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+				if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+					q = parsed
+				}
+			}
+			return q > 0
+		}
+	}
+	return false
+}
+
 // OpenAPISpecHandler returns a handler that redirects to the swagger spec JSON
 func OpenAPISpecHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
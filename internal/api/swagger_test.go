@@ -0,0 +1,126 @@
+package api
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/swaggo/swag"
+)
+
+func newSwaggerRouter(gzipSpec bool) http.Handler {
+	r := chi.NewRouter()
+	r.Get("/swagger/index.html", SwaggerUIHandler())
+	r.Get("/swagger/doc.json", SwaggerSpecHandler(gzipSpec))
+	r.Get("/swagger/*", SwaggerAssetHandler())
+	return r
+}
+
+func getSwagger(h http.Handler, path string, header http.Header) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, path, nil)
+	for k, v := range header {
+		r.Header[k] = v
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestSwaggerSpecHandler_ETag(t *testing.T) {
+	h := newSwaggerRouter(false)
+	doc, err := swag.ReadDoc()
+	if err != nil {
+		t.Fatalf("ReadDoc: %v", err)
+	}
+
+	w := getSwagger(h, "/swagger/doc.json", nil)
+	if w.Code != http.StatusOK || w.Body.String() != doc {
+		t.Fatalf("Expected status 200 with the spec, got %d", w.Code)
+	}
+	etag := w.Header().Get("ETag")
+	if len(etag) != 18 || w.Header().Get("Cache-Control") != swaggerSpecCacheControl ||
+		w.Header().Get("Content-Type") != "application/json; charset=utf-8" {
+		t.Errorf("Unexpected headers %v", w.Header())
+	}
+	if again := getSwagger(h, "/swagger/doc.json", nil).Header().Get("ETag"); again != etag {
+		t.Errorf("Expected a stable ETag %s, got %s", etag, again)
+	}
+
+	w = getSwagger(h, "/swagger/doc.json", http.Header{"If-None-Match": {etag}})
+	if w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Errorf("Expected 304 without a body for a matching If-None-Match, got %d (%d bytes)", w.Code, w.Body.Len())
+	}
+	w = getSwagger(h, "/swagger/doc.json", http.Header{"If-None-Match": {`"stale"`}})
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected 200 for a stale If-None-Match, got %d", w.Code)
+	}
+
+	w = getSwagger(h, "/swagger/doc.json", http.Header{"Accept-Encoding": {"gzip"}})
+	if w.Header().Get("Content-Encoding") != "" || w.Header().Get("Vary") != "" {
+		t.Errorf("Expected no gzip unless enabled, got %v", w.Header())
+	}
+}
+
+func TestSwaggerSpecHandler_Gzip(t *testing.T) {
+	h := newSwaggerRouter(true)
+	doc, _ := swag.ReadDoc()
+
+	w := getSwagger(h, "/swagger/doc.json", http.Header{"Accept-Encoding": {"br, gzip;q=0.8"}})
+	if w.Code != http.StatusOK || w.Header().Get("Content-Encoding") != "gzip" || w.Header().Get("Vary") != "Accept-Encoding" {
+		t.Fatalf("Expected a gzipped 200, got %d %v", w.Code, w.Header())
+	}
+	zr, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatalf("gzip.NewReader: %v", err)
+	}
+	body, err := io.ReadAll(zr)
+	if err != nil || string(body) != doc {
+		t.Errorf("Expected the gzipped spec, got %d bytes (err %v)", len(body), err)
+	}
+	gzipETag := w.Header().Get("ETag")
+	if !strings.HasSuffix(gzipETag, `-gzip"`) {
+		t.Errorf("Expected a gzip-specific ETag, got %s", gzipETag)
+	}
+	w = getSwagger(h, "/swagger/doc.json", http.Header{"Accept-Encoding": {"gzip"}, "If-None-Match": {gzipETag}})
+	if w.Code != http.StatusNotModified {
+		t.Errorf("Expected 304 for the gzip ETag, got %d", w.Code)
+	}
+
+	for _, accept := range []string{"", "identity", "gzip;q=0"} {
+		w = getSwagger(h, "/swagger/doc.json", http.Header{"Accept-Encoding": {accept}})
+		if w.Header().Get("Content-Encoding") != "" || w.Body.String() != doc || w.Header().Get("ETag") == gzipETag {
+			t.Errorf("Accept-Encoding %q: expected the plain spec, got %v", accept, w.Header())
+		}
+	}
+}
+
+func TestSwaggerAssetHandler(t *testing.T) {
+	h := newSwaggerRouter(false)
+
+	w := getSwagger(h, "/swagger/swagger-ui.css", nil)
+	if w.Code != http.StatusOK || w.Header().Get("Cache-Control") != swaggerAssetCacheControl ||
+		!strings.HasPrefix(w.Header().Get("Content-Type"), "text/css") {
+		t.Errorf("Expected a cacheable stylesheet, got %d %v", w.Code, w.Header())
+	}
+
+	w = getSwagger(h, "/swagger/", nil)
+	if w.Code != http.StatusMovedPermanently || w.Header().Get("Location") != "/swagger/index.html" {
+		t.Errorf("Expected a redirect to index.html, got %d %v", w.Code, w.Header())
+	}
+
+	for _, path := range []string{"/swagger/missing.js", "/swagger/../go.mod"} {
+		if w := getSwagger(h, path, nil); w.Code != http.StatusNotFound || w.Header().Get("Cache-Control") != "" {
+			t.Errorf("%s: expected an uncached 404, got %d", path, w.Code)
+		}
+	}
+
+	w = getSwagger(h, "/swagger/index.html", nil)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `url: "doc.json"`) ||
+		w.Header().Get("Cache-Control") != "" {
+		t.Errorf("Expected the uncached UI page, got %d %v", w.Code, w.Header())
+	}
+}
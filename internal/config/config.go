@@ -4,6 +4,8 @@ package config
 import (
 	"errors"
 	"fmt"
+	"net/url"
+	"regexp"
 	"strings"
 
 	"github.com/joho/godotenv"
@@ -12,13 +14,32 @@ import (
 
 // Config holds the complete application configuration.
 type Config struct {
-	Server           ServerConfig
-	Database         DatabaseConfig
-	Redis            RedisConfig
-	ExchangeRateHost ExchangeRateHostConfig `mapstructure:"exchangerate_host"`
-	Frankfurter      FrankfurterConfig      `mapstructure:"frankfurter"`
-	Worker           WorkerConfig
-	Cache            CacheConfig
+	Server            ServerConfig
+	Logging           LoggingConfig
+	Database          DatabaseConfig
+	Redis             RedisConfig
+	ExchangeRateHost  ExchangeRateHostConfig  `mapstructure:"exchangerate_host"`
+	Frankfurter       FrankfurterConfig       `mapstructure:"frankfurter"`
+	HTTPClient        HTTPClientConfig        `mapstructure:"http_client"`
+	CircuitBreaker    CircuitBreakerConfig    `mapstructure:"circuit_breaker"`
+	ProviderWarmup    ProviderWarmupConfig    `mapstructure:"provider_warmup"`
+	ProviderSelection ProviderSelectionConfig `mapstructure:"provider_selection"`
+	Worker            WorkerConfig
+	Cache             CacheConfig
+	Auth              AuthConfig
+	Alerts            AlertConfig
+	Streaming         StreamingProviderConfig `mapstructure:"streaming_provider"`
+	Webhooks          WebhookConfig
+	Events            EventsConfig
+	Verification      VerificationConfig
+	Retention         RetentionConfig
+	Freshness         FreshnessConfig
+	PollHint          PollHintConfig `mapstructure:"poll_hint"`
+	Signing           SigningConfig
+	Reconcile         ReconcileConfig
+	API               APIConfig
+	// Pairs holds per-pair overrides keyed by "BASE/QUOTE".
+	Pairs map[string]PairOverride `mapstructure:"pairs"`
 }
 
 // ServerConfig holds HTTP server settings.
@@ -26,6 +47,23 @@ type ServerConfig struct {
 	Port          int  `mapstructure:"port"`
 	ServeSwagger  bool `mapstructure:"serve_swagger"`
 	ServeAsynqmon bool `mapstructure:"serve_asynqmon"`
+	// SwaggerGzipSpec serves /swagger/doc.json from a copy gzipped at startup to clients
+	// that accept gzip.
+	SwaggerGzipSpec bool `mapstructure:"swagger_gzip_spec"`
+	// InternalPort moves /metrics, /admin/* and asynqmon to a second listener; 0 keeps
+	// them on Port.
+	InternalPort int `mapstructure:"internal_port"`
+	// TimestampPrecision is the number of fractional-second digits (0-9) in API timestamps.
+	TimestampPrecision int `mapstructure:"timestamp_precision"`
+	// QueueRetryAfterSec is the Retry-After hint sent with 503 when the task queue is unavailable.
+	QueueRetryAfterSec int `mapstructure:"queue_retry_after_sec"`
+}
+
+// LoggingConfig holds structured logging settings.
+type LoggingConfig struct {
+	// MaxFieldBytes truncates longer log messages, string fields and error messages,
+	// such as provider response bodies embedded in errors. 0 disables truncation.
+	MaxFieldBytes int `mapstructure:"max_field_bytes"`
 }
 
 // DatabaseConfig holds PostgreSQL connection settings.
@@ -39,13 +77,19 @@ type DatabaseConfig struct {
 	MaxOpenConns       int    `mapstructure:"max_open_conns"`
 	MaxIdleConns       int    `mapstructure:"max_idle_conns"`
 	ConnMaxLifetimeSec int    `mapstructure:"conn_max_lifetime_sec"`
-	DSN                string
+	// SkipMigrations leaves the schema to an external tool: startup only verifies that
+	// the tables and columns the service uses exist, instead of applying migrations.
+	SkipMigrations bool   `mapstructure:"skip_migrations"`
+	DSN            string `mapstructure:"-"` // Built from the fields above by LoadConfig.
 }
 
 // RedisConfig holds connection settings for both Redis instances.
 type RedisConfig struct {
 	AsynqAddr string `mapstructure:"asynq_addr"` // Redis instance for Asynq task queue (required).
 	CacheAddr string `mapstructure:"cache_addr"` // Redis instance for application cache (required).
+	// Namespace is prefixed to every Redis key and asynq queue name, so several
+	// environments can share the Redis instances. Empty keeps the unprefixed names.
+	Namespace string `mapstructure:"namespace"`
 }
 
 // ExchangeRateHostConfig holds settings for the exchangerate.host provider.
@@ -61,18 +105,371 @@ type FrankfurterConfig struct {
 	Timeout int    `mapstructure:"timeout_sec"`
 }
 
+// HTTPClientConfig holds the outbound HTTP settings shared by the exchange rate providers.
+type HTTPClientConfig struct {
+	// ProxyURL is an http, https or socks5 proxy, with credentials in the user info if
+	// needed. Empty uses the HTTP_PROXY/HTTPS_PROXY/NO_PROXY environment variables.
+	ProxyURL string `mapstructure:"proxy_url"`
+	// CACertFile is a PEM bundle trusted in addition to the system roots.
+	CACertFile string `mapstructure:"ca_cert_file"`
+	// InsecureSkipVerify disables TLS certificate verification. Development only.
+	InsecureSkipVerify bool `mapstructure:"insecure_skip_verify"`
+}
+
+func (c HTTPClientConfig) validate() []error {
+	var errs []error
+	if c.ProxyURL != "" {
+		u, err := url.Parse(c.ProxyURL)
+		switch {
+		case err != nil:
+			errs = append(errs, fmt.Errorf("http_client.proxy_url is invalid: %w", err))
+		case u.Scheme != "http" && u.Scheme != "https" && u.Scheme != "socks5":
+			errs = append(errs, fmt.Errorf("http_client.proxy_url must use http, https or socks5, got %q", u.Scheme))
+		case u.Host == "":
+			errs = append(errs, fmt.Errorf("http_client.proxy_url must include a host"))
+		}
+	}
+	// A custom CA is for verifying through a gateway; skipping verification would
+	// silently make it pointless.
+	if c.InsecureSkipVerify && c.CACertFile != "" {
+		errs = append(errs, fmt.Errorf("http_client.insecure_skip_verify cannot be combined with http_client.ca_cert_file"))
+	}
+	return errs
+}
+
+// CircuitBreakerConfig holds the per-provider circuit breaker settings.
+type CircuitBreakerConfig struct {
+	// FailureThreshold is the number of consecutive failures that opens a provider's
+	// circuit; 0 disables the breaker.
+	FailureThreshold int `mapstructure:"failure_threshold"`
+	OpenSec          int `mapstructure:"open_sec"` // How long the circuit stays open before a trial request.
+}
+
+// ProviderWarmupConfig controls refilling a provider's rate cache when its circuit
+// breaker closes after an outage, before normal traffic reaches it again.
+type ProviderWarmupConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	DelayMs int  `mapstructure:"delay_ms"` // Pause between provider calls.
+	// Pairs are the "BASE/QUOTE" pairs fetched; empty uses cache.warmup_pairs.
+	Pairs []string `mapstructure:"pairs"`
+}
+
+// Orderings of the exchange rate providers, for ProviderSelectionConfig.Strategy.
+const (
+	ProviderStrategyFixed    = "fixed"    // The configured order.
+	ProviderStrategyAdaptive = "adaptive" // By recent success rate and latency.
+)
+
+// ProviderSelectionConfig holds how the provider facade orders the providers it tries.
+// Recent calls are tracked with either strategy, so the scores can be inspected before
+// switching to adaptive.
+type ProviderSelectionConfig struct {
+	Strategy   string  `mapstructure:"strategy"`
+	Window     int     `mapstructure:"window"`      // Recent calls kept per provider.
+	ExplorePct float64 `mapstructure:"explore_pct"` // Share of calls that try a random provider first.
+}
+
 // WorkerConfig holds background worker and task queue settings.
 type WorkerConfig struct {
 	Concurrency      int `mapstructure:"concurrency"`
 	MaxRetry         int `mapstructure:"max_retry"`
 	TimeoutSec       int `mapstructure:"timeout_sec"`
 	CheckIntervalSec int `mapstructure:"check_interval_sec"`
+	EnqueueTimeoutMs int `mapstructure:"enqueue_timeout_ms"`
+	// RefreshCooldownSec answers an update request with the latest successful update
+	// when it is younger than this; 0 always fetches. Overridable per pair.
+	RefreshCooldownSec int `mapstructure:"refresh_cooldown_sec"`
+	// AllowRuntimeTuning enables PATCH /admin/worker-config and applies settings saved by it at startup.
+	AllowRuntimeTuning bool `mapstructure:"allow_runtime_tuning"`
+	// DeferUnknownTasks keeps tasks of unknown types queued for a newer worker instead
+	// of archiving them.
+	DeferUnknownTasks bool `mapstructure:"defer_unknown_tasks"`
+	// MaxPending rejects new update requests while this many updates are PENDING;
+	// 0 disables the cap.
+	MaxPending int `mapstructure:"max_pending"`
+	// PendingCountCacheMs is how long the PENDING count checked against MaxPending is reused.
+	PendingCountCacheMs int `mapstructure:"pending_count_cache_ms"`
+	// ArchivedCheckIntervalSec is how often the archived task gauge is refreshed; 0
+	// disables it.
+	ArchivedCheckIntervalSec int `mapstructure:"archived_check_interval_sec"`
 }
 
 // CacheConfig holds caching settings.
 type CacheConfig struct {
 	LatestPriceTTLSec           int `mapstructure:"latest_price_ttl_sec"`
 	ExchangeProviderPriceTTLSec int `mapstructure:"exchange_provider_price_ttl_sec"`
+	// NegativeCacheTTLSec caches "no quote yet" answers of GetLatestQuote; 0 disables it.
+	NegativeCacheTTLSec int `mapstructure:"negative_cache_ttl_sec"`
+	// WarmupRequired keeps /readyz at 503 until the latest-price cache has been warmed from the DB.
+	WarmupRequired bool     `mapstructure:"warmup_required"`
+	WarmupPairs    []string `mapstructure:"warmup_pairs"` // Pairs preloaded at startup, e.g. "EUR/USD".
+	// AllowProviderTTLAboveLatest permits an exchange_provider_price_ttl_sec above
+	// latest_price_ttl_sec, where an expired latest price is refreshed from a provider
+	// answer that can be older than it.
+	AllowProviderTTLAboveLatest bool `mapstructure:"allow_provider_ttl_above_latest"`
+	// InverseEnabled also caches the inverse of every rate an update stores, e.g.
+	// MXN/EUR for EUR/MXN, for InverseTTLSec and rounded to InversePrecision decimal
+	// places. Inverse prices are never written to the DB and never replace a cached
+	// price that was fetched directly.
+	InverseEnabled   bool `mapstructure:"inverse_enabled"`
+	InverseTTLSec    int  `mapstructure:"inverse_ttl_sec"`
+	InversePrecision int  `mapstructure:"inverse_precision"`
+}
+
+// AuthConfig holds API key authentication settings.
+type AuthConfig struct {
+	Enabled bool           `mapstructure:"enabled"`
+	APIKeys []APIKeyConfig `mapstructure:"api_keys"`
+	// QuotaEnabled counts each key's requests per calendar month in the cache Redis and
+	// enforces the keys' MonthlyQuota. Key names must then be set and unique.
+	QuotaEnabled bool `mapstructure:"quota_enabled"`
+}
+
+// APIKeyConfig describes a single API key and the scopes it grants (read, write, admin).
+// Pairs and Bases restrict the key to the listed pairs and to pairs with the listed
+// base currencies; a key with neither may access every pair.
+type APIKeyConfig struct {
+	Name   string   `mapstructure:"name"`
+	Key    string   `mapstructure:"key"`
+	Scopes []string `mapstructure:"scopes"`
+	Pairs  []string `mapstructure:"pairs"` // e.g. "EUR/USD".
+	Bases  []string `mapstructure:"bases"` // e.g. "BTC".
+	// MonthlyQuota limits the key's requests per calendar month (UTC) when quotas are
+	// enabled; 0 counts them without a limit.
+	MonthlyQuota int64 `mapstructure:"monthly_quota"`
+}
+
+// AlertConfig holds operational alert delivery settings.
+type AlertConfig struct {
+	SlackWebhookURL     string   `mapstructure:"slack_webhook_url"`
+	PagerDutyRoutingKey string   `mapstructure:"pagerduty_routing_key"`
+	EnabledAlerts       []string `mapstructure:"enabled_alerts"` // Whitelist: provider_failure, dlq_overflow, queue_depth, stuck_tasks.
+	// ThresholdPct raises a rate_move alert when a pair moves more than this percentage
+	// between consecutive successful updates. 0 disables it unless PairThresholds is set.
+	ThresholdPct        float64            `mapstructure:"threshold_pct"`
+	PairThresholds      map[string]float64 `mapstructure:"pair_thresholds"` // Per-pair overrides, e.g. "EUR/USD": 0.5.
+	RateMoveCooldownSec int                `mapstructure:"rate_move_cooldown_sec"`
+	RateMoveWebhookURL  string             `mapstructure:"rate_move_webhook_url"`
+}
+
+// StreamingProviderConfig holds settings for the optional WebSocket rate stream.
+type StreamingProviderConfig struct {
+	Enabled              bool     `mapstructure:"enabled"`
+	URL                  string   `mapstructure:"url"`
+	Pairs                []string `mapstructure:"pairs"` // Pairs to subscribe to, e.g. "EUR/USD".
+	InitialBackoffMs     int      `mapstructure:"initial_backoff_ms"`
+	MaxBackoffMs         int      `mapstructure:"max_backoff_ms"`
+	MaxReconnectAttempts int      `mapstructure:"max_reconnect_attempts"` // 0 retries forever.
+}
+
+// WebhookConfig holds settings for outbound quote webhooks. Webhooks are enabled only
+// when SecretHashKey is set.
+type WebhookConfig struct {
+	SecretHashKey string `mapstructure:"secret_hash_key"` // HMAC key for hashing webhook secrets at rest.
+}
+
+// Enabled reports whether webhook registration is turned on.
+func (c WebhookConfig) Enabled() bool {
+	return c.SecretHashKey != ""
+}
+
+// minWebhookSecretHashKeyBytes matches the HMAC-SHA256 output size, below which the key
+// is weaker than the digest it produces.
+const minWebhookSecretHashKeyBytes = 32
+
+func (c WebhookConfig) validate() []error {
+	if c.Enabled() && len(c.SecretHashKey) < minWebhookSecretHashKeyBytes {
+		return []error{fmt.Errorf("webhooks.secret_hash_key must be at least %d bytes, got %d",
+			minWebhookSecretHashKeyBytes, len(c.SecretHashKey))}
+	}
+	return nil
+}
+
+// VerificationConfig controls cross-checking each fetched rate against every configured
+// provider. It has no effect with fewer than two providers.
+type VerificationConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// SpreadThresholdPct logs a WARN when the spread across providers exceeds this
+	// percentage of the lowest rate; 0 records the spread without warning.
+	SpreadThresholdPct float64 `mapstructure:"spread_threshold_pct"`
+	MaxConcurrency     int     `mapstructure:"max_concurrency"` // Providers queried at once.
+	// ProviderRequestsPerMin caps verification calls per provider; a provider over its
+	// budget is left out of the comparison.
+	ProviderRequestsPerMin int `mapstructure:"provider_requests_per_min"`
+}
+
+// Retention modes accepted in RetentionConfig.Mode.
+const (
+	RetentionModeSoftDelete   = "soft_delete"
+	RetentionModeArchiveTable = "archive_table"
+)
+
+// RetentionConfig controls the background job that archives old quote updates.
+type RetentionConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Mode is "soft_delete" (set archived_at) or "archive_table" (move rows and their
+	// status events to the *_archive tables).
+	Mode        string `mapstructure:"mode"`
+	MaxAgeDays  int    `mapstructure:"max_age_days"` // Terminal updates older than this are archived.
+	BatchSize   int    `mapstructure:"batch_size"`   // Rows archived or deleted per statement.
+	IntervalSec int    `mapstructure:"interval_sec"` // Pause between retention runs.
+	// EventsMaxAgeDays deletes the status events of terminal updates older than this,
+	// independently of their updates; 0 keeps events as long as their update.
+	EventsMaxAgeDays int `mapstructure:"events_max_age_days"`
+}
+
+func (c RetentionConfig) validate() []error {
+	if !c.Enabled {
+		return nil
+	}
+	var errs []error
+	if c.Mode != RetentionModeSoftDelete && c.Mode != RetentionModeArchiveTable {
+		errs = append(errs, fmt.Errorf("retention.mode must be %q or %q, got %q",
+			RetentionModeSoftDelete, RetentionModeArchiveTable, c.Mode))
+	}
+	if c.MaxAgeDays <= 0 {
+		errs = append(errs, fmt.Errorf("retention.max_age_days must be positive, got %d", c.MaxAgeDays))
+	}
+	if c.BatchSize <= 0 {
+		errs = append(errs, fmt.Errorf("retention.batch_size must be positive, got %d", c.BatchSize))
+	}
+	if c.IntervalSec <= 0 {
+		errs = append(errs, fmt.Errorf("retention.interval_sec must be positive, got %d", c.IntervalSec))
+	}
+	// Events outliving their update would be orphaned once it is archived.
+	if c.EventsMaxAgeDays < 0 {
+		errs = append(errs, fmt.Errorf("retention.events_max_age_days must be non-negative, got %d", c.EventsMaxAgeDays))
+	} else if c.MaxAgeDays > 0 && c.EventsMaxAgeDays > c.MaxAgeDays {
+		errs = append(errs, fmt.Errorf("retention.events_max_age_days=%d must not exceed retention.max_age_days=%d",
+			c.EventsMaxAgeDays, c.MaxAgeDays))
+	}
+	return errs
+}
+
+// FreshnessConfig controls the gauges exporting how old each pair's latest successful
+// quote is.
+type FreshnessConfig struct {
+	Enabled     bool `mapstructure:"enabled"`
+	IntervalSec int  `mapstructure:"interval_sec"` // How often the gauges are refreshed.
+	// Pairs are the "BASE/QUOTE" pairs to export; empty exports every pair with a live
+	// update. The gauges have one series per pair, so this bounds their cardinality.
+	Pairs []string `mapstructure:"pairs"`
+}
+
+// PollHintConfig controls the poll_after_ms hint of update responses and the
+// Retry-After header of unfinished GET /quotes/{update_id} responses. The hint is
+// estimated from the update queue depth and the average duration of the last Window
+// tasks.
+type PollHintConfig struct {
+	Enabled   bool `mapstructure:"enabled"`
+	DefaultMs int  `mapstructure:"default_ms"` // Used until tasks have completed or when Redis fails.
+	MinMs     int  `mapstructure:"min_ms"`
+	MaxMs     int  `mapstructure:"max_ms"`
+	Window    int  `mapstructure:"window"`   // Task durations averaged.
+	CacheMs   int  `mapstructure:"cache_ms"` // How long an estimate is reused.
+}
+
+// ReconcileConfig controls the check, at startup and on POST /admin/reconcile, that
+// every PENDING update still has a task in the queue.
+type ReconcileConfig struct {
+	Enabled bool `mapstructure:"enabled"` // Run at startup; the admin trigger is always available.
+	// GraceSec leaves younger PENDING updates alone, as their task may still be on its way.
+	GraceSec int `mapstructure:"grace_sec"`
+	// GiveUpSec fails updates older than this whose task is gone instead of requeueing them.
+	GiveUpSec int `mapstructure:"give_up_sec"`
+	BatchSize int `mapstructure:"batch_size"` // Updates checked per run, oldest first.
+}
+
+// APIConfig holds settings of individual API endpoints.
+type APIConfig struct {
+	// DefaultPairs are the "BASE/QUOTE" pairs GET /quotes/latest/defaults returns, in
+	// this order. Their currencies must be supported; that is checked at startup.
+	DefaultPairs []string `mapstructure:"default_pairs"`
+	// QuoteSourceHeader sends X-Quote-Source with GET /quotes/latest, naming the tier
+	// that served the quote. Off for deployments that consider it an information leak.
+	QuoteSourceHeader bool `mapstructure:"quote_source_header"`
+}
+
+// SigningConfig controls the HMAC-SHA256 signature sent with GET /quotes/latest and
+// GET /quotes/{update_id} responses. Secrets come from Keys or, e.g. for a mounted
+// secret, from the file KeyFiles names; both map a key id to its secret. Only KeyID
+// signs, so a new key can be deployed before it is switched to.
+type SigningConfig struct {
+	Enabled  bool              `mapstructure:"enabled"`
+	KeyID    string            `mapstructure:"key_id"`    // Sent in X-Quote-Signature-Key-Id.
+	Keys     map[string]string `mapstructure:"keys"`      // Key id to secret.
+	KeyFiles map[string]string `mapstructure:"key_files"` // Key id to the path of a file holding the secret.
+}
+
+func (c SigningConfig) validate() []error {
+	if !c.Enabled {
+		return nil
+	}
+	var errs []error
+	for id := range c.Keys {
+		if !signingKeyIDPattern.MatchString(id) {
+			errs = append(errs, fmt.Errorf("signing.keys: key id %q must match %s", id, signingKeyIDPattern))
+		}
+		if _, ok := c.KeyFiles[id]; ok {
+			errs = append(errs, fmt.Errorf("signing: key %q is set in both keys and key_files", id))
+		}
+	}
+	for id := range c.KeyFiles {
+		if !signingKeyIDPattern.MatchString(id) {
+			errs = append(errs, fmt.Errorf("signing.key_files: key id %q must match %s", id, signingKeyIDPattern))
+		}
+	}
+	secret, inKeys := c.Keys[c.KeyID]
+	_, inFiles := c.KeyFiles[c.KeyID]
+	switch {
+	case c.KeyID == "":
+		errs = append(errs, fmt.Errorf("signing.key_id is required when signing is enabled"))
+	case !inKeys && !inFiles:
+		errs = append(errs, fmt.Errorf("signing.key_id %q is not in signing.keys or signing.key_files", c.KeyID))
+	case inKeys && secret == "":
+		errs = append(errs, fmt.Errorf("signing.keys[%s] must not be empty", c.KeyID))
+	}
+	return errs
+}
+
+// signingKeyIDPattern keeps key ids safe to send in a header. Viper lower-cases map
+// keys, so upper-case ids could never match key_id.
+var signingKeyIDPattern = regexp.MustCompile(`^[a-z0-9._-]{1,64}$`)
+
+// Update priorities accepted in PairOverride.Priority. Each is also the name of the
+// asynq queue the pair's update tasks are enqueued to.
+const (
+	PriorityHigh    = "high"
+	PriorityDefault = "default"
+	PriorityLow     = "low"
+)
+
+// Provider names accepted in PairOverride.ProviderOrder.
+const (
+	ProviderExchangeRateHost = "exchangerate_host"
+	ProviderFrankfurter      = "frankfurter"
+)
+
+// PairOverride holds settings for one pair that take precedence over the global ones.
+// Unset fields fall back to the global value.
+type PairOverride struct {
+	LatestPriceTTLSec *int     `mapstructure:"latest_price_ttl_sec"`
+	ProviderOrder     []string `mapstructure:"provider_order"` // Providers to try, in order.
+	Priority          string   `mapstructure:"priority"`       // "high", "default" or "low".
+	CooldownSec       *int     `mapstructure:"cooldown_sec"`   // Overrides worker.refresh_cooldown_sec.
+}
+
+// Quote event sinks accepted in EventsConfig.Sink.
+const (
+	EventSinkNone        = "none"
+	EventSinkRedisStream = "redis_stream"
+)
+
+// EventsConfig selects where terminal quote update events are published.
+type EventsConfig struct {
+	Sink   string `mapstructure:"sink"`    // "none" or "redis_stream".
+	Stream string `mapstructure:"stream"`  // Redis stream name for the redis_stream sink.
+	MaxLen int64  `mapstructure:"max_len"` // Approximate stream length cap; 0 disables trimming.
 }
 
 // LoadConfig reads configuration from config files, environment variables, and defaults.
@@ -97,7 +494,12 @@ func LoadConfig() (*Config, error) {
 	// default values
 	viper.SetDefault("server.port", 8080)
 	viper.SetDefault("server.serve_swagger", true)
+	viper.SetDefault("server.swagger_gzip_spec", false)
 	viper.SetDefault("server.serve_asynqmon", true)
+	viper.SetDefault("server.internal_port", 0)
+	viper.SetDefault("server.timestamp_precision", 0)
+	viper.SetDefault("server.queue_retry_after_sec", 5)
+	viper.SetDefault("logging.max_field_bytes", 2048)
 	viper.SetDefault("database.host", "db")
 	viper.SetDefault("database.port", 5432)
 	viper.SetDefault("database.user", "postgres")
@@ -107,19 +509,86 @@ func LoadConfig() (*Config, error) {
 	viper.SetDefault("database.max_open_conns", 10)
 	viper.SetDefault("database.max_idle_conns", 5)
 	viper.SetDefault("database.conn_max_lifetime_sec", 300)
+	viper.SetDefault("database.skip_migrations", false)
 	viper.SetDefault("redis.asynq_addr", "redis_asynq:6380")
 	viper.SetDefault("redis.cache_addr", "redis_cache:6381")
+	viper.SetDefault("redis.namespace", "")
 	viper.SetDefault("exchangerate_host.base_url", "https://api.exchangerate.host")
 	viper.SetDefault("exchangerate_host.api_key", "")
 	viper.SetDefault("exchangerate_host.timeout_sec", 5)
 	viper.SetDefault("frankfurter.base_url", "https://api.frankfurter.dev/v1")
 	viper.SetDefault("frankfurter.timeout_sec", 5)
+	viper.SetDefault("http_client.proxy_url", "")
+	viper.SetDefault("http_client.ca_cert_file", "")
+	viper.SetDefault("http_client.insecure_skip_verify", false)
+	viper.SetDefault("circuit_breaker.failure_threshold", 5)
+	viper.SetDefault("circuit_breaker.open_sec", 30)
+	viper.SetDefault("provider_warmup.enabled", false)
+	viper.SetDefault("provider_warmup.delay_ms", 200)
+	viper.SetDefault("provider_selection.strategy", ProviderStrategyFixed)
+	viper.SetDefault("provider_selection.window", 50)
+	viper.SetDefault("provider_selection.explore_pct", 5)
 	viper.SetDefault("worker.concurrency", 1)
 	viper.SetDefault("worker.max_retry", 3)
 	viper.SetDefault("worker.timeout_sec", 30)
 	viper.SetDefault("worker.check_interval_sec", 5)
+	viper.SetDefault("worker.enqueue_timeout_ms", 2000)
+	viper.SetDefault("worker.refresh_cooldown_sec", 0)
+	viper.SetDefault("worker.allow_runtime_tuning", false)
+	viper.SetDefault("worker.defer_unknown_tasks", false)
+	viper.SetDefault("worker.max_pending", 0)
+	viper.SetDefault("worker.pending_count_cache_ms", 1000)
+	viper.SetDefault("worker.archived_check_interval_sec", 60)
 	viper.SetDefault("cache.latest_price_ttl_sec", 600)
 	viper.SetDefault("cache.exchange_provider_price_ttl_sec", 300)
+	viper.SetDefault("cache.negative_cache_ttl_sec", 30)
+	viper.SetDefault("cache.warmup_required", false)
+	viper.SetDefault("cache.allow_provider_ttl_above_latest", false)
+	viper.SetDefault("cache.inverse_enabled", false)
+	viper.SetDefault("cache.inverse_ttl_sec", 60)
+	viper.SetDefault("cache.inverse_precision", 10)
+	viper.SetDefault("auth.enabled", false)
+	viper.SetDefault("auth.quota_enabled", false)
+	viper.SetDefault("streaming_provider.enabled", false)
+	viper.SetDefault("streaming_provider.initial_backoff_ms", 500)
+	viper.SetDefault("streaming_provider.max_backoff_ms", 30000)
+	viper.SetDefault("streaming_provider.max_reconnect_attempts", 0)
+	viper.SetDefault("alerts.slack_webhook_url", "")
+	viper.SetDefault("alerts.pagerduty_routing_key", "")
+	viper.SetDefault("alerts.threshold_pct", 0)
+	viper.SetDefault("alerts.rate_move_cooldown_sec", 300)
+	viper.SetDefault("alerts.rate_move_webhook_url", "")
+	viper.SetDefault("webhooks.secret_hash_key", "")
+	viper.SetDefault("events.sink", EventSinkNone)
+	viper.SetDefault("events.stream", "quotes:events")
+	viper.SetDefault("events.max_len", 100000)
+	viper.SetDefault("verification.enabled", false)
+	viper.SetDefault("verification.spread_threshold_pct", 1.0)
+	viper.SetDefault("verification.max_concurrency", 2)
+	viper.SetDefault("verification.provider_requests_per_min", 60)
+	viper.SetDefault("retention.enabled", false)
+	viper.SetDefault("retention.mode", RetentionModeSoftDelete)
+	viper.SetDefault("retention.max_age_days", 90)
+	viper.SetDefault("retention.batch_size", 1000)
+	viper.SetDefault("retention.interval_sec", 3600)
+	viper.SetDefault("retention.events_max_age_days", 0)
+	viper.SetDefault("freshness.enabled", false)
+	viper.SetDefault("freshness.interval_sec", 60)
+	viper.SetDefault("poll_hint.enabled", true)
+	viper.SetDefault("poll_hint.default_ms", 2000)
+	viper.SetDefault("poll_hint.min_ms", 500)
+	viper.SetDefault("poll_hint.max_ms", 30000)
+	viper.SetDefault("poll_hint.window", 100)
+	viper.SetDefault("poll_hint.cache_ms", 1000)
+	viper.SetDefault("signing.enabled", false)
+	viper.SetDefault("signing.key_id", "")
+	viper.SetDefault("reconcile.enabled", true)
+	viper.SetDefault("reconcile.grace_sec", 60)
+	viper.SetDefault("reconcile.give_up_sec", 3600)
+	viper.SetDefault("reconcile.batch_size", 1000)
+
+	viper.SetDefault("api.default_pairs", []string{})
+	viper.SetDefault("api.quote_source_header", true)
 
 	if err := viper.ReadInConfig(); err != nil {
 		// It's okay if no config file, we have defaults and env
@@ -130,6 +599,11 @@ func LoadConfig() (*Config, error) {
 	if err := viper.Unmarshal(&cfg); err != nil {
 		return nil, fmt.Errorf("error unmarshaling config: %w", err)
 	}
+	pairs, err := DecodePairOverrides(viper.Get("pairs"))
+	if err != nil {
+		return nil, fmt.Errorf("config validation failed: %w", err)
+	}
+	cfg.Pairs = pairs
 	if err := cfg.Validate(); err != nil {
 		return nil, fmt.Errorf("config validation failed: %w", err)
 	}
@@ -152,12 +626,60 @@ func LoadConfig() (*Config, error) {
 	return &cfg, nil
 }
 
+// workerTimeoutHeadroomSec is the part of worker.timeout_sec left for the database and
+// cache round trips of an update after every provider has timed out.
+const workerTimeoutHeadroomSec = 5
+
+// validateRelationships checks the settings that are only valid relative to each other.
+// Fields that are invalid on their own are reported by Validate and skipped here.
+func (c *Config) validateRelationships() []error {
+	var errs []error
+
+	// An update tries the configured providers one after another, so a task timeout
+	// below their sum cancels it before the last provider had its chance.
+	var names []string
+	providerSec := 0
+	if c.ExchangeRateHost.BaseURL != "" && c.ExchangeRateHost.APIKey != "" && c.ExchangeRateHost.Timeout > 0 {
+		names = append(names, fmt.Sprintf("exchangerate_host.timeout_sec=%d", c.ExchangeRateHost.Timeout))
+		providerSec += c.ExchangeRateHost.Timeout
+	}
+	if c.Frankfurter.BaseURL != "" && c.Frankfurter.Timeout > 0 {
+		names = append(names, fmt.Sprintf("frankfurter.timeout_sec=%d", c.Frankfurter.Timeout))
+		providerSec += c.Frankfurter.Timeout
+	}
+	if minSec := providerSec + workerTimeoutHeadroomSec; providerSec > 0 && c.Worker.TimeoutSec > 0 && c.Worker.TimeoutSec < minSec {
+		errs = append(errs, fmt.Errorf("worker.timeout_sec=%d must be at least %d: the sum of the provider timeouts (%s) plus %ds for storing the result",
+			c.Worker.TimeoutSec, minSec, strings.Join(names, " + "), workerTimeoutHeadroomSec))
+	}
+
+	if latest, prov := c.Cache.LatestPriceTTLSec, c.Cache.ExchangeProviderPriceTTLSec; latest > 0 && prov > latest && !c.Cache.AllowProviderTTLAboveLatest {
+		errs = append(errs, fmt.Errorf("cache.exchange_provider_price_ttl_sec=%d must not exceed cache.latest_price_ttl_sec=%d: "+
+			"lower it to %d, raise cache.latest_price_ttl_sec to %d, or set cache.allow_provider_ttl_above_latest",
+			prov, latest, latest, prov))
+	}
+	return errs
+}
+
 // Validate checks that all required configuration fields are set and valid.
 func (c *Config) Validate() error {
 	var errs []error
 	if c.Server.Port <= 0 {
 		errs = append(errs, fmt.Errorf("server.port must be positive, got %d", c.Server.Port))
 	}
+	if c.Server.InternalPort < 0 {
+		errs = append(errs, fmt.Errorf("server.internal_port must be non-negative, got %d", c.Server.InternalPort))
+	} else if c.Server.InternalPort != 0 && c.Server.InternalPort == c.Server.Port {
+		errs = append(errs, fmt.Errorf("server.internal_port must differ from server.port (%d)", c.Server.Port))
+	}
+	if c.Server.TimestampPrecision < 0 || c.Server.TimestampPrecision > 9 {
+		errs = append(errs, fmt.Errorf("server.timestamp_precision must be between 0 and 9, got %d", c.Server.TimestampPrecision))
+	}
+	if c.Server.QueueRetryAfterSec <= 0 {
+		errs = append(errs, fmt.Errorf("server.queue_retry_after_sec must be positive, got %d", c.Server.QueueRetryAfterSec))
+	}
+	if c.Logging.MaxFieldBytes < 0 {
+		errs = append(errs, fmt.Errorf("logging.max_field_bytes must not be negative, got %d", c.Logging.MaxFieldBytes))
+	}
 
 	if c.Database.Host == "" {
 		errs = append(errs, fmt.Errorf("database.host is required"))
@@ -178,7 +700,44 @@ func (c *Config) Validate() error {
 	if c.Redis.CacheAddr == "" {
 		errs = append(errs, fmt.Errorf("redis.cache_addr is required (set QUOTESVC_REDIS_CACHE_ADDR)"))
 	}
+	if !namespacePattern.MatchString(c.Redis.Namespace) {
+		errs = append(errs, fmt.Errorf("redis.namespace must match %s, got %q", namespacePattern, c.Redis.Namespace))
+	}
 
+	errs = append(errs, c.HTTPClient.validate()...)
+	errs = append(errs, c.Signing.validate()...)
+
+	if c.CircuitBreaker.FailureThreshold < 0 {
+		errs = append(errs, fmt.Errorf("circuit_breaker.failure_threshold must be non-negative, got %d", c.CircuitBreaker.FailureThreshold))
+	}
+	if c.CircuitBreaker.FailureThreshold > 0 && c.CircuitBreaker.OpenSec <= 0 {
+		errs = append(errs, fmt.Errorf("circuit_breaker.open_sec must be positive, got %d", c.CircuitBreaker.OpenSec))
+	}
+	if pw := c.ProviderWarmup; pw.Enabled {
+		if c.CircuitBreaker.FailureThreshold == 0 {
+			errs = append(errs, fmt.Errorf("provider_warmup.enabled requires circuit_breaker.failure_threshold, whose close transition triggers it"))
+		}
+		if pw.DelayMs < 0 {
+			errs = append(errs, fmt.Errorf("provider_warmup.delay_ms must not be negative, got %d", pw.DelayMs))
+		}
+		for _, p := range pw.Pairs {
+			if !pairKeyPattern.MatchString(strings.ToUpper(p)) {
+				errs = append(errs, fmt.Errorf("provider_warmup.pairs: %q must have the form BASE/QUOTE", p))
+			}
+		}
+	}
+	switch c.ProviderSelection.Strategy {
+	case ProviderStrategyFixed, ProviderStrategyAdaptive:
+	default:
+		errs = append(errs, fmt.Errorf("provider_selection.strategy must be %q or %q, got %q",
+			ProviderStrategyFixed, ProviderStrategyAdaptive, c.ProviderSelection.Strategy))
+	}
+	if c.ProviderSelection.Window <= 0 {
+		errs = append(errs, fmt.Errorf("provider_selection.window must be positive, got %d", c.ProviderSelection.Window))
+	}
+	if c.ProviderSelection.ExplorePct < 0 || c.ProviderSelection.ExplorePct > 100 {
+		errs = append(errs, fmt.Errorf("provider_selection.explore_pct must be between 0 and 100, got %v", c.ProviderSelection.ExplorePct))
+	}
 	if c.Worker.Concurrency <= 0 {
 		errs = append(errs, fmt.Errorf("worker.concurrency must be positive, got %d", c.Worker.Concurrency))
 	}
@@ -191,6 +750,9 @@ func (c *Config) Validate() error {
 	if c.Worker.CheckIntervalSec <= 0 {
 		errs = append(errs, fmt.Errorf("worker.check_interval_sec must be positive, got %d", c.Worker.CheckIntervalSec))
 	}
+	if c.Worker.EnqueueTimeoutMs <= 0 {
+		errs = append(errs, fmt.Errorf("worker.enqueue_timeout_ms must be positive, got %d", c.Worker.EnqueueTimeoutMs))
+	}
 
 	if c.Cache.LatestPriceTTLSec <= 0 {
 		errs = append(errs, fmt.Errorf("cache.latest_price_ttl_sec must be positive, got %d", c.Cache.LatestPriceTTLSec))
@@ -198,6 +760,187 @@ func (c *Config) Validate() error {
 	if c.Cache.ExchangeProviderPriceTTLSec <= 0 {
 		errs = append(errs, fmt.Errorf("cache.exchange_provider_price_ttl_sec must be positive, got %d", c.Cache.ExchangeProviderPriceTTLSec))
 	}
+	if c.Cache.NegativeCacheTTLSec < 0 {
+		errs = append(errs, fmt.Errorf("cache.negative_cache_ttl_sec must be non-negative, got %d", c.Cache.NegativeCacheTTLSec))
+	}
+	if c.Cache.InverseEnabled {
+		if ttl, latest := c.Cache.InverseTTLSec, c.Cache.LatestPriceTTLSec; ttl <= 0 || ttl > latest {
+			errs = append(errs, fmt.Errorf("cache.inverse_ttl_sec must be between 1 and cache.latest_price_ttl_sec=%d, got %d", latest, ttl))
+		}
+		if c.Cache.InversePrecision < 1 || c.Cache.InversePrecision > 30 {
+			errs = append(errs, fmt.Errorf("cache.inverse_precision must be between 1 and 30, got %d", c.Cache.InversePrecision))
+		}
+	}
+
+	errs = append(errs, c.Webhooks.validate()...)
+
+	if c.Auth.Enabled {
+		if len(c.Auth.APIKeys) == 0 {
+			errs = append(errs, fmt.Errorf("auth.api_keys must not be empty when auth is enabled"))
+		}
+		names := make(map[string]bool, len(c.Auth.APIKeys))
+		for i, k := range c.Auth.APIKeys {
+			if k.Key == "" {
+				errs = append(errs, fmt.Errorf("auth.api_keys[%d].key is required", i))
+			}
+			if k.MonthlyQuota < 0 {
+				errs = append(errs, fmt.Errorf("auth.api_keys[%d].monthly_quota must be non-negative, got %d", i, k.MonthlyQuota))
+			}
+			if c.Auth.QuotaEnabled {
+				switch {
+				case k.Name == "":
+					errs = append(errs, fmt.Errorf("auth.api_keys[%d].name is required when quotas are enabled", i))
+				case names[k.Name]:
+					errs = append(errs, fmt.Errorf("auth.api_keys[%d].name %q is not unique", i, k.Name))
+				}
+				names[k.Name] = true
+			}
+			for _, s := range k.Scopes {
+				if _, ok := validScopes[s]; !ok {
+					errs = append(errs, fmt.Errorf("auth.api_keys[%d] has unknown scope %q", i, s))
+				}
+			}
+			for _, p := range k.Pairs {
+				if !pairKeyPattern.MatchString(p) {
+					errs = append(errs, fmt.Errorf("auth.api_keys[%d].pairs: %q must have the form BASE/QUOTE", i, p))
+				}
+			}
+			for _, b := range k.Bases {
+				if !currencyCodePattern.MatchString(b) {
+					errs = append(errs, fmt.Errorf("auth.api_keys[%d].bases: %q must be an upper-case currency code", i, b))
+				}
+			}
+		}
+	}
+
+	if c.Streaming.Enabled {
+		if c.Streaming.URL == "" {
+			errs = append(errs, fmt.Errorf("streaming_provider.url is required when streaming is enabled"))
+		}
+		if len(c.Streaming.Pairs) == 0 {
+			errs = append(errs, fmt.Errorf("streaming_provider.pairs must not be empty when streaming is enabled"))
+		}
+		if c.Streaming.MaxReconnectAttempts < 0 {
+			errs = append(errs, fmt.Errorf("streaming_provider.max_reconnect_attempts must be non-negative, got %d", c.Streaming.MaxReconnectAttempts))
+		}
+	}
+
+	for _, name := range c.Alerts.EnabledAlerts {
+		if _, ok := validAlertNames[name]; !ok {
+			errs = append(errs, fmt.Errorf("alerts.enabled_alerts has unknown alert %q", name))
+		}
+	}
+	if c.Alerts.ThresholdPct < 0 {
+		errs = append(errs, fmt.Errorf("alerts.threshold_pct must be non-negative, got %g", c.Alerts.ThresholdPct))
+	}
+	for pair, pct := range c.Alerts.PairThresholds {
+		if pct <= 0 {
+			errs = append(errs, fmt.Errorf("alerts.pair_thresholds[%s] must be positive, got %g", pair, pct))
+		}
+	}
+	if c.Alerts.RateMoveCooldownSec < 0 {
+		errs = append(errs, fmt.Errorf("alerts.rate_move_cooldown_sec must be non-negative, got %d", c.Alerts.RateMoveCooldownSec))
+	}
+
+	switch c.Events.Sink {
+	case EventSinkNone:
+	case EventSinkRedisStream:
+		if c.Events.Stream == "" {
+			errs = append(errs, fmt.Errorf("events.stream is required when events.sink is %q", EventSinkRedisStream))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("events.sink must be %q or %q, got %q", EventSinkNone, EventSinkRedisStream, c.Events.Sink))
+	}
+	if c.Worker.RefreshCooldownSec < 0 {
+		errs = append(errs, fmt.Errorf("worker.refresh_cooldown_sec must be non-negative, got %d", c.Worker.RefreshCooldownSec))
+	}
+	if c.Worker.MaxPending < 0 {
+		errs = append(errs, fmt.Errorf("worker.max_pending must be non-negative, got %d", c.Worker.MaxPending))
+	}
+	if c.Worker.PendingCountCacheMs <= 0 {
+		errs = append(errs, fmt.Errorf("worker.pending_count_cache_ms must be positive, got %d", c.Worker.PendingCountCacheMs))
+	}
+	if c.Worker.ArchivedCheckIntervalSec < 0 {
+		errs = append(errs, fmt.Errorf("worker.archived_check_interval_sec must be non-negative, got %d", c.Worker.ArchivedCheckIntervalSec))
+	}
+	errs = append(errs, c.validatePairs()...)
+	errs = append(errs, c.validateRelationships()...)
+
+	if c.Events.MaxLen < 0 {
+		errs = append(errs, fmt.Errorf("events.max_len must be non-negative, got %d", c.Events.MaxLen))
+	}
+
+	if c.Verification.Enabled {
+		if c.Verification.SpreadThresholdPct < 0 {
+			errs = append(errs, fmt.Errorf("verification.spread_threshold_pct must be non-negative, got %g", c.Verification.SpreadThresholdPct))
+		}
+		if c.Verification.MaxConcurrency <= 0 {
+			errs = append(errs, fmt.Errorf("verification.max_concurrency must be positive, got %d", c.Verification.MaxConcurrency))
+		}
+		if c.Verification.ProviderRequestsPerMin <= 0 {
+			errs = append(errs, fmt.Errorf("verification.provider_requests_per_min must be positive, got %d", c.Verification.ProviderRequestsPerMin))
+		}
+	}
+
+	errs = append(errs, c.Retention.validate()...)
+
+	if c.Freshness.Enabled {
+		if c.Freshness.IntervalSec <= 0 {
+			errs = append(errs, fmt.Errorf("freshness.interval_sec must be positive, got %d", c.Freshness.IntervalSec))
+		}
+		for _, p := range c.Freshness.Pairs {
+			if !pairKeyPattern.MatchString(strings.ToUpper(p)) {
+				errs = append(errs, fmt.Errorf("freshness.pairs: %q must have the form BASE/QUOTE", p))
+			}
+		}
+	}
+
+	// The admin trigger runs even when the startup run is disabled.
+	if c.Reconcile.GraceSec < 0 {
+		errs = append(errs, fmt.Errorf("reconcile.grace_sec must be non-negative, got %d", c.Reconcile.GraceSec))
+	}
+	if c.Reconcile.GiveUpSec < c.Reconcile.GraceSec {
+		errs = append(errs, fmt.Errorf("reconcile.give_up_sec must be at least grace_sec (%d), got %d", c.Reconcile.GraceSec, c.Reconcile.GiveUpSec))
+	}
+	if c.Reconcile.BatchSize <= 0 {
+		errs = append(errs, fmt.Errorf("reconcile.batch_size must be positive, got %d", c.Reconcile.BatchSize))
+	}
+	for _, p := range c.API.DefaultPairs {
+		if !pairKeyPattern.MatchString(strings.ToUpper(p)) {
+			errs = append(errs, fmt.Errorf("api.default_pairs: %q must have the form BASE/QUOTE", p))
+		}
+	}
+
+	if ph := c.PollHint; ph.Enabled {
+		if ph.MinMs <= 0 {
+			errs = append(errs, fmt.Errorf("poll_hint.min_ms must be positive, got %d", ph.MinMs))
+		}
+		if ph.MaxMs < ph.MinMs {
+			errs = append(errs, fmt.Errorf("poll_hint.max_ms must be at least min_ms (%d), got %d", ph.MinMs, ph.MaxMs))
+		}
+		if ph.DefaultMs < ph.MinMs || ph.DefaultMs > ph.MaxMs {
+			errs = append(errs, fmt.Errorf("poll_hint.default_ms must be between min_ms and max_ms, got %d", ph.DefaultMs))
+		}
+		if ph.Window <= 0 {
+			errs = append(errs, fmt.Errorf("poll_hint.window must be positive, got %d", ph.Window))
+		}
+		if ph.CacheMs < 0 {
+			errs = append(errs, fmt.Errorf("poll_hint.cache_ms must not be negative, got %d", ph.CacheMs))
+		}
+	}
 
 	return errors.Join(errs...)
 }
+
+var validAlertNames = map[string]struct{}{
+	"provider_failure": {},
+	"dlq_overflow":     {},
+	"queue_depth":      {},
+	"stuck_tasks":      {},
+}
+
+// namespacePattern is what redis.namespace may contain: short enough to keep keys
+// readable and without the ":" that separates it from the key.
+var namespacePattern = regexp.MustCompile(`^[a-z0-9_-]{0,16}$`)
+
+var validScopes = map[string]struct{}{"read": {}, "write": {}, "admin": {}}
@@ -0,0 +1,170 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateRelationships(t *testing.T) {
+	base := func() Config {
+		return Config{
+			ExchangeRateHost: ExchangeRateHostConfig{BaseURL: "https://api.exchangerate.host", APIKey: "key", Timeout: 5},
+			Frankfurter:      FrankfurterConfig{BaseURL: "https://api.frankfurter.dev/v1", Timeout: 5},
+			Worker:           WorkerConfig{TimeoutSec: 30},
+			Cache:            CacheConfig{LatestPriceTTLSec: 600, ExchangeProviderPriceTTLSec: 300},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(c *Config)
+		wantErr []string // Substrings of the joined errors; none means valid.
+	}{
+		{name: "defaults", modify: func(*Config) {}},
+		{
+			name:   "timeout exactly covers the providers",
+			modify: func(c *Config) { c.Worker.TimeoutSec = 15 },
+		},
+		{
+			name:    "timeout below the providers",
+			modify:  func(c *Config) { c.Worker.TimeoutSec = 14 },
+			wantErr: []string{"worker.timeout_sec=14 must be at least 15", "exchangerate_host.timeout_sec=5 + frankfurter.timeout_sec=5"},
+		},
+		{
+			name: "slow provider",
+			modify: func(c *Config) {
+				c.Frankfurter.Timeout = 40
+			},
+			wantErr: []string{"worker.timeout_sec=30 must be at least 50"},
+		},
+		{
+			name: "provider without an API key is not counted",
+			modify: func(c *Config) {
+				c.ExchangeRateHost.APIKey = ""
+				c.Worker.TimeoutSec = 10
+			},
+		},
+		{
+			name: "only the configured provider is listed",
+			modify: func(c *Config) {
+				c.ExchangeRateHost.APIKey = ""
+				c.Worker.TimeoutSec = 9
+			},
+			wantErr: []string{"worker.timeout_sec=9 must be at least 10: the sum of the provider timeouts (frankfurter.timeout_sec=5)"},
+		},
+		{
+			name:   "invalid timeout is left to Validate",
+			modify: func(c *Config) { c.Worker.TimeoutSec = 0 },
+		},
+		{
+			name:   "equal cache TTLs",
+			modify: func(c *Config) { c.Cache.ExchangeProviderPriceTTLSec = 600 },
+		},
+		{
+			name:    "provider TTL above the latest TTL",
+			modify:  func(c *Config) { c.Cache.ExchangeProviderPriceTTLSec = 900 },
+			wantErr: []string{"cache.exchange_provider_price_ttl_sec=900 must not exceed cache.latest_price_ttl_sec=600", "lower it to 600", "raise cache.latest_price_ttl_sec to 900"},
+		},
+		{
+			name: "provider TTL above the latest TTL when allowed",
+			modify: func(c *Config) {
+				c.Cache.ExchangeProviderPriceTTLSec = 900
+				c.Cache.AllowProviderTTLAboveLatest = true
+			},
+		},
+		{
+			name: "both violations",
+			modify: func(c *Config) {
+				c.Worker.TimeoutSec = 5
+				c.Cache.LatestPriceTTLSec = 60
+			},
+			wantErr: []string{"worker.timeout_sec=5", "cache.latest_price_ttl_sec=60"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := base()
+			tt.modify(&cfg)
+			errs := cfg.validateRelationships()
+			if len(tt.wantErr) == 0 {
+				if len(errs) != 0 {
+					t.Fatalf("expected no errors, got %v", errs)
+				}
+				return
+			}
+			var msgs []string
+			for _, err := range errs {
+				msgs = append(msgs, err.Error())
+			}
+			joined := strings.Join(msgs, "\n")
+			for _, want := range tt.wantErr {
+				if !strings.Contains(joined, want) {
+					t.Errorf("expected an error containing %q, got %q", want, joined)
+				}
+			}
+		})
+	}
+}
+
+func TestRetentionConfig_Validate(t *testing.T) {
+	valid := RetentionConfig{Enabled: true, Mode: RetentionModeSoftDelete, MaxAgeDays: 90, BatchSize: 1000, IntervalSec: 3600}
+
+	tests := []struct {
+		name             string
+		eventsMaxAgeDays int
+		wantErr          string
+	}{
+		{name: "events kept with their update", eventsMaxAgeDays: 0},
+		{name: "shorter events horizon", eventsMaxAgeDays: 30},
+		{name: "equal horizons", eventsMaxAgeDays: 90},
+		{name: "events outlive their update", eventsMaxAgeDays: 91,
+			wantErr: "retention.events_max_age_days=91 must not exceed retention.max_age_days=90"},
+		{name: "negative", eventsMaxAgeDays: -1, wantErr: "retention.events_max_age_days must be non-negative"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := valid
+			cfg.EventsMaxAgeDays = tt.eventsMaxAgeDays
+			errs := cfg.validate()
+			switch {
+			case tt.wantErr == "" && len(errs) != 0:
+				t.Errorf("expected no errors, got %v", errs)
+			case tt.wantErr != "" && (len(errs) != 1 || !strings.Contains(errs[0].Error(), tt.wantErr)):
+				t.Errorf("expected an error containing %q, got %v", tt.wantErr, errs)
+			}
+		})
+	}
+
+	disabled := valid
+	disabled.Enabled, disabled.EventsMaxAgeDays = false, 365
+	if errs := disabled.validate(); len(errs) != 0 {
+		t.Errorf("expected disabled retention not to be validated, got %v", errs)
+	}
+}
+
+func TestWebhookConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		wantErr bool
+	}{
+		{name: "disabled", key: ""},
+		{name: "short key", key: "change-me", wantErr: true},
+		{name: "one byte short", key: strings.Repeat("k", 31), wantErr: true},
+		{name: "minimum length", key: strings.Repeat("k", 32)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := WebhookConfig{SecretHashKey: tt.key}.validate()
+			switch {
+			case !tt.wantErr && len(errs) != 0:
+				t.Errorf("expected no errors, got %v", errs)
+			case tt.wantErr && (len(errs) != 1 || !strings.Contains(errs[0].Error(), "webhooks.secret_hash_key must be at least 32 bytes")):
+				t.Errorf("expected a secret_hash_key length error, got %v", errs)
+			}
+		})
+	}
+}
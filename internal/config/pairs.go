@@ -0,0 +1,73 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/go-viper/mapstructure/v2"
+)
+
+var (
+	pairKeyPattern      = regexp.MustCompile(`^[A-Z]{3}/[A-Z]{3}$`)
+	currencyCodePattern = regexp.MustCompile(`^[A-Z]{3}$`)
+)
+
+// DecodePairOverrides decodes the raw "pairs" section, rejecting unknown override
+// fields. Keys are upper-cased because viper lower-cases map keys.
+func DecodePairOverrides(raw any) (map[string]PairOverride, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	var decoded map[string]PairOverride
+	dec, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		ErrorUnused:      true,
+		WeaklyTypedInput: true,
+		Result:           &decoded,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := dec.Decode(raw); err != nil {
+		return nil, fmt.Errorf("pairs: %w", err)
+	}
+
+	pairs := make(map[string]PairOverride, len(decoded))
+	for key, o := range decoded {
+		pairs[strings.ToUpper(key)] = o
+	}
+	return pairs, nil
+}
+
+func (c *Config) validatePairs() []error {
+	var errs []error
+	for key, o := range c.Pairs {
+		if !pairKeyPattern.MatchString(key) {
+			errs = append(errs, fmt.Errorf("pairs: key %q must have the form BASE/QUOTE", key))
+			continue
+		}
+		if o.LatestPriceTTLSec != nil && *o.LatestPriceTTLSec <= 0 {
+			errs = append(errs, fmt.Errorf("pairs[%s].latest_price_ttl_sec must be positive, got %d", key, *o.LatestPriceTTLSec))
+		}
+		if o.CooldownSec != nil && *o.CooldownSec < 0 {
+			errs = append(errs, fmt.Errorf("pairs[%s].cooldown_sec must be non-negative, got %d", key, *o.CooldownSec))
+		}
+		switch o.Priority {
+		case "", PriorityHigh, PriorityDefault, PriorityLow:
+		default:
+			errs = append(errs, fmt.Errorf("pairs[%s].priority must be %q, %q or %q, got %q",
+				key, PriorityHigh, PriorityDefault, PriorityLow, o.Priority))
+		}
+		seen := make(map[string]bool, len(o.ProviderOrder))
+		for _, name := range o.ProviderOrder {
+			switch {
+			case name != ProviderExchangeRateHost && name != ProviderFrankfurter:
+				errs = append(errs, fmt.Errorf("pairs[%s].provider_order has unknown provider %q", key, name))
+			case seen[name]:
+				errs = append(errs, fmt.Errorf("pairs[%s].provider_order lists %q twice", key, name))
+			}
+			seen[name] = true
+		}
+	}
+	return errs
+}
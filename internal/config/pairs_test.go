@@ -0,0 +1,88 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDecodePairOverrides(t *testing.T) {
+	raw := map[string]any{
+		"eur/usd": map[string]any{
+			"latest_price_ttl_sec": 60,
+			"provider_order":       []any{"frankfurter"},
+			"priority":             "high",
+			"cooldown_sec":         "5",
+		},
+	}
+
+	pairs, err := DecodePairOverrides(raw)
+	if err != nil {
+		t.Fatalf("DecodePairOverrides: %v", err)
+	}
+	o, ok := pairs["EUR/USD"]
+	if !ok {
+		t.Fatalf("expected key EUR/USD, got %v", pairs)
+	}
+	if o.LatestPriceTTLSec == nil || *o.LatestPriceTTLSec != 60 {
+		t.Errorf("LatestPriceTTLSec = %v, want 60", o.LatestPriceTTLSec)
+	}
+	if o.CooldownSec == nil || *o.CooldownSec != 5 {
+		t.Errorf("CooldownSec = %v, want 5", o.CooldownSec)
+	}
+	if o.Priority != PriorityHigh {
+		t.Errorf("Priority = %q, want %q", o.Priority, PriorityHigh)
+	}
+	if len(o.ProviderOrder) != 1 || o.ProviderOrder[0] != ProviderFrankfurter {
+		t.Errorf("ProviderOrder = %v, want [%s]", o.ProviderOrder, ProviderFrankfurter)
+	}
+}
+
+func TestDecodePairOverrides_UnknownField(t *testing.T) {
+	raw := map[string]any{
+		"EUR/USD": map[string]any{"latest_price_ttl": 60},
+	}
+
+	_, err := DecodePairOverrides(raw)
+	if err == nil || !strings.Contains(err.Error(), "latest_price_ttl") {
+		t.Fatalf("expected error naming the unknown field, got %v", err)
+	}
+}
+
+func TestValidatePairs(t *testing.T) {
+	ttl := func(v int) *int { return &v }
+
+	tests := []struct {
+		name    string
+		pairs   map[string]PairOverride
+		wantErr string
+	}{
+		{
+			name:  "valid",
+			pairs: map[string]PairOverride{"EUR/USD": {LatestPriceTTLSec: ttl(60), Priority: PriorityHigh, ProviderOrder: []string{ProviderFrankfurter}}},
+		},
+		{name: "missing slash", pairs: map[string]PairOverride{"EURUSD": {}}, wantErr: "must have the form BASE/QUOTE"},
+		{name: "short code", pairs: map[string]PairOverride{"EU/USD": {}}, wantErr: "must have the form BASE/QUOTE"},
+		{name: "extra segment", pairs: map[string]PairOverride{"EUR/USD/GBP": {}}, wantErr: "must have the form BASE/QUOTE"},
+		{name: "non-positive ttl", pairs: map[string]PairOverride{"EUR/USD": {LatestPriceTTLSec: ttl(0)}}, wantErr: "latest_price_ttl_sec must be positive"},
+		{name: "negative cooldown", pairs: map[string]PairOverride{"EUR/USD": {CooldownSec: ttl(-1)}}, wantErr: "cooldown_sec must be non-negative"},
+		{name: "unknown priority", pairs: map[string]PairOverride{"EUR/USD": {Priority: "urgent"}}, wantErr: "priority must be"},
+		{name: "unknown provider", pairs: map[string]PairOverride{"EUR/USD": {ProviderOrder: []string{"ecb"}}}, wantErr: `unknown provider "ecb"`},
+		{name: "duplicate provider", pairs: map[string]PairOverride{"EUR/USD": {ProviderOrder: []string{ProviderFrankfurter, ProviderFrankfurter}}}, wantErr: "twice"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Config{Pairs: tt.pairs}
+			errs := c.validatePairs()
+			if tt.wantErr == "" {
+				if len(errs) != 0 {
+					t.Fatalf("expected no errors, got %v", errs)
+				}
+				return
+			}
+			if len(errs) != 1 || !strings.Contains(errs[0].Error(), tt.wantErr) {
+				t.Fatalf("expected one error containing %q, got %v", tt.wantErr, errs)
+			}
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package config
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/invopop/jsonschema"
+)
+
+// GenerateJSONSchema reflects Config into a JSON Schema describing config.yaml, for IDE
+// validation and documentation. Keys follow the mapstructure tags viper uses.
+func GenerateJSONSchema() ([]byte, error) {
+	r := &jsonschema.Reflector{
+		FieldNameTag: "mapstructure",
+		// Untagged fields (Server, Database, ...) are matched case-insensitively by viper
+		// and written in lower case in config.yaml.
+		KeyNamer: strings.ToLower,
+		// Every key has a default, so none is required in the file itself.
+		RequiredFromJSONSchemaTags: true,
+	}
+	schema := r.Reflect(&Config{})
+	schema.Title = "Quote service configuration"
+	return json.MarshalIndent(schema, "", "  ")
+}
@@ -0,0 +1,36 @@
+package config
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files")
+
+// TestGenerateJSONSchema_Golden catches accidental changes to the config structs. After an
+// intentional change, regenerate with: go test ./internal/config -run Golden -update
+func TestGenerateJSONSchema_Golden(t *testing.T) {
+	got, err := GenerateJSONSchema()
+	if err != nil {
+		t.Fatalf("GenerateJSONSchema: %v", err)
+	}
+	got = append(got, '\n')
+
+	golden := filepath.Join("testdata", "config.schema.json")
+	if *update {
+		if err := os.WriteFile(golden, got, 0o644); err != nil {
+			t.Fatalf("write golden file: %v", err)
+		}
+	}
+
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("read golden file: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("generated schema differs from %s; rerun with -update if the change is intentional", golden)
+	}
+}
@@ -0,0 +1,70 @@
+// Package events publishes quote update lifecycle events to external sinks.
+package events
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"quoteservice/internal/service"
+)
+
+// DefaultStream is the Redis stream quote events are appended to by default.
+const DefaultStream = "quotes:events"
+
+// RedisStreamPublisher appends quote events to a Redis stream with XADD. The stream
+// is trimmed approximately to maxLen entries; consumers read it with consumer groups.
+type RedisStreamPublisher struct {
+	client *redis.Client
+	stream string
+	maxLen int64
+}
+
+// NewRedisStreamPublisher creates a RedisStreamPublisher. An empty stream name selects
+// DefaultStream; a maxLen of 0 disables trimming.
+func NewRedisStreamPublisher(client *redis.Client, stream string, maxLen int64) *RedisStreamPublisher {
+	if stream == "" {
+		stream = DefaultStream
+	}
+	return &RedisStreamPublisher{client: client, stream: stream, maxLen: maxLen}
+}
+
+// PublishQuoteEvent implements service.QuoteEventPublisher. All values are strings;
+// price, error, error_code and rate_timestamp are empty when they do not apply to the status, and
+// provider is empty unless the update was forced through a named provider.
+func (p *RedisStreamPublisher) PublishQuoteEvent(ctx context.Context, ev service.QuoteUpdateEvent) error {
+	args := &redis.XAddArgs{
+		Stream: p.stream,
+		MaxLen: p.maxLen,
+		Approx: p.maxLen > 0,
+		Values: []any{
+			"update_id", ev.UpdateID,
+			"pair", ev.Base + "/" + ev.Quote,
+			"base", ev.Base,
+			"quote", ev.Quote,
+			"status", string(ev.Status),
+			"price", ev.Price,
+			"error", ev.Error,
+			"error_code", string(ev.ErrorCode),
+			"source", ev.Source,
+			"provider", ev.Provider,
+			"rate_timestamp", formatTime(ev.RateTimestamp),
+			"occurred_at", formatTime(ev.OccurredAt),
+		},
+	}
+	if err := p.client.XAdd(ctx, args).Err(); err != nil {
+		return fmt.Errorf("xadd %s: %w", p.stream, err)
+	}
+	return nil
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+var _ service.QuoteEventPublisher = (*RedisStreamPublisher)(nil)
@@ -0,0 +1,107 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"quoteservice/internal/repository"
+	"quoteservice/internal/service"
+)
+
+func newTestClient(t *testing.T) *redis.Client {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	return redis.NewClient(&redis.Options{Addr: mr.Addr()})
+}
+
+func TestRedisStreamPublisher_FieldEncoding(t *testing.T) {
+	ctx := context.Background()
+	rdb := newTestClient(t)
+	p := NewRedisStreamPublisher(rdb, "", 0)
+
+	rateAt := time.Date(2024, 6, 14, 0, 0, 0, 0, time.UTC)
+	occurredAt := time.Date(2024, 6, 14, 10, 15, 30, 123000000, time.FixedZone("CEST", 2*3600))
+	require.NoError(t, p.PublishQuoteEvent(ctx, service.QuoteUpdateEvent{
+		UpdateID:      "123e4567-e89b-12d3-a456-426614174000",
+		Base:          "EUR",
+		Quote:         "MXN",
+		Status:        repository.StatusSuccess,
+		Price:         "18.7543",
+		Source:        service.UpdateSourceProvider,
+		RateTimestamp: rateAt,
+		OccurredAt:    occurredAt,
+	}))
+	require.NoError(t, p.PublishQuoteEvent(ctx, service.QuoteUpdateEvent{
+		UpdateID:   "223e4567-e89b-12d3-a456-426614174000",
+		Base:       "EUR",
+		Quote:      "MXN",
+		Status:     repository.StatusFailed,
+		Error:      "all providers failed",
+		ErrorCode:  repository.ErrorCodeProviderUnavailable,
+		Source:     service.UpdateSourceProvider,
+		OccurredAt: occurredAt,
+	}))
+
+	entries, err := rdb.XRange(ctx, DefaultStream, "-", "+").Result()
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+
+	assert.Equal(t, map[string]any{
+		"update_id":      "123e4567-e89b-12d3-a456-426614174000",
+		"pair":           "EUR/MXN",
+		"base":           "EUR",
+		"quote":          "MXN",
+		"status":         "SUCCESS",
+		"price":          "18.7543",
+		"error":          "",
+		"error_code":     "",
+		"source":         "provider",
+		"provider":       "",
+		"rate_timestamp": "2024-06-14T00:00:00Z",
+		"occurred_at":    "2024-06-14T08:15:30.123Z",
+	}, entries[0].Values)
+
+	assert.Equal(t, "FAILED", entries[1].Values["status"])
+	assert.Equal(t, "", entries[1].Values["price"])
+	assert.Equal(t, "all providers failed", entries[1].Values["error"])
+	assert.Equal(t, "provider_unavailable", entries[1].Values["error_code"])
+	assert.Equal(t, "", entries[1].Values["rate_timestamp"])
+}
+
+func TestRedisStreamPublisher_Trims(t *testing.T) {
+	ctx := context.Background()
+	rdb := newTestClient(t)
+	p := NewRedisStreamPublisher(rdb, "test:events", 3)
+
+	for i := range 10 {
+		require.NoError(t, p.PublishQuoteEvent(ctx, service.QuoteUpdateEvent{
+			UpdateID: fmt.Sprintf("id-%d", i),
+			Status:   repository.StatusSuccess,
+		}))
+	}
+
+	n, err := rdb.XLen(ctx, "test:events").Result()
+	require.NoError(t, err)
+	// miniredis trims exactly; real Redis may keep a few more entries with MAXLEN ~.
+	assert.Equal(t, int64(3), n)
+
+	last, err := rdb.XRevRangeN(ctx, "test:events", "+", "-", 1).Result()
+	require.NoError(t, err)
+	assert.Equal(t, "id-9", last[0].Values["update_id"])
+}
+
+func TestRedisStreamPublisher_Error(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	mr.Close()
+
+	err := NewRedisStreamPublisher(rdb, "", 0).PublishQuoteEvent(context.Background(), service.QuoteUpdateEvent{})
+	assert.ErrorContains(t, err, "xadd quotes:events")
+}
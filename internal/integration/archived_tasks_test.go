@@ -0,0 +1,199 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	"quoteservice/internal/metrics"
+	"quoteservice/internal/repository"
+	"quoteservice/internal/service"
+	"quoteservice/internal/testkit"
+	"quoteservice/internal/worker"
+)
+
+// archivedTasksRedisDB keeps the archived tasks apart from the other tests' keys.
+const archivedTasksRedisDB = 6
+
+// recoveringProvider fails until recovered is set.
+type recoveringProvider struct {
+	recovered atomic.Bool
+}
+
+func (p *recoveringProvider) GetRate(context.Context, string, string) (string, time.Time, error) {
+	if !p.recovered.Load() {
+		return "", time.Time{}, errors.New("provider timeout")
+	}
+	return "18.7543", time.Now().UTC(), nil
+}
+
+func TestArchivedTasks_RetryAfterProviderRecovers(t *testing.T) {
+	t.Cleanup(metrics.WorkerArchivedTasks.Reset)
+	ctx := testContext(t)
+	repo := repository.NewPostgresQuoteRepository(newIsolatedDB(t))
+	logger := zap.NewNop().Sugar()
+
+	redisOpt := asynq.RedisClientOpt{Addr: testkit.Global().RedisAddr(), DB: archivedTasksRedisDB}
+	rdb := redis.NewClient(&redis.Options{Addr: redisOpt.Addr, DB: archivedTasksRedisDB})
+	t.Cleanup(func() {
+		_ = rdb.FlushDB(context.Background()).Err()
+		_ = rdb.Close()
+	})
+	if err := rdb.FlushDB(ctx).Err(); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	client := asynq.NewClient(redisOpt)
+	t.Cleanup(func() { _ = client.Close() })
+	insp := asynq.NewInspector(redisOpt)
+	t.Cleanup(func() { _ = insp.Close() })
+
+	prov := &recoveringProvider{}
+	svc := service.NewQuoteService(service.QuoteServiceDeps{
+		Repo:     repo,
+		Provider: prov,
+		Enqueuer: worker.NewAsynqEnqueuer(client, 0, 10*time.Second, 2*time.Second, ""),
+		Logger:   logger,
+	})
+	pool := worker.NewPool(redisOpt, asynq.Config{
+		Queues:            worker.Queues(""),
+		TaskCheckInterval: 50 * time.Millisecond,
+		IsFailure:         worker.IsFailure,
+		LogLevel:          asynq.FatalLevel,
+	}, worker.PoolConfig{Concurrency: 1, TaskTimeout: 10 * time.Second},
+		asynq.HandlerFunc(worker.NewQuoteUpdateHandler(svc, logger)), nil, logger)
+	if err := pool.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	t.Cleanup(pool.Shutdown)
+
+	collector := worker.NewArchivedTaskCollector(insp, "", time.Minute, logger)
+	if err := collector.Collect(); err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+
+	res, err := svc.RequestQuoteUpdate(ctx, "EUR/MXN", service.UpdateOptions{})
+	if err != nil {
+		t.Fatalf("RequestQuoteUpdate: %v", err)
+	}
+
+	// With max_retry 0 the first failure archives the task.
+	archived := worker.NewArchivedTasks(insp, svc, "", logger)
+	var tasks []worker.QueuedTask
+	waitFor(t, "the task to be archived", func() bool {
+		tasks, _, err = archived.ListArchived(ctx, 0, 10)
+		return err == nil && len(tasks) == 1
+	})
+	task := tasks[0]
+	if task.Payload.UpdateID != res.UpdateID || task.LastErr == "" {
+		t.Errorf("Unexpected archived task %+v", task)
+	}
+	if rec, err := repo.GetByID(ctx, res.UpdateID); err != nil || rec.Status != repository.StatusFailed {
+		t.Fatalf("Expected a FAILED record, got %+v (err %v)", rec, err)
+	}
+
+	if err := collector.Collect(); err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	if got := testutil.ToFloat64(metrics.WorkerArchivedTasks.WithLabelValues("default")); got != 1 {
+		t.Errorf("Expected 1 archived task in the default queue, got %v", got)
+	}
+
+	prov.recovered.Store(true)
+	if _, err := archived.RetryArchived(ctx, task.ID); err != nil {
+		t.Fatalf("RetryArchived: %v", err)
+	}
+	waitFor(t, "the retried update to succeed", func() bool {
+		rec, err := repo.GetByID(ctx, res.UpdateID)
+		return err == nil && rec.Status == repository.StatusSuccess
+	})
+
+	events, err := repo.GetStatusEvents(ctx, res.UpdateID)
+	if err != nil {
+		t.Fatalf("GetStatusEvents: %v", err)
+	}
+	var statuses []repository.Status
+	for _, ev := range events {
+		statuses = append(statuses, ev.Status)
+	}
+	want := []repository.Status{repository.StatusPending, repository.StatusRunning, repository.StatusFailed,
+		repository.StatusPending, repository.StatusRunning, repository.StatusSuccess}
+	if len(statuses) != len(want) {
+		t.Fatalf("Expected events %v, got %v", want, statuses)
+	}
+	for i := range want {
+		if statuses[i] != want[i] {
+			t.Fatalf("Expected events %v, got %v", want, statuses)
+		}
+	}
+
+	if tasks, _, err := archived.ListArchived(ctx, 0, 10); err != nil || len(tasks) != 0 {
+		t.Errorf("Expected no archived tasks after the retry, got %+v (err %v)", tasks, err)
+	}
+}
+
+// TestResetUpdate_PairInFlight retries a FAILED update after a newer update of its pair
+// was requested: uniq_quotes_pair_pending rejects the reset, which must surface as a
+// conflict naming the in-flight update and leave both records unchanged.
+func TestResetUpdate_PairInFlight(t *testing.T) {
+	t.Parallel()
+	ctx := testContext(t)
+	repo := NewIsolatedRepo(t)
+	svc := service.NewQuoteService(service.QuoteServiceDeps{Repo: repo, Logger: zap.NewNop().Sugar()})
+	pair := repository.Pair{Base: "EUR", Quote: "MXN"}
+
+	failed := uuid.New().String()
+	if _, err := repo.CreateUpdate(ctx, pair, failed, repository.OriginAPI); err != nil {
+		t.Fatalf("CreateUpdate: %v", err)
+	}
+	if err := repo.MarkFailed(ctx, failed, 1, repository.ErrorCodeProviderUnavailable, "provider down"); err != nil {
+		t.Fatalf("MarkFailed: %v", err)
+	}
+	pending := uuid.New().String()
+	if _, err := repo.CreateUpdate(ctx, pair, pending, repository.OriginAPI); err != nil {
+		t.Fatalf("CreateUpdate: %v", err)
+	}
+
+	err := repo.MarkPending(ctx, failed, 2)
+	var pairInFlight *repository.PairInFlightError
+	if !errors.As(err, &pairInFlight) || pairInFlight.InFlightID != pending {
+		t.Fatalf("MarkPending: expected a *PairInFlightError naming %s, got %v", pending, err)
+	}
+
+	err = svc.ResetUpdate(ctx, failed)
+	var inFlight *service.UpdateInFlightError
+	if !errors.As(err, &inFlight) || inFlight.UpdateID != failed || inFlight.InFlightID != pending {
+		t.Fatalf("ResetUpdate: expected an *UpdateInFlightError naming %s, got %v", pending, err)
+	}
+
+	for id, want := range map[string]repository.Status{failed: repository.StatusFailed, pending: repository.StatusPending} {
+		q, err := repo.GetByID(ctx, id)
+		if err != nil {
+			t.Fatalf("GetByID: %v", err)
+		}
+		if q.Status != want {
+			t.Errorf("Expected update %s to stay %s, got %s", id, want, q.Status)
+		}
+	}
+}
+
+// waitFor polls cond until it holds, failing the test after 10 seconds.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
@@ -0,0 +1,191 @@
+//go:build integration
+
+package integration
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	"go.uber.org/zap"
+
+	"quoteservice/internal/config"
+	"quoteservice/internal/provider"
+	"quoteservice/internal/repository"
+	"quoteservice/internal/service"
+	"quoteservice/internal/testkit/fakeprovider"
+	"quoteservice/internal/worker"
+)
+
+// newFakeProviders starts an exchangerate.host and a Frankfurter fake serving EUR/USD,
+// and returns a facade with exchangerate.host as the primary.
+func newFakeProviders(t *testing.T) (primary, secondary *fakeprovider.Server, facade provider.RatesProvider) {
+	t.Helper()
+	primary = fakeprovider.New(fakeprovider.ExchangeRateHost)
+	secondary = fakeprovider.New(fakeprovider.Frankfurter)
+	t.Cleanup(primary.Close)
+	t.Cleanup(secondary.Close)
+
+	primary.SetRate("EUR", "USD", "1.0850")
+	secondary.SetRate("EUR", "USD", "1.0900")
+
+	facade = provider.NewExchangeProviderFacade(
+		provider.NewExchangeRateHostProvider(primary.URL(), "test-key", 1),
+		provider.NewFrankfurterProvider(secondary.URL(), 1),
+	)
+	return primary, secondary, facade
+}
+
+func TestFacadeFallback_FaultInjection(t *testing.T) {
+	tests := []struct {
+		name   string
+		inject func(s *fakeprovider.Server)
+	}{
+		{"server error", func(s *fakeprovider.Server) { s.FailSequence(http.StatusInternalServerError) }},
+		{"rate limited", func(s *fakeprovider.Server) {
+			s.SetFaults(fakeprovider.Faults{RateLimited: true, RetryAfterSec: 30})
+		}},
+		{"malformed body", func(s *fakeprovider.Server) { s.SetFaults(fakeprovider.Faults{MalformedBody: true}) }},
+		{"latency above timeout", func(s *fakeprovider.Server) { s.SetFaults(fakeprovider.Faults{LatencyMs: 1500}) }},
+		{"error rate 100%", func(s *fakeprovider.Server) { s.SetFaults(fakeprovider.Faults{ErrorRate: 1}) }},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := testContext(t)
+			primary, secondary, facade := newFakeProviders(t)
+			tc.inject(primary)
+
+			rate, _, err := facade.GetRate(ctx, "EUR", "USD")
+			if err != nil {
+				t.Fatalf("GetRate: %v", err)
+			}
+			if rate != "1.0900" {
+				t.Fatalf("expected fallback rate 1.0900, got %s", rate)
+			}
+			if primary.Calls() != 1 || secondary.Calls() != 1 {
+				t.Fatalf("expected one call per provider, got primary=%d secondary=%d", primary.Calls(), secondary.Calls())
+			}
+		})
+	}
+}
+
+func TestFacadeFallback_AllProvidersDown(t *testing.T) {
+	ctx := testContext(t)
+	primary, secondary, facade := newFakeProviders(t)
+	primary.FailSequence(http.StatusBadGateway)
+	secondary.FailSequence(http.StatusServiceUnavailable)
+
+	if _, _, err := facade.GetRate(ctx, "EUR", "USD"); err == nil {
+		t.Fatal("expected error when all providers fail, got nil")
+	}
+
+	// Sequences are exhausted: the primary serves again.
+	rate, _, err := facade.GetRate(ctx, "EUR", "USD")
+	if err != nil {
+		t.Fatalf("GetRate after recovery: %v", err)
+	}
+	if rate != "1.0850" {
+		t.Fatalf("expected primary rate 1.0850, got %s", rate)
+	}
+}
+
+func TestFakeProvider_ControlEndpoint(t *testing.T) {
+	ctx := testContext(t)
+	primary, _, _ := newFakeProviders(t)
+	prov := provider.NewExchangeRateHostProvider(primary.URL(), "test-key", 1)
+
+	body, _ := json.Marshal(map[string][]int{"statuses": {http.StatusInternalServerError, http.StatusInternalServerError}})
+	resp, err := http.Post(primary.URL()+fakeprovider.ControlPrefix+"/sequence", "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("control request: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("expected 204 from control endpoint, got %d", resp.StatusCode)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, _, err := prov.GetRate(ctx, "EUR", "USD"); err == nil {
+			t.Fatalf("attempt %d: expected injected failure, got nil", i+1)
+		}
+	}
+	if _, _, err := prov.GetRate(ctx, "EUR", "USD"); err != nil {
+		t.Fatalf("expected success after the failure sequence, got %v", err)
+	}
+}
+
+// TestWorkerRetry_FailTwiceThenSucceed drives the worker handler the way asynq
+// retries would: provider failures surface as handler errors (retried), and the
+// third delivery completes the record.
+func TestWorkerRetry_FailTwiceThenSucceed(t *testing.T) {
+	resetTestData(t)
+	ctx := testContext(t)
+
+	fake := fakeprovider.New(fakeprovider.Frankfurter)
+	t.Cleanup(fake.Close)
+	fake.SetRate("EUR", "USD", "1.0850")
+	fake.FailSequence(http.StatusInternalServerError, http.StatusInternalServerError)
+
+	repo := repository.NewPostgresQuoteRepository(testDB)
+	logger := zap.NewNop().Sugar()
+	svc := service.NewQuoteService(service.QuoteServiceDeps{
+		Repo:        repo,
+		Provider:    provider.NewFrankfurterProvider(fake.URL(), 1),
+		Validator:   service.NewValidator(),
+		Cache:       testRDB,
+		Logger:      logger,
+		CacheConfig: config.CacheConfig{LatestPriceTTLSec: 3600, ExchangeProviderPriceTTLSec: 3600},
+	})
+	handler := worker.NewQuoteUpdateHandler(svc, logger)
+
+	id := uuid.New().String()
+	if _, err := repo.CreateUpdate(ctx, repository.Pair{Base: "EUR", Quote: "USD"}, id, repository.OriginAPI); err != nil {
+		t.Fatalf("CreateUpdate: %v", err)
+	}
+	payload, _ := json.Marshal(service.UpdateQuotePayload{UpdateID: id, Pair: service.Pair{Base: "EUR", Quote: "USD"}})
+	task := asynq.NewTask(service.TaskTypeUpdateQuote, payload)
+
+	for attempt := 1; attempt <= 2; attempt++ {
+		if err := handler(ctx, task); err == nil {
+			t.Fatalf("attempt %d: expected retryable error, got nil", attempt)
+		}
+		assertStatus(ctx, t, repo, id, repository.StatusFailed)
+	}
+
+	if err := handler(ctx, task); err != nil {
+		t.Fatalf("attempt 3: expected success, got %v", err)
+	}
+	assertStatus(ctx, t, repo, id, repository.StatusSuccess)
+	if fake.Calls() != 3 {
+		t.Fatalf("expected 3 provider calls, got %d", fake.Calls())
+	}
+}
+
+func TestWorkerRetry_MalformedPayloadIsNotRetried(t *testing.T) {
+	ctx := testContext(t)
+	handler := worker.NewQuoteUpdateHandler(nil, zap.NewNop().Sugar())
+
+	task := asynq.NewTask(service.TaskTypeUpdateQuote, []byte("{not json"))
+	if err := handler(ctx, task); err != nil {
+		t.Fatalf("expected malformed payload to be dropped without retry, got %v", err)
+	}
+}
+
+func assertStatus(ctx context.Context, t *testing.T, repo repository.QuoteRepository, id string, want repository.Status) {
+	t.Helper()
+	q, err := repo.GetByID(ctx, id)
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	if q == nil {
+		t.Fatal("expected record, got nil")
+	}
+	if q.Status != want {
+		t.Fatalf("expected %s, got %s", want, q.Status)
+	}
+}
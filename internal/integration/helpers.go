@@ -16,13 +16,13 @@ var (
 	testRDB *redis.Client
 )
 
-// resetTestData truncates the quotes table and flushes the current Redis database.
+// resetTestData truncates the quotes and webhooks tables and flushes the current Redis database.
 func resetTestData(t *testing.T) {
 	t.Helper()
 
-	_, err := testDB.ExecContext(context.Background(), "TRUNCATE TABLE quotes CASCADE")
+	_, err := testDB.ExecContext(context.Background(), "TRUNCATE TABLE quotes, webhooks CASCADE")
 	if err != nil {
-		t.Fatalf("failed to truncate quotes table: %v", err)
+		t.Fatalf("failed to truncate tables: %v", err)
 	}
 
 	if err := testRDB.FlushDB(context.Background()).Err(); err != nil {
@@ -7,9 +7,7 @@ import (
 	"database/sql"
 	"testing"
 
-	_ "github.com/jackc/pgx/v5/stdlib"
 	"github.com/redis/go-redis/v9"
-	"go.uber.org/zap"
 
 	"quoteservice/internal/repository"
 	"quoteservice/internal/testkit"
@@ -18,16 +16,10 @@ import (
 func TestMain(m *testing.M) {
 	testkit.Run(m, func() error {
 		var err error
-		testDB, err = sql.Open("pgx", testkit.Global().PostgresDSN())
+		testDB, err = testkit.MigrateAndConnect(context.Background(), testkit.Global().PostgresDSN())
 		if err != nil {
 			return err
 		}
-		if err := testDB.Ping(); err != nil {
-			return err
-		}
-		if err := repository.RunMigrations(testDB, zap.NewNop().Sugar()); err != nil {
-			return err
-		}
 
 		testRDB = redis.NewClient(&redis.Options{
 			Addr: testkit.Global().RedisAddr(),
@@ -35,3 +27,16 @@ func TestMain(m *testing.M) {
 		return testRDB.Ping(context.Background()).Err()
 	})
 }
+
+// NewIsolatedRepo returns a quote repository backed by a freshly migrated schema of
+// its own, so the calling test can run with t.Parallel.
+func NewIsolatedRepo(t *testing.T) repository.QuoteRepository {
+	t.Helper()
+	return repository.NewPostgresQuoteRepository(newIsolatedDB(t))
+}
+
+// newIsolatedDB returns a handle to a freshly migrated schema that is dropped when t finishes.
+func newIsolatedDB(t *testing.T) *sql.DB {
+	t.Helper()
+	return testkit.Global().NewTestSchema(testContext(t), t)
+}
@@ -0,0 +1,442 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"quoteservice/internal/repository"
+	"quoteservice/internal/testkit"
+)
+
+// schemaExpectation lists the objects a migration file must leave behind.
+type schemaExpectation struct {
+	tables   []string
+	columns  map[string]string // "table.column" -> format_type
+	defaults map[string]string // "table.column" -> default of a NOT NULL column
+	indexes  []string
+	triggers map[string]string // trigger -> table
+}
+
+// migrationSchema must have an entry for every embedded migration file.
+var migrationSchema = map[string]schemaExpectation{
+	"001_init.sql": {
+		tables: []string{"quotes"},
+		columns: map[string]string{
+			"quotes.id":           "uuid",
+			"quotes.base":         "character(3)",
+			"quotes.quote":        "character(3)",
+			"quotes.price":        "numeric", // Widened by 010.
+			"quotes.status":       "quotes_status",
+			"quotes.error":        "text",
+			"quotes.requested_at": "timestamp with time zone",
+			"quotes.updated_at":   "timestamp with time zone",
+		},
+		// idx_quotes_pair_time is replaced by idx_quotes_pair_live in 007.
+		indexes: []string{"uniq_quotes_pair_pending"},
+	},
+	"002_quotes_notify.sql": {
+		triggers: map[string]string{"quotes_updated_notify": "quotes"},
+	},
+	"003_quotes_rate_timestamp.sql": {
+		columns: map[string]string{"quotes.rate_timestamp": "timestamp with time zone"},
+	},
+	"004_webhooks.sql": {
+		tables: []string{"webhooks"},
+		columns: map[string]string{
+			"webhooks.id":          "uuid",
+			"webhooks.base":        "character(3)",
+			"webhooks.quote":       "character(3)",
+			"webhooks.url":         "text",
+			"webhooks.secret_hash": "text",
+			"webhooks.created_at":  "timestamp with time zone",
+		},
+	},
+	"005_quote_status_events.sql": {
+		tables: []string{"quote_status_events"},
+		columns: map[string]string{
+			"quote_status_events.id":        "bigint",
+			"quote_status_events.update_id": "uuid",
+			"quote_status_events.status":    "quotes_status",
+			"quote_status_events.at":        "timestamp with time zone",
+			"quote_status_events.detail":    "text",
+		},
+		indexes: []string{"idx_quote_status_events_update"},
+	},
+	"006_quote_verification.sql": {
+		columns: map[string]string{
+			"quotes.verify_min_price": "numeric", // Widened by 010.
+			"quotes.verify_max_price": "numeric",
+			"quotes.verify_spread":    "numeric(12,4)",
+			"quotes.verify_providers": "integer",
+		},
+	},
+	"007_quotes_archive.sql": {
+		tables: []string{"quotes_archive", "quote_status_events_archive"},
+		columns: map[string]string{
+			"quotes.archived_at":         "timestamp with time zone",
+			"quotes_archive.archived_at": "timestamp with time zone",
+		},
+		indexes: []string{
+			"idx_quotes_pair_live", "idx_quotes_retention",
+			"idx_quotes_archive_pair_time", "idx_quote_status_events_archive_update",
+		},
+	},
+	"008_quotes_version.sql": {
+		columns: map[string]string{
+			"quotes.version":         "bigint",
+			"quotes_archive.version": "bigint",
+		},
+	},
+	"009_quotes_origin.sql": {
+		columns: map[string]string{
+			"quotes.origin":         "quotes_origin",
+			"quotes_archive.origin": "quotes_origin",
+		},
+		defaults: map[string]string{
+			"quotes.origin":         "'api'::quotes_origin",
+			"quotes_archive.origin": "'api'::quotes_origin",
+		},
+	},
+	"010_quotes_price_precision.sql": {
+		columns: map[string]string{
+			"quotes.price":                    "numeric",
+			"quotes.verify_min_price":         "numeric",
+			"quotes.verify_max_price":         "numeric",
+			"quotes_archive.price":            "numeric",
+			"quotes_archive.verify_min_price": "numeric",
+			"quotes_archive.verify_max_price": "numeric",
+		},
+	},
+	"011_quotes_latest_rate_index.sql": {
+		indexes: []string{"idx_quotes_pair_latest_rate"},
+	},
+	"012_quotes_error_code.sql": {
+		columns: map[string]string{
+			"quotes.error_code":         "text",
+			"quotes_archive.error_code": "text",
+		},
+	},
+	"013_quotes_source.sql": {
+		columns: map[string]string{
+			"quotes.source":                    "text",
+			"quotes.fetch_duration_ms":         "integer",
+			"quotes_archive.source":            "text",
+			"quotes_archive.fetch_duration_ms": "integer",
+		},
+		indexes: []string{"idx_quotes_completed", "idx_quotes_archive_completed"},
+	},
+	"014_quotes_instance.sql": {
+		columns: map[string]string{
+			"quotes.instance_id":                      "text",
+			"quotes_archive.instance_id":              "text",
+			"quote_status_events.instance_id":         "text",
+			"quote_status_events_archive.instance_id": "text",
+		},
+	},
+	"015_quote_status_events_at.sql": {
+		indexes: []string{"idx_quote_status_events_at"},
+	},
+	"016_quotes_notify_rate_timestamp.sql": {
+		triggers: map[string]string{"quotes_updated_notify": "quotes"},
+	},
+}
+
+func TestMigrations_Schema(t *testing.T) {
+	ctx := testContext(t)
+
+	names, err := repository.MigrationNames()
+	if err != nil {
+		t.Fatalf("MigrationNames: %v", err)
+	}
+
+	for _, name := range names {
+		t.Run(name, func(t *testing.T) {
+			want, ok := migrationSchema[name]
+			if !ok {
+				t.Fatalf("no schema expectation for migration %s; add one to migrationSchema", name)
+			}
+			assertSchema(ctx, t, testDB, want)
+		})
+	}
+}
+
+func TestMigrations_Idempotent(t *testing.T) {
+	ctx := testContext(t)
+
+	before := countAppliedMigrations(ctx, t, testDB)
+	applied, err := repository.RunMigrations(testDB, zap.NewNop().Sugar(), repository.MigrationOptions{})
+	if err != nil {
+		t.Fatalf("second RunMigrations: %v", err)
+	}
+	if applied != 0 {
+		t.Errorf("expected no migrations applied on rerun, got %d", applied)
+	}
+	after := countAppliedMigrations(ctx, t, testDB)
+
+	if before != after {
+		t.Fatalf("expected %d applied migrations after rerun, got %d", before, after)
+	}
+	names, err := repository.MigrationNames()
+	if err != nil {
+		t.Fatalf("MigrationNames: %v", err)
+	}
+	if after != len(names) {
+		t.Fatalf("expected %d applied migrations, got %d", len(names), after)
+	}
+}
+
+func TestMigrations_ChecksumRecorded(t *testing.T) {
+	ctx := testContext(t)
+
+	rows, err := testDB.QueryContext(ctx, "SELECT version, checksum FROM schema_migrations")
+	if err != nil {
+		t.Fatalf("read schema_migrations: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var version, checksum string
+		if err := rows.Scan(&version, &checksum); err != nil {
+			t.Fatalf("scan: %v", err)
+		}
+		if len(checksum) != 64 {
+			t.Errorf("migration %s: expected sha256 hex checksum, got %q", version, checksum)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatalf("rows: %v", err)
+	}
+}
+
+func TestMigrations_ChecksumMismatch(t *testing.T) {
+	ctx := testContext(t)
+
+	names, err := repository.MigrationNames()
+	if err != nil {
+		t.Fatalf("MigrationNames: %v", err)
+	}
+	first := names[0]
+	var checksum string
+	if err := testDB.QueryRowContext(ctx, "SELECT checksum FROM schema_migrations WHERE version = $1", first).Scan(&checksum); err != nil {
+		t.Fatalf("read checksum: %v", err)
+	}
+	// Simulate the file being edited after it was applied.
+	if _, err := testDB.ExecContext(ctx, "UPDATE schema_migrations SET checksum = $2 WHERE version = $1", first, strings.Repeat("0", 64)); err != nil {
+		t.Fatalf("tamper checksum: %v", err)
+	}
+	t.Cleanup(func() {
+		if _, err := testDB.ExecContext(context.Background(), "UPDATE schema_migrations SET checksum = $2 WHERE version = $1", first, checksum); err != nil {
+			t.Errorf("restore checksum: %v", err)
+		}
+	})
+
+	_, err = repository.RunMigrations(testDB, zap.NewNop().Sugar(), repository.MigrationOptions{})
+	if !errors.Is(err, repository.ErrMigrationChecksumMismatch) {
+		t.Fatalf("expected ErrMigrationChecksumMismatch, got %v", err)
+	}
+
+	_, err = repository.RunMigrations(testDB, zap.NewNop().Sugar(), repository.MigrationOptions{SkipChecksumVerify: true})
+	if err != nil {
+		t.Fatalf("RunMigrations with SkipChecksumVerify: %v", err)
+	}
+}
+
+func TestMigrations_BackfillsMissingChecksum(t *testing.T) {
+	ctx := testContext(t)
+
+	if _, err := testDB.ExecContext(ctx, "UPDATE schema_migrations SET checksum = ''"); err != nil {
+		t.Fatalf("clear checksums: %v", err)
+	}
+	if _, err := repository.RunMigrations(testDB, zap.NewNop().Sugar(), repository.MigrationOptions{}); err != nil {
+		t.Fatalf("RunMigrations: %v", err)
+	}
+
+	var empty int
+	if err := testDB.QueryRowContext(ctx, "SELECT COUNT(*) FROM schema_migrations WHERE checksum = ''").Scan(&empty); err != nil {
+		t.Fatalf("count empty checksums: %v", err)
+	}
+	if empty != 0 {
+		t.Fatalf("expected all checksums backfilled, %d still empty", empty)
+	}
+}
+
+func TestCheckSchema(t *testing.T) {
+	ctx := testContext(t)
+
+	if err := repository.CheckSchema(ctx, testDB); err != nil {
+		t.Fatalf("CheckSchema on migrated DB: %v", err)
+	}
+
+	names, err := repository.MigrationNames()
+	if err != nil {
+		t.Fatalf("MigrationNames: %v", err)
+	}
+	last := names[len(names)-1]
+	var checksum string
+	if err := testDB.QueryRowContext(ctx, "SELECT checksum FROM schema_migrations WHERE version = $1", last).Scan(&checksum); err != nil {
+		t.Fatalf("read checksum: %v", err)
+	}
+	if _, err := testDB.ExecContext(ctx, "DELETE FROM schema_migrations WHERE version = $1", last); err != nil {
+		t.Fatalf("forget migration: %v", err)
+	}
+	t.Cleanup(func() {
+		if _, err := testDB.ExecContext(context.Background(),
+			"INSERT INTO schema_migrations (version, checksum) VALUES ($1, $2)",
+			last, checksum); err != nil {
+			t.Errorf("restore migration record: %v", err)
+		}
+	})
+
+	err = repository.CheckSchema(ctx, testDB)
+	if err == nil || !strings.Contains(err.Error(), last) {
+		t.Fatalf("expected error naming %s, got %v", last, err)
+	}
+}
+
+func TestPendingMigrations(t *testing.T) {
+	ctx := testContext(t)
+
+	pending, err := repository.PendingMigrations(ctx, testDB, repository.MigrationOptions{})
+	if err != nil {
+		t.Fatalf("PendingMigrations on migrated DB: %v", err)
+	}
+	if len(pending) != 0 {
+		t.Fatalf("expected no pending migrations, got %v", pending)
+	}
+
+	names, err := repository.MigrationNames()
+	if err != nil {
+		t.Fatalf("MigrationNames: %v", err)
+	}
+	first, last := names[0], names[len(names)-1]
+	var checksum string
+	if err := testDB.QueryRowContext(ctx, "SELECT checksum FROM schema_migrations WHERE version = $1", last).Scan(&checksum); err != nil {
+		t.Fatalf("read checksum: %v", err)
+	}
+	if _, err := testDB.ExecContext(ctx, "DELETE FROM schema_migrations WHERE version = $1", last); err != nil {
+		t.Fatalf("forget migration: %v", err)
+	}
+	t.Cleanup(func() {
+		if _, err := testDB.ExecContext(context.Background(),
+			"INSERT INTO schema_migrations (version, checksum) VALUES ($1, $2)",
+			last, checksum); err != nil {
+			t.Errorf("restore migration record: %v", err)
+		}
+	})
+
+	pending, err = repository.PendingMigrations(ctx, testDB, repository.MigrationOptions{})
+	if err != nil {
+		t.Fatalf("PendingMigrations: %v", err)
+	}
+	if len(pending) != 1 || pending[0] != last {
+		t.Fatalf("expected only %s pending, got %v", last, pending)
+	}
+	var applied int
+	if err := testDB.QueryRowContext(ctx, "SELECT COUNT(*) FROM schema_migrations WHERE version = $1", last).Scan(&applied); err != nil {
+		t.Fatalf("count migration: %v", err)
+	}
+	if applied != 0 {
+		t.Fatal("PendingMigrations must not apply migrations")
+	}
+
+	var firstChecksum string
+	if err := testDB.QueryRowContext(ctx, "SELECT checksum FROM schema_migrations WHERE version = $1", first).Scan(&firstChecksum); err != nil {
+		t.Fatalf("read checksum: %v", err)
+	}
+	if _, err := testDB.ExecContext(ctx, "UPDATE schema_migrations SET checksum = 'tampered' WHERE version = $1", first); err != nil {
+		t.Fatalf("tamper checksum: %v", err)
+	}
+	t.Cleanup(func() {
+		if _, err := testDB.ExecContext(context.Background(),
+			"UPDATE schema_migrations SET checksum = $2 WHERE version = $1", first, firstChecksum); err != nil {
+			t.Errorf("restore checksum: %v", err)
+		}
+	})
+	if _, err := repository.PendingMigrations(ctx, testDB, repository.MigrationOptions{}); !errors.Is(err, repository.ErrMigrationChecksumMismatch) {
+		t.Fatalf("expected ErrMigrationChecksumMismatch, got %v", err)
+	}
+}
+
+func TestMigrateAndConnect_FreshHandle(t *testing.T) {
+	ctx := testContext(t)
+
+	db, err := testkit.MigrateAndConnect(ctx, testkit.Global().PostgresDSN())
+	if err != nil {
+		t.Fatalf("MigrateAndConnect: %v", err)
+	}
+	defer db.Close()
+
+	ok, err := testkit.TableExists(ctx, db, "quotes")
+	if err != nil {
+		t.Fatalf("TableExists: %v", err)
+	}
+	if !ok {
+		t.Fatal("expected quotes table to exist")
+	}
+}
+
+func assertSchema(ctx context.Context, t *testing.T, db *sql.DB, want schemaExpectation) {
+	t.Helper()
+	for _, table := range want.tables {
+		ok, err := testkit.TableExists(ctx, db, table)
+		if err != nil {
+			t.Fatalf("TableExists(%s): %v", table, err)
+		}
+		if !ok {
+			t.Errorf("table %s does not exist", table)
+		}
+	}
+	for col, wantType := range want.columns {
+		table, column, _ := strings.Cut(col, ".")
+		got, err := testkit.ColumnType(ctx, db, table, column)
+		if err != nil {
+			t.Fatalf("ColumnType(%s): %v", col, err)
+		}
+		if got != wantType {
+			t.Errorf("column %s: expected type %q, got %q", col, wantType, got)
+		}
+	}
+	for col, wantDefault := range want.defaults {
+		table, column, _ := strings.Cut(col, ".")
+		notNull, def, err := testkit.ColumnDefault(ctx, db, table, column)
+		if err != nil {
+			t.Fatalf("ColumnDefault(%s): %v", col, err)
+		}
+		if !notNull || def != wantDefault {
+			t.Errorf("column %s: expected NOT NULL DEFAULT %s, got not null %v, default %q", col, wantDefault, notNull, def)
+		}
+	}
+	for _, index := range want.indexes {
+		ok, err := testkit.IndexExists(ctx, db, index)
+		if err != nil {
+			t.Fatalf("IndexExists(%s): %v", index, err)
+		}
+		if !ok {
+			t.Errorf("index %s does not exist", index)
+		}
+	}
+	for trigger, table := range want.triggers {
+		ok, err := testkit.TriggerExists(ctx, db, table, trigger)
+		if err != nil {
+			t.Fatalf("TriggerExists(%s): %v", trigger, err)
+		}
+		if !ok {
+			t.Errorf("trigger %s on %s does not exist", trigger, table)
+		}
+	}
+}
+
+func countAppliedMigrations(ctx context.Context, t *testing.T, db *sql.DB) int {
+	t.Helper()
+	var n int
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM schema_migrations").Scan(&n); err != nil {
+		t.Fatalf("count schema_migrations: %v", err)
+	}
+	return n
+}
@@ -0,0 +1,107 @@
+//go:build integration
+
+package integration
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"quoteservice/internal/api"
+	"quoteservice/internal/config"
+	"quoteservice/internal/repository"
+	"quoteservice/internal/service"
+)
+
+// recordingEnqueuer implements service.TaskEnqueuer by keeping the payloads.
+type recordingEnqueuer struct {
+	payloads []service.UpdateQuotePayload
+}
+
+func (e *recordingEnqueuer) EnqueueUpdateTask(_ context.Context, payload service.UpdateQuotePayload, _ service.TaskOptions) error {
+	e.payloads = append(e.payloads, payload)
+	return nil
+}
+
+// TestCurrencyCodeSpellings_ShareState requests updates and latest quotes for one pair
+// spelled in several ways and checks they end up on the same row, dedup record and
+// cache key.
+func TestCurrencyCodeSpellings_ShareState(t *testing.T) {
+	resetTestData(t)
+	ctx := testContext(t)
+
+	enqueuer := &recordingEnqueuer{}
+	svc := service.NewQuoteService(service.QuoteServiceDeps{
+		Repo:        repository.NewPostgresQuoteRepository(testDB),
+		Provider:    &fakeProvider{rate: "18.7543"},
+		Enqueuer:    enqueuer,
+		Cache:       testRDB,
+		Logger:      zap.NewNop().Sugar(),
+		CacheConfig: config.CacheConfig{LatestPriceTTLSec: 3600, ExchangeProviderPriceTTLSec: 3600},
+	})
+	update := api.HandleRequestUpdate(svc, nil, api.ErrorConfig{})
+	latest := api.HandleGetLatestQuote(svc, nil, false, api.ErrorConfig{})
+
+	var updateIDs []string
+	for _, pair := range []string{"eur/mxn", " EUR /MXN", "EUR/MXN"} {
+		body, _ := json.Marshal(api.UpdateRequest{Pair: pair})
+		w := httptest.NewRecorder()
+		update.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/quotes/update", bytes.NewReader(body)))
+		if w.Code != http.StatusAccepted {
+			t.Fatalf("%q: expected status 202, got %d: %s", pair, w.Code, w.Body.String())
+		}
+		var resp api.UpdateResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		updateIDs = append(updateIDs, resp.UpdateID)
+	}
+	for _, id := range updateIDs[1:] {
+		if id != updateIDs[0] {
+			t.Fatalf("expected every spelling to be deduplicated onto one update, got %v", updateIDs)
+		}
+	}
+
+	var rows int
+	if err := testDB.QueryRowContext(ctx, "SELECT count(*) FROM quotes WHERE base = 'EUR' AND quote = 'MXN'").Scan(&rows); err != nil {
+		t.Fatalf("count rows: %v", err)
+	}
+	if rows != 1 {
+		t.Fatalf("expected 1 EUR/MXN row, got %d", rows)
+	}
+	if len(enqueuer.payloads) != 1 || enqueuer.payloads[0].Pair != (service.Pair{Base: "EUR", Quote: "MXN"}) {
+		t.Fatalf("expected 1 task for EUR/MXN, got %+v", enqueuer.payloads)
+	}
+
+	if err := svc.ProcessUpdate(ctx, enqueuer.payloads[0]); err != nil {
+		t.Fatalf("ProcessUpdate: %v", err)
+	}
+	if testRDB.Exists(ctx, "latest:{EUR:MXN}").Val() != 1 {
+		t.Fatal("expected the latest price cached under latest:{EUR:MXN}")
+	}
+	// From here on the quote can only come from the cache.
+	if _, err := testDB.ExecContext(ctx, "TRUNCATE TABLE quotes CASCADE"); err != nil {
+		t.Fatalf("truncate: %v", err)
+	}
+	for _, codes := range [][2]string{{"eur", "mxn"}, {" EUR ", "MXN "}, {"EUR", "MXN"}} {
+		query := url.Values{"base": {codes[0]}, "quote": {codes[1]}}
+		w := httptest.NewRecorder()
+		latest.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/quotes/latest?"+query.Encode(), nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%q: expected the cached quote, got %d: %s", codes, w.Code, w.Body.String())
+		}
+		var resp api.LatestResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if resp.Base != "EUR" || resp.Quote != "MXN" || resp.Price != "18.7543" {
+			t.Errorf("%q: expected EUR/MXN at 18.7543, got %+v", codes, resp)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"quoteservice/internal/repository"
+)
+
+func markCompleted(ctx context.Context, t *testing.T, repo repository.QuoteRepository, base, quote, price string) {
+	t.Helper()
+	id := uuid.New().String()
+	if _, err := repo.CreateUpdate(ctx, repository.Pair{Base: base, Quote: quote}, id, repository.OriginAPI); err != nil {
+		t.Fatalf("CreateUpdate: %v", err)
+	}
+	if err := repo.MarkRunning(ctx, id, 1, ""); err != nil {
+		t.Fatalf("MarkRunning: %v", err)
+	}
+	if err := repo.MarkSuccess(ctx, id, 2, price, time.Now()); err != nil {
+		t.Fatalf("MarkSuccess: %v", err)
+	}
+}
+
+func TestNotifyListener_ReceivesSuccess(t *testing.T) {
+	resetTestData(t)
+	ctx := testContext(t)
+	repo := newRepo()
+
+	listenCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	notifications, err := repository.NewNotifyListener(testDB, repository.QuotesUpdatedChannel).Listen(listenCtx)
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+
+	// A FAILED update must not notify.
+	failedID := uuid.New().String()
+	if _, err := repo.CreateUpdate(ctx, repository.Pair{Base: "GBP", Quote: "JPY"}, failedID, repository.OriginAPI); err != nil {
+		t.Fatalf("CreateUpdate: %v", err)
+	}
+	if err := repo.MarkFailed(ctx, failedID, 1, repository.ErrorCodeProviderUnavailable, "provider down"); err != nil {
+		t.Fatalf("MarkFailed: %v", err)
+	}
+
+	markCompleted(ctx, t, repo, "EUR", "USD", "1.0850")
+
+	select {
+	case n := <-notifications:
+		if n.Base != "EUR" || n.Quote != "USD" {
+			t.Fatalf("expected EUR/USD notification, got %s/%s", n.Base, n.Quote)
+		}
+		if n.Price != "1.0850" {
+			t.Fatalf("expected price 1.085000, got %s", n.Price)
+		}
+		if n.UpdatedAt.IsZero() || n.RateTimestamp == nil {
+			t.Fatal("expected updated_at and rate_timestamp in notification")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no notification received after MarkSuccess")
+	}
+
+	cancel()
+	select {
+	case _, ok := <-notifications:
+		if ok {
+			t.Fatal("expected notification channel to close after cancel")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("notification channel not closed after cancel")
+	}
+}
+
+func TestPGNotifyBroker_WatchPair(t *testing.T) {
+	resetTestData(t)
+	ctx := testContext(t)
+	repo := newRepo()
+
+	listener := repository.NewNotifyListener(testDB, repository.QuotesUpdatedChannel)
+	broker := repository.NewPGNotifyBroker(listener, zap.NewNop().Sugar())
+	go func() { _ = broker.Run(ctx) }()
+	waitFor(t, "the broker to listen", broker.Listening)
+
+	eurUSD := broker.WatchPair(ctx, repository.Pair{Base: "EUR", Quote: "USD"})
+	gbpJPY := broker.WatchPair(ctx, repository.Pair{Base: "GBP", Quote: "JPY"})
+
+	markCompleted(ctx, t, repo, "EUR", "USD", "1.0850")
+
+	select {
+	case n := <-eurUSD:
+		if n.Price != "1.0850" {
+			t.Fatalf("expected price 1.085000, got %s", n.Price)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("EUR/USD watcher received nothing")
+	}
+
+	select {
+	case n := <-gbpJPY:
+		t.Fatalf("GBP/JPY watcher received unrelated notification %+v", n)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func TestPGNotifyBroker_Reconnects(t *testing.T) {
+	resetTestData(t)
+	ctx := testContext(t)
+	repo := newRepo()
+
+	broker := repository.NewPGNotifyBroker(repository.NewNotifyListener(testDB, repository.QuotesUpdatedChannel), zap.NewNop().Sugar())
+	go func() { _ = broker.Run(ctx) }()
+	waitFor(t, "the broker to listen", broker.Listening)
+	pair := repository.Pair{Base: "EUR", Quote: "USD"}
+	watcher := broker.WatchPair(ctx, pair)
+
+	// Drop the listen connection as a Postgres restart would.
+	if _, err := testDB.ExecContext(ctx, `SELECT pg_terminate_backend(pid) FROM pg_stat_activity
+		WHERE datname = current_database() AND query LIKE 'LISTEN %' AND pid <> pg_backend_pid()`); err != nil {
+		t.Fatalf("terminate listener: %v", err)
+	}
+	select {
+	case _, ok := <-watcher:
+		if ok {
+			t.Fatal("expected the watcher to be closed when the connection dropped")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("watcher not closed after the connection dropped")
+	}
+
+	waitFor(t, "the broker to listen again", broker.Listening)
+	watcher = broker.WatchPair(ctx, pair)
+	markCompleted(ctx, t, repo, "EUR", "USD", "1.0850")
+	select {
+	case n := <-watcher:
+		if n.Price != "1.0850" {
+			t.Fatalf("expected price 1.0850, got %s", n.Price)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("watcher received nothing after the reconnect")
+	}
+}
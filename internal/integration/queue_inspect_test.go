@@ -0,0 +1,121 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/redis/go-redis/v9"
+
+	"quoteservice/internal/service"
+	"quoteservice/internal/testkit"
+	"quoteservice/internal/worker"
+)
+
+// queueInspectRedisDB keeps the seeded tasks apart from the other tests' keys.
+const queueInspectRedisDB = 4
+
+func TestPairTaskLister_SeededTasks(t *testing.T) {
+	ctx := testContext(t)
+	redisOpt := asynq.RedisClientOpt{Addr: testkit.Global().RedisAddr(), DB: queueInspectRedisDB}
+	rdb := redis.NewClient(&redis.Options{Addr: redisOpt.Addr, DB: queueInspectRedisDB})
+	t.Cleanup(func() {
+		_ = rdb.FlushDB(context.Background()).Err()
+		_ = rdb.Close()
+	})
+	if err := rdb.FlushDB(ctx).Err(); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+
+	client := asynq.NewClient(redisOpt)
+	t.Cleanup(func() { _ = client.Close() })
+	insp := asynq.NewInspector(redisOpt)
+	t.Cleanup(func() { _ = insp.Close() })
+
+	enqueue := func(taskType, payload string, opts ...asynq.Option) *asynq.TaskInfo {
+		t.Helper()
+		info, err := client.Enqueue(asynq.NewTask(taskType, []byte(payload)), opts...)
+		if err != nil {
+			t.Fatalf("enqueue %s: %v", payload, err)
+		}
+		return info
+	}
+
+	// Payloads from before forced providers existed have no provider field.
+	pending := enqueue(service.TaskTypeUpdateQuote, `{"update_id":"u-pending","base":"EUR","quote":"MXN"}`)
+	scheduled := enqueue(service.TaskTypeUpdateQuote, `{"update_id":"u-scheduled","base":"eur","quote":"mxn","provider":"frankfurter"}`,
+		asynq.Queue("high"), asynq.ProcessIn(time.Hour))
+	archived := enqueue(service.TaskTypeUpdateQuote, `{"update_id":"u-archived","base":"EUR","quote":"MXN"}`, asynq.Queue("low"))
+	if err := insp.ArchiveTask("low", archived.ID); err != nil {
+		t.Fatalf("archive: %v", err)
+	}
+	retry := enqueue(service.TaskTypeUpdateQuote, `{"update_id":"u-retry","base":"EUR","quote":"MXN"}`,
+		asynq.Queue("retrying"), asynq.MaxRetry(3))
+	// Not listed: another pair, another task type and an undecodable payload.
+	enqueue(service.TaskTypeUpdateQuote, `{"update_id":"u-other","base":"GBP","quote":"USD"}`)
+	enqueue("other:task", `{"update_id":"u-type","base":"EUR","quote":"MXN"}`)
+	enqueue(service.TaskTypeUpdateQuote, `{not json`)
+
+	failOnce(t, redisOpt, "retrying")
+
+	tasks, err := worker.NewPairTaskLister(insp, "").ListPairTasks(ctx, service.Pair{Base: "EUR", Quote: "MXN"})
+	if err != nil {
+		t.Fatalf("ListPairTasks: %v", err)
+	}
+	byID := make(map[string]worker.QueuedTask, len(tasks))
+	for _, task := range tasks {
+		byID[task.ID] = task
+	}
+	if len(byID) != 4 {
+		t.Fatalf("Expected 4 EUR/MXN tasks, got %+v", tasks)
+	}
+
+	if got := byID[pending.ID]; got.State != "pending" || got.Payload.UpdateID != "u-pending" || got.Payload.Provider != "" {
+		t.Errorf("Unexpected pending task %+v", got)
+	}
+	if got := byID[scheduled.ID]; got.State != "scheduled" || got.Queue != "high" || got.Payload.Provider != "frankfurter" ||
+		got.NextProcessAt.Before(time.Now().Add(50*time.Minute)) {
+		t.Errorf("Unexpected scheduled task %+v", got)
+	}
+	if got := byID[archived.ID]; got.State != "archived" || got.Queue != "low" {
+		t.Errorf("Unexpected archived task %+v", got)
+	}
+	if got := byID[retry.ID]; got.State != "retry" || got.Retried != 1 || got.MaxRetry != 3 ||
+		got.LastErr != "provider timeout" || got.LastFailedAt.IsZero() {
+		t.Errorf("Unexpected retry task %+v", got)
+	}
+}
+
+// failOnce runs a server on queue until its task has failed once and been moved to the
+// retry set with a retry an hour away.
+func failOnce(t *testing.T, redisOpt asynq.RedisClientOpt, queue string) {
+	t.Helper()
+	failed := make(chan struct{}, 1)
+	srv := asynq.NewServer(redisOpt, asynq.Config{
+		Concurrency:    1,
+		Queues:         map[string]int{queue: 1},
+		RetryDelayFunc: func(int, error, *asynq.Task) time.Duration { return time.Hour },
+		LogLevel:       asynq.FatalLevel,
+	})
+	err := srv.Start(asynq.HandlerFunc(func(context.Context, *asynq.Task) error {
+		select {
+		case failed <- struct{}{}:
+		default:
+		}
+		return errors.New("provider timeout")
+	}))
+	if err != nil {
+		t.Fatalf("start server: %v", err)
+	}
+	select {
+	case <-failed:
+	case <-time.After(10 * time.Second):
+		t.Fatal("task was not processed")
+	}
+	// Shutdown waits for the handler's result to be written back to Redis.
+	srv.Shutdown()
+}
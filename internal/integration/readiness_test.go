@@ -0,0 +1,71 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"quoteservice/internal/api"
+	"quoteservice/internal/config"
+	"quoteservice/internal/repository"
+	"quoteservice/internal/service"
+)
+
+func TestReadyz_WaitsForCacheWarmup(t *testing.T) {
+	resetTestData(t)
+	ctx := testContext(t)
+
+	insertSuccessRecord(t, "EUR", "USD", "1.0850")
+
+	cacheCfg := config.CacheConfig{
+		LatestPriceTTLSec:           3600,
+		ExchangeProviderPriceTTLSec: 3600,
+		WarmupRequired:              true,
+	}
+	svc := service.NewQuoteService(service.QuoteServiceDeps{
+		Repo:        repository.NewPostgresQuoteRepository(testDB),
+		Validator:   service.NewValidator(),
+		Cache:       testRDB,
+		Logger:      zap.NewNop().Sugar(),
+		CacheConfig: cacheCfg,
+	})
+	handler := api.HandleReadyz(
+		api.ReadinessCheck{Name: "postgres", Checker: api.ReadinessFunc(testDB.PingContext)},
+		api.ReadinessCheck{Name: "cache_warmup", Checker: api.ReadinessFunc(func(context.Context) error {
+			if !svc.IsReady() {
+				return errors.New("warming up")
+			}
+			return nil
+		})},
+	)
+
+	readyz := func() int {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		return w.Code
+	}
+
+	if code := readyz(); code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 before warmup, got %d", code)
+	}
+
+	svc.WarmCache(ctx, []string{"EUR/USD", "GBP/JPY"})
+
+	if code := readyz(); code != http.StatusOK {
+		t.Fatalf("expected 200 after warmup, got %d", code)
+	}
+
+	price, err := testRDB.HGet(ctx, "latest:{EUR:USD}", "price").Result()
+	if err != nil {
+		t.Fatalf("read warmed cache: %v", err)
+	}
+	if price != "1.0850" {
+		t.Fatalf("expected warmed price 1.085000, got %s", price)
+	}
+}
@@ -0,0 +1,151 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	"quoteservice/internal/repository"
+	"quoteservice/internal/service"
+	"quoteservice/internal/testkit"
+	"quoteservice/internal/worker"
+)
+
+// reconcileRedisDB keeps the reconciled tasks apart from the other tests' keys.
+const reconcileRedisDB = 5
+
+func TestListPendingOlderThan(t *testing.T) {
+	t.Parallel()
+	ctx := testContext(t)
+	db := newIsolatedDB(t)
+	repo := repository.NewPostgresQuoteRepository(db)
+
+	create := func(base string, age time.Duration) string {
+		t.Helper()
+		id := uuid.New().String()
+		if _, err := repo.CreateUpdate(ctx, repository.Pair{Base: base, Quote: "USD"}, id, repository.OriginAPI); err != nil {
+			t.Fatalf("CreateUpdate: %v", err)
+		}
+		if _, err := db.ExecContext(ctx, "UPDATE quotes SET requested_at = NOW() - $1::interval WHERE id = $2::uuid",
+			age.String(), id); err != nil {
+			t.Fatalf("backdate: %v", err)
+		}
+		return id
+	}
+	oldest := create("EUR", 3*time.Hour)
+	older := create("GBP", 2*time.Hour)
+	create("JPY", time.Second)
+	done := create("CHF", 4*time.Hour)
+	if err := repo.MarkFailed(ctx, done, repository.InitialVersion, repository.ErrorCodeInternal, "boom"); err != nil {
+		t.Fatalf("MarkFailed: %v", err)
+	}
+
+	got, err := repo.ListPendingOlderThan(ctx, time.Now().Add(-time.Hour), 10)
+	if err != nil {
+		t.Fatalf("ListPendingOlderThan: %v", err)
+	}
+	if len(got) != 2 || got[0].ID != oldest || got[1].ID != older {
+		t.Fatalf("Expected the two old PENDING updates oldest first, got %+v", got)
+	}
+	if got[0].Base != "EUR" || got[0].Status != repository.StatusPending || got[0].Version != repository.InitialVersion {
+		t.Errorf("Unexpected record %+v", got[0])
+	}
+
+	got, err = repo.ListPendingOlderThan(ctx, time.Now().Add(-time.Hour), 1)
+	if err != nil {
+		t.Fatalf("ListPendingOlderThan: %v", err)
+	}
+	if len(got) != 1 || got[0].ID != oldest {
+		t.Errorf("Expected the limit to keep the oldest update, got %+v", got)
+	}
+}
+
+// TestReconciler_LostTasks simulates tasks lost between creating an update and
+// enqueueing it: updates are created without a task and backdated.
+func TestReconciler_LostTasks(t *testing.T) {
+	ctx := testContext(t)
+	db := newIsolatedDB(t)
+	repo := repository.NewPostgresQuoteRepository(db)
+
+	redisOpt := asynq.RedisClientOpt{Addr: testkit.Global().RedisAddr(), DB: reconcileRedisDB}
+	rdb := redis.NewClient(&redis.Options{Addr: redisOpt.Addr, DB: reconcileRedisDB})
+	t.Cleanup(func() {
+		_ = rdb.FlushDB(context.Background()).Err()
+		_ = rdb.Close()
+	})
+	if err := rdb.FlushDB(ctx).Err(); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	client := asynq.NewClient(redisOpt)
+	t.Cleanup(func() { _ = client.Close() })
+	insp := asynq.NewInspector(redisOpt)
+	t.Cleanup(func() { _ = insp.Close() })
+
+	logger := zap.NewNop().Sugar()
+	enqueuer := worker.NewAsynqEnqueuer(client, 3, time.Minute, time.Second, "")
+	svc := service.NewQuoteService(service.QuoteServiceDeps{Repo: repo, Enqueuer: enqueuer, Logger: logger})
+
+	create := func(base string, age time.Duration) string {
+		t.Helper()
+		id := uuid.New().String()
+		if _, err := repo.CreateUpdate(ctx, repository.Pair{Base: base, Quote: "USD"}, id, repository.OriginAPI); err != nil {
+			t.Fatalf("CreateUpdate: %v", err)
+		}
+		if _, err := db.ExecContext(ctx, "UPDATE quotes SET requested_at = NOW() - $1::interval WHERE id = $2::uuid",
+			age.String(), id); err != nil {
+			t.Fatalf("backdate: %v", err)
+		}
+		return id
+	}
+	queued := create("EUR", 10*time.Minute)
+	if err := enqueuer.EnqueueUpdateTask(ctx, service.UpdateQuotePayload{UpdateID: queued, Pair: service.Pair{Base: "EUR", Quote: "USD"}},
+		service.TaskOptions{}); err != nil {
+		t.Fatalf("EnqueueUpdateTask: %v", err)
+	}
+	lost := create("GBP", 10*time.Minute)
+	abandoned := create("JPY", 2*time.Hour)
+	fresh := create("CHF", 0)
+
+	r := worker.NewReconciler(repo, svc, insp, "", worker.ReconcileConfig{Grace: time.Minute, GiveUp: time.Hour, BatchSize: 100}, logger)
+	sum, err := r.Reconcile(ctx)
+	if err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	want := worker.ReconcileSummary{Checked: 3, Queued: 1, Requeued: 1, Failed: 1}
+	if sum != want {
+		t.Errorf("Expected summary %+v, got %+v", want, sum)
+	}
+
+	info, err := insp.GetTaskInfo("default", lost)
+	if err != nil {
+		t.Fatalf("Expected the lost update requeued under its ID: %v", err)
+	}
+	if info.Type != service.TaskTypeUpdateQuote {
+		t.Errorf("Expected an update task, got %s", info.Type)
+	}
+	if _, err := insp.GetTaskInfo("default", fresh); !errors.Is(err, asynq.ErrTaskNotFound) {
+		t.Errorf("Expected the update within the grace period left alone, got %v", err)
+	}
+	assertStatus(ctx, t, repo, lost, repository.StatusPending)
+	assertStatus(ctx, t, repo, abandoned, repository.StatusFailed)
+	if q, _ := repo.GetByID(ctx, abandoned); q == nil || q.ErrorMsg == nil || *q.ErrorMsg != "task lost" {
+		t.Errorf("Expected the abandoned update failed with \"task lost\", got %+v", q)
+	}
+
+	// A second run finds both tasks queued.
+	sum, err = r.Reconcile(ctx)
+	if err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	if want := (worker.ReconcileSummary{Checked: 2, Queued: 2}); sum != want {
+		t.Errorf("Expected summary %+v on the second run, got %+v", want, sum)
+	}
+}
@@ -0,0 +1,143 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"quoteservice/internal/config"
+	"quoteservice/internal/repository"
+)
+
+// insertCompleted stores an update of base/quote that reached SUCCESS, or FAILED if
+// failed is set, records fetch unless it is nil, and backdates it by age.
+func insertCompleted(ctx context.Context, t *testing.T, db *sql.DB, repo repository.QuoteRepository,
+	base, quote string, failed bool, fetch *repository.Fetch, age time.Duration) {
+	t.Helper()
+	id := uuid.New().String()
+	if _, err := repo.CreateUpdate(ctx, repository.Pair{Base: base, Quote: quote}, id, repository.OriginAPI); err != nil {
+		t.Fatalf("CreateUpdate: %v", err)
+	}
+	if err := repo.MarkRunning(ctx, id, 1, ""); err != nil {
+		t.Fatalf("MarkRunning: %v", err)
+	}
+	var err error
+	if failed {
+		err = repo.MarkFailed(ctx, id, 2, repository.ErrorCodeTimeout, "provider timeout")
+	} else {
+		err = repo.MarkSuccess(ctx, id, 2, "1.0850", time.Now())
+	}
+	if err != nil {
+		t.Fatalf("complete %s: %v", id, err)
+	}
+	if fetch != nil {
+		if err := repo.RecordFetch(ctx, id, *fetch); err != nil {
+			t.Fatalf("RecordFetch: %v", err)
+		}
+	}
+	if _, err := db.ExecContext(ctx, `UPDATE quotes SET updated_at = NOW() - $1::interval WHERE id = $2::uuid`,
+		age.String(), id); err != nil {
+		t.Fatalf("backdate %s: %v", id, err)
+	}
+}
+
+func TestReliabilityReport_Aggregation(t *testing.T) {
+	t.Parallel()
+	const day = 24 * time.Hour
+	ctx := testContext(t)
+	db := newIsolatedDB(t)
+	repo := repository.NewPostgresQuoteRepository(db)
+	fetch := func(source string, ms int) *repository.Fetch {
+		return &repository.Fetch{Source: source, Duration: time.Duration(ms) * time.Millisecond}
+	}
+
+	insertCompleted(ctx, t, db, repo, "EUR", "USD", false, fetch("frankfurter", 100), day)
+	insertCompleted(ctx, t, db, repo, "EUR", "USD", false, fetch("frankfurter", 200), 2*day)
+	insertCompleted(ctx, t, db, repo, "EUR", "USD", true, fetch("frankfurter", 300), 3*day)
+	insertCompleted(ctx, t, db, repo, "EUR", "USD", true, fetch("exchangerate_host", 50), 3*day)
+	// Archived below: still within the range, and counted.
+	insertCompleted(ctx, t, db, repo, "EUR", "USD", false, fetch("frankfurter", 400), 200*day)
+	// Archived too, but before the range.
+	insertCompleted(ctx, t, db, repo, "EUR", "USD", false, fetch("frankfurter", 9000), 500*day)
+	// Completed without a recorded fetch, like a streamed rate.
+	insertCompleted(ctx, t, db, repo, "EUR", "GBP", false, nil, day)
+	// Not completed.
+	pending := uuid.New().String()
+	if _, err := repo.CreateUpdate(ctx, repository.Pair{Base: "EUR", Quote: "JPY"}, pending, repository.OriginAPI); err != nil {
+		t.Fatalf("CreateUpdate: %v", err)
+	}
+	if err := repo.RecordFetch(ctx, pending, *fetch("frankfurter", 100)); err == nil {
+		t.Error("expected RecordFetch to reject a PENDING update")
+	}
+
+	archiver := repository.NewPostgresQuoteArchiver(db, config.RetentionModeArchiveTable)
+	if n, err := archiver.ArchiveBatch(ctx, time.Now().Add(-90*day), 10); err != nil || n != 2 {
+		t.Fatalf("ArchiveBatch: expected 2 archived, got %d (err %v)", n, err)
+	}
+
+	reporter := repository.NewPostgresReliabilityReporter(db)
+	from, to := time.Now().Add(-365*day), time.Now().Add(time.Hour)
+	var rows []repository.ReliabilityRow
+	truncated, err := reporter.ReliabilityReport(ctx, from, to, 10, func(row repository.ReliabilityRow) error {
+		rows = append(rows, row)
+		return nil
+	})
+	if err != nil || truncated {
+		t.Fatalf("ReliabilityReport: truncated %v, err %v", truncated, err)
+	}
+
+	type want struct {
+		source          string
+		pair            repository.Pair
+		success, failed int64
+		avgMs           float64 // 0 for no recorded fetch.
+	}
+	wants := []want{
+		{"", repository.Pair{Base: "EUR", Quote: "GBP"}, 1, 0, 0},
+		{"exchangerate_host", repository.Pair{Base: "EUR", Quote: "USD"}, 0, 1, 50},
+		{"frankfurter", repository.Pair{Base: "EUR", Quote: "USD"}, 3, 1, 250},
+	}
+	if len(rows) != len(wants) {
+		t.Fatalf("expected %d rows, got %+v", len(wants), rows)
+	}
+	for i, w := range wants {
+		got := rows[i]
+		if got.Source != w.source || got.Pair != w.pair || got.Success != w.success || got.Failed != w.failed {
+			t.Errorf("row %d: expected %+v, got %+v", i, w, got)
+		}
+		switch {
+		case w.avgMs == 0 && got.AvgFetchMs != nil:
+			t.Errorf("row %d: expected no average fetch duration, got %v", i, *got.AvgFetchMs)
+		case w.avgMs != 0 && (got.AvgFetchMs == nil || *got.AvgFetchMs != w.avgMs):
+			t.Errorf("row %d: expected an average fetch duration of %vms, got %v", i, w.avgMs, got.AvgFetchMs)
+		}
+	}
+	if rate := rows[2].FailureRate(); rate != 0.25 {
+		t.Errorf("expected a failure rate of 0.25, got %v", rate)
+	}
+
+	rows = nil
+	truncated, err = reporter.ReliabilityReport(ctx, from, to, 2, func(row repository.ReliabilityRow) error {
+		rows = append(rows, row)
+		return nil
+	})
+	if err != nil || !truncated || len(rows) != 2 {
+		t.Errorf("limit 2: expected 2 rows and truncated, got %d rows, truncated %v, err %v", len(rows), truncated, err)
+	}
+
+	rows = nil
+	if _, err := reporter.ReliabilityReport(ctx, time.Now().Add(-36*time.Hour), to, 10, func(row repository.ReliabilityRow) error {
+		rows = append(rows, row)
+		return nil
+	}); err != nil {
+		t.Fatalf("ReliabilityReport: %v", err)
+	}
+	if len(rows) != 2 || rows[0].Success != 1 || rows[1].Success != 1 || rows[1].Failed != 0 {
+		t.Errorf("last 36h: expected one success each for EUR/GBP and frankfurter EUR/USD, got %+v", rows)
+	}
+}
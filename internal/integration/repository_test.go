@@ -4,7 +4,10 @@ package integration
 
 import (
 	"context"
+	"errors"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 
@@ -16,17 +19,17 @@ func newRepo() repository.QuoteRepository {
 }
 
 func TestCreateUpdate(t *testing.T) {
-	resetTestData(t)
+	t.Parallel()
 	ctx := testContext(t)
-	repo := newRepo()
+	repo := NewIsolatedRepo(t)
 
 	id := uuid.New().String()
-	got, err := repo.CreateUpdate(ctx, "USD", "EUR", id)
+	got, err := repo.CreateUpdate(ctx, repository.Pair{Base: "USD", Quote: "EUR"}, id, repository.OriginAPI)
 	if err != nil {
 		t.Fatalf("CreateUpdate: %v", err)
 	}
-	if got != id {
-		t.Fatalf("expected id %s, got %s", id, got)
+	if got.ID != id || !got.Created {
+		t.Fatalf("expected new record %s, got %+v", id, got)
 	}
 
 	// Verify DB state.
@@ -46,68 +49,132 @@ func TestCreateUpdate(t *testing.T) {
 }
 
 func TestCreateUpdate_Dedup(t *testing.T) {
-	resetTestData(t)
+	t.Parallel()
 	ctx := testContext(t)
-	repo := newRepo()
+	repo := NewIsolatedRepo(t)
 
 	id1 := uuid.New().String()
-	got1, err := repo.CreateUpdate(ctx, "USD", "EUR", id1)
+	got1, err := repo.CreateUpdate(ctx, repository.Pair{Base: "USD", Quote: "EUR"}, id1, repository.OriginAPI)
 	if err != nil {
 		t.Fatalf("first CreateUpdate: %v", err)
 	}
-	if got1 != id1 {
-		t.Fatalf("expected id1 %s, got %s", id1, got1)
+	if got1.ID != id1 || !got1.Created {
+		t.Fatalf("expected new record %s, got %+v", id1, got1)
 	}
 
 	// Second call for same pair while PENDING should return existing ID.
 	id2 := uuid.New().String()
-	got2, err := repo.CreateUpdate(ctx, "USD", "EUR", id2)
+	got2, err := repo.CreateUpdate(ctx, repository.Pair{Base: "USD", Quote: "EUR"}, id2, repository.OriginAPI)
 	if err != nil {
 		t.Fatalf("second CreateUpdate: %v", err)
 	}
-	if got2 != id1 {
-		t.Fatalf("expected dedup to return %s, got %s", id1, got2)
+	if got2.ID != id1 || got2.Created {
+		t.Fatalf("expected dedup onto %s, got %+v", id1, got2)
+	}
+}
+
+func TestCreateUpdate_ConcurrentSamePair(t *testing.T) {
+	t.Parallel()
+	ctx := testContext(t)
+	db := newIsolatedDB(t)
+	repo := repository.NewPostgresQuoteRepository(db)
+
+	const workers = 16
+	pairs := [][2]string{{"USD", "EUR"}, {"GBP", "JPY"}, {"EUR", "MXN"}}
+
+	type result struct {
+		pair string
+		res  repository.CreateUpdateResult
+		err  error
+	}
+	results := make(chan result, workers*len(pairs))
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for _, p := range pairs {
+		for range workers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				<-start
+				res, err := repo.CreateUpdate(ctx, repository.Pair{Base: p[0], Quote: p[1]}, uuid.New().String(), repository.OriginAPI)
+				results <- result{pair: p[0] + "/" + p[1], res: res, err: err}
+			}()
+		}
+	}
+	close(start)
+	wg.Wait()
+	close(results)
+
+	ids := make(map[string]map[string]bool)
+	created := make(map[string]int)
+	for res := range results {
+		if res.err != nil {
+			t.Fatalf("CreateUpdate %s: %v", res.pair, res.err)
+		}
+		if ids[res.pair] == nil {
+			ids[res.pair] = make(map[string]bool)
+		}
+		ids[res.pair][res.res.ID] = true
+		if res.res.Created {
+			created[res.pair]++
+		}
+	}
+	for pair, set := range ids {
+		if len(set) != 1 {
+			t.Errorf("expected one update ID for %s, got %d", pair, len(set))
+		}
+		if created[pair] != 1 {
+			t.Errorf("expected one call to create %s, got %d", pair, created[pair])
+		}
+	}
+
+	var rows int
+	if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM quotes`).Scan(&rows); err != nil {
+		t.Fatalf("count quotes: %v", err)
+	}
+	if rows != len(pairs) {
+		t.Fatalf("expected %d rows, got %d", len(pairs), rows)
 	}
 }
 
 func TestCreateUpdate_AfterCompletion(t *testing.T) {
-	resetTestData(t)
+	t.Parallel()
 	ctx := testContext(t)
-	repo := newRepo()
+	repo := NewIsolatedRepo(t)
 
 	id1 := uuid.New().String()
-	_, err := repo.CreateUpdate(ctx, "USD", "EUR", id1)
+	_, err := repo.CreateUpdate(ctx, repository.Pair{Base: "USD", Quote: "EUR"}, id1, repository.OriginAPI)
 	if err != nil {
 		t.Fatalf("CreateUpdate: %v", err)
 	}
 
-	if err := repo.MarkRunning(ctx, id1); err != nil {
+	if err := repo.MarkRunning(ctx, id1, 1, ""); err != nil {
 		t.Fatalf("MarkRunning: %v", err)
 	}
-	if err := repo.MarkSuccess(ctx, id1, "1.1234"); err != nil {
+	if err := repo.MarkSuccess(ctx, id1, 2, "1.1234", time.Now()); err != nil {
 		t.Fatalf("MarkSuccess: %v", err)
 	}
 
 	id2 := uuid.New().String()
-	got, err := repo.CreateUpdate(ctx, "USD", "EUR", id2)
+	got, err := repo.CreateUpdate(ctx, repository.Pair{Base: "USD", Quote: "EUR"}, id2, repository.OriginAPI)
 	if err != nil {
 		t.Fatalf("CreateUpdate after completion: %v", err)
 	}
-	if got != id2 {
-		t.Fatalf("expected new id %s, got %s", id2, got)
+	if got.ID != id2 || !got.Created {
+		t.Fatalf("expected new record %s, got %+v", id2, got)
 	}
 }
 
 func TestMarkRunning(t *testing.T) {
-	resetTestData(t)
+	t.Parallel()
 	ctx := testContext(t)
-	repo := newRepo()
+	repo := NewIsolatedRepo(t)
 
 	id := uuid.New().String()
-	if _, err := repo.CreateUpdate(ctx, "GBP", "JPY", id); err != nil {
+	if _, err := repo.CreateUpdate(ctx, repository.Pair{Base: "GBP", Quote: "JPY"}, id, repository.OriginAPI); err != nil {
 		t.Fatalf("CreateUpdate: %v", err)
 	}
-	if err := repo.MarkRunning(ctx, id); err != nil {
+	if err := repo.MarkRunning(ctx, id, 1, ""); err != nil {
 		t.Fatalf("MarkRunning: %v", err)
 	}
 
@@ -125,32 +192,84 @@ func TestMarkRunning(t *testing.T) {
 	})
 
 	t.Run("second call fails", func(t *testing.T) {
-		if err := repo.MarkRunning(ctx, id); err == nil {
-			t.Fatal("expected error for MarkRunning on non-PENDING record, got nil")
+		err := repo.MarkRunning(ctx, id, 2, "")
+		var invalid *repository.InvalidTransitionError
+		if !errors.As(err, &invalid) {
+			t.Fatalf("expected *InvalidTransitionError for MarkRunning on RUNNING record, got %v", err)
+		}
+		if invalid.From != repository.StatusRunning || invalid.To != repository.StatusRunning {
+			t.Fatalf("expected RUNNING -> RUNNING, got %+v", invalid)
+		}
+	})
+
+	t.Run("stale version conflicts", func(t *testing.T) {
+		err := repo.MarkRunning(ctx, id, 1, "")
+		var conflict *repository.VersionConflictError
+		if !errors.As(err, &conflict) || conflict.Status != repository.StatusRunning || conflict.Actual != 2 {
+			t.Fatalf("expected conflict with RUNNING at version 2, got %v", err)
 		}
 	})
 }
 
+func TestMarkTransitions_VersionConflict(t *testing.T) {
+	t.Parallel()
+	ctx, repo, id := setupRunningUpdate(t, "USD", "CHF")
+
+	// Two workers read the RUNNING record at version 2; only the first write applies.
+	if err := repo.MarkSuccess(ctx, id, 2, "0.8800", time.Now()); err != nil {
+		t.Fatalf("MarkSuccess: %v", err)
+	}
+	err := repo.MarkFailed(ctx, id, 2, repository.ErrorCodeTimeout, "provider timeout")
+	if !errors.Is(err, repository.ErrVersionConflict) {
+		t.Fatalf("expected ErrVersionConflict, got %v", err)
+	}
+	var conflict *repository.VersionConflictError
+	if !errors.As(err, &conflict) || conflict.Expected != 2 || conflict.Actual != 3 || conflict.Status != repository.StatusSuccess {
+		t.Fatalf("expected conflict with SUCCESS at version 3, got %+v", conflict)
+	}
+
+	q, err := repo.GetByID(ctx, id)
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	if q.Status != repository.StatusSuccess || q.Version != 3 {
+		t.Fatalf("expected SUCCESS at version 3, got %s at %d", q.Status, q.Version)
+	}
+
+	unknown := uuid.New().String()
+	if err := repo.MarkRunning(ctx, unknown, 1, ""); !errors.Is(err, repository.ErrQuoteNotFound) {
+		t.Fatalf("MarkRunning: expected ErrQuoteNotFound for unknown id, got %v", err)
+	}
+	if err := repo.MarkSuccess(ctx, unknown, 2, "0.8800", time.Now()); !errors.Is(err, repository.ErrQuoteNotFound) {
+		t.Fatalf("MarkSuccess: expected ErrQuoteNotFound for unknown id, got %v", err)
+	}
+	if err := repo.MarkFailed(ctx, unknown, 1, repository.ErrorCodeTimeout, "provider timeout"); !errors.Is(err, repository.ErrQuoteNotFound) {
+		t.Fatalf("MarkFailed: expected ErrQuoteNotFound for unknown id, got %v", err)
+	}
+}
+
+// setupRunningUpdate creates a RUNNING update in an isolated schema.
 func setupRunningUpdate(t *testing.T, base, quote string) (context.Context, repository.QuoteRepository, string) {
 	t.Helper()
-	resetTestData(t)
 	ctx := testContext(t)
-	repo := newRepo()
+	repo := NewIsolatedRepo(t)
 
 	id := uuid.New().String()
-	if _, err := repo.CreateUpdate(ctx, base, quote, id); err != nil {
+	if _, err := repo.CreateUpdate(ctx, repository.Pair{Base: base, Quote: quote}, id, repository.OriginAPI); err != nil {
 		t.Fatalf("CreateUpdate: %v", err)
 	}
-	if err := repo.MarkRunning(ctx, id); err != nil {
+	if err := repo.MarkRunning(ctx, id, 1, ""); err != nil {
 		t.Fatalf("MarkRunning: %v", err)
 	}
 	return ctx, repo, id
 }
 
 func TestMarkSuccess(t *testing.T) {
+	t.Parallel()
 	ctx, repo, id := setupRunningUpdate(t, "USD", "GBP")
 
-	if err := repo.MarkSuccess(ctx, id, "0.7890"); err != nil {
+	rateTimestamp := time.Date(2024, 6, 14, 0, 0, 0, 0, time.UTC)
+	if err := repo.MarkSuccess(ctx, id, 2, "0.7890", rateTimestamp); err != nil {
 		t.Fatalf("MarkSuccess: %v", err)
 	}
 
@@ -164,23 +283,78 @@ func TestMarkSuccess(t *testing.T) {
 	if q.Status != repository.StatusSuccess {
 		t.Fatalf("expected SUCCESS, got %s", q.Status)
 	}
-	if q.Price == nil || *q.Price != "0.789000" {
+	if q.Price == nil || *q.Price != "0.7890" {
 		var got string
 		if q.Price != nil {
 			got = *q.Price
 		}
-		t.Fatalf("expected price 0.789000, got %s", got)
+		t.Fatalf("expected price 0.7890, got %s", got)
 	}
 	if q.UpdatedAt == nil {
 		t.Fatal("expected updated_at to be set")
 	}
+	if q.RateTimestamp == nil || !q.RateTimestamp.Equal(rateTimestamp) {
+		t.Fatalf("expected rate_timestamp %v, got %v", rateTimestamp, q.RateTimestamp)
+	}
+}
+
+func TestMarkSuccess_ExtremePrices(t *testing.T) {
+	t.Parallel()
+	// BTC/JPY and IDR/BTC magnitudes, beyond the old NUMERIC(18, 6) column.
+	for _, price := range []string{"15234567.123456789012", "0.000000001234567891"} {
+		ctx, repo, id := setupRunningUpdate(t, "EUR", "JPY")
+		if err := repo.MarkSuccess(ctx, id, 2, price, time.Now()); err != nil {
+			t.Fatalf("MarkSuccess(%s): %v", price, err)
+		}
+		v := repository.Verification{MinPrice: price, MaxPrice: price, Spread: "0.0000", Providers: 2}
+		if err := repo.SaveVerification(ctx, id, v); err != nil {
+			t.Fatalf("SaveVerification(%s): %v", price, err)
+		}
+
+		q, err := repo.GetLatestSuccess(ctx, repository.Pair{Base: "EUR", Quote: "JPY"})
+		if err != nil {
+			t.Fatalf("GetLatestSuccess: %v", err)
+		}
+		if q == nil || q.Price == nil || *q.Price != price {
+			t.Fatalf("expected price %s, got %+v", price, q)
+		}
+		if q.Verification == nil || *q.Verification != v {
+			t.Fatalf("expected verification %+v, got %+v", v, q.Verification)
+		}
+	}
+}
+
+func TestSaveVerification(t *testing.T) {
+	t.Parallel()
+	ctx, repo, id := setupRunningUpdate(t, "USD", "GBP")
+
+	v := repository.Verification{MinPrice: "0.7890", MaxPrice: "0.7912", Spread: "0.2788", Providers: 2}
+	if err := repo.SaveVerification(ctx, id, v); err == nil {
+		t.Fatal("expected SaveVerification to reject a RUNNING update")
+	}
+	if err := repo.MarkSuccess(ctx, id, 2, "0.7890", time.Now()); err != nil {
+		t.Fatalf("MarkSuccess: %v", err)
+	}
+	if err := repo.SaveVerification(ctx, id, v); err != nil {
+		t.Fatalf("SaveVerification: %v", err)
+	}
+
+	q, err := repo.GetByID(ctx, id)
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	want := repository.Verification{MinPrice: "0.7890", MaxPrice: "0.7912", Spread: "0.2788", Providers: 2}
+	if q.Verification == nil || *q.Verification != want {
+		t.Fatalf("expected verification %+v, got %+v", want, q.Verification)
+	}
 }
 
 func TestMarkFailed_FromRunning(t *testing.T) {
+	t.Parallel()
 	ctx, repo, id := setupRunningUpdate(t, "USD", "GBP")
 
 	errMsg := "provider timeout"
-	if err := repo.MarkFailed(ctx, id, errMsg); err != nil {
+	if err := repo.MarkFailed(ctx, id, 2, repository.ErrorCodeTimeout, errMsg); err != nil {
 		t.Fatalf("MarkFailed: %v", err)
 	}
 
@@ -200,20 +374,48 @@ func TestMarkFailed_FromRunning(t *testing.T) {
 	if q.ErrorMsg == nil || *q.ErrorMsg != errMsg {
 		t.Fatalf("expected error message %q, got %v", errMsg, q.ErrorMsg)
 	}
+	if q.ErrorCode != repository.ErrorCodeTimeout {
+		t.Fatalf("expected error code timeout, got %q", q.ErrorCode)
+	}
+}
+
+func TestMarkFailed_LegacyRowWithoutErrorCode(t *testing.T) {
+	t.Parallel()
+	ctx := testContext(t)
+	db := newIsolatedDB(t)
+	repo := repository.NewPostgresQuoteRepository(db)
+
+	// A row failed before error codes were recorded.
+	id := uuid.New().String()
+	if _, err := db.ExecContext(ctx, `INSERT INTO quotes (id, base, quote, status, error, requested_at, updated_at)
+		VALUES ($1::uuid, 'USD', 'GBP', 'FAILED'::quotes_status, 'provider timeout', NOW(), NOW())`, id); err != nil {
+		t.Fatalf("insert legacy row: %v", err)
+	}
+
+	q, err := repo.GetByID(ctx, id)
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	if q == nil || q.Status != repository.StatusFailed || q.ErrorMsg == nil || *q.ErrorMsg != "provider timeout" {
+		t.Fatalf("expected the FAILED row with its message, got %+v", q)
+	}
+	if q.ErrorCode != "" {
+		t.Fatalf("expected no error code, got %q", q.ErrorCode)
+	}
 }
 
 func TestMarkFailed_FromPending(t *testing.T) {
-	resetTestData(t)
+	t.Parallel()
 	ctx := testContext(t)
-	repo := newRepo()
+	repo := NewIsolatedRepo(t)
 
 	id := uuid.New().String()
-	if _, err := repo.CreateUpdate(ctx, "USD", "GBP", id); err != nil {
+	if _, err := repo.CreateUpdate(ctx, repository.Pair{Base: "USD", Quote: "GBP"}, id, repository.OriginAPI); err != nil {
 		t.Fatalf("CreateUpdate: %v", err)
 	}
 
 	errMsg := "enqueue error"
-	if err := repo.MarkFailed(ctx, id, errMsg); err != nil {
+	if err := repo.MarkFailed(ctx, id, 1, repository.ErrorCodeInternal, errMsg); err != nil {
 		t.Fatalf("MarkFailed from PENDING: %v", err)
 	}
 
@@ -233,41 +435,46 @@ func TestMarkFailed_FromPending(t *testing.T) {
 }
 
 func TestMarkSuccess_WrongStatus(t *testing.T) {
-	resetTestData(t)
+	t.Parallel()
 	ctx := testContext(t)
-	repo := newRepo()
+	repo := NewIsolatedRepo(t)
 
 	id := uuid.New().String()
-	if _, err := repo.CreateUpdate(ctx, "USD", "GBP", id); err != nil {
+	if _, err := repo.CreateUpdate(ctx, repository.Pair{Base: "USD", Quote: "GBP"}, id, repository.OriginAPI); err != nil {
 		t.Fatalf("CreateUpdate: %v", err)
 	}
 
 	// Try to mark success while still PENDING (not RUNNING).
-	if err := repo.MarkSuccess(ctx, id, "1.0000"); err == nil {
-		t.Fatal("expected error for MarkSuccess on non-RUNNING record, got nil")
+	err := repo.MarkSuccess(ctx, id, 1, "1.0000", time.Now())
+	var invalid *repository.InvalidTransitionError
+	if !errors.As(err, &invalid) || invalid.From != repository.StatusPending || invalid.To != repository.StatusSuccess {
+		t.Fatalf("expected PENDING -> SUCCESS *InvalidTransitionError, got %v", err)
 	}
 }
 
 func TestMarkFailed_WrongStatus(t *testing.T) {
+	t.Parallel()
 	ctx, repo, id := setupRunningUpdate(t, "USD", "GBP")
 
-	if err := repo.MarkSuccess(ctx, id, "1.0000"); err != nil {
+	if err := repo.MarkSuccess(ctx, id, 2, "1.0000", time.Now()); err != nil {
 		t.Fatalf("MarkSuccess: %v", err)
 	}
 
 	// Try to mark failed on an already completed (SUCCESS) record.
-	if err := repo.MarkFailed(ctx, id, "some error"); err == nil {
-		t.Fatal("expected error for MarkFailed on SUCCESS record, got nil")
+	err := repo.MarkFailed(ctx, id, 3, repository.ErrorCodeInternal, "some error")
+	var invalid *repository.InvalidTransitionError
+	if !errors.As(err, &invalid) || invalid.From != repository.StatusSuccess || invalid.To != repository.StatusFailed {
+		t.Fatalf("expected SUCCESS -> FAILED *InvalidTransitionError, got %v", err)
 	}
 }
 
 func TestGetByID(t *testing.T) {
-	resetTestData(t)
+	t.Parallel()
 	ctx := testContext(t)
-	repo := newRepo()
+	repo := NewIsolatedRepo(t)
 
 	id := uuid.New().String()
-	if _, err := repo.CreateUpdate(ctx, "EUR", "CHF", id); err != nil {
+	if _, err := repo.CreateUpdate(ctx, repository.Pair{Base: "EUR", Quote: "CHF"}, id, repository.OriginAPI); err != nil {
 		t.Fatalf("CreateUpdate: %v", err)
 	}
 
@@ -292,10 +499,51 @@ func TestGetByID(t *testing.T) {
 	}
 }
 
+func TestCreateUpdate_Origin(t *testing.T) {
+	t.Parallel()
+	ctx := testContext(t)
+	db := newIsolatedDB(t)
+	repo := repository.NewPostgresQuoteRepository(db)
+
+	t.Run("round-trips", func(t *testing.T) {
+		id := uuid.New().String()
+		if _, err := repo.CreateUpdate(ctx, repository.Pair{Base: "EUR", Quote: "USD"}, id, repository.OriginStream); err != nil {
+			t.Fatalf("CreateUpdate: %v", err)
+		}
+		q, err := repo.GetByID(ctx, id)
+		if err != nil {
+			t.Fatalf("GetByID: %v", err)
+		}
+		if q.Origin != repository.OriginStream {
+			t.Fatalf("expected origin %s, got %q", repository.OriginStream, q.Origin)
+		}
+	})
+
+	t.Run("rows written before origins default to api", func(t *testing.T) {
+		id := uuid.New().String()
+		if _, err := db.ExecContext(ctx, `INSERT INTO quotes (id, base, quote, status) VALUES ($1, 'GBP', 'JPY', 'PENDING')`, id); err != nil {
+			t.Fatalf("insert: %v", err)
+		}
+		q, err := repo.GetByID(ctx, id)
+		if err != nil {
+			t.Fatalf("GetByID: %v", err)
+		}
+		if q.Origin != repository.OriginAPI {
+			t.Fatalf("expected origin %s, got %q", repository.OriginAPI, q.Origin)
+		}
+	})
+
+	t.Run("unknown origin rejected", func(t *testing.T) {
+		if _, err := repo.CreateUpdate(ctx, repository.Pair{Base: "CHF", Quote: "USD"}, uuid.New().String(), "cron"); err == nil {
+			t.Fatal("expected an error for an unknown origin")
+		}
+	})
+}
+
 func TestGetByID_NotFound(t *testing.T) {
-	resetTestData(t)
+	t.Parallel()
 	ctx := testContext(t)
-	repo := newRepo()
+	repo := NewIsolatedRepo(t)
 
 	q, err := repo.GetByID(ctx, uuid.New().String())
 	if err != nil {
@@ -306,36 +554,63 @@ func TestGetByID_NotFound(t *testing.T) {
 	}
 }
 
+func TestProbeWrite_LeavesNoRecord(t *testing.T) {
+	t.Parallel()
+	ctx := testContext(t)
+	db := newIsolatedDB(t)
+
+	// A record left PENDING by the first probe would make the second one fail.
+	for range 2 {
+		if err := repository.ProbeWrite(ctx, db); err != nil {
+			t.Fatalf("ProbeWrite: %v", err)
+		}
+	}
+
+	var rows int
+	if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM quotes`).Scan(&rows); err != nil {
+		t.Fatalf("count quotes: %v", err)
+	}
+	if rows != 0 {
+		t.Fatalf("expected the probe records rolled back, got %d rows", rows)
+	}
+	if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM quote_status_events`).Scan(&rows); err != nil {
+		t.Fatalf("count status events: %v", err)
+	}
+	if rows != 0 {
+		t.Fatalf("expected no status events, got %d", rows)
+	}
+}
+
 func TestGetLatestSuccess(t *testing.T) {
-	resetTestData(t)
+	t.Parallel()
 	ctx := testContext(t)
-	repo := newRepo()
+	repo := NewIsolatedRepo(t)
 
 	// Create two successful records for same pair.
 	id1 := uuid.New().String()
-	if _, err := repo.CreateUpdate(ctx, "USD", "EUR", id1); err != nil {
+	if _, err := repo.CreateUpdate(ctx, repository.Pair{Base: "USD", Quote: "EUR"}, id1, repository.OriginAPI); err != nil {
 		t.Fatalf("CreateUpdate 1: %v", err)
 	}
-	if err := repo.MarkRunning(ctx, id1); err != nil {
+	if err := repo.MarkRunning(ctx, id1, 1, ""); err != nil {
 		t.Fatalf("MarkRunning 1: %v", err)
 	}
-	if err := repo.MarkSuccess(ctx, id1, "1.1000"); err != nil {
+	if err := repo.MarkSuccess(ctx, id1, 2, "1.1000", time.Now()); err != nil {
 		t.Fatalf("MarkSuccess 1: %v", err)
 	}
 
 	// Need to complete first before inserting second (unique partial index).
 	id2 := uuid.New().String()
-	if _, err := repo.CreateUpdate(ctx, "USD", "EUR", id2); err != nil {
+	if _, err := repo.CreateUpdate(ctx, repository.Pair{Base: "USD", Quote: "EUR"}, id2, repository.OriginAPI); err != nil {
 		t.Fatalf("CreateUpdate 2: %v", err)
 	}
-	if err := repo.MarkRunning(ctx, id2); err != nil {
+	if err := repo.MarkRunning(ctx, id2, 1, ""); err != nil {
 		t.Fatalf("MarkRunning 2: %v", err)
 	}
-	if err := repo.MarkSuccess(ctx, id2, "1.2000"); err != nil {
+	if err := repo.MarkSuccess(ctx, id2, 2, "1.2000", time.Now()); err != nil {
 		t.Fatalf("MarkSuccess 2: %v", err)
 	}
 
-	q, err := repo.GetLatestSuccess(ctx, "USD", "EUR")
+	q, err := repo.GetLatestSuccess(ctx, repository.Pair{Base: "USD", Quote: "EUR"})
 	if err != nil {
 		t.Fatalf("GetLatestSuccess: %v", err)
 	}
@@ -346,21 +621,57 @@ func TestGetLatestSuccess(t *testing.T) {
 	if q.ID != id2 {
 		t.Fatalf("expected latest id %s, got %s", id2, q.ID)
 	}
-	if q.Price == nil || *q.Price != "1.200000" {
+	if q.Price == nil || *q.Price != "1.2000" {
 		var got string
 		if q.Price != nil {
 			got = *q.Price
 		}
-		t.Fatalf("expected price 1.200000, got %s", got)
+		t.Fatalf("expected price 1.2000, got %s", got)
+	}
+}
+
+func TestGetLatestSuccess_NewestRateWins(t *testing.T) {
+	t.Parallel()
+	ctx := testContext(t)
+	repo := NewIsolatedRepo(t)
+	pair := repository.Pair{Base: "USD", Quote: "CHF"}
+	observed := time.Now().Add(-time.Minute)
+
+	// The update completing last carries the older rate.
+	for _, u := range []struct {
+		price string
+		at    time.Time
+	}{
+		{"0.8800", observed},
+		{"0.8700", observed.Add(-30 * time.Second)},
+	} {
+		id := uuid.New().String()
+		if _, err := repo.CreateUpdate(ctx, pair, id, repository.OriginAPI); err != nil {
+			t.Fatalf("CreateUpdate: %v", err)
+		}
+		if err := repo.MarkRunning(ctx, id, 1, ""); err != nil {
+			t.Fatalf("MarkRunning: %v", err)
+		}
+		if err := repo.MarkSuccess(ctx, id, 2, u.price, u.at); err != nil {
+			t.Fatalf("MarkSuccess: %v", err)
+		}
+	}
+
+	q, err := repo.GetLatestSuccess(ctx, pair)
+	if err != nil {
+		t.Fatalf("GetLatestSuccess: %v", err)
+	}
+	if q == nil || q.Price == nil || *q.Price != "0.8800" {
+		t.Fatalf("expected the newest rate 0.8800, got %+v", q)
 	}
 }
 
 func TestGetLatestSuccess_NotFound(t *testing.T) {
-	resetTestData(t)
+	t.Parallel()
 	ctx := testContext(t)
-	repo := newRepo()
+	repo := NewIsolatedRepo(t)
 
-	q, err := repo.GetLatestSuccess(ctx, "AAA", "BBB")
+	q, err := repo.GetLatestSuccess(ctx, repository.Pair{Base: "AAA", Quote: "BBB"})
 	if err != nil {
 		t.Fatalf("GetLatestSuccess: %v", err)
 	}
@@ -368,3 +679,183 @@ func TestGetLatestSuccess_NotFound(t *testing.T) {
 		t.Fatalf("expected nil for unknown pair, got %+v", q)
 	}
 }
+
+func TestGetLatestAny(t *testing.T) {
+	t.Parallel()
+	ctx := testContext(t)
+	repo := NewIsolatedRepo(t)
+
+	t.Run("never attempted", func(t *testing.T) {
+		q, err := repo.GetLatestAny(ctx, repository.Pair{Base: "USD", Quote: "NOK"})
+		if err != nil {
+			t.Fatalf("GetLatestAny: %v", err)
+		}
+		if q != nil {
+			t.Fatalf("expected nil for a pair without updates, got %+v", q)
+		}
+	})
+
+	t.Run("recently failed", func(t *testing.T) {
+		id := uuid.New().String()
+		if _, err := repo.CreateUpdate(ctx, repository.Pair{Base: "USD", Quote: "NOK"}, id, repository.OriginAPI); err != nil {
+			t.Fatalf("CreateUpdate: %v", err)
+		}
+		if err := repo.MarkFailed(ctx, id, 1, repository.ErrorCodeUnsupportedPair, "unsupported currency pair"); err != nil {
+			t.Fatalf("MarkFailed: %v", err)
+		}
+
+		q, err := repo.GetLatestAny(ctx, repository.Pair{Base: "USD", Quote: "NOK"})
+		if err != nil {
+			t.Fatalf("GetLatestAny: %v", err)
+		}
+		if q == nil || q.ID != id || q.Status != repository.StatusFailed {
+			t.Fatalf("expected FAILED update %s, got %+v", id, q)
+		}
+		if q.ErrorMsg == nil || *q.ErrorMsg != "unsupported currency pair" {
+			t.Fatalf("expected error message, got %v", q.ErrorMsg)
+		}
+	})
+}
+
+func TestLatestSuccessTimes(t *testing.T) {
+	t.Parallel()
+	ctx := testContext(t)
+	repo := NewIsolatedRepo(t)
+
+	completedID := uuid.New().String()
+	if _, err := repo.CreateUpdate(ctx, repository.Pair{Base: "EUR", Quote: "SEK"}, completedID, repository.OriginAPI); err != nil {
+		t.Fatalf("CreateUpdate: %v", err)
+	}
+	if err := repo.MarkRunning(ctx, completedID, 1, ""); err != nil {
+		t.Fatalf("MarkRunning: %v", err)
+	}
+	if err := repo.MarkSuccess(ctx, completedID, 2, "11.2000", time.Now()); err != nil {
+		t.Fatalf("MarkSuccess: %v", err)
+	}
+	failedID := uuid.New().String()
+	if _, err := repo.CreateUpdate(ctx, repository.Pair{Base: "EUR", Quote: "NOK"}, failedID, repository.OriginAPI); err != nil {
+		t.Fatalf("CreateUpdate: %v", err)
+	}
+	if err := repo.MarkFailed(ctx, failedID, 1, repository.ErrorCodeProviderUnavailable, "provider down"); err != nil {
+		t.Fatalf("MarkFailed: %v", err)
+	}
+	completed, err := repo.GetByID(ctx, completedID)
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+
+	t.Run("tracked pairs", func(t *testing.T) {
+		pairs := []repository.Pair{{Base: "EUR", Quote: "SEK"}, {Base: "EUR", Quote: "NOK"}, {Base: "EUR", Quote: "DKK"}}
+		got, err := repo.LatestSuccessTimes(ctx, pairs)
+		if err != nil {
+			t.Fatalf("LatestSuccessTimes: %v", err)
+		}
+		if len(got) != 3 {
+			t.Fatalf("expected one row per tracked pair, got %+v", got)
+		}
+		for _, f := range got {
+			switch f.Pair {
+			case pairs[0]:
+				if f.LatestSuccess == nil || !f.LatestSuccess.Equal(*completed.UpdatedAt) {
+					t.Errorf("EUR/SEK: expected %v, got %v", completed.UpdatedAt, f.LatestSuccess)
+				}
+			default:
+				if f.LatestSuccess != nil {
+					t.Errorf("%s/%s: expected no success, got %v", f.Base, f.Quote, f.LatestSuccess)
+				}
+			}
+		}
+	})
+
+	t.Run("all pairs", func(t *testing.T) {
+		got, err := repo.LatestSuccessTimes(ctx, nil)
+		if err != nil {
+			t.Fatalf("LatestSuccessTimes: %v", err)
+		}
+		if len(got) != 2 || got[0].Quote != "NOK" || got[0].LatestSuccess != nil ||
+			got[1].Quote != "SEK" || got[1].LatestSuccess == nil {
+			t.Fatalf("expected EUR/NOK without success and EUR/SEK with one, got %+v", got)
+		}
+	})
+}
+
+func TestGetPriceAtTime(t *testing.T) {
+	resetTestData(t)
+	ctx := testContext(t)
+	repo := newRepo()
+
+	t1 := time.Date(2024, 6, 15, 10, 0, 0, 0, time.UTC)
+	t2 := time.Date(2024, 6, 15, 14, 0, 0, 0, time.UTC)
+	id1 := insertSuccessRecord(t, "EUR", "USD", "1.0800")
+	id2 := insertSuccessRecord(t, "EUR", "USD", "1.0900")
+	for id, ts := range map[string]time.Time{id1: t1, id2: t2} {
+		if _, err := testDB.ExecContext(ctx, "UPDATE quotes SET updated_at=$1 WHERE id=$2::uuid", ts, id); err != nil {
+			t.Fatalf("set updated_at: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		at     time.Time
+		wantID string
+	}{
+		{"between records", time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC), id1},
+		{"exactly at record", t2, id2},
+		{"after all records", t2.Add(time.Hour), id2},
+		{"before all records", t1.Add(-time.Second), ""},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			q, err := repo.GetPriceAtTime(ctx, repository.Pair{Base: "EUR", Quote: "USD"}, tc.at)
+			if err != nil {
+				t.Fatalf("GetPriceAtTime: %v", err)
+			}
+			if tc.wantID == "" {
+				if q != nil {
+					t.Fatalf("expected nil, got %+v", q)
+				}
+				return
+			}
+			if q == nil || q.ID != tc.wantID {
+				t.Fatalf("expected record %s, got %+v", tc.wantID, q)
+			}
+		})
+	}
+}
+
+func TestCountByStatus(t *testing.T) {
+	t.Parallel()
+	ctx := testContext(t)
+	repo := NewIsolatedRepo(t)
+
+	for _, quote := range []string{"SEK", "NOK", "DKK"} {
+		if _, err := repo.CreateUpdate(ctx, repository.Pair{Base: "EUR", Quote: quote}, uuid.New().String(), repository.OriginAPI); err != nil {
+			t.Fatalf("CreateUpdate: %v", err)
+		}
+	}
+	// A deduplicated request does not add a record.
+	if _, err := repo.CreateUpdate(ctx, repository.Pair{Base: "EUR", Quote: "SEK"}, uuid.New().String(), repository.OriginAPI); err != nil {
+		t.Fatalf("CreateUpdate: %v", err)
+	}
+	runningID := uuid.New().String()
+	if _, err := repo.CreateUpdate(ctx, repository.Pair{Base: "EUR", Quote: "PLN"}, runningID, repository.OriginAPI); err != nil {
+		t.Fatalf("CreateUpdate: %v", err)
+	}
+	if err := repo.MarkRunning(ctx, runningID, repository.InitialVersion, ""); err != nil {
+		t.Fatalf("MarkRunning: %v", err)
+	}
+
+	for status, want := range map[repository.Status]int{
+		repository.StatusPending: 3,
+		repository.StatusRunning: 1,
+		repository.StatusSuccess: 0,
+	} {
+		got, err := repo.CountByStatus(ctx, status)
+		if err != nil {
+			t.Fatalf("CountByStatus(%s): %v", status, err)
+		}
+		if got != want {
+			t.Errorf("CountByStatus(%s) = %d, want %d", status, got, want)
+		}
+	}
+}
@@ -0,0 +1,224 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"quoteservice/internal/config"
+	"quoteservice/internal/repository"
+	"quoteservice/internal/worker"
+)
+
+// insertAgedSuccess stores a SUCCESS update for base/quote and backdates it by age.
+func insertAgedSuccess(ctx context.Context, t *testing.T, db *sql.DB, repo repository.QuoteRepository,
+	base, quote, price string, age time.Duration) string {
+	t.Helper()
+	id := uuid.New().String()
+	if _, err := repo.CreateUpdate(ctx, repository.Pair{Base: base, Quote: quote}, id, repository.OriginAPI); err != nil {
+		t.Fatalf("CreateUpdate: %v", err)
+	}
+	if err := repo.MarkRunning(ctx, id, 1, ""); err != nil {
+		t.Fatalf("MarkRunning: %v", err)
+	}
+	if err := repo.MarkSuccess(ctx, id, 2, price, time.Now()); err != nil {
+		t.Fatalf("MarkSuccess: %v", err)
+	}
+	if _, err := db.ExecContext(ctx, `UPDATE quotes SET updated_at = NOW() - $1::interval WHERE id = $2::uuid`,
+		age.String(), id); err != nil {
+		t.Fatalf("backdate %s: %v", id, err)
+	}
+	return id
+}
+
+func countRows(ctx context.Context, t *testing.T, db *sql.DB, query string, args ...any) int {
+	t.Helper()
+	var n int
+	if err := db.QueryRowContext(ctx, query, args...).Scan(&n); err != nil {
+		t.Fatalf("%s: %v", query, err)
+	}
+	return n
+}
+
+func TestRetention_LatestQuoteUnaffected(t *testing.T) {
+	t.Parallel()
+	const day = 24 * time.Hour
+
+	for _, mode := range []string{config.RetentionModeSoftDelete, config.RetentionModeArchiveTable} {
+		t.Run(mode, func(t *testing.T) {
+			t.Parallel()
+			ctx := testContext(t)
+			db := newIsolatedDB(t)
+			repo := repository.NewPostgresQuoteRepository(db)
+
+			oldest := insertAgedSuccess(ctx, t, db, repo, "EUR", "USD", "1.0100", 200*day)
+			older := insertAgedSuccess(ctx, t, db, repo, "EUR", "USD", "1.0200", 150*day)
+			latest := insertAgedSuccess(ctx, t, db, repo, "EUR", "USD", "1.0300", day)
+			// The only quote of a pair is kept however old it is.
+			onlyGBP := insertAgedSuccess(ctx, t, db, repo, "EUR", "GBP", "0.8500", 300*day)
+
+			archiver := repository.NewPostgresQuoteArchiver(db, mode)
+			cutoff := time.Now().Add(-90 * day)
+			// A batch of one proves batches continue where the previous stopped.
+			for _, want := range []int64{1, 1, 0} {
+				n, err := archiver.ArchiveBatch(ctx, cutoff, 1)
+				if err != nil {
+					t.Fatalf("ArchiveBatch: %v", err)
+				}
+				if n != want {
+					t.Fatalf("expected %d archived, got %d", want, n)
+				}
+			}
+
+			q, err := repo.GetLatestSuccess(ctx, repository.Pair{Base: "EUR", Quote: "USD"})
+			if err != nil || q == nil || q.ID != latest {
+				t.Fatalf("EUR/USD latest: expected %s, got %+v (err %v)", latest, q, err)
+			}
+			q, err = repo.GetLatestSuccess(ctx, repository.Pair{Base: "EUR", Quote: "GBP"})
+			if err != nil || q == nil || q.ID != onlyGBP {
+				t.Fatalf("EUR/GBP latest: expected %s, got %+v (err %v)", onlyGBP, q, err)
+			}
+			q, err = repo.GetPriceAtTime(ctx, repository.Pair{Base: "EUR", Quote: "USD"}, time.Now().Add(-100*day))
+			if err != nil || q != nil {
+				t.Fatalf("expected no live history before the cutoff, got %+v (err %v)", q, err)
+			}
+
+			archived := []any{oldest, older}
+			switch mode {
+			case config.RetentionModeSoftDelete:
+				if n := countRows(ctx, t, db, `SELECT COUNT(*) FROM quotes
+                    WHERE archived_at IS NOT NULL AND id IN ($1::uuid, $2::uuid)`, archived...); n != 2 {
+					t.Errorf("expected 2 soft-deleted rows, got %d", n)
+				}
+				if q, err := repo.GetByID(ctx, oldest); err != nil || q == nil {
+					t.Errorf("expected soft-deleted update to stay readable by ID, got %+v (err %v)", q, err)
+				}
+			case config.RetentionModeArchiveTable:
+				if n := countRows(ctx, t, db, `SELECT COUNT(*) FROM quotes_archive
+                    WHERE id IN ($1::uuid, $2::uuid)`, archived...); n != 2 {
+					t.Errorf("expected 2 rows in quotes_archive, got %d", n)
+				}
+				if n := countRows(ctx, t, db, `SELECT COUNT(*) FROM quote_status_events_archive
+                    WHERE update_id IN ($1::uuid, $2::uuid)`, archived...); n != 6 {
+					t.Errorf("expected 6 archived status events, got %d", n)
+				}
+				if n := countRows(ctx, t, db, `SELECT COUNT(*) FROM quotes`); n != 2 {
+					t.Errorf("expected 2 live rows, got %d", n)
+				}
+			}
+		})
+	}
+}
+
+// TestRetention_OutOfOrderRates stores a newer rate before an older one whose write
+// was slow. GetLatestSuccess returns the newer rate, so retention must archive the
+// later-written older rate and keep the other, although it was written first.
+func TestRetention_OutOfOrderRates(t *testing.T) {
+	t.Parallel()
+	const day = 24 * time.Hour
+
+	for _, mode := range []string{config.RetentionModeSoftDelete, config.RetentionModeArchiveTable} {
+		t.Run(mode, func(t *testing.T) {
+			t.Parallel()
+			ctx := testContext(t)
+			db := newIsolatedDB(t)
+			repo := repository.NewPostgresQuoteRepository(db)
+			pair := repository.Pair{Base: "EUR", Quote: "USD"}
+
+			newerRate := insertAgedSuccess(ctx, t, db, repo, "EUR", "USD", "1.0300", 200*day)
+			olderRate := insertAgedSuccess(ctx, t, db, repo, "EUR", "USD", "1.0100", 150*day)
+			for id, age := range map[string]time.Duration{newerRate: 201 * day, olderRate: 250 * day} {
+				if _, err := db.ExecContext(ctx, `UPDATE quotes SET rate_timestamp = NOW() - $1::interval WHERE id = $2::uuid`,
+					age.String(), id); err != nil {
+					t.Fatalf("backdate rate of %s: %v", id, err)
+				}
+			}
+			if q, err := repo.GetLatestSuccess(ctx, pair); err != nil || q == nil || q.ID != newerRate {
+				t.Fatalf("expected latest %s before retention, got %+v (err %v)", newerRate, q, err)
+			}
+
+			archiver := repository.NewPostgresQuoteArchiver(db, mode)
+			n, err := archiver.ArchiveBatch(ctx, time.Now().Add(-90*day), 10)
+			if err != nil {
+				t.Fatalf("ArchiveBatch: %v", err)
+			}
+			if n != 1 {
+				t.Fatalf("expected 1 archived, got %d", n)
+			}
+
+			q, err := repo.GetLatestSuccess(ctx, pair)
+			if err != nil || q == nil || q.ID != newerRate {
+				t.Fatalf("expected latest %s after retention, got %+v (err %v)", newerRate, q, err)
+			}
+			if n := countRows(ctx, t, db, `SELECT COUNT(*) FROM quotes
+                WHERE id = $1::uuid AND archived_at IS NULL`, newerRate); n != 1 {
+				t.Errorf("expected %s to stay live", newerRate)
+			}
+		})
+	}
+}
+
+// backdateEvents moves every status event of update id age into the past.
+func backdateEvents(ctx context.Context, t *testing.T, db *sql.DB, id string, age time.Duration) {
+	t.Helper()
+	if _, err := db.ExecContext(ctx, `UPDATE quote_status_events SET at = NOW() - $1::interval WHERE update_id = $2::uuid`,
+		age.String(), id); err != nil {
+		t.Fatalf("backdate events of %s: %v", id, err)
+	}
+}
+
+func TestRetention_PerTableHorizons(t *testing.T) {
+	t.Parallel()
+	const day = 24 * time.Hour
+	ctx := testContext(t)
+	db := newIsolatedDB(t)
+	repo := repository.NewPostgresQuoteRepository(db)
+
+	// Past both horizons: archived, and its events deleted.
+	old := insertAgedSuccess(ctx, t, db, repo, "EUR", "USD", "1.0100", 200*day)
+	backdateEvents(ctx, t, db, old, 200*day)
+	// Past the events horizon only: the update stays, its events go.
+	mid := insertAgedSuccess(ctx, t, db, repo, "EUR", "USD", "1.0200", 60*day)
+	backdateEvents(ctx, t, db, mid, 60*day)
+	// Within both horizons.
+	recent := insertAgedSuccess(ctx, t, db, repo, "EUR", "USD", "1.0300", day)
+	backdateEvents(ctx, t, db, recent, day)
+	// An update in flight keeps its events however old they are.
+	pending := uuid.New().String()
+	if _, err := repo.CreateUpdate(ctx, repository.Pair{Base: "EUR", Quote: "GBP"}, pending, repository.OriginAPI); err != nil {
+		t.Fatalf("CreateUpdate: %v", err)
+	}
+	backdateEvents(ctx, t, db, pending, 60*day)
+
+	archiver := repository.NewPostgresQuoteArchiver(db, config.RetentionModeSoftDelete)
+	job := worker.NewRetentionJob([]worker.RetentionTable{
+		{Name: "quotes", MaxAge: 90 * day, Batch: archiver.ArchiveBatch},
+		{Name: "quote_status_events", MaxAge: 30 * day, Batch: archiver.DeleteEventsBatch},
+	}, 2, time.Hour, nil, zap.NewNop().Sugar())
+
+	rows, err := job.RunOnce(ctx)
+	if err != nil {
+		t.Fatalf("RunOnce: %v", err)
+	}
+	if rows["quotes"] != 1 || rows["quote_status_events"] != 6 {
+		t.Errorf("expected 1 archived update and 6 deleted events, got %v", rows)
+	}
+
+	if n := countRows(ctx, t, db, `SELECT COUNT(*) FROM quotes WHERE archived_at IS NOT NULL`); n != 1 {
+		t.Errorf("expected 1 soft-deleted update, got %d", n)
+	}
+	if n := countRows(ctx, t, db, `SELECT COUNT(*) FROM quotes WHERE archived_at IS NULL`); n != 3 {
+		t.Errorf("expected 3 live updates, got %d", n)
+	}
+	for id, want := range map[string]int{old: 0, mid: 0, recent: 3, pending: 1} {
+		if n := countRows(ctx, t, db, `SELECT COUNT(*) FROM quote_status_events WHERE update_id = $1::uuid`, id); n != want {
+			t.Errorf("update %s: expected %d events, got %d", id, want, n)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+//go:build integration
+
+package integration
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"quoteservice/internal/repository"
+	"quoteservice/internal/service"
+	"quoteservice/internal/testkit"
+)
+
+func TestVerifySchema_Migrated(t *testing.T) {
+	t.Parallel()
+	if err := repository.VerifySchema(testContext(t), newIsolatedDB(t)); err != nil {
+		t.Fatalf("expected a migrated schema to pass, got %v", err)
+	}
+}
+
+func TestVerifySchema_MissingColumn(t *testing.T) {
+	t.Parallel()
+	ctx := testContext(t)
+	db := newIsolatedDB(t)
+	if _, err := db.ExecContext(ctx, `ALTER TABLE quotes DROP COLUMN fetch_duration_ms;
+	                                  DROP TABLE webhooks`); err != nil {
+		t.Fatalf("alter schema: %v", err)
+	}
+
+	var schemaErr *repository.SchemaError
+	if err := repository.VerifySchema(ctx, db); !errors.As(err, &schemaErr) {
+		t.Fatalf("expected a *SchemaError, got %v", err)
+	}
+	if !reflect.DeepEqual(schemaErr.MissingTables, []string{"webhooks"}) ||
+		!reflect.DeepEqual(schemaErr.MissingColumns, []string{"quotes.fetch_duration_ms"}) {
+		t.Errorf("unexpected schema error %+v", schemaErr)
+	}
+}
+
+func TestSchemaNotReady_WithoutMigrations(t *testing.T) {
+	t.Parallel()
+	ctx := testContext(t)
+	db := testkit.Global().NewEmptySchema(ctx, t)
+
+	var schemaErr *repository.SchemaError
+	if err := repository.VerifySchema(ctx, db); !errors.As(err, &schemaErr) {
+		t.Fatalf("expected a *SchemaError, got %v", err)
+	}
+	if len(schemaErr.MissingTables) != 5 || len(schemaErr.MissingColumns) != 0 {
+		t.Errorf("expected every table to be missing, got %+v", schemaErr)
+	}
+
+	repo := repository.NewPostgresQuoteRepository(db)
+	pair := repository.Pair{Base: "EUR", Quote: "USD"}
+	if _, err := repo.GetLatestSuccess(ctx, pair); !repository.IsSchemaError(err) {
+		t.Errorf("expected a schema error from GetLatestSuccess, got %v", err)
+	}
+	reporter := repository.NewPostgresReliabilityReporter(db)
+	if _, err := reporter.ReliabilityReport(ctx, time.Now().Add(-time.Hour), time.Now(), 10,
+		func(repository.ReliabilityRow) error { return nil }); !repository.IsSchemaError(err) {
+		t.Errorf("expected a schema error from ReliabilityReport, got %v", err)
+	}
+
+	svc := service.NewQuoteService(service.QuoteServiceDeps{Repo: repo})
+	if _, err := svc.GetLatestQuote(ctx, pair); !errors.Is(err, service.ErrSchemaNotReady) {
+		t.Errorf("expected ErrSchemaNotReady from GetLatestQuote, got %v", err)
+	}
+	if _, err := svc.GetQuoteResult(ctx, "8f14e45f-ceea-4672-9b0d-3f8b5e7a1c2d"); !errors.Is(err, service.ErrSchemaNotReady) {
+		t.Errorf("expected ErrSchemaNotReady from GetQuoteResult, got %v", err)
+	}
+}
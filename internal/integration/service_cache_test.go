@@ -5,6 +5,7 @@ package integration
 import (
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"go.uber.org/zap"
@@ -24,7 +25,13 @@ func newCacheTestService() *service.QuoteService {
 		ExchangeProviderPriceTTLSec: 3600,
 	}
 	v := service.NewValidator()
-	return service.NewQuoteService(repo, nil, v, nil, testRDB, logger, cacheCfg)
+	return service.NewQuoteService(service.QuoteServiceDeps{
+		Repo:        repo,
+		Validator:   v,
+		Cache:       testRDB,
+		Logger:      logger,
+		CacheConfig: cacheCfg,
+	})
 }
 
 // insertSuccessRecord is a test helper that creates a quote record and
@@ -35,13 +42,13 @@ func insertSuccessRecord(t *testing.T, base, quote, price string) string {
 	repo := repository.NewPostgresQuoteRepository(testDB)
 
 	id := uuid.New().String()
-	if _, err := repo.CreateUpdate(ctx, base, quote, id); err != nil {
+	if _, err := repo.CreateUpdate(ctx, repository.Pair{Base: base, Quote: quote}, id, repository.OriginAPI); err != nil {
 		t.Fatalf("CreateUpdate: %v", err)
 	}
-	if err := repo.MarkRunning(ctx, id); err != nil {
+	if err := repo.MarkRunning(ctx, id, 1, ""); err != nil {
 		t.Fatalf("MarkRunning: %v", err)
 	}
-	if err := repo.MarkSuccess(ctx, id, price); err != nil {
+	if err := repo.MarkSuccess(ctx, id, 2, price, time.Now()); err != nil {
 		t.Fatalf("MarkSuccess: %v", err)
 	}
 	return id
@@ -54,19 +61,19 @@ func TestGetLatestQuote_CacheMiss_DBHit(t *testing.T) {
 	insertSuccessRecord(t, "USD", "EUR", "1.0500")
 
 	svc := newCacheTestService()
-	q, err := svc.GetLatestQuote(ctx, "USD", "EUR")
+	q, err := svc.GetLatestQuote(ctx, service.Pair{Base: "USD", Quote: "EUR"})
 	if err != nil {
 		t.Fatalf("GetLatestQuote: %v", err)
 	}
 	if q == nil {
 		t.Fatal("expected quote, got nil")
 	}
-	if q.Price == nil || *q.Price != "1.050000" {
+	if q.Price == nil || *q.Price != "1.0500" {
 		var got string
 		if q.Price != nil {
 			got = *q.Price
 		}
-		t.Fatalf("expected price 1.050000, got %s", got)
+		t.Fatalf("expected price 1.0500, got %s", got)
 	}
 
 	// Verify cache was populated: truncate DB and call again.
@@ -75,11 +82,11 @@ func TestGetLatestQuote_CacheMiss_DBHit(t *testing.T) {
 		t.Fatalf("truncate: %v", err)
 	}
 
-	q2, err := svc.GetLatestQuote(ctx, "USD", "EUR")
+	q2, err := svc.GetLatestQuote(ctx, service.Pair{Base: "USD", Quote: "EUR"})
 	if err != nil {
 		t.Fatalf("GetLatestQuote (after truncate): %v", err)
 	}
-	if q2 == nil || q2.Price == nil || *q2.Price != "1.050000" {
+	if q2 == nil || q2.Price == nil || *q2.Price != "1.0500" {
 		t.Fatal("expected cached result after DB truncate")
 	}
 }
@@ -91,26 +98,26 @@ func TestGetLatestQuote_CacheHit(t *testing.T) {
 	// Populate cache by querying a real DB record through the service.
 	insertSuccessRecord(t, "GBP", "JPY", "182.5000")
 	svc := newCacheTestService()
-	svc.GetLatestQuote(ctx, "GBP", "JPY") // populates cache
+	svc.GetLatestQuote(ctx, service.Pair{Base: "GBP", Quote: "JPY"}) // populates cache
 
 	// Truncate DB — proves the next call MUST come from cache.
 	if _, err := testDB.ExecContext(ctx, "TRUNCATE TABLE quotes CASCADE"); err != nil {
 		t.Fatalf("truncate: %v", err)
 	}
 
-	q, err := svc.GetLatestQuote(ctx, "GBP", "JPY")
+	q, err := svc.GetLatestQuote(ctx, service.Pair{Base: "GBP", Quote: "JPY"})
 	if err != nil {
 		t.Fatalf("GetLatestQuote: %v", err)
 	}
 	if q == nil {
 		t.Fatal("expected quote from cache, got nil")
 	}
-	if q.Price == nil || *q.Price != "182.500000" {
+	if q.Price == nil || *q.Price != "182.5000" {
 		var got string
 		if q.Price != nil {
 			got = *q.Price
 		}
-		t.Fatalf("expected price 182.500000, got %s", got)
+		t.Fatalf("expected price 182.5000, got %s", got)
 	}
 	if q.Base != "GBP" || q.Quote != "JPY" {
 		t.Fatalf("expected GBP/JPY, got %s/%s", q.Base, q.Quote)
@@ -122,7 +129,7 @@ func TestGetLatestQuote_NotFound(t *testing.T) {
 	ctx := testContext(t)
 
 	svc := newCacheTestService()
-	_, err := svc.GetLatestQuote(ctx, "USD", "NOK") // Changed to supported currencies that aren't in DB
+	_, err := svc.GetLatestQuote(ctx, service.Pair{Base: "USD", Quote: "NOK"}) // Changed to supported currencies that aren't in DB
 	if err == nil {
 		t.Fatal("expected error, got nil")
 	}
@@ -136,7 +143,7 @@ func TestGetLatestQuote_Unsupported(t *testing.T) {
 	ctx := testContext(t)
 
 	svc := newCacheTestService()
-	_, err := svc.GetLatestQuote(ctx, "AAA", "BBB")
+	_, err := svc.GetLatestQuote(ctx, service.Pair{Base: "AAA", Quote: "BBB"})
 	if err == nil {
 		t.Fatal("expected error, got nil")
 	}
@@ -144,3 +151,30 @@ func TestGetLatestQuote_Unsupported(t *testing.T) {
 		t.Fatalf("expected ErrUnsupportedCurrency, got %v", err)
 	}
 }
+
+func TestGetLatestQuote_TimestampsIdenticalAcrossTiers(t *testing.T) {
+	resetTestData(t)
+	ctx := testContext(t)
+	service.SetTimestampPrecision(6)
+	t.Cleanup(func() { service.SetTimestampPrecision(0) })
+
+	insertSuccessRecord(t, "USD", "EUR", "1.0500")
+	svc := newCacheTestService()
+
+	fromDB, err := svc.GetLatestQuote(ctx, service.Pair{Base: "USD", Quote: "EUR"}) // Cache miss: served from Postgres.
+	if err != nil {
+		t.Fatalf("GetLatestQuote (DB): %v", err)
+	}
+	fromCache, err := svc.GetLatestQuote(ctx, service.Pair{Base: "USD", Quote: "EUR"})
+	if err != nil {
+		t.Fatalf("GetLatestQuote (cache): %v", err)
+	}
+
+	if *fromDB.UpdatedAt != *fromCache.UpdatedAt || *fromDB.RateTimestamp != *fromCache.RateTimestamp {
+		t.Fatalf("timestamps differ between tiers: DB %s/%s, cache %s/%s",
+			*fromDB.UpdatedAt, *fromDB.RateTimestamp, *fromCache.UpdatedAt, *fromCache.RateTimestamp)
+	}
+	if _, err := time.Parse("2006-01-02T15:04:05.000000Z", *fromDB.UpdatedAt); err != nil {
+		t.Errorf("expected UTC timestamp with 6 fractional digits, got %s", *fromDB.UpdatedAt)
+	}
+}
@@ -4,6 +4,7 @@ package integration
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
@@ -40,16 +41,23 @@ func TestProcessUpdate_FullLifecycle(t *testing.T) {
 		ExchangeProviderPriceTTLSec: 3600,
 	}
 	v := service.NewValidator()
-	svc := service.NewQuoteService(repo, prov, v, nil, testRDB, logger, cacheCfg)
+	svc := service.NewQuoteService(service.QuoteServiceDeps{
+		Repo:        repo,
+		Provider:    prov,
+		Validator:   v,
+		Cache:       testRDB,
+		Logger:      logger,
+		CacheConfig: cacheCfg,
+	})
 
 	// 1. Create a PENDING record.
 	id := uuid.New().String()
-	if _, err := repo.CreateUpdate(ctx, "USD", "EUR", id); err != nil {
+	if _, err := repo.CreateUpdate(ctx, repository.Pair{Base: "USD", Quote: "EUR"}, id, repository.OriginAPI); err != nil {
 		t.Fatalf("CreateUpdate: %v", err)
 	}
 
 	// 2. Process the update (marks RUNNING, fetches rate, marks SUCCESS, caches).
-	if err := svc.ProcessUpdate(ctx, id, "USD", "EUR"); err != nil {
+	if err := svc.ProcessUpdate(ctx, service.UpdateQuotePayload{UpdateID: id, Pair: service.Pair{Base: "USD", Quote: "EUR"}}); err != nil {
 		t.Fatalf("ProcessUpdate: %v", err)
 	}
 
@@ -61,12 +69,12 @@ func TestProcessUpdate_FullLifecycle(t *testing.T) {
 	if q.Status != "SUCCESS" {
 		t.Fatalf("expected SUCCESS, got %s", q.Status)
 	}
-	if q.Price == nil || *q.Price != "1.085000" {
+	if q.Price == nil || *q.Price != "1.0850" {
 		var got string
 		if q.Price != nil {
 			got = *q.Price
 		}
-		t.Fatalf("expected price 1.085000, got %s", got)
+		t.Fatalf("expected price 1.0850, got %s", got)
 	}
 
 	// 4. Verify cache was populated via GetLatestQuote after truncating DB.
@@ -74,7 +82,7 @@ func TestProcessUpdate_FullLifecycle(t *testing.T) {
 		t.Fatalf("truncate: %v", err)
 	}
 
-	cached, err := svc.GetLatestQuote(ctx, "USD", "EUR")
+	cached, err := svc.GetLatestQuote(ctx, service.Pair{Base: "USD", Quote: "EUR"})
 	if err != nil {
 		t.Fatalf("GetLatestQuote (from cache): %v", err)
 	}
@@ -82,3 +90,127 @@ func TestProcessUpdate_FullLifecycle(t *testing.T) {
 		t.Fatal("expected cached rate 1.0850 after DB truncate")
 	}
 }
+
+// failingProvider always fails with a retryable provider error.
+type failingProvider struct{}
+
+func (failingProvider) GetRate(context.Context, string, string) (string, time.Time, error) {
+	return "", time.Time{}, &provider.ProviderError{Code: 503, Message: "upstream down", Retryable: true}
+}
+
+// transitionRecorder counts MarkRunning calls on top of the real repository.
+type transitionRecorder struct {
+	repository.QuoteRepository
+	markRunning int
+}
+
+func (r *transitionRecorder) MarkRunning(ctx context.Context, id string, version int64, instanceID string) error {
+	r.markRunning++
+	return r.QuoteRepository.MarkRunning(ctx, id, version, instanceID)
+}
+
+func TestProcessUpdate_CircuitOpen_FailsWithoutRunning(t *testing.T) {
+	resetTestData(t)
+	ctx := testContext(t)
+
+	repo := &transitionRecorder{QuoteRepository: repository.NewPostgresQuoteRepository(testDB)}
+	breaker := provider.NewCircuitBreakerProvider(failingProvider{}, 1, time.Hour)
+	// Force the circuit open with one failed call.
+	if _, _, err := breaker.GetRate(ctx, "USD", "EUR"); err == nil {
+		t.Fatal("expected provider failure")
+	}
+	if breaker.IsProviderAvailable("USD", "EUR") {
+		t.Fatal("expected circuit to be open")
+	}
+
+	svc := service.NewQuoteService(service.QuoteServiceDeps{
+		Repo:      repo,
+		Provider:  breaker,
+		Validator: service.NewValidator(),
+		Cache:     testRDB,
+		Logger:    zap.NewNop().Sugar(),
+		CacheConfig: config.CacheConfig{
+			LatestPriceTTLSec:           3600,
+			ExchangeProviderPriceTTLSec: 3600,
+		},
+	})
+
+	id := uuid.New().String()
+	if _, err := repo.CreateUpdate(ctx, repository.Pair{Base: "USD", Quote: "EUR"}, id, repository.OriginAPI); err != nil {
+		t.Fatalf("CreateUpdate: %v", err)
+	}
+
+	err := svc.ProcessUpdate(ctx, service.UpdateQuotePayload{UpdateID: id, Pair: service.Pair{Base: "USD", Quote: "EUR"}})
+	if !errors.Is(err, service.ErrServiceUnavailable) {
+		t.Fatalf("expected ErrServiceUnavailable, got %v", err)
+	}
+	if repo.markRunning != 0 {
+		t.Errorf("expected no RUNNING transition, got %d MarkRunning calls", repo.markRunning)
+	}
+
+	q, err := repo.GetByID(ctx, id)
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	if q.Status != repository.StatusFailed {
+		t.Fatalf("expected FAILED, got %s", q.Status)
+	}
+	if q.ErrorMsg == nil || *q.ErrorMsg != service.ErrServiceUnavailable.Error() {
+		t.Errorf("expected error %q, got %v", service.ErrServiceUnavailable.Error(), q.ErrorMsg)
+	}
+}
+
+func TestProcessUpdate_RecordsInstance(t *testing.T) {
+	t.Parallel()
+	ctx := testContext(t)
+	db := newIsolatedDB(t)
+
+	repo := repository.NewPostgresQuoteRepository(db)
+	svc := service.NewQuoteService(service.QuoteServiceDeps{
+		Repo:       repo,
+		Provider:   &fakeProvider{rate: "1.0850"},
+		Logger:     zap.NewNop().Sugar(),
+		InstanceID: "worker-1-a1b2c3",
+	})
+
+	id := uuid.New().String()
+	if _, err := repo.CreateUpdate(ctx, repository.Pair{Base: "USD", Quote: "EUR"}, id, repository.OriginAPI); err != nil {
+		t.Fatalf("CreateUpdate: %v", err)
+	}
+	if err := svc.ProcessUpdate(ctx, service.UpdateQuotePayload{UpdateID: id, Pair: service.Pair{Base: "USD", Quote: "EUR"}}); err != nil {
+		t.Fatalf("ProcessUpdate: %v", err)
+	}
+
+	var column string
+	if err := db.QueryRowContext(ctx, "SELECT instance_id FROM quotes WHERE id = $1", id).Scan(&column); err != nil {
+		t.Fatalf("read instance_id: %v", err)
+	}
+	if column != "worker-1-a1b2c3" {
+		t.Errorf("expected quotes.instance_id worker-1-a1b2c3, got %q", column)
+	}
+
+	q, err := svc.GetQuoteResult(ctx, id)
+	if err != nil {
+		t.Fatalf("GetQuoteResult: %v", err)
+	}
+	if q.InstanceID != "worker-1-a1b2c3" {
+		t.Errorf("expected the result to carry the instance, got %q", q.InstanceID)
+	}
+
+	events, err := repo.GetStatusEvents(ctx, id)
+	if err != nil {
+		t.Fatalf("GetStatusEvents: %v", err)
+	}
+	if len(events) != 3 {
+		t.Fatalf("expected 3 events, got %+v", events)
+	}
+	for _, ev := range events {
+		want := ""
+		if ev.Status == repository.StatusRunning {
+			want = "worker-1-a1b2c3"
+		}
+		if ev.InstanceID != want {
+			t.Errorf("%s event: expected instance %q, got %q", ev.Status, want, ev.InstanceID)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+//go:build integration
+
+package integration
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"quoteservice/internal/repository"
+)
+
+func assertTimeline(t *testing.T, events []repository.StatusEvent, want ...repository.Status) {
+	t.Helper()
+	if len(events) != len(want) {
+		t.Fatalf("expected %d events %v, got %+v", len(want), want, events)
+	}
+	for i, ev := range events {
+		if ev.Status != want[i] {
+			t.Errorf("event %d: expected %s, got %s", i, want[i], ev.Status)
+		}
+		if i > 0 && ev.At.Before(events[i-1].At) {
+			t.Errorf("event %d (%s at %s) is before event %d (%s at %s)",
+				i, ev.Status, ev.At, i-1, events[i-1].Status, events[i-1].At)
+		}
+	}
+}
+
+func TestStatusEvents_SuccessTimeline(t *testing.T) {
+	t.Parallel()
+	ctx := testContext(t)
+	repo := NewIsolatedRepo(t)
+
+	id := uuid.New().String()
+	if _, err := repo.CreateUpdate(ctx, repository.Pair{Base: "EUR", Quote: "USD"}, id, repository.OriginAPI); err != nil {
+		t.Fatalf("CreateUpdate: %v", err)
+	}
+	if err := repo.MarkRunning(ctx, id, 1, ""); err != nil {
+		t.Fatalf("MarkRunning: %v", err)
+	}
+	if err := repo.MarkSuccess(ctx, id, 2, "1.0825", time.Now()); err != nil {
+		t.Fatalf("MarkSuccess: %v", err)
+	}
+
+	events, err := repo.GetStatusEvents(ctx, id)
+	if err != nil {
+		t.Fatalf("GetStatusEvents: %v", err)
+	}
+	assertTimeline(t, events, repository.StatusPending, repository.StatusRunning, repository.StatusSuccess)
+
+	q, err := repo.GetByID(ctx, id)
+	if err != nil || q == nil {
+		t.Fatalf("GetByID: %v, %v", q, err)
+	}
+	if !events[0].At.Equal(q.RequestedAt) {
+		t.Errorf("PENDING at %s, expected requested_at %s", events[0].At, q.RequestedAt)
+	}
+	if q.UpdatedAt == nil || !events[2].At.Equal(*q.UpdatedAt) {
+		t.Errorf("SUCCESS at %s, expected updated_at %v", events[2].At, q.UpdatedAt)
+	}
+	if events[2].Detail == nil || *events[2].Detail != "1.0825" {
+		t.Errorf("expected SUCCESS detail 1.082500, got %v", events[2].Detail)
+	}
+	if events[0].Detail != nil || events[1].Detail != nil {
+		t.Errorf("expected no detail on PENDING/RUNNING, got %v, %v", events[0].Detail, events[1].Detail)
+	}
+}
+
+func TestStatusEvents_RetryAfterFailure(t *testing.T) {
+	t.Parallel()
+	ctx := testContext(t)
+	repo := NewIsolatedRepo(t)
+
+	id := uuid.New().String()
+	if _, err := repo.CreateUpdate(ctx, repository.Pair{Base: "EUR", Quote: "USD"}, id, repository.OriginAPI); err != nil {
+		t.Fatalf("CreateUpdate: %v", err)
+	}
+	if err := repo.MarkRunning(ctx, id, 1, ""); err != nil {
+		t.Fatalf("MarkRunning: %v", err)
+	}
+	if err := repo.MarkFailed(ctx, id, 2, repository.ErrorCodeTimeout, "provider timeout"); err != nil {
+		t.Fatalf("MarkFailed: %v", err)
+	}
+	if err := repo.MarkRunning(ctx, id, 3, ""); err != nil {
+		t.Fatalf("MarkRunning (retry): %v", err)
+	}
+	if err := repo.MarkSuccess(ctx, id, 4, "1.08", time.Now()); err != nil {
+		t.Fatalf("MarkSuccess: %v", err)
+	}
+
+	events, err := repo.GetStatusEvents(ctx, id)
+	if err != nil {
+		t.Fatalf("GetStatusEvents: %v", err)
+	}
+	assertTimeline(t, events,
+		repository.StatusPending, repository.StatusRunning, repository.StatusFailed,
+		repository.StatusRunning, repository.StatusSuccess)
+	if events[2].Detail == nil || *events[2].Detail != "provider timeout" {
+		t.Errorf("expected FAILED detail %q, got %v", "provider timeout", events[2].Detail)
+	}
+}
+
+func TestStatusEvents_NoEventWithoutTransition(t *testing.T) {
+	t.Parallel()
+	ctx := testContext(t)
+	repo := NewIsolatedRepo(t)
+
+	id := uuid.New().String()
+	if _, err := repo.CreateUpdate(ctx, repository.Pair{Base: "EUR", Quote: "USD"}, id, repository.OriginAPI); err != nil {
+		t.Fatalf("CreateUpdate: %v", err)
+	}
+	// Deduplicated onto the in-flight update: no second PENDING event.
+	if got, err := repo.CreateUpdate(ctx, repository.Pair{Base: "EUR", Quote: "USD"}, uuid.New().String(), repository.OriginAPI); err != nil || got.ID != id || got.Created {
+		t.Fatalf("expected dedup onto %s, got %+v, %v", id, got, err)
+	}
+	// Rejected transition: no SUCCESS event.
+	if err := repo.MarkSuccess(ctx, id, 1, "1.08", time.Now()); err == nil {
+		t.Fatal("expected MarkSuccess from PENDING to fail")
+	}
+
+	events, err := repo.GetStatusEvents(ctx, id)
+	if err != nil {
+		t.Fatalf("GetStatusEvents: %v", err)
+	}
+	assertTimeline(t, events, repository.StatusPending)
+}
+
+func TestStatusEvents_UnknownID(t *testing.T) {
+	t.Parallel()
+	ctx := testContext(t)
+
+	events, err := NewIsolatedRepo(t).GetStatusEvents(ctx, uuid.New().String())
+	if err != nil {
+		t.Fatalf("GetStatusEvents: %v", err)
+	}
+	if len(events) != 0 {
+		t.Fatalf("expected no events, got %+v", events)
+	}
+}
@@ -0,0 +1,51 @@
+//go:build integration
+
+package integration
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"quoteservice/internal/repository"
+)
+
+func TestWebhookRepo_CreateAndDelete(t *testing.T) {
+	resetTestData(t)
+	ctx := testContext(t)
+	repo := repository.NewPostgresWebhookRepository(testDB)
+
+	w := &repository.Webhook{
+		ID:         uuid.New().String(),
+		Base:       "USD",
+		Quote:      "EUR",
+		URL:        "https://example.com/hook",
+		SecretHash: "deadbeef",
+	}
+	if err := repo.CreateWebhook(ctx, w); err != nil {
+		t.Fatalf("CreateWebhook: %v", err)
+	}
+	if w.CreatedAt.IsZero() {
+		t.Fatal("expected CreatedAt to be set")
+	}
+
+	dup := *w
+	dup.ID = uuid.New().String()
+	if err := repo.CreateWebhook(ctx, &dup); !errors.Is(err, repository.ErrWebhookExists) {
+		t.Fatalf("expected ErrWebhookExists for duplicate pair/url, got %v", err)
+	}
+
+	other := dup
+	other.Quote = "GBP"
+	if err := repo.CreateWebhook(ctx, &other); err != nil {
+		t.Fatalf("CreateWebhook for another pair: %v", err)
+	}
+
+	if err := repo.DeleteWebhook(ctx, w.ID); err != nil {
+		t.Fatalf("DeleteWebhook: %v", err)
+	}
+	if err := repo.DeleteWebhook(ctx, w.ID); !errors.Is(err, repository.ErrWebhookNotFound) {
+		t.Fatalf("expected ErrWebhookNotFound on second delete, got %v", err)
+	}
+}
@@ -0,0 +1,136 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	"quoteservice/internal/testkit"
+	"quoteservice/internal/worker"
+)
+
+// workerPoolRedisDB keeps the pool's asynq queues apart from the cache tests' keys.
+const workerPoolRedisDB = 3
+
+func TestWorkerPool_ReconfigureDrainsBeforeRestart(t *testing.T) {
+	redisOpt := asynq.RedisClientOpt{Addr: testkit.Global().RedisAddr(), DB: workerPoolRedisDB}
+	rdb := redis.NewClient(&redis.Options{Addr: redisOpt.Addr, DB: workerPoolRedisDB})
+	t.Cleanup(func() {
+		_ = rdb.FlushDB(context.Background()).Err()
+		_ = rdb.Close()
+	})
+	if err := rdb.FlushDB(testContext(t)).Err(); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+
+	var (
+		mu          sync.Mutex
+		events      []string
+		inFlight    int
+		maxInFlight int
+	)
+	record := func(ev string, delta int) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, ev)
+		inFlight += delta
+		maxInFlight = max(maxInFlight, inFlight)
+	}
+	oldStarted := make(chan struct{})
+	releaseOld := make(chan struct{})
+	allNewRunning := make(chan struct{})
+	var newStarted sync.WaitGroup
+	newStarted.Add(3)
+
+	handler := asynq.HandlerFunc(func(ctx context.Context, task *asynq.Task) error {
+		switch task.Type() {
+		case "old":
+			record("old:start", 1)
+			close(oldStarted)
+			<-releaseOld
+			record("old:end", -1)
+		case "new":
+			record("new:start", 1)
+			newStarted.Done()
+			select {
+			case <-allNewRunning: // Released once all three run at the same time.
+			case <-ctx.Done():
+			}
+			record("new:end", -1)
+		}
+		return nil
+	})
+
+	pool := worker.NewPool(redisOpt, asynq.Config{
+		TaskCheckInterval: 50 * time.Millisecond,
+		ShutdownTimeout:   10 * time.Second,
+	}, worker.PoolConfig{Concurrency: 1, TaskTimeout: 30 * time.Second}, handler, nil, zap.NewNop().Sugar())
+	if err := pool.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	t.Cleanup(pool.Shutdown)
+
+	client := asynq.NewClient(redisOpt)
+	t.Cleanup(func() { _ = client.Close() })
+	if _, err := client.Enqueue(asynq.NewTask("old", nil)); err != nil {
+		t.Fatalf("enqueue old: %v", err)
+	}
+	select {
+	case <-oldStarted:
+	case <-time.After(10 * time.Second):
+		t.Fatal("old task did not start")
+	}
+
+	reconfigured := make(chan error, 1)
+	go func() {
+		reconfigured <- pool.Reconfigure(worker.PoolConfig{Concurrency: 3, TaskTimeout: 30 * time.Second})
+	}()
+	for range 3 {
+		if _, err := client.Enqueue(asynq.NewTask("new", nil)); err != nil {
+			t.Fatalf("enqueue new: %v", err)
+		}
+	}
+
+	// While the old task runs, Reconfigure must wait and no new worker may pick up tasks.
+	select {
+	case err := <-reconfigured:
+		t.Fatalf("Reconfigure returned before the old task drained: %v", err)
+	case <-time.After(500 * time.Millisecond):
+	}
+	close(releaseOld)
+
+	select {
+	case err := <-reconfigured:
+		if err != nil {
+			t.Fatalf("Reconfigure: %v", err)
+		}
+	case <-time.After(15 * time.Second):
+		t.Fatal("Reconfigure did not return after the old task finished")
+	}
+
+	done := make(chan struct{})
+	go func() { newStarted.Wait(); close(done) }()
+	select {
+	case <-done:
+		close(allNewRunning)
+	case <-time.After(10 * time.Second):
+		close(allNewRunning)
+		t.Fatal("new pool did not run three tasks concurrently")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(events) < 2 || events[0] != "old:start" || events[1] != "old:end" {
+		t.Errorf("expected the old task to finish before any new task started, got %v", events)
+	}
+	if maxInFlight != 3 {
+		t.Errorf("expected 3 tasks in flight with the new concurrency, got %d", maxInFlight)
+	}
+}
@@ -0,0 +1,115 @@
+// Package lifecycle logs the milestones of an instance's startup and shutdown as
+// structured events that deploy tooling can parse, and tracks the resulting phase.
+package lifecycle
+
+import (
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Event names a lifecycle milestone. Each is logged as a "Lifecycle event" line whose
+// "event" field holds the name.
+type Event string
+
+// Lifecycle events, in the order an instance normally emits them. HTTPListening is
+// emitted once per listener, after its port is bound.
+const (
+	ConfigLoaded      Event = "config_loaded"
+	MigrationsApplied Event = "migrations_applied" // With "count".
+	WorkerStarted     Event = "worker_started"
+	HTTPListening     Event = "http_listening" // With "listener" and "addr".
+	Draining          Event = "draining"
+	ShutdownComplete  Event = "shutdown_complete"
+)
+
+// Phase is the state an instance is in between lifecycle events.
+type Phase string
+
+// Lifecycle phases.
+const (
+	PhaseStarting Phase = "starting" // Until the first HTTP listener is bound.
+	PhaseServing  Phase = "serving"
+	PhaseDraining Phase = "draining"
+	PhaseStopped  Phase = "stopped"
+)
+
+// phaseAfter lists the events that move the instance into another phase; the others
+// leave it where it is.
+var phaseAfter = map[Event]Phase{
+	HTTPListening:    PhaseServing,
+	Draining:         PhaseDraining,
+	ShutdownComplete: PhaseStopped,
+}
+
+// Record is an emitted event.
+type Record struct {
+	Event Event
+	At    time.Time
+}
+
+// State is a snapshot of an Emitter: the current phase, when it was entered and the
+// events emitted so far.
+type State struct {
+	Phase  Phase
+	Since  time.Time
+	Events []Record
+}
+
+// Emitter logs lifecycle events and tracks the phase. A nil *Emitter discards events,
+// so code shared with short-lived commands need not check for one.
+type Emitter struct {
+	logger *zap.SugaredLogger
+	now    func() time.Time
+
+	mu     sync.Mutex
+	phase  Phase
+	since  time.Time
+	events []Record
+}
+
+// NewEmitter returns an Emitter in PhaseStarting that logs to logger.
+func NewEmitter(logger *zap.SugaredLogger) *Emitter {
+	e := &Emitter{logger: logger, now: time.Now, phase: PhaseStarting}
+	e.since = e.now()
+	return e
+}
+
+// Emit records event and logs it with keysAndValues. A phase only moves forward:
+// a late HTTPListening, e.g. of the internal listener, does not end draining.
+func (e *Emitter) Emit(event Event, keysAndValues ...any) {
+	if e == nil {
+		return
+	}
+	e.mu.Lock()
+	now := e.now()
+	e.events = append(e.events, Record{Event: event, At: now})
+	if next, ok := phaseAfter[event]; ok && phaseOrder(next) > phaseOrder(e.phase) {
+		e.phase, e.since = next, now
+	}
+	phase := e.phase
+	e.mu.Unlock()
+
+	e.logger.Infow("Lifecycle event", append([]any{"event", string(event), "phase", string(phase)}, keysAndValues...)...)
+}
+
+// State returns the current phase and the events emitted so far.
+func (e *Emitter) State() State {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return State{Phase: e.phase, Since: e.since, Events: append([]Record(nil), e.events...)}
+}
+
+func phaseOrder(p Phase) int {
+	switch p {
+	case PhaseServing:
+		return 1
+	case PhaseDraining:
+		return 2
+	case PhaseStopped:
+		return 3
+	default:
+		return 0
+	}
+}
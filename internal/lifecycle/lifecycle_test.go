@@ -0,0 +1,63 @@
+package lifecycle
+
+import (
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestEmitter(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	e := NewEmitter(zap.New(core).Sugar())
+	clock := time.Date(2025, 12, 1, 10, 0, 0, 0, time.UTC)
+	e.now = func() time.Time {
+		clock = clock.Add(time.Second)
+		return clock
+	}
+
+	steps := []struct {
+		event Event
+		phase Phase
+	}{
+		{ConfigLoaded, PhaseStarting},
+		{MigrationsApplied, PhaseStarting},
+		{HTTPListening, PhaseServing},
+		{WorkerStarted, PhaseServing},
+		{Draining, PhaseDraining},
+		{HTTPListening, PhaseDraining}, // A listener bound late does not undo draining.
+		{ShutdownComplete, PhaseStopped},
+	}
+	for i, s := range steps {
+		e.Emit(s.event, "step", i)
+		if got := e.State().Phase; got != s.phase {
+			t.Errorf("After %s: expected phase %s, got %s", s.event, s.phase, got)
+		}
+	}
+
+	state := e.State()
+	if len(state.Events) != len(steps) {
+		t.Fatalf("Expected %d events, got %d", len(steps), len(state.Events))
+	}
+	if want := time.Date(2025, 12, 1, 10, 0, 7, 0, time.UTC); !state.Since.Equal(want) {
+		t.Errorf("Expected stopped since %v, got %v", want, state.Since)
+	}
+
+	entries := logs.All()
+	if len(entries) != len(steps) {
+		t.Fatalf("Expected %d log lines, got %d", len(steps), len(entries))
+	}
+	for i, s := range steps {
+		fields := entries[i].ContextMap()
+		if entries[i].Message != "Lifecycle event" || fields["event"] != string(s.event) || fields["phase"] != string(s.phase) ||
+			fields["step"] != int64(i) {
+			t.Errorf("Line %d: unexpected %q %v", i, entries[i].Message, fields)
+		}
+	}
+}
+
+func TestEmitter_Nil(t *testing.T) {
+	var e *Emitter
+	e.Emit(ConfigLoaded) // Must not panic.
+}
@@ -0,0 +1,189 @@
+// Package lock provides a Redis lock that lets a single instance of the service at a
+// time run a background job, such as retention or cache warm-up, that every replica
+// would otherwise run and duplicate.
+package lock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	"quoteservice/internal/metrics"
+	"quoteservice/internal/rediskey"
+)
+
+// DefaultTTL is the lifetime of a job lock between extensions. It bounds how long a
+// crashed instance keeps a job from running elsewhere.
+const DefaultTTL = 30 * time.Second
+
+// releaseTimeout bounds releasing a lock, which runs even after the job's context is
+// cancelled so a shutdown frees the lock without waiting on Redis for long.
+const releaseTimeout = 2 * time.Second
+
+var (
+	// ErrNotAcquired is returned when another instance holds the lock.
+	ErrNotAcquired = errors.New("lock held by another instance")
+	// ErrNotHeld is returned when extending or releasing a lock that expired and may
+	// have been taken by another instance.
+	ErrNotHeld = errors.New("lock no longer held")
+)
+
+// extendScript resets the TTL of the lock if ARGV[1] still holds it.
+var extendScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
+
+// releaseScript deletes the lock if ARGV[1] still holds it, or with a positive ARGV[2]
+// leaves it to expire after that many milliseconds.
+var releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) ~= ARGV[1] then
+	return 0
+end
+if tonumber(ARGV[2]) > 0 then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return redis.call("DEL", KEYS[1])`)
+
+// Locker takes job locks stored under "lock:<job>" in keys' namespace.
+type Locker struct {
+	client *redis.Client
+	keys   rediskey.Namespace
+	ttl    time.Duration
+	logger *zap.SugaredLogger
+}
+
+// NewLocker creates a Locker whose locks live for ttl between extensions.
+func NewLocker(client *redis.Client, keys rediskey.Namespace, ttl time.Duration, logger *zap.SugaredLogger) *Locker {
+	return &Locker{client: client, keys: keys, ttl: ttl, logger: logger}
+}
+
+// Lock is a held job lock. Its token tells it apart from a later holder of the same
+// key, so an expired Lock never extends or releases another instance's lock.
+type Lock struct {
+	client *redis.Client
+	key    string
+	token  string
+	ttl    time.Duration
+}
+
+// Acquire takes the lock of job for the Locker's TTL, or returns ErrNotAcquired if
+// another instance holds it.
+func (l *Locker) Acquire(ctx context.Context, job string) (*Lock, error) {
+	lk := &Lock{client: l.client, key: l.keys.Key("lock:" + job), token: uuid.New().String(), ttl: l.ttl}
+	ok, err := l.client.SetNX(ctx, lk.key, lk.token, l.ttl).Result()
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrNotAcquired
+	}
+	return lk, nil
+}
+
+// Extend resets the lock's TTL. It returns ErrNotHeld if the lock expired.
+func (lk *Lock) Extend(ctx context.Context) error {
+	n, err := extendScript.Run(ctx, lk.client, []string{lk.key}, lk.token, lk.ttl.Milliseconds()).Int()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotHeld
+	}
+	return nil
+}
+
+// Release deletes the lock, or with a positive keep leaves it to expire after keep so
+// other instances cannot take it until then. It returns ErrNotHeld, leaving the key
+// alone, if the lock expired.
+func (lk *Lock) Release(ctx context.Context, keep time.Duration) error {
+	n, err := releaseScript.Run(ctx, lk.client, []string{lk.key}, lk.token, keep.Milliseconds()).Int()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotHeld
+	}
+	return nil
+}
+
+// Do runs fn while holding the lock of job, so no other instance runs the job at the
+// same time. If another instance holds the lock, Do returns ErrNotAcquired without
+// calling fn.
+//
+// The lock is extended every third of the TTL while fn runs. If it is lost, because it
+// expired and could not be extended in time, fn's context is cancelled. When fn returns,
+// the lock is released, or kept until hold has passed since it was taken, so other
+// instances skip the job until then. Releasing uses a short timeout detached from ctx,
+// so a shutdown still frees the lock and is not held up by it. Do returns fn's error.
+func (l *Locker) Do(ctx context.Context, job string, hold time.Duration, fn func(context.Context) error) error {
+	lk, err := l.Acquire(ctx, job)
+	switch {
+	case errors.Is(err, ErrNotAcquired):
+		metrics.JobLockAttemptsTotal.WithLabelValues(job, "contended").Inc()
+		l.logger.Debugw("Job lock held by another instance, skipping run", "job", job)
+		return err
+	case err != nil:
+		metrics.JobLockAttemptsTotal.WithLabelValues(job, "error").Inc()
+		return fmt.Errorf("acquire lock of %s: %w", job, err)
+	}
+	metrics.JobLockAttemptsTotal.WithLabelValues(job, "acquired").Inc()
+	l.logger.Debugw("Job lock acquired", "job", job)
+	acquired := time.Now()
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	heartbeatDone := make(chan struct{})
+	go func() {
+		defer close(heartbeatDone)
+		l.heartbeat(runCtx, lk, job, cancel)
+	}()
+	err = fn(runCtx)
+	cancel()
+	<-heartbeatDone
+
+	releaseCtx, cancelRelease := context.WithTimeout(context.WithoutCancel(ctx), releaseTimeout)
+	defer cancelRelease()
+	if rErr := lk.Release(releaseCtx, hold-time.Since(acquired)); rErr != nil && !errors.Is(rErr, ErrNotHeld) {
+		l.logger.Warnw("Failed to release job lock", "job", job, "error", rErr)
+	}
+	return err
+}
+
+// heartbeat extends lk until ctx is done and calls lost once the lock is gone, or has
+// not been extended for a whole TTL, as another instance may then hold it.
+func (l *Locker) heartbeat(ctx context.Context, lk *Lock, job string, lost context.CancelFunc) {
+	ticker := time.NewTicker(l.ttl / 3)
+	defer ticker.Stop()
+	extended := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		err := lk.Extend(ctx)
+		if err == nil {
+			extended = time.Now()
+			continue
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		if !errors.Is(err, ErrNotHeld) && time.Since(extended) < l.ttl {
+			l.logger.Warnw("Failed to extend job lock, retrying", "job", job, "error", err)
+			continue
+		}
+		metrics.JobLockLostTotal.WithLabelValues(job).Inc()
+		l.logger.Warnw("Job lock lost, cancelling run", "job", job, "error", err)
+		lost()
+		return
+	}
+}
@@ -0,0 +1,208 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	"quoteservice/internal/metrics"
+)
+
+func newTestLocker(t *testing.T, ttl time.Duration) (*Locker, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	return NewLocker(client, "staging", ttl, zap.NewNop().Sugar()), mr
+}
+
+func TestAcquire(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("contention", func(t *testing.T) {
+		l, mr := newTestLocker(t, time.Minute)
+		lk, err := l.Acquire(ctx, "retention")
+		if err != nil {
+			t.Fatalf("Expected the first Acquire to succeed, got %v", err)
+		}
+		if ttl := mr.TTL("staging:lock:retention"); ttl != time.Minute {
+			t.Errorf("Expected the lock to expire after 1m, got %v", ttl)
+		}
+		if _, err := l.Acquire(ctx, "retention"); !errors.Is(err, ErrNotAcquired) {
+			t.Errorf("Expected ErrNotAcquired while the lock is held, got %v", err)
+		}
+		if _, err := l.Acquire(ctx, "reconcile"); err != nil {
+			t.Errorf("Expected another job's lock to be free, got %v", err)
+		}
+
+		if err := lk.Release(ctx, 0); err != nil {
+			t.Fatalf("Expected Release to succeed, got %v", err)
+		}
+		if _, err := l.Acquire(ctx, "retention"); err != nil {
+			t.Errorf("Expected Acquire to succeed after release, got %v", err)
+		}
+	})
+
+	t.Run("expiry takeover", func(t *testing.T) {
+		l, mr := newTestLocker(t, time.Minute)
+		first, err := l.Acquire(ctx, "retention")
+		if err != nil {
+			t.Fatal(err)
+		}
+		mr.FastForward(time.Minute)
+		second, err := l.Acquire(ctx, "retention")
+		if err != nil {
+			t.Fatalf("Expected the expired lock to be taken over, got %v", err)
+		}
+
+		if err := first.Extend(ctx); !errors.Is(err, ErrNotHeld) {
+			t.Errorf("Expected the expired holder's Extend to fail with ErrNotHeld, got %v", err)
+		}
+		if err := second.Extend(ctx); err != nil {
+			t.Errorf("Expected the new holder's Extend to succeed, got %v", err)
+		}
+	})
+
+	t.Run("wrong token release is refused", func(t *testing.T) {
+		l, mr := newTestLocker(t, time.Minute)
+		lk, err := l.Acquire(ctx, "retention")
+		if err != nil {
+			t.Fatal(err)
+		}
+		mr.Set("staging:lock:retention", "other-instance")
+
+		if err := lk.Release(ctx, 0); !errors.Is(err, ErrNotHeld) {
+			t.Errorf("Expected ErrNotHeld, got %v", err)
+		}
+		if got, _ := mr.Get("staging:lock:retention"); got != "other-instance" {
+			t.Errorf("Expected the other instance's lock to be kept, got %q", got)
+		}
+	})
+
+	t.Run("release keeps the lock for a while", func(t *testing.T) {
+		l, mr := newTestLocker(t, time.Minute)
+		lk, err := l.Acquire(ctx, "retention")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := lk.Release(ctx, 5*time.Minute); err != nil {
+			t.Fatalf("Expected Release to succeed, got %v", err)
+		}
+		if ttl := mr.TTL("staging:lock:retention"); ttl != 5*time.Minute {
+			t.Errorf("Expected the lock to be kept for 5m, got %v", ttl)
+		}
+	})
+}
+
+func TestDo(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("runs fn and releases the lock", func(t *testing.T) {
+		l, mr := newTestLocker(t, time.Minute)
+		ran := false
+		err := l.Do(ctx, "do_release", 0, func(context.Context) error {
+			ran = true
+			if !mr.Exists("staging:lock:do_release") {
+				t.Error("Expected the lock to be held while fn runs")
+			}
+			return errors.New("boom")
+		})
+		if err == nil || err.Error() != "boom" || !ran {
+			t.Errorf("Expected fn to run and its error returned, got ran=%v err=%v", ran, err)
+		}
+		if mr.Exists("staging:lock:do_release") {
+			t.Error("Expected the lock to be released")
+		}
+		if got := testutil.ToFloat64(metrics.JobLockAttemptsTotal.WithLabelValues("do_release", "acquired")); got != 1 {
+			t.Errorf("Expected 1 acquisition counted, got %v", got)
+		}
+	})
+
+	t.Run("skips fn while contended", func(t *testing.T) {
+		l, mr := newTestLocker(t, time.Minute)
+		mr.Set("staging:lock:do_contended", "other-instance")
+		err := l.Do(ctx, "do_contended", 0, func(context.Context) error {
+			t.Error("Expected fn not to run")
+			return nil
+		})
+		if !errors.Is(err, ErrNotAcquired) {
+			t.Errorf("Expected ErrNotAcquired, got %v", err)
+		}
+		if got := testutil.ToFloat64(metrics.JobLockAttemptsTotal.WithLabelValues("do_contended", "contended")); got != 1 {
+			t.Errorf("Expected 1 contention counted, got %v", got)
+		}
+	})
+
+	t.Run("hold keeps other instances out", func(t *testing.T) {
+		l, mr := newTestLocker(t, time.Minute)
+		if err := l.Do(ctx, "do_hold", time.Hour, func(context.Context) error { return nil }); err != nil {
+			t.Fatal(err)
+		}
+		if ttl := mr.TTL("staging:lock:do_hold"); ttl <= 59*time.Minute || ttl > time.Hour {
+			t.Errorf("Expected the lock to be kept for about 1h, got %v", ttl)
+		}
+	})
+
+	t.Run("heartbeat extends the lock during a long run", func(t *testing.T) {
+		l, mr := newTestLocker(t, 90*time.Millisecond)
+		err := l.Do(ctx, "do_heartbeat", 0, func(ctx context.Context) error {
+			// miniredis only expires keys on FastForward: age the lock past its TTL in
+			// steps, each followed by a heartbeat.
+			for range 3 {
+				mr.FastForward(60 * time.Millisecond)
+				time.Sleep(60 * time.Millisecond)
+			}
+			if !mr.Exists("staging:lock:do_heartbeat") || ctx.Err() != nil {
+				t.Error("Expected the lock to be held after outliving its TTL")
+			}
+			return nil
+		})
+		if err != nil {
+			t.Errorf("Expected no error, got %v", err)
+		}
+	})
+
+	t.Run("lost lock cancels fn", func(t *testing.T) {
+		l, mr := newTestLocker(t, 90*time.Millisecond)
+		err := l.Do(ctx, "do_lost", 0, func(ctx context.Context) error {
+			mr.Set("staging:lock:do_lost", "other-instance") // Expired and taken over.
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(5 * time.Second):
+				return errors.New("not cancelled")
+			}
+		})
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected fn to be cancelled, got %v", err)
+		}
+		if got, _ := mr.Get("staging:lock:do_lost"); got != "other-instance" {
+			t.Errorf("Expected the other instance's lock to be kept, got %q", got)
+		}
+		if got := testutil.ToFloat64(metrics.JobLockLostTotal.WithLabelValues("do_lost")); got != 1 {
+			t.Errorf("Expected 1 lost lock counted, got %v", got)
+		}
+	})
+
+	t.Run("cancelled context still releases the lock", func(t *testing.T) {
+		l, mr := newTestLocker(t, time.Minute)
+		cctx, cancel := context.WithCancel(ctx)
+		err := l.Do(cctx, "do_shutdown", 0, func(ctx context.Context) error {
+			cancel()
+			<-ctx.Done()
+			return ctx.Err()
+		})
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected the cancellation error, got %v", err)
+		}
+		if mr.Exists("staging:lock:do_shutdown") {
+			t.Error("Expected the lock to be released after cancellation")
+		}
+	})
+}
@@ -0,0 +1,38 @@
+// Package fields defines the structured log fields shared by the API, service and
+// worker, so the same concept is logged under one key everywhere. Log calls pass them
+// to the sugared logger next to ad-hoc key-value pairs:
+//
+//	log.Infow("Enqueued update task", fields.UpdateID(id), fields.Pair(base, quote))
+package fields
+
+import "go.uber.org/zap"
+
+// Keys of the shared fields. Use the constructors below; the keys are exported for
+// values that do not fit them, such as a pair string that failed to parse.
+const (
+	KeyPair       = "pair"
+	KeyUpdateID   = "update_id"
+	KeyProvider   = "provider"
+	KeyInstanceID = "instance_id"
+)
+
+// Pair is the currency pair as "BASE/QUOTE".
+func Pair(base, quote string) zap.Field {
+	return zap.String(KeyPair, base+"/"+quote)
+}
+
+// UpdateID is the id of a quote update.
+func UpdateID(id string) zap.Field {
+	return zap.String(KeyUpdateID, id)
+}
+
+// Provider is the name of an exchange rate provider, e.g. "frankfurter". An empty
+// name, as for an update without a provider override, is logged as is.
+func Provider(name string) zap.Field {
+	return zap.String(KeyProvider, name)
+}
+
+// InstanceID identifies the service process, e.g. the worker that ran a task.
+func InstanceID(id string) zap.Field {
+	return zap.String(KeyInstanceID, id)
+}
@@ -0,0 +1,93 @@
+package fields
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// adHocKeys are log keys that must be written with a helper of this package, mapped
+// to that helper.
+var adHocKeys = map[string]string{
+	KeyPair:        "Pair",
+	"base":         "Pair",
+	"quote":        "Pair",
+	"currency":     "Pair",
+	KeyUpdateID:    "UpdateID",
+	"id":           "UpdateID",
+	"updateID":     "UpdateID",
+	"update":       "UpdateID",
+	KeyProvider:    "Provider",
+	"provider_id":  "Provider",
+	"providerName": "Provider",
+	KeyInstanceID:  "InstanceID",
+	"instance":     "InstanceID",
+}
+
+// sugaredMethods are the zap.SugaredLogger methods taking key-value pairs; With takes
+// them from its first argument, the others after the message.
+var sugaredMethods = map[string]int{
+	"Debugw": 1, "Infow": 1, "Warnw": 1, "Errorw": 1, "DPanicw": 1, "Panicw": 1, "Fatalw": 1,
+	"With": 0,
+}
+
+// TestNoAdHocLogKeys scans the non-test sources of the module for sugared log calls
+// passing a string literal key that one of the helpers covers, so the same concept is
+// not logged under several names again.
+func TestNoAdHocLogKeys(t *testing.T) {
+	root := filepath.Join("..", "..", "..")
+	fset := token.NewFileSet()
+	scanned := 0
+	for _, dir := range []string{"cmd", "internal", "pkg"} {
+		err := filepath.WalkDir(filepath.Join(root, dir), func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() || !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+				return nil
+			}
+			f, err := parser.ParseFile(fset, path, nil, parser.SkipObjectResolution)
+			if err != nil {
+				return err
+			}
+			scanned++
+			ast.Inspect(f, func(n ast.Node) bool {
+				call, ok := n.(*ast.CallExpr)
+				if !ok {
+					return true
+				}
+				sel, ok := call.Fun.(*ast.SelectorExpr)
+				if !ok {
+					return true
+				}
+				first, ok := sugaredMethods[sel.Sel.Name]
+				if !ok {
+					return true
+				}
+				for _, arg := range call.Args[min(first, len(call.Args)):] {
+					lit, ok := arg.(*ast.BasicLit)
+					if !ok || lit.Kind != token.STRING {
+						continue
+					}
+					key, _ := strconv.Unquote(lit.Value)
+					if helper, ok := adHocKeys[key]; ok {
+						t.Errorf("%s: log key %q, use fields.%s", fset.Position(lit.Pos()), key, helper)
+					}
+				}
+				return true
+			})
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Failed to scan %s: %v", dir, err)
+		}
+	}
+	if scanned == 0 {
+		t.Fatal("Expected to scan the module sources, found none")
+	}
+}
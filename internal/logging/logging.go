@@ -0,0 +1,90 @@
+// Package logging builds the service's zap logger.
+package logging
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"quoteservice/internal/config"
+)
+
+// New returns the production JSON logger configured by cfg.
+func New(cfg config.LoggingConfig) (*zap.Logger, error) {
+	return zap.NewProduction(MaxFieldBytes(cfg.MaxFieldBytes))
+}
+
+// MaxFieldBytes truncates log messages, string fields and error messages longer than
+// max bytes, so an error embedding a whole provider response body does not produce a
+// huge log line. A non-positive max leaves entries unchanged.
+func MaxFieldBytes(max int) zap.Option {
+	return zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		if max <= 0 {
+			return core
+		}
+		return &truncatingCore{Core: core, max: max}
+	})
+}
+
+type truncatingCore struct {
+	zapcore.Core
+	max int
+}
+
+func (c *truncatingCore) With(fields []zapcore.Field) zapcore.Core {
+	return &truncatingCore{Core: c.Core.With(c.truncateFields(fields)), max: c.max}
+}
+
+func (c *truncatingCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *truncatingCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	ent.Message = truncate(ent.Message, c.max)
+	return c.Core.Write(ent, c.truncateFields(fields))
+}
+
+// truncateFields returns fields with long strings and error messages truncated,
+// copying the slice only when a field changes. A truncated error is logged as its
+// message string.
+func (c *truncatingCore) truncateFields(fields []zapcore.Field) []zapcore.Field {
+	out, copied := fields, false
+	for i, f := range fields {
+		var s string
+		switch f.Type {
+		case zapcore.StringType:
+			s = f.String
+		case zapcore.ErrorType:
+			if err, ok := f.Interface.(error); ok && err != nil {
+				s = err.Error()
+			}
+		default:
+			continue
+		}
+		if len(s) <= c.max {
+			continue
+		}
+		if !copied {
+			out, copied = append([]zapcore.Field(nil), fields...), true
+		}
+		out[i] = zap.String(f.Key, truncate(s, c.max))
+	}
+	return out
+}
+
+// truncate cuts s to at most max bytes on a rune boundary and notes how much was cut.
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	cut := max
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return fmt.Sprintf("%s...(%d bytes truncated)", s[:cut], len(s)-cut)
+}
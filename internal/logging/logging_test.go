@@ -0,0 +1,70 @@
+package logging
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestMaxFieldBytes(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	log := zap.New(core, MaxFieldBytes(16)).Sugar()
+
+	body := strings.Repeat("x", 100)
+	log.With("body", body).Errorw("Provider returned an error page "+body,
+		"short", "ok", "count", 3,
+		"error", errors.New("frankfurter API returned status 502: "+body))
+
+	entries := logs.All()
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 entry, got %d", len(entries))
+	}
+	e := entries[0]
+	if want := "Provider returne...(116 bytes truncated)"; e.Message != want {
+		t.Errorf("Expected message %q, got %q", want, e.Message)
+	}
+	got := e.ContextMap()
+	want := map[string]any{
+		"body":  "xxxxxxxxxxxxxxxx...(84 bytes truncated)",
+		"short": "ok",
+		"count": int64(3),
+		"error": "frankfurter API ...(121 bytes truncated)",
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("Expected %s %v, got %v", k, v, got[k])
+		}
+	}
+}
+
+func TestMaxFieldBytes_RuneBoundary(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	log := zap.New(core, MaxFieldBytes(4)).Sugar()
+
+	log.Infow("msg", "name", "ééé") // Two bytes per rune: the cut backs off to 4 bytes.
+	log.Infow("msg", "name", "aéé") // The 4th byte is inside a rune: the cut backs off to 3.
+
+	entries := logs.All()
+	for i, want := range []string{"éé...(2 bytes truncated)", "aé...(2 bytes truncated)"} {
+		if got := entries[i].ContextMap()["name"]; got != want {
+			t.Errorf("Expected %q, got %q", want, got)
+		}
+	}
+}
+
+func TestMaxFieldBytes_Disabled(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	log := zap.New(core, MaxFieldBytes(0)).Sugar()
+
+	body := strings.Repeat("x", 10000)
+	log.Infow(body, "body", body)
+
+	e := logs.All()[0]
+	if e.Message != body || e.ContextMap()["body"] != body {
+		t.Error("Expected entries to be unchanged without a limit")
+	}
+}
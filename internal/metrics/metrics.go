@@ -0,0 +1,155 @@
+// Package metrics defines the Prometheus metrics the service exports on /metrics.
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "quotesvc"
+
+// Registry holds every collector served by Handler. A dedicated registry keeps
+// metrics registered by dependencies on the global default out of the output.
+var Registry = prometheus.NewRegistry()
+
+// UnknownTasksTotal counts tasks received with a type no worker handler is
+// registered for, by task type and what was done with them ("skipped" or "deferred").
+var UnknownTasksTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Subsystem: "worker",
+	Name:      "unknown_tasks_total",
+	Help:      "Tasks received with a type this worker has no handler for.",
+}, []string{"task_type", "action"})
+
+// TaskPanicsTotal counts worker tasks whose handler panicked, by task type.
+var TaskPanicsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Subsystem: "worker",
+	Name:      "task_panics_total",
+	Help:      "Tasks whose handler panicked, by task type.",
+}, []string{"task_type"})
+
+// WorkerDrained is 1 while the worker pool is drained by POST /admin/worker/drain.
+var WorkerDrained = prometheus.NewGauge(prometheus.GaugeOpts{
+	Namespace: namespace,
+	Subsystem: "worker",
+	Name:      "drained",
+	Help:      "Whether the worker pool is drained and fetches no new tasks (1) or not (0).",
+})
+
+// WorkerArchivedTasks is the number of archived tasks in each update queue: tasks that
+// exhausted their retries or failed with SkipRetry. Refreshed periodically.
+var WorkerArchivedTasks = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: namespace,
+	Subsystem: "worker",
+	Name:      "archived_tasks",
+	Help:      "Archived tasks in each update queue.",
+}, []string{"queue"})
+
+// WorkerTasksProcessedTotal counts the tasks this process's worker finished, by
+// instance ID and result ("success" or "failure"). A process only ever exports its
+// own instance, so the instance label has one value per scrape target.
+var WorkerTasksProcessedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Subsystem: "worker",
+	Name:      "tasks_processed_total",
+	Help:      "Tasks finished by this worker instance, by result.",
+}, []string{"instance_id", "result"})
+
+// ProviderSpreadExceededTotal counts successful updates whose rate differed across
+// providers by more than verification.spread_threshold_pct.
+var ProviderSpreadExceededTotal = prometheus.NewCounter(prometheus.CounterOpts{
+	Namespace: namespace,
+	Subsystem: "provider",
+	Name:      "spread_exceeded_total",
+	Help:      "Updates whose rate spread across providers exceeded the verification threshold.",
+})
+
+// QuoteUpdatesTotal counts quote updates that reached a terminal status, by status
+// (SUCCESS or FAILED) and the origin that created them.
+var QuoteUpdatesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Subsystem: "quote",
+	Name:      "updates_total",
+	Help:      "Quote updates that reached a terminal status, by status and origin.",
+}, []string{"status", "origin"})
+
+// QuoteUpdateFailuresTotal counts quote updates that FAILED, by error code, e.g.
+// "timeout" or "unsupported_pair".
+var QuoteUpdateFailuresTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Subsystem: "quote",
+	Name:      "update_failures_total",
+	Help:      "Quote updates that failed, by error code.",
+}, []string{"error_code"})
+
+// QuoteLatestAgeSeconds is the age of each exported pair's latest successful quote,
+// refreshed periodically; pairs without one have no series.
+var QuoteLatestAgeSeconds = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: namespace,
+	Subsystem: "quote",
+	Name:      "latest_age_seconds",
+	Help:      "Seconds since the pair's latest successful quote was stored.",
+}, []string{"pair"})
+
+// QuotePairsWithoutSuccess is the number of exported pairs that have no successful quote.
+var QuotePairsWithoutSuccess = prometheus.NewGauge(prometheus.GaugeOpts{
+	Namespace: namespace,
+	Subsystem: "quote",
+	Name:      "pairs_without_success",
+	Help:      "Exported pairs that have no successful quote.",
+})
+
+// JobLockAttemptsTotal counts attempts to take the lock of a singleton background job,
+// by job and result: "acquired", "contended" (held by another instance) or "error".
+var JobLockAttemptsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Subsystem: "job",
+	Name:      "lock_attempts_total",
+	Help:      "Attempts to take the lock of a singleton background job, by job and result.",
+}, []string{"job", "result"})
+
+// JobLockLostTotal counts runs of a singleton job cancelled because its lock expired
+// and could not be extended.
+var JobLockLostTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Subsystem: "job",
+	Name:      "lock_lost_total",
+	Help:      "Singleton job runs cancelled because their lock was lost.",
+}, []string{"job"})
+
+// RetentionRowsTotal counts rows the retention job archived or deleted, by table.
+var RetentionRowsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Subsystem: "retention",
+	Name:      "rows_total",
+	Help:      "Rows archived or deleted by the retention job, by table.",
+}, []string{"table"})
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		UnknownTasksTotal,
+		TaskPanicsTotal,
+		WorkerDrained,
+		WorkerArchivedTasks,
+		WorkerTasksProcessedTotal,
+		ProviderSpreadExceededTotal,
+		QuoteUpdatesTotal,
+		QuoteUpdateFailuresTotal,
+		QuoteLatestAgeSeconds,
+		QuotePairsWithoutSuccess,
+		JobLockAttemptsTotal,
+		JobLockLostTotal,
+		RetentionRowsTotal,
+	)
+}
+
+// Handler serves Registry in the Prometheus exposition format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
@@ -6,28 +6,38 @@ import (
 	"time"
 
 	"github.com/redis/go-redis/v9"
+
+	"quoteservice/internal/clock"
+	"quoteservice/internal/rediskey"
 )
 
-// CachedRatesProviderDecorator wraps a RatesProvider with Redis caching.
+// CachedRatesProviderDecorator wraps a RatesProvider with Redis caching. An entry is
+// served for ttl after it was cached by the decorator's clock; Redis expires it after
+// the same ttl, so stale entries do not pile up.
 type CachedRatesProviderDecorator struct {
 	provider     RatesProvider
 	cache        *redis.Client
 	ttl          time.Duration
 	providerName string
+	keys         rediskey.Namespace
+	clock        clock.Clock
 }
 
-// NewCachedRatesProvider creates a new CachedRatesProviderDecorator.
-func NewCachedRatesProvider(provider RatesProvider, cache *redis.Client, ttl time.Duration, providerName string) *CachedRatesProviderDecorator {
+// NewCachedRatesProvider creates a new CachedRatesProviderDecorator storing rates under
+// keys in keys' namespace.
+func NewCachedRatesProvider(provider RatesProvider, cache *redis.Client, ttl time.Duration, providerName string, keys rediskey.Namespace) *CachedRatesProviderDecorator {
 	return &CachedRatesProviderDecorator{
 		provider:     provider,
 		cache:        cache,
 		ttl:          ttl,
 		providerName: providerName,
+		keys:         keys,
+		clock:        clock.Real,
 	}
 }
 
 func (p *CachedRatesProviderDecorator) cacheKey(base, quote string) string {
-	return fmt.Sprintf("provider_cache:%s:{%s:%s}", p.providerName, base, quote)
+	return p.keys.Key(fmt.Sprintf("provider_cache:%s:{%s:%s}", p.providerName, base, quote))
 }
 
 // GetRate attempts to fetch the rate from cache before calling the underlying provider.
@@ -39,14 +49,14 @@ func (p *CachedRatesProviderDecorator) GetRate(ctx context.Context, base, quote
 	key := p.cacheKey(base, quote)
 
 	// check cache
-	vals, err := p.cache.HMGet(ctx, key, "price", "updated_at").Result()
-	if err == nil && len(vals) == 2 && vals[0] != nil && vals[1] != nil {
+	vals, err := p.cache.HMGet(ctx, key, "price", "updated_at", "cached_at").Result()
+	if err == nil && len(vals) == 3 && vals[0] != nil && vals[1] != nil {
 		price, ok1 := vals[0].(string)
 		tsStr, ok2 := vals[1].(string)
-		if ok1 && ok2 {
-			ts, err2 := time.Parse(time.RFC3339, tsStr)
+		if ok1 && ok2 && p.fresh(vals[2]) {
+			ts, err2 := time.Parse(time.RFC3339Nano, tsStr)
 			if err2 == nil {
-				return price, ts, nil
+				return price, ts.UTC(), nil
 			}
 		}
 	}
@@ -57,11 +67,23 @@ func (p *CachedRatesProviderDecorator) GetRate(ctx context.Context, base, quote
 	}
 
 	pipe := p.cache.Pipeline()
-	pipe.HSet(ctx, key, "price", price, "updated_at", ts.Format(time.RFC3339))
+	pipe.HSet(ctx, key, "price", price, "updated_at", ts.UTC().Format(time.RFC3339Nano),
+		"cached_at", p.clock.Now().UTC().Format(time.RFC3339Nano))
 	pipe.Expire(ctx, key, p.ttl)
 	_, _ = pipe.Exec(ctx)
 
 	return price, ts, nil
 }
 
+// fresh reports whether an entry cached at cachedAt is younger than the ttl. Entries
+// cached before cached_at was stored are left to their Redis expiry.
+func (p *CachedRatesProviderDecorator) fresh(cachedAt any) bool {
+	s, ok := cachedAt.(string)
+	if !ok {
+		return true
+	}
+	at, err := time.Parse(time.RFC3339Nano, s)
+	return err != nil || clock.Since(p.clock, at) < p.ttl
+}
+
 var _ RatesProvider = (*CachedRatesProviderDecorator)(nil)
@@ -9,6 +9,8 @@ import (
 	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+
+	"quoteservice/internal/testkit/fakeclock"
 )
 
 func TestCachedRatesProvider_GetRate(t *testing.T) {
@@ -33,7 +35,7 @@ func TestCachedRatesProvider_GetRate(t *testing.T) {
 		mockProv := new(MockProvider)
 		mockProv.On("GetRate", mock.Anything, base, quote).Return(rate, now, nil).Once()
 
-		cachedProv := NewCachedRatesProvider(mockProv, rdb, ttl, "test_provider")
+		cachedProv := NewCachedRatesProvider(mockProv, rdb, ttl, "test_provider", "")
 
 		// First call - cache miss
 		resRate, resTime, err := cachedProv.GetRate(context.Background(), base, quote)
@@ -54,7 +56,7 @@ func TestCachedRatesProvider_GetRate(t *testing.T) {
 		mockProv := new(MockProvider)
 		mockProv.On("GetRate", mock.Anything, base, quote).Return("", time.Time{}, assert.AnError).Once()
 
-		cachedProv := NewCachedRatesProvider(mockProv, rdb, ttl, "test_provider")
+		cachedProv := NewCachedRatesProvider(mockProv, rdb, ttl, "test_provider", "")
 
 		// First call - provider error
 		_, _, err := cachedProv.GetRate(context.Background(), base, quote)
@@ -68,21 +70,59 @@ func TestCachedRatesProvider_GetRate(t *testing.T) {
 		mockProv.AssertExpectations(t)
 	})
 
+	t.Run("namespace prefixes the key", func(t *testing.T) {
+		mr.FlushAll()
+		mockProv := new(MockProvider)
+		mockProv.On("GetRate", mock.Anything, base, quote).Return(rate, now, nil).Twice()
+
+		_, _, err := NewCachedRatesProvider(mockProv, rdb, ttl, "test_provider", "staging").GetRate(context.Background(), base, quote)
+		assert.NoError(t, err)
+		assert.True(t, mr.Exists("staging:provider_cache:test_provider:{USD:EUR}"), "keys: %v", mr.Keys())
+
+		// Another namespace does not read the cached rate.
+		_, _, err = NewCachedRatesProvider(mockProv, rdb, ttl, "test_provider", "").GetRate(context.Background(), base, quote)
+		assert.NoError(t, err)
+		mockProv.AssertExpectations(t)
+	})
+
 	t.Run("cache expires", func(t *testing.T) {
 		mr.FlushAll()
 		mockProv := new(MockProvider)
 		mockProv.On("GetRate", mock.Anything, base, quote).Return(rate, now, nil).Once()
 
-		cachedProv := NewCachedRatesProvider(mockProv, rdb, ttl, "test_provider")
+		clk := fakeclock.New(now)
+		cachedProv := NewCachedRatesProvider(mockProv, rdb, ttl, "test_provider", "")
+		cachedProv.clock = clk
 
 		_, _, _ = cachedProv.GetRate(context.Background(), base, quote)
+		assert.Equal(t, ttl, mr.TTL("provider_cache:test_provider:{USD:EUR}"))
 
-		mr.FastForward(ttl + time.Second)
+		// Just before the TTL the entry is still served.
+		clk.Advance(ttl - time.Millisecond)
+		_, _, err := cachedProv.GetRate(context.Background(), base, quote)
+		assert.NoError(t, err)
+		mockProv.AssertNumberOfCalls(t, "GetRate", 1)
 
-		// Second call - cache expired, should call provider again
+		// At the TTL it is stale even though Redis has not expired it yet.
+		clk.Advance(time.Millisecond)
 		mockProv.On("GetRate", mock.Anything, base, quote).Return(rate, now, nil).Once()
-		_, _, err := cachedProv.GetRate(context.Background(), base, quote)
+		_, _, err = cachedProv.GetRate(context.Background(), base, quote)
 		assert.NoError(t, err)
 		mockProv.AssertExpectations(t)
 	})
+
+	t.Run("entry without cached_at is served until Redis expires it", func(t *testing.T) {
+		mr.FlushAll()
+		mockProv := new(MockProvider)
+		mr.HSet("provider_cache:test_provider:{USD:EUR}", "price", rate, "updated_at", now.Format(time.RFC3339Nano))
+
+		clk := fakeclock.New(now.Add(time.Hour))
+		cachedProv := NewCachedRatesProvider(mockProv, rdb, ttl, "test_provider", "")
+		cachedProv.clock = clk
+
+		resRate, _, err := cachedProv.GetRate(context.Background(), base, quote)
+		assert.NoError(t, err)
+		assert.Equal(t, rate, resRate)
+		mockProv.AssertNotCalled(t, "GetRate", mock.Anything, mock.Anything, mock.Anything)
+	})
 }
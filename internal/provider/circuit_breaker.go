@@ -0,0 +1,132 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned by CircuitBreakerProvider while its circuit is open.
+// It is retryable, so the facade falls through to the next provider.
+var ErrCircuitOpen = errors.New("provider circuit breaker is open")
+
+// AvailabilityChecker is implemented by providers that can tell, without making a
+// request, whether a call for the pair is currently expected to fail.
+type AvailabilityChecker interface {
+	IsProviderAvailable(base, quote string) bool
+}
+
+// IsAvailable reports whether p may serve the pair. Providers that do not implement
+// AvailabilityChecker are assumed available.
+func IsAvailable(p RatesProvider, base, quote string) bool {
+	ac, ok := p.(AvailabilityChecker)
+	return !ok || ac.IsProviderAvailable(base, quote)
+}
+
+// CircuitBreakerProvider wraps a RatesProvider and stops calling it after
+// failureThreshold consecutive retryable failures. After openDuration one trial
+// request is let through: success closes the circuit, failure re-opens it.
+// Non-retryable errors (e.g. an unknown pair) do not count as failures.
+type CircuitBreakerProvider struct {
+	provider         RatesProvider
+	failureThreshold int
+	openDuration     time.Duration
+	now              func() time.Time
+	onClose          func()
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time
+	trial    bool
+}
+
+// NewCircuitBreakerProvider creates a CircuitBreakerProvider around provider.
+func NewCircuitBreakerProvider(provider RatesProvider, failureThreshold int, openDuration time.Duration) *CircuitBreakerProvider {
+	return &CircuitBreakerProvider{
+		provider:         provider,
+		failureThreshold: failureThreshold,
+		openDuration:     openDuration,
+		now:              time.Now,
+	}
+}
+
+// OnClose sets fn to be called each time the circuit closes again after being open.
+// fn runs on the goroutine of the call that closed it, outside the breaker's lock, so
+// it should return quickly. OnClose must be called before the provider is used.
+func (p *CircuitBreakerProvider) OnClose(fn func()) {
+	p.onClose = fn
+}
+
+// IsProviderAvailable reports whether the circuit would let a request through.
+// The breaker is provider-wide, so base and quote do not affect the answer.
+func (p *CircuitBreakerProvider) IsProviderAvailable(_, _ string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return !p.isOpen()
+}
+
+// GetRate calls the wrapped provider unless the circuit is open.
+func (p *CircuitBreakerProvider) GetRate(ctx context.Context, base, quote string) (string, time.Time, error) {
+	if !p.acquire() {
+		return "", time.Time{}, ErrCircuitOpen
+	}
+
+	rate, ts, err := p.provider.GetRate(ctx, base, quote)
+	p.record(err)
+	return rate, ts, err
+}
+
+// isOpen must be called with mu held.
+func (p *CircuitBreakerProvider) isOpen() bool {
+	if p.openedAt.IsZero() {
+		return false
+	}
+	return p.trial || p.now().Sub(p.openedAt) < p.openDuration
+}
+
+func (p *CircuitBreakerProvider) acquire() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.isOpen() {
+		return false
+	}
+	if !p.openedAt.IsZero() {
+		p.trial = true // Half-open: only this request goes through.
+	}
+	return true
+}
+
+func (p *CircuitBreakerProvider) record(err error) {
+	if p.update(err) && p.onClose != nil {
+		p.onClose()
+	}
+}
+
+// update records the outcome of a call and reports whether it closed an open circuit.
+func (p *CircuitBreakerProvider) update(err error) (closed bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.trial = false
+
+	if errors.Is(err, context.Canceled) {
+		return false // The caller gave up; says nothing about the provider.
+	}
+	if err == nil || !IsRetryable(err) {
+		closed = !p.openedAt.IsZero()
+		p.failures = 0
+		p.openedAt = time.Time{}
+		return closed
+	}
+
+	p.failures++
+	if !p.openedAt.IsZero() || p.failures >= p.failureThreshold {
+		p.openedAt = p.now()
+	}
+	return false
+}
+
+var (
+	_ RatesProvider       = (*CircuitBreakerProvider)(nil)
+	_ AvailabilityChecker = (*CircuitBreakerProvider)(nil)
+)
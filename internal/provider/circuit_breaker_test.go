@@ -0,0 +1,116 @@
+package provider
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestBreaker(p RatesProvider, threshold int) (*CircuitBreakerProvider, *time.Time) {
+	now := time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC)
+	b := NewCircuitBreakerProvider(p, threshold, 30*time.Second)
+	b.now = func() time.Time { return now }
+	return b, &now
+}
+
+func TestCircuitBreakerProvider(t *testing.T) {
+	ctx := context.Background()
+	upstreamDown := &ProviderError{Code: 503, Message: "down", Retryable: true}
+
+	t.Run("opens after threshold consecutive failures", func(t *testing.T) {
+		m := new(MockProvider)
+		m.On("GetRate", ctx, "USD", "EUR").Return("", time.Time{}, upstreamDown).Twice()
+		b, _ := newTestBreaker(m, 2)
+
+		_, _, err := b.GetRate(ctx, "USD", "EUR")
+		require.Error(t, err)
+		assert.True(t, b.IsProviderAvailable("USD", "EUR"))
+
+		_, _, err = b.GetRate(ctx, "USD", "EUR")
+		require.Error(t, err)
+		assert.False(t, b.IsProviderAvailable("USD", "EUR"))
+
+		_, _, err = b.GetRate(ctx, "USD", "EUR")
+		assert.ErrorIs(t, err, ErrCircuitOpen)
+		assert.True(t, IsRetryable(err))
+		m.AssertExpectations(t)
+	})
+
+	t.Run("non-retryable errors do not open the circuit", func(t *testing.T) {
+		m := new(MockProvider)
+		m.On("GetRate", ctx, "USD", "XXX").Return("", time.Time{}, &ProviderError{Message: "unknown pair"})
+		b, _ := newTestBreaker(m, 1)
+
+		_, _, _ = b.GetRate(ctx, "USD", "XXX")
+		assert.True(t, b.IsProviderAvailable("USD", "XXX"))
+	})
+
+	t.Run("half-open trial closes on success and re-opens on failure", func(t *testing.T) {
+		m := new(MockProvider)
+		m.On("GetRate", ctx, "USD", "EUR").Return("", time.Time{}, upstreamDown).Twice()
+		b, now := newTestBreaker(m, 1)
+
+		_, _, _ = b.GetRate(ctx, "USD", "EUR")
+		require.False(t, b.IsProviderAvailable("USD", "EUR"))
+
+		*now = now.Add(31 * time.Second)
+		assert.True(t, b.IsProviderAvailable("USD", "EUR"))
+		_, _, err := b.GetRate(ctx, "USD", "EUR") // Trial fails.
+		require.Error(t, err)
+		assert.False(t, b.IsProviderAvailable("USD", "EUR"))
+
+		*now = now.Add(31 * time.Second)
+		m.ExpectedCalls = nil
+		m.On("GetRate", ctx, "USD", "EUR").Return("1.08", *now, nil)
+		rate, _, err := b.GetRate(ctx, "USD", "EUR")
+		require.NoError(t, err)
+		assert.Equal(t, "1.08", rate)
+		assert.True(t, b.IsProviderAvailable("USD", "EUR"))
+	})
+
+	t.Run("OnClose runs when an open circuit closes", func(t *testing.T) {
+		m := new(MockProvider)
+		m.On("GetRate", ctx, "USD", "EUR").Return("", time.Time{}, upstreamDown).Twice()
+		b, now := newTestBreaker(m, 2)
+		closes := 0
+		b.OnClose(func() {
+			assert.True(t, b.IsProviderAvailable("USD", "EUR"), "OnClose must run outside the lock")
+			closes++
+		})
+
+		_, _, _ = b.GetRate(ctx, "USD", "EUR") // Below the threshold: still closed.
+		m.ExpectedCalls = nil
+		m.On("GetRate", ctx, "USD", "EUR").Return("1.08", *now, nil)
+		_, _, _ = b.GetRate(ctx, "USD", "EUR")
+		assert.Equal(t, 0, closes, "a success on a closed circuit is not a close")
+
+		m.ExpectedCalls = nil
+		m.On("GetRate", ctx, "USD", "EUR").Return("", time.Time{}, upstreamDown).Twice()
+		_, _, _ = b.GetRate(ctx, "USD", "EUR")
+		_, _, _ = b.GetRate(ctx, "USD", "EUR")
+		require.False(t, b.IsProviderAvailable("USD", "EUR"))
+
+		*now = now.Add(31 * time.Second)
+		m.ExpectedCalls = nil
+		m.On("GetRate", ctx, "USD", "EUR").Return("1.08", *now, nil)
+		_, _, err := b.GetRate(ctx, "USD", "EUR")
+		require.NoError(t, err)
+		_, _, _ = b.GetRate(ctx, "USD", "EUR")
+		assert.Equal(t, 1, closes)
+	})
+}
+
+func TestExchangeProviderFacade_IsProviderAvailable(t *testing.T) {
+	ctx := context.Background()
+	m := new(MockProvider)
+	m.On("GetRate", mock.Anything, "USD", "EUR").Return("", time.Time{}, &ProviderError{Code: 503, Retryable: true})
+	open, _ := newTestBreaker(m, 1)
+	_, _, _ = open.GetRate(ctx, "USD", "EUR")
+
+	assert.False(t, NewExchangeProviderFacade(open).IsProviderAvailable("USD", "EUR"))
+	assert.True(t, NewExchangeProviderFacade(open, new(MockProvider)).IsProviderAvailable("USD", "EUR"))
+}
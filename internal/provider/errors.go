@@ -0,0 +1,72 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ErrAllProvidersUnavailable is wrapped by ExchangeProviderFacade.GetRate when every
+// provider it tried failed with a retryable error.
+var ErrAllProvidersUnavailable = errors.New("all providers failed")
+
+// ErrUnsupportedPair is wrapped by a ProviderError when the provider answered but has no
+// rate for the requested pair.
+var ErrUnsupportedPair = errors.New("pair not supported by provider")
+
+// ErrUnauthorized is wrapped by a ProviderError when the provider rejected the
+// credentials in a response body rather than with a 401 or 403 status.
+var ErrUnauthorized = errors.New("provider rejected the credentials")
+
+// ErrSourceMismatch is wrapped by a ProviderError when the provider answered with rates
+// for another base currency than requested. exchangerate.host does this on plans that do
+// not allow changing the source currency, so retrying cannot help until the plan or the
+// configuration changes.
+var ErrSourceMismatch = errors.New("provider answered for a different base currency")
+
+// ProviderError is returned by rate providers. Retryable reports whether the failure is
+// transient (5xx, rate limiting, network or decoding failures) so another provider or a
+// later attempt may succeed; non-retryable failures (bad API key, unsupported pair) will
+// fail the same way again.
+type ProviderError struct {
+	Code      int // HTTP status of the provider response, or 0 if none was received.
+	Message   string
+	Retryable bool
+	Err       error // Underlying cause, if any.
+}
+
+func (e *ProviderError) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *ProviderError) Unwrap() error { return e.Err }
+
+// IsRetryable reports whether err is worth retrying. Errors that are not a ProviderError
+// are assumed to be transient.
+func IsRetryable(err error) bool {
+	var pErr *ProviderError
+	if errors.As(err, &pErr) {
+		return pErr.Retryable
+	}
+	return err != nil
+}
+
+// newStatusError classifies a non-200 provider response. 4xx responses are permanent
+// except 408 and 429, which signal load rather than a bad request.
+func newStatusError(name string, code int, body []byte) *ProviderError {
+	retryable := code >= http.StatusInternalServerError ||
+		code == http.StatusRequestTimeout || code == http.StatusTooManyRequests
+	return &ProviderError{
+		Code:      code,
+		Message:   fmt.Sprintf("%s API returned status %d: %s", name, code, body),
+		Retryable: retryable,
+	}
+}
+
+// newTransportError wraps a failure to reach the provider or read its response.
+func newTransportError(msg string, err error) *ProviderError {
+	return &ProviderError{Message: msg, Retryable: true, Err: err}
+}
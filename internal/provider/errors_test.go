@@ -0,0 +1,161 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetRate_ErrorClassification(t *testing.T) {
+	providers := map[string]func(url string) RatesProvider{
+		"exchangerate_host": func(url string) RatesProvider { return NewExchangeRateHostProvider(url, "key", 1) },
+		"frankfurter":       func(url string) RatesProvider { return NewFrankfurterProvider(url, 1) },
+	}
+
+	tests := []struct {
+		name      string
+		status    int
+		body      string
+		wantCode  int
+		retryable bool
+	}{
+		{"bad request", http.StatusBadRequest, `{}`, http.StatusBadRequest, false},
+		{"unauthorized", http.StatusUnauthorized, `{}`, http.StatusUnauthorized, false},
+		{"not found", http.StatusNotFound, `{}`, http.StatusNotFound, false},
+		{"rate limited", http.StatusTooManyRequests, `{}`, http.StatusTooManyRequests, true},
+		{"server error", http.StatusInternalServerError, `{}`, http.StatusInternalServerError, true},
+		{"bad gateway", http.StatusBadGateway, `{}`, http.StatusBadGateway, true},
+		{"malformed body", http.StatusOK, `{`, 0, true},
+	}
+
+	for provName, newProvider := range providers {
+		for _, tc := range tests {
+			t.Run(provName+"/"+tc.name, func(t *testing.T) {
+				srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+					w.WriteHeader(tc.status)
+					_, _ = w.Write([]byte(tc.body))
+				}))
+				defer srv.Close()
+
+				_, _, err := newProvider(srv.URL).GetRate(context.Background(), "EUR", "USD")
+
+				var pErr *ProviderError
+				require.ErrorAs(t, err, &pErr)
+				assert.Equal(t, tc.wantCode, pErr.Code)
+				assert.Equal(t, tc.retryable, pErr.Retryable)
+				assert.Equal(t, tc.retryable, IsRetryable(err))
+			})
+		}
+	}
+}
+
+func TestGetRate_NetworkErrorIsRetryable(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	url := srv.URL
+	srv.Close()
+
+	for _, p := range []RatesProvider{NewExchangeRateHostProvider(url, "key", 1), NewFrankfurterProvider(url, 1)} {
+		_, _, err := p.GetRate(context.Background(), "EUR", "USD")
+
+		var pErr *ProviderError
+		require.ErrorAs(t, err, &pErr)
+		assert.Zero(t, pErr.Code)
+		assert.True(t, pErr.Retryable)
+		assert.NotNil(t, errors.Unwrap(err))
+	}
+}
+
+func TestExchangeRateHost_InvalidAccessKey(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"success":false,"error":{"code":101,"type":"invalid_access_key","info":"You have not supplied a valid API Access Key."}}`))
+	}))
+	defer srv.Close()
+
+	_, _, err := NewExchangeRateHostProvider(srv.URL, "bad", 1).GetRate(context.Background(), "EUR", "USD")
+
+	require.Error(t, err)
+	assert.False(t, IsRetryable(err))
+	assert.Contains(t, err.Error(), "invalid_access_key")
+	assert.ErrorIs(t, err, ErrUnauthorized)
+}
+
+func TestExchangeRateHost_SourceFallback(t *testing.T) {
+	// A plan without source switching answers source=EUR with USD rates.
+	body, err := os.ReadFile("testdata/exchangerate_host_source_fallback.json")
+	require.NoError(t, err)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "EUR", r.URL.Query().Get("source"))
+		_, _ = w.Write(body)
+	}))
+	defer srv.Close()
+
+	_, _, err = NewExchangeRateHostProvider(srv.URL, "key", 1).GetRate(context.Background(), "EUR", "MXN")
+
+	require.ErrorIs(t, err, ErrSourceMismatch)
+	assert.NotErrorIs(t, err, ErrUnsupportedPair)
+	assert.False(t, IsRetryable(err))
+	assert.Contains(t, err.Error(), "source USD instead of EUR")
+	assert.Contains(t, err.Error(), "plan")
+}
+
+func TestGetRate_UnsupportedPair(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      string
+		retryable bool // A pair missing from the answer may be listed by another provider.
+	}{
+		{"missing rate", `{"success":true,"source":"EUR","quotes":{}}`, true},
+		{"invalid currency", `{"success":false,"error":{"code":202,"type":"invalid_currency_codes","info":"You have provided one or more invalid Currency Codes."}}`, false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				_, _ = w.Write([]byte(tc.body))
+			}))
+			defer srv.Close()
+
+			_, _, err := NewExchangeRateHostProvider(srv.URL, "key", 1).GetRate(context.Background(), "EUR", "XXX")
+			assert.ErrorIs(t, err, ErrUnsupportedPair)
+			assert.Equal(t, tc.retryable, IsRetryable(err))
+		})
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"date":"2025-12-01","rates":{}}`))
+	}))
+	defer srv.Close()
+	_, _, err := NewFrankfurterProvider(srv.URL, 1).GetRate(context.Background(), "EUR", "XXX")
+	assert.ErrorIs(t, err, ErrUnsupportedPair)
+	assert.False(t, IsRetryable(err))
+}
+
+func TestExchangeRateHost_UsageErrorsAreRetryable(t *testing.T) {
+	for _, body := range []string{
+		`{"success":false,"error":{"code":104,"type":"usage_limit_reached","info":"Your monthly usage limit has been reached."}}`,
+		`{"success":false,"error":{"code":106,"type":"rate_limit_reached","info":"You have exceeded the rate limit."}}`,
+		`{"success":false}`,
+	} {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write([]byte(body))
+		}))
+		_, _, err := NewExchangeRateHostProvider(srv.URL, "key", 1).GetRate(context.Background(), "EUR", "USD")
+		srv.Close()
+
+		require.Error(t, err, body)
+		assert.True(t, IsRetryable(err), body)
+		assert.NotErrorIs(t, err, ErrUnauthorized, body)
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	assert.False(t, IsRetryable(nil))
+	assert.True(t, IsRetryable(errors.New("unclassified")))
+	assert.False(t, IsRetryable(&ProviderError{Message: "bad key"}))
+	assert.True(t, IsRetryable(&ProviderError{Message: "down", Retryable: true}))
+}
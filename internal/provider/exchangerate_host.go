@@ -7,7 +7,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"strconv"
+	"strings"
 	"time"
 )
 
@@ -21,14 +21,14 @@ type ExchangeRateHostProvider struct {
 }
 
 // NewExchangeRateHostProvider creates a new ExchangeRateHostProvider with the given configuration.
-func NewExchangeRateHostProvider(baseURL, apiKey string, timeoutSec int) *ExchangeRateHostProvider {
+func NewExchangeRateHostProvider(baseURL, apiKey string, timeoutSec int, opts ...HTTPOption) *ExchangeRateHostProvider {
 	if baseURL == "" {
 		baseURL = "https://api.exchangerate.host"
 	}
 	return &ExchangeRateHostProvider{
 		baseURL: baseURL,
 		apiKey:  apiKey,
-		client:  &http.Client{Timeout: time.Duration(timeoutSec) * time.Second},
+		client:  newHTTPClient(timeoutSec, opts),
 	}
 }
 
@@ -40,9 +40,31 @@ func (p *ExchangeRateHostProvider) getLatestURL(base, quote string) string {
 
 // exchangerate.host latest API response structure
 type erHostResponse struct {
-	Success bool               `json:"success"`
-	Source  string             `json:"source"`
-	Quotes  map[string]float64 `json:"quotes"`
+	Success bool                   `json:"success"`
+	Source  string                 `json:"source"`
+	Quotes  map[string]json.Number `json:"quotes"`
+	Error   *erHostError           `json:"error"`
+}
+
+// erHostError is set when success=false, e.g. for an invalid access key.
+type erHostError struct {
+	Code int    `json:"code"`
+	Type string `json:"type"`
+	Info string `json:"info"`
+}
+
+// cause returns the sentinel matching the documented error code, or nil: 101 and 102
+// are a missing or inactive access key, 201 and 202 an unknown source or quote currency.
+// Only these are permanent; every other code, e.g. 104 for an exhausted monthly quota or
+// a rate limit, is answered with HTTP 200 too but lets another provider be tried.
+func (e *erHostError) cause() error {
+	switch e.Code {
+	case 101, 102:
+		return ErrUnauthorized
+	case 201, 202:
+		return ErrUnsupportedPair
+	}
+	return nil
 }
 
 // GetRate fetches the exchange rate for the given base/quote currency pair.
@@ -50,31 +72,52 @@ func (p *ExchangeRateHostProvider) GetRate(ctx context.Context, base, quote stri
 	reqURL := p.getLatestURL(base, quote)
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, http.NoBody)
 	if err != nil {
-		return "", time.Time{}, fmt.Errorf("external API request creation failed: %w", err)
+		return "", time.Time{}, &ProviderError{Message: "external API request creation failed", Err: err}
 	}
 	resp, err := p.client.Do(req)
 	if err != nil {
-		return "", time.Time{}, fmt.Errorf("external API request failed: %w", err)
+		return "", time.Time{}, newTransportError("external API request failed", err)
 	}
 	defer resp.Body.Close() //nolint:errcheck // best-effort close
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return "", time.Time{}, fmt.Errorf("external API returned status %d: %s", resp.StatusCode, string(body))
+		return "", time.Time{}, newStatusError("external", resp.StatusCode, body)
 	}
 	var result erHostResponse
 	dec := json.NewDecoder(resp.Body)
 	if err := dec.Decode(&result); err != nil {
-		return "", time.Time{}, fmt.Errorf("failed to decode external API response: %w", err)
+		return "", time.Time{}, newTransportError("failed to decode external API response", err)
 	}
 	if !result.Success {
-		return "", time.Time{}, fmt.Errorf("external API returned success=false for %s/%s", base, quote)
+		msg := fmt.Sprintf("external API returned success=false for %s/%s", base, quote)
+		var cause error
+		if result.Error != nil {
+			msg += fmt.Sprintf(": %s (%d) %s", result.Error.Type, result.Error.Code, result.Error.Info)
+			cause = result.Error.cause()
+		}
+		return "", time.Time{}, &ProviderError{Code: resp.StatusCode, Message: msg, Retryable: cause == nil, Err: cause}
+	}
+	// Plans without source switching silently fall back to USD and key the quotes
+	// "USDMXN"; the pair would only be reported missing and retried.
+	if result.Source != "" && !strings.EqualFold(result.Source, base) {
+		return "", time.Time{}, &ProviderError{
+			Code: resp.StatusCode,
+			Message: fmt.Sprintf("external API answered %s/%s with source %s instead of %s; "+
+				"the access key's plan probably does not allow changing the source currency", base, quote, result.Source, base),
+			Err: ErrSourceMismatch,
+		}
 	}
 	// The API returns quotes keyed as "BASEQUOTE", e.g. "EURMXN"
 	key := base + quote
 	rateVal, ok := result.Quotes[key]
 	if !ok {
-		return "", time.Time{}, fmt.Errorf("no rate for %s in response", key)
+		// Retryable so the facade asks the next provider, which may list the pair.
+		return "", time.Time{}, &ProviderError{Code: resp.StatusCode, Message: fmt.Sprintf("no rate for %s in response", key),
+			Retryable: true, Err: ErrUnsupportedPair}
+	}
+	rateStr, err := decimalRate(rateVal)
+	if err != nil {
+		return "", time.Time{}, &ProviderError{Code: resp.StatusCode, Message: "external API returned an " + err.Error()}
 	}
-	rateStr := strconv.FormatFloat(rateVal, 'f', -1, 64)
 	return rateStr, time.Now().UTC(), nil
 }
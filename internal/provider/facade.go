@@ -4,14 +4,28 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"time"
 )
 
-var _ RatesProvider = (*ExchangeProviderFacade)(nil)
+var (
+	_ RatesProvider       = (*ExchangeProviderFacade)(nil)
+	_ AvailabilityChecker = (*ExchangeProviderFacade)(nil)
+)
+
+// NamedProvider is a RatesProvider with the name used in per-pair provider orders.
+type NamedProvider struct {
+	Name     string
+	Provider RatesProvider
+}
 
 // ExchangeProviderFacade is an abstraction that calls providers sequentially.
 type ExchangeProviderFacade struct {
 	providers []RatesProvider
+	names     []string // Parallel to providers; nil for an unnamed facade.
+	byName    map[string]RatesProvider
+	scorer    *Scorer // Records the calls of a named facade when set.
+	adaptive  bool    // Order the default providers by scorer.
 }
 
 // NewExchangeProviderFacade creates a new ExchangeProviderFacade with the given list of providers.
@@ -21,16 +35,127 @@ func NewExchangeProviderFacade(providers ...RatesProvider) *ExchangeProviderFaca
 	}
 }
 
-// GetRate calls providers sequentially until one succeeds.
+// NewNamedExchangeProviderFacade creates an ExchangeProviderFacade whose default order
+// can be replaced per call with WithProviderOrder.
+func NewNamedExchangeProviderFacade(providers ...NamedProvider) *ExchangeProviderFacade {
+	f := &ExchangeProviderFacade{byName: make(map[string]RatesProvider, len(providers))}
+	for _, np := range providers {
+		f.providers = append(f.providers, np.Provider)
+		f.names = append(f.names, np.Name)
+		f.byName[np.Name] = np.Provider
+	}
+	return f
+}
+
+// UseScorer makes a named facade record every provider call on scorer. With adaptive
+// set, the default order is replaced on each call by scorer's ranking, with the time
+// left until ctx's deadline as the latency budget; an order set with
+// WithProviderOrder is still followed as given.
+func (p *ExchangeProviderFacade) UseScorer(scorer *Scorer, adaptive bool) {
+	p.scorer, p.adaptive = scorer, adaptive
+}
+
+// Adaptive reports whether the facade orders its providers by their scores.
+func (p *ExchangeProviderFacade) Adaptive() bool {
+	return p.adaptive && p.scorer != nil && p.names != nil
+}
+
+// ProviderScores returns the scores of a named facade's providers in their default
+// order, or nil if the facade has no scorer.
+func (p *ExchangeProviderFacade) ProviderScores() []ProviderScore {
+	if p.scorer == nil || p.names == nil {
+		return nil
+	}
+	return p.scorer.Scores(p.names)
+}
+
+// GetRate calls providers sequentially until one succeeds. Only retryable errors fall
+// through to the next provider; a non-retryable one is returned immediately. An order
+// set with WithProviderOrder replaces the default order; names that are not
+// configured are skipped. The provider it ended on is reported to a WithSource
+// context.
 func (p *ExchangeProviderFacade) GetRate(ctx context.Context, base, quote string) (string, time.Time, error) {
 	var errs []error
-	for _, prov := range p.providers {
-		rate, timestamp, err := prov.GetRate(ctx, base, quote)
+	for _, np := range p.ordered(ctx) {
+		setSource(ctx, np)
+		start := time.Now()
+		rate, timestamp, err := np.Provider.GetRate(ctx, base, quote)
+		p.record(ctx, np.Name, time.Since(start), err)
 		if err == nil {
 			return rate, timestamp, nil
 		}
+		if !IsRetryable(err) {
+			return "", time.Time{}, err
+		}
 		errs = append(errs, err)
 	}
 
-	return "", time.Time{}, fmt.Errorf("all providers failed: %w", errors.Join(errs...))
+	if len(errs) == 0 {
+		return "", time.Time{}, errors.New("no provider configured for the requested order")
+	}
+	return "", time.Time{}, fmt.Errorf("%w: %w", ErrAllProvidersUnavailable, errors.Join(errs...))
+}
+
+func (p *ExchangeProviderFacade) ordered(ctx context.Context) []NamedProvider {
+	order := providerOrder(ctx)
+	if len(order) == 0 || p.byName == nil {
+		if !p.Adaptive() {
+			return p.defaultOrder()
+		}
+		var budget time.Duration
+		if deadline, ok := ctx.Deadline(); ok {
+			budget = time.Until(deadline)
+		}
+		order = p.scorer.Order(p.names, budget)
+	}
+	providers := make([]NamedProvider, 0, len(order))
+	for _, name := range order {
+		if prov, ok := p.byName[name]; ok {
+			providers = append(providers, NamedProvider{Name: name, Provider: prov})
+		}
+	}
+	return providers
+}
+
+func (p *ExchangeProviderFacade) defaultOrder() []NamedProvider {
+	named := make([]NamedProvider, len(p.providers))
+	for i, prov := range p.providers {
+		named[i].Provider = prov
+		if p.names != nil {
+			named[i].Name = p.names[i]
+		}
+	}
+	return named
+}
+
+// record adds a call to the scorer. Calls that did not reach the provider (an open
+// circuit) or were abandoned by the caller say nothing about the provider and are
+// left out; a non-retryable error is an answer, not a failure.
+func (p *ExchangeProviderFacade) record(ctx context.Context, name string, latency time.Duration, err error) {
+	if p.scorer == nil || name == "" || errors.Is(err, ErrCircuitOpen) || errors.Is(ctx.Err(), context.Canceled) {
+		return
+	}
+	p.scorer.Record(name, latency, IsRetryable(err))
+}
+
+// Providers returns the facade's providers in their default order.
+func (p *ExchangeProviderFacade) Providers() []RatesProvider {
+	return slices.Clone(p.providers)
+}
+
+// NamedProviders returns the providers of a facade created with
+// NewNamedExchangeProviderFacade in their default order, or nil for an unnamed facade.
+func (p *ExchangeProviderFacade) NamedProviders() []NamedProvider {
+	if p.names == nil {
+		return nil
+	}
+	return p.defaultOrder()
+}
+
+// IsProviderAvailable reports whether at least one provider may serve the pair.
+// Providers that do not implement AvailabilityChecker are assumed available.
+func (p *ExchangeProviderFacade) IsProviderAvailable(base, quote string) bool {
+	return slices.ContainsFunc(p.providers, func(prov RatesProvider) bool {
+		return IsAvailable(prov, base, quote)
+	})
 }
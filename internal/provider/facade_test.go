@@ -3,11 +3,14 @@ package provider
 import (
 	"context"
 	"errors"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 func TestFallbackProvider_GetRate(t *testing.T) {
@@ -56,11 +59,208 @@ func TestFallbackProvider_GetRate(t *testing.T) {
 		p := NewExchangeProviderFacade(m1, m2)
 		_, _, err := p.GetRate(context.Background(), "EUR", "USD")
 
-		assert.Error(t, err)
+		assert.ErrorIs(t, err, ErrAllProvidersUnavailable)
 		assert.Contains(t, err.Error(), "all providers failed")
 		assert.Contains(t, err.Error(), "m1 failed")
 		assert.Contains(t, err.Error(), "m2 failed")
 		m1.AssertExpectations(t)
 		m2.AssertExpectations(t)
 	})
+
+	t.Run("non-retryable error stops the fallback", func(t *testing.T) {
+		m1 := new(MockProvider)
+		m2 := new(MockProvider)
+		permanent := &ProviderError{Code: 401, Message: "invalid api key"}
+
+		m1.On("GetRate", mock.Anything, "EUR", "USD").Return("", time.Time{}, permanent)
+
+		p := NewExchangeProviderFacade(m1, m2)
+		_, _, err := p.GetRate(context.Background(), "EUR", "USD")
+
+		assert.ErrorIs(t, err, permanent)
+		assert.False(t, IsRetryable(err))
+		m1.AssertExpectations(t)
+		m2.AssertNotCalled(t, "GetRate", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("retryable error falls through", func(t *testing.T) {
+		m1 := new(MockProvider)
+		m2 := new(MockProvider)
+		now := time.Now().UTC()
+
+		m1.On("GetRate", mock.Anything, "EUR", "USD").Return("", time.Time{}, &ProviderError{Code: 503, Message: "unavailable", Retryable: true})
+		m2.On("GetRate", mock.Anything, "EUR", "USD").Return("1.2", now, nil)
+
+		p := NewExchangeProviderFacade(m1, m2)
+		rate, _, err := p.GetRate(context.Background(), "EUR", "USD")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "1.2", rate)
+		m2.AssertExpectations(t)
+	})
+}
+
+func TestFacade_FailsOverOnExchangeRateHostErrors(t *testing.T) {
+	bodies := map[string]string{
+		"usage limit":  `{"success":false,"error":{"code":104,"type":"usage_limit_reached","info":"Your monthly usage limit has been reached."}}`,
+		"missing rate": `{"success":true,"source":"EUR","quotes":{}}`,
+	}
+	for name, body := range bodies {
+		t.Run(name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				_, _ = w.Write([]byte(body))
+			}))
+			defer srv.Close()
+			fallback := new(MockProvider)
+			fallback.On("GetRate", mock.Anything, "EUR", "USD").Return("1.2", time.Now().UTC(), nil)
+
+			p := NewExchangeProviderFacade(NewExchangeRateHostProvider(srv.URL, "key", 1), fallback)
+			rate, _, err := p.GetRate(context.Background(), "EUR", "USD")
+
+			require.NoError(t, err)
+			assert.Equal(t, "1.2", rate)
+			fallback.AssertExpectations(t)
+		})
+	}
+}
+
+func TestNamedFacade_ProviderOrder(t *testing.T) {
+	now := time.Now().UTC()
+
+	t.Run("order replaces default", func(t *testing.T) {
+		m1 := new(MockProvider)
+		m2 := new(MockProvider)
+		m2.On("GetRate", mock.Anything, "EUR", "USD").Return("1.2", now, nil)
+
+		p := NewNamedExchangeProviderFacade(NamedProvider{Name: "first", Provider: m1}, NamedProvider{Name: "second", Provider: m2})
+		rate, _, err := p.GetRate(WithProviderOrder(context.Background(), []string{"second", "first"}), "EUR", "USD")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "1.2", rate)
+		m1.AssertNotCalled(t, "GetRate", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("unlisted providers are skipped", func(t *testing.T) {
+		m1 := new(MockProvider)
+		m2 := new(MockProvider)
+		m2.On("GetRate", mock.Anything, "EUR", "USD").Return("", time.Time{}, errors.New("m2 failed"))
+
+		p := NewNamedExchangeProviderFacade(NamedProvider{Name: "first", Provider: m1}, NamedProvider{Name: "second", Provider: m2})
+		_, _, err := p.GetRate(WithProviderOrder(context.Background(), []string{"second"}), "EUR", "USD")
+
+		assert.Error(t, err)
+		m1.AssertNotCalled(t, "GetRate", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("order with no configured provider", func(t *testing.T) {
+		m1 := new(MockProvider)
+
+		p := NewNamedExchangeProviderFacade(NamedProvider{Name: "first", Provider: m1})
+		_, _, err := p.GetRate(WithProviderOrder(context.Background(), []string{"second"}), "EUR", "USD")
+
+		assert.Error(t, err)
+		m1.AssertNotCalled(t, "GetRate", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestNamedFacade_Source(t *testing.T) {
+	now := time.Now().UTC()
+	newFacade := func(secondErr error) (*ExchangeProviderFacade, *MockProvider) {
+		m1 := new(MockProvider)
+		m2 := new(MockProvider)
+		m1.On("GetRate", mock.Anything, "EUR", "USD").Return("", time.Time{}, errors.New("m1 failed"))
+		m2.On("GetRate", mock.Anything, "EUR", "USD").Return("1.2", now, secondErr)
+		return NewNamedExchangeProviderFacade(NamedProvider{Name: "first", Provider: m1}, NamedProvider{Name: "second", Provider: m2}), m2
+	}
+
+	t.Run("provider that answered", func(t *testing.T) {
+		f, m2 := newFacade(nil)
+		var src Source
+		_, _, err := f.GetRate(WithSource(context.Background(), &src), "EUR", "USD")
+		require.NoError(t, err)
+		assert.Equal(t, "second", src.Name)
+		assert.Same(t, m2, src.Provider)
+	})
+
+	t.Run("last provider tried when all fail", func(t *testing.T) {
+		f, m2 := newFacade(errors.New("m2 failed"))
+		var src Source
+		_, _, err := f.GetRate(WithSource(context.Background(), &src), "EUR", "USD")
+		require.ErrorIs(t, err, ErrAllProvidersUnavailable)
+		assert.Equal(t, "second", src.Name)
+		assert.Same(t, m2, src.Provider)
+	})
+
+	t.Run("unnamed facade", func(t *testing.T) {
+		m1 := new(MockProvider)
+		m1.On("GetRate", mock.Anything, "EUR", "USD").Return("1.1", now, nil)
+		var src Source
+		_, _, err := NewExchangeProviderFacade(m1).GetRate(WithSource(context.Background(), &src), "EUR", "USD")
+		require.NoError(t, err)
+		assert.Empty(t, src.Name)
+		assert.Same(t, m1, src.Provider)
+	})
+}
+
+func TestFacade_Scorer(t *testing.T) {
+	newFacade := func(adaptive bool) (*ExchangeProviderFacade, *MockProvider, *MockProvider) {
+		m1, m2 := new(MockProvider), new(MockProvider)
+		p := NewNamedExchangeProviderFacade(NamedProvider{Name: "first", Provider: m1}, NamedProvider{Name: "second", Provider: m2})
+		p.UseScorer(newTestScorer(10), adaptive)
+		return p, m1, m2
+	}
+
+	t.Run("calls are recorded with either strategy", func(t *testing.T) {
+		p, m1, m2 := newFacade(false)
+		m1.On("GetRate", mock.Anything, "EUR", "USD").Return("", time.Time{}, errors.New("m1 failed")).Once()
+		m1.On("GetRate", mock.Anything, "EUR", "XXX").Return("", time.Time{}, &ProviderError{Code: 400, Message: "unknown pair"}).Once()
+		m2.On("GetRate", mock.Anything, "EUR", "USD").Return("1.2", time.Now(), nil)
+
+		_, _, err := p.GetRate(context.Background(), "EUR", "USD")
+		require.NoError(t, err)
+		_, _, err = p.GetRate(context.Background(), "EUR", "XXX")
+		require.Error(t, err)
+
+		scores := p.ProviderScores()
+		require.Len(t, scores, 2)
+		assert.False(t, p.Adaptive())
+		assert.Equal(t, 2, scores[0].Calls)
+		assert.InDelta(t, 2.0/4, scores[0].SuccessRate, 1e-9, "a non-retryable error is an answer, not a failure")
+		assert.Equal(t, 1, scores[1].Calls)
+		assert.InDelta(t, 2.0/3, scores[1].SuccessRate, 1e-9)
+	})
+
+	t.Run("an open circuit is not recorded", func(t *testing.T) {
+		p, m1, m2 := newFacade(false)
+		m1.On("GetRate", mock.Anything, "EUR", "USD").Return("", time.Time{}, ErrCircuitOpen)
+		m2.On("GetRate", mock.Anything, "EUR", "USD").Return("1.2", time.Now(), nil)
+
+		_, _, err := p.GetRate(context.Background(), "EUR", "USD")
+		require.NoError(t, err)
+		assert.Equal(t, 0, p.ProviderScores()[0].Calls)
+	})
+
+	t.Run("adaptive tries the best provider first", func(t *testing.T) {
+		p, m1, m2 := newFacade(true)
+		record(p.scorer, "first", 10, time.Second, true)
+		record(p.scorer, "second", 10, 100*time.Millisecond, false)
+		m2.On("GetRate", mock.Anything, "EUR", "USD").Return("1.2", time.Now(), nil)
+
+		rate, _, err := p.GetRate(context.Background(), "EUR", "USD")
+		require.NoError(t, err)
+		assert.Equal(t, "1.2", rate)
+		assert.True(t, p.Adaptive())
+		m1.AssertNotCalled(t, "GetRate", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("adaptive keeps a per-pair order", func(t *testing.T) {
+		p, m1, m2 := newFacade(true)
+		record(p.scorer, "second", 10, 100*time.Millisecond, false)
+		m1.On("GetRate", mock.Anything, "EUR", "USD").Return("1.1", time.Now(), nil)
+
+		rate, _, err := p.GetRate(WithProviderOrder(context.Background(), []string{"first", "second"}), "EUR", "USD")
+		require.NoError(t, err)
+		assert.Equal(t, "1.1", rate)
+		m2.AssertNotCalled(t, "GetRate", mock.Anything, mock.Anything, mock.Anything)
+	})
 }
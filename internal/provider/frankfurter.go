@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"strconv"
 	"time"
 )
 
@@ -19,21 +18,21 @@ type FrankfurterProvider struct {
 }
 
 // NewFrankfurterProvider creates a new FrankfurterProvider.
-func NewFrankfurterProvider(baseURL string, timeoutSec int) *FrankfurterProvider {
+func NewFrankfurterProvider(baseURL string, timeoutSec int, opts ...HTTPOption) *FrankfurterProvider {
 	if baseURL == "" {
 		baseURL = "https://api.frankfurter.dev/v1"
 	}
 	return &FrankfurterProvider{
 		baseURL: baseURL,
-		client:  &http.Client{Timeout: time.Duration(timeoutSec) * time.Second},
+		client:  newHTTPClient(timeoutSec, opts),
 	}
 }
 
 type frankfurterResponse struct {
-	Amount float64            `json:"amount"`
-	Base   string             `json:"base"`
-	Date   string             `json:"date"`
-	Rates  map[string]float64 `json:"rates"`
+	Amount float64                `json:"amount"`
+	Base   string                 `json:"base"`
+	Date   string                 `json:"date"`
+	Rates  map[string]json.Number `json:"rates"`
 }
 
 // GetRate retrieves the exchange rate between the specified base and quote currencies
@@ -41,31 +40,34 @@ func (p *FrankfurterProvider) GetRate(ctx context.Context, base, quote string) (
 	reqURL := fmt.Sprintf("%s/latest?base=%s&symbols=%s", p.baseURL, base, quote)
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, http.NoBody)
 	if err != nil {
-		return "", time.Time{}, fmt.Errorf("frankfurter API request creation failed: %w", err)
+		return "", time.Time{}, &ProviderError{Message: "frankfurter API request creation failed", Err: err}
 	}
 
 	resp, err := p.client.Do(req)
 	if err != nil {
-		return "", time.Time{}, fmt.Errorf("frankfurter API request failed: %w", err)
+		return "", time.Time{}, newTransportError("frankfurter API request failed", err)
 	}
 	defer resp.Body.Close() //nolint:errcheck // best-effort close
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return "", time.Time{}, fmt.Errorf("frankfurter API returned status %d: %s", resp.StatusCode, string(body))
+		return "", time.Time{}, newStatusError("frankfurter", resp.StatusCode, body)
 	}
 
 	var result frankfurterResponse
 	if err = json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return "", time.Time{}, fmt.Errorf("failed to decode frankfurter API response: %w", err)
+		return "", time.Time{}, newTransportError("failed to decode frankfurter API response", err)
 	}
 
 	rateVal, ok := result.Rates[quote]
 	if !ok {
-		return "", time.Time{}, fmt.Errorf("no rate for %s in frankfurter response", quote)
+		return "", time.Time{}, &ProviderError{Code: resp.StatusCode, Message: fmt.Sprintf("no rate for %s in frankfurter response", quote), Err: ErrUnsupportedPair}
 	}
 
-	rateStr := strconv.FormatFloat(rateVal, 'f', -1, 64)
+	rateStr, err := decimalRate(rateVal)
+	if err != nil {
+		return "", time.Time{}, &ProviderError{Code: resp.StatusCode, Message: "frankfurter API returned an " + err.Error()}
+	}
 
 	// Parse date from response if possible, otherwise use current time
 	resDate, err := time.Parse("2006-01-02", result.Date)
@@ -9,3 +9,41 @@ import (
 type RatesProvider interface {
 	GetRate(ctx context.Context, base, quote string) (string, time.Time, error)
 }
+
+type providerOrderKey struct{}
+
+// WithProviderOrder returns a context asking ExchangeProviderFacade to try only the
+// named providers, in the given order. An empty order keeps the default.
+func WithProviderOrder(ctx context.Context, order []string) context.Context {
+	if len(order) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, providerOrderKey{}, order)
+}
+
+func providerOrder(ctx context.Context) []string {
+	order, _ := ctx.Value(providerOrderKey{}).([]string)
+	return order
+}
+
+type sourceKey struct{}
+
+// Source receives the provider that served a GetRate call made with a context from
+// WithSource.
+type Source struct {
+	Name     string // Empty for an unnamed facade.
+	Provider RatesProvider
+}
+
+// WithSource returns a context in which an ExchangeProviderFacade records in src the
+// provider that answered GetRate or, if all of them failed, the last one it tried.
+// src is left alone by other providers.
+func WithSource(ctx context.Context, src *Source) context.Context {
+	return context.WithValue(ctx, sourceKey{}, src)
+}
+
+func setSource(ctx context.Context, np NamedProvider) {
+	if src, ok := ctx.Value(sourceKey{}).(*Source); ok {
+		src.Name, src.Provider = np.Name, np.Provider
+	}
+}
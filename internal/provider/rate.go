@@ -0,0 +1,35 @@
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// decimalRate returns a rate decoded from a provider response as a plain decimal
+// string with every digit the provider sent. Rates are never converted to float64,
+// which keeps only ~15 significant digits: BTC/JPY runs into the tens of millions and
+// IDR/BTC is around 1e-9. Exponent notation is expanded, since the rate is stored and
+// cached as given and both copies must read the same.
+func decimalRate(n json.Number) (string, error) {
+	s := n.String()
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return "", fmt.Errorf("invalid rate %q", s)
+	}
+	mantissa, exp, found := strings.Cut(strings.ToLower(s), "e")
+	if !found {
+		return s, nil
+	}
+	e, err := strconv.Atoi(exp)
+	if err != nil {
+		return "", fmt.Errorf("invalid rate %q", s)
+	}
+	scale := 0
+	if i := strings.IndexByte(mantissa, '.'); i >= 0 {
+		scale = len(mantissa) - i - 1
+	}
+	return r.FloatString(max(scale-e, 0)), nil
+}
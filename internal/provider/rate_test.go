@@ -0,0 +1,76 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecimalRate(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"18.7543", "18.7543"},
+		{"1.0850", "1.0850"},                               // Trailing zeros are the provider's scale.
+		{"15234567.123456789012", "15234567.123456789012"}, // BTC/JPY: past float64's ~15 digits.
+		{"0.000000001234567891", "0.000000001234567891"},   // IDR/BTC.
+		{"1.234567891e-9", "0.000000001234567891"},         // Exponent notation is expanded.
+		{"1.5234567123456789012E7", "15234567.123456789012"},
+		{"2e3", "2000"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.in, func(t *testing.T) {
+			got, err := decimalRate(json.Number(tc.in))
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+
+	_, err := decimalRate("")
+	assert.Error(t, err)
+}
+
+func TestGetRate_KeepsRatePrecision(t *testing.T) {
+	providers := map[string]struct {
+		newProvider func(url string) RatesProvider
+		body        string // Formatted with base, quote and the raw rate.
+	}{
+		"exchangerate_host": {
+			func(url string) RatesProvider { return NewExchangeRateHostProvider(url, "key", 1) },
+			`{"success":true,"source":%[1]q,"quotes":{"%[1]s%[2]s":%[3]s}}`,
+		},
+		"frankfurter": {
+			func(url string) RatesProvider { return NewFrankfurterProvider(url, 1) },
+			`{"amount":1.0,"base":%[1]q,"date":"2025-12-01","rates":{%[2]q:%[3]s}}`,
+		},
+	}
+	rates := []struct {
+		base, quote, raw, want string
+	}{
+		{"BTC", "JPY", "15234567.123456789012", "15234567.123456789012"},
+		{"IDR", "BTC", "0.000000001234567891", "0.000000001234567891"},
+		{"IDR", "BTC", "1.234567891e-9", "0.000000001234567891"},
+	}
+
+	for name, p := range providers {
+		for _, r := range rates {
+			t.Run(name+"/"+r.raw, func(t *testing.T) {
+				srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+					_, _ = fmt.Fprintf(w, p.body, r.base, r.quote, r.raw)
+				}))
+				defer srv.Close()
+
+				got, _, err := p.newProvider(srv.URL).GetRate(context.Background(), r.base, r.quote)
+				require.NoError(t, err)
+				assert.Equal(t, r.want, got)
+			})
+		}
+	}
+}
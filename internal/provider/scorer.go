@@ -0,0 +1,151 @@
+package provider
+
+import (
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"time"
+)
+
+// latencyHalfScore is the median latency at which a provider that always succeeds
+// scores 0.5; at zero latency it scores 1.
+const latencyHalfScore = time.Second
+
+// ProviderScore is a provider's recent record as seen by a Scorer.
+type ProviderScore struct {
+	Name        string
+	Calls       int           // Calls in the window.
+	SuccessRate float64       // Smoothed: (successes+1) / (calls+2).
+	P50Latency  time.Duration // Median latency of the successful calls; 0 without any.
+	Score       float64       // SuccessRate scaled down by P50Latency; higher is better.
+}
+
+// Scorer keeps a rolling window of the last calls of each provider and ranks the
+// providers by success rate and median latency. It is in-memory and per instance.
+//
+// The success rate is smoothed so a provider with no history scores like one with an
+// even record instead of a perfect one, and a single failure does not sink a provider.
+type Scorer struct {
+	window  int
+	explore float64
+
+	mu      sync.Mutex
+	history map[string]*callWindow
+	// Random sources, replaced in tests.
+	float func() float64
+	intN  func(n int) int
+}
+
+// NewScorer creates a Scorer that keeps the last window calls of each provider and
+// moves a random provider to the front of an order with probability explore, so a
+// provider that recovered gets tried again.
+func NewScorer(window int, explore float64) *Scorer {
+	return &Scorer{
+		window:  window,
+		explore: explore,
+		history: make(map[string]*callWindow),
+		float:   rand.Float64,
+		intN:    rand.IntN,
+	}
+}
+
+// Record adds a call of the named provider. The latency of a failed call is not used
+// for the median.
+func (s *Scorer) Record(name string, latency time.Duration, failed bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w, ok := s.history[name]
+	if !ok {
+		w = &callWindow{calls: make([]call, 0, s.window)}
+		s.history[name] = w
+	}
+	w.add(call{latency: latency, failed: failed}, s.window)
+}
+
+// Scores returns the current score of each named provider, in the given order.
+func (s *Scorer) Scores(names []string) []ProviderScore {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	scores := make([]ProviderScore, len(names))
+	for i, name := range names {
+		scores[i] = s.score(name)
+	}
+	return scores
+}
+
+// Order returns names sorted by score, best first; ties keep their given order.
+// Providers whose median latency exceeds budget go last, since they would likely not
+// answer in time; a zero budget means no deadline. With probability explore, a random
+// provider other than the best is then moved to the front.
+func (s *Scorer) Order(names []string, budget time.Duration) []string {
+	scores := s.Scores(names)
+	tooSlow := func(sc ProviderScore) bool { return budget > 0 && sc.P50Latency > budget }
+	slices.SortStableFunc(scores, func(a, b ProviderScore) int {
+		if sa, sb := tooSlow(a), tooSlow(b); sa != sb {
+			if sa {
+				return 1
+			}
+			return -1
+		}
+		switch {
+		case a.Score > b.Score:
+			return -1
+		case a.Score < b.Score:
+			return 1
+		}
+		return 0
+	})
+
+	ordered := make([]string, len(scores))
+	for i, sc := range scores {
+		ordered[i] = sc.Name
+	}
+	if len(ordered) > 1 && s.float() < s.explore {
+		i := 1 + s.intN(len(ordered)-1)
+		explored := ordered[i]
+		copy(ordered[1:i+1], ordered[:i])
+		ordered[0] = explored
+	}
+	return ordered
+}
+
+func (s *Scorer) score(name string) ProviderScore {
+	sc := ProviderScore{Name: name, SuccessRate: 0.5}
+	w, ok := s.history[name]
+	if ok {
+		var successes []time.Duration
+		for _, c := range w.calls {
+			if !c.failed {
+				successes = append(successes, c.latency)
+			}
+		}
+		sc.Calls = len(w.calls)
+		sc.SuccessRate = float64(len(successes)+1) / float64(len(w.calls)+2)
+		if len(successes) > 0 {
+			slices.Sort(successes)
+			sc.P50Latency = successes[len(successes)/2]
+		}
+	}
+	sc.Score = sc.SuccessRate * float64(latencyHalfScore) / float64(latencyHalfScore+sc.P50Latency)
+	return sc
+}
+
+type call struct {
+	latency time.Duration
+	failed  bool
+}
+
+// callWindow is a ring buffer of the last calls of a provider.
+type callWindow struct {
+	calls []call
+	next  int // Index the next call overwrites once the window is full.
+}
+
+func (w *callWindow) add(c call, size int) {
+	if len(w.calls) < size {
+		w.calls = append(w.calls, c)
+		return
+	}
+	w.calls[w.next] = c
+	w.next = (w.next + 1) % size
+}
@@ -0,0 +1,104 @@
+package provider
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestScorer returns a Scorer that never explores.
+func newTestScorer(window int) *Scorer {
+	s := NewScorer(window, 0)
+	s.float = func() float64 { return 1 }
+	return s
+}
+
+// record adds n calls of name with the given latency and outcome.
+func record(s *Scorer, name string, n int, latency time.Duration, failed bool) {
+	for range n {
+		s.Record(name, latency, failed)
+	}
+}
+
+func TestScorer_Score(t *testing.T) {
+	s := newTestScorer(10)
+	record(s, "a", 8, 250*time.Millisecond, false)
+	record(s, "a", 2, 5*time.Second, true) // Failures do not count toward the median.
+
+	scores := s.Scores([]string{"a", "unseen"})
+	require.Len(t, scores, 2)
+
+	a := scores[0]
+	assert.Equal(t, 10, a.Calls)
+	assert.InDelta(t, 9.0/12, a.SuccessRate, 1e-9)
+	assert.Equal(t, 250*time.Millisecond, a.P50Latency)
+	assert.InDelta(t, 9.0/12*0.8, a.Score, 1e-9)
+
+	unseen := scores[1]
+	assert.Equal(t, ProviderScore{Name: "unseen", SuccessRate: 0.5, Score: 0.5}, unseen)
+}
+
+func TestScorer_Order(t *testing.T) {
+	names := []string{"primary", "secondary", "tertiary"}
+
+	t.Run("no history keeps the configured order", func(t *testing.T) {
+		assert.Equal(t, names, newTestScorer(10).Order(names, 0))
+	})
+
+	t.Run("slow primary ranks after fast secondary", func(t *testing.T) {
+		s := newTestScorer(20)
+		record(s, "primary", 20, 2*time.Second, false)
+		record(s, "secondary", 20, 100*time.Millisecond, false)
+		record(s, "tertiary", 20, 300*time.Millisecond, false)
+		assert.Equal(t, []string{"secondary", "tertiary", "primary"}, s.Order(names, 0))
+	})
+
+	t.Run("failing primary ranks after a slower reliable one", func(t *testing.T) {
+		s := newTestScorer(20)
+		record(s, "primary", 10, 100*time.Millisecond, false)
+		record(s, "primary", 10, time.Second, true)
+		record(s, "secondary", 20, 400*time.Millisecond, false)
+		assert.Equal(t, []string{"secondary", "tertiary", "primary"}, s.Order(names, 0))
+	})
+
+	t.Run("recovery pushes failures out of the window", func(t *testing.T) {
+		s := newTestScorer(10)
+		record(s, "primary", 10, time.Second, true)
+		record(s, "secondary", 10, 200*time.Millisecond, false)
+		require.Equal(t, "secondary", s.Order(names, 0)[0])
+
+		record(s, "primary", 10, 100*time.Millisecond, false)
+		assert.Equal(t, 10, s.Scores([]string{"primary"})[0].Calls)
+		assert.Equal(t, "primary", s.Order(names, 0)[0])
+	})
+
+	t.Run("providers slower than the budget go last", func(t *testing.T) {
+		s := newTestScorer(10)
+		record(s, "primary", 10, 1200*time.Millisecond, false)
+		record(s, "secondary", 4, 200*time.Millisecond, false)
+		record(s, "secondary", 6, time.Second, true)
+		record(s, "tertiary", 10, 500*time.Millisecond, false)
+
+		assert.Equal(t, []string{"tertiary", "primary", "secondary"}, s.Order(names, 0))
+		assert.Equal(t, []string{"tertiary", "secondary", "primary"}, s.Order(names, time.Second))
+	})
+
+	t.Run("exploration moves a random provider to the front", func(t *testing.T) {
+		s := NewScorer(10, 0.05)
+		record(s, "primary", 10, 100*time.Millisecond, false)
+		record(s, "secondary", 10, 200*time.Millisecond, false)
+		record(s, "tertiary", 10, 300*time.Millisecond, false)
+
+		s.float = func() float64 { return 0.04 }
+		s.intN = func(n int) int {
+			assert.Equal(t, 2, n)
+			return 1
+		}
+		assert.Equal(t, []string{"tertiary", "primary", "secondary"}, s.Order(names, 0))
+
+		s.float = func() float64 { return 0.05 }
+		assert.Equal(t, names, s.Order(names, 0))
+	})
+}
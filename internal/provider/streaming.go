@@ -0,0 +1,32 @@
+package provider
+
+import (
+	"context"
+	"time"
+)
+
+// PairKey identifies a currency pair.
+type PairKey struct {
+	Base  string
+	Quote string
+}
+
+// String returns the pair as "BASE/QUOTE".
+func (p PairKey) String() string { return p.Base + "/" + p.Quote }
+
+// RateEvent is a single pushed rate, or a stream error when Error is set.
+type RateEvent struct {
+	Base       string
+	Quote      string
+	Rate       string
+	ReceivedAt time.Time
+	Error      error
+}
+
+// StreamingRatesProvider pushes rates for subscribed pairs instead of being polled.
+type StreamingRatesProvider interface {
+	// StreamRates subscribes to pairs and returns a channel of events. The channel is
+	// closed when ctx is cancelled, Close is called, or the provider gives up reconnecting.
+	StreamRates(ctx context.Context, pairs []PairKey) (<-chan RateEvent, error)
+	Close() error
+}
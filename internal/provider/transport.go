@@ -0,0 +1,86 @@
+package provider
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+// TransportConfig configures the HTTP transport shared by the API providers.
+type TransportConfig struct {
+	// ProxyURL routes requests through a proxy; credentials go in its user info.
+	// Empty uses the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables.
+	ProxyURL string
+	// CACertFile is a PEM bundle trusted in addition to the system roots.
+	CACertFile string
+	// InsecureSkipVerify disables TLS certificate verification. Development only.
+	InsecureSkipVerify bool
+}
+
+// NewTransport builds an http.Transport from cfg, starting from http.DefaultTransport's
+// settings. It fails if the proxy URL is invalid or the CA file is unreadable or holds
+// no PEM certificates.
+func NewTransport(cfg TransportConfig) (*http.Transport, error) {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+
+	if cfg.ProxyURL != "" {
+		u, err := url.Parse(cfg.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("parse proxy URL: %w", err)
+		}
+		t.Proxy = http.ProxyURL(u)
+	}
+
+	if cfg.CACertFile != "" || cfg.InsecureSkipVerify {
+		t.TLSClientConfig = &tls.Config{
+			MinVersion:         tls.VersionTLS12,
+			InsecureSkipVerify: cfg.InsecureSkipVerify, //nolint:gosec // Explicit opt-in for development.
+		}
+	}
+	if cfg.CACertFile != "" {
+		pool, err := loadCertPool(cfg.CACertFile)
+		if err != nil {
+			return nil, err
+		}
+		t.TLSClientConfig.RootCAs = pool
+	}
+	return t, nil
+}
+
+// loadCertPool returns the system roots plus the certificates in the PEM file at path.
+func loadCertPool(path string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read CA certificate file: %w", err)
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("CA certificate file %s: no valid PEM certificates found", path)
+	}
+	return pool, nil
+}
+
+// HTTPOption configures the HTTP client of an API provider.
+type HTTPOption func(*http.Client)
+
+// WithTransport makes the provider send its requests through rt.
+func WithTransport(rt http.RoundTripper) HTTPOption {
+	return func(c *http.Client) {
+		c.Transport = rt
+	}
+}
+
+func newHTTPClient(timeoutSec int, opts []HTTPOption) *http.Client {
+	c := &http.Client{Timeout: time.Duration(timeoutSec) * time.Second}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
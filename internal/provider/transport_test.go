@@ -0,0 +1,71 @@
+package provider
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const frankfurterBody = `{"amount":1,"base":"EUR","date":"2024-06-14","rates":{"USD":1.0712}}`
+
+func writeFile(t *testing.T, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+	return path
+}
+
+func TestNewTransport_CustomCA(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(frankfurterBody))
+	}))
+	defer srv.Close()
+
+	// Without the server's CA the certificate is rejected.
+	_, _, err := NewFrankfurterProvider(srv.URL, 1).GetRate(context.Background(), "EUR", "USD")
+	require.Error(t, err)
+
+	caFile := writeFile(t, "ca.pem", pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}))
+	transport, err := NewTransport(TransportConfig{CACertFile: caFile})
+	require.NoError(t, err)
+
+	rate, _, err := NewFrankfurterProvider(srv.URL, 1, WithTransport(transport)).GetRate(context.Background(), "EUR", "USD")
+	require.NoError(t, err)
+	assert.Equal(t, "1.0712", rate)
+}
+
+func TestNewTransport_Proxy(t *testing.T) {
+	var target, auth string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		target = r.URL.String()
+		auth = r.Header.Get("Proxy-Authorization")
+		_, _ = w.Write([]byte(frankfurterBody))
+	}))
+	defer proxy.Close()
+
+	transport, err := NewTransport(TransportConfig{ProxyURL: "http://svc:s3cret@" + proxy.Listener.Addr().String()})
+	require.NoError(t, err)
+
+	rate, _, err := NewFrankfurterProvider("http://rates.example", 1, WithTransport(transport)).
+		GetRate(context.Background(), "EUR", "USD")
+	require.NoError(t, err)
+	assert.Equal(t, "1.0712", rate)
+	assert.Equal(t, "http://rates.example/latest?base=EUR&symbols=USD", target)
+	assert.Equal(t, "Basic "+base64.StdEncoding.EncodeToString([]byte("svc:s3cret")), auth)
+}
+
+func TestNewTransport_InvalidCA(t *testing.T) {
+	_, err := NewTransport(TransportConfig{CACertFile: filepath.Join(t.TempDir(), "missing.pem")})
+	assert.ErrorContains(t, err, "read CA certificate file")
+
+	_, err = NewTransport(TransportConfig{CACertFile: writeFile(t, "bad.pem", []byte("not a certificate"))})
+	assert.ErrorContains(t, err, "no valid PEM certificates")
+}
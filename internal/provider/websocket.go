@@ -0,0 +1,194 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+var _ StreamingRatesProvider = (*WebSocketProvider)(nil)
+
+// WebSocketConfig configures a WebSocketProvider.
+type WebSocketConfig struct {
+	URL                  string
+	HandshakeTimeout     time.Duration
+	InitialBackoff       time.Duration // Delay before the first reconnect attempt; doubled after each failure.
+	MaxBackoff           time.Duration
+	MaxReconnectAttempts int // Consecutive failed reconnects before giving up; 0 retries forever.
+}
+
+// WebSocketProvider streams rates from an exchange that pushes updates over WebSocket.
+//
+// After connecting it sends {"action":"subscribe","pairs":["EUR/USD",...]} and expects
+// messages of the form {"base":"EUR","quote":"USD","rate":1.0850}. Messages without a
+// base and quote (heartbeats, acks) are ignored.
+type WebSocketProvider struct {
+	cfg    WebSocketConfig
+	dialer *websocket.Dialer
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+}
+
+// NewWebSocketProvider creates a WebSocketProvider, filling in default backoff and timeouts.
+func NewWebSocketProvider(cfg WebSocketConfig) *WebSocketProvider {
+	if cfg.HandshakeTimeout <= 0 {
+		cfg.HandshakeTimeout = 10 * time.Second
+	}
+	if cfg.InitialBackoff <= 0 {
+		cfg.InitialBackoff = 500 * time.Millisecond
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = 30 * time.Second
+	}
+	return &WebSocketProvider{
+		cfg:    cfg,
+		dialer: &websocket.Dialer{HandshakeTimeout: cfg.HandshakeTimeout},
+	}
+}
+
+type wsSubscribe struct {
+	Action string   `json:"action"`
+	Pairs  []string `json:"pairs"`
+}
+
+type wsRateMessage struct {
+	Base  string      `json:"base"`
+	Quote string      `json:"quote"`
+	Rate  json.Number `json:"rate"`
+}
+
+// StreamRates connects, subscribes to pairs and streams events until ctx is cancelled or
+// Close is called. Read failures are reported as error events and followed by reconnect
+// attempts with exponential backoff.
+func (p *WebSocketProvider) StreamRates(ctx context.Context, pairs []PairKey) (<-chan RateEvent, error) {
+	conn, err := p.connect(ctx, pairs)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	p.mu.Lock()
+	p.cancel = cancel
+	p.mu.Unlock()
+
+	out := make(chan RateEvent, 64)
+	go p.run(ctx, conn, pairs, out)
+	return out, nil
+}
+
+// Close stops the active stream, if any.
+func (p *WebSocketProvider) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.cancel != nil {
+		p.cancel()
+	}
+	return nil
+}
+
+func (p *WebSocketProvider) run(ctx context.Context, conn *websocket.Conn, pairs []PairKey, out chan<- RateEvent) {
+	defer close(out)
+
+	for {
+		err := p.readLoop(ctx, conn, out)
+		_ = conn.Close()
+		if ctx.Err() != nil {
+			return
+		}
+		p.emit(ctx, out, RateEvent{Error: fmt.Errorf("websocket stream interrupted: %w", err), ReceivedAt: time.Now().UTC()})
+
+		conn, err = p.reconnect(ctx, pairs)
+		if err != nil {
+			if ctx.Err() == nil {
+				p.emit(ctx, out, RateEvent{Error: err, ReceivedAt: time.Now().UTC()})
+			}
+			return
+		}
+	}
+}
+
+func (p *WebSocketProvider) readLoop(ctx context.Context, conn *websocket.Conn, out chan<- RateEvent) error {
+	// Unblock ReadMessage when the stream is cancelled.
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	defer stop()
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+
+		var msg wsRateMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			p.emit(ctx, out, RateEvent{Error: fmt.Errorf("decode websocket message: %w", err), ReceivedAt: time.Now().UTC()})
+			continue
+		}
+		if msg.Base == "" || msg.Quote == "" {
+			continue
+		}
+		rate, err := decimalRate(msg.Rate)
+		if err != nil {
+			p.emit(ctx, out, RateEvent{Error: fmt.Errorf("websocket message for %s/%s: %w", msg.Base, msg.Quote, err), ReceivedAt: time.Now().UTC()})
+			continue
+		}
+		p.emit(ctx, out, RateEvent{
+			Base:       strings.ToUpper(msg.Base),
+			Quote:      strings.ToUpper(msg.Quote),
+			Rate:       rate,
+			ReceivedAt: time.Now().UTC(),
+		})
+	}
+}
+
+func (p *WebSocketProvider) reconnect(ctx context.Context, pairs []PairKey) (*websocket.Conn, error) {
+	backoff := p.cfg.InitialBackoff
+	var lastErr error
+	for attempt := 1; p.cfg.MaxReconnectAttempts == 0 || attempt <= p.cfg.MaxReconnectAttempts; attempt++ {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(backoff):
+		}
+
+		conn, err := p.connect(ctx, pairs)
+		if err == nil {
+			return conn, nil
+		}
+		lastErr = err
+		backoff = min(backoff*2, p.cfg.MaxBackoff)
+	}
+	return nil, fmt.Errorf("websocket reconnect gave up after %d attempts: %w", p.cfg.MaxReconnectAttempts, lastErr)
+}
+
+func (p *WebSocketProvider) connect(ctx context.Context, pairs []PairKey) (*websocket.Conn, error) {
+	conn, resp, err := p.dialer.DialContext(ctx, p.cfg.URL, nil)
+	if resp != nil && resp.Body != nil {
+		_ = resp.Body.Close()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("websocket dial failed: %w", err)
+	}
+
+	sub := wsSubscribe{Action: "subscribe", Pairs: make([]string, 0, len(pairs))}
+	for _, pair := range pairs {
+		sub.Pairs = append(sub.Pairs, pair.String())
+	}
+	if err := conn.WriteJSON(sub); err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("websocket subscribe failed: %w", err)
+	}
+	return conn, nil
+}
+
+func (p *WebSocketProvider) emit(ctx context.Context, out chan<- RateEvent, ev RateEvent) {
+	select {
+	case out <- ev:
+	case <-ctx.Done():
+	}
+}
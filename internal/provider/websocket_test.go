@@ -0,0 +1,155 @@
+package provider
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newWSServer starts a WebSocket server that hands each accepted connection (after
+// reading the subscribe message) to handle. It returns the ws:// URL.
+func newWSServer(t *testing.T, handle func(n int, conn *websocket.Conn, sub wsSubscribe)) (string, *httptest.Server) {
+	t.Helper()
+	var conns atomic.Int32
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		var sub wsSubscribe
+		if err := conn.ReadJSON(&sub); err != nil {
+			return
+		}
+		handle(int(conns.Add(1)), conn, sub)
+	}))
+	t.Cleanup(srv.Close)
+	return "ws" + strings.TrimPrefix(srv.URL, "http"), srv
+}
+
+func nextEvent(t *testing.T, ch <-chan RateEvent) RateEvent {
+	t.Helper()
+	select {
+	case ev, ok := <-ch:
+		require.True(t, ok, "stream closed unexpectedly")
+		return ev
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for rate event")
+		return RateEvent{}
+	}
+}
+
+func waitClosed(t *testing.T, ch <-chan RateEvent) []RateEvent {
+	t.Helper()
+	var rest []RateEvent
+	deadline := time.After(5 * time.Second)
+	for {
+		select {
+		case ev, ok := <-ch:
+			if !ok {
+				return rest
+			}
+			rest = append(rest, ev)
+		case <-deadline:
+			t.Fatal("stream was not closed")
+		}
+	}
+}
+
+func TestWebSocketProvider_StreamRates(t *testing.T) {
+	gotSub := make(chan wsSubscribe, 1)
+	url, _ := newWSServer(t, func(_ int, conn *websocket.Conn, sub wsSubscribe) {
+		gotSub <- sub
+		_ = conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"heartbeat"}`))
+		_ = conn.WriteMessage(websocket.TextMessage, []byte(`{"base":"eur","quote":"usd","rate":1.085}`))
+		_ = conn.WriteMessage(websocket.TextMessage, []byte(`{"base":"GBP","quote":"JPY","rate":"182.5"}`))
+		_, _, _ = conn.ReadMessage() // hold the connection open until the client goes away
+	})
+
+	p := NewWebSocketProvider(WebSocketConfig{URL: url})
+	ch, err := p.StreamRates(context.Background(), []PairKey{{"EUR", "USD"}, {"GBP", "JPY"}})
+	require.NoError(t, err)
+
+	sub := <-gotSub
+	assert.Equal(t, "subscribe", sub.Action)
+	assert.Equal(t, []string{"EUR/USD", "GBP/JPY"}, sub.Pairs)
+
+	ev := nextEvent(t, ch)
+	assert.NoError(t, ev.Error)
+	assert.Equal(t, "EUR", ev.Base)
+	assert.Equal(t, "USD", ev.Quote)
+	assert.Equal(t, "1.085", ev.Rate)
+	assert.False(t, ev.ReceivedAt.IsZero())
+
+	ev = nextEvent(t, ch)
+	assert.Equal(t, "GBP", ev.Base)
+	assert.Equal(t, "182.5", ev.Rate)
+
+	require.NoError(t, p.Close())
+	waitClosed(t, ch)
+}
+
+func TestWebSocketProvider_Reconnects(t *testing.T) {
+	url, _ := newWSServer(t, func(n int, conn *websocket.Conn, _ wsSubscribe) {
+		if n == 1 {
+			// Drop the first connection after one rate.
+			_ = conn.WriteMessage(websocket.TextMessage, []byte(`{"base":"EUR","quote":"USD","rate":1.08}`))
+			return
+		}
+		_ = conn.WriteMessage(websocket.TextMessage, []byte(`{"base":"EUR","quote":"USD","rate":1.09}`))
+		_, _, _ = conn.ReadMessage()
+	})
+
+	p := NewWebSocketProvider(WebSocketConfig{URL: url, InitialBackoff: 10 * time.Millisecond})
+	ctx, cancel := context.WithCancel(context.Background())
+	ch, err := p.StreamRates(ctx, []PairKey{{"EUR", "USD"}})
+	require.NoError(t, err)
+
+	assert.Equal(t, "1.08", nextEvent(t, ch).Rate)
+
+	ev := nextEvent(t, ch)
+	require.Error(t, ev.Error)
+	assert.Contains(t, ev.Error.Error(), "websocket stream interrupted")
+
+	assert.Equal(t, "1.09", nextEvent(t, ch).Rate)
+
+	cancel()
+	waitClosed(t, ch)
+}
+
+func TestWebSocketProvider_GivesUpAfterMaxAttempts(t *testing.T) {
+	url, srv := newWSServer(t, func(_ int, _ *websocket.Conn, _ wsSubscribe) {})
+
+	p := NewWebSocketProvider(WebSocketConfig{
+		URL:                  url,
+		InitialBackoff:       5 * time.Millisecond,
+		MaxBackoff:           10 * time.Millisecond,
+		MaxReconnectAttempts: 2,
+	})
+	ch, err := p.StreamRates(context.Background(), []PairKey{{"EUR", "USD"}})
+	require.NoError(t, err)
+	srv.Close()
+
+	events := waitClosed(t, ch)
+	require.NotEmpty(t, events)
+	last := events[len(events)-1]
+	require.Error(t, last.Error)
+	assert.Contains(t, last.Error.Error(), "gave up after 2 attempts")
+}
+
+func TestWebSocketProvider_DialError(t *testing.T) {
+	p := NewWebSocketProvider(WebSocketConfig{URL: "ws://127.0.0.1:1/rates", HandshakeTimeout: time.Second})
+	_, err := p.StreamRates(context.Background(), []PairKey{{"EUR", "USD"}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "websocket dial failed")
+}
@@ -0,0 +1,119 @@
+// Package quota counts requests per API key in monthly windows kept in the cache Redis.
+package quota
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"quoteservice/internal/rediskey"
+)
+
+// expiryGrace keeps a window's counter past its reset, so an instance whose clock lags
+// does not recreate an expired key with an expiry in the past.
+const expiryGrace = 24 * time.Hour
+
+// Usage is an API key's request count in the current window.
+type Usage struct {
+	Key   string    // API key name.
+	Limit int64     // 0 means unlimited.
+	Used  int64     // Requests in the window, rejected ones included.
+	Reset time.Time // Start of the next window.
+}
+
+// Remaining returns how many requests are left in the window, or -1 if unlimited.
+func (u Usage) Remaining() int64 {
+	if u.Limit == 0 {
+		return -1
+	}
+	return max(u.Limit-u.Used, 0)
+}
+
+// Exceeded reports whether the last counted request went over the limit.
+func (u Usage) Exceeded() bool {
+	return u.Limit > 0 && u.Used > u.Limit
+}
+
+// Tracker counts requests of the configured API keys. Windows are calendar months in
+// UTC; each has its own counter, so a month starts from zero without a reset job.
+type Tracker struct {
+	rdb    *redis.Client
+	keys   rediskey.Namespace
+	limits map[string]int64
+	now    func() time.Time
+}
+
+// NewTracker creates a Tracker for the API keys in limits, which maps a key name to its
+// monthly limit (0 counts the key's requests without limiting them).
+func NewTracker(rdb *redis.Client, keys rediskey.Namespace, limits map[string]int64) *Tracker {
+	return &Tracker{rdb: rdb, keys: keys, limits: limits, now: time.Now}
+}
+
+// Consume counts a request of the named key and returns the key's usage including it.
+// Unknown keys are not counted. The increment and its expiry are sent in one pipeline.
+func (t *Tracker) Consume(ctx context.Context, key string) (Usage, error) {
+	limit, ok := t.limits[key]
+	if !ok {
+		return Usage{}, fmt.Errorf("unknown API key %q", key)
+	}
+	start, reset := window(t.now())
+	var incr *redis.IntCmd
+	_, err := t.rdb.Pipelined(ctx, func(p redis.Pipeliner) error {
+		incr = p.Incr(ctx, t.counterKey(key, start))
+		p.ExpireAt(ctx, t.counterKey(key, start), reset.Add(expiryGrace))
+		return nil
+	})
+	if err != nil {
+		return Usage{}, fmt.Errorf("count request: %w", err)
+	}
+	return Usage{Key: key, Limit: limit, Used: incr.Val(), Reset: reset}, nil
+}
+
+// Usage returns the current window's usage of every configured key, sorted by name.
+func (t *Tracker) Usage(ctx context.Context) ([]Usage, error) {
+	names := make([]string, 0, len(t.limits))
+	for name := range t.limits {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	if len(names) == 0 {
+		return []Usage{}, nil
+	}
+
+	start, reset := window(t.now())
+	counters := make([]string, len(names))
+	for i, name := range names {
+		counters[i] = t.counterKey(name, start)
+	}
+	vals, err := t.rdb.MGet(ctx, counters...).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("read counters: %w", err)
+	}
+
+	usage := make([]Usage, len(names))
+	for i, name := range names {
+		usage[i] = Usage{Key: name, Limit: t.limits[name], Reset: reset}
+		if s, ok := vals[i].(string); ok {
+			if usage[i].Used, err = strconv.ParseInt(s, 10, 64); err != nil {
+				return nil, fmt.Errorf("read counter of %q: %w", name, err)
+			}
+		}
+	}
+	return usage, nil
+}
+
+func (t *Tracker) counterKey(key string, start time.Time) string {
+	return t.keys.Key("quota:" + start.Format("2006-01") + ":" + key)
+}
+
+// window returns the start of now's calendar month in UTC and the start of the next.
+func window(now time.Time) (start, reset time.Time) {
+	now = now.UTC()
+	start = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return start, start.AddDate(0, 1, 0)
+}
@@ -0,0 +1,121 @@
+package quota
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func newTestTracker(t *testing.T, limits map[string]int64) (*Tracker, *miniredis.Miniredis, *time.Time) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = rdb.Close() })
+
+	now := time.Date(2025, 11, 30, 23, 59, 0, 0, time.UTC)
+	mr.SetTime(now)
+	tr := NewTracker(rdb, "staging", limits)
+	tr.now = func() time.Time { return now }
+	return tr, mr, &now
+}
+
+func TestTracker_Consume(t *testing.T) {
+	tr, mr, _ := newTestTracker(t, map[string]int64{"desk": 2, "ops": 0})
+	ctx := context.Background()
+	reset := time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC)
+
+	for i, want := range []struct {
+		used      int64
+		remaining int64
+		exceeded  bool
+	}{{1, 1, false}, {2, 0, false}, {3, 0, true}} {
+		u, err := tr.Consume(ctx, "desk")
+		if err != nil {
+			t.Fatalf("Consume %d: %v", i, err)
+		}
+		if u.Used != want.used || u.Remaining() != want.remaining || u.Exceeded() != want.exceeded || !u.Reset.Equal(reset) {
+			t.Errorf("Consume %d: unexpected usage %+v", i, u)
+		}
+	}
+
+	u, err := tr.Consume(ctx, "ops")
+	if err != nil {
+		t.Fatalf("Consume: %v", err)
+	}
+	if u.Limit != 0 || u.Used != 1 || u.Remaining() != -1 || u.Exceeded() {
+		t.Errorf("Expected an unlimited key counted without a limit, got %+v", u)
+	}
+
+	const key = "staging:quota:2025-11:desk"
+	if got, _ := mr.Get(key); got != "3" {
+		t.Errorf("Expected counter %s = 3, got %q", key, got)
+	}
+	if ttl := mr.TTL(key); ttl != time.Minute+expiryGrace {
+		t.Errorf("Expected the counter to expire a grace period after the reset, TTL %v", ttl)
+	}
+
+	if _, err := tr.Consume(ctx, "unknown"); err == nil {
+		t.Error("Expected an error for an unknown key")
+	}
+}
+
+func TestTracker_WindowRollover(t *testing.T) {
+	tr, mr, now := newTestTracker(t, map[string]int64{"desk": 2})
+	ctx := context.Background()
+
+	for range 3 {
+		if _, err := tr.Consume(ctx, "desk"); err != nil {
+			t.Fatalf("Consume: %v", err)
+		}
+	}
+
+	*now = now.Add(2 * time.Minute) // 2025-12-01T00:01:00Z
+	mr.SetTime(*now)
+	mr.FastForward(2 * time.Minute)
+	u, err := tr.Consume(ctx, "desk")
+	if err != nil {
+		t.Fatalf("Consume: %v", err)
+	}
+	if u.Used != 1 || u.Exceeded() || !u.Reset.Equal(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected a fresh December window, got %+v", u)
+	}
+
+	// The November counter lives on for the grace period, then expires.
+	if !mr.Exists("staging:quota:2025-11:desk") {
+		t.Error("Expected the November counter kept past the reset")
+	}
+	mr.FastForward(expiryGrace)
+	if mr.Exists("staging:quota:2025-11:desk") {
+		t.Error("Expected the November counter expired")
+	}
+}
+
+func TestTracker_Usage(t *testing.T) {
+	tr, _, _ := newTestTracker(t, map[string]int64{"ops": 0, "desk": 10, "idle": 5})
+	ctx := context.Background()
+	for _, key := range []string{"desk", "desk", "ops"} {
+		if _, err := tr.Consume(ctx, key); err != nil {
+			t.Fatalf("Consume: %v", err)
+		}
+	}
+
+	usage, err := tr.Usage(ctx)
+	if err != nil {
+		t.Fatalf("Usage: %v", err)
+	}
+	want := []struct {
+		key         string
+		limit, used int64
+	}{{"desk", 10, 2}, {"idle", 5, 0}, {"ops", 0, 1}}
+	if len(usage) != len(want) {
+		t.Fatalf("Expected %d keys, got %+v", len(want), usage)
+	}
+	for i, w := range want {
+		if u := usage[i]; u.Key != w.key || u.Limit != w.limit || u.Used != w.used {
+			t.Errorf("Key %d: expected %+v, got %+v", i, w, u)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+// Package rediskey builds the names of the Redis keys and asynq queues the service
+// uses, so that an environment namespace is applied to all of them in one place.
+package rediskey
+
+// Namespace separates environments sharing a Redis instance, e.g. "staging". It is
+// prefixed to every key and queue name; the empty Namespace leaves names unchanged.
+type Namespace string
+
+// Key returns key in the namespace.
+func (n Namespace) Key(key string) string {
+	return n.prefix(key)
+}
+
+// Queue returns the asynq queue name in the namespace.
+func (n Namespace) Queue(name string) string {
+	return n.prefix(name)
+}
+
+func (n Namespace) prefix(name string) string {
+	if n == "" {
+		return name
+	}
+	return string(n) + ":" + name
+}
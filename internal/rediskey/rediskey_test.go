@@ -0,0 +1,22 @@
+package rediskey
+
+import "testing"
+
+func TestNamespace(t *testing.T) {
+	tests := []struct {
+		ns        Namespace
+		wantKey   string
+		wantQueue string
+	}{
+		{"", "latest:{EUR:USD}", "default"},
+		{"staging", "staging:latest:{EUR:USD}", "staging:default"},
+	}
+	for _, tc := range tests {
+		if got := tc.ns.Key("latest:{EUR:USD}"); got != tc.wantKey {
+			t.Errorf("Namespace(%q).Key = %q, want %q", tc.ns, got, tc.wantKey)
+		}
+		if got := tc.ns.Queue("default"); got != tc.wantQueue {
+			t.Errorf("Namespace(%q).Queue = %q, want %q", tc.ns, got, tc.wantQueue)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"quoteservice/internal/config"
+)
+
+// QuoteArchiver archives old terminal quote updates for the retention job.
+type QuoteArchiver interface {
+	// ArchiveBatch archives up to limit updates last written before cutoff, oldest
+	// first, and returns how many were archived.
+	ArchiveBatch(ctx context.Context, cutoff time.Time, limit int) (int64, error)
+}
+
+// PostgresQuoteArchiver is a QuoteArchiver using PostgreSQL.
+type PostgresQuoteArchiver struct {
+	db   *sql.DB
+	mode string
+}
+
+// NewPostgresQuoteArchiver creates a PostgresQuoteArchiver. mode is
+// config.RetentionModeSoftDelete or config.RetentionModeArchiveTable.
+func NewPostgresQuoteArchiver(db *sql.DB, mode string) *PostgresQuoteArchiver {
+	return &PostgresQuoteArchiver{db: db, mode: mode}
+}
+
+// retentionBatch selects the next archivable rows. Only SUCCESS and FAILED rows are
+// eligible, and a SUCCESS row only once a newer live SUCCESS exists for its pair, so
+// the latest quote of every pair stays live no matter how old it is. "Newer" orders
+// rows like GetLatestSuccess, by rate time and then write time, so a slow write of an
+// older rate cannot get the row GetLatestSuccess returns archived. SKIP LOCKED lets
+// several instances run the job without waiting on each other.
+const retentionBatch = `SELECT q.id FROM quotes q
+                  WHERE q.archived_at IS NULL
+                    AND q.updated_at < $1
+                    AND (q.status = 'FAILED'::quotes_status
+                         OR (q.status = 'SUCCESS'::quotes_status AND EXISTS (
+                             SELECT 1 FROM quotes n
+                             WHERE n.base = q.base AND n.quote = q.quote
+                               AND n.status = 'SUCCESS'::quotes_status
+                               AND n.archived_at IS NULL
+                               AND (COALESCE(n.rate_timestamp, n.updated_at), n.updated_at)
+                                   > (COALESCE(q.rate_timestamp, q.updated_at), q.updated_at))))
+                  ORDER BY q.updated_at
+                  LIMIT $2
+                  FOR UPDATE OF q SKIP LOCKED`
+
+// ArchiveBatch implements QuoteArchiver. In soft_delete mode the rows get archived_at
+// set and stay in quotes; in archive_table mode they and their status events are
+// copied to the archive tables and deleted in the same statement.
+func (a *PostgresQuoteArchiver) ArchiveBatch(ctx context.Context, cutoff time.Time, limit int) (int64, error) {
+	var query string
+	switch a.mode {
+	case config.RetentionModeSoftDelete:
+		query = `WITH batch AS (` + retentionBatch + `)
+              UPDATE quotes SET archived_at = NOW(), version = quotes.version + 1
+              FROM batch WHERE quotes.id = batch.id`
+	case config.RetentionModeArchiveTable:
+		// All sub-statements see the same snapshot, so the events are copied before
+		// the cascading delete removes them.
+		query = `WITH batch AS (` + retentionBatch + `),
+              events AS (
+                  INSERT INTO quote_status_events_archive (id, update_id, status, at, detail, instance_id)
+                  SELECT e.id, e.update_id, e.status, e.at, e.detail, e.instance_id
+                  FROM quote_status_events e JOIN batch ON e.update_id = batch.id
+              ),
+              moved AS (
+                  DELETE FROM quotes USING batch WHERE quotes.id = batch.id
+                  RETURNING quotes.*
+              )
+              INSERT INTO quotes_archive (id, base, quote, price, status, error, requested_at, updated_at,
+                                          rate_timestamp, verify_min_price, verify_max_price, verify_spread,
+                                          verify_providers, version, origin, error_code, source,
+                                          fetch_duration_ms, instance_id, archived_at)
+              SELECT id, base, quote, price, status, error, requested_at, updated_at,
+                     rate_timestamp, verify_min_price, verify_max_price, verify_spread,
+                     verify_providers, version, origin, error_code, source, fetch_duration_ms,
+                     instance_id, NOW()
+              FROM moved`
+	default:
+		return 0, fmt.Errorf("unknown retention mode %q", a.mode)
+	}
+
+	result, err := a.db.ExecContext(ctx, query, cutoff, limit)
+	if err != nil {
+		return 0, fmt.Errorf("archive quotes: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// StatusEventPruner deletes old status events for the retention job, independently of
+// their updates.
+type StatusEventPruner interface {
+	// DeleteEventsBatch deletes up to limit status events of terminal updates recorded
+	// before cutoff, oldest first, and returns how many were deleted.
+	DeleteEventsBatch(ctx context.Context, cutoff time.Time, limit int) (int64, error)
+}
+
+// DeleteEventsBatch implements StatusEventPruner. Events of PENDING and RUNNING updates
+// are kept, so an update in flight never loses its timeline. The rows are deleted
+// whatever the mode: archive_table only moves the events of updates it archives.
+func (a *PostgresQuoteArchiver) DeleteEventsBatch(ctx context.Context, cutoff time.Time, limit int) (int64, error) {
+	result, err := a.db.ExecContext(ctx, `DELETE FROM quote_status_events WHERE id IN (
+              SELECT e.id FROM quote_status_events e JOIN quotes q ON q.id = e.update_id
+              WHERE e.at < $1
+                AND q.status IN ('SUCCESS'::quotes_status, 'FAILED'::quotes_status)
+              ORDER BY e.at
+              LIMIT $2
+              FOR UPDATE OF e SKIP LOCKED)`, cutoff, limit)
+	if err != nil {
+		return 0, fmt.Errorf("delete status events: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+var (
+	_ QuoteArchiver     = (*PostgresQuoteArchiver)(nil)
+	_ StatusEventPruner = (*PostgresQuoteArchiver)(nil)
+)
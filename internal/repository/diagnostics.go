@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// DiagnosticsPair is the pair of the throwaway records written by ProbeWrite. Both codes
+// are reserved by ISO 4217 (XTS for testing, XXX for no currency), so no real update
+// uses them.
+var DiagnosticsPair = Pair{Base: "XTS", Quote: "XXX"}
+
+// ProbeWrite checks that quotes can be written and read back: it inserts a PENDING
+// record of DiagnosticsPair in a transaction, reads it and rolls the transaction back,
+// so nothing is left behind and no notification is sent.
+func ProbeWrite(ctx context.Context, db *sql.DB) (err error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin: %w", err)
+	}
+	defer func() {
+		if rbErr := tx.Rollback(); rbErr != nil && err == nil && !errors.Is(rbErr, sql.ErrTxDone) {
+			err = fmt.Errorf("rollback: %w", rbErr)
+		}
+	}()
+
+	id := uuid.New().String()
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO quotes (id, base, quote, status, requested_at, origin)
+         VALUES ($1::uuid, $2, $3, 'PENDING'::quotes_status, NOW(), $4::quotes_origin)`,
+		id, DiagnosticsPair.Base, DiagnosticsPair.Quote, OriginAPI); err != nil {
+		return fmt.Errorf("insert: %w", err)
+	}
+	var status string
+	if err := tx.QueryRowContext(ctx, `SELECT status FROM quotes WHERE id=$1::uuid`, id).Scan(&status); err != nil {
+		return fmt.Errorf("read back: %w", err)
+	}
+	if status != string(StatusPending) {
+		return fmt.Errorf("read back: status %s, want %s", status, StatusPending)
+	}
+	return nil
+}
@@ -1,9 +1,14 @@
 package repository
 
 import (
+	"context"
+	"crypto/sha256"
 	"database/sql"
 	"embed"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"strings"
 
 	"go.uber.org/zap"
 )
@@ -11,66 +16,226 @@ import (
 //go:embed migrations/*.sql
 var migrationsFS embed.FS
 
-// RunMigrations applies SQL migrations from the migrations folder using transactions.
-func RunMigrations(db *sql.DB, logger *zap.SugaredLogger) error {
+// ErrMigrationChecksumMismatch is returned when an already applied migration file
+// no longer matches the checksum recorded when it was applied.
+var ErrMigrationChecksumMismatch = errors.New("migration checksum mismatch")
+
+// MigrationOptions tunes RunMigrations.
+type MigrationOptions struct {
+	// SkipChecksumVerify disables the comparison of applied migrations against
+	// their recorded checksums. Intended for recovery only.
+	SkipChecksumVerify bool
+}
+
+// RunMigrations applies SQL migrations from the migrations folder using transactions
+// and returns how many it applied. Already applied migrations are verified against
+// their recorded checksum unless opts.SkipChecksumVerify is set.
+func RunMigrations(db *sql.DB, logger *zap.SugaredLogger, opts MigrationOptions) (int, error) {
 	if err := ensureMigrationsTable(db); err != nil {
-		return err
+		return 0, err
 	}
 
-	files, err := migrationsFS.ReadDir("migrations")
+	names, err := MigrationNames()
 	if err != nil {
-		return fmt.Errorf("migrations read error: %w", err)
+		return 0, err
 	}
 
-	for _, file := range files {
-		if file.IsDir() {
-			continue
+	applied := 0
+	for _, name := range names {
+		sqlBytes, err := migrationsFS.ReadFile("migrations/" + name)
+		if err != nil {
+			return applied, fmt.Errorf("read migration file %s: %w", name, err)
 		}
-		name := file.Name()
+		sqlScript := string(sqlBytes)
+		checksum := MigrationChecksum(sqlScript)
 
-		applied, err := isApplied(db, name)
+		done, stored, err := isApplied(db, name)
 		if err != nil {
-			return err
+			return applied, err
 		}
-		if applied {
+		if done {
+			if err := verifyApplied(db, name, stored, checksum, opts, logger); err != nil {
+				return applied, err
+			}
 			logger.Infow("Skipping already applied migration", "migration", name)
 			continue
 		}
 
+		if err := executeMigration(db, name, sqlScript, checksum, logger); err != nil {
+			return applied, err
+		}
+		applied++
+	}
+	return applied, nil
+}
+
+// PendingMigrations returns the embedded migrations RunMigrations would apply, without
+// changing the database. Applied migrations are verified against their recorded
+// checksum like RunMigrations does, unless opts.SkipChecksumVerify is set.
+func PendingMigrations(ctx context.Context, db *sql.DB, opts MigrationOptions) ([]string, error) {
+	names, err := MigrationNames()
+	if err != nil {
+		return nil, err
+	}
+
+	var exists bool
+	if err := db.QueryRowContext(ctx, "SELECT to_regclass('schema_migrations') IS NOT NULL").Scan(&exists); err != nil {
+		return nil, fmt.Errorf("check schema_migrations table: %w", err)
+	}
+	if !exists {
+		return names, nil
+	}
+
+	var pending []string
+	for _, name := range names {
+		applied, stored, err := isApplied(db, name)
+		if err != nil {
+			return nil, err
+		}
+		if !applied {
+			pending = append(pending, name)
+			continue
+		}
+		// An empty checksum predates checksums and is backfilled by RunMigrations.
+		if stored == "" || opts.SkipChecksumVerify {
+			continue
+		}
 		sqlBytes, err := migrationsFS.ReadFile("migrations/" + name)
 		if err != nil {
-			return fmt.Errorf("read migration file %s: %w", name, err)
+			return nil, fmt.Errorf("read migration file %s: %w", name, err)
 		}
-		sqlScript := string(sqlBytes)
+		if err := verifyChecksum(name, stored, MigrationChecksum(string(sqlBytes))); err != nil {
+			return nil, err
+		}
+	}
+	return pending, nil
+}
+
+// MigrationNames returns the embedded migration file names in the order they are applied.
+func MigrationNames() ([]string, error) {
+	files, err := migrationsFS.ReadDir("migrations")
+	if err != nil {
+		return nil, fmt.Errorf("migrations read error: %w", err)
+	}
+	names := make([]string, 0, len(files))
+	for _, file := range files {
+		if !file.IsDir() {
+			names = append(names, file.Name())
+		}
+	}
+	return names, nil
+}
+
+// CheckSchema verifies that every embedded migration has been applied and that the
+// quotes table can be read with the current credentials.
+func CheckSchema(ctx context.Context, db *sql.DB) error {
+	names, err := MigrationNames()
+	if err != nil {
+		return err
+	}
 
-		if err := executeMigration(db, name, sqlScript, logger); err != nil {
-			return err
+	rows, err := db.QueryContext(ctx, "SELECT version FROM schema_migrations")
+	if err != nil {
+		return fmt.Errorf("read schema_migrations: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	applied := make(map[string]bool, len(names))
+	for rows.Next() {
+		var version string
+		if err := rows.Scan(&version); err != nil {
+			return fmt.Errorf("read schema_migrations: %w", err)
+		}
+		applied[version] = true
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("read schema_migrations: %w", err)
+	}
+
+	var pending []string
+	for _, name := range names {
+		if !applied[name] {
+			pending = append(pending, name)
 		}
 	}
+	if len(pending) > 0 {
+		return fmt.Errorf("migrations not applied: %s", strings.Join(pending, ", "))
+	}
+
+	var one int
+	err = db.QueryRowContext(ctx, "SELECT 1 FROM quotes LIMIT 1").Scan(&one)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("read quotes table: %w", err)
+	}
 	return nil
 }
 
+// MigrationChecksum returns the hex-encoded SHA-256 of a migration script, as
+// recorded in schema_migrations.checksum.
+func MigrationChecksum(sqlScript string) string {
+	sum := sha256.Sum256([]byte(sqlScript))
+	return hex.EncodeToString(sum[:])
+}
+
+// verifyChecksum compares the checksum recorded for an applied migration with the
+// checksum of the embedded file.
+func verifyChecksum(name, stored, current string) error {
+	if stored != current {
+		return fmt.Errorf("%w: %s was modified after being applied (recorded %s, file %s)",
+			ErrMigrationChecksumMismatch, name, stored, current)
+	}
+	return nil
+}
+
+func verifyApplied(db *sql.DB, name, stored, current string, opts MigrationOptions, logger *zap.SugaredLogger) error {
+	if stored == "" {
+		// Applied before checksums were recorded: trust the current file.
+		if _, err := db.Exec("UPDATE schema_migrations SET checksum = $2 WHERE version = $1", name, current); err != nil {
+			return fmt.Errorf("record checksum for migration %s: %w", name, err)
+		}
+		logger.Infow("Recorded checksum for previously applied migration", "migration", name)
+		return nil
+	}
+	if opts.SkipChecksumVerify {
+		if stored != current {
+			logger.Warnw("Ignoring migration checksum mismatch", "migration", name)
+		}
+		return nil
+	}
+	return verifyChecksum(name, stored, current)
+}
+
 func ensureMigrationsTable(db *sql.DB) error {
 	const query = `CREATE TABLE IF NOT EXISTS schema_migrations (
 		version    TEXT PRIMARY KEY,
-		applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		checksum   TEXT NOT NULL
 	)`
 	if _, err := db.Exec(query); err != nil {
 		return fmt.Errorf("create schema_migrations table: %w", err)
 	}
+	// Tables created before checksums existed get an empty checksum, which
+	// RunMigrations backfills from the embedded file.
+	const alter = `ALTER TABLE schema_migrations ADD COLUMN IF NOT EXISTS checksum TEXT NOT NULL DEFAULT ''`
+	if _, err := db.Exec(alter); err != nil {
+		return fmt.Errorf("add schema_migrations.checksum column: %w", err)
+	}
 	return nil
 }
 
-func isApplied(db *sql.DB, version string) (bool, error) {
-	var exists bool
-	err := db.QueryRow("SELECT EXISTS(SELECT 1 FROM schema_migrations WHERE version = $1)", version).Scan(&exists)
+func isApplied(db *sql.DB, version string) (bool, string, error) {
+	var checksum string
+	err := db.QueryRow("SELECT checksum FROM schema_migrations WHERE version = $1", version).Scan(&checksum)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, "", nil
+	}
 	if err != nil {
-		return false, fmt.Errorf("check migration %s: %w", version, err)
+		return false, "", fmt.Errorf("check migration %s: %w", version, err)
 	}
-	return exists, nil
+	return true, checksum, nil
 }
 
-func executeMigration(db *sql.DB, name, sqlScript string, logger *zap.SugaredLogger) error {
+func executeMigration(db *sql.DB, name, sqlScript, checksum string, logger *zap.SugaredLogger) error {
 	tx, err := db.Begin()
 	if err != nil {
 		return fmt.Errorf("begin transaction for migration %s: %w", name, err)
@@ -85,7 +250,7 @@ func executeMigration(db *sql.DB, name, sqlScript string, logger *zap.SugaredLog
 		return fmt.Errorf("execute migration %s: %w", name, err)
 	}
 
-	if _, err = tx.Exec("INSERT INTO schema_migrations (version) VALUES ($1)", name); err != nil {
+	if _, err = tx.Exec("INSERT INTO schema_migrations (version, checksum) VALUES ($1, $2)", name, checksum); err != nil {
 		return fmt.Errorf("record migration %s: %w", name, err)
 	}
 
@@ -0,0 +1,21 @@
+-- Publish successful quote updates on the quotes_updated channel so that
+-- processes other than the worker can observe them via LISTEN.
+CREATE OR REPLACE FUNCTION notify_quotes_updated() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('quotes_updated', json_build_object(
+        'base',       NEW.base,
+        'quote',      NEW.quote,
+        'price',      NEW.price::text,
+        'updated_at', NEW.updated_at
+    )::text);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS quotes_updated_notify ON quotes;
+
+CREATE TRIGGER quotes_updated_notify
+    AFTER UPDATE ON quotes
+    FOR EACH ROW
+    WHEN (NEW.status = 'SUCCESS' AND OLD.status IS DISTINCT FROM NEW.status)
+    EXECUTE FUNCTION notify_quotes_updated();
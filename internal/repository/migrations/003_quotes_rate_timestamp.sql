@@ -0,0 +1,7 @@
+-- Record when the provider observed the rate, separately from when the row was written.
+ALTER TABLE quotes ADD COLUMN IF NOT EXISTS rate_timestamp TIMESTAMPTZ;
+
+-- Existing successful rows only know their write time, so use it as the best estimate.
+UPDATE quotes
+SET rate_timestamp = updated_at
+WHERE status = 'SUCCESS' AND rate_timestamp IS NULL;
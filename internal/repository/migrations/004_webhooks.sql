@@ -0,0 +1,11 @@
+-- Webhook subscriptions notified when a pair's quote is updated
+CREATE TABLE IF NOT EXISTS webhooks
+(
+    id          UUID PRIMARY KEY,
+    base        CHAR(3) NOT NULL,
+    quote       CHAR(3) NOT NULL,
+    url         TEXT NOT NULL,
+    secret_hash TEXT NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT webhooks_pair_url_unique UNIQUE (base, quote, url)
+);
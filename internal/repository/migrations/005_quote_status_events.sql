@@ -0,0 +1,13 @@
+-- Timeline of status transitions per quote update, written by the same statement as
+-- each transition. Events go away together with their quote row.
+CREATE TABLE IF NOT EXISTS quote_status_events
+(
+    id        BIGSERIAL PRIMARY KEY,
+    update_id UUID NOT NULL REFERENCES quotes (id) ON DELETE CASCADE,
+    status    quotes_status NOT NULL,
+    at        TIMESTAMPTZ NOT NULL,
+    detail    TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_quote_status_events_update
+    ON quote_status_events (update_id, at, id);
@@ -0,0 +1,6 @@
+-- Spread across all providers for a successful update, recorded when verification is
+-- enabled. verify_spread is (max - min) / min in percent; all columns stay NULL otherwise.
+ALTER TABLE quotes ADD COLUMN IF NOT EXISTS verify_min_price NUMERIC(18, 6);
+ALTER TABLE quotes ADD COLUMN IF NOT EXISTS verify_max_price NUMERIC(18, 6);
+ALTER TABLE quotes ADD COLUMN IF NOT EXISTS verify_spread NUMERIC(12, 4);
+ALTER TABLE quotes ADD COLUMN IF NOT EXISTS verify_providers INT;
@@ -0,0 +1,49 @@
+-- Retention: old terminal updates are either soft-deleted (archived_at set) or moved to
+-- quotes_archive together with their status events. Reads of live data filter on
+-- archived_at IS NULL, so the pair index becomes partial and the old one is dropped.
+ALTER TABLE quotes ADD COLUMN IF NOT EXISTS archived_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_quotes_pair_live
+    ON quotes (base, quote, updated_at DESC)
+    WHERE archived_at IS NULL;
+
+DROP INDEX IF EXISTS idx_quotes_pair_time;
+
+-- Candidates for the next retention batch, oldest first.
+CREATE INDEX IF NOT EXISTS idx_quotes_retention
+    ON quotes (updated_at)
+    WHERE archived_at IS NULL;
+
+-- Columns mirror quotes; a migration adding a column to quotes must add it here too.
+CREATE TABLE IF NOT EXISTS quotes_archive
+(
+    id               UUID PRIMARY KEY,
+    base             CHAR(3) NOT NULL,
+    quote            CHAR(3) NOT NULL,
+    price            NUMERIC(18, 6),
+    status           quotes_status NOT NULL,
+    error            TEXT,
+    requested_at     TIMESTAMPTZ NOT NULL,
+    updated_at       TIMESTAMPTZ,
+    rate_timestamp   TIMESTAMPTZ,
+    verify_min_price NUMERIC(18, 6),
+    verify_max_price NUMERIC(18, 6),
+    verify_spread    NUMERIC(12, 4),
+    verify_providers INT,
+    archived_at      TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_quotes_archive_pair_time
+    ON quotes_archive (base, quote, updated_at DESC);
+
+CREATE TABLE IF NOT EXISTS quote_status_events_archive
+(
+    id        BIGINT PRIMARY KEY,
+    update_id UUID NOT NULL,
+    status    quotes_status NOT NULL,
+    at        TIMESTAMPTZ NOT NULL,
+    detail    TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_quote_status_events_archive_update
+    ON quote_status_events_archive (update_id, at, id);
@@ -0,0 +1,4 @@
+-- Optimistic concurrency: every write to a quote increments version, and status
+-- transitions only apply when the caller's expected version still matches.
+ALTER TABLE quotes ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1;
+ALTER TABLE quotes_archive ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1;
@@ -0,0 +1,10 @@
+-- The code path that created an update. Rows created before origins were recorded
+-- came from the API.
+DO $$ BEGIN
+    CREATE TYPE quotes_origin AS ENUM ('api', 'scheduler', 'auto_refresh', 'backfill', 'retry', 'stream');
+EXCEPTION
+    WHEN duplicate_object THEN null;
+END $$;
+
+ALTER TABLE quotes ADD COLUMN IF NOT EXISTS origin quotes_origin NOT NULL DEFAULT 'api';
+ALTER TABLE quotes_archive ADD COLUMN IF NOT EXISTS origin quotes_origin NOT NULL DEFAULT 'api';
@@ -0,0 +1,12 @@
+-- Prices are stored exactly as the provider sent them. NUMERIC(18, 6) rounded rates of
+-- pairs like IDR/BTC (around 1e-9) to zero; an unconstrained NUMERIC keeps every digit
+-- and scale of the input. Widening the type does not rewrite the tables.
+ALTER TABLE quotes
+    ALTER COLUMN price TYPE NUMERIC,
+    ALTER COLUMN verify_min_price TYPE NUMERIC,
+    ALTER COLUMN verify_max_price TYPE NUMERIC;
+
+ALTER TABLE quotes_archive
+    ALTER COLUMN price TYPE NUMERIC,
+    ALTER COLUMN verify_min_price TYPE NUMERIC,
+    ALTER COLUMN verify_max_price TYPE NUMERIC;
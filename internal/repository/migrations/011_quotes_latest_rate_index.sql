@@ -0,0 +1,5 @@
+-- The latest successful quote of a pair is the one with the most recent rate, not the
+-- one that completed last: updates can finish out of order.
+CREATE INDEX IF NOT EXISTS idx_quotes_pair_latest_rate
+    ON quotes (base, quote, (COALESCE(rate_timestamp, updated_at)) DESC, updated_at DESC)
+    WHERE status = 'SUCCESS' AND archived_at IS NULL;
@@ -0,0 +1,5 @@
+-- Normalized cause of a FAILED update, e.g. 'timeout' or 'unsupported_pair', next to
+-- the free-text error. Plain text rather than an enum so a new code needs no
+-- migration. Rows failed before it was recorded keep NULL.
+ALTER TABLE quotes ADD COLUMN IF NOT EXISTS error_code TEXT;
+ALTER TABLE quotes_archive ADD COLUMN IF NOT EXISTS error_code TEXT;
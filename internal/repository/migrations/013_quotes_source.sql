@@ -0,0 +1,16 @@
+-- How a completed update's rate was fetched: the provider that answered or, for a
+-- failed update, the last one tried, and how long fetching took. NULL for rows
+-- completed before they were recorded and for updates that never called a provider.
+ALTER TABLE quotes ADD COLUMN IF NOT EXISTS source TEXT;
+ALTER TABLE quotes ADD COLUMN IF NOT EXISTS fetch_duration_ms INT;
+ALTER TABLE quotes_archive ADD COLUMN IF NOT EXISTS source TEXT;
+ALTER TABLE quotes_archive ADD COLUMN IF NOT EXISTS fetch_duration_ms INT;
+
+-- Reliability reports aggregate completed updates by when they completed, archived
+-- or not; the included columns let them read the index alone.
+CREATE INDEX IF NOT EXISTS idx_quotes_completed
+    ON quotes (updated_at) INCLUDE (source, base, quote, status, fetch_duration_ms)
+    WHERE status IN ('SUCCESS', 'FAILED');
+
+CREATE INDEX IF NOT EXISTS idx_quotes_archive_completed
+    ON quotes_archive (updated_at) INCLUDE (source, base, quote, status, fetch_duration_ms);
@@ -0,0 +1,7 @@
+-- Service instance that claimed an update, written by MarkRunning, and the instance
+-- that made each status transition where one is known. NULL for rows written before
+-- it was recorded and for transitions no worker made, e.g. PENDING.
+ALTER TABLE quotes ADD COLUMN IF NOT EXISTS instance_id TEXT;
+ALTER TABLE quotes_archive ADD COLUMN IF NOT EXISTS instance_id TEXT;
+ALTER TABLE quote_status_events ADD COLUMN IF NOT EXISTS instance_id TEXT;
+ALTER TABLE quote_status_events_archive ADD COLUMN IF NOT EXISTS instance_id TEXT;
@@ -0,0 +1,3 @@
+-- Status events have their own retention horizon, deleted oldest first by time.
+CREATE INDEX IF NOT EXISTS idx_quote_status_events_at
+    ON quote_status_events (at);
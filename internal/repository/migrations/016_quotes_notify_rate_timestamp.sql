@@ -0,0 +1,14 @@
+-- Include when the provider observed the rate, so subscribers get the rate_timestamp
+-- that GET /quotes/{update_id} returns.
+CREATE OR REPLACE FUNCTION notify_quotes_updated() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('quotes_updated', json_build_object(
+        'base',           NEW.base,
+        'quote',          NEW.quote,
+        'price',          NEW.price::text,
+        'updated_at',     NEW.updated_at,
+        'rate_timestamp', NEW.rate_timestamp
+    )::text);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
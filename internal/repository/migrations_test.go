@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMigrationChecksum(t *testing.T) {
+	const want = "17db4fd369edb9244b9f91d9aeed145c3d04ad8ba6e95d06247f07a63527d11a" // sha256("SELECT 1;")
+	if got := MigrationChecksum("SELECT 1;"); got != want {
+		t.Fatalf("expected %s, got %s", want, got)
+	}
+	if MigrationChecksum("SELECT 1; ") == want {
+		t.Fatal("whitespace change must alter the checksum")
+	}
+}
+
+func TestVerifyChecksum(t *testing.T) {
+	names, err := MigrationNames()
+	if err != nil {
+		t.Fatalf("MigrationNames: %v", err)
+	}
+	for _, name := range names {
+		sqlBytes, err := migrationsFS.ReadFile("migrations/" + name)
+		if err != nil {
+			t.Fatalf("read %s: %v", name, err)
+		}
+		sum := MigrationChecksum(string(sqlBytes))
+
+		if err := verifyChecksum(name, sum, sum); err != nil {
+			t.Errorf("%s: clean migration rejected: %v", name, err)
+		}
+		tampered := MigrationChecksum(string(sqlBytes) + "\n-- edited")
+		if err := verifyChecksum(name, sum, tampered); !errors.Is(err, ErrMigrationChecksumMismatch) {
+			t.Errorf("%s: expected ErrMigrationChecksumMismatch, got %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+	"go.uber.org/zap"
+)
+
+// QuotesUpdatedChannel is the Postgres NOTIFY channel fed by the quotes_updated_notify trigger.
+const QuotesUpdatedChannel = "quotes_updated"
+
+// QuoteNotification is the payload published when a quote update reaches SUCCESS.
+type QuoteNotification struct {
+	Base      string    `json:"base"`
+	Quote     string    `json:"quote"`
+	Price     string    `json:"price"`
+	UpdatedAt time.Time `json:"updated_at"`
+	// RateTimestamp is nil for records without one and in notifications sent before
+	// migration 016.
+	RateTimestamp *time.Time `json:"rate_timestamp"`
+}
+
+// NotifyListener receives quote notifications over Postgres LISTEN/NOTIFY.
+type NotifyListener struct {
+	db      *sql.DB
+	channel string
+}
+
+// NewNotifyListener creates a NotifyListener for the given channel.
+func NewNotifyListener(db *sql.DB, channel string) *NotifyListener {
+	return &NotifyListener{db: db, channel: channel}
+}
+
+// Listen dedicates a pooled connection to LISTEN on the channel and streams decoded
+// notifications until ctx is cancelled or the connection fails. The returned channel
+// is closed when listening stops.
+func (l *NotifyListener) Listen(ctx context.Context) (<-chan QuoteNotification, error) {
+	conn, err := l.db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("acquire listen connection: %w", err)
+	}
+
+	ident := pgx.Identifier{l.channel}.Sanitize()
+	if _, err := conn.ExecContext(ctx, "LISTEN "+ident); err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("listen on %s: %w", l.channel, err)
+	}
+
+	out := make(chan QuoteNotification, 16)
+	go func() {
+		defer close(out)
+		defer conn.Close() //nolint:errcheck // best-effort close
+
+		_ = conn.Raw(func(driverConn any) error {
+			pgConn := driverConn.(*stdlib.Conn).Conn()
+			// Return the connection to the pool without a lingering subscription.
+			defer func() {
+				unlistenCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				defer cancel()
+				_, _ = pgConn.Exec(unlistenCtx, "UNLISTEN "+ident)
+			}()
+
+			for {
+				n, err := pgConn.WaitForNotification(ctx)
+				if err != nil {
+					return err
+				}
+				var qn QuoteNotification
+				if err := json.Unmarshal([]byte(n.Payload), &qn); err != nil {
+					continue
+				}
+				select {
+				case out <- qn:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+		})
+	}()
+	return out, nil
+}
+
+// Backoff of PGNotifyBroker's attempts to listen: doubled after each failed attempt up
+// to the maximum, and reset once listening again.
+const (
+	notifyInitialBackoff = time.Second
+	notifyMaxBackoff     = 30 * time.Second
+)
+
+// PGNotifyBroker fans out notifications from a NotifyListener to per-pair watchers,
+// so API instances see updates completed by workers running in other processes.
+type PGNotifyBroker struct {
+	listener       *NotifyListener
+	logger         *zap.SugaredLogger
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+	listening      atomic.Bool
+
+	mu   sync.Mutex
+	subs map[Pair]map[chan QuoteNotification]struct{}
+}
+
+// NewPGNotifyBroker creates a PGNotifyBroker. WatchPair's channels receive nothing
+// until Run is listening.
+func NewPGNotifyBroker(listener *NotifyListener, logger *zap.SugaredLogger) *PGNotifyBroker {
+	return &PGNotifyBroker{
+		listener:       listener,
+		logger:         logger,
+		initialBackoff: notifyInitialBackoff,
+		maxBackoff:     notifyMaxBackoff,
+		subs:           make(map[Pair]map[chan QuoteNotification]struct{}),
+	}
+}
+
+// Run listens and dispatches until ctx is cancelled, then returns nil. When LISTEN
+// fails, or the listen connection drops later, e.g. on a Postgres restart or failover,
+// every watcher's channel is closed, so subscribers reconnect rather than wait on a
+// subscription that never fires, and the broker listens again after a backoff.
+// Notifications sent while it is not listening are lost.
+func (b *PGNotifyBroker) Run(ctx context.Context) error {
+	backoff := b.initialBackoff
+	for {
+		notifications, err := b.listener.Listen(ctx)
+		if err == nil {
+			b.listening.Store(true)
+			for n := range notifications {
+				b.dispatch(n)
+			}
+			b.listening.Store(false)
+			backoff = b.initialBackoff
+			if ctx.Err() == nil {
+				b.logger.Warnw("Quote notification listener disconnected, reconnecting", "retry_in", backoff)
+			}
+		} else if ctx.Err() == nil {
+			b.logger.Warnw("Failed to listen for quote notifications", "error", err, "retry_in", backoff)
+		}
+		b.closeAll()
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(backoff):
+		}
+		if err != nil {
+			backoff = min(backoff*2, b.maxBackoff)
+		}
+	}
+}
+
+// Listening reports whether the broker currently holds a listen connection.
+func (b *PGNotifyBroker) Listening() bool {
+	return b.listening.Load()
+}
+
+// WatchPair returns a channel receiving notifications for pair until ctx is
+// cancelled. Slow watchers miss notifications rather than blocking the broker.
+func (b *PGNotifyBroker) WatchPair(ctx context.Context, pair Pair) <-chan QuoteNotification {
+	ch := make(chan QuoteNotification, 1)
+
+	b.mu.Lock()
+	if b.subs[pair] == nil {
+		b.subs[pair] = make(map[chan QuoteNotification]struct{})
+	}
+	b.subs[pair][ch] = struct{}{}
+	b.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if _, ok := b.subs[pair][ch]; ok {
+			delete(b.subs[pair], ch)
+			if len(b.subs[pair]) == 0 {
+				delete(b.subs, pair)
+			}
+			close(ch)
+		}
+	}()
+	return ch
+}
+
+func (b *PGNotifyBroker) dispatch(n QuoteNotification) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.subs[Pair{Base: n.Base, Quote: n.Quote}] {
+		select {
+		case ch <- n:
+		default:
+		}
+	}
+}
+
+func (b *PGNotifyBroker) closeAll() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for key, chans := range b.subs {
+		for ch := range chans {
+			close(ch)
+		}
+		delete(b.subs, key)
+	}
+}
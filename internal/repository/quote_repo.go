@@ -6,6 +6,8 @@ import (
 	"errors"
 	"fmt"
 	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
 // Status represents the state of a quote update request.
@@ -19,28 +21,248 @@ const (
 	StatusFailed  Status = "FAILED"
 )
 
+// Origin is the code path that created a quote update.
+type Origin string
+
+// Origin values. Only OriginAPI and OriginStream are produced so far; the others are
+// reserved in the quotes_origin enum for the background paths that will create updates.
+const (
+	OriginAPI         Origin = "api"
+	OriginScheduler   Origin = "scheduler"
+	OriginAutoRefresh Origin = "auto_refresh"
+	OriginBackfill    Origin = "backfill"
+	OriginRetry       Origin = "retry"
+	OriginStream      Origin = "stream"
+)
+
+// Origins lists every Origin, in enum order.
+var Origins = []Origin{OriginAPI, OriginScheduler, OriginAutoRefresh, OriginBackfill, OriginRetry, OriginStream}
+
+// ErrorCode classifies why an update FAILED, so failures can be counted by cause; the
+// error message keeps the details.
+type ErrorCode string
+
+// ErrorCode values. Records failed before error codes were recorded have none.
+const (
+	ErrorCodeTimeout             ErrorCode = "timeout"
+	ErrorCodeRateLimited         ErrorCode = "rate_limited"
+	ErrorCodeAuth                ErrorCode = "auth"
+	ErrorCodeProviderConfig      ErrorCode = "provider_config"
+	ErrorCodeUnsupportedPair     ErrorCode = "unsupported_pair"
+	ErrorCodeProviderUnavailable ErrorCode = "provider_unavailable"
+	ErrorCodeBadResponse         ErrorCode = "bad_response"
+	ErrorCodeInvalidRequest      ErrorCode = "invalid_request"
+	ErrorCodeTaskLost            ErrorCode = "task_lost"
+	ErrorCodeInternal            ErrorCode = "internal"
+)
+
+// ErrorCodes lists every ErrorCode.
+var ErrorCodes = []ErrorCode{
+	ErrorCodeTimeout, ErrorCodeRateLimited, ErrorCodeAuth, ErrorCodeProviderConfig, ErrorCodeUnsupportedPair, ErrorCodeProviderUnavailable,
+	ErrorCodeBadResponse, ErrorCodeInvalidRequest, ErrorCodeTaskLost, ErrorCodeInternal,
+}
+
+// InitialVersion is the version of a record created by CreateUpdate. Every write to a
+// record increments its version by one.
+const InitialVersion int64 = 1
+
+// ErrQuoteNotFound is returned by a status transition whose record does not exist.
+var ErrQuoteNotFound = errors.New("quote not found")
+
+// ErrVersionConflict is matched by a *VersionConflictError.
+var ErrVersionConflict = errors.New("quote version conflict")
+
+// ErrInvalidTransition is matched by an *InvalidTransitionError.
+var ErrInvalidTransition = errors.New("invalid quote status transition")
+
+// VersionConflictError is returned by a status transition when the record was changed
+// since the caller read it and is no longer at the expected version.
+type VersionConflictError struct {
+	ID       string
+	Expected int64
+	Actual   int64
+	Status   Status // Status of the record when the transition was rejected.
+}
+
+func (e *VersionConflictError) Error() string {
+	return fmt.Sprintf("quote %s is at version %d in status %s, expected version %d",
+		e.ID, e.Actual, e.Status, e.Expected)
+}
+
+// Is reports whether target is ErrVersionConflict.
+func (e *VersionConflictError) Is(target error) bool {
+	return target == ErrVersionConflict
+}
+
+// InvalidTransitionError is returned by a status transition when the record is at the
+// expected version but in a status the transition may not leave, e.g. MarkSuccess on
+// a PENDING record.
+type InvalidTransitionError struct {
+	ID   string
+	From Status
+	To   Status
+}
+
+func (e *InvalidTransitionError) Error() string {
+	return fmt.Sprintf("quote %s cannot move from %s to %s", e.ID, e.From, e.To)
+}
+
+// Is reports whether target is ErrInvalidTransition.
+func (e *InvalidTransitionError) Is(target error) bool {
+	return target == ErrInvalidTransition
+}
+
+// ErrPairInFlight is matched by a *PairInFlightError.
+var ErrPairInFlight = errors.New("pair already has an update in flight")
+
+// PairInFlightError is returned by MarkPending when another update of the record's pair
+// is already PENDING or RUNNING; uniq_quotes_pair_pending allows only one per pair.
+type PairInFlightError struct {
+	ID         string
+	InFlightID string // Empty if the in-flight update finished before it could be read.
+}
+
+func (e *PairInFlightError) Error() string {
+	if e.InFlightID == "" {
+		return fmt.Sprintf("quote %s cannot return to PENDING while its pair has an update in flight", e.ID)
+	}
+	return fmt.Sprintf("quote %s cannot return to PENDING while update %s of its pair is in flight", e.ID, e.InFlightID)
+}
+
+// Is reports whether target is ErrPairInFlight.
+func (e *PairInFlightError) Is(target error) bool {
+	return target == ErrPairInFlight
+}
+
 // Quote represents a quote update record in the DB.
 type Quote struct {
-	ID          string
-	Base        string
-	Quote       string
-	Price       *string
-	Status      Status
-	ErrorMsg    *string
+	ID       string
+	Base     string
+	Quote    string
+	Price    *string
+	Status   Status
+	ErrorMsg *string
+	// ErrorCode is set with ErrorMsg; empty for records failed before codes were recorded.
+	ErrorCode   ErrorCode
 	RequestedAt time.Time
 	UpdatedAt   *time.Time
+	// RateTimestamp is when the provider observed the price; UpdatedAt is when the row was written.
+	RateTimestamp *time.Time
+	// Verification is the spread across providers, set once SaveVerification has run.
+	Verification *Verification
+	Version      int64
+	Origin       Origin
+	// InstanceID is the service instance that last marked the update RUNNING; empty
+	// if none did or it was not recorded.
+	InstanceID string
+	// Derived says how a latest price read from the cache was computed rather than
+	// fetched, e.g. "inverse"; it is never stored.
+	Derived string
 }
 
-// QuoteRepository defines DB operations for quotes.
+// Pair returns the record's currency pair.
+func (q *Quote) Pair() Pair {
+	return Pair{Base: q.Base, Quote: q.Quote}
+}
+
+// Verification records how far the providers' rates for an update diverged. Prices
+// are decimal strings; Spread is (MaxPrice - MinPrice) / MinPrice in percent.
+type Verification struct {
+	MinPrice  string
+	MaxPrice  string
+	Spread    string
+	Providers int // Number of providers whose rates were compared.
+}
+
+// Fetch records how an update's rate was fetched. Source is the provider that answered
+// or, for a failed update, the last one tried; empty if unknown.
+type Fetch struct {
+	Source   string
+	Duration time.Duration
+}
+
+// Pair is a currency pair in upper case. It is passed between layers instead of
+// separate base and quote strings, which are easy to swap; service.NewPair builds a
+// validated one. In JSON it is the two fields "base" and "quote".
+type Pair struct {
+	Base  string `json:"base"`
+	Quote string `json:"quote"`
+}
+
+// String returns the pair as "BASE/QUOTE", e.g. "EUR/MXN".
+func (p Pair) String() string {
+	return p.Base + "/" + p.Quote
+}
+
+// Inverse returns the pair with base and quote swapped.
+func (p Pair) Inverse() Pair {
+	return Pair{Base: p.Quote, Quote: p.Base}
+}
+
+// CacheKey returns the pair's part of a Redis key, "{BASE:QUOTE}". The braces make it
+// the key's hash tag, so all keys of a pair share a cluster slot.
+func (p Pair) CacheKey() string {
+	return "{" + p.Base + ":" + p.Quote + "}"
+}
+
+// PairFreshness is when a pair's latest live SUCCESS update was written; LatestSuccess
+// is nil if the pair has none.
+type PairFreshness struct {
+	Pair
+	LatestSuccess *time.Time
+}
+
+// StatusEvent records when a quote update entered a status. Detail holds the price
+// for SUCCESS and the error message for FAILED; InstanceID is the service instance
+// that made a RUNNING transition, empty for other events.
+type StatusEvent struct {
+	Status     Status
+	At         time.Time
+	Detail     *string
+	InstanceID string
+}
+
+// QuoteRepository defines DB operations for quotes. Every status transition also
+// appends a StatusEvent in the same statement.
+//
+// The Mark* transitions take the version the caller last read and only apply if the
+// record is still at it; on success the record is at version+1. Otherwise they return
+// ErrQuoteNotFound, a *VersionConflictError, or an *InvalidTransitionError if the
+// record is at version but in a status the transition may not leave.
 type QuoteRepository interface {
-	CreateUpdate(ctx context.Context, base, quote, id string) (string, error)
-	MarkRunning(ctx context.Context, id string) error
-	MarkSuccess(ctx context.Context, id, price string) error
-	MarkFailed(ctx context.Context, id, errorMsg string) error
+	CreateUpdate(ctx context.Context, pair Pair, id string, origin Origin) (CreateUpdateResult, error)
+	// MarkRunning records instanceID, the service instance claiming the update, on the
+	// record and its RUNNING event; empty records none.
+	MarkRunning(ctx context.Context, id string, version int64, instanceID string) error
+	MarkSuccess(ctx context.Context, id string, version int64, price string, rateTimestamp time.Time) error
+	MarkFailed(ctx context.Context, id string, version int64, code ErrorCode, errorMsg string) error
+	// MarkPending returns a FAILED or RUNNING update to PENDING, clearing its outcome,
+	// so a requeued task processes it like a new one. It returns a *PairInFlightError
+	// for a FAILED update whose pair has another update PENDING or RUNNING.
+	MarkPending(ctx context.Context, id string, version int64) error
+	// SaveVerification records the provider spread of a SUCCESS update.
+	SaveVerification(ctx context.Context, id string, v Verification) error
+	// RecordFetch records how a SUCCESS or FAILED update's rate was fetched.
+	RecordFetch(ctx context.Context, id string, f Fetch) error
 	GetByID(ctx context.Context, id string) (*Quote, error)
-	GetLatestSuccess(ctx context.Context, base, quote string) (*Quote, error)
+	GetLatestSuccess(ctx context.Context, pair Pair) (*Quote, error)
+	// GetLatestAny returns the pair's most recently changed update of any status.
+	GetLatestAny(ctx context.Context, pair Pair) (*Quote, error)
+	GetPriceAtTime(ctx context.Context, pair Pair, at time.Time) (*Quote, error)
+	// LatestSuccessTimes returns one PairFreshness per pair in pairs, or for every pair
+	// with a live update when pairs is empty, in a single query.
+	LatestSuccessTimes(ctx context.Context, pairs []Pair) ([]PairFreshness, error)
+	// CountByStatus returns the number of live updates with the given status.
+	CountByStatus(ctx context.Context, status Status) (int, error)
+	// ListPendingOlderThan returns up to limit live PENDING updates requested before
+	// before, oldest first.
+	ListPendingOlderThan(ctx context.Context, before time.Time, limit int) ([]Quote, error)
+	// GetStatusEvents returns the update's events oldest first; empty for an unknown id.
+	GetStatusEvents(ctx context.Context, id string) ([]StatusEvent, error)
 }
 
+var _ QuoteRepository = (*PostgresQuoteRepository)(nil)
+
 // PostgresQuoteRepository is an implementation of QuoteRepository using PostgreSQL.
 type PostgresQuoteRepository struct {
 	db *sql.DB
@@ -51,67 +273,236 @@ func NewPostgresQuoteRepository(db *sql.DB) QuoteRepository {
 	return &PostgresQuoteRepository{db: db}
 }
 
-// CreateUpdate inserts a new quote update request. If an update for the same pair is already pending/running, it returns the existing one's ID.
-func (r *PostgresQuoteRepository) CreateUpdate(ctx context.Context, base, quote, id string) (string, error) {
-	query := `INSERT INTO quotes (id, base, quote, status, requested_at)
-              VALUES ($1::uuid, $2, $3, 'PENDING'::quotes_status, NOW())
-              ON CONFLICT (base, quote) WHERE status IN ('PENDING', 'RUNNING')
-              DO UPDATE SET base = quotes.base  -- no-op, changes nothing
-              RETURNING id::text`
+// CreateUpdateResult is the outcome of CreateUpdate.
+type CreateUpdateResult struct {
+	// ID is the new update's, or that of the in-flight update the request was
+	// deduplicated onto.
+	ID      string
+	Created bool
+}
+
+// createUpdateAttempts bounds how often CreateUpdate retries when the in-flight update
+// it conflicted with finished before it could be read.
+const createUpdateAttempts = 3
 
-	var returnedID string
-	err := r.db.QueryRowContext(ctx, query, id, base, quote).Scan(&returnedID)
-	if err != nil {
-		return "", fmt.Errorf("failed to create update: %w", err)
+// CreateUpdate inserts a new quote update request with the given id. If an update for
+// the same pair is already pending/running, it returns that one's ID with Created unset.
+//
+// The insert uses ON CONFLICT DO NOTHING on uniq_quotes_pair_pending, so a concurrent
+// insert for the same pair waits for the other transaction and then inserts nothing;
+// there is no window in which two in-flight rows can be created. Whether a row was
+// created is what the insert returned, never a comparison of IDs. Only on a conflict
+// does a second statement read the in-flight update, with a fresh snapshot that sees
+// the row the conflict was with. uniq_quotes_pair_pending is a partial index rather
+// than a constraint, hence the inferred conflict target. The PENDING event is only
+// written for a new row.
+func (r *PostgresQuoteRepository) CreateUpdate(ctx context.Context, pair Pair, id string, origin Origin) (CreateUpdateResult, error) {
+	insert := `WITH ins AS (
+                   INSERT INTO quotes (id, base, quote, status, requested_at, origin)
+                   VALUES ($1::uuid, $2, $3, 'PENDING'::quotes_status, NOW(), $4::quotes_origin)
+                   ON CONFLICT (base, quote) WHERE status IN ('PENDING', 'RUNNING')
+                   DO NOTHING
+                   RETURNING id, requested_at
+               ), ev AS (
+                   INSERT INTO quote_status_events (update_id, status, at)
+                   SELECT id, 'PENDING'::quotes_status, requested_at FROM ins
+               )
+               SELECT id::text FROM ins`
+	inFlight := `SELECT id::text
+                 FROM quotes
+                 WHERE base=$1 AND quote=$2 AND status IN ('PENDING', 'RUNNING')`
+
+	for range createUpdateAttempts {
+		var returnedID string
+		err := r.db.QueryRowContext(ctx, insert, id, pair.Base, pair.Quote, origin).Scan(&returnedID)
+		if err == nil {
+			return CreateUpdateResult{ID: returnedID, Created: true}, nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return CreateUpdateResult{}, fmt.Errorf("failed to create update: %w", err)
+		}
+
+		err = r.db.QueryRowContext(ctx, inFlight, pair.Base, pair.Quote).Scan(&returnedID)
+		if err == nil {
+			return CreateUpdateResult{ID: returnedID}, nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return CreateUpdateResult{}, fmt.Errorf("failed to read in-flight update: %w", err)
+		}
+		// The in-flight update finished in between; try inserting again.
 	}
-	return returnedID, nil
+	return CreateUpdateResult{}, fmt.Errorf("failed to create update: %s kept conflicting with updates that finished", pair)
 }
 
-// MarkRunning updates a quote record status to RUNNING.
-func (r *PostgresQuoteRepository) MarkRunning(ctx context.Context, id string) error {
+// MarkRunning updates a quote record status to RUNNING, recording the instance that
+// claimed it.
+func (r *PostgresQuoteRepository) MarkRunning(ctx context.Context, id string, version int64, instanceID string) error {
 	// Failed status can occur on Asynq retry
-	query := `UPDATE quotes
-				SET status=$1::quotes_status, updated_at=NOW()
-				WHERE id=$2::uuid AND status IN ($3::quotes_status, $4::quotes_status)`
-	result, err := r.db.ExecContext(ctx, query, StatusRunning, id, StatusPending, StatusFailed)
+	query := `WITH upd AS (
+				UPDATE quotes
+				SET status=$1::quotes_status, updated_at=NOW(), version=version+1, instance_id=NULLIF($6, '')
+				WHERE id=$2::uuid AND version=$3 AND status IN ($4::quotes_status, $5::quotes_status)
+				RETURNING id, status, updated_at, instance_id
+			)
+			INSERT INTO quote_status_events (update_id, status, at, instance_id)
+			SELECT id, status, updated_at, instance_id FROM upd`
+	result, err := r.db.ExecContext(ctx, query, StatusRunning, id, version, StatusPending, StatusFailed, instanceID)
+	if err != nil {
+		return err
+	}
+	return r.checkTransition(ctx, result, id, version, StatusRunning)
+}
+
+// MarkSuccess updates the quote record to SUCCESS with the fetched price and the time the provider observed it.
+func (r *PostgresQuoteRepository) MarkSuccess(ctx context.Context, id string, version int64, price string, rateTimestamp time.Time) error {
+	query := `WITH upd AS (
+				UPDATE quotes
+				SET status=$1::quotes_status,
+				    price=$2::numeric,
+				    rate_timestamp=$3,
+				    updated_at=NOW(),
+				    version=version+1
+				WHERE id=$4::uuid AND version=$5 AND status=$6::quotes_status
+				RETURNING id, status, updated_at, price
+			)
+			INSERT INTO quote_status_events (update_id, status, at, detail)
+			SELECT id, status, updated_at, price::text FROM upd`
+
+	result, err := r.db.ExecContext(ctx, query, StatusSuccess, price, rateTimestamp, id, version, StatusRunning)
 	if err != nil {
 		return err
 	}
+	return r.checkTransition(ctx, result, id, version, StatusSuccess)
+}
+
+// MarkFailed updates the quote record to FAILED with an error code and message and NULL price.
+func (r *PostgresQuoteRepository) MarkFailed(ctx context.Context, id string, version int64, code ErrorCode, errorMsg string) error {
+	query := `WITH upd AS (
+				UPDATE quotes
+				SET status=$1::quotes_status,
+				    price=NULL,
+				    rate_timestamp=NULL,
+				    error=$2,
+				    error_code=$3,
+				    updated_at=NOW(),
+				    version=version+1
+				WHERE id=$4::uuid AND version=$5 AND status IN ($6::quotes_status, $7::quotes_status)
+				RETURNING id, status, updated_at, error
+			)
+			INSERT INTO quote_status_events (update_id, status, at, detail)
+			SELECT id, status, updated_at, error FROM upd`
+
+	result, err := r.db.ExecContext(ctx, query, StatusFailed, errorMsg, code, id, version, StatusPending, StatusRunning)
+	if err != nil {
+		return err
+	}
+	return r.checkTransition(ctx, result, id, version, StatusFailed)
+}
+
+// MarkPending resets a FAILED or RUNNING quote record to PENDING, clearing its price and error.
+// A FAILED record whose pair has a newer in-flight update violates pairPendingIndex and
+// yields a *PairInFlightError.
+func (r *PostgresQuoteRepository) MarkPending(ctx context.Context, id string, version int64) error {
+	query := `WITH upd AS (
+				UPDATE quotes
+				SET status=$1::quotes_status,
+				    price=NULL,
+				    rate_timestamp=NULL,
+				    error=NULL,
+				    error_code=NULL,
+				    updated_at=NOW(),
+				    version=version+1
+				WHERE id=$2::uuid AND version=$3 AND status IN ($4::quotes_status, $5::quotes_status)
+				RETURNING id, status, updated_at
+			)
+			INSERT INTO quote_status_events (update_id, status, at)
+			SELECT id, status, updated_at FROM upd`
+
+	result, err := r.db.ExecContext(ctx, query, StatusPending, id, version, StatusFailed, StatusRunning)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation && pgErr.ConstraintName == pairPendingIndex {
+		return r.pairInFlight(ctx, id)
+	}
+	if err != nil {
+		return err
+	}
+	return r.checkTransition(ctx, result, id, version, StatusPending)
+}
+
+// pairPendingIndex is the partial unique index that allows one PENDING or RUNNING update per pair.
+const pairPendingIndex = "uniq_quotes_pair_pending"
+
+// pairInFlight returns the *PairInFlightError for a FAILED update id that could not
+// return to PENDING, naming the other update of its pair that holds pairPendingIndex.
+func (r *PostgresQuoteRepository) pairInFlight(ctx context.Context, id string) error {
+	const query = `SELECT o.id::text
+		FROM quotes q
+		JOIN quotes o ON o.base = q.base AND o.quote = q.quote AND o.id <> q.id
+		WHERE q.id = $1::uuid AND o.status IN ('PENDING', 'RUNNING')`
+
+	inFlight := &PairInFlightError{ID: id}
+	err := r.db.QueryRowContext(ctx, query, id).Scan(&inFlight.InFlightID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("failed to read in-flight update: %w", err)
+	}
+	return inFlight
+}
+
+// checkTransition explains a transition to status to that updated no row: the record is
+// missing, was changed since the caller read it at version, or is at version but in a
+// status the transition may not leave.
+func (r *PostgresQuoteRepository) checkTransition(ctx context.Context, result sql.Result, id string, version int64, to Status) error {
 	rows, err := result.RowsAffected()
 	if err != nil {
 		return err
 	}
-	if rows == 0 {
-		return fmt.Errorf("quote %s not found or not in PENDING/FAILED status", id)
+	if rows > 0 {
+		return nil
 	}
-	return nil
+
+	var statusStr string
+	var actual int64
+	err = r.db.QueryRowContext(ctx, "SELECT status, version FROM quotes WHERE id=$1::uuid", id).Scan(&statusStr, &actual)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: %s", ErrQuoteNotFound, id)
+	}
+	if err != nil {
+		return fmt.Errorf("check quote %s: %w", id, err)
+	}
+	if actual == version {
+		return &InvalidTransitionError{ID: id, From: Status(statusStr), To: to}
+	}
+	return &VersionConflictError{ID: id, Expected: version, Actual: actual, Status: Status(statusStr)}
 }
 
-// MarkSuccess updates the quote record to SUCCESS with the fetched price.
-func (r *PostgresQuoteRepository) MarkSuccess(ctx context.Context, id, price string) error {
+// SaveVerification stores the spread across providers for a SUCCESS update. It does
+// not change the status, so no StatusEvent is written.
+func (r *PostgresQuoteRepository) SaveVerification(ctx context.Context, id string, v Verification) error {
 	query := `UPDATE quotes
-				SET status=$1::quotes_status,
-				    price=$2::numeric,
-				    updated_at=NOW()
-				WHERE id=$3::uuid AND status=$4::quotes_status`
+              SET verify_min_price=$1::numeric,
+                  verify_max_price=$2::numeric,
+                  verify_spread=$3::numeric,
+                  verify_providers=$4,
+                  version=version+1
+              WHERE id=$5::uuid AND status=$6::quotes_status`
 
-	result, err := r.db.ExecContext(ctx, query, StatusSuccess, price, id, StatusRunning)
+	result, err := r.db.ExecContext(ctx, query, v.MinPrice, v.MaxPrice, v.Spread, v.Providers, id, StatusSuccess)
 	if err != nil {
 		return err
 	}
 	return checkRowsAffected(result, id)
 }
 
-// MarkFailed updates the quote record to FAILED with an error message and NULL price.
-func (r *PostgresQuoteRepository) MarkFailed(ctx context.Context, id, errorMsg string) error {
+// RecordFetch stores the provider and duration of a completed update's fetch. Like
+// SaveVerification it changes no status and writes no StatusEvent.
+func (r *PostgresQuoteRepository) RecordFetch(ctx context.Context, id string, f Fetch) error {
 	query := `UPDATE quotes
-				SET status=$1::quotes_status,
-				    price=NULL,
-				    error=$2,
-				    updated_at=NOW()
-				WHERE id=$3::uuid AND status IN ($4::quotes_status, $5::quotes_status)`
+              SET source=NULLIF($1, ''),
+                  fetch_duration_ms=$2,
+                  version=version+1
+              WHERE id=$3::uuid AND status IN ($4::quotes_status, $5::quotes_status)`
 
-	result, err := r.db.ExecContext(ctx, query, StatusFailed, errorMsg, id, StatusPending, StatusRunning)
+	result, err := r.db.ExecContext(ctx, query, f.Source, f.Duration.Milliseconds(), id, StatusSuccess, StatusFailed)
 	if err != nil {
 		return err
 	}
@@ -131,7 +522,8 @@ func checkRowsAffected(result sql.Result, id string) error {
 
 // GetByID retrieves a quote record by update_id.
 func (r *PostgresQuoteRepository) GetByID(ctx context.Context, id string) (*Quote, error) {
-	query := `SELECT id::text, base, quote, price, status, error, requested_at, updated_at
+	query := `SELECT id::text, base, quote, price, status, error, requested_at, updated_at, rate_timestamp,
+                     verify_min_price, verify_max_price, verify_spread, verify_providers, version, origin, error_code, instance_id
               FROM quotes
               WHERE id=$1::uuid`
 
@@ -139,27 +531,181 @@ func (r *PostgresQuoteRepository) GetByID(ctx context.Context, id string) (*Quot
 	return scanQuote(row)
 }
 
-// GetLatestSuccess finds the most recent successful quote for the given currency pair.
-func (r *PostgresQuoteRepository) GetLatestSuccess(ctx context.Context, base, quote string) (*Quote, error) {
-	query := `SELECT id::text, base, quote, price, status, error, requested_at, updated_at
+// GetLatestSuccess finds the successful quote of the given currency pair with the most
+// recent rate, ignoring archived rows. Updates can complete out of order, so the rate's
+// observation time decides; the completion time only breaks ties.
+func (r *PostgresQuoteRepository) GetLatestSuccess(ctx context.Context, pair Pair) (*Quote, error) {
+	query := `SELECT id::text, base, quote, price, status, error, requested_at, updated_at, rate_timestamp,
+                     verify_min_price, verify_max_price, verify_spread, verify_providers, version, origin, error_code, instance_id
+              FROM quotes
+              WHERE base=$1 AND quote=$2 AND status=$3::quotes_status AND archived_at IS NULL
+              ORDER BY COALESCE(rate_timestamp, updated_at) DESC, updated_at DESC
+              LIMIT 1`
+
+	row := r.db.QueryRowContext(ctx, query, pair.Base, pair.Quote, StatusSuccess)
+	return scanQuote(row)
+}
+
+// GetLatestAny finds the update of the pair that changed status last, whatever that
+// status is, ignoring archived rows. A PENDING update counts from its request time.
+func (r *PostgresQuoteRepository) GetLatestAny(ctx context.Context, pair Pair) (*Quote, error) {
+	query := `SELECT id::text, base, quote, price, status, error, requested_at, updated_at, rate_timestamp,
+                     verify_min_price, verify_max_price, verify_spread, verify_providers, version, origin, error_code, instance_id
+              FROM quotes
+              WHERE base=$1 AND quote=$2 AND archived_at IS NULL
+              ORDER BY COALESCE(updated_at, requested_at) DESC
+              LIMIT 1`
+
+	row := r.db.QueryRowContext(ctx, query, pair.Base, pair.Quote)
+	return scanQuote(row)
+}
+
+// GetPriceAtTime finds the most recent successful quote for the pair whose updated_at is
+// at or before at, ignoring archived rows.
+func (r *PostgresQuoteRepository) GetPriceAtTime(ctx context.Context, pair Pair, at time.Time) (*Quote, error) {
+	query := `SELECT id::text, base, quote, price, status, error, requested_at, updated_at, rate_timestamp,
+                     verify_min_price, verify_max_price, verify_spread, verify_providers, version, origin, error_code, instance_id
               FROM quotes
-              WHERE base=$1 AND quote=$2 AND status=$3::quotes_status
+              WHERE base=$1 AND quote=$2 AND status=$3::quotes_status AND updated_at <= $4 AND archived_at IS NULL
               ORDER BY updated_at DESC
               LIMIT 1`
 
-	row := r.db.QueryRowContext(ctx, query, base, quote, StatusSuccess)
+	row := r.db.QueryRowContext(ctx, query, pair.Base, pair.Quote, StatusSuccess, at)
 	return scanQuote(row)
 }
 
+// LatestSuccessTimes implements QuoteRepository.
+func (r *PostgresQuoteRepository) LatestSuccessTimes(ctx context.Context, pairs []Pair) ([]PairFreshness, error) {
+	var rows *sql.Rows
+	var err error
+	if len(pairs) == 0 {
+		query := `SELECT base, quote, MAX(updated_at) FILTER (WHERE status=$1::quotes_status)
+                  FROM quotes
+                  WHERE archived_at IS NULL
+                  GROUP BY base, quote
+                  ORDER BY base, quote`
+		rows, err = r.db.QueryContext(ctx, query, StatusSuccess)
+	} else {
+		bases := make([]string, len(pairs))
+		quotes := make([]string, len(pairs))
+		for i, p := range pairs {
+			bases[i], quotes[i] = p.Base, p.Quote
+		}
+		query := `SELECT p.base, p.quote,
+                         (SELECT MAX(q.updated_at) FROM quotes q
+                          WHERE q.base = p.base AND q.quote = p.quote
+                            AND q.status = $3::quotes_status AND q.archived_at IS NULL)
+                  FROM unnest($1::text[], $2::text[]) AS p(base, quote)`
+		rows, err = r.db.QueryContext(ctx, query, bases, quotes, StatusSuccess)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest success times: %w", err)
+	}
+	defer rows.Close()
+
+	var out []PairFreshness
+	for rows.Next() {
+		var f PairFreshness
+		var latest sql.NullTime
+		if err := rows.Scan(&f.Base, &f.Quote, &latest); err != nil {
+			return nil, err
+		}
+		if latest.Valid {
+			t := latest.Time.UTC()
+			f.LatestSuccess = &t
+		}
+		out = append(out, f)
+	}
+	return out, rows.Err()
+}
+
+// CountByStatus implements QuoteRepository.
+func (r *PostgresQuoteRepository) CountByStatus(ctx context.Context, status Status) (int, error) {
+	query := `SELECT COUNT(*) FROM quotes WHERE status=$1::quotes_status AND archived_at IS NULL`
+
+	var n int
+	if err := r.db.QueryRowContext(ctx, query, status).Scan(&n); err != nil {
+		return 0, fmt.Errorf("failed to count %s updates: %w", status, err)
+	}
+	return n, nil
+}
+
+// ListPendingOlderThan implements QuoteRepository.
+func (r *PostgresQuoteRepository) ListPendingOlderThan(ctx context.Context, before time.Time, limit int) ([]Quote, error) {
+	query := `SELECT id::text, base, quote, price, status, error, requested_at, updated_at, rate_timestamp,
+                     verify_min_price, verify_max_price, verify_spread, verify_providers, version, origin, error_code, instance_id
+              FROM quotes
+              WHERE status=$1::quotes_status AND requested_at < $2 AND archived_at IS NULL
+              ORDER BY requested_at
+              LIMIT $3`
+
+	rows, err := r.db.QueryContext(ctx, query, StatusPending, before, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pending updates: %w", err)
+	}
+	defer rows.Close()
+
+	var quotes []Quote
+	for rows.Next() {
+		q, err := scanQuote(rows)
+		if err != nil {
+			return nil, err
+		}
+		quotes = append(quotes, *q)
+	}
+	return quotes, rows.Err()
+}
+
+// GetStatusEvents returns the status events of an update, oldest first.
+func (r *PostgresQuoteRepository) GetStatusEvents(ctx context.Context, id string) ([]StatusEvent, error) {
+	query := `SELECT status, at, detail, instance_id
+              FROM quote_status_events
+              WHERE update_id=$1::uuid
+              ORDER BY at, id`
+
+	rows, err := r.db.QueryContext(ctx, query, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get status events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []StatusEvent
+	for rows.Next() {
+		var ev StatusEvent
+		var statusStr string
+		var detail, instanceID sql.NullString
+		if err := rows.Scan(&statusStr, &ev.At, &detail, &instanceID); err != nil {
+			return nil, err
+		}
+		ev.Status = Status(statusStr)
+		ev.At = ev.At.UTC()
+		if detail.Valid {
+			ev.Detail = &detail.String
+		}
+		ev.InstanceID = instanceID.String
+		events = append(events, ev)
+	}
+	return events, rows.Err()
+}
+
+// rowScanner is a *sql.Row or *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
 // scanQuote maps a single row into a Quote, returning (nil, nil) for sql.ErrNoRows.
-func scanQuote(row *sql.Row) (*Quote, error) {
+func scanQuote(row rowScanner) (*Quote, error) {
 	var q Quote
 	var price sql.NullString
 	var updatedAt sql.NullTime
-	var errMsg sql.NullString
-	var statusStr string
+	var rateTimestamp sql.NullTime
+	var errMsg, errCode, instanceID sql.NullString
+	var statusStr, originStr string
+	var verifyMin, verifyMax, verifySpread sql.NullString
+	var verifyProviders sql.NullInt64
 
-	err := row.Scan(&q.ID, &q.Base, &q.Quote, &price, &statusStr, &errMsg, &q.RequestedAt, &updatedAt)
+	err := row.Scan(&q.ID, &q.Base, &q.Quote, &price, &statusStr, &errMsg, &q.RequestedAt, &updatedAt, &rateTimestamp,
+		&verifyMin, &verifyMax, &verifySpread, &verifyProviders, &q.Version, &originStr, &errCode, &instanceID)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, nil
@@ -168,14 +714,31 @@ func scanQuote(row *sql.Row) (*Quote, error) {
 	}
 
 	q.Status = Status(statusStr)
+	q.Origin = Origin(originStr)
+	q.RequestedAt = q.RequestedAt.UTC()
 	if price.Valid {
 		q.Price = &price.String
 	}
 	if updatedAt.Valid {
-		q.UpdatedAt = &updatedAt.Time
+		t := updatedAt.Time.UTC()
+		q.UpdatedAt = &t
+	}
+	if rateTimestamp.Valid {
+		t := rateTimestamp.Time.UTC()
+		q.RateTimestamp = &t
 	}
 	if errMsg.Valid {
 		q.ErrorMsg = &errMsg.String
 	}
+	q.ErrorCode = ErrorCode(errCode.String)
+	q.InstanceID = instanceID.String
+	if verifyMin.Valid && verifyMax.Valid && verifySpread.Valid {
+		q.Verification = &Verification{
+			MinPrice:  verifyMin.String,
+			MaxPrice:  verifyMax.String,
+			Spread:    verifySpread.String,
+			Providers: int(verifyProviders.Int64),
+		}
+	}
 	return &q, nil
 }
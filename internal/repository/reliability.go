@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// ReliabilityRow sums up the updates of one pair completed through one provider.
+type ReliabilityRow struct {
+	// Source is the provider recorded by RecordFetch; empty for updates completed
+	// without one, e.g. streamed rates and rows from before sources were recorded.
+	Source  string
+	Pair    Pair
+	Success int64
+	Failed  int64
+	// AvgFetchMs is the mean fetch duration in milliseconds, nil if no update of the
+	// row recorded one.
+	AvgFetchMs *float64
+}
+
+// FailureRate returns Failed as a fraction of all the row's updates.
+func (r ReliabilityRow) FailureRate() float64 {
+	if total := r.Success + r.Failed; total > 0 {
+		return float64(r.Failed) / float64(total)
+	}
+	return 0
+}
+
+// ReliabilityReporter aggregates completed updates for the reliability report.
+type ReliabilityReporter interface {
+	// ReliabilityReport calls fn with one row per provider and pair, ordered by both,
+	// for the updates that reached SUCCESS or FAILED in [from, to). It stops after
+	// limit rows and reports whether more were left.
+	ReliabilityReport(ctx context.Context, from, to time.Time, limit int, fn func(ReliabilityRow) error) (truncated bool, err error)
+}
+
+// PostgresReliabilityReporter is a ReliabilityReporter using PostgreSQL.
+type PostgresReliabilityReporter struct {
+	db *sql.DB
+}
+
+// NewPostgresReliabilityReporter creates a PostgresReliabilityReporter.
+func NewPostgresReliabilityReporter(db *sql.DB) *PostgresReliabilityReporter {
+	return &PostgresReliabilityReporter{db: db}
+}
+
+// reliabilityQuery reads archived updates too, soft-deleted ones from quotes and moved
+// ones from quotes_archive, so retention does not thin out older ranges. Both halves
+// are served by the idx_*_completed indexes.
+const reliabilityQuery = `SELECT COALESCE(source, ''), base, quote,
+                                 count(*) FILTER (WHERE status = 'SUCCESS'::quotes_status),
+                                 count(*) FILTER (WHERE status = 'FAILED'::quotes_status),
+                                 avg(fetch_duration_ms)::float8
+                          FROM (
+                              SELECT source, base, quote, status, fetch_duration_ms
+                              FROM quotes
+                              WHERE status IN ('SUCCESS'::quotes_status, 'FAILED'::quotes_status)
+                                AND updated_at >= $1 AND updated_at < $2
+                              UNION ALL
+                              SELECT source, base, quote, status, fetch_duration_ms
+                              FROM quotes_archive
+                              WHERE status IN ('SUCCESS'::quotes_status, 'FAILED'::quotes_status)
+                                AND updated_at >= $1 AND updated_at < $2
+                          ) completed
+                          GROUP BY 1, 2, 3
+                          ORDER BY 1, 2, 3
+                          LIMIT $3`
+
+// ReliabilityReport implements ReliabilityReporter. Rows are passed to fn as they are
+// read, so a large report is never held in memory.
+func (r *PostgresReliabilityReporter) ReliabilityReport(ctx context.Context, from, to time.Time, limit int, fn func(ReliabilityRow) error) (bool, error) {
+	// One row beyond the limit tells whether the report was cut short.
+	rows, err := r.db.QueryContext(ctx, reliabilityQuery, from, to, limit+1)
+	if err != nil {
+		return false, fmt.Errorf("query reliability report: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	n := 0
+	for rows.Next() {
+		if n == limit {
+			return true, nil
+		}
+		var row ReliabilityRow
+		var avg sql.NullFloat64
+		if err := rows.Scan(&row.Source, &row.Pair.Base, &row.Pair.Quote, &row.Success, &row.Failed, &avg); err != nil {
+			return false, fmt.Errorf("scan reliability row: %w", err)
+		}
+		if avg.Valid {
+			row.AvgFetchMs = &avg.Float64
+		}
+		if err := fn(row); err != nil {
+			return false, err
+		}
+		n++
+	}
+	if err := rows.Err(); err != nil {
+		return false, fmt.Errorf("read reliability report: %w", err)
+	}
+	return false, nil
+}
+
+var _ ReliabilityReporter = (*PostgresReliabilityReporter)(nil)
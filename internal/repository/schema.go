@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// ErrSchemaNotReady indicates that the database lacks tables or columns the service
+// uses, e.g. because migrations managed outside the service were not applied yet.
+var ErrSchemaNotReady = errors.New("database schema not ready")
+
+// requiredSchema lists every table and column the repositories use. A schema managed
+// without RunMigrations must have all of them; a migration adding a column the code
+// uses must add it here too.
+var requiredSchema = []struct {
+	table   string
+	columns []string
+}{
+	{"quotes", []string{
+		"id", "base", "quote", "price", "status", "error", "requested_at", "updated_at", "rate_timestamp",
+		"verify_min_price", "verify_max_price", "verify_spread", "verify_providers", "archived_at", "version",
+		"origin", "error_code", "source", "fetch_duration_ms", "instance_id",
+	}},
+	{"quote_status_events", []string{"id", "update_id", "status", "at", "detail", "instance_id"}},
+	{"quotes_archive", []string{
+		"id", "base", "quote", "price", "status", "error", "requested_at", "updated_at", "rate_timestamp",
+		"verify_min_price", "verify_max_price", "verify_spread", "verify_providers", "archived_at", "version",
+		"origin", "error_code", "source", "fetch_duration_ms", "instance_id",
+	}},
+	{"quote_status_events_archive", []string{"id", "update_id", "status", "at", "detail", "instance_id"}},
+	{"webhooks", []string{"id", "base", "quote", "url", "secret_hash", "created_at"}},
+}
+
+// SchemaError lists what VerifySchema found missing. It matches ErrSchemaNotReady.
+type SchemaError struct {
+	MissingTables  []string
+	MissingColumns []string // As table.column, for tables that exist.
+}
+
+func (e *SchemaError) Error() string {
+	var parts []string
+	if len(e.MissingTables) > 0 {
+		parts = append(parts, "missing tables "+strings.Join(e.MissingTables, ", "))
+	}
+	if len(e.MissingColumns) > 0 {
+		parts = append(parts, "missing columns "+strings.Join(e.MissingColumns, ", "))
+	}
+	return ErrSchemaNotReady.Error() + ": " + strings.Join(parts, "; ")
+}
+
+// Is reports whether target is ErrSchemaNotReady.
+func (e *SchemaError) Is(target error) bool {
+	return target == ErrSchemaNotReady
+}
+
+// VerifySchema checks that the current schema has every table and column the service
+// uses, without looking at schema_migrations, so it also applies to a schema managed
+// by other tools. It returns a *SchemaError listing everything missing.
+func VerifySchema(ctx context.Context, db *sql.DB) error {
+	rows, err := db.QueryContext(ctx, `SELECT table_name, column_name FROM information_schema.columns
+                                       WHERE table_schema = current_schema()`)
+	if err != nil {
+		return fmt.Errorf("read schema: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	existing := make(map[string]map[string]bool)
+	for rows.Next() {
+		var table, column string
+		if err := rows.Scan(&table, &column); err != nil {
+			return fmt.Errorf("read schema: %w", err)
+		}
+		if existing[table] == nil {
+			existing[table] = make(map[string]bool)
+		}
+		existing[table][column] = true
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("read schema: %w", err)
+	}
+
+	var schemaErr SchemaError
+	for _, t := range requiredSchema {
+		columns, ok := existing[t.table]
+		if !ok {
+			schemaErr.MissingTables = append(schemaErr.MissingTables, t.table)
+			continue
+		}
+		for _, c := range t.columns {
+			if !columns[c] {
+				schemaErr.MissingColumns = append(schemaErr.MissingColumns, t.table+"."+c)
+			}
+		}
+	}
+	if len(schemaErr.MissingTables) > 0 || len(schemaErr.MissingColumns) > 0 {
+		return &schemaErr
+	}
+	return nil
+}
+
+// Postgres error codes of statements referring to schema objects that do not exist.
+var schemaErrorCodes = map[string]bool{
+	"42P01": true, // undefined_table
+	"42703": true, // undefined_column
+	"42704": true, // undefined_object, e.g. the quotes_status type
+	"3F000": true, // invalid_schema_name
+}
+
+// IsSchemaError reports whether err comes from a schema that is not ready: a
+// *SchemaError, or a statement that failed because a table, column or type it uses
+// does not exist.
+func IsSchemaError(err error) bool {
+	if errors.Is(err, ErrSchemaNotReady) {
+		return true
+	}
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && schemaErrorCodes[pgErr.Code]
+}
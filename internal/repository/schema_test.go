@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestSchemaError(t *testing.T) {
+	err := error(&SchemaError{MissingTables: []string{"webhooks"}, MissingColumns: []string{"quotes.source", "quotes.fetch_duration_ms"}})
+	want := "database schema not ready: missing tables webhooks; missing columns quotes.source, quotes.fetch_duration_ms"
+	if err.Error() != want {
+		t.Errorf("expected %q, got %q", want, err.Error())
+	}
+	if !errors.Is(err, ErrSchemaNotReady) {
+		t.Error("expected SchemaError to match ErrSchemaNotReady")
+	}
+}
+
+func TestIsSchemaError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{&pgconn.PgError{Code: "42P01", Message: `relation "quotes" does not exist`}, true},
+		{fmt.Errorf("query: %w", &pgconn.PgError{Code: "42703"}), true},
+		{&pgconn.PgError{Code: "42704"}, true},
+		{&SchemaError{MissingTables: []string{"quotes"}}, true},
+		{&pgconn.PgError{Code: uniqueViolation}, false},
+		{errors.New("connection refused"), false},
+		{nil, false},
+	}
+	for _, tt := range tests {
+		if got := IsSchemaError(tt.err); got != tt.want {
+			t.Errorf("IsSchemaError(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// ErrWebhookExists indicates a webhook for the same pair and URL is already registered.
+var ErrWebhookExists = errors.New("webhook already registered")
+
+// ErrWebhookNotFound indicates no webhook has the given ID.
+var ErrWebhookNotFound = errors.New("webhook not found")
+
+const uniqueViolation = "23505"
+
+// Webhook represents a webhook subscription record in the DB.
+type Webhook struct {
+	ID         string
+	Base       string
+	Quote      string
+	URL        string
+	SecretHash string
+	CreatedAt  time.Time
+}
+
+// WebhookRepository defines DB operations for webhook subscriptions.
+type WebhookRepository interface {
+	CreateWebhook(ctx context.Context, w *Webhook) error
+	DeleteWebhook(ctx context.Context, id string) error
+}
+
+var _ WebhookRepository = (*PostgresWebhookRepository)(nil)
+
+// PostgresWebhookRepository is an implementation of WebhookRepository using PostgreSQL.
+type PostgresWebhookRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresWebhookRepository creates a new PostgresWebhookRepository.
+func NewPostgresWebhookRepository(db *sql.DB) WebhookRepository {
+	return &PostgresWebhookRepository{db: db}
+}
+
+// CreateWebhook inserts w and fills in CreatedAt. It returns ErrWebhookExists if the
+// pair already has a webhook with the same URL.
+func (r *PostgresWebhookRepository) CreateWebhook(ctx context.Context, w *Webhook) error {
+	query := `INSERT INTO webhooks (id, base, quote, url, secret_hash, created_at)
+              VALUES ($1::uuid, $2, $3, $4, $5, NOW())
+              RETURNING created_at`
+
+	err := r.db.QueryRowContext(ctx, query, w.ID, w.Base, w.Quote, w.URL, w.SecretHash).Scan(&w.CreatedAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			return ErrWebhookExists
+		}
+		return fmt.Errorf("failed to create webhook: %w", err)
+	}
+	return nil
+}
+
+// DeleteWebhook removes the webhook with the given ID, returning ErrWebhookNotFound if there is none.
+func (r *PostgresWebhookRepository) DeleteWebhook(ctx context.Context, id string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM webhooks WHERE id=$1::uuid`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrWebhookNotFound
+	}
+	return nil
+}
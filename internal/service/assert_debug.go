@@ -0,0 +1,16 @@
+//go:build debug
+
+package service
+
+import "fmt"
+
+// assertCanonical panics if a currency code or "BASE/QUOTE" pair is not in the form
+// NormalizeCode and NormalizePair produce, catching a caller that passes input to the
+// service without normalizing it first. Release builds only uppercase such input.
+func assertCanonical(values ...string) {
+	for _, v := range values {
+		if v != NormalizePair(v) {
+			panic(fmt.Sprintf("service: non-canonical currency input %q, normalize it at the API boundary", v))
+		}
+	}
+}
@@ -0,0 +1,26 @@
+//go:build debug
+
+package service
+
+import (
+	"context"
+	"testing"
+)
+
+func TestAssertCanonical(t *testing.T) {
+	svc := NewQuoteService(QuoteServiceDeps{CacheConfig: testCacheCfg})
+	calls := map[string]func(){
+		"RequestQuoteUpdate": func() { _, _ = svc.RequestQuoteUpdate(context.Background(), " eur/mxn", UpdateOptions{}) },
+		"GetLatestQuote":     func() { _, _ = svc.GetLatestQuote(context.Background(), Pair{Base: "eur", Quote: "MXN"}) },
+	}
+	for name, call := range calls {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("Expected non-canonical input to panic in debug builds")
+				}
+			}()
+			call()
+		})
+	}
+}
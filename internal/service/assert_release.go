@@ -0,0 +1,7 @@
+//go:build !debug
+
+package service
+
+// assertCanonical checks that input is normalized in builds with the debug tag; see
+// assert_debug.go.
+func assertCanonical(...string) {}
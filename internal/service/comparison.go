@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"time"
+
+	"quoteservice/internal/logging/fields"
+)
+
+// ErrInvalidComparisonRange indicates a comparison whose first point in time is not
+// before the second, or that reaches into the future.
+var ErrInvalidComparisonRange = errors.New("at must be before vs and neither may be in the future")
+
+// ComparisonNotFoundError is returned by CompareQuotes when no successful quote was
+// recorded at or before one or both points in time. It matches ErrNotFound.
+type ComparisonNotFoundError struct {
+	Missing []string // "at", "vs" or both, naming the points without a quote.
+}
+
+func (e *ComparisonNotFoundError) Error() string {
+	return "no quote at or before " + strings.Join(e.Missing, " and ")
+}
+
+// Is reports whether target is ErrNotFound.
+func (e *ComparisonNotFoundError) Is(target error) bool {
+	return target == ErrNotFound
+}
+
+// QuoteComparison is a pair's rate at two points in time. At and Vs are the successful
+// quotes that were current at each point; their UpdatedAt is the time actually used.
+type QuoteComparison struct {
+	Base      string
+	Quote     string
+	At        *QuoteResult
+	Vs        *QuoteResult
+	Change    string // Vs price - At price, exact.
+	ChangePct string // Change / At price in percent, rounded to 4 decimals.
+}
+
+// CompareQuotes returns the quotes that were current for the pair at the times at and
+// vs, looked up like GetHistoricalRate, and the change between them. at must be before
+// vs and neither may be after now.
+func (s *QuoteService) CompareQuotes(ctx context.Context, pair Pair, at, vs time.Time) (*QuoteComparison, error) {
+	assertCanonical(pair.Base, pair.Quote)
+	pair, err := NewPair(pair.Base, pair.Quote)
+	if err != nil {
+		return nil, err
+	}
+	if vErr := s.validatePair(pair); vErr != nil {
+		return nil, vErr
+	}
+	if !at.Before(vs) || vs.After(s.clock.Now()) {
+		return nil, ErrInvalidComparisonRange
+	}
+	if err := checkPairAccess(ctx, pair); err != nil {
+		return nil, err
+	}
+
+	cmp := &QuoteComparison{Base: pair.Base, Quote: pair.Quote}
+	var missing []string
+	for _, side := range []struct {
+		name string
+		t    time.Time
+		res  **QuoteResult
+	}{{"at", at, &cmp.At}, {"vs", vs, &cmp.Vs}} {
+		q, err := s.repo.GetPriceAtTime(ctx, pair, side.t)
+		if err != nil {
+			s.log.Errorw("DB error fetching quote to compare", fields.Pair(pair.Base, pair.Quote), side.name, side.t, "error", err)
+			return nil, storageError(err)
+		}
+		if q == nil || q.Price == nil {
+			missing = append(missing, side.name)
+			continue
+		}
+		*side.res = quoteResultFromRepo(q)
+	}
+	if len(missing) > 0 {
+		return nil, &ComparisonNotFoundError{Missing: missing}
+	}
+
+	cmp.Change, cmp.ChangePct, err = priceChange(*cmp.At.Price, *cmp.Vs.Price)
+	if err != nil {
+		s.log.Errorw("Stored price is not a positive decimal", fields.Pair(pair.Base, pair.Quote), "error", err)
+		return nil, ErrInternal
+	}
+	return cmp, nil
+}
+
+// priceChange returns to - from with as many decimals as the more precise price, and
+// the change relative to from in percent with 4 decimals.
+func priceChange(from, to string) (change, changePct string, err error) {
+	fromRat, ok := new(big.Rat).SetString(from)
+	if !ok || fromRat.Sign() <= 0 {
+		return "", "", errors.New("invalid price " + from)
+	}
+	toRat, ok := new(big.Rat).SetString(to)
+	if !ok {
+		return "", "", errors.New("invalid price " + to)
+	}
+
+	diff := new(big.Rat).Sub(toRat, fromRat)
+	pct := new(big.Rat).Quo(diff, fromRat)
+	pct.Mul(pct, big.NewRat(100, 1))
+	return diff.FloatString(max(decimals(from), decimals(to))), pct.FloatString(4), nil
+}
+
+// decimals returns the number of digits after the decimal point of a decimal string.
+func decimals(price string) int {
+	if i := strings.IndexByte(price, '.'); i >= 0 {
+		return len(price) - i - 1
+	}
+	return 0
+}
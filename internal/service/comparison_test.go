@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"quoteservice/internal/repository"
+	"quoteservice/internal/testkit/fakeclock"
+)
+
+// historyRepo returns a mockQuoteRepo whose GetPriceAtTime picks the newest of the
+// given SUCCESS records updated at or before the queried time, like the Postgres query.
+func historyRepo(records map[time.Time]string) *mockQuoteRepo {
+	return &mockQuoteRepo{
+		getPriceAtTimeFunc: func(_ context.Context, pair Pair, at time.Time) (*repository.Quote, error) {
+			var best *repository.Quote
+			for updatedAt, price := range records {
+				if updatedAt.After(at) || (best != nil && !updatedAt.After(*best.UpdatedAt)) {
+					continue
+				}
+				best = &repository.Quote{Base: pair.Base, Quote: pair.Quote, Status: repository.StatusSuccess, Price: &price, UpdatedAt: &updatedAt}
+			}
+			return best, nil
+		},
+	}
+}
+
+func TestCompareQuotes(t *testing.T) {
+	jan1 := time.Date(2025, 1, 1, 21, 4, 12, 0, time.UTC)
+	jan2 := time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)
+	jun2 := time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC)
+	now := time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)
+	newService := func(repo *mockQuoteRepo) *QuoteService {
+		return NewQuoteService(QuoteServiceDeps{Repo: repo, Validator: NewValidator(), CacheConfig: testCacheCfg, Clock: fakeclock.New(now)})
+	}
+
+	tests := []struct {
+		name                   string
+		records                map[time.Time]string
+		at, vs                 time.Time
+		wantAtAsOf, wantVsAsOf time.Time
+		wantChange, wantPct    string
+	}{
+		{
+			name:    "exact match",
+			records: map[time.Time]string{jan2: "20.00", jun2: "21.5"},
+			at:      jan2, vs: jun2,
+			wantAtAsOf: jan2, wantVsAsOf: jun2,
+			wantChange: "1.50", wantPct: "7.5000",
+		},
+		{
+			name:    "nearest before",
+			records: map[time.Time]string{jan1.Add(-time.Hour): "20.1", jan1: "20.7481", jan2.Add(time.Hour): "20.9", jun2.Add(-24 * time.Hour): "19.9179"},
+			at:      jan2, vs: jun2,
+			wantAtAsOf: jan1, wantVsAsOf: jun2.Add(-24 * time.Hour),
+			wantChange: "-0.8302", wantPct: "-4.0013",
+		},
+		{
+			name:    "same quote on both sides",
+			records: map[time.Time]string{jan1: "20.7481"},
+			at:      jan2, vs: jun2,
+			wantAtAsOf: jan1, wantVsAsOf: jan1,
+			wantChange: "0.0000", wantPct: "0.0000",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cmp, err := newService(historyRepo(tc.records)).CompareQuotes(context.Background(), Pair{Base: "EUR", Quote: "MXN"}, tc.at, tc.vs)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if cmp.Base != "EUR" || cmp.Quote != "MXN" {
+				t.Errorf("Expected pair EUR/MXN, got %s/%s", cmp.Base, cmp.Quote)
+			}
+			if got := *cmp.At.UpdatedAt; got != FormatTimestamp(tc.wantAtAsOf) {
+				t.Errorf("Expected at as of %s, got %s", FormatTimestamp(tc.wantAtAsOf), got)
+			}
+			if got := *cmp.Vs.UpdatedAt; got != FormatTimestamp(tc.wantVsAsOf) {
+				t.Errorf("Expected vs as of %s, got %s", FormatTimestamp(tc.wantVsAsOf), got)
+			}
+			if cmp.Change != tc.wantChange || cmp.ChangePct != tc.wantPct {
+				t.Errorf("Expected change %s (%s%%), got %s (%s%%)", tc.wantChange, tc.wantPct, cmp.Change, cmp.ChangePct)
+			}
+		})
+	}
+
+	t.Run("missing data names the side", func(t *testing.T) {
+		for _, tc := range []struct {
+			records     map[time.Time]string
+			wantMissing []string
+		}{
+			{map[time.Time]string{jan2.Add(time.Hour): "20.9"}, []string{"at"}},
+			{map[time.Time]string{}, []string{"at", "vs"}},
+		} {
+			_, err := newService(historyRepo(tc.records)).CompareQuotes(context.Background(), Pair{Base: "EUR", Quote: "MXN"}, jan2, jun2)
+			var notFound *ComparisonNotFoundError
+			if !errors.As(err, &notFound) || !slices.Equal(notFound.Missing, tc.wantMissing) {
+				t.Errorf("Expected missing %v, got %v", tc.wantMissing, err)
+			}
+			if !errors.Is(err, ErrNotFound) {
+				t.Errorf("Expected the error to match ErrNotFound, got %v", err)
+			}
+		}
+	})
+
+	t.Run("invalid range", func(t *testing.T) {
+		svc := newService(historyRepo(nil))
+		for name, r := range map[string][2]time.Time{
+			"at after vs":  {jun2, jan2},
+			"at equals vs": {jan2, jan2},
+			"vs in future": {jan2, now.Add(time.Second)},
+		} {
+			_, err := svc.CompareQuotes(context.Background(), Pair{Base: "EUR", Quote: "MXN"}, r[0], r[1])
+			if !errors.Is(err, ErrInvalidComparisonRange) || !IsValidationError(err) {
+				t.Errorf("%s: expected ErrInvalidComparisonRange, got %v", name, err)
+			}
+		}
+	})
+
+	t.Run("repository error", func(t *testing.T) {
+		repo := &mockQuoteRepo{
+			getPriceAtTimeFunc: func(context.Context, Pair, time.Time) (*repository.Quote, error) {
+				return nil, errors.New("connection refused")
+			},
+		}
+		if _, err := newService(repo).CompareQuotes(context.Background(), Pair{Base: "EUR", Quote: "MXN"}, jan2, jun2); !errors.Is(err, ErrInternal) {
+			t.Errorf("Expected ErrInternal, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+//go:embed currencies.json
+var currenciesJSON []byte
+
+// Currency is display metadata for a supported currency.
+type Currency struct {
+	Code          string `json:"code"`
+	Name          string `json:"name"`
+	Symbol        string `json:"symbol"`
+	DecimalDigits int    `json:"decimal_digits"` // Conventional minor units, e.g. 2 for USD, 0 for JPY.
+}
+
+var currencyMetadata = mustLoadCurrencies(currenciesJSON)
+
+func mustLoadCurrencies(data []byte) map[string]Currency {
+	var list []Currency
+	if err := json.Unmarshal(data, &list); err != nil {
+		panic(fmt.Sprintf("invalid embedded currencies.json: %v", err))
+	}
+	m := make(map[string]Currency, len(list))
+	for _, c := range list {
+		m[c.Code] = c
+	}
+	return m
+}
+
+// Currencies returns the metadata of every supported currency, sorted by code.
+func Currencies() []Currency {
+	list := make([]Currency, 0, len(supportedCurrencies))
+	for code := range supportedCurrencies {
+		if c, ok := currencyMetadata[code]; ok {
+			list = append(list, c)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Code < list[j].Code })
+	return list
+}
+
+// LookupCurrency returns the metadata for code (case-insensitive). It returns
+// ErrInvalidPairFormat for a malformed code and ErrNotFound for an unsupported one.
+func LookupCurrency(code string) (Currency, error) {
+	if !IsValidCurrencyCode(code) {
+		return Currency{}, ErrInvalidPairFormat
+	}
+	code = strings.ToUpper(code)
+	if _, ok := supportedCurrencies[code]; !ok {
+		return Currency{}, ErrNotFound
+	}
+	c, ok := currencyMetadata[code]
+	if !ok {
+		return Currency{}, ErrNotFound
+	}
+	return c, nil
+}
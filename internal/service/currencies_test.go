@@ -0,0 +1,56 @@
+package service
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCurrencyMetadata_Complete(t *testing.T) {
+	for code := range supportedCurrencies {
+		c, ok := currencyMetadata[code]
+		if !ok {
+			t.Errorf("supported currency %s has no metadata in currencies.json", code)
+			continue
+		}
+		if c.Name == "" || c.Symbol == "" {
+			t.Errorf("%s: name and symbol are required, got %+v", code, c)
+		}
+		if c.DecimalDigits < 0 || c.DecimalDigits > 4 {
+			t.Errorf("%s: decimal_digits %d out of range", code, c.DecimalDigits)
+		}
+	}
+	for code := range currencyMetadata {
+		if _, ok := supportedCurrencies[code]; !ok {
+			t.Errorf("currencies.json has metadata for unsupported currency %s", code)
+		}
+	}
+}
+
+func TestCurrencies_Sorted(t *testing.T) {
+	list := Currencies()
+	if len(list) != len(supportedCurrencies) {
+		t.Fatalf("expected %d currencies, got %d", len(supportedCurrencies), len(list))
+	}
+	for i := 1; i < len(list); i++ {
+		if list[i-1].Code >= list[i].Code {
+			t.Fatalf("currencies not sorted: %s before %s", list[i-1].Code, list[i].Code)
+		}
+	}
+}
+
+func TestLookupCurrency(t *testing.T) {
+	c, err := LookupCurrency("jpy")
+	if err != nil {
+		t.Fatalf("LookupCurrency: %v", err)
+	}
+	if c.Code != "JPY" || c.DecimalDigits != 0 {
+		t.Errorf("unexpected JPY metadata: %+v", c)
+	}
+
+	if _, err := LookupCurrency("XYZ"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for XYZ, got %v", err)
+	}
+	if _, err := LookupCurrency("US"); !errors.Is(err, ErrInvalidPairFormat) {
+		t.Errorf("expected ErrInvalidPairFormat for US, got %v", err)
+	}
+}
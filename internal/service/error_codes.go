@@ -0,0 +1,104 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"slices"
+
+	"quoteservice/internal/provider"
+	"quoteservice/internal/repository"
+)
+
+// errorCodeRules map the error a failed update was failed with to its ErrorCode. The
+// first matching rule wins and a rule matches anywhere in the error tree, so a specific
+// cause such as one provider's timeout beats the ErrAllProvidersUnavailable around it.
+var errorCodeRules = []struct {
+	code  repository.ErrorCode
+	match func(error) bool
+}{
+	{repository.ErrorCodeInvalidRequest, IsValidationError},
+	{repository.ErrorCodeTimeout, func(err error) bool {
+		return inTree(err, func(e error) bool {
+			ne, ok := e.(net.Error) // Also matches context.DeadlineExceeded.
+			return ok && ne.Timeout()
+		}) || hasProviderStatus(err, http.StatusRequestTimeout, http.StatusGatewayTimeout)
+	}},
+	{repository.ErrorCodeRateLimited, func(err error) bool {
+		return hasProviderStatus(err, http.StatusTooManyRequests)
+	}},
+	{repository.ErrorCodeAuth, func(err error) bool {
+		return errors.Is(err, provider.ErrUnauthorized) ||
+			hasProviderStatus(err, http.StatusUnauthorized, http.StatusForbidden)
+	}},
+	// Before unsupported_pair and bad_response: the rate is missing because the
+	// provider's plan answered for another base, which changing the pair won't fix.
+	{repository.ErrorCodeProviderConfig, func(err error) bool {
+		return errors.Is(err, provider.ErrSourceMismatch)
+	}},
+	{repository.ErrorCodeUnsupportedPair, func(err error) bool {
+		return errors.Is(err, provider.ErrUnsupportedPair) ||
+			hasProviderStatus(err, http.StatusBadRequest, http.StatusNotFound, http.StatusUnprocessableEntity)
+	}},
+	{repository.ErrorCodeBadResponse, func(err error) bool {
+		var syntaxErr *json.SyntaxError
+		var typeErr *json.UnmarshalTypeError
+		// A provider that answered 200 without a usable rate sent a bad response.
+		return errors.As(err, &syntaxErr) || errors.As(err, &typeErr) || hasProviderStatus(err, http.StatusOK)
+	}},
+	{repository.ErrorCodeProviderUnavailable, func(err error) bool {
+		return errors.Is(err, ErrServiceUnavailable) || errors.Is(err, ErrProviderUnavailable) ||
+			errors.Is(err, provider.ErrAllProvidersUnavailable) || errors.Is(err, provider.ErrCircuitOpen) ||
+			inTree(err, func(e error) bool {
+				pErr, ok := e.(*provider.ProviderError)
+				return ok && (pErr.Code >= http.StatusInternalServerError || pErr.Code == 0 && pErr.Retryable)
+			})
+	}},
+}
+
+// errorCodeOf classifies err; errors no rule matches are ErrorCodeInternal.
+func errorCodeOf(err error) repository.ErrorCode {
+	if err == nil {
+		return ""
+	}
+	for _, rule := range errorCodeRules {
+		if rule.match(err) {
+			return rule.code
+		}
+	}
+	return repository.ErrorCodeInternal
+}
+
+// IsValidErrorCode reports whether code is a known error code.
+func IsValidErrorCode(code string) bool {
+	return slices.Contains(repository.ErrorCodes, repository.ErrorCode(code))
+}
+
+// hasProviderStatus reports whether a ProviderError anywhere in err's tree has one of
+// the HTTP statuses.
+func hasProviderStatus(err error, statuses ...int) bool {
+	return inTree(err, func(e error) bool {
+		pErr, ok := e.(*provider.ProviderError)
+		return ok && slices.Contains(statuses, pErr.Code)
+	})
+}
+
+// inTree reports whether match holds for err or any error it wraps. Unlike errors.As,
+// it looks past the first error of a type, e.g. at every provider's error joined into
+// ErrAllProvidersUnavailable.
+func inTree(err error, match func(error) bool) bool {
+	if err == nil {
+		return false
+	}
+	if match(err) {
+		return true
+	}
+	switch u := err.(type) {
+	case interface{ Unwrap() error }:
+		return inTree(u.Unwrap(), match)
+	case interface{ Unwrap() []error }:
+		return slices.ContainsFunc(u.Unwrap(), func(e error) bool { return inTree(e, match) })
+	}
+	return false
+}
@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"testing"
+	"time"
+
+	"quoteservice/internal/provider"
+	"quoteservice/internal/repository"
+)
+
+func TestErrorCodeOf(t *testing.T) {
+	status := func(code int) error {
+		return &provider.ProviderError{Code: code, Message: fmt.Sprintf("API returned status %d", code), Retryable: code >= 500}
+	}
+	transport := func(err error) error {
+		return &provider.ProviderError{Message: "API request failed", Retryable: true, Err: err}
+	}
+	allFailed := func(errs ...error) error {
+		return fmt.Errorf("%w: %w: %w", ErrProviderUnavailable, provider.ErrAllProvidersUnavailable, errors.Join(errs...))
+	}
+	malformed := json.Unmarshal([]byte("{"), new(any))
+	timeout := transport(&url.Error{Op: "Get", URL: "https://api.frankfurter.app", Err: context.DeadlineExceeded})
+
+	tests := []struct {
+		name string
+		err  error
+		want repository.ErrorCode
+	}{
+		{"nil", nil, ""},
+		{"invalid pair", fmt.Errorf("%w: XXX", ErrUnsupportedCurrency), repository.ErrorCodeInvalidRequest},
+		{"unknown provider", &UnknownProviderError{Name: "ecb"}, repository.ErrorCodeInvalidRequest},
+		{"deadline", context.DeadlineExceeded, repository.ErrorCodeTimeout},
+		{"request timeout", timeout, repository.ErrorCodeTimeout},
+		{"408", status(408), repository.ErrorCodeTimeout},
+		{"504", status(504), repository.ErrorCodeTimeout},
+		{"429", status(429), repository.ErrorCodeRateLimited},
+		{"401", status(401), repository.ErrorCodeAuth},
+		{"403", status(403), repository.ErrorCodeAuth},
+		{"rejected key in body", &provider.ProviderError{Code: 200, Message: "success=false", Err: provider.ErrUnauthorized}, repository.ErrorCodeAuth},
+		{"source fallback", &provider.ProviderError{Code: 200, Message: "source USD instead of EUR", Err: provider.ErrSourceMismatch}, repository.ErrorCodeProviderConfig},
+		{"404", status(404), repository.ErrorCodeUnsupportedPair},
+		{"no rate for the pair", &provider.ProviderError{Code: 200, Message: "no rate for XXX", Err: provider.ErrUnsupportedPair}, repository.ErrorCodeUnsupportedPair},
+		{"malformed body", transport(malformed), repository.ErrorCodeBadResponse},
+		{"invalid rate", &provider.ProviderError{Code: 200, Message: `API returned an invalid rate "x"`}, repository.ErrorCodeBadResponse},
+		{"500", status(500), repository.ErrorCodeProviderUnavailable},
+		{"connection refused", transport(errors.New("connect: connection refused")), repository.ErrorCodeProviderUnavailable},
+		{"circuit open", provider.ErrCircuitOpen, repository.ErrorCodeProviderUnavailable},
+		{"no provider available", ErrServiceUnavailable, repository.ErrorCodeProviderUnavailable},
+		{"all providers down", allFailed(status(502), status(503)), repository.ErrorCodeProviderUnavailable},
+		{"a specific cause among all providers wins", allFailed(status(503), status(429)), repository.ErrorCodeRateLimited},
+		{"unclassified", errors.New("boom"), repository.ErrorCodeInternal},
+		{"cancelled", context.Canceled, repository.ErrorCodeInternal},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := errorCodeOf(tc.err); got != tc.want {
+				t.Errorf("Expected %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestIsValidErrorCode(t *testing.T) {
+	for _, code := range repository.ErrorCodes {
+		if !IsValidErrorCode(string(code)) {
+			t.Errorf("Expected %q to be valid", code)
+		}
+	}
+	if IsValidErrorCode("") || IsValidErrorCode("TIMEOUT") {
+		t.Error("Expected empty and upper-case codes to be invalid")
+	}
+}
+
+func TestProcessUpdate_StoresErrorCode(t *testing.T) {
+	var gotCode repository.ErrorCode
+	var gotMsg string
+	repo := &mockQuoteRepo{
+		getByIDFunc: func(ctx context.Context, id string) (*repository.Quote, error) {
+			return &repository.Quote{ID: id, Base: "EUR", Quote: "MXN", Status: repository.StatusPending, Version: 1}, nil
+		},
+		markRunningFunc: func(context.Context, string, int64) error { return nil },
+		markFailedFunc: func(_ context.Context, _ string, _ int64, code repository.ErrorCode, errorMsg string) error {
+			gotCode, gotMsg = code, errorMsg
+			return nil
+		},
+	}
+	svc := NewQuoteService(QuoteServiceDeps{
+		Repo: repo,
+		Provider: &mockRatesProvider{getRateFunc: func(string, string) (string, time.Time, error) {
+			return "", time.Time{}, &provider.ProviderError{Code: 200, Message: "no rate for MXN in response", Err: provider.ErrUnsupportedPair}
+		}},
+		CacheConfig: testCacheCfg,
+	})
+
+	err := svc.ProcessUpdate(context.Background(), UpdateQuotePayload{UpdateID: "u1", Pair: Pair{Base: "EUR", Quote: "MXN"}})
+	if !errors.Is(err, provider.ErrUnsupportedPair) {
+		t.Fatalf("Expected the provider error, got %v", err)
+	}
+	if gotCode != repository.ErrorCodeUnsupportedPair || gotMsg != "no rate for MXN in response: pair not supported by provider" {
+		t.Errorf("Expected unsupported_pair with the provider message, got %q %q", gotCode, gotMsg)
+	}
+}
+
+func TestQuoteResultFromRepo_ErrorCode(t *testing.T) {
+	msg := "provider timeout"
+	failed := &repository.Quote{ID: "u1", Status: repository.StatusFailed, ErrorMsg: &msg, ErrorCode: repository.ErrorCodeTimeout}
+	if r := quoteResultFromRepo(failed); r.ErrorCode != "timeout" || r.ErrorMsg == nil || *r.ErrorMsg != msg {
+		t.Errorf("Expected the error and its code, got %+v", r)
+	}
+
+	// Failed before error codes were recorded: the message still renders.
+	legacy := &repository.Quote{ID: "u2", Status: repository.StatusFailed, ErrorMsg: &msg}
+	if r := quoteResultFromRepo(legacy); r.ErrorCode != "" || r.ErrorMsg == nil || *r.ErrorMsg != msg {
+		t.Errorf("Expected the error without a code, got %+v", r)
+	}
+}
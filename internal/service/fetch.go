@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"quoteservice/internal/repository"
+)
+
+// Backoff of FetchQuote's polls of the update: the first poll follows the request after
+// fetchPollInitial, each further one after twice the previous delay up to fetchPollMax.
+const (
+	fetchPollInitial = 20 * time.Millisecond
+	fetchPollMax     = 500 * time.Millisecond
+)
+
+// FetchQuote requests an update of the pair like RequestQuoteUpdate and waits up to
+// wait for it to reach SUCCESS or FAILED, polling the update with backoff. It returns
+// the update's result, which is still PENDING or RUNNING if wait elapsed first; the
+// caller then polls GetQuoteResult like after RequestQuoteUpdate. Errors are those of
+// RequestQuoteUpdate and GetQuoteResult, or ctx's error once it is cancelled.
+func (s *QuoteService) FetchQuote(ctx context.Context, rawPair string, wait time.Duration) (*QuoteResult, error) {
+	req, err := s.RequestQuoteUpdate(ctx, rawPair, UpdateOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, wait)
+	defer cancel()
+	delay := fetchPollInitial
+	if isTerminal(repository.Status(req.Status)) {
+		delay = 0 // A recent update reused during its cooldown.
+	}
+	for {
+		if !sleepCtx(waitCtx, delay) {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			// One last look, so an update finishing right at the deadline is not missed.
+			return s.GetQuoteResult(ctx, req.UpdateID)
+		}
+		res, err := s.GetQuoteResult(ctx, req.UpdateID)
+		if err != nil {
+			return nil, err
+		}
+		if res.Finished() {
+			return res, nil
+		}
+		delay = min(delay*2, fetchPollMax)
+	}
+}
+
+// sleepCtx waits for d and reports whether ctx is still live.
+func sleepCtx(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"quoteservice/internal/repository"
+)
+
+// fetchService returns a service whose updates report RUNNING for the first polls and
+// then status, counting the polls.
+func fetchService(runningPolls int32, status repository.Status, polls *atomic.Int32) *QuoteService {
+	price := "18.7543"
+	repo := &mockQuoteRepo{
+		createUpdateFunc: func(_ context.Context, _ Pair, id string) (repository.CreateUpdateResult, error) {
+			return created(id)
+		},
+		getByIDFunc: func(_ context.Context, id string) (*repository.Quote, error) {
+			q := &repository.Quote{ID: id, Base: "EUR", Quote: "MXN", Status: repository.StatusRunning}
+			if polls.Add(1) > runningPolls {
+				q.Status = status
+				q.Price = &price
+			}
+			return q, nil
+		},
+	}
+	return NewQuoteService(QuoteServiceDeps{
+		Repo:        repo,
+		Validator:   NewValidator(),
+		Enqueuer:    &mockTaskEnqueuer{enqueueUpdateTaskFunc: func(context.Context, UpdateQuotePayload) error { return nil }},
+		CacheConfig: testCacheCfg,
+	})
+}
+
+func TestFetchQuote(t *testing.T) {
+	t.Run("completed in time", func(t *testing.T) {
+		var polls atomic.Int32
+		res, err := fetchService(2, repository.StatusSuccess, &polls).FetchQuote(context.Background(), "EUR/MXN", 5*time.Second)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if res.Status != string(repository.StatusSuccess) || res.Price == nil || *res.Price != "18.7543" {
+			t.Errorf("Expected SUCCESS at 18.7543, got %s %v", res.Status, res.Price)
+		}
+		if got := polls.Load(); got != 3 {
+			t.Errorf("Expected 3 polls, got %d", got)
+		}
+	})
+
+	t.Run("timed out", func(t *testing.T) {
+		var polls atomic.Int32
+		wait := 100 * time.Millisecond
+		start := time.Now()
+		res, err := fetchService(1000, repository.StatusSuccess, &polls).FetchQuote(context.Background(), "EUR/MXN", wait)
+		elapsed := time.Since(start)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if res.Finished() || res.ID == "" {
+			t.Errorf("Expected the unfinished update, got %+v", res)
+		}
+		if elapsed < wait || elapsed > wait+fetchPollMax {
+			t.Errorf("Expected to return after about %v, took %v", wait, elapsed)
+		}
+		// Backoff from 20ms: polls at 20, 60, 140ms, plus the last one at the deadline.
+		if got := polls.Load(); got > 4 {
+			t.Errorf("Expected at most 4 polls with backoff, got %d", got)
+		}
+	})
+
+	t.Run("request rejected", func(t *testing.T) {
+		var polls atomic.Int32
+		_, err := fetchService(0, repository.StatusSuccess, &polls).FetchQuote(context.Background(), "EUR/ZZZ", time.Second)
+		if !IsValidationError(err) {
+			t.Errorf("Expected a validation error, got %v", err)
+		}
+		if got := polls.Load(); got != 0 {
+			t.Errorf("Expected no polls, got %d", got)
+		}
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		var polls atomic.Int32
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		_, err := fetchService(1000, repository.StatusSuccess, &polls).FetchQuote(ctx, "EUR/MXN", time.Minute)
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected the context's error, got %v", err)
+		}
+	})
+}
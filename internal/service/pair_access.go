@@ -0,0 +1,47 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+)
+
+// ErrPairForbidden indicates that the caller's PairAccess does not include the pair.
+var ErrPairForbidden = errors.New("pair not permitted")
+
+// PairAccess restricts the currency pairs a caller may read or update. A pair is
+// allowed if it is listed in Pairs or its base currency is listed in Bases. A nil
+// *PairAccess, or one with both lists empty, allows every pair.
+type PairAccess struct {
+	Pairs []string // Upper-case "BASE/QUOTE".
+	Bases []string // Upper-case currency codes.
+}
+
+// Allows reports whether pair, given in upper case, is accessible.
+func (a *PairAccess) Allows(pair Pair) bool {
+	if a == nil || (len(a.Pairs) == 0 && len(a.Bases) == 0) {
+		return true
+	}
+	return slices.Contains(a.Bases, pair.Base) || slices.Contains(a.Pairs, pair.String())
+}
+
+type pairAccessKey struct{}
+
+// WithPairAccess returns a copy of ctx whose service calls are limited to access.
+func WithPairAccess(ctx context.Context, access *PairAccess) context.Context {
+	return context.WithValue(ctx, pairAccessKey{}, access)
+}
+
+// PairAccessFromContext returns the PairAccess stored by WithPairAccess, or nil.
+func PairAccessFromContext(ctx context.Context) *PairAccess {
+	access, _ := ctx.Value(pairAccessKey{}).(*PairAccess)
+	return access
+}
+
+// checkPairAccess returns ErrPairForbidden if the caller in ctx may not access the pair.
+func checkPairAccess(ctx context.Context, pair Pair) error {
+	if !PairAccessFromContext(ctx).Allows(pair) {
+		return ErrPairForbidden
+	}
+	return nil
+}
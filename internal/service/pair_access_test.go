@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"quoteservice/internal/repository"
+)
+
+func TestPairAccess_Allows(t *testing.T) {
+	tests := []struct {
+		name   string
+		access *PairAccess
+		pair   Pair
+		want   bool
+	}{
+		{"nil allows everything", nil, Pair{Base: "EUR", Quote: "USD"}, true},
+		{"empty allows everything", &PairAccess{}, Pair{Base: "EUR", Quote: "USD"}, true},
+		{"listed pair", &PairAccess{Pairs: []string{"EUR/USD"}}, Pair{Base: "EUR", Quote: "USD"}, true},
+		{"inverse pair is not listed", &PairAccess{Pairs: []string{"EUR/USD"}}, Pair{Base: "USD", Quote: "EUR"}, false},
+		{"listed base", &PairAccess{Bases: []string{"GBP"}}, Pair{Base: "GBP", Quote: "JPY"}, true},
+		{"base matches only as base", &PairAccess{Bases: []string{"GBP"}}, Pair{Base: "EUR", Quote: "GBP"}, false},
+		{"pair or base", &PairAccess{Pairs: []string{"EUR/USD"}, Bases: []string{"GBP"}}, Pair{Base: "GBP", Quote: "USD"}, true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.access.Allows(tc.pair); got != tc.want {
+				t.Errorf("Allows(%s) = %v, want %v", tc.pair, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestQuoteService_PairAccess(t *testing.T) {
+	repo := &mockQuoteRepo{
+		createUpdateFunc: func(_ context.Context, _ Pair, id string) (repository.CreateUpdateResult, error) { return created(id) },
+		getLatestSuccessFunc: func(_ context.Context, pair Pair) (*repository.Quote, error) {
+			return &repository.Quote{ID: "latest", Base: pair.Base, Quote: pair.Quote, Status: repository.StatusSuccess}, nil
+		},
+		getByIDFunc: func(_ context.Context, id string) (*repository.Quote, error) {
+			return &repository.Quote{ID: id, Base: "GBP", Quote: "USD", Status: repository.StatusPending}, nil
+		},
+	}
+	svc := NewQuoteService(QuoteServiceDeps{
+		Repo:        repo,
+		Validator:   NewValidator(),
+		Enqueuer:    &mockTaskEnqueuer{enqueueUpdateTaskFunc: func(context.Context, UpdateQuotePayload) error { return nil }},
+		Logger:      zap.NewNop().Sugar(),
+		CacheConfig: testCacheCfg,
+	})
+	const gbpUpdateID = "3f1c2d4e-5a6b-4c7d-8e9f-0a1b2c3d4e5f"
+
+	tests := []struct {
+		name       string
+		access     *PairAccess
+		wantUpdate error // RequestQuoteUpdate and GetLatestQuote for EUR/USD.
+		wantResult error // GetQuoteResult for a GBP/USD update.
+	}{
+		{"unscoped key", nil, nil, nil},
+		{"allowed pair", &PairAccess{Pairs: []string{"EUR/USD", "GBP/USD"}}, nil, nil},
+		{"allowed base", &PairAccess{Bases: []string{"EUR", "GBP"}}, nil, nil},
+		{"denied", &PairAccess{Bases: []string{"JPY"}}, ErrPairForbidden, ErrNotFound},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := WithPairAccess(context.Background(), tc.access)
+
+			if _, err := svc.RequestQuoteUpdate(ctx, "EUR/USD", UpdateOptions{}); !errors.Is(err, tc.wantUpdate) {
+				t.Errorf("RequestQuoteUpdate: expected %v, got %v", tc.wantUpdate, err)
+			}
+			if _, err := svc.GetLatestQuote(ctx, Pair{Base: "EUR", Quote: "USD"}); !errors.Is(err, tc.wantUpdate) {
+				t.Errorf("GetLatestQuote: expected %v, got %v", tc.wantUpdate, err)
+			}
+			if _, err := svc.GetQuoteResult(ctx, gbpUpdateID); !errors.Is(err, tc.wantResult) {
+				t.Errorf("GetQuoteResult: expected %v, got %v", tc.wantResult, err)
+			}
+		})
+	}
+}
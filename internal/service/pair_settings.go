@@ -0,0 +1,70 @@
+package service
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"quoteservice/internal/config"
+)
+
+// PairSettings are the settings in effect for one pair once its overrides are applied.
+type PairSettings struct {
+	LatestPriceTTL  time.Duration
+	ProviderOrder   []string // Empty means the provider's configured order.
+	Queue           string   // asynq queue for the pair's update tasks.
+	RefreshCooldown time.Duration
+}
+
+// PairResolver merges per-pair overrides over global defaults. A nil *PairResolver
+// resolves every pair to the zero PairSettings.
+type PairResolver struct {
+	defaults  PairSettings
+	overrides map[string]config.PairOverride
+}
+
+// NewPairResolver creates a PairResolver. Override keys are "BASE/QUOTE" in any case.
+func NewPairResolver(defaults PairSettings, overrides map[string]config.PairOverride) *PairResolver {
+	normalized := make(map[string]config.PairOverride, len(overrides))
+	for key, o := range overrides {
+		normalized[strings.ToUpper(key)] = o
+	}
+	return &PairResolver{defaults: defaults, overrides: normalized}
+}
+
+// DefaultPairSettings derives the global PairSettings from the configuration.
+func DefaultPairSettings(cfg *config.Config) PairSettings {
+	return PairSettings{
+		LatestPriceTTL:  time.Duration(cfg.Cache.LatestPriceTTLSec) * time.Second,
+		Queue:           config.PriorityDefault,
+		RefreshCooldown: time.Duration(cfg.Worker.RefreshCooldownSec) * time.Second,
+	}
+}
+
+// Resolve returns the settings for pair: each field set in the pair's override
+// wins, every other field keeps the global default.
+func (r *PairResolver) Resolve(pair Pair) PairSettings {
+	if r == nil {
+		return PairSettings{}
+	}
+	s := r.defaults
+	s.ProviderOrder = slices.Clone(s.ProviderOrder)
+
+	o, ok := r.overrides[strings.ToUpper(pair.String())]
+	if !ok {
+		return s
+	}
+	if o.LatestPriceTTLSec != nil {
+		s.LatestPriceTTL = time.Duration(*o.LatestPriceTTLSec) * time.Second
+	}
+	if len(o.ProviderOrder) > 0 {
+		s.ProviderOrder = slices.Clone(o.ProviderOrder)
+	}
+	if o.Priority != "" {
+		s.Queue = o.Priority
+	}
+	if o.CooldownSec != nil {
+		s.RefreshCooldown = time.Duration(*o.CooldownSec) * time.Second
+	}
+	return s
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"quoteservice/internal/config"
+)
+
+func intPtr(v int) *int { return &v }
+
+func TestPairResolver_Resolve(t *testing.T) {
+	defaults := PairSettings{
+		LatestPriceTTL:  10 * time.Minute,
+		Queue:           config.PriorityDefault,
+		RefreshCooldown: 30 * time.Second,
+	}
+	resolver := NewPairResolver(defaults, map[string]config.PairOverride{
+		"EUR/USD": {
+			LatestPriceTTLSec: intPtr(60),
+			ProviderOrder:     []string{config.ProviderFrankfurter},
+			Priority:          config.PriorityHigh,
+			CooldownSec:       intPtr(0),
+		},
+		"usd/try": {
+			LatestPriceTTLSec: intPtr(3600),
+			Priority:          config.PriorityLow,
+		},
+	})
+
+	tests := []struct {
+		name        string
+		base, quote string
+		want        PairSettings
+	}{
+		{
+			name: "all fields overridden",
+			base: "EUR", quote: "USD",
+			want: PairSettings{
+				LatestPriceTTL:  time.Minute,
+				ProviderOrder:   []string{config.ProviderFrankfurter},
+				Queue:           config.PriorityHigh,
+				RefreshCooldown: 0,
+			},
+		},
+		{
+			name: "partial override keeps remaining defaults",
+			base: "USD", quote: "TRY",
+			want: PairSettings{
+				LatestPriceTTL:  time.Hour,
+				Queue:           config.PriorityLow,
+				RefreshCooldown: 30 * time.Second,
+			},
+		},
+		{
+			name: "lower-case lookup",
+			base: "eur", quote: "usd",
+			want: PairSettings{
+				LatestPriceTTL:  time.Minute,
+				ProviderOrder:   []string{config.ProviderFrankfurter},
+				Queue:           config.PriorityHigh,
+				RefreshCooldown: 0,
+			},
+		},
+		{
+			name: "pair without override",
+			base: "GBP", quote: "JPY",
+			want: defaults,
+		},
+		{
+			name: "reversed pair is a different pair",
+			base: "USD", quote: "EUR",
+			want: defaults,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := resolver.Resolve(Pair{Base: tt.base, Quote: tt.quote})
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Resolve(%s, %s) = %+v, want %+v", tt.base, tt.quote, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPairResolver_ResolveDoesNotAliasOverrides(t *testing.T) {
+	resolver := NewPairResolver(PairSettings{}, map[string]config.PairOverride{
+		"EUR/USD": {ProviderOrder: []string{config.ProviderFrankfurter, config.ProviderExchangeRateHost}},
+	})
+
+	got := resolver.Resolve(Pair{Base: "EUR", Quote: "USD"})
+	got.ProviderOrder[0] = "changed"
+
+	if again := resolver.Resolve(Pair{Base: "EUR", Quote: "USD"}); again.ProviderOrder[0] != config.ProviderFrankfurter {
+		t.Errorf("Resolve returned a slice aliasing the override: %v", again.ProviderOrder)
+	}
+}
+
+func TestDefaultPairSettings(t *testing.T) {
+	cfg := &config.Config{
+		Cache:  config.CacheConfig{LatestPriceTTLSec: 600},
+		Worker: config.WorkerConfig{RefreshCooldownSec: 15},
+	}
+
+	want := PairSettings{
+		LatestPriceTTL:  10 * time.Minute,
+		Queue:           config.PriorityDefault,
+		RefreshCooldown: 15 * time.Second,
+	}
+	if got := DefaultPairSettings(cfg); !reflect.DeepEqual(got, want) {
+		t.Errorf("DefaultPairSettings = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"quoteservice/internal/clock"
+	"quoteservice/internal/repository"
+)
+
+// pendingLimit caps the number of PENDING updates. The count is read from the
+// repository at most once per ttl; updates created in between are added to the cached
+// count so a burst cannot overshoot the cap by more than the requests racing the check.
+type pendingLimit struct {
+	max   int
+	ttl   time.Duration
+	clock clock.Clock
+
+	mu        sync.Mutex
+	count     int
+	countedAt time.Time
+}
+
+// WithPendingLimit makes RequestQuoteUpdate fail with ErrQueueFull while max updates
+// are PENDING, re-counting them at most once per countTTL. A non-positive max disables
+// the cap.
+func WithPendingLimit(maxPending int, countTTL time.Duration) QuoteServiceOption {
+	return func(s *QuoteService) {
+		if maxPending <= 0 {
+			s.pending = nil
+			return
+		}
+		s.pending = &pendingLimit{max: maxPending, ttl: countTTL, clock: s.clock}
+	}
+}
+
+// full reports whether the cap is reached. A nil *pendingLimit is never full.
+func (l *pendingLimit) full(ctx context.Context, repo repository.QuoteRepository) (bool, error) {
+	if l == nil {
+		return false, nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.clock.Now()
+	if l.countedAt.IsZero() || now.Sub(l.countedAt) >= l.ttl {
+		n, err := repo.CountByStatus(ctx, repository.StatusPending)
+		if err != nil {
+			return false, err
+		}
+		l.count, l.countedAt = n, now
+	}
+	return l.count >= l.max, nil
+}
+
+// added records a newly created PENDING update in the cached count.
+func (l *pendingLimit) added() {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	l.count++
+	l.mu.Unlock()
+}
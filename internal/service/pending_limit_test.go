@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"quoteservice/internal/repository"
+	"quoteservice/internal/testkit/fakeclock"
+)
+
+func TestRequestQuoteUpdate_PendingLimit(t *testing.T) {
+	clk := fakeclock.New(time.Date(2025, 12, 1, 12, 0, 0, 0, time.UTC))
+	newService := func(repo *mockQuoteRepo, opts ...QuoteServiceOption) *QuoteService {
+		return NewQuoteService(QuoteServiceDeps{
+			Repo:        repo,
+			Validator:   NewValidator(),
+			Enqueuer:    &mockTaskEnqueuer{enqueueUpdateTaskFunc: func(context.Context, UpdateQuotePayload) error { return nil }},
+			Logger:      zap.NewNop().Sugar(),
+			CacheConfig: testCacheCfg,
+			Clock:       clk,
+		}, opts...)
+	}
+
+	t.Run("saturated queue is rejected before creating an update", func(t *testing.T) {
+		repo := &mockQuoteRepo{
+			countByStatusFunc: func(_ context.Context, status repository.Status) (int, error) {
+				if status != repository.StatusPending {
+					t.Errorf("Expected a PENDING count, got %s", status)
+				}
+				return 100, nil
+			},
+			createUpdateFunc: func(ctx context.Context, pair Pair, id string) (repository.CreateUpdateResult, error) {
+				t.Error("CreateUpdate must not be called while the queue is full")
+				return created(id)
+			},
+		}
+		svc := newService(repo, WithPendingLimit(100, time.Second))
+
+		if _, err := svc.RequestQuoteUpdate(context.Background(), "EUR/USD", UpdateOptions{}); !errors.Is(err, ErrQueueFull) {
+			t.Fatalf("Expected ErrQueueFull, got %v", err)
+		}
+	})
+
+	t.Run("count is cached and counts new updates", func(t *testing.T) {
+		counts := 0
+		repo := &mockQuoteRepo{
+			countByStatusFunc: func(context.Context, repository.Status) (int, error) {
+				counts++
+				return 1, nil
+			},
+			createUpdateFunc: func(ctx context.Context, pair Pair, id string) (repository.CreateUpdateResult, error) {
+				return created(id)
+			},
+		}
+		svc := newService(repo, WithPendingLimit(3, time.Minute))
+
+		for _, pair := range []string{"EUR/USD", "GBP/USD"} {
+			if _, err := svc.RequestQuoteUpdate(context.Background(), pair, UpdateOptions{}); err != nil {
+				t.Fatalf("%s: %v", pair, err)
+			}
+		}
+		// 1 counted + 2 created reaches the cap of 3 without another query.
+		if _, err := svc.RequestQuoteUpdate(context.Background(), "USD/JPY", UpdateOptions{}); !errors.Is(err, ErrQueueFull) {
+			t.Fatalf("Expected ErrQueueFull, got %v", err)
+		}
+		if counts != 1 {
+			t.Errorf("Expected 1 count query within the TTL, got %d", counts)
+		}
+
+		// Once the TTL passes the count is refreshed from the repository.
+		clk.Advance(time.Minute)
+		if _, err := svc.RequestQuoteUpdate(context.Background(), "USD/JPY", UpdateOptions{}); err != nil {
+			t.Fatalf("Expected the refreshed count to admit the request, got %v", err)
+		}
+		if counts != 2 {
+			t.Errorf("Expected 2 count queries, got %d", counts)
+		}
+	})
+
+	t.Run("count failure does not block requests", func(t *testing.T) {
+		repo := &mockQuoteRepo{
+			countByStatusFunc: func(context.Context, repository.Status) (int, error) {
+				return 0, errors.New("db down")
+			},
+			createUpdateFunc: func(ctx context.Context, pair Pair, id string) (repository.CreateUpdateResult, error) {
+				return created(id)
+			},
+		}
+		svc := newService(repo, WithPendingLimit(1, time.Second))
+
+		if _, err := svc.RequestQuoteUpdate(context.Background(), "EUR/USD", UpdateOptions{}); err != nil {
+			t.Fatalf("Expected the request to pass, got %v", err)
+		}
+	})
+
+	t.Run("zero disables the cap", func(t *testing.T) {
+		repo := &mockQuoteRepo{
+			createUpdateFunc: func(ctx context.Context, pair Pair, id string) (repository.CreateUpdateResult, error) {
+				return created(id)
+			},
+		}
+		svc := newService(repo, WithPendingLimit(0, time.Second))
+
+		if _, err := svc.RequestQuoteUpdate(context.Background(), "EUR/USD", UpdateOptions{}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,31 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"quoteservice/internal/repository"
+)
+
+// PollEstimator suggests how long a client should wait before polling an update that
+// has not finished yet.
+type PollEstimator interface {
+	PollAfter(ctx context.Context) time.Duration
+}
+
+// WithPollEstimator makes RequestQuoteUpdate and GetQuoteResult report the estimate of
+// e for PENDING and RUNNING updates. Without an estimator no hint is given.
+func WithPollEstimator(e PollEstimator) QuoteServiceOption {
+	return func(s *QuoteService) {
+		s.pollEstimator = e
+	}
+}
+
+// pollAfter returns the poll hint for an update in status, or 0 if the update has
+// finished or no estimator is configured.
+func (s *QuoteService) pollAfter(ctx context.Context, status string) time.Duration {
+	if s.pollEstimator == nil || isTerminal(repository.Status(status)) {
+		return 0
+	}
+	return s.pollEstimator.PollAfter(ctx)
+}
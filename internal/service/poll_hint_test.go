@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"quoteservice/internal/repository"
+)
+
+type fixedPollEstimator time.Duration
+
+func (e fixedPollEstimator) PollAfter(context.Context) time.Duration { return time.Duration(e) }
+
+func TestRequestQuoteUpdate_PollAfter(t *testing.T) {
+	const hint = fixedPollEstimator(3 * time.Second)
+	newService := func(createdID string, opts ...QuoteServiceOption) *QuoteService {
+		repo := &mockQuoteRepo{
+			createUpdateFunc: func(_ context.Context, _ Pair, id string) (repository.CreateUpdateResult, error) {
+				if createdID != "" {
+					return deduplicated(createdID)
+				}
+				return created(id)
+			},
+		}
+		return NewQuoteService(QuoteServiceDeps{
+			Repo:        repo,
+			Validator:   NewValidator(),
+			Enqueuer:    &mockTaskEnqueuer{enqueueUpdateTaskFunc: func(context.Context, UpdateQuotePayload) error { return nil }},
+			Logger:      zap.NewNop().Sugar(),
+			CacheConfig: testCacheCfg,
+		}, opts...)
+	}
+
+	tests := []struct {
+		name string
+		svc  *QuoteService
+		want time.Duration
+	}{
+		{"new update", newService("", WithPollEstimator(hint)), time.Duration(hint)},
+		{"pending update reused", newService("existing-uuid", WithPollEstimator(hint)), time.Duration(hint)},
+		{"no estimator", newService(""), 0},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := tc.svc.RequestQuoteUpdate(context.Background(), "EUR/USD", UpdateOptions{})
+			if err != nil {
+				t.Fatalf("RequestQuoteUpdate: %v", err)
+			}
+			if result.PollAfter != tc.want {
+				t.Errorf("expected PollAfter %v, got %v", tc.want, result.PollAfter)
+			}
+		})
+	}
+}
+
+func TestGetQuoteResult_PollAfter(t *testing.T) {
+	const hint = fixedPollEstimator(3 * time.Second)
+	id := "123e4567-e89b-12d3-a456-426614174000"
+	now := time.Now().UTC()
+
+	tests := []struct {
+		status repository.Status
+		want   time.Duration
+	}{
+		{repository.StatusPending, time.Duration(hint)},
+		{repository.StatusRunning, time.Duration(hint)},
+		{repository.StatusSuccess, 0},
+		{repository.StatusFailed, 0},
+	}
+	for _, tc := range tests {
+		t.Run(string(tc.status), func(t *testing.T) {
+			repo := &mockQuoteRepo{
+				getByIDFunc: func(context.Context, string) (*repository.Quote, error) {
+					return &repository.Quote{ID: id, Base: "EUR", Quote: "USD", Status: tc.status, RequestedAt: now}, nil
+				},
+			}
+			svc := NewQuoteService(QuoteServiceDeps{
+				Repo:        repo,
+				Validator:   NewValidator(),
+				Logger:      zap.NewNop().Sugar(),
+				CacheConfig: testCacheCfg,
+			}, WithPollEstimator(hint))
+
+			result, err := svc.GetQuoteResult(context.Background(), id)
+			if err != nil {
+				t.Fatalf("GetQuoteResult: %v", err)
+			}
+			if result.PollAfter != tc.want {
+				t.Errorf("expected PollAfter %v, got %v", tc.want, result.PollAfter)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"quoteservice/internal/repository"
+)
+
+// Quote event types delivered to subscribers.
+const (
+	QuoteEventUpdate    = "update"
+	QuoteEventHeartbeat = "heartbeat"
+	QuoteEventDone      = "done"
+)
+
+// ErrSubscriptionsUnavailable indicates the service was built without a PairWatcher.
+var ErrSubscriptionsUnavailable = errors.New("quote subscriptions unavailable")
+
+// PairWatcher delivers notifications for successful quote updates of a single pair.
+// The returned channel is closed when ctx is cancelled or the watcher stops.
+type PairWatcher interface {
+	WatchPair(ctx context.Context, pair Pair) <-chan repository.QuoteNotification
+}
+
+// QuoteEvent is a single event on a pair subscription.
+type QuoteEvent struct {
+	Type      string
+	Data      QuoteResult
+	Timestamp time.Time
+}
+
+// SubscribePair streams an "update" event each time a new rate for the pair is stored,
+// whichever process stored it. The channel is closed when ctx is cancelled or the
+// underlying watcher stops; heartbeats are left to the transport.
+func (s *QuoteService) SubscribePair(ctx context.Context, pair Pair) (<-chan QuoteEvent, error) {
+	assertCanonical(pair.Base, pair.Quote)
+	pair, err := NewPair(pair.Base, pair.Quote)
+	if err != nil {
+		return nil, err
+	}
+	if vErr := s.validatePair(pair); vErr != nil {
+		return nil, vErr
+	}
+	if err := checkPairAccess(ctx, pair); err != nil {
+		return nil, err
+	}
+	if s.watcher == nil {
+		return nil, ErrSubscriptionsUnavailable
+	}
+
+	notifications := s.watcher.WatchPair(ctx, pair)
+	events := make(chan QuoteEvent, 1)
+	go func() {
+		defer close(events)
+		for n := range notifications {
+			ev := QuoteEvent{Type: QuoteEventUpdate, Data: quoteResultFromNotification(n), Timestamp: s.clock.Now().UTC()}
+			select {
+			case events <- ev:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return events, nil
+}
+
+func quoteResultFromNotification(n repository.QuoteNotification) QuoteResult {
+	price := n.Price
+	return QuoteResult{
+		Base:          n.Base,
+		Quote:         n.Quote,
+		Price:         &price,
+		Status:        string(repository.StatusSuccess),
+		UpdatedAt:     formatTimestamp(&n.UpdatedAt),
+		RateTimestamp: formatTimestamp(n.RateTimestamp),
+	}
+}
@@ -8,37 +8,168 @@ import (
 
 // QuoteResult represents a quote result returned by the service layer.
 // Fields are populated according to the quote's status:
-//   - SUCCESS: Price and UpdatedAt are set, ErrorMsg is nil.
-//   - FAILED:  ErrorMsg is set, Price is nil.
-//   - PENDING/RUNNING: Price, ErrorMsg, and UpdatedAt are nil.
+//   - SUCCESS: Price, UpdatedAt and RateTimestamp are set, ErrorMsg is nil. Verification
+//     is set once the spread across providers has been recorded.
+//   - FAILED:  ErrorMsg and ErrorCode are set, Price is nil. Updates failed before error
+//     codes were recorded have no ErrorCode.
+//   - PENDING/RUNNING: Price, ErrorMsg, UpdatedAt and RateTimestamp are nil.
+//
+// UpdatedAt is when the record was written; RateTimestamp is when the provider observed the price.
 type QuoteResult struct {
-	ID        string
-	Base      string
-	Quote     string
-	Price     *string
-	Status    string
-	ErrorMsg  *string
-	UpdatedAt *string
+	ID            string
+	Base          string
+	Quote         string
+	Price         *string
+	Status        string
+	ErrorMsg      *string
+	ErrorCode     string // Set with ErrorMsg, unless the update failed before codes were recorded.
+	UpdatedAt     *string
+	RateTimestamp *string
+	Verification  *QuoteVerification
+	Origin        string // Code path that created the update, e.g. "api" or "stream".
+	InstanceID    string // Service instance that last marked the update RUNNING, if recorded.
+	// PollAfter is how long to wait before polling again; only set for PENDING and
+	// RUNNING updates when a PollEstimator is configured.
+	PollAfter time.Duration
+	// ServedFrom is the tier GetLatestQuote read the quote from, ServedFromRedis or
+	// ServedFromDB; empty for results of other methods.
+	ServedFrom string
+	// Derived is "inverse" for a latest price computed from the inverse pair's rate
+	// instead of fetched; empty otherwise.
+	Derived string
+}
+
+// Tiers GetLatestQuote serves a quote from, for QuoteResult.ServedFrom.
+const (
+	ServedFromRedis = "redis" // The latest-price cache.
+	ServedFromDB    = "db"    // PostgreSQL, after a cache miss or while the cache is down.
+)
+
+// QuoteVerification is the spread of the providers' rates for an update. SpreadPct is
+// (MaxPrice - MinPrice) / MinPrice in percent.
+type QuoteVerification struct {
+	MinPrice  string
+	MaxPrice  string
+	SpreadPct string
+	Providers int
+}
+
+// Finished reports whether the update reached SUCCESS or FAILED.
+func (r *QuoteResult) Finished() bool {
+	return isTerminal(repository.Status(r.Status))
 }
 
 func quoteResultFromRepo(q *repository.Quote) *QuoteResult {
 	r := &QuoteResult{
-		ID:     q.ID,
-		Base:   q.Base,
-		Quote:  q.Quote,
-		Status: string(q.Status),
+		ID:         q.ID,
+		Base:       q.Base,
+		Quote:      q.Quote,
+		Status:     string(q.Status),
+		Origin:     string(q.Origin),
+		InstanceID: q.InstanceID,
 	}
 
 	switch q.Status {
 	case repository.StatusSuccess:
 		r.Price = q.Price
-		if q.UpdatedAt != nil {
-			ts := q.UpdatedAt.Format(time.RFC3339)
-			r.UpdatedAt = &ts
+		r.UpdatedAt = formatTimestamp(q.UpdatedAt)
+		r.RateTimestamp = formatTimestamp(q.RateTimestamp)
+		r.Derived = q.Derived
+		if v := q.Verification; v != nil {
+			r.Verification = &QuoteVerification{
+				MinPrice:  v.MinPrice,
+				MaxPrice:  v.MaxPrice,
+				SpreadPct: v.Spread,
+				Providers: v.Providers,
+			}
 		}
 	case repository.StatusFailed:
 		r.ErrorMsg = q.ErrorMsg
+		r.ErrorCode = string(q.ErrorCode)
 	}
 
 	return r
 }
+
+// UpdateReuseReason says why RequestQuoteUpdate returned an existing update instead of
+// starting a new one.
+type UpdateReuseReason string
+
+// Reuse reasons reported in UpdateRequestResult.Reason.
+const (
+	// ReuseReasonPendingExists: an update for the pair is already PENDING or RUNNING.
+	ReuseReasonPendingExists UpdateReuseReason = "pending_exists"
+	// ReuseReasonCooldownActive: the pair's latest SUCCESS is younger than its refresh cooldown.
+	ReuseReasonCooldownActive UpdateReuseReason = "cooldown_active"
+)
+
+// UpdateOptions are optional settings for RequestQuoteUpdate.
+type UpdateOptions struct {
+	// Provider, if set, is the name of the only provider the update is fetched from,
+	// bypassing the facade's order and fallback. It also skips the refresh cooldown.
+	Provider string
+}
+
+// UpdateRequestResult is the outcome of RequestQuoteUpdate. Reason is empty when a new
+// update was enqueued.
+type UpdateRequestResult struct {
+	UpdateID string
+	Status   string
+	Reason   UpdateReuseReason
+	// CooldownRemaining is how long the cooldown still applies; only set with
+	// ReuseReasonCooldownActive.
+	CooldownRemaining time.Duration
+	// PollAfter is how long to wait before polling the update; only set for an
+	// unfinished update when a PollEstimator is configured.
+	PollAfter time.Duration
+}
+
+// QuoteAttempt describes the most recent update of a pair regardless of its outcome.
+// ErrorMsg is set for FAILED; AttemptAt is when the update last changed status.
+type QuoteAttempt struct {
+	Status    string
+	ErrorMsg  *string
+	AttemptAt string
+}
+
+func quoteAttemptFromRepo(q *repository.Quote) *QuoteAttempt {
+	at := q.RequestedAt
+	if q.UpdatedAt != nil {
+		at = *q.UpdatedAt
+	}
+	a := &QuoteAttempt{Status: string(q.Status), AttemptAt: FormatTimestamp(at)}
+	if q.Status == repository.StatusFailed {
+		a.ErrorMsg = q.ErrorMsg
+	}
+	return a
+}
+
+// QuoteStatusEvent records when a quote update entered a status. Detail is the price
+// for SUCCESS and the error message for FAILED.
+type QuoteStatusEvent struct {
+	Status     string
+	At         string
+	Detail     *string
+	InstanceID string // Set for RUNNING events, if recorded.
+}
+
+func statusEventsFromRepo(events []repository.StatusEvent) []QuoteStatusEvent {
+	out := make([]QuoteStatusEvent, len(events))
+	for i, ev := range events {
+		out[i] = QuoteStatusEvent{
+			Status:     string(ev.Status),
+			At:         FormatTimestamp(ev.At),
+			Detail:     ev.Detail,
+			InstanceID: ev.InstanceID,
+		}
+	}
+	return out
+}
+
+func formatTimestamp(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	ts := FormatTimestamp(*t)
+	return &ts
+}
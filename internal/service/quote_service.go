@@ -3,198 +3,915 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
 
+	"quoteservice/internal/clock"
 	"quoteservice/internal/config"
+	"quoteservice/internal/logging/fields"
+	"quoteservice/internal/metrics"
 	"quoteservice/internal/provider"
+	"quoteservice/internal/rediskey"
 	"quoteservice/internal/repository"
 )
 
 // QuoteServiceInterface defines the operations available for quote management.
 type QuoteServiceInterface interface {
-	RequestQuoteUpdate(ctx context.Context, pair string) (updateID, status string, err error)
+	RequestQuoteUpdate(ctx context.Context, pair string, opts UpdateOptions) (*UpdateRequestResult, error)
+	FetchQuote(ctx context.Context, pair string, wait time.Duration) (*QuoteResult, error)
 	GetQuoteResult(ctx context.Context, updateID string) (*QuoteResult, error)
-	GetLatestQuote(ctx context.Context, base, quote string) (*QuoteResult, error)
-	ProcessUpdate(ctx context.Context, updateID, base, quote string) error
+	GetStatusEvents(ctx context.Context, updateID string) ([]QuoteStatusEvent, error)
+	GetLatestQuote(ctx context.Context, pair Pair) (*QuoteResult, error)
+	GetLastAttempt(ctx context.Context, pair Pair) (*QuoteAttempt, error)
+	GetHistoricalRate(ctx context.Context, pair Pair, at time.Time) (*QuoteResult, error)
+	CompareQuotes(ctx context.Context, pair Pair, at, vs time.Time) (*QuoteComparison, error)
+	ProcessUpdate(ctx context.Context, payload UpdateQuotePayload) error
+	SubscribePair(ctx context.Context, pair Pair) (<-chan QuoteEvent, error)
+	RegisterWebhook(ctx context.Context, pair, rawURL, secret string) (*Webhook, error)
+	DeregisterWebhook(ctx context.Context, webhookID string) error
 }
 
 // TaskEnqueuer abstracts background task enqueueing
 type TaskEnqueuer interface {
-	EnqueueUpdateTask(ctx context.Context, payload UpdateQuotePayload) error
+	EnqueueUpdateTask(ctx context.Context, payload UpdateQuotePayload, opts TaskOptions) error
+}
+
+// TaskOptions carries per-task enqueue settings resolved for the task's pair.
+type TaskOptions struct {
+	Queue string // Empty selects the enqueuer's default queue.
+}
+
+// RateMoveObserver is notified of every new successful rate together with the previous one.
+type RateMoveObserver interface {
+	ObserveRateMove(ctx context.Context, base, quote, oldPrice, newPrice string, oldAt, newAt time.Time)
 }
 
 // QuoteService defines business logic for quotes
 type QuoteService struct {
-	repo           repository.QuoteRepository
-	provider       provider.RatesProvider
-	validator      Validator
-	taskEnqueuer   TaskEnqueuer
-	cache          *redis.Client
-	log            *zap.SugaredLogger
-	latestPriceTTL time.Duration
+	repo             repository.QuoteRepository
+	provider         provider.RatesProvider
+	validator        Validator
+	taskEnqueuer     TaskEnqueuer
+	cache            *redis.Client
+	keys             rediskey.Namespace
+	watcher          PairWatcher
+	rateMoves        RateMoveObserver
+	events           QuoteEventPublisher
+	pairs            *PairResolver
+	routing          PairRoutingStrategy
+	verifier         *SpreadVerifier
+	pending          *pendingLimit
+	pollEstimator    PollEstimator
+	webhookRepo      repository.WebhookRepository
+	webhookSecretKey []byte
+	probeClient      *http.Client
+	log              *zap.SugaredLogger
+	latestPriceTTL   time.Duration
+	negativeCacheTTL time.Duration
+	inverseTTL       time.Duration // 0 unless inverse prices are cached.
+	inversePrecision int
+	warmupRequired   bool
+	cacheWarmed      atomic.Bool
+	clock            clock.Clock
+	instanceID       string
+}
+
+// QuoteServiceDeps groups the collaborators of a QuoteService. Provider, Enqueuer, Cache,
+// Watcher, RateMoves and WebhookRepo may be nil when the caller never exercises the paths that need them.
+type QuoteServiceDeps struct {
+	Repo        repository.QuoteRepository
+	Provider    provider.RatesProvider
+	Validator   Validator // Defaults to NewValidator().
+	Enqueuer    TaskEnqueuer
+	Cache       *redis.Client
+	Namespace   rediskey.Namespace // Prefixes the cache keys; empty keeps the bare keys.
+	Watcher     PairWatcher
+	RateMoves   RateMoveObserver
+	Events      QuoteEventPublisher // Defaults to NopQuoteEventPublisher.
+	Pairs       *PairResolver       // Defaults to CacheConfig's latest-price TTL for every pair.
+	WebhookRepo repository.WebhookRepository
+	// WebhookSecretKey is the HMAC key used to hash webhook secrets before storage;
+	// webhooks are unavailable without it.
+	WebhookSecretKey []byte
+	// ProbeClient is copied for the registration probe, which never follows redirects.
+	// Defaults to a client with a webhookProbeTimeout timeout.
+	ProbeClient *http.Client
+	Logger      *zap.SugaredLogger // Defaults to a no-op logger.
+	CacheConfig config.CacheConfig
+	Clock       clock.Clock // Defaults to clock.Real.
+	// InstanceID identifies this process; it is recorded on every update it marks RUNNING.
+	InstanceID string
+}
+
+// QuoteServiceOption configures optional QuoteService behaviour.
+type QuoteServiceOption func(*QuoteService)
+
+// WithRoutingStrategy makes ProcessUpdate pick each pair's provider with strategy.
+// The candidates are the providers of QuoteServiceDeps.Provider if it is an
+// ExchangeProviderFacade, or the provider itself otherwise. Without a strategy every
+// pair goes to QuoteServiceDeps.Provider.
+func WithRoutingStrategy(strategy PairRoutingStrategy) QuoteServiceOption {
+	return func(s *QuoteService) {
+		s.routing = strategy
+	}
+}
+
+// WithSpreadVerifier makes ProcessUpdate record the spread across providers for every
+// successful update. A nil verifier leaves verification off.
+func WithSpreadVerifier(v *SpreadVerifier) QuoteServiceOption {
+	return func(s *QuoteService) {
+		s.verifier = v
+	}
 }
 
 // NewQuoteService creates a new QuoteService
-func NewQuoteService(
-	repo repository.QuoteRepository,
-	prov provider.RatesProvider,
-	validator Validator,
-	taskClient TaskEnqueuer,
-	cache *redis.Client,
-	logger *zap.SugaredLogger,
-	cacheCfg config.CacheConfig) *QuoteService {
-	return &QuoteService{
-		repo:           repo,
-		provider:       prov,
-		validator:      validator,
-		taskEnqueuer:   taskClient,
-		cache:          cache,
-		log:            logger,
-		latestPriceTTL: time.Duration(cacheCfg.LatestPriceTTLSec) * time.Second,
+func NewQuoteService(deps QuoteServiceDeps, opts ...QuoteServiceOption) *QuoteService {
+	if deps.Validator == nil {
+		deps.Validator = NewValidator()
+	}
+	if deps.Logger == nil {
+		deps.Logger = zap.NewNop().Sugar()
+	}
+	probe := http.Client{Timeout: webhookProbeTimeout}
+	if deps.ProbeClient != nil {
+		probe = *deps.ProbeClient
+	}
+	// A redirect could lead the probe to a plain-HTTP or internal address that the URL
+	// check in RegisterWebhook never saw, so the first response is the answer.
+	probe.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	if deps.Events == nil {
+		deps.Events = NopQuoteEventPublisher{}
+	}
+	if deps.Clock == nil {
+		deps.Clock = clock.Real
 	}
+	if deps.Pairs == nil {
+		deps.Pairs = NewPairResolver(PairSettings{
+			LatestPriceTTL: time.Duration(deps.CacheConfig.LatestPriceTTLSec) * time.Second,
+		}, nil)
+	}
+	s := &QuoteService{
+		repo:             deps.Repo,
+		provider:         deps.Provider,
+		validator:        deps.Validator,
+		taskEnqueuer:     deps.Enqueuer,
+		cache:            deps.Cache,
+		keys:             deps.Namespace,
+		watcher:          deps.Watcher,
+		rateMoves:        deps.RateMoves,
+		events:           deps.Events,
+		pairs:            deps.Pairs,
+		webhookRepo:      deps.WebhookRepo,
+		webhookSecretKey: deps.WebhookSecretKey,
+		probeClient:      &probe,
+		log:              deps.Logger,
+		latestPriceTTL:   time.Duration(deps.CacheConfig.LatestPriceTTLSec) * time.Second,
+		negativeCacheTTL: time.Duration(deps.CacheConfig.NegativeCacheTTLSec) * time.Second,
+		inversePrecision: deps.CacheConfig.InversePrecision,
+		warmupRequired:   deps.CacheConfig.WarmupRequired,
+		clock:            deps.Clock,
+		instanceID:       deps.InstanceID,
+	}
+	if deps.CacheConfig.InverseEnabled {
+		s.inverseTTL = time.Duration(deps.CacheConfig.InverseTTLSec) * time.Second
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
-// RequestQuoteUpdate processes a request to update a quote asynchronously.
-func (s *QuoteService) RequestQuoteUpdate(ctx context.Context, pair string) (updateID, status string, err error) {
-	base, quote, err := ParsePair(pair)
+// RequestQuoteUpdate processes a request to update a quote asynchronously. A pair
+// outside the caller's PairAccess is rejected with ErrPairForbidden, an opts.Provider
+// that is not configured with an *UnknownProviderError, and ErrQueueFull is returned
+// while the WithPendingLimit cap is reached.
+func (s *QuoteService) RequestQuoteUpdate(ctx context.Context, rawPair string, opts UpdateOptions) (*UpdateRequestResult, error) {
+	assertCanonical(rawPair)
+	pair, err := ParsePair(rawPair)
 	if err != nil {
-		return "", "", err
+		return nil, err
 	}
 
-	if vErr := s.validatePair(base, quote); vErr != nil {
-		return "", "", vErr
+	if vErr := s.validatePair(pair); vErr != nil {
+		return nil, vErr
+	}
+	if err := checkPairAccess(ctx, pair); err != nil {
+		return nil, err
+	}
+	if opts.Provider != "" {
+		if _, err := s.namedProvider(opts.Provider); err != nil {
+			return nil, err
+		}
+	}
+
+	settings := s.pairs.Resolve(pair)
+	// A forced provider is an explicit request for that provider's rate, which a
+	// recent result from the facade does not answer.
+	if opts.Provider != "" {
+		settings.RefreshCooldown = 0
+	}
+	if recent := s.recentSuccess(ctx, pair, settings.RefreshCooldown); recent != nil {
+		return &UpdateRequestResult{
+			UpdateID:          recent.ID,
+			Status:            string(repository.StatusSuccess),
+			Reason:            ReuseReasonCooldownActive,
+			CooldownRemaining: settings.RefreshCooldown - clock.Since(s.clock, *recent.UpdatedAt),
+		}, nil
+	}
+
+	// Checked before CreateUpdate, so a saturated queue also refuses requests that
+	// would have been deduplicated onto an in-flight update.
+	if full, err := s.pending.full(ctx, s.repo); err != nil {
+		s.log.Warnw("Failed to count pending updates, skipping the cap", "error", err)
+	} else if full {
+		return nil, ErrQueueFull
 	}
 
 	uid := uuid.New().String()
-	id, err := s.repo.CreateUpdate(ctx, base, quote, uid)
+	created, err := s.repo.CreateUpdate(ctx, pair, uid, repository.OriginAPI)
 	if err != nil {
 		s.log.Errorw("CreateUpdate DB error", "error", err)
-		return "", "", ErrInternal
+		return nil, storageError(err)
 	}
-
-	if id != uid {
-		return id, string(repository.StatusPending), nil
+	id := created.ID
+
+	if !created.Created {
+		return &UpdateRequestResult{
+			UpdateID:  id,
+			Status:    string(repository.StatusPending),
+			Reason:    ReuseReasonPendingExists,
+			PollAfter: s.pollAfter(ctx, string(repository.StatusPending)),
+		}, nil
 	}
 
-	if err := s.enqueueUpdateTask(ctx, id, base, quote); err != nil {
-		return "", "", err
+	s.pending.added()
+	payload := UpdateQuotePayload{UpdateID: id, Pair: pair, Provider: opts.Provider}
+	if err := s.enqueueUpdateTask(ctx, payload, TaskOptions{Queue: settings.Queue}); err != nil {
+		return nil, err
 	}
 
-	s.log.Infow("Enqueued update task", "update_id", id, "pair", base+"/"+quote)
-	return id, string(repository.StatusPending), nil
+	s.log.Infow("Enqueued update task", fields.UpdateID(id), fields.Pair(pair.Base, pair.Quote), fields.Provider(opts.Provider))
+	return &UpdateRequestResult{
+		UpdateID:  id,
+		Status:    string(repository.StatusPending),
+		PollAfter: s.pollAfter(ctx, string(repository.StatusPending)),
+	}, nil
 }
 
-// GetQuoteResult retrieves the quote (price and status) for a given update ID.
+// GetQuoteResult retrieves the quote (price and status) for a given update ID. An
+// update for a pair outside the caller's PairAccess is reported as ErrNotFound, so its
+// existence is not revealed.
 func (s *QuoteService) GetQuoteResult(ctx context.Context, updateID string) (*QuoteResult, error) {
-	if _, err := uuid.Parse(updateID); err != nil {
+	uid, err := uuid.Parse(updateID)
+	if err != nil {
 		return nil, ErrInvalidUpdateID
 	}
+	if q, ok := s.cacheGetQuoteResult(ctx, uid.String()); ok {
+		if checkPairAccess(ctx, q.Pair()) != nil {
+			return nil, ErrNotFound
+		}
+		return quoteResultFromRepo(q), nil
+	}
+
 	q, err := s.repo.GetByID(ctx, updateID)
 	if err != nil {
-		s.log.Errorw("DB error fetching quote by ID", "update_id", updateID, "error", err)
-		return nil, ErrInternal
+		s.log.Errorw("DB error fetching quote by ID", fields.UpdateID(updateID), "error", err)
+		return nil, storageError(err)
 	}
 	if q == nil {
 		return nil, ErrNotFound
 	}
 
-	return quoteResultFromRepo(q), nil
+	s.cacheSetQuoteResult(ctx, q)
+	if checkPairAccess(ctx, q.Pair()) != nil {
+		return nil, ErrNotFound
+	}
+	result := quoteResultFromRepo(q)
+	result.PollAfter = s.pollAfter(ctx, result.Status)
+	return result, nil
+}
+
+// GetStatusEvents returns the status timeline of an update, oldest first. An unknown
+// update ID yields an empty timeline.
+func (s *QuoteService) GetStatusEvents(ctx context.Context, updateID string) ([]QuoteStatusEvent, error) {
+	uid, err := uuid.Parse(updateID)
+	if err != nil {
+		return nil, ErrInvalidUpdateID
+	}
+
+	events, err := s.repo.GetStatusEvents(ctx, uid.String())
+	if err != nil {
+		s.log.Errorw("DB error fetching status events", fields.UpdateID(updateID), "error", err)
+		return nil, storageError(err)
+	}
+	return statusEventsFromRepo(events), nil
 }
 
-// GetLatestQuote returns the latest successful quote for the given currency pair.
-func (s *QuoteService) GetLatestQuote(ctx context.Context, base, quote string) (*QuoteResult, error) {
-	base, quote, err := normalizePair(base, quote)
+// GetLatestQuote returns the latest successful quote for the given currency pair, or
+// ErrPairForbidden if the pair is outside the caller's PairAccess.
+func (s *QuoteService) GetLatestQuote(ctx context.Context, pair Pair) (*QuoteResult, error) {
+	assertCanonical(pair.Base, pair.Quote)
+	pair, err := NewPair(pair.Base, pair.Quote)
 	if err != nil {
 		return nil, err
 	}
 
-	if vErr := s.validatePair(base, quote); vErr != nil {
+	if vErr := s.validatePair(pair); vErr != nil {
 		return nil, vErr
 	}
+	if err := checkPairAccess(ctx, pair); err != nil {
+		return nil, err
+	}
 
-	if q, ok := s.cacheGetLatest(ctx, base, quote); ok {
-		return quoteResultFromRepo(q), nil
+	switch q, lookup := s.cacheGetLatest(ctx, pair); lookup {
+	case latestHit:
+		res := quoteResultFromRepo(q)
+		res.ServedFrom = ServedFromRedis
+		return res, nil
+	case latestMissing:
+		return nil, ErrNotFound
 	}
 
-	q, err := s.repo.GetLatestSuccess(ctx, base, quote)
+	q, err := s.repo.GetLatestSuccess(ctx, pair)
 	if err != nil {
-		s.log.Errorw("DB error fetching latest quote", "base", base, "quote", quote, "error", err)
-		return nil, ErrInternal
+		s.log.Errorw("DB error fetching latest quote", fields.Pair(pair.Base, pair.Quote), "error", err)
+		return nil, storageError(err)
 	}
 	if q == nil {
+		s.cacheSetLatestNotFound(ctx, pair)
 		return nil, ErrNotFound
 	}
 
 	s.cacheSetLatestFromQuote(ctx, q)
+	res := quoteResultFromRepo(q)
+	res.ServedFrom = ServedFromDB
+	return res, nil
+}
+
+// LatestQuote is one pair's answer from GetLatestQuotes.
+type LatestQuote struct {
+	Pair  Pair
+	Quote *QuoteResult // Nil if the pair has no successful quote.
+}
+
+// GetLatestQuotes returns the latest successful quote of each pair, in order. It reads
+// the cache for all pairs in one round trip and only asks the DB, once per distinct
+// pair, for those the cache knows nothing about; the answers are cached in one batch.
+// Pairs outside the caller's PairAccess are left out. A storage error fails the call.
+func (s *QuoteService) GetLatestQuotes(ctx context.Context, pairs []Pair) ([]LatestQuote, error) {
+	allowed := make([]Pair, 0, len(pairs))
+	for _, pair := range pairs {
+		assertCanonical(pair.Base, pair.Quote)
+		if vErr := s.validatePair(pair); vErr != nil {
+			return nil, vErr
+		}
+		if checkPairAccess(ctx, pair) == nil {
+			allowed = append(allowed, pair)
+		}
+	}
+
+	cached, lookups := s.cacheGetLatestBatch(ctx, allowed)
+	loaded := make(map[Pair]*repository.Quote)
+	var entries []latestEntry
+	for i, pair := range allowed {
+		if _, done := loaded[pair]; done || lookups[i] != latestUnknown {
+			continue
+		}
+		q, err := s.repo.GetLatestSuccess(ctx, pair)
+		if err != nil {
+			s.log.Errorw("DB error fetching latest quote", fields.Pair(pair.Base, pair.Quote), "error", err)
+			return nil, storageError(err)
+		}
+		loaded[pair] = q
+		if e, ok := latestEntryFromQuote(q); ok {
+			entries = append(entries, e)
+		} else {
+			s.cacheSetLatestNotFound(ctx, pair)
+		}
+	}
+	s.cacheSetLatestBatch(ctx, entries)
+
+	quotes := make([]LatestQuote, len(allowed))
+	for i, pair := range allowed {
+		q := cached[i]
+		if lookups[i] == latestUnknown {
+			q = loaded[pair]
+		}
+		quotes[i] = LatestQuote{Pair: pair}
+		if q != nil {
+			quotes[i].Quote = quoteResultFromRepo(q)
+		}
+	}
+	return quotes, nil
+}
+
+// GetLastAttempt returns the pair's most recent update of any status, so a caller that
+// found no latest quote can tell a pair never updated (ErrNotFound) from one whose
+// updates failed. It always reads the DB.
+func (s *QuoteService) GetLastAttempt(ctx context.Context, pair Pair) (*QuoteAttempt, error) {
+	assertCanonical(pair.Base, pair.Quote)
+	pair, err := NewPair(pair.Base, pair.Quote)
+	if err != nil {
+		return nil, err
+	}
+
+	if vErr := s.validatePair(pair); vErr != nil {
+		return nil, vErr
+	}
+	if err := checkPairAccess(ctx, pair); err != nil {
+		return nil, err
+	}
+
+	q, err := s.repo.GetLatestAny(ctx, pair)
+	if err != nil {
+		s.log.Errorw("DB error fetching last attempt", fields.Pair(pair.Base, pair.Quote), "error", err)
+		return nil, storageError(err)
+	}
+	if q == nil {
+		return nil, ErrNotFound
+	}
+	return quoteAttemptFromRepo(q), nil
+}
+
+// GetHistoricalRate returns the successful quote that was current for the pair at the given time.
+func (s *QuoteService) GetHistoricalRate(ctx context.Context, pair Pair, at time.Time) (*QuoteResult, error) {
+	assertCanonical(pair.Base, pair.Quote)
+	pair, err := NewPair(pair.Base, pair.Quote)
+	if err != nil {
+		return nil, err
+	}
+
+	if vErr := s.validatePair(pair); vErr != nil {
+		return nil, vErr
+	}
+	if err := checkPairAccess(ctx, pair); err != nil {
+		return nil, err
+	}
+
+	q, err := s.repo.GetPriceAtTime(ctx, pair, at)
+	if err != nil {
+		s.log.Errorw("DB error fetching historical quote", fields.Pair(pair.Base, pair.Quote), "at", at, "error", err)
+		return nil, storageError(err)
+	}
+	if q == nil {
+		return nil, ErrNotFound
+	}
+
 	return quoteResultFromRepo(q), nil
 }
 
 // ProcessUpdate performs the external fetch and updates the result (called by background worker).
-func (s *QuoteService) ProcessUpdate(ctx context.Context, updateID, base, quote string) error {
-	base, quote, err := normalizePair(base, quote)
+//
+// Every status transition is conditional on the version read at the start, so when
+// several tasks run for the same update only the first write wins. It returns
+// ErrAlreadyCompleted if another task finished the update first and ErrUpdateConflict
+// if another task is still working on it.
+//
+// When payload.Provider is set the rate is fetched from that provider alone, without
+// the facade's order, fallback or routing strategy.
+func (s *QuoteService) ProcessUpdate(ctx context.Context, payload UpdateQuotePayload) error {
+	updateID := payload.UpdateID
+	pair, err := NewPair(payload.Base, payload.Quote)
 	if err != nil {
 		return err
 	}
 
-	if vErr := s.validatePair(base, quote); vErr != nil {
-		s.completeFailure(ctx, updateID, vErr)
-		return vErr
+	rec, err := s.repo.GetByID(ctx, updateID)
+	if err != nil {
+		s.log.Errorw("DB error loading update", fields.UpdateID(updateID), "error", err)
+		return storageError(err)
+	}
+	if rec == nil {
+		return ErrNotFound
+	}
+	if rec.Status == repository.StatusSuccess {
+		return fmt.Errorf("%w: update %s is %s", ErrAlreadyCompleted, updateID, rec.Status)
 	}
+	version := rec.Version
 
-	s.log.Infow("Processing update", "update_id", updateID, "base", base, "quote", quote)
-	s.markRunning(ctx, updateID)
+	if vErr := s.validatePair(pair); vErr != nil {
+		return s.completeFailure(ctx, rec, version, pair, vErr)
+	}
+
+	s.log.Infow("Processing update", fields.UpdateID(updateID), fields.Pair(pair.Base, pair.Quote), fields.Provider(payload.Provider))
+	var prov provider.RatesProvider
+	if payload.Provider != "" {
+		// The provider may have been removed from the configuration since the task
+		// was enqueued.
+		if prov, err = s.namedProvider(payload.Provider); err != nil {
+			return s.completeFailure(ctx, rec, version, pair, err)
+		}
+	} else if prov = s.providerFor(pair); prov == nil {
+		// Known-down or no matching provider: fail without passing through RUNNING.
+		return s.completeFailure(ctx, rec, version, pair, ErrServiceUnavailable)
+	}
+	// A RUNNING record was left behind by an attempt that timed out or crashed; take
+	// it over at its current version.
+	if rec.Status != repository.StatusRunning {
+		if err := s.markRunning(ctx, updateID, version); err != nil {
+			return err
+		}
+		version++
+	}
 
-	rate, fetchedAt, err := s.provider.GetRate(ctx, base, quote)
+	order := s.pairs.Resolve(pair).ProviderOrder
+	var src provider.Source
+	start := s.clock.Now()
+	rate, fetchedAt, err := prov.GetRate(provider.WithSource(provider.WithProviderOrder(ctx, order), &src), pair.Base, pair.Quote)
+	fetch := repository.Fetch{Source: s.sourceName(src, prov, payload.Provider), Duration: clock.Since(s.clock, start)}
 	if err != nil {
-		s.completeFailure(ctx, updateID, err)
-		return err
+		if errors.Is(err, provider.ErrAllProvidersUnavailable) {
+			err = fmt.Errorf("%w: %w", ErrProviderUnavailable, err)
+		}
+		failErr := s.completeFailure(ctx, rec, version, pair, err)
+		if !errors.Is(failErr, ErrAlreadyCompleted) && !errors.Is(failErr, ErrUpdateConflict) {
+			s.recordFetch(ctx, updateID, fetch)
+			s.cacheDeleteQuoteResult(ctx, updateID)
+		}
+		return failErr
+	}
+
+	// The previous latest must be read before MarkSuccess replaces it.
+	prev := s.previousLatest(ctx, pair)
+
+	if err := s.repo.MarkSuccess(ctx, updateID, version, rate, fetchedAt); err != nil {
+		s.log.Errorw("DB update error on success", fields.UpdateID(updateID), "error", err)
+		return transitionError(updateID, err)
+	}
+
+	s.recordFetch(ctx, updateID, fetch)
+	// A GET /quotes/{id} while the update ran may have cached the previous attempt's
+	// FAILED record; it must not outlive the success.
+	s.cacheDeleteQuoteResult(ctx, updateID)
+	now := s.clock.Now()
+	s.cacheSetLatest(ctx, pair, updateID, rate, fetchedAt, now)
+	s.cacheSetInverse(ctx, pair, rate, fetchedAt, now)
+	observeUpdate(repository.StatusSuccess, rec.Origin)
+	s.log.Infow("Update success", fields.UpdateID(updateID), "rate", rate)
+	s.publishSuccess(ctx, updateID, pair, UpdateSourceProvider, payload.Provider, rate, fetchedAt)
+
+	if prev != nil && prev.Price != nil {
+		s.rateMoves.ObserveRateMove(ctx, pair.Base, pair.Quote, *prev.Price, rate, rateTime(prev), fetchedAt)
+	}
+	s.verifySpread(ctx, updateID, pair)
+	return nil
+}
+
+// recordFetch stores which provider served a completed update and how long it took,
+// for the reliability report. Like the spread, it never fails the update.
+func (s *QuoteService) recordFetch(ctx context.Context, updateID string, f repository.Fetch) {
+	if err := s.repo.RecordFetch(ctx, updateID, f); err != nil {
+		s.log.Warnw("Failed to record fetch", fields.UpdateID(updateID), "error", err)
+	}
+}
+
+// verifySpread records the spread across providers for a successful update when a
+// SpreadVerifier is configured. It runs after the result is stored and published, so
+// it never delays or fails the update.
+func (s *QuoteService) verifySpread(ctx context.Context, updateID string, pair Pair) {
+	if s.verifier == nil {
+		return
+	}
+	v := s.verifier.Verify(ctx, pair)
+	if v == nil {
+		return
+	}
+	if err := s.repo.SaveVerification(ctx, updateID, *v); err != nil {
+		s.log.Warnw("Failed to save provider spread", fields.UpdateID(updateID), "error", err)
+		return
+	}
+	s.cacheDeleteQuoteResult(ctx, updateID)
+}
+
+// providerFor returns the provider that should serve pair, or nil if none is
+// available. Providers whose circuit breaker is open are not offered to the routing
+// strategy.
+func (s *QuoteService) providerFor(pair Pair) provider.RatesProvider {
+	if s.routing == nil {
+		if !provider.IsAvailable(s.provider, pair.Base, pair.Quote) {
+			return nil
+		}
+		return s.provider
+	}
+
+	candidates := []provider.RatesProvider{s.provider}
+	if f, ok := s.provider.(*provider.ExchangeProviderFacade); ok {
+		candidates = f.Providers()
+	}
+	available := slices.DeleteFunc(candidates, func(p provider.RatesProvider) bool {
+		return !provider.IsAvailable(p, pair.Base, pair.Quote)
+	})
+	return s.routing.SelectProvider(pair.Base, pair.Quote, available)
+}
+
+// namedProvider returns the configured provider called name, bypassing the facade,
+// or an *UnknownProviderError listing the configured names.
+func (s *QuoteService) namedProvider(name string) (provider.RatesProvider, error) {
+	var valid []string
+	if f, ok := s.provider.(*provider.ExchangeProviderFacade); ok {
+		for _, np := range f.NamedProviders() {
+			if np.Name == name {
+				return np.Provider, nil
+			}
+			valid = append(valid, np.Name)
+		}
+	}
+	return nil, &UnknownProviderError{Name: name, Valid: valid}
+}
+
+// sourceName returns the configured name of the provider that served a GetRate call
+// on prov, as reported to src, or "" if it has none. forced is the provider the update
+// asked for, if any.
+func (s *QuoteService) sourceName(src provider.Source, prov provider.RatesProvider, forced string) string {
+	switch {
+	case forced != "":
+		return forced
+	case src.Name != "":
+		return src.Name
+	case src.Provider != nil:
+		// An unnamed facade, such as the fiat facade built by PrefixRoutingStrategy.
+		prov = src.Provider
+	}
+	f, ok := s.provider.(*provider.ExchangeProviderFacade)
+	if !ok {
+		return ""
+	}
+	// Providers behind a facade are comparable, as the routing strategies require.
+	for _, np := range f.NamedProviders() {
+		if np.Provider == prov {
+			return np.Name
+		}
+	}
+	return ""
+}
+
+// previousLatest returns the current latest successful quote for the pair when a
+// RateMoveObserver is configured, preferring the cache over the DB.
+func (s *QuoteService) previousLatest(ctx context.Context, pair Pair) *repository.Quote {
+	if s.rateMoves == nil {
+		return nil
+	}
+	switch q, lookup := s.cacheGetLatest(ctx, pair); lookup {
+	case latestHit:
+		return q
+	case latestMissing:
+		return nil
+	}
+	q, err := s.repo.GetLatestSuccess(ctx, pair)
+	if err != nil {
+		s.log.Warnw("Failed to load previous rate for move check", fields.Pair(pair.Base, pair.Quote), "error", err)
+		return nil
+	}
+	return q
+}
+
+// rateTime returns when the provider published q's rate, falling back to the write time.
+func rateTime(q *repository.Quote) time.Time {
+	switch {
+	case q.RateTimestamp != nil:
+		return *q.RateTimestamp
+	case q.UpdatedAt != nil:
+		return *q.UpdatedAt
+	default:
+		return time.Time{}
 	}
+}
 
-	if err := s.repo.MarkSuccess(ctx, updateID, rate); err != nil {
-		s.log.Errorw("DB update error on success", "update_id", updateID, "error", err)
+// ApplyStreamedRate records a rate pushed by a streaming provider: it stores a SUCCESS
+// record and refreshes the latest-price cache, like ProcessUpdate does for polled rates.
+// If an update for the pair is already in flight, only the cache is refreshed so the
+// pending task is left to the worker.
+func (s *QuoteService) ApplyStreamedRate(ctx context.Context, pair Pair, rate string, receivedAt time.Time) error {
+	pair, err := NewPair(pair.Base, pair.Quote)
+	if err != nil {
 		return err
 	}
+	if vErr := s.validatePair(pair); vErr != nil {
+		return vErr
+	}
+
+	uid := uuid.New().String()
+	created, err := s.repo.CreateUpdate(ctx, pair, uid, repository.OriginStream)
+	if err != nil {
+		s.log.Errorw("CreateUpdate DB error for streamed rate", fields.Pair(pair.Base, pair.Quote), "error", err)
+		return storageError(err)
+	}
+	// Without a record of its own the streamed rate is cached without an update_id.
+	var storedID string
+	if id := created.ID; created.Created {
+		if err := s.repo.MarkRunning(ctx, id, repository.InitialVersion, s.instanceID); err != nil {
+			s.log.Errorw("DB update error on streamed rate", fields.UpdateID(id), "error", err)
+			return storageError(err)
+		}
+		if err := s.repo.MarkSuccess(ctx, id, repository.InitialVersion+1, rate, receivedAt); err != nil {
+			s.log.Errorw("DB update error on streamed rate", fields.UpdateID(id), "error", err)
+			return storageError(err)
+		}
+		observeUpdate(repository.StatusSuccess, repository.OriginStream)
+		s.publishSuccess(ctx, id, pair, UpdateSourceStream, "", rate, receivedAt)
+		storedID = id
+	}
 
-	s.cacheSetLatest(ctx, base, quote, rate, fetchedAt)
-	s.log.Infow("Update success", "update_id", updateID, "rate", rate)
+	s.cacheSetLatest(ctx, pair, storedID, rate, receivedAt, s.clock.Now())
 	return nil
 }
 
-func (s *QuoteService) enqueueUpdateTask(ctx context.Context, updateID, base, quote string) error {
-	payload := UpdateQuotePayload{
-		UpdateID: updateID,
-		Base:     base,
-		Quote:    quote,
+// compensationTimeout bounds the cleanup that runs after a failed enqueue.
+const compensationTimeout = 2 * time.Second
+
+// recentSuccess returns the pair's latest successful update if it is younger than
+// cooldown, so a new request can be answered without another provider call.
+func (s *QuoteService) recentSuccess(ctx context.Context, pair Pair, cooldown time.Duration) *repository.Quote {
+	if cooldown <= 0 {
+		return nil
 	}
+	q, err := s.repo.GetLatestSuccess(ctx, pair)
+	if err != nil {
+		s.log.Warnw("Failed to check refresh cooldown", fields.Pair(pair.Base, pair.Quote), "error", err)
+		return nil
+	}
+	if q == nil || q.UpdatedAt == nil || clock.Since(s.clock, *q.UpdatedAt) >= cooldown {
+		return nil
+	}
+	return q
+}
 
-	if err := s.taskEnqueuer.EnqueueUpdateTask(ctx, payload); err != nil {
-		s.log.Errorw("Failed to enqueue task", "update_id", updateID, "error", err)
-		s.markFailed(ctx, updateID, "enqueue error")
+func (s *QuoteService) enqueueUpdateTask(ctx context.Context, payload UpdateQuotePayload, opts TaskOptions) error {
+	updateID, pair := payload.UpdateID, payload.Pair
+	if err := s.taskEnqueuer.EnqueueUpdateTask(ctx, payload, opts); err != nil {
+		s.log.Errorw("Failed to enqueue task", fields.UpdateID(updateID), "error", err)
+		// The request may already be cancelled; the PENDING record must still be
+		// released, or the pair's retry would be deduplicated onto a dead update.
+		cctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), compensationTimeout)
+		defer cancel()
+		const reason = "enqueue error"
+		if s.markFailed(cctx, updateID, repository.ErrorCodeInternal, reason) {
+			observeFailure(repository.OriginAPI, repository.ErrorCodeInternal)
+			s.publishFailure(cctx, updateID, pair, UpdateSourceEnqueue, repository.ErrorCodeInternal, reason)
+		}
 		return ErrInternalQueue
 	}
 	return nil
 }
 
-func (s *QuoteService) markFailed(ctx context.Context, updateID, reason string) {
-	if err := s.repo.MarkFailed(ctx, updateID, reason); err != nil {
-		s.log.Warnw("Failed to mark record as FAILED", "update_id", updateID, "error", err)
+// RequeueUpdate enqueues a new task for the PENDING update rec, e.g. after its task
+// was lost. A forced provider is not stored with the update, so the new task uses the
+// pair's provider order. Unlike a failed first enqueue, a failure leaves the update
+// PENDING so a later attempt can requeue it.
+func (s *QuoteService) RequeueUpdate(ctx context.Context, rec repository.Quote) error {
+	pair := rec.Pair()
+	payload := UpdateQuotePayload{UpdateID: rec.ID, Pair: pair}
+	if err := s.taskEnqueuer.EnqueueUpdateTask(ctx, payload, TaskOptions{Queue: s.pairs.Resolve(pair).Queue}); err != nil {
+		s.log.Errorw("Failed to requeue update", fields.UpdateID(rec.ID), "error", err)
+		return ErrInternalQueue
+	}
+	s.log.Infow("Requeued update task", fields.UpdateID(rec.ID), fields.Pair(pair.Base, pair.Quote))
+	return nil
+}
+
+// markFailed fails an update that was just created and never reached a worker.
+func (s *QuoteService) markFailed(ctx context.Context, updateID string, code repository.ErrorCode, reason string) bool {
+	if err := s.repo.MarkFailed(ctx, updateID, repository.InitialVersion, code, reason); err != nil {
+		s.log.Warnw("Failed to mark record as FAILED", fields.UpdateID(updateID), "error", err)
+		return false
+	}
+	return true
+}
+
+// FailUpdate marks an unfinished update FAILED with code and reason at whatever version
+// it has, e.g. after its task panicked. It returns ErrNotFound for an unknown update and
+// ErrAlreadyCompleted if the update has already finished.
+func (s *QuoteService) FailUpdate(ctx context.Context, updateID string, code repository.ErrorCode, reason string) error {
+	rec, err := s.repo.GetByID(ctx, updateID)
+	if err != nil {
+		s.log.Errorw("DB error loading update", fields.UpdateID(updateID), "error", err)
+		return storageError(err)
+	}
+	if rec == nil {
+		return ErrNotFound
+	}
+	if isTerminal(rec.Status) {
+		return fmt.Errorf("%w: update %s is %s", ErrAlreadyCompleted, updateID, rec.Status)
+	}
+	if err := s.repo.MarkFailed(ctx, updateID, rec.Version, code, reason); err != nil {
+		s.log.Warnw("Failed to mark record as FAILED", fields.UpdateID(updateID), "error", err)
+		return transitionError(updateID, err)
+	}
+	s.cacheDeleteQuoteResult(ctx, updateID)
+	observeFailure(rec.Origin, code)
+	s.publishFailure(ctx, updateID, rec.Pair(), UpdateSourceWorker, code, reason)
+	return nil
+}
+
+// ResetUpdate returns a FAILED or RUNNING update to PENDING before its task is run
+// again, e.g. when an archived task is retried. A PENDING update is left as it is. It
+// returns ErrNotFound for an unknown update and ErrAlreadyCompleted for a SUCCESS one,
+// and an *UpdateInFlightError for a FAILED one whose pair has another update in flight.
+func (s *QuoteService) ResetUpdate(ctx context.Context, updateID string) error {
+	rec, err := s.repo.GetByID(ctx, updateID)
+	if err != nil {
+		s.log.Errorw("DB error loading update", fields.UpdateID(updateID), "error", err)
+		return storageError(err)
+	}
+	if rec == nil {
+		return ErrNotFound
+	}
+	switch rec.Status {
+	case repository.StatusSuccess:
+		return fmt.Errorf("%w: update %s is %s", ErrAlreadyCompleted, updateID, rec.Status)
+	case repository.StatusPending:
+		return nil
+	}
+	if err := s.repo.MarkPending(ctx, updateID, rec.Version); err != nil {
+		s.log.Warnw("Failed to mark record as PENDING", fields.UpdateID(updateID), "error", err)
+		return transitionError(updateID, err)
 	}
+	s.cacheDeleteQuoteResult(ctx, updateID)
+	s.log.Infow("Reset update to PENDING", fields.UpdateID(updateID), "from", rec.Status)
+	return nil
+}
+
+func (s *QuoteService) markRunning(ctx context.Context, updateID string, version int64) error {
+	// An Asynq retry moves a FAILED record back to RUNNING, so drop any cached terminal
+	// result, and again after the transition: a read in between caches the FAILED row.
+	s.cacheDeleteQuoteResult(ctx, updateID)
+	if err := s.repo.MarkRunning(ctx, updateID, version, s.instanceID); err != nil {
+		s.log.Warnw("Failed to mark record as RUNNING", fields.UpdateID(updateID), "error", err)
+		return transitionError(updateID, err)
+	}
+	s.cacheDeleteQuoteResult(ctx, updateID)
+	return nil
 }
 
-func (s *QuoteService) markRunning(ctx context.Context, updateID string) {
-	if err := s.repo.MarkRunning(ctx, updateID); err != nil {
-		s.log.Warnw("Failed to mark record as RUNNING", "update_id", updateID, "error", err)
+// completeFailure marks the update rec FAILED and returns the error ProcessUpdate
+// should report: cause, unless another task changed the record first.
+func (s *QuoteService) completeFailure(ctx context.Context, rec *repository.Quote, version int64, pair Pair, cause error) error {
+	updateID := rec.ID
+	code := errorCodeOf(cause)
+	s.log.Errorw("Provider error", fields.UpdateID(updateID), "error_code", code, "error", cause)
+	if err := s.repo.MarkFailed(ctx, updateID, version, code, cause.Error()); err != nil {
+		s.log.Warnw("Failed to mark record as FAILED after provider error", fields.UpdateID(updateID), "error", err)
+		if errors.Is(err, repository.ErrVersionConflict) || errors.Is(err, repository.ErrInvalidTransition) ||
+			errors.Is(err, repository.ErrQuoteNotFound) {
+			return transitionError(updateID, err)
+		}
+		return cause
 	}
+	s.cacheDeleteQuoteResult(ctx, updateID)
+	observeFailure(rec.Origin, code)
+	s.publishFailure(ctx, updateID, pair, UpdateSourceProvider, code, cause.Error())
+	return cause
 }
 
-func (s *QuoteService) completeFailure(ctx context.Context, updateID string, cause error) {
-	s.log.Errorw("Provider error", "update_id", updateID, "error", cause)
-	if err := s.repo.MarkFailed(ctx, updateID, cause.Error()); err != nil {
-		s.log.Warnw("Failed to mark record as FAILED after provider error", "update_id", updateID, "error", err)
+// observeUpdate counts an update that reached status. Records read before origins were
+// recorded have none and count as OriginAPI, like the migration's default.
+func observeUpdate(status repository.Status, origin repository.Origin) {
+	if origin == "" {
+		origin = repository.OriginAPI
+	}
+	metrics.QuoteUpdatesTotal.WithLabelValues(string(status), string(origin)).Inc()
+}
+
+// observeFailure counts an update that FAILED with code.
+func observeFailure(origin repository.Origin, code repository.ErrorCode) {
+	observeUpdate(repository.StatusFailed, origin)
+	metrics.QuoteUpdateFailuresTotal.WithLabelValues(string(code)).Inc()
+}
+
+// transitionError maps a failed status transition to the error ProcessUpdate returns.
+// A record that is already SUCCESS or FAILED, whether another task changed it or the
+// task is a replay, was finished elsewhere and is reported as ErrAlreadyCompleted.
+// Other conflicts and transitions the record's status does not allow are
+// ErrUpdateConflict.
+func transitionError(updateID string, err error) error {
+	var (
+		conflict *repository.VersionConflictError
+		invalid  *repository.InvalidTransitionError
+		inFlight *repository.PairInFlightError
+	)
+	switch {
+	case errors.As(err, &inFlight):
+		return &UpdateInFlightError{UpdateID: updateID, InFlightID: inFlight.InFlightID}
+	case errors.As(err, &conflict) && isTerminal(conflict.Status):
+		return fmt.Errorf("%w: update %s is %s", ErrAlreadyCompleted, updateID, conflict.Status)
+	case errors.As(err, &invalid) && isTerminal(invalid.From):
+		return fmt.Errorf("%w: update %s is %s", ErrAlreadyCompleted, updateID, invalid.From)
+	case errors.Is(err, repository.ErrVersionConflict), errors.Is(err, repository.ErrInvalidTransition):
+		return fmt.Errorf("%w: %w", ErrUpdateConflict, err)
+	case errors.Is(err, repository.ErrQuoteNotFound):
+		return ErrNotFound
+	default:
+		return err
 	}
 }
 
@@ -204,13 +921,13 @@ const TaskTypeUpdateQuote = "quote:update"
 // UpdateQuotePayload is the payload structure for quote update Asynq tasks.
 type UpdateQuotePayload struct {
 	UpdateID string `json:"update_id"`
-	Base     string `json:"base"`
-	Quote    string `json:"quote"`
+	Pair            // Encoded as the separate "base" and "quote" fields.
+	Provider string `json:"provider,omitempty"` // Forced provider name; empty uses the facade.
 }
 
-func (s *QuoteService) validatePair(base, quote string) error {
-	if err := s.validator.Validate(base); err != nil {
+func (s *QuoteService) validatePair(pair Pair) error {
+	if err := s.validator.Validate(pair.Base); err != nil {
 		return err
 	}
-	return s.validator.Validate(quote)
+	return s.validator.Validate(pair.Quote)
 }
@@ -2,25 +2,190 @@ package service
 
 import (
 	"context"
+	"errors"
+	"math/big"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	"quoteservice/internal/logging/fields"
 	"quoteservice/internal/repository"
 )
 
-const cacheKeyPrefixLatest = "latest:"
+const (
+	cacheKeyPrefixLatest      = "latest:"
+	cacheKeyPrefixQuoteResult = "quote_result:"
+)
+
+// derivedInverse marks a latest price computed as the inverse of the pair's inverse
+// rather than fetched, in the derived field of the latest hash.
+const derivedInverse = "inverse"
+
+// setLatestScript writes the latest price of a pair unless the cached one was observed
+// later, so concurrent writers (a worker, a stream, a read-through from the DB) cannot
+// roll the price back. Timestamps are compared as strings: storedTimeLayout is fixed
+// width, and an entry in another layout is overwritten. A write also drops the
+// not-found marker, and the update_id of the previous price if the new one has none.
+//
+// A derived price never replaces a fetched one, and a fetched price replaces a derived
+// one whatever their timestamps; derived prices are only compared with each other.
+//
+// KEYS: latest hash, not-found marker. ARGV: price, updated_at, rate_timestamp, TTL in
+// ms, update_id (empty if the price has no update record), derived (empty if fetched).
+var setLatestScript = redis.NewScript(`
+local stored = redis.call('HMGET', KEYS[1], 'rate_timestamp', 'derived')
+local derived = ARGV[6] ~= ''
+if stored[1] then
+	if derived and not stored[2] then
+		return 0
+	end
+	if (derived or not stored[2]) and #stored[1] == #ARGV[3] and stored[1] > ARGV[3] then
+		return 0
+	end
+end
+redis.call('HSET', KEYS[1], 'price', ARGV[1], 'updated_at', ARGV[2], 'rate_timestamp', ARGV[3])
+if ARGV[5] == '' then
+	redis.call('HDEL', KEYS[1], 'update_id')
+else
+	redis.call('HSET', KEYS[1], 'update_id', ARGV[5])
+end
+if derived then
+	redis.call('HSET', KEYS[1], 'derived', ARGV[6])
+else
+	redis.call('HDEL', KEYS[1], 'derived')
+end
+redis.call('PEXPIRE', KEYS[1], ARGV[4])
+redis.call('DEL', KEYS[2])
+return 1
+`)
 
-func latestCacheKey(base, quote string) string {
-	return cacheKeyPrefixLatest + "{" + base + ":" + quote + "}"
+// setLatestNotFoundScript writes the not-found marker only while the pair has no latest
+// hash: a lookup that found nothing in the DB can finish after a success has cached its
+// price, and must not hide it.
+//
+// KEYS: latest hash, not-found marker. ARGV: TTL in ms.
+var setLatestNotFoundScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 1 then
+	return 0
+end
+redis.call('SET', KEYS[2], '1', 'PX', ARGV[1])
+return 1
+`)
+
+func (s *QuoteService) latestCacheKey(pair Pair) string {
+	return s.keys.Key(cacheKeyPrefixLatest + pair.CacheKey())
 }
 
-func (s *QuoteService) cacheGetLatest(ctx context.Context, base, quote string) (*repository.Quote, bool) {
-	if s.cache == nil {
-		return nil, false
+// latestNotFoundCacheKey marks a pair with no successful quote; it shares the hash slot of latestCacheKey.
+func (s *QuoteService) latestNotFoundCacheKey(pair Pair) string {
+	return s.latestCacheKey(pair) + ":notfound"
+}
+
+func (s *QuoteService) quoteResultCacheKey(id string) string {
+	return s.keys.Key(cacheKeyPrefixQuoteResult + "{" + id + "}")
+}
+
+// WarmCache preloads the latest-price cache from the DB for the given "BASE/QUOTE" pairs,
+// writing all of them in one batch. Each pair is loaded once however many times and
+// spellings it is listed in. Pairs that are invalid or fail to load are logged and
+// skipped; the service is marked as warmed once the pass completes, even partially.
+func (s *QuoteService) WarmCache(ctx context.Context, pairs []string) {
+	defer s.cacheWarmed.Store(true)
+
+	parsed, unique := CanonicalizePairs(pairs)
+	for i, e := range parsed {
+		if e.Err != nil {
+			s.log.Warnw("Skipping invalid warmup pair", zap.String(fields.KeyPair, pairs[i]), "error", e.Err)
+		}
 	}
+	var entries []latestEntry
+	for _, p := range unique {
+		if ctx.Err() != nil {
+			break
+		}
+		q, err := s.repo.GetLatestSuccess(ctx, p)
+		if err != nil {
+			s.log.Warnw("Cache warmup failed for pair", fields.Pair(p.Base, p.Quote), "error", err)
+			continue
+		}
+		if e, ok := latestEntryFromQuote(q); ok {
+			entries = append(entries, e)
+		}
+	}
+	s.cacheSetLatestBatch(ctx, entries)
+	s.log.Infow("Cache warmup finished", "pairs", len(pairs), "warmed", len(entries))
+}
+
+// SkipCacheWarmup marks the service as warmed without loading the cache, for when
+// another instance is warming the shared cache.
+func (s *QuoteService) SkipCacheWarmup() {
+	s.cacheWarmed.Store(true)
+}
+
+// IsReady reports whether the service may receive traffic. It is always true unless
+// cache warmup is required, in which case it turns true once WarmCache has finished.
+func (s *QuoteService) IsReady() bool {
+	return !s.warmupRequired || s.cacheWarmed.Load()
+}
+
+// latestLookup is the outcome of a latest-price cache read.
+type latestLookup int
+
+const (
+	latestUnknown latestLookup = iota // Nothing usable is cached, or Redis failed: ask the DB.
+	latestHit                         // The pair's latest successful quote is cached.
+	latestMissing                     // Negatively cached: the DB recently had no successful quote.
+)
+
+// cacheGetLatest reads the latest quote hash and the negative marker of the pair in a
+// single pipelined round trip. The quote is non-nil only for latestHit.
+func (s *QuoteService) cacheGetLatest(ctx context.Context, pair Pair) (*repository.Quote, latestLookup) {
+	quotes, lookups := s.cacheGetLatestBatch(ctx, []Pair{pair})
+	return quotes[0], lookups[0]
+}
+
+// cacheGetLatestBatch is cacheGetLatest for several pairs in one round trip. Both
+// results have one element per pair, in order.
+func (s *QuoteService) cacheGetLatestBatch(ctx context.Context, pairs []Pair) ([]*repository.Quote, []latestLookup) {
+	quotes := make([]*repository.Quote, len(pairs))
+	lookups := make([]latestLookup, len(pairs))
+	if s.cache == nil || len(pairs) == 0 {
+		return quotes, lookups
+	}
+
+	pipe := s.cache.Pipeline()
+	notFound := make([]*redis.IntCmd, len(pairs))
+	hmget := make([]*redis.SliceCmd, len(pairs))
+	for i, pair := range pairs {
+		notFound[i] = pipe.Exists(ctx, s.latestNotFoundCacheKey(pair))
+		hmget[i] = pipe.HMGet(ctx, s.latestCacheKey(pair), "price", "updated_at", "rate_timestamp", "update_id", "derived")
+	}
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return quotes, lookups
+	}
+	for i, pair := range pairs {
+		// A price outranks a marker that outlived it; setLatestScript drops the marker
+		// on every write, so both only coexist briefly.
+		if q, ok := cachedLatestQuote(pair, hmget[i].Val()); ok {
+			quotes[i], lookups[i] = q, latestHit
+			continue
+		}
+		if notFound[i].Val() > 0 {
+			lookups[i] = latestMissing
+		}
+	}
+	return quotes, lookups
+}
 
-	key := latestCacheKey(base, quote)
-	vals, err := s.cache.HMGet(ctx, key, "price", "updated_at").Result()
-	if err != nil || len(vals) != 2 || vals[0] == nil || vals[1] == nil {
+// cachedLatestQuote decodes the price, updated_at, rate_timestamp, update_id and derived
+// fields of a latest quote hash; ok is false if any of the first three is missing or
+// malformed. update_id is absent for prices cached without an update record and by
+// older versions, derived for fetched prices.
+func cachedLatestQuote(pair Pair, vals []any) (*repository.Quote, bool) {
+	if len(vals) != 5 || vals[0] == nil || vals[1] == nil || vals[2] == nil {
 		return nil, false
 	}
 
@@ -28,39 +193,273 @@ func (s *QuoteService) cacheGetLatest(ctx context.Context, base, quote string) (
 	if !ok {
 		return nil, false
 	}
-	ts, ok := asString(vals[1])
+	updatedAt, ok := cachedTime(vals[1])
 	if !ok {
 		return nil, false
 	}
-	t, err := timeParse(ts)
-	if err != nil {
+	rateTimestamp, ok := cachedTime(vals[2])
+	if !ok {
 		return nil, false
 	}
 
+	id, _ := asString(vals[3])
+	derived, _ := asString(vals[4])
 	return &repository.Quote{
-		Base:      base,
-		Quote:     quote,
-		Status:    repository.StatusSuccess,
-		Price:     &price,
-		UpdatedAt: &t,
+		ID:            id,
+		Base:          pair.Base,
+		Quote:         pair.Quote,
+		Status:        repository.StatusSuccess,
+		Price:         &price,
+		UpdatedAt:     &updatedAt,
+		RateTimestamp: &rateTimestamp,
+		Derived:       derived,
 	}, true
 }
 
-func (s *QuoteService) cacheSetLatestFromQuote(ctx context.Context, q *repository.Quote) {
+// latestEntry is a pair's latest price as written to the latest cache. id is the
+// update that stored the price; empty if there is none. derived is empty for a fetched
+// price and derivedInverse for one computed from the inverse pair's.
+type latestEntry struct {
+	pair          Pair
+	id            string
+	rate          string
+	rateTimestamp time.Time
+	updatedAt     time.Time
+	derived       string
+}
+
+// latestEntryFromQuote returns the latest cache entry of a SUCCESS record; ok is false
+// for a record without a price.
+func latestEntryFromQuote(q *repository.Quote) (e latestEntry, ok bool) {
 	if q == nil || q.Price == nil || q.UpdatedAt == nil {
+		return latestEntry{}, false
+	}
+	rateTimestamp := *q.UpdatedAt
+	if q.RateTimestamp != nil {
+		rateTimestamp = *q.RateTimestamp
+	}
+	return latestEntry{pair: q.Pair(), id: q.ID, rate: *q.Price, rateTimestamp: rateTimestamp, updatedAt: *q.UpdatedAt}, true
+}
+
+func (s *QuoteService) cacheSetLatestFromQuote(ctx context.Context, q *repository.Quote) {
+	if e, ok := latestEntryFromQuote(q); ok {
+		s.cacheSetLatest(ctx, e.pair, e.id, e.rate, e.rateTimestamp, e.updatedAt)
+	}
+}
+
+// cacheSetLatest caches rate as the latest price of pair, stored by update id.
+func (s *QuoteService) cacheSetLatest(ctx context.Context, pair Pair, id, rate string, rateTimestamp, updatedAt time.Time) {
+	if s.cache == nil {
+		return
+	}
+	s.cacheSetLatestEntry(ctx, latestEntry{pair: pair, id: id, rate: rate, rateTimestamp: rateTimestamp, updatedAt: updatedAt})
+}
+
+func (s *QuoteService) cacheSetLatestEntry(ctx context.Context, e latestEntry) {
+	keys, args := s.latestScriptArgs(e)
+	s.logLatestWrite(e, setLatestScript.Run(ctx, s.cache, keys, args...))
+}
+
+// cacheSetInverse caches the inverse of rate, the latest price of pair, as the derived
+// latest price of the inverse pair, so its lookups are cache hits without an update of
+// their own. It does nothing unless inverse prices are enabled.
+func (s *QuoteService) cacheSetInverse(ctx context.Context, pair Pair, rate string, rateTimestamp, updatedAt time.Time) {
+	if s.cache == nil || s.inverseTTL <= 0 {
+		return
+	}
+	inverse, ok := inverseRate(rate, s.inversePrecision)
+	if !ok {
+		s.log.Debugw("Skipped inverse price", fields.Pair(pair.Base, pair.Quote), "rate", rate)
+		return
+	}
+	s.cacheSetLatestEntry(ctx, latestEntry{pair: pair.Inverse(), rate: inverse, rateTimestamp: rateTimestamp,
+		updatedAt: updatedAt, derived: derivedInverse})
+}
+
+// inverseRate returns 1/rate rounded to precision decimal places; ok is false if rate is
+// not a positive decimal or its inverse rounds to zero.
+func inverseRate(rate string, precision int) (string, bool) {
+	r, ok := new(big.Rat).SetString(rate)
+	if !ok || r.Sign() <= 0 {
+		return "", false
+	}
+	inverse := r.Inv(r).FloatString(precision)
+	if strings.Trim(inverse, "0.") == "" {
+		return "", false
+	}
+	return inverse, true
+}
+
+// cacheSetLatestBatch writes the latest price of every entry like cacheSetLatest, but
+// in a single pipeline. EVALSHA is pipelined, so entries the pipeline could not write,
+// e.g. because Redis restarted and lost the script, are retried one by one with
+// cacheSetLatest, which loads it again.
+func (s *QuoteService) cacheSetLatestBatch(ctx context.Context, entries []latestEntry) {
+	if s.cache == nil || len(entries) == 0 {
 		return
 	}
-	s.cacheSetLatest(ctx, q.Base, q.Quote, *q.Price, *q.UpdatedAt)
+
+	pipe := s.cache.Pipeline()
+	cmds := make([]*redis.Cmd, len(entries))
+	for i, e := range entries {
+		keys, args := s.latestScriptArgs(e)
+		cmds[i] = setLatestScript.EvalSha(ctx, pipe, keys, args...)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		s.log.Debugw("Batched latest cache write failed, writing pairs one by one", "pairs", len(entries), "error", err)
+	}
+	for i, cmd := range cmds {
+		if cmd.Err() != nil {
+			s.cacheSetLatestEntry(ctx, entries[i])
+			continue
+		}
+		s.logLatestWrite(entries[i], cmd)
+	}
 }
 
-func (s *QuoteService) cacheSetLatest(ctx context.Context, base, quote, rate string, t time.Time) {
+// latestScriptArgs returns the keys and arguments of setLatestScript for e.
+// A derived price expires after the shorter of the inverse TTL and the pair's own.
+func (s *QuoteService) latestScriptArgs(e latestEntry) (keys []string, args []any) {
+	ttl := s.pairs.Resolve(e.pair).LatestPriceTTL
+	if e.derived != "" {
+		ttl = min(ttl, s.inverseTTL)
+	}
+	return []string{s.latestCacheKey(e.pair), s.latestNotFoundCacheKey(e.pair)},
+		[]any{e.rate, formatStoredTime(e.updatedAt), formatStoredTime(e.rateTimestamp),
+			ttl.Milliseconds(), e.id, e.derived}
+}
+
+func (s *QuoteService) logLatestWrite(e latestEntry, cmd *redis.Cmd) {
+	key := s.latestCacheKey(e.pair)
+	written, err := cmd.Int()
+	if err != nil {
+		s.log.Warnw("Failed to update cache", "key", key, "error", err)
+		return
+	}
+	if written == 0 {
+		s.log.Debugw("Skipped stale latest price", "key", key, "rate_timestamp", e.rateTimestamp)
+	}
+}
+
+// cacheSetLatestNotFound negatively caches a pair that has no successful quote yet,
+// unless a latest price was cached in the meantime.
+func (s *QuoteService) cacheSetLatestNotFound(ctx context.Context, pair Pair) {
+	if s.cache == nil || s.negativeCacheTTL <= 0 {
+		return
+	}
+	key := s.latestNotFoundCacheKey(pair)
+	keys := []string{s.latestCacheKey(pair), key}
+	if err := setLatestNotFoundScript.Run(ctx, s.cache, keys, s.negativeCacheTTL.Milliseconds()).Err(); err != nil {
+		s.log.Warnw("Failed to update cache", "key", key, "error", err)
+	}
+}
+
+// cacheGetQuoteResult returns a terminal (SUCCESS/FAILED) quote record cached by cacheSetQuoteResult.
+func (s *QuoteService) cacheGetQuoteResult(ctx context.Context, id string) (*repository.Quote, bool) {
 	if s.cache == nil {
+		return nil, false
+	}
+
+	vals, err := s.cache.HGetAll(ctx, s.quoteResultCacheKey(id)).Result()
+	if err != nil || len(vals) == 0 {
+		return nil, false
+	}
+
+	requestedAt, err := parseStoredTime(vals["requested_at"])
+	if err != nil {
+		return nil, false
+	}
+	q := &repository.Quote{
+		ID:          id,
+		Base:        vals["base"],
+		Quote:       vals["quote"],
+		Status:      repository.Status(vals["status"]),
+		RequestedAt: requestedAt,
+		Origin:      repository.Origin(vals["origin"]),
+		InstanceID:  vals["instance_id"],
+	}
+	if !isTerminal(q.Status) {
+		return nil, false
+	}
+	if price, ok := vals["price"]; ok {
+		q.Price = &price
+	}
+	if errMsg, ok := vals["error"]; ok {
+		q.ErrorMsg = &errMsg
+	}
+	q.ErrorCode = repository.ErrorCode(vals["error_code"])
+	if ts, ok := vals["updated_at"]; ok {
+		t, err := parseStoredTime(ts)
+		if err != nil {
+			return nil, false
+		}
+		q.UpdatedAt = &t
+	}
+	if ts, ok := vals["rate_timestamp"]; ok {
+		t, err := parseStoredTime(ts)
+		if err != nil {
+			return nil, false
+		}
+		q.RateTimestamp = &t
+	}
+	if minPrice, ok := vals["verify_min_price"]; ok {
+		providers, err := strconv.Atoi(vals["verify_providers"])
+		if err != nil {
+			return nil, false
+		}
+		q.Verification = &repository.Verification{
+			MinPrice:  minPrice,
+			MaxPrice:  vals["verify_max_price"],
+			Spread:    vals["verify_spread"],
+			Providers: providers,
+		}
+	}
+	return q, true
+}
+
+// cacheSetQuoteResult caches a quote record by ID. PENDING and RUNNING records are transient and skipped.
+func (s *QuoteService) cacheSetQuoteResult(ctx context.Context, q *repository.Quote) {
+	if s.cache == nil || q == nil || !isTerminal(q.Status) {
 		return
 	}
 
-	key := latestCacheKey(base, quote)
+	vals := []any{
+		"base", q.Base,
+		"quote", q.Quote,
+		"status", string(q.Status),
+		"requested_at", formatStoredTime(q.RequestedAt),
+		"origin", string(q.Origin),
+	}
+	if q.Price != nil {
+		vals = append(vals, "price", *q.Price)
+	}
+	if q.ErrorMsg != nil {
+		vals = append(vals, "error", *q.ErrorMsg)
+	}
+	if q.ErrorCode != "" {
+		vals = append(vals, "error_code", string(q.ErrorCode))
+	}
+	if q.InstanceID != "" {
+		vals = append(vals, "instance_id", q.InstanceID)
+	}
+	if q.UpdatedAt != nil {
+		vals = append(vals, "updated_at", formatStoredTime(*q.UpdatedAt))
+	}
+	if q.RateTimestamp != nil {
+		vals = append(vals, "rate_timestamp", formatStoredTime(*q.RateTimestamp))
+	}
+	if v := q.Verification; v != nil {
+		vals = append(vals,
+			"verify_min_price", v.MinPrice,
+			"verify_max_price", v.MaxPrice,
+			"verify_spread", v.Spread,
+			"verify_providers", v.Providers,
+		)
+	}
+
+	key := s.quoteResultCacheKey(q.ID)
 	pipe := s.cache.Pipeline()
-	pipe.HSet(ctx, key, "price", rate, "updated_at", t.Format(time.RFC3339))
+	pipe.HSet(ctx, key, vals...)
 	pipe.Expire(ctx, key, s.latestPriceTTL)
 
 	if _, err := pipe.Exec(ctx); err != nil {
@@ -68,6 +467,20 @@ func (s *QuoteService) cacheSetLatest(ctx context.Context, base, quote, rate str
 	}
 }
 
+func (s *QuoteService) cacheDeleteQuoteResult(ctx context.Context, id string) {
+	if s.cache == nil {
+		return
+	}
+	key := s.quoteResultCacheKey(id)
+	if err := s.cache.Del(ctx, key).Err(); err != nil {
+		s.log.Warnw("Failed to invalidate cache", "key", key, "error", err)
+	}
+}
+
+func isTerminal(status repository.Status) bool {
+	return status == repository.StatusSuccess || status == repository.StatusFailed
+}
+
 func asString(v any) (string, bool) {
 	switch x := v.(type) {
 	case string:
@@ -79,6 +492,11 @@ func asString(v any) (string, bool) {
 	}
 }
 
-func timeParse(s string) (time.Time, error) {
-	return time.Parse(time.RFC3339, s)
+func cachedTime(v any) (time.Time, bool) {
+	ts, ok := asString(v)
+	if !ok {
+		return time.Time{}, false
+	}
+	t, err := parseStoredTime(ts)
+	return t, err == nil
 }
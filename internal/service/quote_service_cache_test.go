@@ -0,0 +1,586 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+
+	"quoteservice/internal/config"
+	"quoteservice/internal/repository"
+)
+
+// roundTripCounter is a redis.Hook counting the requests a client sends to Redis; a
+// pipeline counts as one.
+type roundTripCounter struct {
+	n atomic.Int64
+}
+
+func (c *roundTripCounter) DialHook(next redis.DialHook) redis.DialHook { return next }
+
+func (c *roundTripCounter) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		c.n.Add(1)
+		return next(ctx, cmd)
+	}
+}
+
+func (c *roundTripCounter) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		c.n.Add(1)
+		return next(ctx, cmds)
+	}
+}
+
+// countedRedis returns a connected client of mr and the counter of its round trips,
+// which starts after the connection handshake. The client does not retry.
+func countedRedis(tb testing.TB, mr *miniredis.Miniredis) (*redis.Client, *roundTripCounter) {
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr(), MaxRetries: -1})
+	counter := &roundTripCounter{}
+	rdb.AddHook(counter)
+	if err := rdb.Ping(context.Background()).Err(); err != nil {
+		tb.Fatalf("ping miniredis: %v", err)
+	}
+	counter.n.Store(0)
+	return rdb, counter
+}
+
+func TestCacheGetLatest_States(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb, counter := countedRedis(t, mr)
+	svc := NewQuoteService(QuoteServiceDeps{Cache: rdb, CacheConfig: testCacheCfg})
+	ctx := context.Background()
+	now := time.Date(2025, 12, 1, 10, 15, 30, 0, time.UTC)
+
+	svc.cacheSetLatest(ctx, Pair{Base: "EUR", Quote: "USD"}, "", "1.085", now, now)
+	mr.Set("latest:{GBP:USD}:notfound", "1")
+	mr.HSet("latest:{CHF:USD}", "price", "1.1") // Incomplete entry.
+	svc.cacheSetLatest(ctx, Pair{Base: "USD", Quote: "EUR"}, "", "1.085", now, now)
+	mr.Set("latest:{USD:EUR}:notfound", "1") // Outlived by a later price.
+
+	tests := []struct {
+		name        string
+		base, quote string
+		want        latestLookup
+	}{
+		{"hit", "EUR", "USD", latestHit},
+		{"hit despite a not-found marker", "USD", "EUR", latestHit},
+		{"known missing", "GBP", "USD", latestMissing},
+		{"nothing cached", "JPY", "USD", latestUnknown},
+		{"incomplete entry", "CHF", "USD", latestUnknown},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			before := counter.n.Load()
+			q, lookup := svc.cacheGetLatest(ctx, Pair{Base: tc.base, Quote: tc.quote})
+			if lookup != tc.want {
+				t.Fatalf("expected lookup %d, got %d", tc.want, lookup)
+			}
+			if got := counter.n.Load() - before; got != 1 {
+				t.Errorf("expected 1 Redis round trip, got %d", got)
+			}
+			if (q != nil) != (lookup == latestHit) {
+				t.Errorf("expected a quote only on a hit, got %+v", q)
+			}
+			if lookup == latestHit && (q.Price == nil || *q.Price != "1.085") {
+				t.Errorf("expected cached price 1.085, got %+v", q)
+			}
+		})
+	}
+
+	t.Run("redis unavailable", func(t *testing.T) {
+		mr.Close()
+		if q, lookup := svc.cacheGetLatest(ctx, Pair{Base: "EUR", Quote: "USD"}); lookup != latestUnknown || q != nil {
+			t.Errorf("expected an unknown lookup, got %d with %+v", lookup, q)
+		}
+	})
+}
+
+func TestCacheSetLatest_NewestRateWins(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	svc := NewQuoteService(QuoteServiceDeps{Cache: rdb, CacheConfig: testCacheCfg})
+	ctx := context.Background()
+	pair := Pair{Base: "EUR", Quote: "USD"}
+	t0 := time.Date(2025, 12, 1, 10, 15, 30, 0, time.UTC)
+
+	writes := []struct {
+		price string
+		at    time.Time
+	}{
+		{"1.0850", t0.Add(2 * time.Second)},
+		{"1.0840", t0},                             // Older: skipped.
+		{"1.0860", t0.Add(2*time.Second + 1000)},   // A microsecond later: kept.
+		{"1.0830", t0.Add(time.Second + 999*1000)}, // Older: skipped.
+	}
+	for _, w := range writes {
+		svc.cacheSetLatest(ctx, pair, "", w.price, w.at, time.Now())
+	}
+
+	if got := mr.HGet(svc.latestCacheKey(pair), "price"); got != "1.0860" {
+		t.Errorf("Expected the newest price 1.0860, got %q", got)
+	}
+	if got := mr.HGet(svc.latestCacheKey(pair), "rate_timestamp"); got != formatStoredTime(t0.Add(2*time.Second+1000)) {
+		t.Errorf("Expected the newest rate_timestamp, got %q", got)
+	}
+
+	// An entry in a legacy layout cannot be compared and is replaced.
+	mr.HSet(svc.latestCacheKey(pair), "rate_timestamp", "2099-01-01T00:00:00Z")
+	svc.cacheSetLatest(ctx, pair, "", "1.0870", t0, time.Now())
+	if got := mr.HGet(svc.latestCacheKey(pair), "price"); got != "1.0870" {
+		t.Errorf("Expected a legacy entry to be replaced, got price %q", got)
+	}
+}
+
+func TestCacheSetLatestNotFound_KeepsCachedPrice(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	cfg := testCacheCfg
+	cfg.NegativeCacheTTLSec = 60
+	svc := NewQuoteService(QuoteServiceDeps{Cache: rdb, CacheConfig: cfg})
+	ctx := context.Background()
+	now := time.Date(2025, 12, 1, 10, 15, 30, 0, time.UTC)
+
+	// A DB miss that finishes after a success cached the price must not hide it.
+	cached := Pair{Base: "EUR", Quote: "USD"}
+	svc.cacheSetLatest(ctx, cached, "", "1.085", now, now)
+	svc.cacheSetLatestNotFound(ctx, cached)
+	if mr.Exists(svc.latestNotFoundCacheKey(cached)) {
+		t.Error("Expected no not-found marker next to a cached price")
+	}
+
+	missing := Pair{Base: "GBP", Quote: "USD"}
+	svc.cacheSetLatestNotFound(ctx, missing)
+	key := svc.latestNotFoundCacheKey(missing)
+	if !mr.Exists(key) {
+		t.Fatal("Expected a not-found marker for a pair without a cached price")
+	}
+	if ttl := mr.TTL(key); ttl != time.Minute {
+		t.Errorf("Expected the marker to expire after 1m, got %v", ttl)
+	}
+}
+
+func TestCacheSetLatest_DerivedPrices(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	cfg := testCacheCfg
+	cfg.InverseEnabled, cfg.InverseTTLSec = true, 60
+	svc := NewQuoteService(QuoteServiceDeps{Cache: rdb, CacheConfig: cfg})
+	ctx := context.Background()
+	pair := Pair{Base: "MXN", Quote: "EUR"}
+	key := svc.latestCacheKey(pair)
+	t0 := time.Date(2025, 12, 1, 10, 15, 30, 0, time.UTC)
+	derived := func(price string, at time.Time) {
+		svc.cacheSetLatestEntry(ctx, latestEntry{pair: pair, rate: price, rateTimestamp: at, updatedAt: at, derived: derivedInverse})
+	}
+
+	mr.Set(key+":notfound", "1")
+	derived("0.0533", t0)
+	derived("0.0532", t0.Add(-time.Second)) // Older: skipped.
+	if got := mr.HGet(key, "price"); got != "0.0533" || mr.Exists(key+":notfound") {
+		t.Errorf("Expected the newest derived price 0.0533 and no not-found marker, got %q", got)
+	}
+	derived("0.0534", t0.Add(time.Second))
+	if got := mr.HGet(key, "price"); got != "0.0534" {
+		t.Errorf("Expected a newer derived price to replace the older one, got %q", got)
+	}
+	if q, lookup := svc.cacheGetLatest(ctx, pair); lookup != latestHit || q.Derived != derivedInverse {
+		t.Errorf("Expected a hit marked %q, got %d with %+v", derivedInverse, lookup, q)
+	}
+}
+
+func TestInverseRate(t *testing.T) {
+	tests := []struct {
+		rate      string
+		precision int
+		want      string
+		ok        bool
+	}{
+		{"18.7543", 10, "0.0533211050", true},
+		{"0.5", 2, "2.00", true},
+		{"1e-3", 4, "1000.0000", true},
+		{"20000000", 6, "", false}, // Rounds to zero.
+		{"0", 6, "", false},
+		{"-2", 6, "", false},
+		{"n/a", 6, "", false},
+	}
+	for _, tc := range tests {
+		got, ok := inverseRate(tc.rate, tc.precision)
+		if got != tc.want || ok != tc.ok {
+			t.Errorf("inverseRate(%q, %d) = %q, %v; want %q, %v", tc.rate, tc.precision, got, ok, tc.want, tc.ok)
+		}
+	}
+}
+
+// batchEntries returns latest cache entries for n distinct pairs.
+func batchEntries(n int, at time.Time) []latestEntry {
+	entries := make([]latestEntry, n)
+	for i := range entries {
+		entries[i] = latestEntry{
+			pair:          Pair{Base: "EUR", Quote: fmt.Sprintf("%c%c%c", 'A'+i/676%26, 'A'+i/26%26, 'A'+i%26)},
+			rate:          fmt.Sprintf("1.%04d", i),
+			rateTimestamp: at,
+			updatedAt:     at,
+		}
+	}
+	return entries
+}
+
+func TestCacheSetLatestBatch(t *testing.T) {
+	t0 := time.Date(2025, 12, 1, 10, 15, 30, 0, time.UTC)
+	newService := func(rdb *redis.Client) *QuoteService {
+		return NewQuoteService(QuoteServiceDeps{
+			Cache: rdb, CacheConfig: testCacheCfg,
+			Pairs: NewPairResolver(PairSettings{LatestPriceTTL: 10 * time.Minute}, map[string]config.PairOverride{
+				"EUR/AAB": {LatestPriceTTLSec: intPtr(60)},
+			}),
+		})
+	}
+	assertWritten := func(t *testing.T, mr *miniredis.Miniredis, svc *QuoteService, entries []latestEntry) {
+		t.Helper()
+		for _, e := range entries {
+			key := svc.latestCacheKey(e.pair)
+			if got := mr.HGet(key, "price"); got != e.rate {
+				t.Errorf("Expected %s price %s, got %q", key, e.rate, got)
+			}
+			if got, want := mr.TTL(key), svc.pairs.Resolve(e.pair).LatestPriceTTL; got != want {
+				t.Errorf("Expected %s TTL %v, got %v", key, want, got)
+			}
+		}
+	}
+
+	t.Run("one round trip", func(t *testing.T) {
+		mr := miniredis.RunT(t)
+		rdb, counter := countedRedis(t, mr)
+		svc := newService(rdb)
+		ctx := context.Background()
+		// Load the script, as any earlier write does.
+		svc.cacheSetLatest(ctx, Pair{Base: "GBP", Quote: "USD"}, "", "1.27", t0, t0)
+		counter.n.Store(0)
+
+		entries := batchEntries(50, t0)
+		svc.cacheSetLatestBatch(ctx, entries)
+
+		if got := counter.n.Load(); got != 1 {
+			t.Errorf("Expected 1 round trip for 50 pairs, got %d", got)
+		}
+		assertWritten(t, mr, svc, entries)
+	})
+
+	t.Run("stale entries are skipped", func(t *testing.T) {
+		mr := miniredis.RunT(t)
+		svc := newService(redis.NewClient(&redis.Options{Addr: mr.Addr()}))
+		ctx := context.Background()
+		pair := Pair{Base: "EUR", Quote: "USD"}
+		svc.cacheSetLatest(ctx, pair, "", "1.0860", t0.Add(time.Second), t0)
+
+		svc.cacheSetLatestBatch(ctx, []latestEntry{{pair: pair, rate: "1.0840", rateTimestamp: t0, updatedAt: t0}})
+
+		if got := mr.HGet(svc.latestCacheKey(pair), "price"); got != "1.0860" {
+			t.Errorf("Expected the newer price 1.0860 to be kept, got %q", got)
+		}
+	})
+
+	t.Run("falls back to per-pair writes", func(t *testing.T) {
+		mr := miniredis.RunT(t)
+		rdb, counter := countedRedis(t, mr)
+		svc := newService(rdb)
+		ctx := context.Background()
+
+		// The script was never loaded, so every pipelined EVALSHA fails with NOSCRIPT.
+		entries := batchEntries(3, t0)
+		svc.cacheSetLatestBatch(ctx, entries)
+
+		if got := counter.n.Load(); got <= 1 {
+			t.Errorf("Expected per-pair writes after the pipeline, got %d round trips", got)
+		}
+		assertWritten(t, mr, svc, entries)
+	})
+}
+
+func TestCacheQuoteResult_ErrorCode(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	svc := NewQuoteService(QuoteServiceDeps{Repo: &mockQuoteRepo{}, Validator: NewValidator(), Cache: rdb, CacheConfig: testCacheCfg})
+	ctx := context.Background()
+	msg := "provider timeout"
+	updatedAt := time.Date(2025, 12, 1, 10, 0, 0, 0, time.UTC)
+
+	for _, code := range []repository.ErrorCode{repository.ErrorCodeTimeout, ""} {
+		svc.cacheSetQuoteResult(ctx, &repository.Quote{
+			ID: "u1", Base: "EUR", Quote: "MXN", Status: repository.StatusFailed, ErrorMsg: &msg, ErrorCode: code,
+			RequestedAt: updatedAt, UpdatedAt: &updatedAt,
+		})
+		q, ok := svc.cacheGetQuoteResult(ctx, "u1")
+		if !ok || q.ErrorMsg == nil || *q.ErrorMsg != msg || q.ErrorCode != code {
+			t.Errorf("Expected the cached FAILED result with code %q, got %+v (hit %v)", code, q, ok)
+		}
+		svc.cacheDeleteQuoteResult(ctx, "u1")
+	}
+}
+
+func TestCacheKeys_Namespace(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	cacheCfg := testCacheCfg
+	cacheCfg.NegativeCacheTTLSec = 30
+	staging := NewQuoteService(QuoteServiceDeps{Cache: rdb, Namespace: "staging", CacheConfig: cacheCfg})
+	prod := NewQuoteService(QuoteServiceDeps{Cache: rdb, CacheConfig: cacheCfg})
+	ctx := context.Background()
+	now := time.Date(2025, 12, 1, 10, 15, 30, 0, time.UTC)
+	price := "1.085"
+
+	staging.cacheSetLatest(ctx, Pair{Base: "EUR", Quote: "USD"}, "", price, now, now)
+	staging.cacheSetLatestNotFound(ctx, Pair{Base: "GBP", Quote: "USD"})
+	staging.cacheSetQuoteResult(ctx, &repository.Quote{
+		ID: "u1", Base: "EUR", Quote: "USD", Status: repository.StatusSuccess, Price: &price, RequestedAt: now, UpdatedAt: &now,
+	})
+
+	wantKeys := []string{"staging:latest:{EUR:USD}", "staging:latest:{GBP:USD}:notfound", "staging:quote_result:{u1}"}
+	for _, key := range wantKeys {
+		if !mr.Exists(key) {
+			t.Errorf("Expected key %s, have %v", key, mr.Keys())
+		}
+	}
+	if got := len(mr.Keys()); got != len(wantKeys) {
+		t.Errorf("Expected only namespaced keys, have %v", mr.Keys())
+	}
+	if _, lookup := prod.cacheGetLatest(ctx, Pair{Base: "EUR", Quote: "USD"}); lookup != latestUnknown {
+		t.Errorf("Expected another namespace not to see the entry, got lookup %d", lookup)
+	}
+	if _, lookup := staging.cacheGetLatest(ctx, Pair{Base: "GBP", Quote: "USD"}); lookup != latestMissing {
+		t.Errorf("Expected the namespaced negative marker, got lookup %d", lookup)
+	}
+}
+
+func TestGetLatestQuote_OneRoundTripBeforeDB(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb, counter := countedRedis(t, mr)
+	now := time.Date(2025, 12, 1, 10, 15, 30, 0, time.UTC)
+	price := "18.7543"
+
+	var tripsAtDB int64 = -1
+	repo := &mockQuoteRepo{
+		getLatestSuccessFunc: func(_ context.Context, pair Pair) (*repository.Quote, error) {
+			tripsAtDB = counter.n.Load()
+			if pair.Base == "GBP" {
+				return nil, nil
+			}
+			return &repository.Quote{Base: pair.Base, Quote: pair.Quote, Status: repository.StatusSuccess, Price: &price, UpdatedAt: &now}, nil
+		},
+	}
+	cacheCfg := testCacheCfg
+	cacheCfg.NegativeCacheTTLSec = 30
+	svc := NewQuoteService(QuoteServiceDeps{Repo: repo, Validator: NewValidator(), Cache: rdb, CacheConfig: cacheCfg})
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		pair    [2]string
+		wantErr error
+		wantDB  bool
+	}{
+		{"cold pair reads the DB", [2]string{"EUR", "MXN"}, nil, true},
+		{"cached pair", [2]string{"EUR", "MXN"}, nil, false},
+		{"pair without quotes reads the DB", [2]string{"GBP", "USD"}, ErrNotFound, true},
+		{"negatively cached pair", [2]string{"GBP", "USD"}, ErrNotFound, false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			counter.n.Store(0)
+			tripsAtDB = -1
+			if _, err := svc.GetLatestQuote(ctx, Pair{Base: tc.pair[0], Quote: tc.pair[1]}); !errors.Is(err, tc.wantErr) {
+				t.Fatalf("expected %v, got %v", tc.wantErr, err)
+			}
+			switch {
+			case tc.wantDB && tripsAtDB != 1:
+				t.Errorf("expected 1 Redis round trip before the DB read, got %d", tripsAtDB)
+			case !tc.wantDB && tripsAtDB != -1:
+				t.Error("expected no DB read")
+			case !tc.wantDB && counter.n.Load() != 1:
+				t.Errorf("expected 1 Redis round trip, got %d", counter.n.Load())
+			}
+		})
+	}
+}
+
+func TestGetLatestQuotes(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb, counter := countedRedis(t, mr)
+	now := time.Date(2025, 12, 1, 10, 15, 30, 0, time.UTC)
+	price := "18.7543"
+
+	var dbReads []Pair
+	var tripsAtDB int64 = -1
+	repo := &mockQuoteRepo{
+		getLatestSuccessFunc: func(_ context.Context, pair Pair) (*repository.Quote, error) {
+			dbReads = append(dbReads, pair)
+			tripsAtDB = counter.n.Load()
+			if pair.Base == "GBP" {
+				return nil, nil
+			}
+			return &repository.Quote{Base: pair.Base, Quote: pair.Quote, Status: repository.StatusSuccess, Price: &price, UpdatedAt: &now}, nil
+		},
+	}
+	cacheCfg := testCacheCfg
+	cacheCfg.NegativeCacheTTLSec = 30
+	svc := NewQuoteService(QuoteServiceDeps{Repo: repo, Validator: NewValidator(), Cache: rdb, CacheConfig: cacheCfg})
+	ctx := context.Background()
+	svc.cacheSetLatest(ctx, Pair{Base: "EUR", Quote: "USD"}, "", "1.085", now, now)
+	counter.n.Store(0)
+
+	pairs := []Pair{{Base: "EUR", Quote: "MXN"}, {Base: "EUR", Quote: "USD"}, {Base: "GBP", Quote: "USD"}, {Base: "EUR", Quote: "MXN"}}
+	quotes, err := svc.GetLatestQuotes(ctx, pairs)
+	if err != nil {
+		t.Fatalf("GetLatestQuotes: %v", err)
+	}
+	if len(quotes) != 4 {
+		t.Fatalf("expected 4 quotes, got %+v", quotes)
+	}
+	for i, q := range quotes {
+		if q.Pair != pairs[i] {
+			t.Errorf("expected quote %d to be %s, got %s", i, pairs[i], q.Pair)
+		}
+	}
+	if q := quotes[0].Quote; q == nil || q.Price == nil || *q.Price != price || quotes[3].Quote == nil {
+		t.Errorf("expected EUR/MXN from the DB twice, got %+v and %+v", q, quotes[3].Quote)
+	}
+	if q := quotes[1].Quote; q == nil || q.Price == nil || *q.Price != "1.085" {
+		t.Errorf("expected the cached EUR/USD, got %+v", q)
+	}
+	if quotes[2].Quote != nil {
+		t.Errorf("expected no GBP/USD quote, got %+v", quotes[2].Quote)
+	}
+	if len(dbReads) != 2 || tripsAtDB != 1 {
+		t.Errorf("expected one cache round trip, then one DB read per uncached pair, got reads %v after %d trips", dbReads, tripsAtDB)
+	}
+
+	// The DB answers were cached, and the caller's PairAccess filters the pairs.
+	dbReads = nil
+	counter.n.Store(0)
+	restricted := WithPairAccess(ctx, &PairAccess{Bases: []string{"EUR"}})
+	if quotes, err = svc.GetLatestQuotes(restricted, pairs); err != nil {
+		t.Fatalf("GetLatestQuotes: %v", err)
+	}
+	if len(quotes) != 3 || quotes[2].Pair != (Pair{Base: "EUR", Quote: "MXN"}) || quotes[2].Quote == nil {
+		t.Errorf("expected the 3 EUR quotes, got %+v", quotes)
+	}
+	if len(dbReads) != 0 || counter.n.Load() != 1 {
+		t.Errorf("expected a single cache round trip, got %d trips and DB reads %v", counter.n.Load(), dbReads)
+	}
+
+	if _, err := svc.GetLatestQuotes(ctx, []Pair{{Base: "EUR", Quote: "XXX"}}); !errors.Is(err, ErrUnsupportedCurrency) {
+		t.Errorf("expected ErrUnsupportedCurrency, got %v", err)
+	}
+}
+
+func TestGetLatestQuote_UpdateID(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	now := time.Date(2025, 12, 1, 10, 15, 30, 0, time.UTC)
+	price := "18.7543"
+	repo := &mockQuoteRepo{
+		getLatestSuccessFunc: func(_ context.Context, pair Pair) (*repository.Quote, error) {
+			return &repository.Quote{ID: "db-update", Base: pair.Base, Quote: pair.Quote, Status: repository.StatusSuccess,
+				Price: &price, UpdatedAt: &now, RateTimestamp: &now}, nil
+		},
+	}
+	svc := NewQuoteService(QuoteServiceDeps{Repo: repo, Validator: NewValidator(), Cache: rdb, CacheConfig: testCacheCfg})
+	ctx := context.Background()
+	pair := Pair{Base: "EUR", Quote: "MXN"}
+
+	for _, tier := range []string{"DB", "cache"} {
+		q, err := svc.GetLatestQuote(ctx, pair)
+		if err != nil {
+			t.Fatalf("%s: GetLatestQuote: %v", tier, err)
+		}
+		if q.ID != "db-update" {
+			t.Errorf("%s: expected update_id db-update, got %q", tier, q.ID)
+		}
+	}
+	if got := mr.HGet("latest:{EUR:MXN}", "update_id"); got != "db-update" {
+		t.Errorf("expected the update_id cached, got %q", got)
+	}
+
+	// A newer price without an update record must not keep the previous update_id.
+	svc.cacheSetLatest(ctx, pair, "", "18.80", now.Add(time.Minute), now.Add(time.Minute))
+	if q, err := svc.GetLatestQuote(ctx, pair); err != nil || q.ID != "" || q.Price == nil || *q.Price != "18.80" {
+		t.Errorf("expected the newer price without an update_id, got %+v (err %v)", q, err)
+	}
+}
+
+// BenchmarkGetLatestQuote reports the Redis round trips of GetLatestQuote for a cached
+// pair and a negatively cached pair, against reading the negative marker and the latest
+// hash with separate requests. Redis runs in process, so the round trips per op, not
+// the latency, are the figure to compare.
+func BenchmarkGetLatestQuote(b *testing.B) {
+	mr := miniredis.RunT(b)
+	rdb, counter := countedRedis(b, mr)
+	now := time.Now().UTC()
+	cacheCfg := testCacheCfg
+	cacheCfg.NegativeCacheTTLSec = 3600
+	svc := NewQuoteService(QuoteServiceDeps{Repo: &mockQuoteRepo{}, Validator: NewValidator(), Cache: rdb, CacheConfig: cacheCfg})
+	ctx := context.Background()
+	svc.cacheSetLatest(ctx, Pair{Base: "EUR", Quote: "USD"}, "", "1.085", now, now)
+	svc.cacheSetLatestNotFound(ctx, Pair{Base: "GBP", Quote: "USD"})
+
+	run := func(b *testing.B, get func() error) {
+		counter.n.Store(0)
+		for b.Loop() {
+			if err := get(); err != nil && !errors.Is(err, ErrNotFound) {
+				b.Fatal(err)
+			}
+		}
+		b.ReportMetric(float64(counter.n.Load())/float64(b.N), "roundtrips/op")
+	}
+
+	b.Run("hit", func(b *testing.B) {
+		run(b, func() error { _, err := svc.GetLatestQuote(ctx, Pair{Base: "EUR", Quote: "USD"}); return err })
+	})
+	b.Run("known_missing", func(b *testing.B) {
+		run(b, func() error { _, err := svc.GetLatestQuote(ctx, Pair{Base: "GBP", Quote: "USD"}); return err })
+	})
+	b.Run("sequential_reads", func(b *testing.B) {
+		run(b, func() error {
+			if err := rdb.Exists(ctx, svc.latestNotFoundCacheKey(Pair{Base: "EUR", Quote: "USD"})).Err(); err != nil {
+				return err
+			}
+			return rdb.HMGet(ctx, svc.latestCacheKey(Pair{Base: "EUR", Quote: "USD"}), "price", "updated_at", "rate_timestamp").Err()
+		})
+	})
+}
+
+// BenchmarkCacheSetLatest reports the Redis round trips of caching the latest prices of
+// 50 pairs one by one against one batch, as done on cache warmup.
+func BenchmarkCacheSetLatest(b *testing.B) {
+	mr := miniredis.RunT(b)
+	rdb, counter := countedRedis(b, mr)
+	svc := NewQuoteService(QuoteServiceDeps{Cache: rdb, CacheConfig: testCacheCfg})
+	ctx := context.Background()
+	entries := batchEntries(50, time.Now().UTC())
+	svc.cacheSetLatestBatch(ctx, entries[:1]) // Load the script.
+
+	run := func(b *testing.B, write func()) {
+		counter.n.Store(0)
+		for b.Loop() {
+			write()
+		}
+		b.ReportMetric(float64(counter.n.Load())/float64(b.N), "roundtrips/op")
+	}
+
+	b.Run("per_pair", func(b *testing.B) {
+		run(b, func() {
+			for _, e := range entries {
+				svc.cacheSetLatest(ctx, e.pair, "", e.rate, e.rateTimestamp, e.updatedAt)
+			}
+		})
+	})
+	b.Run("batched", func(b *testing.B) {
+		run(b, func() { svc.cacheSetLatestBatch(ctx, entries) })
+	})
+}
@@ -2,50 +2,130 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/alicebob/miniredis/v2"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
 
 	"quoteservice/internal/config"
+	"quoteservice/internal/metrics"
+	"quoteservice/internal/provider"
 	"quoteservice/internal/repository"
+	"quoteservice/internal/testkit/fakeclock"
 )
 
 // Mock repository
+var _ repository.QuoteRepository = (*mockQuoteRepo)(nil)
+
 type mockQuoteRepo struct {
-	createUpdateFunc     func(ctx context.Context, base, quote, id string) (string, error)
-	markRunningFunc      func(ctx context.Context, id string) error
-	markSuccessFunc      func(ctx context.Context, id, price string) error
-	markFailedFunc       func(ctx context.Context, id, errorMsg string) error
-	getByIDFunc          func(ctx context.Context, id string) (*repository.Quote, error)
-	getLatestSuccessFunc func(ctx context.Context, base, quote string) (*repository.Quote, error)
+	createUpdateFunc       func(ctx context.Context, pair Pair, id string) (repository.CreateUpdateResult, error)
+	markRunningFunc        func(ctx context.Context, id string, version int64) error
+	markSuccessFunc        func(ctx context.Context, id string, version int64, price string, rateTimestamp time.Time) error
+	markFailedFunc         func(ctx context.Context, id string, version int64, code repository.ErrorCode, errorMsg string) error
+	markPendingFunc        func(ctx context.Context, id string, version int64) error
+	getByIDFunc            func(ctx context.Context, id string) (*repository.Quote, error)
+	getLatestSuccessFunc   func(ctx context.Context, pair Pair) (*repository.Quote, error)
+	getLatestAnyFunc       func(ctx context.Context, pair Pair) (*repository.Quote, error)
+	latestSuccessTimesFunc func(ctx context.Context, pairs []repository.Pair) ([]repository.PairFreshness, error)
+	countByStatusFunc      func(ctx context.Context, status repository.Status) (int, error)
+	listPendingFunc        func(ctx context.Context, before time.Time, limit int) ([]repository.Quote, error)
+	getPriceAtTimeFunc     func(ctx context.Context, pair Pair, at time.Time) (*repository.Quote, error)
+	getStatusEventsFunc    func(ctx context.Context, id string) ([]repository.StatusEvent, error)
+	saveVerificationFunc   func(ctx context.Context, id string, v repository.Verification) error
+	recordFetchFunc        func(ctx context.Context, id string, f repository.Fetch) error
+	lastOrigin             repository.Origin // Origin of the last CreateUpdate call.
+	lastInstanceID         string            // Instance of the last MarkRunning call.
+}
+
+func (m *mockQuoteRepo) CreateUpdate(ctx context.Context, pair Pair, id string, origin repository.Origin) (repository.CreateUpdateResult, error) {
+	m.lastOrigin = origin
+	return m.createUpdateFunc(ctx, pair, id)
+}
+
+// created and deduplicated are createUpdateFunc results: a new record with id, and
+// the in-flight update id a request was deduplicated onto.
+func created(id string) (repository.CreateUpdateResult, error) {
+	return repository.CreateUpdateResult{ID: id, Created: true}, nil
+}
+
+func deduplicated(id string) (repository.CreateUpdateResult, error) {
+	return repository.CreateUpdateResult{ID: id}, nil
 }
 
-func (m *mockQuoteRepo) CreateUpdate(ctx context.Context, base, quote, id string) (string, error) {
-	return m.createUpdateFunc(ctx, base, quote, id)
+func (m *mockQuoteRepo) MarkRunning(ctx context.Context, id string, version int64, instanceID string) error {
+	m.lastInstanceID = instanceID
+	return m.markRunningFunc(ctx, id, version)
 }
 
-func (m *mockQuoteRepo) MarkRunning(ctx context.Context, id string) error {
-	return m.markRunningFunc(ctx, id)
+func (m *mockQuoteRepo) MarkSuccess(ctx context.Context, id string, version int64, price string, rateTimestamp time.Time) error {
+	return m.markSuccessFunc(ctx, id, version, price, rateTimestamp)
 }
 
-func (m *mockQuoteRepo) MarkSuccess(ctx context.Context, id, price string) error {
-	return m.markSuccessFunc(ctx, id, price)
+func (m *mockQuoteRepo) MarkFailed(ctx context.Context, id string, version int64, code repository.ErrorCode, errorMsg string) error {
+	return m.markFailedFunc(ctx, id, version, code, errorMsg)
 }
 
-func (m *mockQuoteRepo) MarkFailed(ctx context.Context, id, errorMsg string) error {
-	return m.markFailedFunc(ctx, id, errorMsg)
+func (m *mockQuoteRepo) MarkPending(ctx context.Context, id string, version int64) error {
+	return m.markPendingFunc(ctx, id, version)
 }
 
 func (m *mockQuoteRepo) GetByID(ctx context.Context, id string) (*repository.Quote, error) {
 	return m.getByIDFunc(ctx, id)
 }
 
-func (m *mockQuoteRepo) GetLatestSuccess(ctx context.Context, base, quote string) (*repository.Quote, error) {
-	return m.getLatestSuccessFunc(ctx, base, quote)
+func (m *mockQuoteRepo) GetLatestSuccess(ctx context.Context, pair Pair) (*repository.Quote, error) {
+	return m.getLatestSuccessFunc(ctx, pair)
+}
+
+func (m *mockQuoteRepo) GetLatestAny(ctx context.Context, pair Pair) (*repository.Quote, error) {
+	return m.getLatestAnyFunc(ctx, pair)
+}
+
+func (m *mockQuoteRepo) GetPriceAtTime(ctx context.Context, pair Pair, at time.Time) (*repository.Quote, error) {
+	return m.getPriceAtTimeFunc(ctx, pair, at)
+}
+
+func (m *mockQuoteRepo) LatestSuccessTimes(ctx context.Context, pairs []repository.Pair) ([]repository.PairFreshness, error) {
+	return m.latestSuccessTimesFunc(ctx, pairs)
+}
+
+func (m *mockQuoteRepo) CountByStatus(ctx context.Context, status repository.Status) (int, error) {
+	return m.countByStatusFunc(ctx, status)
+}
+
+func (m *mockQuoteRepo) ListPendingOlderThan(ctx context.Context, before time.Time, limit int) ([]repository.Quote, error) {
+	return m.listPendingFunc(ctx, before, limit)
+}
+
+func (m *mockQuoteRepo) GetStatusEvents(ctx context.Context, id string) ([]repository.StatusEvent, error) {
+	return m.getStatusEventsFunc(ctx, id)
+}
+
+func (m *mockQuoteRepo) SaveVerification(ctx context.Context, id string, v repository.Verification) error {
+	return m.saveVerificationFunc(ctx, id, v)
+}
+
+func (m *mockQuoteRepo) RecordFetch(ctx context.Context, id string, f repository.Fetch) error {
+	if m.recordFetchFunc == nil {
+		return nil
+	}
+	return m.recordFetchFunc(ctx, id, f)
+}
+
+// pendingRecord is a getByIDFunc for an update that no worker has picked up yet.
+func pendingRecord(_ context.Context, id string) (*repository.Quote, error) {
+	return &repository.Quote{ID: id, Status: repository.StatusPending, Version: repository.InitialVersion}, nil
 }
 
 // Mock provider
@@ -88,6 +168,120 @@ func TestIsValidCurrencyCode(t *testing.T) {
 	}
 }
 
+func TestParsePair(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Pair
+		wantErr bool
+	}{
+		{in: "EUR/MXN", want: Pair{Base: "EUR", Quote: "MXN"}},
+		{in: "eur/mxn", want: Pair{Base: "EUR", Quote: "MXN"}},
+		{in: "EURMXN", wantErr: true},
+		{in: "EUR/MXN/USD", wantErr: true},
+		{in: "EU/MXN", wantErr: true},
+		{in: "", wantErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.in, func(t *testing.T) {
+			got, err := ParsePair(tc.in)
+			if tc.wantErr {
+				if !errors.Is(err, ErrInvalidPairFormat) {
+					t.Fatalf("ParsePair(%q) error = %v, want ErrInvalidPairFormat", tc.in, err)
+				}
+				return
+			}
+			if err != nil || got != tc.want {
+				t.Fatalf("ParsePair(%q) = %v, %v, want %v", tc.in, got, err, tc.want)
+			}
+		})
+	}
+}
+
+func TestNormalizePair(t *testing.T) {
+	tests := map[string]string{
+		"eur/mxn":      "EUR/MXN",
+		" EUR /MXN":    "EUR/MXN",
+		"EUR/MXN":      "EUR/MXN",
+		"\teur / mxn ": "EUR/MXN",
+		" eur":         "EUR",
+		"EUR/M1N":      "EUR/M1N", // Left for ParsePair to reject.
+	}
+	for in, want := range tests {
+		if got := NormalizePair(in); got != want {
+			t.Errorf("NormalizePair(%q) = %q, want %q", in, got, want)
+		}
+	}
+	if got := NormalizeCode(" usd\n"); got != "USD" {
+		t.Errorf("NormalizeCode = %q, want USD", got)
+	}
+}
+
+func TestCanonicalizePairs(t *testing.T) {
+	eurMXN, gbpJPY := Pair{Base: "EUR", Quote: "MXN"}, Pair{Base: "GBP", Quote: "JPY"}
+	entries, unique := CanonicalizePairs([]string{
+		"EUR/MXN", "eur/mxn", "gbp/jpy", "EUR-MXN", " EUR / MXN ", "", "GBP/JPY", "EUR/MX1",
+	})
+
+	want := []PairEntry{
+		{Pair: eurMXN, DuplicateOf: -1},
+		{Pair: eurMXN, DuplicateOf: 0},
+		{Pair: gbpJPY, DuplicateOf: -1},
+		{Err: ErrInvalidPairFormat, DuplicateOf: -1}, // Only "/" separates codes.
+		{Pair: eurMXN, DuplicateOf: 0},
+		{Err: ErrInvalidPairFormat, DuplicateOf: -1},
+		{Pair: gbpJPY, DuplicateOf: 2},
+		{Err: ErrInvalidPairFormat, DuplicateOf: -1},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("Expected %d entries, got %+v", len(want), entries)
+	}
+	for i, w := range want {
+		if got := entries[i]; got.Pair != w.Pair || !errors.Is(got.Err, w.Err) || got.DuplicateOf != w.DuplicateOf {
+			t.Errorf("Entry %d: expected %+v, got %+v", i, w, got)
+		}
+	}
+	if !reflect.DeepEqual(unique, []Pair{eurMXN, gbpJPY}) {
+		t.Errorf("Expected the unique pairs in first-seen order, got %v", unique)
+	}
+
+	if entries, unique := CanonicalizePairs(nil); len(entries) != 0 || len(unique) != 0 {
+		t.Errorf("Expected nothing for an empty list, got %v, %v", entries, unique)
+	}
+}
+
+func TestPair(t *testing.T) {
+	p, err := NewPair("eur", "mxn")
+	if err != nil {
+		t.Fatalf("NewPair: %v", err)
+	}
+	if got := p.String(); got != "EUR/MXN" {
+		t.Errorf("String() = %q, want EUR/MXN", got)
+	}
+	if got := p.Inverse(); got != (Pair{Base: "MXN", Quote: "EUR"}) {
+		t.Errorf("Inverse() = %v, want MXN/EUR", got)
+	}
+	if got := p.CacheKey(); got != "{EUR:MXN}" {
+		t.Errorf("CacheKey() = %q, want {EUR:MXN}", got)
+	}
+	if _, err := NewPair("EUR", "M1N"); !errors.Is(err, ErrInvalidPairFormat) {
+		t.Errorf("NewPair(EUR, M1N) error = %v, want ErrInvalidPairFormat", err)
+	}
+}
+
+func TestUpdateQuotePayload_JSON(t *testing.T) {
+	// Tasks already queued by earlier releases must still decode, so the pair stays
+	// two top-level fields.
+	payload := UpdateQuotePayload{UpdateID: "u1", Pair: Pair{Base: "EUR", Quote: "MXN"}, Provider: "frankfurter"}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const want = `{"update_id":"u1","base":"EUR","quote":"MXN","provider":"frankfurter"}`
+	if string(data) != want {
+		t.Errorf("Marshal = %s, want %s", data, want)
+	}
+}
+
 func TestRequestQuoteUpdate_Validation(t *testing.T) {
 	logger, _ := zap.NewDevelopment()
 	sugar := logger.Sugar()
@@ -117,13 +311,18 @@ func TestRequestQuoteUpdate_Validation(t *testing.T) {
 		t.Run(tc.pair, func(t *testing.T) {
 			repo := &mockQuoteRepo{}
 			// No taskEnqueuer needed for validation errors
-			svc := NewQuoteService(repo, nil, v, nil, nil, sugar, testCacheCfg)
-
-			_, _, err := svc.RequestQuoteUpdate(context.Background(), tc.pair)
+			svc := NewQuoteService(QuoteServiceDeps{
+				Repo:        repo,
+				Validator:   v,
+				Logger:      sugar,
+				CacheConfig: testCacheCfg,
+			})
+
+			_, err := svc.RequestQuoteUpdate(context.Background(), tc.pair, UpdateOptions{})
 			if tc.shouldErr && err == nil {
 				t.Errorf("Expected error for pair %q, got nil", tc.pair)
 			}
-			if tc.shouldErr && err != tc.errType {
+			if tc.shouldErr && !errors.Is(err, tc.errType) {
 				t.Errorf("Expected error %v, got %v", tc.errType, err)
 			}
 		})
@@ -155,10 +354,15 @@ func TestGetLatestQuote_Validation(t *testing.T) {
 	for _, tc := range tests {
 		t.Run(tc.base+"/"+tc.quote, func(t *testing.T) {
 			repo := &mockQuoteRepo{}
-			svc := NewQuoteService(repo, nil, v, nil, nil, sugar, testCacheCfg)
-
-			_, err := svc.GetLatestQuote(context.Background(), tc.base, tc.quote)
-			if tc.shouldErr && err != tc.errType {
+			svc := NewQuoteService(QuoteServiceDeps{
+				Repo:        repo,
+				Validator:   v,
+				Logger:      sugar,
+				CacheConfig: testCacheCfg,
+			})
+
+			_, err := svc.GetLatestQuote(context.Background(), Pair{Base: tc.base, Quote: tc.quote})
+			if tc.shouldErr && !errors.Is(err, tc.errType) {
 				t.Errorf("Expected error %v for %s/%s, got %v", tc.errType, tc.base, tc.quote, err)
 			}
 		})
@@ -170,7 +374,7 @@ func TestGetQuoteResult_InvalidUUID(t *testing.T) {
 	sugar := logger.Sugar()
 	v := NewValidator()
 
-	svc := NewQuoteService(nil, nil, v, nil, nil, sugar, testCacheCfg)
+	svc := NewQuoteService(QuoteServiceDeps{Validator: v, Logger: sugar, CacheConfig: testCacheCfg})
 
 	_, err := svc.GetQuoteResult(context.Background(), "not-a-uuid")
 	if !errors.Is(err, ErrInvalidUpdateID) {
@@ -178,26 +382,68 @@ func TestGetQuoteResult_InvalidUUID(t *testing.T) {
 	}
 }
 
+func TestGetStatusEvents(t *testing.T) {
+	const id = "123e4567-e89b-12d3-a456-426614174000"
+	at := time.Date(2025, 12, 1, 10, 15, 30, 0, time.UTC)
+	price := "1.080000"
+	repo := &mockQuoteRepo{
+		getStatusEventsFunc: func(ctx context.Context, gotID string) ([]repository.StatusEvent, error) {
+			if gotID != id {
+				t.Errorf("Expected id %s, got %s", id, gotID)
+			}
+			return []repository.StatusEvent{
+				{Status: repository.StatusPending, At: at.Add(-2 * time.Second)},
+				{Status: repository.StatusRunning, At: at.Add(-time.Second)},
+				{Status: repository.StatusSuccess, At: at, Detail: &price},
+			}, nil
+		},
+	}
+	svc := NewQuoteService(QuoteServiceDeps{Repo: repo, CacheConfig: testCacheCfg})
+
+	events, err := svc.GetStatusEvents(context.Background(), id)
+	if err != nil {
+		t.Fatalf("GetStatusEvents: %v", err)
+	}
+	if len(events) != 3 {
+		t.Fatalf("Expected 3 events, got %d", len(events))
+	}
+	if events[2].Status != string(repository.StatusSuccess) || events[2].At != FormatTimestamp(at) {
+		t.Errorf("Unexpected last event %+v", events[2])
+	}
+	if events[2].Detail == nil || *events[2].Detail != price {
+		t.Errorf("Expected detail %s, got %v", price, events[2].Detail)
+	}
+
+	if _, err := svc.GetStatusEvents(context.Background(), "not-a-uuid"); !errors.Is(err, ErrInvalidUpdateID) {
+		t.Errorf("Expected ErrInvalidUpdateID, got %v", err)
+	}
+}
+
 func TestProcessUpdate_Success(t *testing.T) {
 	logger, _ := zap.NewDevelopment()
 	sugar := logger.Sugar()
 	v := NewValidator()
+	fetchedAt := time.Date(2024, 6, 14, 0, 0, 0, 0, time.UTC)
 
 	repo := &mockQuoteRepo{
-		markRunningFunc: func(ctx context.Context, id string) error {
+		getByIDFunc: pendingRecord,
+		markRunningFunc: func(ctx context.Context, id string, version int64) error {
 			return nil
 		},
-		markSuccessFunc: func(ctx context.Context, id, price string) error {
+		markSuccessFunc: func(ctx context.Context, id string, version int64, price string, rateTimestamp time.Time) error {
 			if price != "18.7543" {
 				t.Errorf("Expected price 18.7543, got %s", price)
 			}
+			if !rateTimestamp.Equal(fetchedAt) {
+				t.Errorf("Expected rate timestamp %v, got %v", fetchedAt, rateTimestamp)
+			}
 			return nil
 		},
 	}
 
 	provider := &mockRatesProvider{
 		getRateFunc: func(base string, quote string) (string, time.Time, error) {
-			return "18.7543", time.Now(), nil
+			return "18.7543", fetchedAt, nil
 		},
 	}
 
@@ -210,9 +456,16 @@ func TestProcessUpdate_Success(t *testing.T) {
 
 	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
 
-	svc := NewQuoteService(repo, provider, v, nil, rdb, sugar, testCacheCfg)
+	svc := NewQuoteService(QuoteServiceDeps{
+		Repo:        repo,
+		Provider:    provider,
+		Validator:   v,
+		Cache:       rdb,
+		Logger:      sugar,
+		CacheConfig: testCacheCfg,
+	})
 
-	err = svc.ProcessUpdate(context.Background(), "test-id", "EUR", "MXN")
+	err = svc.ProcessUpdate(context.Background(), UpdateQuotePayload{UpdateID: "test-id", Pair: Pair{Base: "EUR", Quote: "MXN"}})
 	if err != nil {
 		t.Errorf("Expected no error, got %v", err)
 	}
@@ -226,224 +479,1380 @@ func TestProcessUpdate_Success(t *testing.T) {
 	if price != "18.7543" {
 		t.Errorf("Expected cached price 18.7543, got %s", price)
 	}
-}
-
-func TestProcessUpdate_Failure(t *testing.T) {
-	logger, _ := zap.NewDevelopment()
-	sugar := logger.Sugar()
-	v := NewValidator()
-
-	repo := &mockQuoteRepo{
-		markRunningFunc: func(ctx context.Context, id string) error {
-			return nil
-		},
-		markFailedFunc: func(ctx context.Context, id, errorMsg string) error {
-			if errorMsg == "" {
-				t.Error("Expected error message, got empty string")
-			}
-			return nil
-		},
+	if got := mr.HGet(key, "rate_timestamp"); got != "2024-06-14T00:00:00.000000Z" {
+		t.Errorf("Expected cached rate_timestamp 2024-06-14T00:00:00.000000Z, got %s", got)
 	}
-
-	provider := &mockRatesProvider{
-		getRateFunc: func(base string, quote string) (string, time.Time, error) {
-			return "", time.Time{}, errors.New("provider error")
-		},
+	if got := mr.HGet(key, "updated_at"); got == "2024-06-14T00:00:00.000000Z" {
+		t.Errorf("Expected cached updated_at to be the write time, got the provider time %s", got)
 	}
-
-	svc := NewQuoteService(repo, provider, v, nil, nil, sugar, testCacheCfg)
-
-	err := svc.ProcessUpdate(context.Background(), "test-id", "EUR", "MXN")
-	if err == nil {
-		t.Error("Expected error, got nil")
+	if mr.Exists("latest:{MXN:EUR}") {
+		t.Errorf("Expected no inverse price unless enabled")
 	}
-}
-
-func TestGetLatestQuote_Cached(t *testing.T) {
-	logger, _ := zap.NewDevelopment()
-	sugar := logger.Sugar()
-	v := NewValidator()
 
-	// Use miniredis
-	mr, err := miniredis.Run()
+	res, err := svc.GetLatestQuote(context.Background(), Pair{Base: "EUR", Quote: "MXN"})
 	if err != nil {
-		t.Fatalf("failed to start miniredis: %v", err)
+		t.Fatalf("GetLatestQuote: %v", err)
 	}
-	defer mr.Close()
-
-	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	if res.RateTimestamp == nil || *res.RateTimestamp != "2024-06-14T00:00:00Z" {
+		t.Errorf("Expected RateTimestamp 2024-06-14T00:00:00Z, got %v", res.RateTimestamp)
+	}
+}
 
-	// Set value in cache
-	key := "latest:{EUR:MXN}"
-	mr.HSet(key, "price", "18.7543")
-	mr.HSet(key, "updated_at", time.Now().Format(time.RFC3339))
+// TestProcessUpdate_DropsStaleResult caches the FAILED record of a previous attempt
+// while the retry runs, as a concurrent GET /quotes/{id} that read it before the retry
+// started would, and expects the update to drop it whether it succeeds or fails again.
+func TestProcessUpdate_DropsStaleResult(t *testing.T) {
+	for _, fail := range []bool{false, true} {
+		t.Run(fmt.Sprintf("fail=%v", fail), func(t *testing.T) {
+			mr := miniredis.RunT(t)
+			var svc *QuoteService
+			msg := "provider timeout"
+			failed := &repository.Quote{ID: "test-id", Base: "EUR", Quote: "MXN", Status: repository.StatusFailed,
+				ErrorMsg: &msg, RequestedAt: time.Now()}
+			staleRead := func() { svc.cacheSetQuoteResult(context.Background(), failed) }
+			repo := &mockQuoteRepo{
+				getByIDFunc:     pendingRecord,
+				markRunningFunc: func(context.Context, string, int64) error { return nil },
+				markSuccessFunc: func(context.Context, string, int64, string, time.Time) error { staleRead(); return nil },
+				markFailedFunc: func(context.Context, string, int64, repository.ErrorCode, string) error {
+					staleRead()
+					return nil
+				},
+			}
+			prov := &mockRatesProvider{getRateFunc: func(string, string) (string, time.Time, error) {
+				if fail {
+					return "", time.Time{}, errors.New("provider error")
+				}
+				return "18.7543", time.Now(), nil
+			}}
+			svc = NewQuoteService(QuoteServiceDeps{
+				Repo: repo, Provider: prov, Validator: NewValidator(), CacheConfig: testCacheCfg,
+				Cache: redis.NewClient(&redis.Options{Addr: mr.Addr()}), Logger: zap.NewNop().Sugar(),
+			})
+
+			_ = svc.ProcessUpdate(context.Background(), UpdateQuotePayload{UpdateID: "test-id", Pair: Pair{Base: "EUR", Quote: "MXN"}})
+			if key := svc.quoteResultCacheKey("test-id"); mr.Exists(key) {
+				t.Errorf("Expected the stale result %s to be dropped", key)
+			}
+		})
+	}
+}
 
-	// Repo should NOT be called if cached
+func TestProcessUpdate_CachesInverse(t *testing.T) {
+	t0 := time.Date(2024, 6, 14, 0, 0, 0, 0, time.UTC)
+	rates := map[Pair]struct {
+		price string
+		at    time.Time
+	}{
+		{Base: "EUR", Quote: "MXN"}: {"20", t0},
+		// Fetched directly, and older than the inverse of EUR/MXN.
+		{Base: "MXN", Quote: "EUR"}: {"0.0499", t0.Add(-time.Minute)},
+	}
+	var stored []string
 	repo := &mockQuoteRepo{
-		getLatestSuccessFunc: func(ctx context.Context, base, quote string) (*repository.Quote, error) {
-			t.Error("Repo should not be called when value is cached")
+		getByIDFunc:     pendingRecord,
+		markRunningFunc: func(context.Context, string, int64) error { return nil },
+		markSuccessFunc: func(_ context.Context, _ string, _ int64, price string, _ time.Time) error {
+			stored = append(stored, price)
+			return nil
+		},
+		getLatestSuccessFunc: func(_ context.Context, pair Pair) (*repository.Quote, error) {
+			t.Errorf("Expected %s to be served from the cache", pair)
 			return nil, nil
 		},
 	}
-
-	svc := NewQuoteService(repo, nil, v, nil, rdb, sugar, testCacheCfg)
-
-	res, err := svc.GetLatestQuote(context.Background(), "EUR", "MXN")
-	if err != nil {
-		t.Fatalf("Expected no error, got %v", err)
+	prov := &mockRatesProvider{getRateFunc: func(base, quote string) (string, time.Time, error) {
+		r := rates[Pair{Base: base, Quote: quote}]
+		return r.price, r.at, nil
+	}}
+	mr := miniredis.RunT(t)
+	cfg := testCacheCfg
+	cfg.InverseEnabled, cfg.InverseTTLSec, cfg.InversePrecision = true, 30, 6
+	svc := NewQuoteService(QuoteServiceDeps{
+		Repo: repo, Provider: prov, Validator: NewValidator(), CacheConfig: cfg,
+		Cache: redis.NewClient(&redis.Options{Addr: mr.Addr()}), Logger: zap.NewNop().Sugar(),
+	})
+	ctx := context.Background()
+	eurMXN, mxnEUR := Pair{Base: "EUR", Quote: "MXN"}, Pair{Base: "MXN", Quote: "EUR"}
+	update := func(pair Pair) {
+		t.Helper()
+		if err := svc.ProcessUpdate(ctx, UpdateQuotePayload{UpdateID: "test-id", Pair: pair}); err != nil {
+			t.Fatalf("ProcessUpdate %s: %v", pair, err)
+		}
 	}
 
-	if res.Price == nil || *res.Price != "18.7543" {
-		t.Errorf("Expected price 18.7543, got %v", res.Price)
+	update(eurMXN)
+	if got := mr.HGet("latest:{EUR:MXN}", "price"); got != "20" || mr.HGet("latest:{EUR:MXN}", "derived") != "" {
+		t.Errorf("Expected the fetched price 20, got %q", got)
 	}
-}
-
-func TestGetLatestQuote_NotCached(t *testing.T) {
-	logger, _ := zap.NewDevelopment()
-	sugar := logger.Sugar()
-	v := NewValidator()
-
-	// Use miniredis
-	mr, err := miniredis.Run()
-	if err != nil {
-		t.Fatalf("failed to start miniredis: %v", err)
+	inv := "latest:{MXN:EUR}"
+	if got := mr.HGet(inv, "price"); got != "0.050000" || mr.HGet(inv, "derived") != derivedInverse ||
+		mr.HGet(inv, "rate_timestamp") != formatStoredTime(t0) || mr.HGet(inv, "update_id") != "" {
+		t.Errorf("Expected the derived inverse 0.050000 without an update_id, got %q", got)
 	}
-	defer mr.Close()
-
-	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
-
-	now := time.Now().Truncate(time.Second)
-	price := "18.7543"
-
-	repo := &mockQuoteRepo{
-		getLatestSuccessFunc: func(ctx context.Context, base, quote string) (*repository.Quote, error) {
-			return &repository.Quote{
-				Base:      base,
-				Quote:     quote,
-				Price:     &price,
-				UpdatedAt: &now,
-				Status:    repository.StatusSuccess,
-			}, nil
-		},
+	if ttl := mr.TTL(inv); ttl != 30*time.Second {
+		t.Errorf("Expected the inverse TTL of 30s, got %v", ttl)
+	}
+	if len(stored) != 1 {
+		t.Errorf("Expected only the fetched price in the DB, got %v", stored)
+	}
+	if res, err := svc.GetLatestQuote(ctx, mxnEUR); err != nil || res.Price == nil || *res.Price != "0.050000" || res.ServedFrom != ServedFromRedis {
+		t.Errorf("Expected MXN/EUR 0.050000 from the cache, got %+v (err %v)", res, err)
 	}
 
-	svc := NewQuoteService(repo, nil, v, nil, rdb, sugar, testCacheCfg)
-
-	res, err := svc.GetLatestQuote(context.Background(), "EUR", "MXN")
-	if err != nil {
-		t.Fatalf("Expected no error, got %v", err)
+	// A fetched price replaces the derived one even though it is older, and its own
+	// inverse does not replace the fetched EUR/MXN.
+	update(mxnEUR)
+	if got := mr.HGet(inv, "price"); got != "0.0499" || mr.HGet(inv, "derived") != "" || mr.TTL(inv) != time.Hour {
+		t.Errorf("Expected the fetched price 0.0499 to win, got %q", got)
+	}
+	if got := mr.HGet("latest:{EUR:MXN}", "price"); got != "20" {
+		t.Errorf("Expected the fetched EUR/MXN to stay, got %q", got)
 	}
 
-	if res.Price == nil || *res.Price != price {
-		t.Errorf("Expected price %s, got %v", price, res.Price)
+	// Nor does a newer EUR/MXN replace the fetched MXN/EUR with its inverse.
+	rates[eurMXN] = struct {
+		price string
+		at    time.Time
+	}{"21", t0.Add(time.Minute)}
+	update(eurMXN)
+	if got := mr.HGet(inv, "price"); got != "0.0499" {
+		t.Errorf("Expected the fetched MXN/EUR to stay, got %q", got)
 	}
+}
 
-	// Verify it was cached
-	key := "latest:{EUR:MXN}"
-	if !mr.Exists(key) {
-		t.Errorf("Expected key %s to be cached", key)
+// TestProcessUpdate_KeepsRatePrecision runs rates of BTC/JPY and IDR/BTC magnitude
+// from a provider response through the stored update and the latest-price cache. The
+// codes are placed on supported pairs, since the validator lists no crypto currencies.
+func TestProcessUpdate_KeepsRatePrecision(t *testing.T) {
+	tests := []struct {
+		pair      Pair
+		raw, want string
+	}{
+		{Pair{Base: "EUR", Quote: "JPY"}, "15234567.123456789012", "15234567.123456789012"},
+		{Pair{Base: "JPY", Quote: "GBP"}, "0.000000001234567891", "0.000000001234567891"},
+		{Pair{Base: "JPY", Quote: "GBP"}, "1.234567891e-9", "0.000000001234567891"},
 	}
-	cachedPrice := mr.HGet(key, "price")
-	if cachedPrice != price {
-		t.Errorf("Expected cached price %s, got %s", price, cachedPrice)
+	for _, tc := range tests {
+		t.Run(tc.raw, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				_, _ = fmt.Fprintf(w, `{"amount":1.0,"base":%q,"date":"2025-12-01","rates":{%q:%s}}`,
+					tc.pair.Base, tc.pair.Quote, tc.raw)
+			}))
+			defer srv.Close()
+
+			var stored string
+			repo := &mockQuoteRepo{
+				getByIDFunc:     pendingRecord,
+				markRunningFunc: func(context.Context, string, int64) error { return nil },
+				markSuccessFunc: func(_ context.Context, _ string, _ int64, price string, _ time.Time) error {
+					stored = price
+					return nil
+				},
+			}
+			mr := miniredis.RunT(t)
+			svc := NewQuoteService(QuoteServiceDeps{
+				Repo:        repo,
+				Provider:    provider.NewFrankfurterProvider(srv.URL, 1),
+				Validator:   NewValidator(),
+				Cache:       redis.NewClient(&redis.Options{Addr: mr.Addr()}),
+				Logger:      zap.NewNop().Sugar(),
+				CacheConfig: testCacheCfg,
+			})
+
+			if err := svc.ProcessUpdate(context.Background(), UpdateQuotePayload{UpdateID: "test-id", Pair: tc.pair}); err != nil {
+				t.Fatalf("ProcessUpdate: %v", err)
+			}
+			if stored != tc.want {
+				t.Errorf("Expected stored price %s, got %s", tc.want, stored)
+			}
+			res, err := svc.GetLatestQuote(context.Background(), tc.pair)
+			if err != nil {
+				t.Fatalf("GetLatestQuote: %v", err)
+			}
+			if res.Price == nil || *res.Price != tc.want {
+				t.Errorf("Expected cached price %s, got %v", tc.want, res.Price)
+			}
+		})
 	}
 }
 
-// Mock task enqueuer
-type mockTaskEnqueuer struct {
-	enqueueUpdateTaskFunc func(ctx context.Context, payload UpdateQuotePayload) error
+type rateMoveCall struct {
+	oldPrice, newPrice string
+	oldAt, newAt       time.Time
 }
 
-func (m *mockTaskEnqueuer) EnqueueUpdateTask(ctx context.Context, payload UpdateQuotePayload) error {
-	return m.enqueueUpdateTaskFunc(ctx, payload)
+type fakeRateMoveObserver struct {
+	calls []rateMoveCall
 }
 
-func TestRequestQuoteUpdate_EnqueueSuccess(t *testing.T) {
-	logger, _ := zap.NewDevelopment()
-	sugar := logger.Sugar()
-	v := NewValidator()
+func (f *fakeRateMoveObserver) ObserveRateMove(_ context.Context, _, _, oldPrice, newPrice string, oldAt, newAt time.Time) {
+	f.calls = append(f.calls, rateMoveCall{oldPrice, newPrice, oldAt, newAt})
+}
 
-	repo := &mockQuoteRepo{
-		createUpdateFunc: func(ctx context.Context, base, quote, id string) (string, error) {
-			// Return the same ID to indicate a new record was created
-			return id, nil
+func TestProcessUpdate_ObservesRateMove(t *testing.T) {
+	prevAt := time.Date(2024, 6, 13, 0, 0, 0, 0, time.UTC)
+	fetchedAt := prevAt.Add(24 * time.Hour)
+	prevPrice := "18.500000"
+
+	tests := []struct {
+		name      string
+		cached    bool
+		dbLatest  *repository.Quote
+		wantCalls []rateMoveCall
+	}{
+		{
+			name:      "previous from cache",
+			cached:    true,
+			wantCalls: []rateMoveCall{{prevPrice, "18.7543", prevAt, fetchedAt}},
+		},
+		{
+			name:      "previous from DB",
+			dbLatest:  &repository.Quote{Price: &prevPrice, RateTimestamp: &prevAt, UpdatedAt: &fetchedAt},
+			wantCalls: []rateMoveCall{{prevPrice, "18.7543", prevAt, fetchedAt}},
+		},
+		{
+			name: "no previous rate",
 		},
 	}
 
-	enqueueCalled := false
-	enqueuer := &mockTaskEnqueuer{
-		enqueueUpdateTaskFunc: func(ctx context.Context, payload UpdateQuotePayload) error {
-			enqueueCalled = true
-			if payload.Base != "EUR" || payload.Quote != "MXN" {
-				t.Errorf("Expected pair EUR/MXN, got %s/%s", payload.Base, payload.Quote)
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mr := miniredis.RunT(t)
+			rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+
+			repo := &mockQuoteRepo{
+				getByIDFunc:     pendingRecord,
+				markRunningFunc: func(context.Context, string, int64) error { return nil },
+				markSuccessFunc: func(context.Context, string, int64, string, time.Time) error { return nil },
+				getLatestSuccessFunc: func(context.Context, Pair) (*repository.Quote, error) {
+					return tc.dbLatest, nil
+				},
+			}
+			provider := &mockRatesProvider{
+				getRateFunc: func(string, string) (string, time.Time, error) { return "18.7543", fetchedAt, nil },
+			}
+			observer := &fakeRateMoveObserver{}
+			svc := NewQuoteService(QuoteServiceDeps{
+				Repo:        repo,
+				Provider:    provider,
+				Cache:       rdb,
+				RateMoves:   observer,
+				CacheConfig: testCacheCfg,
+			})
+			if tc.cached {
+				svc.cacheSetLatest(context.Background(), Pair{Base: "EUR", Quote: "MXN"}, "", prevPrice, prevAt, prevAt)
 			}
-			return nil
-		},
-	}
 
-	svc := NewQuoteService(repo, nil, v, enqueuer, nil, sugar, testCacheCfg)
+			if err := svc.ProcessUpdate(context.Background(), UpdateQuotePayload{UpdateID: "test-id", Pair: Pair{Base: "EUR", Quote: "MXN"}}); err != nil {
+				t.Fatalf("ProcessUpdate: %v", err)
+			}
 
-	updateID, status, err := svc.RequestQuoteUpdate(context.Background(), "EUR/MXN")
-	if err != nil {
-		t.Fatalf("Expected no error, got %v", err)
-	}
-	if status != string(repository.StatusPending) {
-		t.Errorf("Expected status %s, got %s", repository.StatusPending, status)
-	}
-	if updateID == "" {
-		t.Error("Expected non-empty updateID")
-	}
-	if !enqueueCalled {
-		t.Error("Expected EnqueueUpdateTask to be called")
+			if len(observer.calls) != len(tc.wantCalls) {
+				t.Fatalf("expected %d observer calls, got %d", len(tc.wantCalls), len(observer.calls))
+			}
+			for i, want := range tc.wantCalls {
+				got := observer.calls[i]
+				if got.oldPrice != want.oldPrice || got.newPrice != want.newPrice ||
+					!got.oldAt.Equal(want.oldAt) || !got.newAt.Equal(want.newAt) {
+					t.Errorf("call %d: expected %+v, got %+v", i, want, got)
+				}
+			}
+		})
 	}
 }
 
-func TestRequestQuoteUpdate_EnqueueFailure(t *testing.T) {
+func TestProcessUpdate_Failure(t *testing.T) {
 	logger, _ := zap.NewDevelopment()
 	sugar := logger.Sugar()
 	v := NewValidator()
 
-	markFailedCalled := false
 	repo := &mockQuoteRepo{
-		createUpdateFunc: func(ctx context.Context, base, quote, id string) (string, error) {
-			return id, nil
+		getByIDFunc: pendingRecord,
+		markRunningFunc: func(ctx context.Context, id string, version int64) error {
+			return nil
 		},
-		markFailedFunc: func(ctx context.Context, id, errorMsg string) error {
-			markFailedCalled = true
-			if errorMsg != "enqueue error" {
-				t.Errorf("Expected error message 'enqueue error', got %q", errorMsg)
+		markFailedFunc: func(ctx context.Context, id string, version int64, code repository.ErrorCode, errorMsg string) error {
+			if errorMsg == "" {
+				t.Error("Expected error message, got empty string")
 			}
 			return nil
 		},
 	}
 
-	enqueuer := &mockTaskEnqueuer{
-		enqueueUpdateTaskFunc: func(ctx context.Context, payload UpdateQuotePayload) error {
-			return errors.New("redis connection refused")
+	provider := &mockRatesProvider{
+		getRateFunc: func(base string, quote string) (string, time.Time, error) {
+			return "", time.Time{}, errors.New("provider error")
 		},
 	}
 
-	svc := NewQuoteService(repo, nil, v, enqueuer, nil, sugar, testCacheCfg)
+	svc := NewQuoteService(QuoteServiceDeps{
+		Repo:        repo,
+		Provider:    provider,
+		Validator:   v,
+		Logger:      sugar,
+		CacheConfig: testCacheCfg,
+	})
 
-	_, _, err := svc.RequestQuoteUpdate(context.Background(), "EUR/MXN")
-	if !errors.Is(err, ErrInternalQueue) {
-		t.Errorf("Expected ErrInternalQueue, got %v", err)
-	}
-	if !markFailedCalled {
-		t.Error("Expected MarkFailed to be called")
+	err := svc.ProcessUpdate(context.Background(), UpdateQuotePayload{UpdateID: "test-id", Pair: Pair{Base: "EUR", Quote: "MXN"}})
+	if err == nil {
+		t.Error("Expected error, got nil")
 	}
 }
 
-func TestRequestQuoteUpdate_ExistingPending(t *testing.T) {
-	logger, _ := zap.NewDevelopment()
-	sugar := logger.Sugar()
-	v := NewValidator()
-
+func TestProcessUpdate_AllProvidersFailed(t *testing.T) {
+	var failedWith string
+	repo := &mockQuoteRepo{
+		getByIDFunc:     pendingRecord,
+		markRunningFunc: func(ctx context.Context, id string, version int64) error { return nil },
+		markFailedFunc: func(ctx context.Context, id string, version int64, code repository.ErrorCode, errorMsg string) error {
+			failedWith = errorMsg
+			return nil
+		},
+	}
+	failing := &mockRatesProvider{getRateFunc: func(string, string) (string, time.Time, error) {
+		return "", time.Time{}, errors.New("provider error")
+	}}
+
+	svc := NewQuoteService(QuoteServiceDeps{
+		Repo:        repo,
+		Provider:    provider.NewExchangeProviderFacade(failing, failing),
+		CacheConfig: testCacheCfg,
+	})
+
+	err := svc.ProcessUpdate(context.Background(), UpdateQuotePayload{UpdateID: "test-id", Pair: Pair{Base: "EUR", Quote: "MXN"}})
+	if !errors.Is(err, ErrProviderUnavailable) || !errors.Is(err, provider.ErrAllProvidersUnavailable) {
+		t.Fatalf("Expected ErrProviderUnavailable wrapping ErrAllProvidersUnavailable, got %v", err)
+	}
+	if failedWith != err.Error() {
+		t.Errorf("Expected record failed with %q, got %q", err.Error(), failedWith)
+	}
+}
+
+// unavailableProvider reports itself unavailable, like a provider with an open circuit.
+type unavailableProvider struct{ mockRatesProvider }
+
+func (unavailableProvider) IsProviderAvailable(string, string) bool { return false }
+
+func TestProcessUpdate_ProviderUnavailable(t *testing.T) {
+	var failedWith string
+	repo := &mockQuoteRepo{
+		getByIDFunc: pendingRecord,
+		markRunningFunc: func(ctx context.Context, id string, version int64) error {
+			t.Error("MarkRunning must not be called when the provider is unavailable")
+			return nil
+		},
+		markFailedFunc: func(ctx context.Context, id string, version int64, code repository.ErrorCode, errorMsg string) error {
+			failedWith = errorMsg
+			return nil
+		},
+	}
+	prov := &unavailableProvider{mockRatesProvider{
+		getRateFunc: func(string, string) (string, time.Time, error) {
+			t.Error("GetRate must not be called when the provider is unavailable")
+			return "", time.Time{}, nil
+		},
+	}}
+
+	svc := NewQuoteService(QuoteServiceDeps{
+		Repo:        repo,
+		Provider:    prov,
+		Validator:   NewValidator(),
+		Logger:      zap.NewNop().Sugar(),
+		CacheConfig: testCacheCfg,
+	})
+
+	err := svc.ProcessUpdate(context.Background(), UpdateQuotePayload{UpdateID: "test-id", Pair: Pair{Base: "EUR", Quote: "MXN"}})
+	if !errors.Is(err, ErrServiceUnavailable) {
+		t.Fatalf("Expected ErrServiceUnavailable, got %v", err)
+	}
+	if failedWith != ErrServiceUnavailable.Error() {
+		t.Errorf("Expected record failed with %q, got %q", ErrServiceUnavailable.Error(), failedWith)
+	}
+}
+
+func TestProcessUpdate_ThreadsVersion(t *testing.T) {
+	var runningAt, successAt int64
+	repo := &mockQuoteRepo{
+		getByIDFunc: pendingRecord,
+		markRunningFunc: func(_ context.Context, _ string, version int64) error {
+			runningAt = version
+			return nil
+		},
+		markSuccessFunc: func(_ context.Context, _ string, version int64, _ string, _ time.Time) error {
+			successAt = version
+			return nil
+		},
+	}
+	svc := NewQuoteService(QuoteServiceDeps{
+		Repo: repo,
+		Provider: &mockRatesProvider{getRateFunc: func(string, string) (string, time.Time, error) {
+			return "18.7", time.Now(), nil
+		}},
+		Logger:      zap.NewNop().Sugar(),
+		CacheConfig: testCacheCfg,
+		InstanceID:  "worker-1-a1b2c3",
+	})
+
+	if err := svc.ProcessUpdate(context.Background(), UpdateQuotePayload{UpdateID: "test-id", Pair: Pair{Base: "EUR", Quote: "MXN"}}); err != nil {
+		t.Fatalf("ProcessUpdate: %v", err)
+	}
+	if runningAt != repository.InitialVersion || successAt != repository.InitialVersion+1 {
+		t.Errorf("Expected MarkRunning at %d and MarkSuccess at %d, got %d and %d",
+			repository.InitialVersion, repository.InitialVersion+1, runningAt, successAt)
+	}
+	if repo.lastInstanceID != "worker-1-a1b2c3" {
+		t.Errorf("Expected MarkRunning to record the instance, got %q", repo.lastInstanceID)
+	}
+}
+
+func TestProcessUpdate_TakesOverRunningRecord(t *testing.T) {
+	var successAt int64
+	repo := &mockQuoteRepo{
+		getByIDFunc: func(_ context.Context, id string) (*repository.Quote, error) {
+			return &repository.Quote{ID: id, Status: repository.StatusRunning, Version: 2}, nil
+		},
+		markRunningFunc: func(context.Context, string, int64) error {
+			t.Error("MarkRunning must not be called for a RUNNING record")
+			return nil
+		},
+		markSuccessFunc: func(_ context.Context, _ string, version int64, _ string, _ time.Time) error {
+			successAt = version
+			return nil
+		},
+	}
+	svc := NewQuoteService(QuoteServiceDeps{
+		Repo: repo,
+		Provider: &mockRatesProvider{getRateFunc: func(string, string) (string, time.Time, error) {
+			return "18.7", time.Now(), nil
+		}},
+		Logger:      zap.NewNop().Sugar(),
+		CacheConfig: testCacheCfg,
+	})
+
+	if err := svc.ProcessUpdate(context.Background(), UpdateQuotePayload{UpdateID: "test-id", Pair: Pair{Base: "EUR", Quote: "MXN"}}); err != nil {
+		t.Fatalf("ProcessUpdate: %v", err)
+	}
+	if successAt != 2 {
+		t.Errorf("Expected MarkSuccess at version 2, got %d", successAt)
+	}
+}
+
+func TestProcessUpdate_AlreadySucceeded(t *testing.T) {
+	repo := &mockQuoteRepo{
+		getByIDFunc: func(_ context.Context, id string) (*repository.Quote, error) {
+			return &repository.Quote{ID: id, Status: repository.StatusSuccess, Version: 3}, nil
+		},
+	}
+	svc := NewQuoteService(QuoteServiceDeps{
+		Repo: repo,
+		Provider: &mockRatesProvider{getRateFunc: func(string, string) (string, time.Time, error) {
+			t.Error("Provider must not be called for a completed update")
+			return "", time.Time{}, nil
+		}},
+		Logger:      zap.NewNop().Sugar(),
+		CacheConfig: testCacheCfg,
+	})
+
+	err := svc.ProcessUpdate(context.Background(), UpdateQuotePayload{UpdateID: "test-id", Pair: Pair{Base: "EUR", Quote: "MXN"}})
+	if !errors.Is(err, ErrAlreadyCompleted) {
+		t.Errorf("Expected ErrAlreadyCompleted, got %v", err)
+	}
+}
+
+func TestProcessUpdate_VersionConflict(t *testing.T) {
+	conflict := func(status repository.Status) error {
+		return &repository.VersionConflictError{ID: "test-id", Expected: 2, Actual: 3, Status: status}
+	}
+	tests := []struct {
+		name       string
+		runningErr error
+		successErr error
+		providerOK bool
+		failedErr  error
+		want       error
+	}{
+		{name: "success lost to completed update", providerOK: true,
+			successErr: conflict(repository.StatusSuccess), want: ErrAlreadyCompleted},
+		{name: "success lost to running update", providerOK: true,
+			successErr: conflict(repository.StatusRunning), want: ErrUpdateConflict},
+		{name: "running lost to failed update", runningErr: conflict(repository.StatusFailed), want: ErrAlreadyCompleted},
+		{name: "failure lost to completed update",
+			failedErr: conflict(repository.StatusSuccess), want: ErrAlreadyCompleted},
+		{name: "replay of a completed update",
+			runningErr: &repository.InvalidTransitionError{ID: "test-id", From: repository.StatusSuccess, To: repository.StatusRunning},
+			want:       ErrAlreadyCompleted},
+		{name: "transition not allowed from running",
+			runningErr: &repository.InvalidTransitionError{ID: "test-id", From: repository.StatusRunning, To: repository.StatusRunning},
+			want:       ErrUpdateConflict},
+		{name: "record deleted", runningErr: fmt.Errorf("%w: test-id", repository.ErrQuoteNotFound), want: ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mockQuoteRepo{
+				getByIDFunc:     pendingRecord,
+				markRunningFunc: func(context.Context, string, int64) error { return tt.runningErr },
+				markSuccessFunc: func(context.Context, string, int64, string, time.Time) error { return tt.successErr },
+				markFailedFunc:  func(context.Context, string, int64, repository.ErrorCode, string) error { return tt.failedErr },
+			}
+			svc := NewQuoteService(QuoteServiceDeps{
+				Repo: repo,
+				Provider: &mockRatesProvider{getRateFunc: func(string, string) (string, time.Time, error) {
+					if tt.runningErr != nil {
+						t.Error("Provider must not be called after MarkRunning failed")
+					}
+					if !tt.providerOK {
+						return "", time.Time{}, errors.New("provider error")
+					}
+					return "18.7", time.Now(), nil
+				}},
+				Logger:      zap.NewNop().Sugar(),
+				CacheConfig: testCacheCfg,
+			})
+
+			err := svc.ProcessUpdate(context.Background(), UpdateQuotePayload{UpdateID: "test-id", Pair: Pair{Base: "EUR", Quote: "MXN"}})
+			if !errors.Is(err, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestGetLastAttempt(t *testing.T) {
+	requestedAt := time.Date(2025, 12, 1, 9, 0, 0, 0, time.UTC)
+	updatedAt := requestedAt.Add(4 * time.Second)
+	errMsg := "unsupported currency pair"
+
+	tests := []struct {
+		name    string
+		record  *repository.Quote
+		want    *QuoteAttempt
+		wantErr error
+	}{
+		{name: "never attempted", wantErr: ErrNotFound},
+		{
+			name: "failed",
+			record: &repository.Quote{Status: repository.StatusFailed, ErrorMsg: &errMsg,
+				RequestedAt: requestedAt, UpdatedAt: &updatedAt},
+			want: &QuoteAttempt{Status: "FAILED", ErrorMsg: &errMsg, AttemptAt: FormatTimestamp(updatedAt)},
+		},
+		{
+			name:   "pending",
+			record: &repository.Quote{Status: repository.StatusPending, RequestedAt: requestedAt},
+			want:   &QuoteAttempt{Status: "PENDING", AttemptAt: FormatTimestamp(requestedAt)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mockQuoteRepo{
+				getLatestAnyFunc: func(_ context.Context, pair Pair) (*repository.Quote, error) {
+					if pair.Base != "EUR" || pair.Quote != "MXN" {
+						t.Errorf("Expected normalized EUR/MXN, got %s", pair)
+					}
+					return tt.record, nil
+				},
+			}
+			svc := NewQuoteService(QuoteServiceDeps{Repo: repo, Logger: zap.NewNop().Sugar(), CacheConfig: testCacheCfg})
+
+			got, err := svc.GetLastAttempt(context.Background(), Pair{Base: "EUR", Quote: "MXN"})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestGetLatestQuote_NegativeCache(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	ctx := context.Background()
+	fetchedAt := time.Date(2024, 6, 14, 0, 0, 0, 0, time.UTC)
+
+	dbCalls := 0
+	repo := &mockQuoteRepo{
+		getByIDFunc: pendingRecord,
+		getLatestSuccessFunc: func(context.Context, Pair) (*repository.Quote, error) {
+			dbCalls++
+			return nil, nil
+		},
+		markRunningFunc: func(context.Context, string, int64) error { return nil },
+		markSuccessFunc: func(context.Context, string, int64, string, time.Time) error { return nil },
+	}
+	cacheCfg := testCacheCfg
+	cacheCfg.NegativeCacheTTLSec = 30
+	svc := NewQuoteService(QuoteServiceDeps{
+		Repo: repo,
+		Provider: &mockRatesProvider{
+			getRateFunc: func(string, string) (string, time.Time, error) { return "18.7543", fetchedAt, nil },
+		},
+		Cache:       rdb,
+		CacheConfig: cacheCfg,
+	})
+	negKey := "latest:{EUR:MXN}:notfound"
+
+	// First miss hits the DB and stores the negative entry.
+	if _, err := svc.GetLatestQuote(ctx, Pair{Base: "EUR", Quote: "MXN"}); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	if !mr.Exists(negKey) {
+		t.Fatalf("expected negative cache key %s", negKey)
+	}
+	if ttl := mr.TTL(negKey); ttl != 30*time.Second {
+		t.Errorf("expected negative cache TTL 30s, got %v", ttl)
+	}
+
+	// Second miss is answered from the negative entry.
+	if _, err := svc.GetLatestQuote(ctx, Pair{Base: "EUR", Quote: "MXN"}); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	if dbCalls != 1 {
+		t.Fatalf("expected 1 DB call, got %d", dbCalls)
+	}
+
+	// The entry expires after its TTL.
+	mr.FastForward(31 * time.Second)
+	if _, err := svc.GetLatestQuote(ctx, Pair{Base: "EUR", Quote: "MXN"}); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	if dbCalls != 2 {
+		t.Fatalf("expected a DB call after expiry, got %d calls", dbCalls)
+	}
+
+	// A successful update clears the entry and serves the new price.
+	if err := svc.ProcessUpdate(ctx, UpdateQuotePayload{UpdateID: "test-id", Pair: Pair{Base: "EUR", Quote: "MXN"}}); err != nil {
+		t.Fatalf("ProcessUpdate: %v", err)
+	}
+	if mr.Exists(negKey) {
+		t.Fatal("expected negative cache key to be deleted after a successful update")
+	}
+	res, err := svc.GetLatestQuote(ctx, Pair{Base: "EUR", Quote: "MXN"})
+	if err != nil {
+		t.Fatalf("GetLatestQuote after update: %v", err)
+	}
+	if res.Price == nil || *res.Price != "18.7543" {
+		t.Errorf("expected price 18.7543, got %v", res.Price)
+	}
+	if dbCalls != 2 {
+		t.Errorf("expected no further DB calls, got %d", dbCalls)
+	}
+}
+
+func TestGetLatestQuote_NegativeCacheDisabled(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+
+	repo := &mockQuoteRepo{
+		getLatestSuccessFunc: func(context.Context, Pair) (*repository.Quote, error) { return nil, nil },
+	}
+	svc := NewQuoteService(QuoteServiceDeps{Repo: repo, Cache: rdb, CacheConfig: testCacheCfg})
+
+	if _, err := svc.GetLatestQuote(context.Background(), Pair{Base: "EUR", Quote: "MXN"}); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	if mr.Exists("latest:{EUR:MXN}:notfound") {
+		t.Fatal("expected no negative cache entry when the TTL is 0")
+	}
+}
+
+func TestGetLatestQuote_SchemaNotReady(t *testing.T) {
+	repo := &mockQuoteRepo{
+		getLatestSuccessFunc: func(context.Context, Pair) (*repository.Quote, error) {
+			return nil, fmt.Errorf("get latest: %w", &pgconn.PgError{Code: "42P01", Message: `relation "quotes" does not exist`})
+		},
+	}
+	svc := NewQuoteService(QuoteServiceDeps{Repo: repo})
+
+	_, err := svc.GetLatestQuote(context.Background(), Pair{Base: "EUR", Quote: "MXN"})
+	if !errors.Is(err, ErrSchemaNotReady) {
+		t.Fatalf("expected ErrSchemaNotReady, got %v", err)
+	}
+
+	repo.getLatestSuccessFunc = func(context.Context, Pair) (*repository.Quote, error) {
+		return nil, errors.New("connection refused")
+	}
+	if _, err := svc.GetLatestQuote(context.Background(), Pair{Base: "EUR", Quote: "MXN"}); !errors.Is(err, ErrInternal) {
+		t.Fatalf("expected ErrInternal for other errors, got %v", err)
+	}
+}
+
+func TestGetLatestQuote_Cached(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	sugar := logger.Sugar()
+	v := NewValidator()
+
+	// Use miniredis
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("failed to start miniredis: %v", err)
+	}
+	defer mr.Close()
+
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+
+	// Set value in cache
+	key := "latest:{EUR:MXN}"
+	mr.HSet(key, "price", "18.7543")
+	mr.HSet(key, "updated_at", time.Now().Format(time.RFC3339))
+	mr.HSet(key, "rate_timestamp", time.Now().Format(time.RFC3339))
+
+	// Repo should NOT be called if cached
+	repo := &mockQuoteRepo{
+		getLatestSuccessFunc: func(ctx context.Context, pair Pair) (*repository.Quote, error) {
+			t.Error("Repo should not be called when value is cached")
+			return nil, nil
+		},
+	}
+
+	svc := NewQuoteService(QuoteServiceDeps{
+		Repo:        repo,
+		Validator:   v,
+		Cache:       rdb,
+		Logger:      sugar,
+		CacheConfig: testCacheCfg,
+	})
+
+	res, err := svc.GetLatestQuote(context.Background(), Pair{Base: "EUR", Quote: "MXN"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if res.Price == nil || *res.Price != "18.7543" {
+		t.Errorf("Expected price 18.7543, got %v", res.Price)
+	}
+}
+
+func TestGetLatestQuote_NotCached(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	sugar := logger.Sugar()
+	v := NewValidator()
+
+	// Use miniredis
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("failed to start miniredis: %v", err)
+	}
+	defer mr.Close()
+
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+
+	now := time.Now().Truncate(time.Second)
+	price := "18.7543"
+
+	repo := &mockQuoteRepo{
+		getLatestSuccessFunc: func(ctx context.Context, pair Pair) (*repository.Quote, error) {
+			return &repository.Quote{
+				Base:      pair.Base,
+				Quote:     pair.Quote,
+				Price:     &price,
+				UpdatedAt: &now,
+				Status:    repository.StatusSuccess,
+			}, nil
+		},
+	}
+
+	svc := NewQuoteService(QuoteServiceDeps{
+		Repo:        repo,
+		Validator:   v,
+		Cache:       rdb,
+		Logger:      sugar,
+		CacheConfig: testCacheCfg,
+	})
+
+	res, err := svc.GetLatestQuote(context.Background(), Pair{Base: "EUR", Quote: "MXN"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if res.Price == nil || *res.Price != price {
+		t.Errorf("Expected price %s, got %v", price, res.Price)
+	}
+
+	// Verify it was cached
+	key := "latest:{EUR:MXN}"
+	if !mr.Exists(key) {
+		t.Errorf("Expected key %s to be cached", key)
+	}
+	cachedPrice := mr.HGet(key, "price")
+	if cachedPrice != price {
+		t.Errorf("Expected cached price %s, got %s", price, cachedPrice)
+	}
+}
+
+func TestWarmCache_ReadyAfterPartialWarmup(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("failed to start miniredis: %v", err)
+	}
+	defer mr.Close()
+
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+
+	now := time.Now().Truncate(time.Second)
+	price := "1.0850"
+	repo := &mockQuoteRepo{
+		getLatestSuccessFunc: func(ctx context.Context, pair Pair) (*repository.Quote, error) {
+			if pair.Base == "GBP" {
+				return nil, errors.New("db down")
+			}
+			return &repository.Quote{Base: pair.Base, Quote: pair.Quote, Price: &price, UpdatedAt: &now, Status: repository.StatusSuccess}, nil
+		},
+	}
+
+	cfg := testCacheCfg
+	cfg.WarmupRequired = true
+	svc := NewQuoteService(QuoteServiceDeps{
+		Repo:        repo,
+		Validator:   NewValidator(),
+		Cache:       rdb,
+		Logger:      zap.NewNop().Sugar(),
+		CacheConfig: cfg,
+	})
+
+	if svc.IsReady() {
+		t.Fatal("Expected service not ready before warmup")
+	}
+
+	svc.WarmCache(context.Background(), []string{"EUR/USD", "GBP/JPY", "bad"})
+
+	if !svc.IsReady() {
+		t.Fatal("Expected service ready after warmup")
+	}
+	if got := mr.HGet("latest:{EUR:USD}", "price"); got != price {
+		t.Errorf("Expected cached price %s, got %q", price, got)
+	}
+	if mr.Exists("latest:{GBP:JPY}") {
+		t.Error("Expected GBP/JPY not to be cached")
+	}
+}
+
+func TestWarmCache_LoadsEachPairOnce(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer rdb.Close()
+
+	now := time.Now().Truncate(time.Second)
+	price := "18.7543"
+	loads := map[Pair]int{}
+	repo := &mockQuoteRepo{
+		getLatestSuccessFunc: func(_ context.Context, pair Pair) (*repository.Quote, error) {
+			loads[pair]++
+			return &repository.Quote{Base: pair.Base, Quote: pair.Quote, Price: &price, UpdatedAt: &now, Status: repository.StatusSuccess}, nil
+		},
+	}
+	svc := NewQuoteService(QuoteServiceDeps{
+		Repo:        repo,
+		Validator:   NewValidator(),
+		Cache:       rdb,
+		Logger:      zap.NewNop().Sugar(),
+		CacheConfig: testCacheCfg,
+	})
+
+	svc.WarmCache(context.Background(), []string{"EUR/MXN", "eur/mxn", " EUR/MXN", "GBP/JPY"})
+
+	want := map[Pair]int{{Base: "EUR", Quote: "MXN"}: 1, {Base: "GBP", Quote: "JPY"}: 1}
+	if !reflect.DeepEqual(loads, want) {
+		t.Errorf("Expected each pair loaded once, got %v", loads)
+	}
+	if got := mr.HGet("latest:{EUR:MXN}", "price"); got != price {
+		t.Errorf("Expected cached price %s, got %q", price, got)
+	}
+}
+
+func TestIsReady_WarmupNotRequired(t *testing.T) {
+	svc := NewQuoteService(QuoteServiceDeps{
+		Validator:   NewValidator(),
+		Logger:      zap.NewNop().Sugar(),
+		CacheConfig: testCacheCfg,
+	})
+	if !svc.IsReady() {
+		t.Fatal("Expected service ready when warmup is not required")
+	}
+}
+
+func TestGetHistoricalRate(t *testing.T) {
+	at := time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC)
+	recordTime := at.Add(-2 * time.Minute)
+	price := "1.0850"
+
+	repo := &mockQuoteRepo{
+		getPriceAtTimeFunc: func(ctx context.Context, pair Pair, gotAt time.Time) (*repository.Quote, error) {
+			if pair.Base != "EUR" || pair.Quote != "USD" || !gotAt.Equal(at) {
+				t.Errorf("Unexpected repo call %s/%s at %v", pair.Base, pair.Quote, gotAt)
+			}
+			return &repository.Quote{Base: pair.Base, Quote: pair.Quote, Price: &price, UpdatedAt: &recordTime, Status: repository.StatusSuccess}, nil
+		},
+	}
+	svc := NewQuoteService(QuoteServiceDeps{
+		Repo:        repo,
+		Validator:   NewValidator(),
+		Logger:      zap.NewNop().Sugar(),
+		CacheConfig: testCacheCfg,
+	})
+
+	res, err := svc.GetHistoricalRate(context.Background(), Pair{Base: "EUR", Quote: "USD"}, at)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if res.UpdatedAt == nil || *res.UpdatedAt != recordTime.Format(time.RFC3339) {
+		t.Errorf("Expected updated_at %s, got %v", recordTime.Format(time.RFC3339), res.UpdatedAt)
+	}
+
+	repo.getPriceAtTimeFunc = func(ctx context.Context, pair Pair, at time.Time) (*repository.Quote, error) {
+		return nil, nil
+	}
+	if _, err := svc.GetHistoricalRate(context.Background(), Pair{Base: "EUR", Quote: "USD"}, at); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+
+	if _, err := svc.GetHistoricalRate(context.Background(), Pair{Base: "ABC", Quote: "USD"}, at); !errors.Is(err, ErrUnsupportedCurrency) {
+		t.Errorf("Expected ErrUnsupportedCurrency, got %v", err)
+	}
+}
+
+func TestApplyStreamedRate(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("failed to start miniredis: %v", err)
+	}
+	defer mr.Close()
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+
+	t.Run("stores record and refreshes cache", func(t *testing.T) {
+		var markedSuccess string
+		repo := &mockQuoteRepo{
+			createUpdateFunc: func(ctx context.Context, pair Pair, id string) (repository.CreateUpdateResult, error) {
+				return created(id)
+			},
+			markRunningFunc: func(ctx context.Context, id string, version int64) error { return nil },
+			markSuccessFunc: func(ctx context.Context, id string, version int64, price string, rateTimestamp time.Time) error {
+				markedSuccess = price
+				return nil
+			},
+		}
+		svc := NewQuoteService(QuoteServiceDeps{
+			Repo:        repo,
+			Validator:   NewValidator(),
+			Cache:       rdb,
+			Logger:      zap.NewNop().Sugar(),
+			CacheConfig: testCacheCfg,
+		})
+		streamed := metrics.QuoteUpdatesTotal.WithLabelValues(string(repository.StatusSuccess), string(repository.OriginStream))
+		before := testutil.ToFloat64(streamed)
+
+		if err := svc.ApplyStreamedRate(context.Background(), Pair{Base: "eur", Quote: "usd"}, "1.085", time.Now()); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if markedSuccess != "1.085" {
+			t.Errorf("Expected MarkSuccess with 1.085, got %q", markedSuccess)
+		}
+		if repo.lastOrigin != repository.OriginStream {
+			t.Errorf("Expected origin %s, got %q", repository.OriginStream, repo.lastOrigin)
+		}
+		if got := testutil.ToFloat64(streamed) - before; got != 1 {
+			t.Errorf("Expected one streamed success counted, got %v", got)
+		}
+		if got := mr.HGet("latest:{EUR:USD}", "price"); got != "1.085" {
+			t.Errorf("Expected cached price 1.085, got %q", got)
+		}
+	})
+
+	t.Run("in-flight update only refreshes cache", func(t *testing.T) {
+		repo := &mockQuoteRepo{
+			createUpdateFunc: func(ctx context.Context, pair Pair, id string) (repository.CreateUpdateResult, error) {
+				return deduplicated("existing-id")
+			},
+		}
+		svc := NewQuoteService(QuoteServiceDeps{
+			Repo:        repo,
+			Validator:   NewValidator(),
+			Cache:       rdb,
+			Logger:      zap.NewNop().Sugar(),
+			CacheConfig: testCacheCfg,
+		})
+
+		if err := svc.ApplyStreamedRate(context.Background(), Pair{Base: "GBP", Quote: "JPY"}, "182.5", time.Now()); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if got := mr.HGet("latest:{GBP:JPY}", "price"); got != "182.5" {
+			t.Errorf("Expected cached price 182.5, got %q", got)
+		}
+	})
+
+	t.Run("unsupported currency rejected", func(t *testing.T) {
+		svc := NewQuoteService(QuoteServiceDeps{
+			Repo:        &mockQuoteRepo{},
+			Validator:   NewValidator(),
+			Cache:       rdb,
+			Logger:      zap.NewNop().Sugar(),
+			CacheConfig: testCacheCfg,
+		})
+		err := svc.ApplyStreamedRate(context.Background(), Pair{Base: "ABC", Quote: "USD"}, "1", time.Now())
+		if !errors.Is(err, ErrUnsupportedCurrency) {
+			t.Errorf("Expected ErrUnsupportedCurrency, got %v", err)
+		}
+	})
+}
+
+func TestGetQuoteResult_TerminalCached(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("failed to start miniredis: %v", err)
+	}
+	defer mr.Close()
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+
+	id := "123e4567-e89b-12d3-a456-426614174000"
+	now := time.Now().UTC().Truncate(time.Second)
+	price := "1.085000"
+
+	tests := []struct {
+		status     repository.Status
+		wantCached bool
+	}{
+		{repository.StatusSuccess, true},
+		{repository.StatusFailed, true},
+		{repository.StatusPending, false},
+		{repository.StatusRunning, false},
+	}
+
+	for _, tc := range tests {
+		t.Run(string(tc.status), func(t *testing.T) {
+			mr.FlushAll()
+			calls := 0
+			repo := &mockQuoteRepo{
+				getByIDFunc: func(ctx context.Context, gotID string) (*repository.Quote, error) {
+					calls++
+					q := &repository.Quote{ID: id, Base: "EUR", Quote: "USD", Status: tc.status, RequestedAt: now}
+					if tc.status == repository.StatusSuccess {
+						q.Price, q.UpdatedAt = &price, &now
+					}
+					if tc.status == repository.StatusFailed {
+						msg := "provider down"
+						q.ErrorMsg, q.UpdatedAt = &msg, &now
+					}
+					return q, nil
+				},
+			}
+			svc := NewQuoteService(QuoteServiceDeps{
+				Repo:        repo,
+				Validator:   NewValidator(),
+				Cache:       rdb,
+				Logger:      zap.NewNop().Sugar(),
+				CacheConfig: testCacheCfg,
+			})
+
+			first, err := svc.GetQuoteResult(context.Background(), id)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			second, err := svc.GetQuoteResult(context.Background(), id)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			wantCalls := 2
+			if tc.wantCached {
+				wantCalls = 1
+			}
+			if calls != wantCalls {
+				t.Errorf("Expected %d repo calls, got %d", wantCalls, calls)
+			}
+			if mr.Exists("quote_result:{"+id+"}") != tc.wantCached {
+				t.Errorf("Expected cached=%v", tc.wantCached)
+			}
+			if second.Status != first.Status || derefTest(second.Price) != derefTest(first.Price) ||
+				derefTest(second.ErrorMsg) != derefTest(first.ErrorMsg) || derefTest(second.UpdatedAt) != derefTest(first.UpdatedAt) {
+				t.Errorf("Cached result %+v differs from DB result %+v", second, first)
+			}
+		})
+	}
+}
+
+func TestProcessUpdate_RetryInvalidatesCachedResult(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("failed to start miniredis: %v", err)
+	}
+	defer mr.Close()
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+
+	id := "123e4567-e89b-12d3-a456-426614174000"
+	mr.HSet("quote_result:{"+id+"}", "status", "FAILED")
+
+	repo := &mockQuoteRepo{
+		getByIDFunc: func(context.Context, string) (*repository.Quote, error) {
+			return &repository.Quote{ID: id, Status: repository.StatusFailed, Version: 3}, nil
+		},
+		markRunningFunc: func(ctx context.Context, id string, version int64) error { return nil },
+		markSuccessFunc: func(ctx context.Context, id string, version int64, price string, rateTimestamp time.Time) error {
+			return nil
+		},
+	}
+	prov := &mockRatesProvider{getRateFunc: func(base, quote string) (string, time.Time, error) {
+		return "1.085", time.Now(), nil
+	}}
+	svc := NewQuoteService(QuoteServiceDeps{
+		Repo:        repo,
+		Provider:    prov,
+		Validator:   NewValidator(),
+		Cache:       rdb,
+		Logger:      zap.NewNop().Sugar(),
+		CacheConfig: testCacheCfg,
+	})
+
+	if err := svc.ProcessUpdate(context.Background(), UpdateQuotePayload{UpdateID: id, Pair: Pair{Base: "EUR", Quote: "USD"}}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if mr.Exists("quote_result:{" + id + "}") {
+		t.Error("Expected cached FAILED result to be invalidated on retry")
+	}
+}
+
+func derefTest(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// BenchmarkGetQuoteResult compares 1000 GetQuoteResult calls for a SUCCESS record
+// served from the result cache against the same calls going to the repository.
+// The repository mock sleeps to approximate a Postgres round trip.
+func BenchmarkGetQuoteResult(b *testing.B) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		b.Fatalf("failed to start miniredis: %v", err)
+	}
+	defer mr.Close()
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+
+	id := "123e4567-e89b-12d3-a456-426614174000"
+	now := time.Now().UTC()
+	price := "1.085000"
+	repo := &mockQuoteRepo{
+		getByIDFunc: func(ctx context.Context, id string) (*repository.Quote, error) {
+			time.Sleep(200 * time.Microsecond)
+			return &repository.Quote{
+				ID: id, Base: "EUR", Quote: "USD", Status: repository.StatusSuccess,
+				Price: &price, RequestedAt: now, UpdatedAt: &now,
+			}, nil
+		},
+	}
+	logger := zap.NewNop().Sugar()
+
+	run := func(b *testing.B, svc *QuoteService) {
+		for b.Loop() {
+			for range 1000 {
+				if _, err := svc.GetQuoteResult(context.Background(), id); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	}
+
+	b.Run("uncached", func(b *testing.B) {
+		run(b, NewQuoteService(QuoteServiceDeps{
+			Repo:        repo,
+			Validator:   NewValidator(),
+			Logger:      logger,
+			CacheConfig: testCacheCfg,
+		}))
+	})
+	b.Run("cached", func(b *testing.B) {
+		run(b, NewQuoteService(QuoteServiceDeps{
+			Repo:        repo,
+			Validator:   NewValidator(),
+			Cache:       rdb,
+			Logger:      logger,
+			CacheConfig: testCacheCfg,
+		}))
+	})
+}
+
+// Mock task enqueuer
+type mockTaskEnqueuer struct {
+	enqueueUpdateTaskFunc func(ctx context.Context, payload UpdateQuotePayload) error
+	lastOpts              TaskOptions
+}
+
+func (m *mockTaskEnqueuer) EnqueueUpdateTask(ctx context.Context, payload UpdateQuotePayload, opts TaskOptions) error {
+	m.lastOpts = opts
+	return m.enqueueUpdateTaskFunc(ctx, payload)
+}
+
+// TestRequestQuoteUpdate_CreatedWithNormalizedID checks that a new record is enqueued
+// even when the repository returns its ID in another form than the one generated.
+func TestRequestQuoteUpdate_CreatedWithNormalizedID(t *testing.T) {
+	repo := &mockQuoteRepo{
+		createUpdateFunc: func(_ context.Context, _ Pair, id string) (repository.CreateUpdateResult, error) {
+			return created(strings.ToUpper(id))
+		},
+	}
+	var enqueued UpdateQuotePayload
+	svc := NewQuoteService(QuoteServiceDeps{
+		Repo:      repo,
+		Validator: NewValidator(),
+		Enqueuer: &mockTaskEnqueuer{enqueueUpdateTaskFunc: func(_ context.Context, payload UpdateQuotePayload) error {
+			enqueued = payload
+			return nil
+		}},
+		Logger:      zap.NewNop().Sugar(),
+		CacheConfig: testCacheCfg,
+	})
+
+	result, err := svc.RequestQuoteUpdate(context.Background(), "EUR/MXN", UpdateOptions{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.Reason != "" || enqueued.UpdateID != result.UpdateID || result.UpdateID != strings.ToUpper(result.UpdateID) {
+		t.Errorf("Expected the returned record %s enqueued, got result %+v and payload %+v", result.UpdateID, result, enqueued)
+	}
+}
+
+func TestRequestQuoteUpdate_EnqueueSuccess(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	sugar := logger.Sugar()
+	v := NewValidator()
+
+	repo := &mockQuoteRepo{
+		createUpdateFunc: func(ctx context.Context, pair Pair, id string) (repository.CreateUpdateResult, error) {
+			return created(id)
+		},
+	}
+
+	enqueueCalled := false
+	enqueuer := &mockTaskEnqueuer{
+		enqueueUpdateTaskFunc: func(ctx context.Context, payload UpdateQuotePayload) error {
+			enqueueCalled = true
+			if payload.Base != "EUR" || payload.Quote != "MXN" {
+				t.Errorf("Expected pair EUR/MXN, got %s/%s", payload.Base, payload.Quote)
+			}
+			return nil
+		},
+	}
+
+	svc := NewQuoteService(QuoteServiceDeps{
+		Repo:        repo,
+		Validator:   v,
+		Enqueuer:    enqueuer,
+		Logger:      sugar,
+		CacheConfig: testCacheCfg,
+	})
+
+	result, err := svc.RequestQuoteUpdate(context.Background(), "EUR/MXN", UpdateOptions{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.Status != string(repository.StatusPending) {
+		t.Errorf("Expected status %s, got %s", repository.StatusPending, result.Status)
+	}
+	if result.UpdateID == "" {
+		t.Error("Expected non-empty updateID")
+	}
+	if result.Reason != "" {
+		t.Errorf("Expected no reuse reason for a new update, got %q", result.Reason)
+	}
+	if repo.lastOrigin != repository.OriginAPI {
+		t.Errorf("Expected origin %s, got %q", repository.OriginAPI, repo.lastOrigin)
+	}
+	if !enqueueCalled {
+		t.Error("Expected EnqueueUpdateTask to be called")
+	}
+}
+
+func TestRequestQuoteUpdate_EnqueueFailure(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	sugar := logger.Sugar()
+	v := NewValidator()
+
+	markFailedCalled := false
+	repo := &mockQuoteRepo{
+		createUpdateFunc: func(ctx context.Context, pair Pair, id string) (repository.CreateUpdateResult, error) {
+			return created(id)
+		},
+		markFailedFunc: func(ctx context.Context, id string, version int64, code repository.ErrorCode, errorMsg string) error {
+			markFailedCalled = true
+			if errorMsg != "enqueue error" {
+				t.Errorf("Expected error message 'enqueue error', got %q", errorMsg)
+			}
+			return nil
+		},
+	}
+
+	enqueuer := &mockTaskEnqueuer{
+		enqueueUpdateTaskFunc: func(ctx context.Context, payload UpdateQuotePayload) error {
+			return errors.New("redis connection refused")
+		},
+	}
+
+	svc := NewQuoteService(QuoteServiceDeps{
+		Repo:        repo,
+		Validator:   v,
+		Enqueuer:    enqueuer,
+		Logger:      sugar,
+		CacheConfig: testCacheCfg,
+	})
+
+	_, err := svc.RequestQuoteUpdate(context.Background(), "EUR/MXN", UpdateOptions{})
+	if !errors.Is(err, ErrInternalQueue) {
+		t.Errorf("Expected ErrInternalQueue, got %v", err)
+	}
+	if !markFailedCalled {
+		t.Error("Expected MarkFailed to be called")
+	}
+}
+
+func TestRequestQuoteUpdate_EnqueueFailure_CancelledRequest(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var markFailedErr error
+	repo := &mockQuoteRepo{
+		createUpdateFunc: func(ctx context.Context, pair Pair, id string) (repository.CreateUpdateResult, error) {
+			return created(id)
+		},
+		markFailedFunc: func(ctx context.Context, id string, version int64, code repository.ErrorCode, errorMsg string) error {
+			markFailedErr = ctx.Err()
+			return nil
+		},
+	}
+	enqueuer := &mockTaskEnqueuer{
+		enqueueUpdateTaskFunc: func(ctx context.Context, payload UpdateQuotePayload) error {
+			cancel() // Client goes away while Redis is timing out.
+			return errors.New("redis connection refused")
+		},
+	}
+
+	svc := NewQuoteService(QuoteServiceDeps{
+		Repo:        repo,
+		Validator:   NewValidator(),
+		Enqueuer:    enqueuer,
+		Logger:      zap.NewNop().Sugar(),
+		CacheConfig: testCacheCfg,
+	})
+
+	_, err := svc.RequestQuoteUpdate(ctx, "EUR/MXN", UpdateOptions{})
+	if !errors.Is(err, ErrInternalQueue) {
+		t.Fatalf("Expected ErrInternalQueue, got %v", err)
+	}
+	if markFailedErr != nil {
+		t.Errorf("MarkFailed ran with a cancelled context (%v); the PENDING record would block retries", markFailedErr)
+	}
+}
+
+func TestRequestQuoteUpdate_ExistingPending(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	sugar := logger.Sugar()
+	v := NewValidator()
+
 	existingID := "existing-uuid-1234"
 	repo := &mockQuoteRepo{
-		createUpdateFunc: func(ctx context.Context, base, quote, id string) (string, error) {
-			// Return a different ID to simulate dedup (existing pending record)
-			return existingID, nil
+		createUpdateFunc: func(ctx context.Context, pair Pair, id string) (repository.CreateUpdateResult, error) {
+			return deduplicated(existingID)
 		},
 	}
 
@@ -455,19 +1864,558 @@ func TestRequestQuoteUpdate_ExistingPending(t *testing.T) {
 		},
 	}
 
-	svc := NewQuoteService(repo, nil, v, enqueuer, nil, sugar, testCacheCfg)
+	svc := NewQuoteService(QuoteServiceDeps{
+		Repo:        repo,
+		Validator:   v,
+		Enqueuer:    enqueuer,
+		Logger:      sugar,
+		CacheConfig: testCacheCfg,
+	})
 
-	updateID, status, err := svc.RequestQuoteUpdate(context.Background(), "EUR/MXN")
+	result, err := svc.RequestQuoteUpdate(context.Background(), "EUR/MXN", UpdateOptions{})
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
 	}
-	if updateID != existingID {
-		t.Errorf("Expected existing ID %s, got %s", existingID, updateID)
+	if result.UpdateID != existingID {
+		t.Errorf("Expected existing ID %s, got %s", existingID, result.UpdateID)
+	}
+	if result.Status != string(repository.StatusPending) {
+		t.Errorf("Expected status %s, got %s", repository.StatusPending, result.Status)
 	}
-	if status != string(repository.StatusPending) {
-		t.Errorf("Expected status %s, got %s", repository.StatusPending, status)
+	if result.Reason != ReuseReasonPendingExists {
+		t.Errorf("Expected reason %q, got %q", ReuseReasonPendingExists, result.Reason)
 	}
 	if enqueueCalled {
 		t.Error("Expected Enqueue NOT to be called for existing pending record")
 	}
 }
+
+type fakePairWatcher struct {
+	ch      chan repository.QuoteNotification
+	watched string
+}
+
+func (f *fakePairWatcher) WatchPair(ctx context.Context, pair Pair) <-chan repository.QuoteNotification {
+	f.watched = pair.String()
+	go func() {
+		<-ctx.Done()
+		close(f.ch)
+	}()
+	return f.ch
+}
+
+func TestSubscribePair(t *testing.T) {
+	t.Run("forwards notifications as update events", func(t *testing.T) {
+		watcher := &fakePairWatcher{ch: make(chan repository.QuoteNotification, 1)}
+		svc := NewQuoteService(QuoteServiceDeps{Watcher: watcher, CacheConfig: testCacheCfg})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		events, err := svc.SubscribePair(ctx, Pair{Base: "EUR", Quote: "USD"})
+		if err != nil {
+			t.Fatalf("SubscribePair: %v", err)
+		}
+		if watcher.watched != "EUR/USD" {
+			t.Errorf("Expected watch on EUR/USD, got %s", watcher.watched)
+		}
+
+		updatedAt := time.Date(2025, 12, 1, 10, 15, 30, 0, time.UTC)
+		rateTimestamp := updatedAt.Add(-time.Second)
+		watcher.ch <- repository.QuoteNotification{Base: "EUR", Quote: "USD", Price: "1.085000", UpdatedAt: updatedAt, RateTimestamp: &rateTimestamp}
+
+		ev := <-events
+		if ev.Type != QuoteEventUpdate {
+			t.Errorf("Expected update event, got %s", ev.Type)
+		}
+		if derefTest(ev.Data.Price) != "1.085000" || derefTest(ev.Data.UpdatedAt) != "2025-12-01T10:15:30Z" ||
+			derefTest(ev.Data.RateTimestamp) != "2025-12-01T10:15:29Z" {
+			t.Errorf("Unexpected event data: %+v", ev.Data)
+		}
+
+		cancel()
+		if _, ok := <-events; ok {
+			t.Error("Expected events channel to close after cancel")
+		}
+	})
+
+	t.Run("invalid pair", func(t *testing.T) {
+		svc := NewQuoteService(QuoteServiceDeps{Watcher: &fakePairWatcher{}, CacheConfig: testCacheCfg})
+		if _, err := svc.SubscribePair(context.Background(), Pair{Base: "EU", Quote: "USD"}); !errors.Is(err, ErrInvalidPairFormat) {
+			t.Errorf("Expected ErrInvalidPairFormat, got %v", err)
+		}
+	})
+
+	t.Run("no watcher", func(t *testing.T) {
+		svc := NewQuoteService(QuoteServiceDeps{CacheConfig: testCacheCfg})
+		if _, err := svc.SubscribePair(context.Background(), Pair{Base: "EUR", Quote: "USD"}); !errors.Is(err, ErrSubscriptionsUnavailable) {
+			t.Errorf("Expected ErrSubscriptionsUnavailable, got %v", err)
+		}
+	})
+}
+
+func TestRequestQuoteUpdate_PairOverrides(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	sugar := logger.Sugar()
+
+	resolver := NewPairResolver(PairSettings{Queue: config.PriorityDefault}, map[string]config.PairOverride{
+		"EUR/USD": {Priority: config.PriorityHigh, CooldownSec: intPtr(60)},
+	})
+	clk := fakeclock.New(time.Date(2025, 12, 1, 12, 0, 0, 0, time.UTC))
+
+	t.Run("recent success within cooldown is returned", func(t *testing.T) {
+		updatedAt := clk.Now().Add(-10 * time.Second)
+		repo := &mockQuoteRepo{
+			getLatestSuccessFunc: func(ctx context.Context, pair Pair) (*repository.Quote, error) {
+				return &repository.Quote{ID: "recent-id", Base: pair.Base, Quote: pair.Quote, Status: repository.StatusSuccess, UpdatedAt: &updatedAt}, nil
+			},
+			createUpdateFunc: func(ctx context.Context, pair Pair, id string) (repository.CreateUpdateResult, error) {
+				t.Error("CreateUpdate must not be called within the cooldown")
+				return created(id)
+			},
+		}
+		svc := NewQuoteService(QuoteServiceDeps{
+			Repo:        repo,
+			Validator:   NewValidator(),
+			Enqueuer:    &mockTaskEnqueuer{},
+			Logger:      sugar,
+			CacheConfig: testCacheCfg,
+			Pairs:       resolver,
+			Clock:       clk,
+		})
+
+		result, err := svc.RequestQuoteUpdate(context.Background(), "EUR/USD", UpdateOptions{})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if result.UpdateID != "recent-id" || result.Status != string(repository.StatusSuccess) {
+			t.Errorf("Expected recent-id/SUCCESS, got %s/%s", result.UpdateID, result.Status)
+		}
+		if result.Reason != ReuseReasonCooldownActive {
+			t.Errorf("Expected reason %q, got %q", ReuseReasonCooldownActive, result.Reason)
+		}
+		// 60s cooldown, success 10s ago.
+		if result.CooldownRemaining != 50*time.Second {
+			t.Errorf("Expected 50s of cooldown remaining, got %s", result.CooldownRemaining)
+		}
+	})
+
+	t.Run("stale success enqueues on the pair's queue", func(t *testing.T) {
+		// The cooldown ends exactly 60s after the success.
+		updatedAt := clk.Now().Add(-time.Minute)
+		repo := &mockQuoteRepo{
+			getLatestSuccessFunc: func(ctx context.Context, pair Pair) (*repository.Quote, error) {
+				return &repository.Quote{ID: "old-id", UpdatedAt: &updatedAt}, nil
+			},
+			createUpdateFunc: func(ctx context.Context, pair Pair, id string) (repository.CreateUpdateResult, error) {
+				return created(id)
+			},
+		}
+		enqueuer := &mockTaskEnqueuer{
+			enqueueUpdateTaskFunc: func(ctx context.Context, payload UpdateQuotePayload) error { return nil },
+		}
+		svc := NewQuoteService(QuoteServiceDeps{
+			Repo:        repo,
+			Validator:   NewValidator(),
+			Enqueuer:    enqueuer,
+			Logger:      sugar,
+			CacheConfig: testCacheCfg,
+			Pairs:       resolver,
+			Clock:       clk,
+		})
+
+		result, err := svc.RequestQuoteUpdate(context.Background(), "EUR/USD", UpdateOptions{})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if result.Status != string(repository.StatusPending) {
+			t.Errorf("Expected status %s, got %s", repository.StatusPending, result.Status)
+		}
+		if enqueuer.lastOpts.Queue != config.PriorityHigh {
+			t.Errorf("Expected queue %q, got %q", config.PriorityHigh, enqueuer.lastOpts.Queue)
+		}
+	})
+
+	t.Run("pair without override uses the default queue and no cooldown", func(t *testing.T) {
+		repo := &mockQuoteRepo{
+			createUpdateFunc: func(ctx context.Context, pair Pair, id string) (repository.CreateUpdateResult, error) {
+				return created(id)
+			},
+		}
+		enqueuer := &mockTaskEnqueuer{
+			enqueueUpdateTaskFunc: func(ctx context.Context, payload UpdateQuotePayload) error { return nil },
+		}
+		svc := NewQuoteService(QuoteServiceDeps{
+			Repo:        repo,
+			Validator:   NewValidator(),
+			Enqueuer:    enqueuer,
+			Logger:      sugar,
+			CacheConfig: testCacheCfg,
+			Pairs:       resolver,
+		})
+
+		if _, err := svc.RequestQuoteUpdate(context.Background(), "GBP/JPY", UpdateOptions{}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if enqueuer.lastOpts.Queue != config.PriorityDefault {
+			t.Errorf("Expected queue %q, got %q", config.PriorityDefault, enqueuer.lastOpts.Queue)
+		}
+	})
+}
+
+func TestRequestQuoteUpdate_ForcedProvider(t *testing.T) {
+	sugar := zap.NewNop().Sugar()
+	resolver := NewPairResolver(PairSettings{Queue: config.PriorityDefault}, map[string]config.PairOverride{
+		"EUR/USD": {CooldownSec: intPtr(60)},
+	})
+
+	t.Run("unknown provider lists the configured ones", func(t *testing.T) {
+		svc := NewQuoteService(QuoteServiceDeps{
+			Repo:        &mockQuoteRepo{},
+			Validator:   NewValidator(),
+			Provider:    provider.NewNamedExchangeProviderFacade(namedProviders("18.70", "19.00")...),
+			Logger:      sugar,
+			CacheConfig: testCacheCfg,
+		})
+
+		_, err := svc.RequestQuoteUpdate(context.Background(), "EUR/USD", UpdateOptions{Provider: "ecb"})
+		var unknown *UnknownProviderError
+		if !errors.As(err, &unknown) {
+			t.Fatalf("Expected *UnknownProviderError, got %v", err)
+		}
+		if unknown.Name != "ecb" || !reflect.DeepEqual(unknown.Valid, []string{"a", "b"}) {
+			t.Errorf("Unexpected error %+v", unknown)
+		}
+		if !IsValidationError(err) {
+			t.Error("Expected an unknown provider to be a validation error")
+		}
+	})
+
+	t.Run("provider is enqueued and skips the cooldown", func(t *testing.T) {
+		updatedAt := time.Now().Add(-10 * time.Second)
+		repo := &mockQuoteRepo{
+			getLatestSuccessFunc: func(ctx context.Context, pair Pair) (*repository.Quote, error) {
+				return &repository.Quote{ID: "recent-id", Status: repository.StatusSuccess, UpdatedAt: &updatedAt}, nil
+			},
+			createUpdateFunc: func(ctx context.Context, pair Pair, id string) (repository.CreateUpdateResult, error) {
+				return created(id)
+			},
+		}
+		var enqueued UpdateQuotePayload
+		svc := NewQuoteService(QuoteServiceDeps{
+			Repo:      repo,
+			Validator: NewValidator(),
+			Provider:  provider.NewNamedExchangeProviderFacade(namedProviders("18.70", "19.00")...),
+			Enqueuer: &mockTaskEnqueuer{enqueueUpdateTaskFunc: func(_ context.Context, payload UpdateQuotePayload) error {
+				enqueued = payload
+				return nil
+			}},
+			Logger:      sugar,
+			CacheConfig: testCacheCfg,
+			Pairs:       resolver,
+		})
+
+		result, err := svc.RequestQuoteUpdate(context.Background(), "EUR/USD", UpdateOptions{Provider: "b"})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if result.Reason != "" || result.UpdateID == "recent-id" {
+			t.Errorf("Expected a new update despite the cooldown, got %+v", result)
+		}
+		if enqueued.Provider != "b" || enqueued.UpdateID != result.UpdateID {
+			t.Errorf("Expected provider b in the payload of %s, got %+v", result.UpdateID, enqueued)
+		}
+	})
+}
+
+func TestProcessUpdate_ForcedProvider(t *testing.T) {
+	t.Run("fetches from the named provider only", func(t *testing.T) {
+		var stored string
+		repo := &mockQuoteRepo{
+			getByIDFunc:     pendingRecord,
+			markRunningFunc: func(context.Context, string, int64) error { return nil },
+			markSuccessFunc: func(_ context.Context, _ string, _ int64, price string, _ time.Time) error {
+				stored = price
+				return nil
+			},
+		}
+		pub := &recordingPublisher{}
+		svc := NewQuoteService(QuoteServiceDeps{
+			Repo:        repo,
+			Provider:    provider.NewNamedExchangeProviderFacade(namedProviders("18.70", "19.00")...),
+			Events:      pub,
+			CacheConfig: testCacheCfg,
+		})
+
+		payload := UpdateQuotePayload{UpdateID: "test-id", Pair: Pair{Base: "EUR", Quote: "MXN"}, Provider: "b"}
+		if err := svc.ProcessUpdate(context.Background(), payload); err != nil {
+			t.Fatalf("ProcessUpdate: %v", err)
+		}
+		// The facade's default order would have stored the first provider's 18.70.
+		if stored != "19.00" {
+			t.Errorf("Expected provider b's price 19.00, got %q", stored)
+		}
+		if len(pub.events) != 1 || pub.events[0].Provider != "b" || pub.events[0].Source != UpdateSourceProvider {
+			t.Errorf("Expected one event recording provider b, got %+v", pub.events)
+		}
+	})
+
+	t.Run("provider removed since enqueueing fails the update", func(t *testing.T) {
+		var failedWith string
+		repo := &mockQuoteRepo{
+			getByIDFunc: pendingRecord,
+			markFailedFunc: func(_ context.Context, _ string, _ int64, _ repository.ErrorCode, msg string) error {
+				failedWith = msg
+				return nil
+			},
+		}
+		svc := NewQuoteService(QuoteServiceDeps{
+			Repo:        repo,
+			Provider:    provider.NewNamedExchangeProviderFacade(namedProviders("18.70")...),
+			CacheConfig: testCacheCfg,
+		})
+
+		payload := UpdateQuotePayload{UpdateID: "test-id", Pair: Pair{Base: "EUR", Quote: "MXN"}, Provider: "b"}
+		err := svc.ProcessUpdate(context.Background(), payload)
+		if !errors.Is(err, ErrUnknownProvider) {
+			t.Fatalf("Expected ErrUnknownProvider, got %v", err)
+		}
+		if failedWith == "" {
+			t.Error("Expected the update to be marked FAILED")
+		}
+	})
+}
+
+func TestProcessUpdate_RecordsFetch(t *testing.T) {
+	clk := fakeclock.New(time.Date(2025, 12, 1, 12, 0, 0, 0, time.UTC))
+	// slowProvider answers rate, or fails if rate is empty, after 250ms.
+	slowProvider := func(rate string) *mockRatesProvider {
+		return &mockRatesProvider{getRateFunc: func(string, string) (string, time.Time, error) {
+			clk.Advance(250 * time.Millisecond)
+			if rate == "" {
+				return "", time.Time{}, &provider.ProviderError{Message: "unavailable", Retryable: true}
+			}
+			return rate, clk.Now(), nil
+		}}
+	}
+	named := func(rates ...string) []provider.NamedProvider {
+		nps := make([]provider.NamedProvider, len(rates))
+		for i, r := range rates {
+			nps[i] = provider.NamedProvider{Name: string(rune('a' + i)), Provider: slowProvider(r)}
+		}
+		return nps
+	}
+
+	tests := []struct {
+		name       string
+		providers  []provider.NamedProvider
+		routing    bool
+		pair       Pair
+		forced     string
+		wantSource string
+		wantMs     int64
+		wantErr    bool
+	}{
+		{name: "provider that answered", providers: named("", "1.08"), pair: Pair{Base: "EUR", Quote: "USD"},
+			wantSource: "b", wantMs: 500},
+		{name: "forced provider", providers: named("1.07", "1.08"), pair: Pair{Base: "EUR", Quote: "USD"}, forced: "b",
+			wantSource: "b", wantMs: 250},
+		{name: "last provider tried when all fail", providers: named("", ""), pair: Pair{Base: "EUR", Quote: "USD"},
+			wantSource: "b", wantMs: 500, wantErr: true},
+		{name: "crypto provider picked by routing", providers: named("1.08", "0.000016"), routing: true,
+			pair: Pair{Base: "USD", Quote: "BTC"}, wantSource: "b", wantMs: 250},
+		{name: "fiat provider behind the routing facade", providers: named("", "1.08", "0.000016"), routing: true,
+			pair: Pair{Base: "EUR", Quote: "USD"}, wantSource: "b", wantMs: 500},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var recorded []repository.Fetch
+			repo := &mockQuoteRepo{
+				getByIDFunc:     pendingRecord,
+				markRunningFunc: func(context.Context, string, int64) error { return nil },
+				markSuccessFunc: func(context.Context, string, int64, string, time.Time) error { return nil },
+				markFailedFunc:  func(context.Context, string, int64, repository.ErrorCode, string) error { return nil },
+				recordFetchFunc: func(_ context.Context, _ string, f repository.Fetch) error {
+					recorded = append(recorded, f)
+					return nil
+				},
+			}
+			var opts []QuoteServiceOption
+			if tt.routing {
+				opts = append(opts, WithRoutingStrategy(NewPrefixRoutingStrategy(tt.providers[len(tt.providers)-1].Provider)))
+			}
+			svc := NewQuoteService(QuoteServiceDeps{
+				Repo:        repo,
+				Provider:    provider.NewNamedExchangeProviderFacade(tt.providers...),
+				Validator:   anyCodeValidator{},
+				CacheConfig: testCacheCfg,
+				Clock:       clk,
+			}, opts...)
+
+			err := svc.ProcessUpdate(context.Background(), UpdateQuotePayload{UpdateID: "test-id", Pair: tt.pair, Provider: tt.forced})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ProcessUpdate: %v", err)
+			}
+			want := repository.Fetch{Source: tt.wantSource, Duration: time.Duration(tt.wantMs) * time.Millisecond}
+			if len(recorded) != 1 || recorded[0] != want {
+				t.Errorf("Expected fetch %+v to be recorded, got %+v", want, recorded)
+			}
+		})
+	}
+
+	t.Run("recording error does not fail the update", func(t *testing.T) {
+		repo := &mockQuoteRepo{
+			getByIDFunc:     pendingRecord,
+			markRunningFunc: func(context.Context, string, int64) error { return nil },
+			markSuccessFunc: func(context.Context, string, int64, string, time.Time) error { return nil },
+			recordFetchFunc: func(context.Context, string, repository.Fetch) error { return errors.New("db down") },
+		}
+		svc := NewQuoteService(QuoteServiceDeps{
+			Repo:        repo,
+			Provider:    provider.NewNamedExchangeProviderFacade(named("1.08")...),
+			CacheConfig: testCacheCfg,
+			Clock:       clk,
+		})
+		if err := svc.ProcessUpdate(context.Background(), UpdateQuotePayload{UpdateID: "test-id", Pair: Pair{Base: "EUR", Quote: "USD"}}); err != nil {
+			t.Errorf("Expected the update to succeed, got %v", err)
+		}
+	})
+
+	t.Run("not recorded when another task finished the update", func(t *testing.T) {
+		repo := &mockQuoteRepo{
+			getByIDFunc:     pendingRecord,
+			markRunningFunc: func(context.Context, string, int64) error { return nil },
+			markFailedFunc: func(_ context.Context, id string, v int64, _ repository.ErrorCode, _ string) error {
+				return &repository.VersionConflictError{ID: id, Expected: v, Actual: v + 1, Status: repository.StatusSuccess}
+			},
+			recordFetchFunc: func(context.Context, string, repository.Fetch) error {
+				t.Error("RecordFetch must not overwrite another task's fetch")
+				return nil
+			},
+		}
+		svc := NewQuoteService(QuoteServiceDeps{
+			Repo:        repo,
+			Provider:    provider.NewNamedExchangeProviderFacade(named("")...),
+			CacheConfig: testCacheCfg,
+			Clock:       clk,
+		})
+		err := svc.ProcessUpdate(context.Background(), UpdateQuotePayload{UpdateID: "test-id", Pair: Pair{Base: "EUR", Quote: "USD"}})
+		if !errors.Is(err, ErrAlreadyCompleted) {
+			t.Errorf("Expected ErrAlreadyCompleted, got %v", err)
+		}
+	})
+}
+
+func TestCacheSetLatest_PairTTL(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer rdb.Close()
+
+	svc := NewQuoteService(QuoteServiceDeps{
+		Repo:        &mockQuoteRepo{},
+		Validator:   NewValidator(),
+		Cache:       rdb,
+		Logger:      logger.Sugar(),
+		CacheConfig: testCacheCfg,
+		Pairs: NewPairResolver(PairSettings{LatestPriceTTL: 10 * time.Minute}, map[string]config.PairOverride{
+			"EUR/USD": {LatestPriceTTLSec: intPtr(60)},
+		}),
+	})
+
+	now := time.Now()
+	svc.cacheSetLatest(context.Background(), Pair{Base: "EUR", Quote: "USD"}, "", "1.1", now, now)
+	svc.cacheSetLatest(context.Background(), Pair{Base: "GBP", Quote: "JPY"}, "", "190", now, now)
+
+	if ttl := mr.TTL(svc.latestCacheKey(Pair{Base: "EUR", Quote: "USD"})); ttl != time.Minute {
+		t.Errorf("Expected EUR/USD TTL 1m, got %v", ttl)
+	}
+	if ttl := mr.TTL(svc.latestCacheKey(Pair{Base: "GBP", Quote: "JPY"})); ttl != 10*time.Minute {
+		t.Errorf("Expected GBP/JPY TTL 10m, got %v", ttl)
+	}
+}
+
+func TestFailUpdate(t *testing.T) {
+	id := "123e4567-e89b-12d3-a456-426614174000"
+	tests := []struct {
+		name       string
+		rec        *repository.Quote
+		wantErr    error
+		wantMarked bool
+	}{
+		{"running update", &repository.Quote{ID: id, Status: repository.StatusRunning, Version: 2}, nil, true},
+		{"pending update", &repository.Quote{ID: id, Status: repository.StatusPending, Version: 1}, nil, true},
+		{"finished update", &repository.Quote{ID: id, Status: repository.StatusSuccess, Version: 3}, ErrAlreadyCompleted, false},
+		{"unknown update", nil, ErrNotFound, false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			marked := false
+			repo := &mockQuoteRepo{
+				getByIDFunc: func(context.Context, string) (*repository.Quote, error) { return tc.rec, nil },
+				markFailedFunc: func(_ context.Context, _ string, version int64, _ repository.ErrorCode, errorMsg string) error {
+					marked = true
+					if version != tc.rec.Version || errorMsg != "internal error" {
+						t.Errorf("Expected MarkFailed at version %d with the reason, got %d %q", tc.rec.Version, version, errorMsg)
+					}
+					return nil
+				},
+			}
+			svc := NewQuoteService(QuoteServiceDeps{Repo: repo, Logger: zap.NewNop().Sugar(), CacheConfig: testCacheCfg})
+
+			if err := svc.FailUpdate(context.Background(), id, repository.ErrorCodeInternal, "internal error"); !errors.Is(err, tc.wantErr) {
+				t.Fatalf("Expected %v, got %v", tc.wantErr, err)
+			}
+			if marked != tc.wantMarked {
+				t.Errorf("Expected MarkFailed called: %v, got %v", tc.wantMarked, marked)
+			}
+		})
+	}
+}
+
+func TestResetUpdate(t *testing.T) {
+	id := "123e4567-e89b-12d3-a456-426614174000"
+	tests := []struct {
+		name       string
+		rec        *repository.Quote
+		markErr    error
+		wantErr    error
+		wantMarked bool
+	}{
+		{"failed update", &repository.Quote{ID: id, Status: repository.StatusFailed, Version: 3}, nil, nil, true},
+		{"running update", &repository.Quote{ID: id, Status: repository.StatusRunning, Version: 2}, nil, nil, true},
+		{"pending update", &repository.Quote{ID: id, Status: repository.StatusPending, Version: 1}, nil, nil, false},
+		{"succeeded update", &repository.Quote{ID: id, Status: repository.StatusSuccess, Version: 3}, nil, ErrAlreadyCompleted, false},
+		{"unknown update", nil, nil, ErrNotFound, false},
+		{"changed meanwhile", &repository.Quote{ID: id, Status: repository.StatusFailed, Version: 3},
+			&repository.VersionConflictError{ID: id, Expected: 3, Actual: 4, Status: repository.StatusRunning}, ErrUpdateConflict, true},
+		{"pair in flight", &repository.Quote{ID: id, Status: repository.StatusFailed, Version: 3},
+			&repository.PairInFlightError{ID: id, InFlightID: "other"}, ErrUpdateInFlight, true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			marked := false
+			repo := &mockQuoteRepo{
+				getByIDFunc: func(context.Context, string) (*repository.Quote, error) { return tc.rec, nil },
+				markPendingFunc: func(_ context.Context, _ string, version int64) error {
+					marked = true
+					if version != tc.rec.Version {
+						t.Errorf("Expected MarkPending at version %d, got %d", tc.rec.Version, version)
+					}
+					return tc.markErr
+				},
+			}
+			svc := NewQuoteService(QuoteServiceDeps{Repo: repo, Logger: zap.NewNop().Sugar(), CacheConfig: testCacheCfg})
+
+			err := svc.ResetUpdate(context.Background(), id)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("Expected %v, got %v", tc.wantErr, err)
+			}
+			var inFlight *UpdateInFlightError
+			if errors.As(err, &inFlight) && (inFlight.UpdateID != id || inFlight.InFlightID != "other") {
+				t.Errorf("Expected update %s blocked by update other, got %+v", id, inFlight)
+			}
+			if marked != tc.wantMarked {
+				t.Errorf("Expected MarkPending called: %v, got %v", tc.wantMarked, marked)
+			}
+		})
+	}
+}
@@ -2,14 +2,30 @@ package service
 
 import (
 	"errors"
+	"fmt"
+	"slices"
 	"strings"
+
+	"quoteservice/internal/repository"
 )
 
-func normalizePair(base, quote string) (normBase, normQuote string, err error) {
+// Pair is a currency pair in upper case, e.g. EUR/MXN. It is defined by the
+// repository package, which the service passes it to, so both layers share one type.
+type Pair = repository.Pair
+
+// NewPair validates base and quote as currency codes and returns them as an upper-case
+// Pair, or ErrInvalidPairFormat.
+func NewPair(base, quote string) (Pair, error) {
 	if !IsValidCurrencyCode(base) || !IsValidCurrencyCode(quote) {
-		return "", "", ErrInvalidPairFormat
+		return Pair{}, ErrInvalidPairFormat
 	}
-	return strings.ToUpper(base), strings.ToUpper(quote), nil
+	return Pair{Base: strings.ToUpper(base), Quote: strings.ToUpper(quote)}, nil
+}
+
+// IsValidOrigin reports whether origin names a code path that creates updates, e.g.
+// "api" or "stream".
+func IsValidOrigin(origin string) bool {
+	return slices.Contains(repository.Origins, repository.Origin(origin))
 }
 
 // ErrInvalidPairFormat indicates the currency pair format is invalid.
@@ -24,9 +40,97 @@ var ErrNotFound = errors.New("not found")
 // ErrInternal indicates an internal server error.
 var ErrInternal = errors.New("internal error")
 
+// ErrSchemaNotReady indicates that the database lacks tables or columns the service
+// uses, typically because migrations managed outside the service are still pending.
+var ErrSchemaNotReady = errors.New("database schema not ready")
+
+// storageError returns the error to report for a failed repository call:
+// ErrSchemaNotReady if the schema is missing something the call used, ErrInternal
+// otherwise.
+func storageError(err error) error {
+	if repository.IsSchemaError(err) {
+		return ErrSchemaNotReady
+	}
+	return ErrInternal
+}
+
 // ErrInternalQueue indicates an internal queue error.
 var ErrInternalQueue = errors.New("internal queue error")
 
+// ErrQueueFull indicates that the configured cap on PENDING updates is reached.
+var ErrQueueFull = errors.New("update queue full")
+
+// ErrServiceUnavailable indicates that no rate provider can currently serve the request.
+var ErrServiceUnavailable = errors.New("rate provider unavailable")
+
+// ErrProviderUnavailable indicates that every exchange rate provider tried for the
+// request failed with a transient error.
+var ErrProviderUnavailable = errors.New("exchange rate provider unavailable")
+
+// ErrAlreadyCompleted indicates that another worker already moved the update to a
+// terminal status, so the task has nothing left to do.
+var ErrAlreadyCompleted = errors.New("update already completed")
+
+// ErrUpdateConflict indicates that the update was changed by another worker while it
+// was being processed.
+var ErrUpdateConflict = errors.New("update changed concurrently")
+
+// ErrUpdateInFlight indicates that a FAILED update cannot be reset to PENDING because
+// another update of its pair is already PENDING or RUNNING.
+var ErrUpdateInFlight = errors.New("another update of the pair is in flight")
+
+// UpdateInFlightError is returned by ResetUpdate when another update of the pair is in
+// flight. It matches ErrUpdateInFlight.
+type UpdateInFlightError struct {
+	UpdateID   string
+	InFlightID string // Empty if the in-flight update finished before it could be read.
+}
+
+func (e *UpdateInFlightError) Error() string {
+	if e.InFlightID == "" {
+		return fmt.Sprintf("update %s cannot be reset while another update of its pair is in flight", e.UpdateID)
+	}
+	return fmt.Sprintf("update %s cannot be reset while update %s of its pair is in flight", e.UpdateID, e.InFlightID)
+}
+
+// Is reports whether target is ErrUpdateInFlight.
+func (e *UpdateInFlightError) Is(target error) bool {
+	return target == ErrUpdateInFlight
+}
+
+// ErrUnknownProvider indicates that an update named a provider that is not configured.
+var ErrUnknownProvider = errors.New("unknown provider")
+
+// UnknownProviderError is returned for an update that names a provider that is not
+// configured. It matches ErrUnknownProvider.
+type UnknownProviderError struct {
+	Name  string
+	Valid []string // Configured provider names, in default order.
+}
+
+func (e *UnknownProviderError) Error() string {
+	return fmt.Sprintf("unknown provider %q, valid providers: %s", e.Name, strings.Join(e.Valid, ", "))
+}
+
+// Is reports whether target is ErrUnknownProvider.
+func (e *UnknownProviderError) Is(target error) bool {
+	return target == ErrUnknownProvider
+}
+
+// IsValidationError reports whether err is caused by invalid client input
+// (malformed pair, unsupported currency, unknown provider, malformed ID, unusable
+// webhook URL, invalid comparison range).
+func IsValidationError(err error) bool {
+	return errors.Is(err, ErrInvalidPairFormat) ||
+		errors.Is(err, ErrUnsupportedCurrency) ||
+		errors.Is(err, ErrUnknownProvider) ||
+		errors.Is(err, ErrInvalidUpdateID) ||
+		errors.Is(err, ErrInvalidWebhookID) ||
+		errors.Is(err, ErrInvalidWebhookURL) ||
+		errors.Is(err, ErrWebhookProbeFailed) ||
+		errors.Is(err, ErrInvalidComparisonRange)
+}
+
 // IsValidCurrencyCode checks whether a string is a valid 3-letter currency code.
 func IsValidCurrencyCode(code string) bool {
 	if len(code) != 3 {
@@ -41,11 +145,62 @@ func IsValidCurrencyCode(code string) bool {
 	return true
 }
 
-// ParsePair splits a "BASE/QUOTE" string into its components and validates them.
-func ParsePair(pair string) (base, quote string, err error) {
-	parts := strings.Split(pair, "/")
-	if len(parts) != 2 || !IsValidCurrencyCode(parts[0]) || !IsValidCurrencyCode(parts[1]) {
-		return "", "", ErrInvalidPairFormat
+// NormalizeCode returns code in the canonical form the service expects: trimmed and
+// upper case, e.g. " eur" becomes "EUR". API handlers apply it to every currency code
+// they receive, so all spellings of a code share the same cache keys, rows and dedup
+// records.
+func NormalizeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// NormalizePair applies NormalizeCode to both sides of a "BASE/QUOTE" string, e.g.
+// " eur /mxn" becomes "EUR/MXN". A string without "/" is normalized as a whole.
+func NormalizePair(pair string) string {
+	base, quote, ok := strings.Cut(pair, "/")
+	if !ok {
+		return NormalizeCode(pair)
+	}
+	return NormalizeCode(base) + "/" + NormalizeCode(quote)
+}
+
+// ParsePair parses a "BASE/QUOTE" string into a Pair, validating it like NewPair.
+func ParsePair(pair string) (Pair, error) {
+	base, quote, ok := strings.Cut(pair, "/")
+	if !ok {
+		return Pair{}, ErrInvalidPairFormat
+	}
+	return NewPair(base, quote)
+}
+
+// PairEntry is one entry of a pair list canonicalized by CanonicalizePairs.
+type PairEntry struct {
+	Pair Pair  // Zero when Err is set.
+	Err  error // Why the entry does not parse, e.g. ErrInvalidPairFormat.
+	// DuplicateOf is the index of the first entry naming the same pair, or -1 for that
+	// first entry itself and for invalid entries.
+	DuplicateOf int
+}
+
+// CanonicalizePairs normalizes and parses every "BASE/QUOTE" entry of a pair list. It
+// returns one PairEntry per entry, in the same order, and the distinct valid pairs in
+// the order they first appear, so a batch of "EUR/MXN", "eur/mxn" and " EUR/MXN"
+// does the work for EUR/MXN once and every entry can still be answered in place.
+func CanonicalizePairs(pairs []string) (entries []PairEntry, unique []Pair) {
+	entries = make([]PairEntry, len(pairs))
+	first := make(map[Pair]int, len(pairs))
+	for i, raw := range pairs {
+		pair, err := ParsePair(NormalizePair(raw))
+		if err != nil {
+			entries[i] = PairEntry{Err: err, DuplicateOf: -1}
+			continue
+		}
+		entries[i] = PairEntry{Pair: pair, DuplicateOf: -1}
+		if j, seen := first[pair]; seen {
+			entries[i].DuplicateOf = j
+			continue
+		}
+		first[pair] = i
+		unique = append(unique, pair)
 	}
-	return strings.ToUpper(parts[0]), strings.ToUpper(parts[1]), nil
+	return entries, unique
 }
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"slices"
+	"strings"
+
+	"quoteservice/internal/provider"
+)
+
+// PairRoutingStrategy picks the provider that serves a pair.
+type PairRoutingStrategy interface {
+	// SelectProvider returns the provider for base/quote chosen from available, or nil
+	// if none of them can serve the pair.
+	SelectProvider(base, quote string, available []provider.RatesProvider) provider.RatesProvider
+}
+
+// DefaultCryptoPrefixes are the currency code prefixes PrefixRoutingStrategy treats as
+// crypto when none are configured.
+var DefaultCryptoPrefixes = []string{"BTC", "ETH", "USDT", "USDC", "XRP", "LTC", "SOL", "DOGE"}
+
+// PrefixRoutingStrategy sends pairs in which either currency starts with one of
+// CryptoPrefixes to Crypto, and every other pair to the remaining (fiat) providers,
+// tried in their configured order; per-pair provider orders do not apply to them.
+// Crypto must be comparable, e.g. a pointer, because it is looked up in the available
+// list by identity.
+type PrefixRoutingStrategy struct {
+	CryptoPrefixes []string
+	Crypto         provider.RatesProvider
+}
+
+// NewPrefixRoutingStrategy creates a PrefixRoutingStrategy. With no prefixes,
+// DefaultCryptoPrefixes is used.
+func NewPrefixRoutingStrategy(crypto provider.RatesProvider, prefixes ...string) *PrefixRoutingStrategy {
+	if len(prefixes) == 0 {
+		prefixes = DefaultCryptoPrefixes
+	}
+	normalized := make([]string, len(prefixes))
+	for i, p := range prefixes {
+		normalized[i] = strings.ToUpper(p)
+	}
+	return &PrefixRoutingStrategy{CryptoPrefixes: normalized, Crypto: crypto}
+}
+
+// IsCrypto reports whether base or quote matches one of the crypto prefixes.
+func (r *PrefixRoutingStrategy) IsCrypto(base, quote string) bool {
+	base, quote = strings.ToUpper(base), strings.ToUpper(quote)
+	return slices.ContainsFunc(r.CryptoPrefixes, func(prefix string) bool {
+		return strings.HasPrefix(base, prefix) || strings.HasPrefix(quote, prefix)
+	})
+}
+
+// SelectProvider implements PairRoutingStrategy. A crypto pair gets nil when the
+// crypto provider is not available; a fiat pair never goes to the crypto provider.
+func (r *PrefixRoutingStrategy) SelectProvider(base, quote string, available []provider.RatesProvider) provider.RatesProvider {
+	if r.IsCrypto(base, quote) {
+		if r.Crypto != nil && slices.Contains(available, r.Crypto) {
+			return r.Crypto
+		}
+		return nil
+	}
+
+	fiat := slices.DeleteFunc(slices.Clone(available), func(p provider.RatesProvider) bool {
+		return p == r.Crypto
+	})
+	switch len(fiat) {
+	case 0:
+		return nil
+	case 1:
+		return fiat[0]
+	default:
+		return provider.NewExchangeProviderFacade(fiat...)
+	}
+}
+
+var _ PairRoutingStrategy = (*PrefixRoutingStrategy)(nil)
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"quoteservice/internal/provider"
+	"quoteservice/internal/repository"
+)
+
+// anyCodeValidator accepts every currency code, including crypto codes the default
+// validator does not know.
+type anyCodeValidator struct{}
+
+func (anyCodeValidator) Validate(string) error   { return nil }
+func (anyCodeValidator) IsSupported(string) bool { return true }
+func (anyCodeValidator) BulkValidate(codes []string) map[string]error {
+	out := make(map[string]error, len(codes))
+	for _, c := range codes {
+		out[c] = nil
+	}
+	return out
+}
+
+func fixedRateProvider(rate string) *mockRatesProvider {
+	return &mockRatesProvider{getRateFunc: func(string, string) (string, time.Time, error) {
+		return rate, time.Now(), nil
+	}}
+}
+
+func TestPrefixRoutingStrategy_SelectProvider(t *testing.T) {
+	crypto := fixedRateProvider("0.000016")
+	fiat := fixedRateProvider("1.08")
+	strategy := NewPrefixRoutingStrategy(crypto)
+	available := []provider.RatesProvider{fiat, crypto}
+
+	if got := strategy.SelectProvider("USD", "BTC", available); got != crypto {
+		t.Errorf("USD/BTC: expected the crypto provider, got %v", got)
+	}
+	if got := strategy.SelectProvider("eth", "eur", available); got != crypto {
+		t.Errorf("ETH/EUR: expected the crypto provider, got %v", got)
+	}
+	if got := strategy.SelectProvider("EUR", "USD", available); got != fiat {
+		t.Errorf("EUR/USD: expected the fiat provider, got %v", got)
+	}
+}
+
+func TestPrefixRoutingStrategy_Unavailable(t *testing.T) {
+	crypto := fixedRateProvider("0.000016")
+	fiat := fixedRateProvider("1.08")
+	strategy := NewPrefixRoutingStrategy(crypto, "BTC")
+
+	if got := strategy.SelectProvider("USD", "BTC", []provider.RatesProvider{fiat}); got != nil {
+		t.Errorf("expected nil when the crypto provider is unavailable, got %v", got)
+	}
+	if got := strategy.SelectProvider("EUR", "USD", []provider.RatesProvider{crypto}); got != nil {
+		t.Errorf("expected nil when only the crypto provider is available, got %v", got)
+	}
+}
+
+func TestPrefixRoutingStrategy_MultipleFiatProviders(t *testing.T) {
+	crypto := fixedRateProvider("0.000016")
+	first := &mockRatesProvider{getRateFunc: func(string, string) (string, time.Time, error) {
+		return "", time.Time{}, &provider.ProviderError{Message: "unavailable", Retryable: true}
+	}}
+	second := fixedRateProvider("1.09")
+	strategy := NewPrefixRoutingStrategy(crypto)
+
+	got := strategy.SelectProvider("EUR", "USD", []provider.RatesProvider{first, crypto, second})
+	if got == nil {
+		t.Fatal("expected a fiat provider")
+	}
+	rate, _, err := got.GetRate(context.Background(), "EUR", "USD")
+	if err != nil || rate != "1.09" {
+		t.Errorf("expected fallback to the second fiat provider, got %q, %v", rate, err)
+	}
+}
+
+func TestProcessUpdate_RoutingStrategy(t *testing.T) {
+	crypto := fixedRateProvider("0.000016")
+	fiat := fixedRateProvider("1.08")
+
+	tests := []struct {
+		pair     [2]string
+		wantRate string
+	}{
+		{pair: [2]string{"USD", "BTC"}, wantRate: "0.000016"},
+		{pair: [2]string{"EUR", "USD"}, wantRate: "1.08"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pair[0]+"/"+tt.pair[1], func(t *testing.T) {
+			var stored string
+			repo := &mockQuoteRepo{
+				getByIDFunc:     pendingRecord,
+				markRunningFunc: func(context.Context, string, int64) error { return nil },
+				markSuccessFunc: func(_ context.Context, _ string, _ int64, price string, _ time.Time) error {
+					stored = price
+					return nil
+				},
+			}
+			svc := NewQuoteService(QuoteServiceDeps{
+				Repo:        repo,
+				Provider:    provider.NewExchangeProviderFacade(fiat, crypto),
+				Validator:   anyCodeValidator{},
+				CacheConfig: testCacheCfg,
+			}, WithRoutingStrategy(NewPrefixRoutingStrategy(crypto)))
+
+			if err := svc.ProcessUpdate(context.Background(), UpdateQuotePayload{UpdateID: "123e4567-e89b-12d3-a456-426614174000", Pair: Pair{Base: tt.pair[0], Quote: tt.pair[1]}}); err != nil {
+				t.Fatalf("ProcessUpdate: %v", err)
+			}
+			if stored != tt.wantRate {
+				t.Errorf("expected rate %s, got %s", tt.wantRate, stored)
+			}
+		})
+	}
+}
+
+func TestProcessUpdate_RoutingStrategy_NoProvider(t *testing.T) {
+	crypto := &unavailableProvider{}
+	fiat := fixedRateProvider("1.08")
+	repo := &mockQuoteRepo{
+		getByIDFunc: pendingRecord,
+		markRunningFunc: func(context.Context, string, int64) error {
+			t.Error("MarkRunning must not be called when no provider is selected")
+			return nil
+		},
+		markFailedFunc: func(context.Context, string, int64, repository.ErrorCode, string) error { return nil },
+	}
+	svc := NewQuoteService(QuoteServiceDeps{
+		Repo:        repo,
+		Provider:    provider.NewExchangeProviderFacade(fiat, crypto),
+		Validator:   anyCodeValidator{},
+		CacheConfig: testCacheCfg,
+	}, WithRoutingStrategy(NewPrefixRoutingStrategy(crypto)))
+
+	err := svc.ProcessUpdate(context.Background(), UpdateQuotePayload{UpdateID: "123e4567-e89b-12d3-a456-426614174000", Pair: Pair{Base: "USD", Quote: "BTC"}})
+	if !errors.Is(err, ErrServiceUnavailable) {
+		t.Errorf("expected ErrServiceUnavailable, got %v", err)
+	}
+}
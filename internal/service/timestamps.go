@@ -0,0 +1,62 @@
+package service
+
+import (
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// MaxTimestampPrecision is the largest supported number of fractional-second digits.
+const MaxTimestampPrecision = 9
+
+// timestampLayout is the layout FormatTimestamp uses; see SetTimestampPrecision.
+var timestampLayout atomic.Pointer[string]
+
+func init() { SetTimestampPrecision(0) }
+
+// SetTimestampPrecision sets how many fractional-second digits (0-9) FormatTimestamp
+// emits. Digits are always zero-padded so equal instants format to identical strings.
+// It is meant to be called once at startup; out-of-range values are clamped.
+func SetTimestampPrecision(digits int) {
+	digits = max(0, min(digits, MaxTimestampPrecision))
+	layout := "2006-01-02T15:04:05"
+	if digits > 0 {
+		layout += "." + strings.Repeat("0", digits)
+	}
+	layout += "Z07:00"
+	timestampLayout.Store(&layout)
+}
+
+// FormatTimestamp formats t as UTC RFC3339 with the configured precision. Every timestamp
+// the service emits goes through it, whichever tier (DB, cache, notification) it came from.
+func FormatTimestamp(t time.Time) string {
+	return t.UTC().Format(*timestampLayout.Load())
+}
+
+// storedTimeLayout is the layout timestamps are cached with: UTC, trimmed to the
+// microsecond precision Postgres stores, so cached and DB values are identical.
+const storedTimeLayout = "2006-01-02T15:04:05.000000Z07:00"
+
+func formatStoredTime(t time.Time) string {
+	return t.UTC().Truncate(time.Microsecond).Format(storedTimeLayout)
+}
+
+// legacyTimeLayouts are accepted when reading timestamps cached by older versions or
+// produced by Postgres text output.
+var legacyTimeLayouts = []string{
+	time.RFC3339Nano, // Also covers plain RFC3339 with "Z" or "+00:00".
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999Z07",
+}
+
+// parseStoredTime parses a cached timestamp in any known format and returns it in UTC.
+func parseStoredTime(s string) (time.Time, error) {
+	var err error
+	for _, layout := range legacyTimeLayouts {
+		var t time.Time
+		if t, err = time.Parse(layout, s); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, err
+}
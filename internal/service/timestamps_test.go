@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+
+	"quoteservice/internal/repository"
+)
+
+func withTimestampPrecision(t *testing.T, digits int) {
+	t.Helper()
+	SetTimestampPrecision(digits)
+	t.Cleanup(func() { SetTimestampPrecision(0) })
+}
+
+func TestFormatTimestamp(t *testing.T) {
+	plus3 := time.FixedZone("UTC+3", 3*60*60)
+	ts := time.Date(2025, 12, 1, 13, 15, 30, 120_000_000, plus3)
+
+	tests := []struct {
+		digits int
+		want   string
+	}{
+		{0, "2025-12-01T10:15:30Z"},
+		{3, "2025-12-01T10:15:30.120Z"},
+		{6, "2025-12-01T10:15:30.120000Z"},
+		{9, "2025-12-01T10:15:30.120000000Z"},
+		{-1, "2025-12-01T10:15:30Z"},
+		{12, "2025-12-01T10:15:30.120000000Z"},
+	}
+	for _, tc := range tests {
+		withTimestampPrecision(t, tc.digits)
+		if got := FormatTimestamp(ts); got != tc.want {
+			t.Errorf("precision %d: expected %s, got %s", tc.digits, tc.want, got)
+		}
+	}
+}
+
+func TestParseStoredTime_LegacyFormats(t *testing.T) {
+	want := time.Date(2025, 12, 1, 10, 15, 30, 0, time.UTC)
+	for _, s := range []string{
+		"2025-12-01T10:15:30Z",
+		"2025-12-01T10:15:30+00:00",
+		"2025-12-01T13:15:30+03:00",
+		"2025-12-01T10:15:30.000000Z",
+		"2025-12-01 10:15:30+00",
+		"2025-12-01 10:15:30.000000+00:00",
+	} {
+		got, err := parseStoredTime(s)
+		if err != nil {
+			t.Errorf("%s: %v", s, err)
+			continue
+		}
+		if !got.Equal(want) || got.Location() != time.UTC {
+			t.Errorf("%s: expected %v in UTC, got %v", s, want, got)
+		}
+	}
+	if _, err := parseStoredTime("yesterday"); err == nil {
+		t.Error("expected an error for an unparsable timestamp")
+	}
+}
+
+// TestTimestamps_IdenticalAcrossTiers checks that a record formats to the same strings
+// whether it is served straight from the DB, from the latest cache, from the
+// quote_result cache or from a pushed notification.
+func TestTimestamps_IdenticalAcrossTiers(t *testing.T) {
+	// Postgres stores microseconds and may return them in the session time zone.
+	local := time.FixedZone("UTC-5", -5*60*60)
+	updatedAt := time.Date(2025, 12, 1, 5, 15, 30, 123_456_000, local)
+	rateAt := time.Date(2025, 12, 1, 0, 0, 0, 0, local)
+	price := "18.754300"
+	dbQuote := &repository.Quote{
+		ID:            "0b5f8a4e-9f5c-4d25-8f0e-6f3f0b4f2a11",
+		Base:          "EUR",
+		Quote:         "MXN",
+		Status:        repository.StatusSuccess,
+		Price:         &price,
+		RequestedAt:   updatedAt.Add(-time.Second),
+		UpdatedAt:     &updatedAt,
+		RateTimestamp: &rateAt,
+	}
+
+	for _, digits := range []int{0, 3, 6, 9} {
+		withTimestampPrecision(t, digits)
+		mr := miniredis.RunT(t)
+		svc := NewQuoteService(QuoteServiceDeps{
+			Cache:       redis.NewClient(&redis.Options{Addr: mr.Addr()}),
+			CacheConfig: testCacheCfg,
+		})
+		ctx := context.Background()
+
+		fromDB := quoteResultFromRepo(dbQuote)
+
+		svc.cacheSetLatestFromQuote(ctx, dbQuote)
+		cachedLatest, lookup := svc.cacheGetLatest(ctx, Pair{Base: "EUR", Quote: "MXN"})
+		if lookup != latestHit {
+			t.Fatalf("precision %d: expected latest cache hit", digits)
+		}
+		fromLatest := quoteResultFromRepo(cachedLatest)
+
+		svc.cacheSetQuoteResult(ctx, dbQuote)
+		cachedResult, ok := svc.cacheGetQuoteResult(ctx, dbQuote.ID)
+		if !ok {
+			t.Fatalf("precision %d: expected quote_result cache hit", digits)
+		}
+		fromResult := quoteResultFromRepo(cachedResult)
+
+		fromNotification := quoteResultFromNotification(repository.QuoteNotification{
+			Base: "EUR", Quote: "MXN", Price: price, UpdatedAt: updatedAt, RateTimestamp: dbQuote.RateTimestamp,
+		})
+
+		want := FormatTimestamp(updatedAt)
+		if want[len(want)-1] != 'Z' {
+			t.Fatalf("precision %d: expected a Z suffix, got %s", digits, want)
+		}
+		for name, r := range map[string]*QuoteResult{
+			"latest cache": fromLatest, "quote_result cache": fromResult, "notification": &fromNotification,
+		} {
+			if *r.UpdatedAt != *fromDB.UpdatedAt {
+				t.Errorf("precision %d, %s: updated_at %s differs from DB %s", digits, name, *r.UpdatedAt, *fromDB.UpdatedAt)
+			}
+		}
+		for name, r := range map[string]*QuoteResult{
+			"latest cache": fromLatest, "quote_result cache": fromResult, "notification": &fromNotification,
+		} {
+			if *r.RateTimestamp != *fromDB.RateTimestamp {
+				t.Errorf("precision %d, %s: rate_timestamp %s differs from DB %s", digits, name, *r.RateTimestamp, *fromDB.RateTimestamp)
+			}
+		}
+		if *fromDB.UpdatedAt != want {
+			t.Errorf("precision %d: expected updated_at %s, got %s", digits, want, *fromDB.UpdatedAt)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"quoteservice/internal/logging/fields"
+	"quoteservice/internal/repository"
+)
+
+// Sources of a QuoteUpdateEvent.
+const (
+	UpdateSourceProvider = "provider" // Polled by the worker through the rate provider.
+	UpdateSourceStream   = "stream"   // Pushed by the streaming provider.
+	UpdateSourceEnqueue  = "enqueue"  // Failed before reaching the worker.
+	UpdateSourceWorker   = "worker"   // Failed by the worker outside the provider path, e.g. a panic.
+)
+
+// QuoteUpdateEvent describes a quote update that reached SUCCESS or FAILED.
+type QuoteUpdateEvent struct {
+	UpdateID      string
+	Base          string
+	Quote         string
+	Status        repository.Status
+	Price         string               // Empty unless Status is SUCCESS.
+	Error         string               // Empty unless Status is FAILED.
+	ErrorCode     repository.ErrorCode // Set with Error.
+	Source        string
+	Provider      string    // Set when the update was forced through a named provider.
+	RateTimestamp time.Time // Zero unless Status is SUCCESS.
+	OccurredAt    time.Time
+}
+
+// QuoteEventPublisher receives every quote update that reaches a terminal state.
+// Publishing is best effort: a returned error is logged and does not fail the update.
+type QuoteEventPublisher interface {
+	PublishQuoteEvent(ctx context.Context, ev QuoteUpdateEvent) error
+}
+
+// NopQuoteEventPublisher discards all events. It is the default publisher.
+type NopQuoteEventPublisher struct{}
+
+// PublishQuoteEvent implements QuoteEventPublisher.
+func (NopQuoteEventPublisher) PublishQuoteEvent(context.Context, QuoteUpdateEvent) error {
+	return nil
+}
+
+func (s *QuoteService) publishSuccess(ctx context.Context, updateID string, pair Pair, source, providerName, rate string,
+	rateAt time.Time) {
+	s.publishEvent(ctx, QuoteUpdateEvent{
+		UpdateID:      updateID,
+		Base:          pair.Base,
+		Quote:         pair.Quote,
+		Status:        repository.StatusSuccess,
+		Price:         rate,
+		Source:        source,
+		Provider:      providerName,
+		RateTimestamp: rateAt,
+	})
+}
+
+func (s *QuoteService) publishFailure(ctx context.Context, updateID string, pair Pair, source string,
+	code repository.ErrorCode, reason string) {
+	s.publishEvent(ctx, QuoteUpdateEvent{
+		UpdateID:  updateID,
+		Base:      pair.Base,
+		Quote:     pair.Quote,
+		Status:    repository.StatusFailed,
+		Error:     reason,
+		ErrorCode: code,
+		Source:    source,
+	})
+}
+
+func (s *QuoteService) publishEvent(ctx context.Context, ev QuoteUpdateEvent) {
+	ev.OccurredAt = s.clock.Now().UTC()
+	if err := s.events.PublishQuoteEvent(ctx, ev); err != nil {
+		s.log.Warnw("Failed to publish quote event", fields.UpdateID(ev.UpdateID), "status", ev.Status, "error", err)
+	}
+}
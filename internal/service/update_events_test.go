@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"quoteservice/internal/repository"
+)
+
+// recordingPublisher collects published events.
+type recordingPublisher struct {
+	events []QuoteUpdateEvent
+	err    error
+}
+
+func (p *recordingPublisher) PublishQuoteEvent(_ context.Context, ev QuoteUpdateEvent) error {
+	p.events = append(p.events, ev)
+	return p.err
+}
+
+func newEventTestService(repo *mockQuoteRepo, prov *mockRatesProvider, pub QuoteEventPublisher) *QuoteService {
+	return NewQuoteService(QuoteServiceDeps{
+		Repo:        repo,
+		Provider:    prov,
+		Enqueuer:    &mockTaskEnqueuer{enqueueUpdateTaskFunc: func(context.Context, UpdateQuotePayload) error { return errors.New("redis down") }},
+		Events:      pub,
+		Logger:      zap.NewNop().Sugar(),
+		CacheConfig: testCacheCfg,
+	})
+}
+
+func TestProcessUpdate_PublishesSuccessEvent(t *testing.T) {
+	fetchedAt := time.Date(2024, 6, 14, 0, 0, 0, 0, time.UTC)
+	pub := &recordingPublisher{}
+	svc := newEventTestService(&mockQuoteRepo{
+		getByIDFunc:     pendingRecord,
+		markRunningFunc: func(context.Context, string, int64) error { return nil },
+		markSuccessFunc: func(context.Context, string, int64, string, time.Time) error { return nil },
+	}, &mockRatesProvider{
+		getRateFunc: func(string, string) (string, time.Time, error) { return "18.7543", fetchedAt, nil },
+	}, pub)
+
+	if err := svc.ProcessUpdate(context.Background(), UpdateQuotePayload{UpdateID: "test-id", Pair: Pair{Base: "eur", Quote: "mxn"}}); err != nil {
+		t.Fatalf("ProcessUpdate: %v", err)
+	}
+
+	if len(pub.events) != 1 {
+		t.Fatalf("Expected 1 event, got %d", len(pub.events))
+	}
+	ev := pub.events[0]
+	if ev.UpdateID != "test-id" || ev.Base != "EUR" || ev.Quote != "MXN" {
+		t.Errorf("Unexpected event identity: %+v", ev)
+	}
+	if ev.Status != repository.StatusSuccess || ev.Price != "18.7543" || ev.Error != "" {
+		t.Errorf("Unexpected event result: %+v", ev)
+	}
+	if ev.Source != UpdateSourceProvider || !ev.RateTimestamp.Equal(fetchedAt) || ev.OccurredAt.IsZero() {
+		t.Errorf("Unexpected event metadata: %+v", ev)
+	}
+}
+
+func TestProcessUpdate_PublishesFailureEvent(t *testing.T) {
+	pub := &recordingPublisher{err: errors.New("sink down")} // Publish errors must not fail the update.
+	svc := newEventTestService(&mockQuoteRepo{
+		getByIDFunc:     pendingRecord,
+		markRunningFunc: func(context.Context, string, int64) error { return nil },
+		markFailedFunc:  func(context.Context, string, int64, repository.ErrorCode, string) error { return nil },
+	}, &mockRatesProvider{
+		getRateFunc: func(string, string) (string, time.Time, error) { return "", time.Time{}, errors.New("provider error") },
+	}, pub)
+
+	err := svc.ProcessUpdate(context.Background(), UpdateQuotePayload{UpdateID: "test-id", Pair: Pair{Base: "EUR", Quote: "MXN"}})
+	if err == nil || err.Error() != "provider error" {
+		t.Fatalf("Expected provider error, got %v", err)
+	}
+
+	if len(pub.events) != 1 {
+		t.Fatalf("Expected 1 event, got %d", len(pub.events))
+	}
+	ev := pub.events[0]
+	if ev.Status != repository.StatusFailed || ev.Error != "provider error" || ev.Price != "" {
+		t.Errorf("Unexpected event: %+v", ev)
+	}
+}
+
+func TestProcessUpdate_NoEventWhenMarkFailedFails(t *testing.T) {
+	pub := &recordingPublisher{}
+	svc := newEventTestService(&mockQuoteRepo{
+		getByIDFunc:     pendingRecord,
+		markRunningFunc: func(context.Context, string, int64) error { return nil },
+		markFailedFunc:  func(context.Context, string, int64, repository.ErrorCode, string) error { return errors.New("db down") },
+	}, &mockRatesProvider{
+		getRateFunc: func(string, string) (string, time.Time, error) { return "", time.Time{}, errors.New("provider error") },
+	}, pub)
+
+	_ = svc.ProcessUpdate(context.Background(), UpdateQuotePayload{UpdateID: "test-id", Pair: Pair{Base: "EUR", Quote: "MXN"}})
+	if len(pub.events) != 0 {
+		t.Errorf("Expected no event when the record did not reach FAILED, got %+v", pub.events)
+	}
+}
+
+func TestRequestQuoteUpdate_EnqueueFailurePublishesEvent(t *testing.T) {
+	pub := &recordingPublisher{}
+	svc := newEventTestService(&mockQuoteRepo{
+		createUpdateFunc: func(_ context.Context, _ Pair, id string) (repository.CreateUpdateResult, error) { return created(id) },
+		markFailedFunc:   func(context.Context, string, int64, repository.ErrorCode, string) error { return nil },
+	}, nil, pub)
+
+	_, err := svc.RequestQuoteUpdate(context.Background(), "EUR/MXN", UpdateOptions{})
+	if !errors.Is(err, ErrInternalQueue) {
+		t.Fatalf("Expected ErrInternalQueue, got %v", err)
+	}
+
+	if len(pub.events) != 1 {
+		t.Fatalf("Expected 1 event, got %d", len(pub.events))
+	}
+	if ev := pub.events[0]; ev.Status != repository.StatusFailed || ev.Source != UpdateSourceEnqueue {
+		t.Errorf("Unexpected event: %+v", ev)
+	}
+}
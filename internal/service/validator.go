@@ -2,6 +2,7 @@ package service
 
 import (
 	"errors"
+	"fmt"
 	"strings"
 )
 
@@ -30,6 +31,7 @@ var ErrUnsupportedCurrency = errors.New("unsupported currency")
 type Validator interface {
 	Validate(code string) error
 	IsSupported(code string) bool
+	BulkValidate(codes []string) map[string]error
 }
 
 type validator struct{}
@@ -44,7 +46,7 @@ func (v *validator) Validate(code string) error {
 	if v.IsSupported(code) {
 		return nil
 	}
-	return ErrUnsupportedCurrency
+	return unsupportedCurrencyError(code)
 }
 
 // IsSupported returns true if the currency code is supported (case-insensitive).
@@ -52,3 +54,25 @@ func (v *validator) IsSupported(code string) bool {
 	_, ok := supportedCurrencies[strings.ToUpper(code)]
 	return ok
 }
+
+// BulkValidate validates all codes in a single pass and returns a map of
+// code to error (nil for supported codes). Duplicate codes share one entry.
+func (v *validator) BulkValidate(codes []string) map[string]error {
+	result := make(map[string]error, len(codes))
+	for _, code := range codes {
+		if _, seen := result[code]; seen {
+			continue
+		}
+		if _, ok := supportedCurrencies[strings.ToUpper(code)]; ok {
+			result[code] = nil
+		} else {
+			result[code] = unsupportedCurrencyError(code)
+		}
+	}
+	return result
+}
+
+// unsupportedCurrencyError wraps ErrUnsupportedCurrency with the offending code.
+func unsupportedCurrencyError(code string) error {
+	return fmt.Errorf("%w: %s", ErrUnsupportedCurrency, strings.ToUpper(code))
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestBulkValidate(t *testing.T) {
+	v := NewValidator()
+
+	codes := []string{"USD", "eur", "ABC", "MXN", "XYZ"}
+	result := v.BulkValidate(codes)
+
+	if len(result) != len(codes) {
+		t.Fatalf("Expected %d entries, got %d", len(codes), len(result))
+	}
+
+	var errCount, nilCount int
+	for code, err := range result {
+		if err == nil {
+			nilCount++
+			continue
+		}
+		errCount++
+		if !errors.Is(err, ErrUnsupportedCurrency) {
+			t.Errorf("Expected ErrUnsupportedCurrency for %s, got %v", code, err)
+		}
+	}
+	if errCount != 2 {
+		t.Errorf("Expected 2 error entries, got %d", errCount)
+	}
+	if nilCount != 3 {
+		t.Errorf("Expected 3 nil entries, got %d", nilCount)
+	}
+	if result["ABC"] == nil || result["XYZ"] == nil {
+		t.Error("Expected ABC and XYZ to be reported as unsupported")
+	}
+}
+
+func TestBulkValidate_Duplicates(t *testing.T) {
+	v := NewValidator()
+
+	result := v.BulkValidate([]string{"USD", "USD", "ABC", "ABC"})
+	if len(result) != 2 {
+		t.Fatalf("Expected 2 entries for duplicated codes, got %d", len(result))
+	}
+	if result["USD"] != nil {
+		t.Errorf("Expected USD to be valid, got %v", result["USD"])
+	}
+	if !errors.Is(result["ABC"], ErrUnsupportedCurrency) {
+		t.Errorf("Expected ErrUnsupportedCurrency for ABC, got %v", result["ABC"])
+	}
+}
+
+func TestValidate_ErrorNamesCurrency(t *testing.T) {
+	err := NewValidator().Validate("abc")
+	if !errors.Is(err, ErrUnsupportedCurrency) {
+		t.Fatalf("Expected ErrUnsupportedCurrency, got %v", err)
+	}
+	if err.Error() != "unsupported currency: ABC" {
+		t.Errorf("Expected message to name the currency, got %q", err.Error())
+	}
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"math/big"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/time/rate"
+
+	"quoteservice/internal/config"
+	"quoteservice/internal/logging/fields"
+	"quoteservice/internal/metrics"
+	"quoteservice/internal/provider"
+	"quoteservice/internal/repository"
+)
+
+// SpreadVerifier asks every provider for a pair's rate and measures how far the
+// answers diverge. It is best effort: providers that fail, have an open circuit or are
+// over their verification budget are left out of the comparison.
+type SpreadVerifier struct {
+	providers      []provider.NamedProvider
+	limiters       []*rate.Limiter // Parallel to providers.
+	maxConcurrency int
+	thresholdPct   float64
+	log            *zap.SugaredLogger
+}
+
+// NewSpreadVerifier creates a SpreadVerifier from cfg. It returns nil when fewer than
+// two providers are given, since there would be nothing to compare.
+func NewSpreadVerifier(providers []provider.NamedProvider, cfg config.VerificationConfig, logger *zap.SugaredLogger) *SpreadVerifier {
+	if len(providers) < 2 {
+		return nil
+	}
+	if logger == nil {
+		logger = zap.NewNop().Sugar()
+	}
+	perSec := rate.Limit(float64(cfg.ProviderRequestsPerMin) / time.Minute.Seconds())
+	limiters := make([]*rate.Limiter, len(providers))
+	for i := range providers {
+		limiters[i] = rate.NewLimiter(perSec, max(cfg.ProviderRequestsPerMin, 1))
+	}
+	return &SpreadVerifier{
+		providers:      providers,
+		limiters:       limiters,
+		maxConcurrency: max(cfg.MaxConcurrency, 1),
+		thresholdPct:   cfg.SpreadThresholdPct,
+		log:            logger,
+	}
+}
+
+// Verify queries the providers for pair and returns the spread of their rates,
+// or nil when fewer than two rates could be compared. A spread above the threshold is
+// logged at WARN and counted in metrics.ProviderSpreadExceededTotal.
+func (v *SpreadVerifier) Verify(ctx context.Context, pair Pair) *repository.Verification {
+	rates := make([]string, len(v.providers))
+
+	var g errgroup.Group
+	g.SetLimit(v.maxConcurrency)
+	for i, np := range v.providers {
+		if !provider.IsAvailable(np.Provider, pair.Base, pair.Quote) || !v.limiters[i].Allow() {
+			continue
+		}
+		g.Go(func() error {
+			r, _, err := np.Provider.GetRate(ctx, pair.Base, pair.Quote)
+			if err != nil {
+				v.log.Debugw("Provider failed during spread verification", fields.Pair(pair.Base, pair.Quote), fields.Provider(np.Name), "error", err)
+				return nil
+			}
+			rates[i] = r
+			return nil
+		})
+	}
+	_ = g.Wait()
+
+	var minRat, maxRat *big.Rat
+	var minPrice, maxPrice string
+	answered := make(map[string]string, len(rates))
+	for i, r := range rates {
+		rat, ok := new(big.Rat).SetString(r)
+		if !ok || rat.Sign() <= 0 {
+			continue
+		}
+		answered[v.providers[i].Name] = r
+		if minRat == nil || rat.Cmp(minRat) < 0 {
+			minRat, minPrice = rat, r
+		}
+		if maxRat == nil || rat.Cmp(maxRat) > 0 {
+			maxRat, maxPrice = rat, r
+		}
+	}
+	if len(answered) < 2 {
+		return nil
+	}
+
+	// (max - min) / min * 100
+	spread := new(big.Rat).Sub(maxRat, minRat)
+	spread.Quo(spread, minRat)
+	spread.Mul(spread, big.NewRat(100, 1))
+	result := &repository.Verification{
+		MinPrice:  minPrice,
+		MaxPrice:  maxPrice,
+		Spread:    spread.FloatString(4),
+		Providers: len(answered),
+	}
+
+	if v.thresholdPct > 0 && spread.Cmp(new(big.Rat).SetFloat64(v.thresholdPct)) > 0 {
+		metrics.ProviderSpreadExceededTotal.Inc()
+		v.log.Warnw("Provider rates diverge beyond threshold",
+			fields.Pair(pair.Base, pair.Quote), "min_price", minPrice, "max_price", maxPrice,
+			"spread_pct", result.Spread, "threshold_pct", v.thresholdPct, "rates", answered)
+	}
+	return result
+}
@@ -0,0 +1,156 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"quoteservice/internal/config"
+	"quoteservice/internal/metrics"
+	"quoteservice/internal/provider"
+	"quoteservice/internal/repository"
+)
+
+var testVerificationCfg = config.VerificationConfig{
+	Enabled:                true,
+	SpreadThresholdPct:     1,
+	MaxConcurrency:         2,
+	ProviderRequestsPerMin: 60,
+}
+
+func namedProviders(rates ...string) []provider.NamedProvider {
+	named := make([]provider.NamedProvider, len(rates))
+	for i, r := range rates {
+		named[i] = provider.NamedProvider{Name: string(rune('a' + i)), Provider: fixedRateProvider(r)}
+	}
+	return named
+}
+
+func TestNewSpreadVerifier_SingleProvider(t *testing.T) {
+	if v := NewSpreadVerifier(namedProviders("1.08"), testVerificationCfg, nil); v != nil {
+		t.Fatal("expected no verifier for a single provider")
+	}
+}
+
+func TestSpreadVerifier_DivergentRates(t *testing.T) {
+	tests := []struct {
+		name         string
+		rates        []string
+		want         repository.Verification
+		wantExceeded float64
+	}{
+		{
+			name:         "beyond threshold",
+			rates:        []string{"1.01", "1.00", "1.02"},
+			want:         repository.Verification{MinPrice: "1.00", MaxPrice: "1.02", Spread: "2.0000", Providers: 3},
+			wantExceeded: 1,
+		},
+		{
+			name:  "within threshold",
+			rates: []string{"1.0850", "1.0852"},
+			want:  repository.Verification{MinPrice: "1.0850", MaxPrice: "1.0852", Spread: "0.0184", Providers: 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := NewSpreadVerifier(namedProviders(tt.rates...), testVerificationCfg, nil)
+			before := testutil.ToFloat64(metrics.ProviderSpreadExceededTotal)
+
+			got := v.Verify(context.Background(), Pair{Base: "EUR", Quote: "USD"})
+			if got == nil {
+				t.Fatal("expected a verification")
+			}
+			if *got != tt.want {
+				t.Errorf("got %+v, want %+v", *got, tt.want)
+			}
+			if d := testutil.ToFloat64(metrics.ProviderSpreadExceededTotal) - before; d != tt.wantExceeded {
+				t.Errorf("spread exceeded metric grew by %v, want %v", d, tt.wantExceeded)
+			}
+		})
+	}
+}
+
+func TestSpreadVerifier_SkipsFailedProviders(t *testing.T) {
+	failing := &mockRatesProvider{getRateFunc: func(string, string) (string, time.Time, error) {
+		return "", time.Time{}, errors.New("provider down")
+	}}
+
+	providers := append(namedProviders("1.08"), provider.NamedProvider{Name: "down", Provider: failing})
+	v := NewSpreadVerifier(providers, testVerificationCfg, nil)
+	if got := v.Verify(context.Background(), Pair{Base: "EUR", Quote: "USD"}); got != nil {
+		t.Errorf("expected nil with one answering provider, got %+v", *got)
+	}
+
+	providers = append(namedProviders("1.08", "1.09"), provider.NamedProvider{Name: "down", Provider: failing})
+	v = NewSpreadVerifier(providers, testVerificationCfg, nil)
+	got := v.Verify(context.Background(), Pair{Base: "EUR", Quote: "USD"})
+	if got == nil || got.Providers != 2 {
+		t.Errorf("expected 2 compared providers, got %+v", got)
+	}
+}
+
+func TestSpreadVerifier_RespectsProviderRateLimit(t *testing.T) {
+	var calls atomic.Int32
+	counting := func(rate string) *mockRatesProvider {
+		return &mockRatesProvider{getRateFunc: func(string, string) (string, time.Time, error) {
+			calls.Add(1)
+			return rate, time.Now(), nil
+		}}
+	}
+	cfg := testVerificationCfg
+	cfg.ProviderRequestsPerMin = 1
+	v := NewSpreadVerifier([]provider.NamedProvider{
+		{Name: "a", Provider: counting("1.08")},
+		{Name: "b", Provider: counting("1.09")},
+	}, cfg, nil)
+
+	if got := v.Verify(context.Background(), Pair{Base: "EUR", Quote: "USD"}); got == nil {
+		t.Fatal("expected the first verification to run")
+	}
+	if got := v.Verify(context.Background(), Pair{Base: "EUR", Quote: "USD"}); got != nil {
+		t.Errorf("expected no verification once the budget is spent, got %+v", *got)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("expected 2 provider calls, got %d", n)
+	}
+}
+
+func TestProcessUpdate_RecordsSpread(t *testing.T) {
+	var saved *repository.Verification
+	repo := &mockQuoteRepo{
+		getByIDFunc:     pendingRecord,
+		markRunningFunc: func(context.Context, string, int64) error { return nil },
+		markSuccessFunc: func(_ context.Context, _ string, _ int64, price string, _ time.Time) error {
+			if price != "18.70" {
+				t.Errorf("expected the primary's price 18.70 to be stored, got %s", price)
+			}
+			return nil
+		},
+		saveVerificationFunc: func(_ context.Context, id string, v repository.Verification) error {
+			if id != "test-id" {
+				t.Errorf("expected update test-id, got %s", id)
+			}
+			saved = &v
+			return nil
+		},
+	}
+	providers := namedProviders("18.70", "19.00")
+	svc := NewQuoteService(QuoteServiceDeps{
+		Repo:        repo,
+		Provider:    provider.NewNamedExchangeProviderFacade(providers...),
+		CacheConfig: testCacheCfg,
+	}, WithSpreadVerifier(NewSpreadVerifier(providers, testVerificationCfg, nil)))
+
+	if err := svc.ProcessUpdate(context.Background(), UpdateQuotePayload{UpdateID: "test-id", Pair: Pair{Base: "EUR", Quote: "MXN"}}); err != nil {
+		t.Fatalf("ProcessUpdate: %v", err)
+	}
+	want := repository.Verification{MinPrice: "18.70", MaxPrice: "19.00", Spread: "1.6043", Providers: 2}
+	if saved == nil || *saved != want {
+		t.Errorf("saved verification %+v, want %+v", saved, want)
+	}
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+
+	"quoteservice/internal/logging/fields"
+	"quoteservice/internal/repository"
+)
+
+// webhookProbeTimeout bounds the reachability probe made when a webhook is registered.
+const webhookProbeTimeout = 2 * time.Second
+
+// ErrInvalidWebhookURL indicates the webhook URL is malformed or not HTTPS.
+var ErrInvalidWebhookURL = errors.New("webhook url must be an absolute https url")
+
+// ErrWebhookProbeFailed indicates the webhook URL did not answer the registration probe.
+var ErrWebhookProbeFailed = errors.New("webhook url is not reachable")
+
+// ErrInvalidWebhookID indicates the webhook ID format is invalid.
+var ErrInvalidWebhookID = errors.New("invalid webhook_id")
+
+// ErrWebhookExists indicates the pair already has a webhook with the same URL.
+var ErrWebhookExists = errors.New("webhook already registered")
+
+// ErrWebhooksUnavailable indicates the service was built without a WebhookRepository
+// or a WebhookSecretKey.
+var ErrWebhooksUnavailable = errors.New("webhooks unavailable")
+
+// Webhook is a registered webhook subscription. The secret is never returned.
+type Webhook struct {
+	ID        string
+	Base      string
+	Quote     string
+	URL       string
+	CreatedAt time.Time
+}
+
+// RegisterWebhook subscribes rawURL to updates of pair. The URL must be HTTPS and answer
+// a GET probe without a 5xx; the secret is stored only as an HMAC-SHA256 digest.
+func (s *QuoteService) RegisterWebhook(ctx context.Context, pair, rawURL, secret string) (*Webhook, error) {
+	p, err := ParsePair(pair)
+	if err != nil {
+		return nil, err
+	}
+	if vErr := s.validatePair(p); vErr != nil {
+		return nil, vErr
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return nil, ErrInvalidWebhookURL
+	}
+	if s.webhookRepo == nil || len(s.webhookSecretKey) == 0 {
+		return nil, ErrWebhooksUnavailable
+	}
+
+	if err := s.probeWebhook(ctx, u.String()); err != nil {
+		s.log.Infow("Webhook probe failed", "url", u.Redacted(), "error", err)
+		return nil, ErrWebhookProbeFailed
+	}
+
+	w := &repository.Webhook{
+		ID:         uuid.New().String(),
+		Base:       p.Base,
+		Quote:      p.Quote,
+		URL:        u.String(),
+		SecretHash: s.hashWebhookSecret(secret),
+	}
+	if err := s.webhookRepo.CreateWebhook(ctx, w); err != nil {
+		if errors.Is(err, repository.ErrWebhookExists) {
+			return nil, ErrWebhookExists
+		}
+		s.log.Errorw("CreateWebhook DB error", "error", err)
+		return nil, storageError(err)
+	}
+
+	s.log.Infow("Registered webhook", "webhook_id", w.ID, fields.Pair(p.Base, p.Quote))
+	return &Webhook{ID: w.ID, Base: w.Base, Quote: w.Quote, URL: w.URL, CreatedAt: w.CreatedAt}, nil
+}
+
+// DeregisterWebhook removes the webhook with the given ID.
+func (s *QuoteService) DeregisterWebhook(ctx context.Context, webhookID string) error {
+	uid, err := uuid.Parse(webhookID)
+	if err != nil {
+		return ErrInvalidWebhookID
+	}
+	if s.webhookRepo == nil {
+		return ErrWebhooksUnavailable
+	}
+
+	if err := s.webhookRepo.DeleteWebhook(ctx, uid.String()); err != nil {
+		if errors.Is(err, repository.ErrWebhookNotFound) {
+			return ErrNotFound
+		}
+		s.log.Errorw("DeleteWebhook DB error", "webhook_id", webhookID, "error", err)
+		return storageError(err)
+	}
+
+	s.log.Infow("Deregistered webhook", "webhook_id", webhookID)
+	return nil
+}
+
+// probeWebhook issues a GET to target. Any response below 500 counts as reachable:
+// receivers commonly reject GETs on a POST endpoint, which still proves they are up.
+// Redirects are not followed, so a 3xx is judged on its own.
+func (s *QuoteService) probeWebhook(ctx context.Context, target string) error {
+	ctx, cancel := context.WithTimeout(ctx, webhookProbeTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, http.NoBody)
+	if err != nil {
+		return err
+	}
+	resp, err := s.probeClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return errors.New(resp.Status)
+	}
+	return nil
+}
+
+func (s *QuoteService) hashWebhookSecret(secret string) string {
+	mac := hmac.New(sha256.New, s.webhookSecretKey)
+	mac.Write([]byte(secret))
+	return hex.EncodeToString(mac.Sum(nil))
+}
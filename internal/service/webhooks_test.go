@@ -0,0 +1,288 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"quoteservice/internal/repository"
+)
+
+var _ repository.WebhookRepository = (*mockWebhookRepo)(nil)
+
+type mockWebhookRepo struct {
+	createWebhookFunc func(ctx context.Context, w *repository.Webhook) error
+	deleteWebhookFunc func(ctx context.Context, id string) error
+}
+
+func (m *mockWebhookRepo) CreateWebhook(ctx context.Context, w *repository.Webhook) error {
+	return m.createWebhookFunc(ctx, w)
+}
+
+func (m *mockWebhookRepo) DeleteWebhook(ctx context.Context, id string) error {
+	return m.deleteWebhookFunc(ctx, id)
+}
+
+var testWebhookKey = []byte("test-webhook-key")
+
+func newWebhookTestService(repo repository.WebhookRepository, probe *http.Client) *QuoteService {
+	return NewQuoteService(QuoteServiceDeps{
+		Validator:        NewValidator(),
+		WebhookRepo:      repo,
+		WebhookSecretKey: testWebhookKey,
+		ProbeClient:      probe,
+		Logger:           zap.NewNop().Sugar(),
+		CacheConfig:      testCacheCfg,
+	})
+}
+
+func TestRegisterWebhook_Success(t *testing.T) {
+	var probes atomic.Int32
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		probes.Add(1)
+		if r.Method != http.MethodGet {
+			t.Errorf("expected GET probe, got %s", r.Method)
+		}
+		w.WriteHeader(http.StatusMethodNotAllowed) // POST-only receivers still count as reachable.
+	}))
+	defer srv.Close()
+
+	createdAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	var stored *repository.Webhook
+	repo := &mockWebhookRepo{
+		createWebhookFunc: func(_ context.Context, w *repository.Webhook) error {
+			stored = w
+			w.CreatedAt = createdAt
+			return nil
+		},
+	}
+	svc := newWebhookTestService(repo, srv.Client())
+
+	wh, err := svc.RegisterWebhook(context.Background(), "usd/eur", srv.URL+"/hook", "s3cret")
+	if err != nil {
+		t.Fatalf("RegisterWebhook: %v", err)
+	}
+	if probes.Load() != 1 {
+		t.Fatalf("expected 1 probe, got %d", probes.Load())
+	}
+	if stored == nil {
+		t.Fatal("CreateWebhook was not called")
+	}
+	if _, err := uuid.Parse(wh.ID); err != nil || wh.ID != stored.ID {
+		t.Errorf("expected returned ID %q to be the stored UUID %q", wh.ID, stored.ID)
+	}
+	if wh.Base != "USD" || wh.Quote != "EUR" || wh.URL != srv.URL+"/hook" || !wh.CreatedAt.Equal(createdAt) {
+		t.Errorf("unexpected webhook: %+v", wh)
+	}
+
+	mac := hmac.New(sha256.New, testWebhookKey)
+	mac.Write([]byte("s3cret"))
+	if want := hex.EncodeToString(mac.Sum(nil)); stored.SecretHash != want {
+		t.Errorf("expected secret hash %s, got %s", want, stored.SecretHash)
+	}
+}
+
+func TestRegisterWebhook_Validation(t *testing.T) {
+	tests := []struct {
+		name    string
+		pair    string
+		url     string
+		wantErr error
+	}{
+		{"bad pair", "USDEUR", "https://example.com/hook", ErrInvalidPairFormat},
+		{"unsupported currency", "ABC/USD", "https://example.com/hook", ErrUnsupportedCurrency},
+		{"http scheme", "USD/EUR", "http://example.com/hook", ErrInvalidWebhookURL},
+		{"relative url", "USD/EUR", "/hook", ErrInvalidWebhookURL},
+		{"no host", "USD/EUR", "https:///hook", ErrInvalidWebhookURL},
+		{"unparsable url", "USD/EUR", "https://exa mple.com", ErrInvalidWebhookURL},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := &mockWebhookRepo{} // Any repo call panics on the nil func.
+			svc := newWebhookTestService(repo, nil)
+
+			_, err := svc.RegisterWebhook(context.Background(), tc.pair, tc.url, "secret")
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("expected %v, got %v", tc.wantErr, err)
+			}
+			if !IsValidationError(err) {
+				t.Errorf("expected %v to be a validation error", err)
+			}
+		})
+	}
+}
+
+func TestRegisterWebhook_ProbeFailed(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+	}{
+		{"server error", func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+		}},
+		{"timeout", func(_ http.ResponseWriter, r *http.Request) {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(webhookProbeTimeout + time.Second):
+			}
+		}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := httptest.NewTLSServer(tc.handler)
+			defer srv.Close()
+
+			repo := &mockWebhookRepo{}
+			svc := newWebhookTestService(repo, srv.Client())
+
+			_, err := svc.RegisterWebhook(context.Background(), "USD/EUR", srv.URL, "secret")
+			if !errors.Is(err, ErrWebhookProbeFailed) {
+				t.Fatalf("expected ErrWebhookProbeFailed, got %v", err)
+			}
+		})
+	}
+}
+
+func TestRegisterWebhook_UntrustedCertificate(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	// The default client does not trust the test server's self-signed certificate.
+	svc := newWebhookTestService(&mockWebhookRepo{}, nil)
+
+	_, err := svc.RegisterWebhook(context.Background(), "USD/EUR", srv.URL, "secret")
+	if !errors.Is(err, ErrWebhookProbeFailed) {
+		t.Fatalf("expected ErrWebhookProbeFailed, got %v", err)
+	}
+}
+
+func TestRegisterWebhook_DoesNotFollowRedirects(t *testing.T) {
+	var followed atomic.Int32
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		followed.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer target.Close()
+
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, target.URL, http.StatusFound)
+	}))
+	defer srv.Close()
+
+	repo := &mockWebhookRepo{
+		createWebhookFunc: func(context.Context, *repository.Webhook) error { return nil },
+	}
+	svc := newWebhookTestService(repo, srv.Client())
+
+	if _, err := svc.RegisterWebhook(context.Background(), "USD/EUR", srv.URL, "secret"); err != nil {
+		t.Fatalf("RegisterWebhook: %v", err)
+	}
+	if n := followed.Load(); n != 0 {
+		t.Errorf("expected the redirect not to be followed, got %d requests to its target", n)
+	}
+	if srv.Client().CheckRedirect != nil {
+		t.Error("expected the caller's ProbeClient not to be modified")
+	}
+}
+
+func TestRegisterWebhook_RepoErrors(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name    string
+		repoErr error
+		wantErr error
+	}{
+		{"duplicate", repository.ErrWebhookExists, ErrWebhookExists},
+		{"db failure", errors.New("connection reset"), ErrInternal},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := &mockWebhookRepo{
+				createWebhookFunc: func(context.Context, *repository.Webhook) error { return tc.repoErr },
+			}
+			svc := newWebhookTestService(repo, srv.Client())
+
+			_, err := svc.RegisterWebhook(context.Background(), "USD/EUR", srv.URL, "secret")
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("expected %v, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestDeregisterWebhook(t *testing.T) {
+	id := uuid.New().String()
+
+	tests := []struct {
+		name    string
+		id      string
+		repoErr error
+		wantErr error
+	}{
+		{"deleted", id, nil, nil},
+		{"not found", id, repository.ErrWebhookNotFound, ErrNotFound},
+		{"db failure", id, errors.New("connection reset"), ErrInternal},
+		{"invalid id", "not-a-uuid", nil, ErrInvalidWebhookID},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var deleted string
+			repo := &mockWebhookRepo{
+				deleteWebhookFunc: func(_ context.Context, id string) error {
+					deleted = id
+					return tc.repoErr
+				},
+			}
+			svc := newWebhookTestService(repo, nil)
+
+			err := svc.DeregisterWebhook(context.Background(), tc.id)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("expected %v, got %v", tc.wantErr, err)
+			}
+			if tc.wantErr == nil && deleted != id {
+				t.Errorf("expected DeleteWebhook(%s), got %q", id, deleted)
+			}
+		})
+	}
+}
+
+func TestWebhooks_Unavailable(t *testing.T) {
+	svc := newWebhookTestService(nil, nil)
+
+	if _, err := svc.RegisterWebhook(context.Background(), "USD/EUR", "https://example.com", "s"); !errors.Is(err, ErrWebhooksUnavailable) {
+		t.Errorf("RegisterWebhook: expected ErrWebhooksUnavailable, got %v", err)
+	}
+	if err := svc.DeregisterWebhook(context.Background(), uuid.New().String()); !errors.Is(err, ErrWebhooksUnavailable) {
+		t.Errorf("DeregisterWebhook: expected ErrWebhooksUnavailable, got %v", err)
+	}
+
+	keyless := NewQuoteService(QuoteServiceDeps{
+		Validator:   NewValidator(),
+		WebhookRepo: &mockWebhookRepo{},
+		Logger:      zap.NewNop().Sugar(),
+		CacheConfig: testCacheCfg,
+	})
+	if _, err := keyless.RegisterWebhook(context.Background(), "USD/EUR", "https://example.com", "s"); !errors.Is(err, ErrWebhooksUnavailable) {
+		t.Errorf("RegisterWebhook without a secret key: expected ErrWebhooksUnavailable, got %v", err)
+	}
+}
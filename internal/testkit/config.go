@@ -9,28 +9,56 @@ import (
 )
 
 // Config holds environment-driven configuration for integration test infrastructure.
+//
+// Environment matrix:
+//
+//	TEST_PG_IMAGE, TEST_REDIS_IMAGE   container images (testcontainers mode only)
+//	TEST_PG_DSN, TEST_REDIS_ADDR      use external services instead of containers
+//	                                  (CI service containers, docker-compose)
+//	TEST_REUSE_CONTAINERS=true        reuse named containers across runs and packages;
+//	                                  they are never terminated by Shutdown, and every
+//	                                  package gets its own database inside them
+//	TEST_RESET_SCHEMA=true            drop and recreate the public schema on Setup when
+//	                                  the database may hold state from a previous run;
+//	                                  defaults to true for reused containers and false
+//	                                  for TEST_PG_DSN, which may point at a shared database
+//	TEST_STARTUP_TIMEOUT=90s          readiness deadline (duration or plain seconds)
+//	KEEP_CONTAINERS=true              leave freshly started containers running after Shutdown
+//
+// Without any variables set, every package gets fresh, random-named containers.
 type Config struct {
-	PGImage        string
-	RedisImage     string
-	PGDSN          string        // If set, skip Postgres container.
-	RedisAddr      string        // If set, skip Redis container.
-	StartupTimeout time.Duration // Max time to wait for containers to become ready.
-	KeepContainers bool          // If true, do not terminate containers on shutdown.
+	PGImage         string
+	RedisImage      string
+	PGDSN           string        // If set, skip Postgres container.
+	RedisAddr       string        // If set, skip Redis container.
+	StartupTimeout  time.Duration // Max time to wait for containers to become ready.
+	KeepContainers  bool          // If true, do not terminate containers on shutdown.
+	ReuseContainers bool          // If true, reuse containers by name and never terminate them.
+	ResetSchema     bool          // If true, reset the public schema of a possibly dirty database.
 }
 
 // LoadConfig reads test infrastructure settings from environment variables.
 func LoadConfig() Config {
+	pgDSN := os.Getenv("TEST_PG_DSN")
 	cfg := Config{
-		PGImage:        envOrDefault("TEST_PG_IMAGE", "postgres:18.1-alpine"),
-		RedisImage:     envOrDefault("TEST_REDIS_IMAGE", "redis:8.4.0-alpine"),
-		PGDSN:          os.Getenv("TEST_PG_DSN"),
-		RedisAddr:      os.Getenv("TEST_REDIS_ADDR"),
-		StartupTimeout: envDurationOrDefault("TEST_STARTUP_TIMEOUT", 90*time.Second),
-		KeepContainers: envBoolOrDefault("KEEP_CONTAINERS", false),
+		PGImage:         envOrDefault("TEST_PG_IMAGE", "postgres:18.1-alpine"),
+		RedisImage:      envOrDefault("TEST_REDIS_IMAGE", "redis:8.4.0-alpine"),
+		PGDSN:           pgDSN,
+		RedisAddr:       os.Getenv("TEST_REDIS_ADDR"),
+		StartupTimeout:  envDurationOrDefault("TEST_STARTUP_TIMEOUT", 90*time.Second),
+		KeepContainers:  envBoolOrDefault("KEEP_CONTAINERS", false),
+		ReuseContainers: envBoolOrDefault("TEST_REUSE_CONTAINERS", false),
+		// Dropping public on a database the tests do not own is opt-in.
+		ResetSchema: envBoolOrDefault("TEST_RESET_SCHEMA", pgDSN == ""),
 	}
 	return cfg
 }
 
+// needsSchemaReset reports whether the Postgres database may contain state from a previous run.
+func (c *Config) needsSchemaReset() bool {
+	return c.ResetSchema && (c.PGDSN != "" || c.ReuseContainers)
+}
+
 func envOrDefault(key, def string) string {
 	if v := os.Getenv(key); v != "" {
 		return v
@@ -0,0 +1,246 @@
+// Package fakeprovider provides httptest servers that mimic the external rate
+// providers (Frankfurter and exchangerate.host) with runtime-adjustable fault
+// injection for integration tests.
+package fakeprovider
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Flavor selects which provider API the fake server emulates.
+type Flavor string
+
+// Supported provider flavors.
+const (
+	Frankfurter      Flavor = "frankfurter"
+	ExchangeRateHost Flavor = "exchangerate_host"
+)
+
+// ControlPrefix is the path prefix of the control endpoints.
+const ControlPrefix = "/_control"
+
+// Faults describes the failure modes applied to provider requests.
+// The zero value means a healthy provider.
+type Faults struct {
+	ErrorRate      float64 `json:"error_rate"`       // Probability [0..1] of answering 500.
+	LatencyMs      int     `json:"latency_ms"`       // Fixed delay added to every request.
+	SpikeLatencyMs int     `json:"spike_latency_ms"` // Extra delay applied every SpikeEvery requests.
+	SpikeEvery     int     `json:"spike_every"`      // Spike period in requests (0 disables spikes).
+	MalformedBody  bool    `json:"malformed_body"`   // Respond 200 with a body that is not valid JSON.
+	RateLimited    bool    `json:"rate_limited"`     // Respond 429 with a Retry-After header.
+	RetryAfterSec  int     `json:"retry_after_sec"`  // Retry-After value for rate-limited responses.
+}
+
+// Server is a fake rate provider backed by httptest.Server.
+type Server struct {
+	flavor Flavor
+	srv    *httptest.Server
+
+	mu       sync.Mutex
+	rates    map[string]string // "EURUSD" -> "1.0850"
+	faults   Faults
+	statuses []int // Queued status codes returned before normal handling resumes.
+	calls    int
+}
+
+// New starts a fake provider server of the given flavor.
+func New(flavor Flavor) *Server {
+	s := &Server{
+		flavor: flavor,
+		rates:  make(map[string]string),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(ControlPrefix+"/faults", s.handleControlFaults)
+	mux.HandleFunc(ControlPrefix+"/sequence", s.handleControlSequence)
+	mux.HandleFunc(ControlPrefix+"/reset", s.handleControlReset)
+	mux.HandleFunc("/", s.handleRate)
+
+	s.srv = httptest.NewServer(mux)
+	return s
+}
+
+// URL returns the base URL to configure the real provider client with.
+func (s *Server) URL() string { return s.srv.URL }
+
+// Close shuts down the server.
+func (s *Server) Close() { s.srv.Close() }
+
+// SetRate sets the rate returned for base/quote.
+func (s *Server) SetRate(base, quote, rate string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rates[base+quote] = rate
+}
+
+// SetFaults replaces the active fault configuration.
+func (s *Server) SetFaults(f Faults) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = f
+}
+
+// FailSequence queues status codes returned by the next requests, in order,
+// before normal handling resumes. FailSequence(500, 500) means
+// "fail twice then succeed".
+func (s *Server) FailSequence(statuses ...int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.statuses = append(s.statuses[:0], statuses...)
+}
+
+// Reset clears faults, queued statuses and the call counter. Rates are kept.
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = Faults{}
+	s.statuses = nil
+	s.calls = 0
+}
+
+// Calls returns the number of provider requests served (control calls excluded).
+func (s *Server) Calls() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls
+}
+
+// plan captures the decision for a single request, taken under the lock.
+type plan struct {
+	delay      time.Duration
+	status     int
+	retryAfter int
+	malformed  bool
+	rate       string
+	hasRate    bool
+}
+
+func (s *Server) nextPlan(base, quote string) plan {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.calls++
+	p := plan{delay: time.Duration(s.faults.LatencyMs) * time.Millisecond}
+	if s.faults.SpikeEvery > 0 && s.calls%s.faults.SpikeEvery == 0 {
+		p.delay += time.Duration(s.faults.SpikeLatencyMs) * time.Millisecond
+	}
+
+	switch {
+	case len(s.statuses) > 0:
+		p.status = s.statuses[0]
+		s.statuses = s.statuses[1:]
+	case s.faults.RateLimited:
+		p.status = http.StatusTooManyRequests
+		p.retryAfter = s.faults.RetryAfterSec
+	case s.faults.ErrorRate > 0 && rand.Float64() < s.faults.ErrorRate: //nolint:gosec // test-only randomness
+		p.status = http.StatusInternalServerError
+	case s.faults.MalformedBody:
+		p.status = http.StatusOK
+		p.malformed = true
+	default:
+		p.status = http.StatusOK
+	}
+
+	p.rate, p.hasRate = s.rates[base+quote]
+	return p
+}
+
+func (s *Server) handleRate(w http.ResponseWriter, r *http.Request) {
+	base, quote := s.requestedPair(r)
+	p := s.nextPlan(base, quote)
+
+	if p.delay > 0 {
+		select {
+		case <-time.After(p.delay):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	if p.status == http.StatusTooManyRequests && p.retryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(p.retryAfter))
+	}
+	if p.status != http.StatusOK {
+		http.Error(w, fmt.Sprintf(`{"error":"injected status %d"}`, p.status), p.status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if p.malformed {
+		_, _ = w.Write([]byte(`{"rates": {`))
+		return
+	}
+
+	switch s.flavor {
+	case Frankfurter:
+		if !p.hasRate {
+			http.Error(w, `{"message":"not found"}`, http.StatusNotFound)
+			return
+		}
+		_, _ = fmt.Fprintf(w, `{"amount":1.0,"base":%q,"date":%q,"rates":{%q:%s}}`,
+			base, time.Now().UTC().Format("2006-01-02"), quote, p.rate)
+	case ExchangeRateHost:
+		if !p.hasRate {
+			_, _ = fmt.Fprintf(w, `{"success":false,"source":%q,"quotes":{}}`, base)
+			return
+		}
+		_, _ = fmt.Fprintf(w, `{"success":true,"source":%q,"quotes":{%q:%s}}`, base, base+quote, p.rate)
+	}
+}
+
+func (s *Server) requestedPair(r *http.Request) (base, quote string) {
+	q := r.URL.Query()
+	switch s.flavor {
+	case Frankfurter:
+		return strings.ToUpper(q.Get("base")), strings.ToUpper(q.Get("symbols"))
+	default:
+		return strings.ToUpper(q.Get("source")), strings.ToUpper(q.Get("currencies"))
+	}
+}
+
+func (s *Server) handleControlFaults(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	var f Faults
+	if err := json.NewDecoder(r.Body).Decode(&f); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.SetFaults(f)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleControlSequence(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	var body struct {
+		Statuses []int `json:"statuses"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.FailSequence(body.Statuses...)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleControlReset(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	s.Reset()
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -3,9 +3,17 @@ package testkit
 import (
 	"context"
 	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"net/url"
+	"os"
+	"time"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	_ "github.com/jackc/pgx/v5/stdlib" // pgx driver registration
 	"github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/modules/postgres"
@@ -35,15 +43,23 @@ func StartPostgres(ctx context.Context, cfg *Config) (*PostgresModule, error) {
 		return &PostgresModule{dsn: cfg.PGDSN}, nil
 	}
 
-	ctr, err := postgres.Run(ctx,
-		cfg.PGImage,
-		postgres.WithDatabase(randomDBName()),
+	dbName := randomDBName()
+	opts := []testcontainers.ContainerCustomizer{
 		postgres.WithUsername("testuser"),
 		postgres.WithPassword("testpass"),
 		testcontainers.WithWaitStrategyAndDeadline(cfg.StartupTimeout,
 			wait.ForLog("database system is ready to accept connections").WithOccurrence(2),
 		),
-	)
+	}
+	if cfg.ReuseContainers {
+		// A reused container keeps its databases, so the name must be stable. It only
+		// hosts the per-package databases created by UsePackageDatabase.
+		dbName = reusablePostgresDB
+		opts = append(opts, testcontainers.WithReuseByName(reusablePostgresName))
+	}
+	opts = append(opts, postgres.WithDatabase(dbName))
+
+	ctr, err := postgres.Run(ctx, cfg.PGImage, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("start postgres container: %w", err)
 	}
@@ -60,7 +76,86 @@ func StartPostgres(ctx context.Context, cfg *Config) (*PostgresModule, error) {
 	}, nil
 }
 
-// randomDBName generates a random database name like "test_a1b2c3d4".
+// reusablePostgresName is the container name shared by all packages when reuse is enabled.
+const reusablePostgresName = "quotesvc-testkit-postgres"
+
+// reusablePostgresDB is the database the reused container is created with.
+const reusablePostgresDB = "quotesvc_test"
+
+// duplicateDatabase is the SQLSTATE of CREATE DATABASE for an existing database.
+const duplicateDatabase = "42P04"
+
+// UsePackageDatabase switches the module to a database of its own for the test
+// package in the working directory, creating it on first use. Packages run in
+// parallel against a reused container, so they must not share a database.
+func (p *PostgresModule) UsePackageDatabase(ctx context.Context) error {
+	name, err := packageDBName()
+	if err != nil {
+		return err
+	}
+	u, err := url.Parse(p.dsn)
+	if err != nil {
+		return fmt.Errorf("parse postgres DSN: %w", err)
+	}
+
+	db, err := sql.Open("pgx", p.dsn)
+	if err != nil {
+		return fmt.Errorf("open postgres: %w", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	_, err = db.ExecContext(ctx, "CREATE DATABASE "+pgx.Identifier{name}.Sanitize())
+	var pgErr *pgconn.PgError
+	if err != nil && (!errors.As(err, &pgErr) || pgErr.Code != duplicateDatabase) {
+		return fmt.Errorf("create database %s: %w", name, err)
+	}
+
+	u.Path = "/" + name
+	p.dsn = u.String()
+	return nil
+}
+
+// packageDBName derives a stable database name from the working directory, which
+// go test sets to the directory of the package under test.
+func packageDBName() (string, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("package database name: %w", err)
+	}
+	sum := sha256.Sum256([]byte(wd))
+	return reusablePostgresDB + "_" + hex.EncodeToString(sum[:6]), nil
+}
+
+// WaitPostgresReady pings Postgres through a real driver connection until it
+// answers or the timeout elapses. The container log-based wait strategy can
+// report readiness slightly before the server accepts TCP connections.
+func WaitPostgresReady(ctx context.Context, dsn string, timeout time.Duration) error {
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		return fmt.Errorf("open postgres: %w", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	return pollReady(ctx, timeout, func(ctx context.Context) error {
+		return db.PingContext(ctx)
+	})
+}
+
+// ResetSchema drops and recreates the public schema so migrations start from scratch.
+func ResetSchema(ctx context.Context, dsn string) error {
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		return fmt.Errorf("open postgres: %w", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	if _, err := db.ExecContext(ctx, "DROP SCHEMA IF EXISTS public CASCADE; CREATE SCHEMA public"); err != nil {
+		return fmt.Errorf("reset public schema: %w", err)
+	}
+	return nil
+}
+
+// randomDBName generates a random database or schema name like "test_a1b2c3d4".
 func randomDBName() string {
 	b := make([]byte, 4)
 	if _, err := rand.Read(b); err != nil {
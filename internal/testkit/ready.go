@@ -0,0 +1,36 @@
+package testkit
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const readyPollInterval = 250 * time.Millisecond
+
+// pollReady calls ping until it succeeds, the timeout elapses, or ctx is canceled.
+func pollReady(ctx context.Context, timeout time.Duration, ping func(context.Context) error) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var lastErr error
+	for {
+		pingCtx, pingCancel := context.WithTimeout(ctx, 2*time.Second)
+		lastErr = ping(pingCtx)
+		pingCancel()
+		if lastErr == nil {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("not ready after %v: %w", timeout, lastErr)
+		case <-time.After(readyPollInterval):
+		}
+	}
+}
+
+// logTiming prints how long a setup step took, so slow package startups can be attributed.
+func logTiming(step string, start time.Time) {
+	fmt.Printf("testkit: %s took %v\n", step, time.Since(start).Round(time.Millisecond))
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 	"fmt"
 	"net/url"
+	"time"
 
+	"github.com/redis/go-redis/v9"
 	"github.com/testcontainers/testcontainers-go"
 	tcredis "github.com/testcontainers/testcontainers-go/modules/redis"
 )
@@ -33,7 +35,12 @@ func StartRedis(ctx context.Context, cfg *Config) (*RedisModule, error) {
 		return &RedisModule{addr: cfg.RedisAddr}, nil
 	}
 
-	ctr, err := tcredis.Run(ctx, cfg.RedisImage)
+	var opts []testcontainers.ContainerCustomizer
+	if cfg.ReuseContainers {
+		opts = append(opts, testcontainers.WithReuseByName(reusableRedisName))
+	}
+
+	ctr, err := tcredis.Run(ctx, cfg.RedisImage, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("start redis container: %w", err)
 	}
@@ -57,6 +64,19 @@ func StartRedis(ctx context.Context, cfg *Config) (*RedisModule, error) {
 	}, nil
 }
 
+// reusableRedisName is the container name shared by all packages when reuse is enabled.
+const reusableRedisName = "quotesvc-testkit-redis"
+
+// WaitRedisReady pings Redis until it answers or the timeout elapses.
+func WaitRedisReady(ctx context.Context, addr string, timeout time.Duration) error {
+	rdb := redis.NewClient(&redis.Options{Addr: addr})
+	defer func() { _ = rdb.Close() }()
+
+	return pollReady(ctx, timeout, func(ctx context.Context) error {
+		return rdb.Ping(ctx).Err()
+	})
+}
+
 // extractAddr parses a redis:// URL and returns host:port.
 func extractAddr(connStr string) (string, error) {
 	u, err := url.Parse(connStr)
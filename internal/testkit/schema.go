@@ -0,0 +1,102 @@
+package testkit
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"quoteservice/internal/repository"
+)
+
+// MigrateAndConnect opens the database at dsn, verifies connectivity and applies
+// all embedded migrations. The caller owns the returned *sql.DB.
+func MigrateAndConnect(ctx context.Context, dsn string) (*sql.DB, error) {
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("open postgres: %w", err)
+	}
+	if err := db.PingContext(ctx); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("ping postgres: %w", err)
+	}
+	if _, err := repository.RunMigrations(db, zap.NewNop().Sugar(), repository.MigrationOptions{}); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// TableExists reports whether a table exists in the public schema.
+func TableExists(ctx context.Context, db *sql.DB, table string) (bool, error) {
+	return exists(ctx, db, `SELECT EXISTS(
+		SELECT 1 FROM information_schema.tables
+		WHERE table_schema = 'public' AND table_name = $1)`, table)
+}
+
+// ColumnType returns the Postgres type of table.column as reported by format_type
+// (e.g. "numeric(18,6)", "quotes_status"), or "" if the column does not exist.
+func ColumnType(ctx context.Context, db *sql.DB, table, column string) (string, error) {
+	const query = `SELECT format_type(a.atttypid, a.atttypmod)
+		FROM pg_attribute a
+		JOIN pg_class c ON c.oid = a.attrelid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = 'public' AND c.relname = $1 AND a.attname = $2
+		  AND a.attnum > 0 AND NOT a.attisdropped`
+
+	var typ string
+	err := db.QueryRowContext(ctx, query, table, column).Scan(&typ)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("column type %s.%s: %w", table, column, err)
+	}
+	return typ, nil
+}
+
+// ColumnDefault returns whether table.column is NOT NULL and its default expression as
+// reported by pg_get_expr (e.g. "'api'::quotes_origin"), "" if it has none.
+func ColumnDefault(ctx context.Context, db *sql.DB, table, column string) (notNull bool, def string, err error) {
+	const query = `SELECT a.attnotnull, COALESCE(pg_get_expr(d.adbin, d.adrelid), '')
+		FROM pg_attribute a
+		JOIN pg_class c ON c.oid = a.attrelid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		LEFT JOIN pg_attrdef d ON d.adrelid = a.attrelid AND d.adnum = a.attnum
+		WHERE n.nspname = 'public' AND c.relname = $1 AND a.attname = $2
+		  AND a.attnum > 0 AND NOT a.attisdropped`
+
+	err = db.QueryRowContext(ctx, query, table, column).Scan(&notNull, &def)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, "", nil
+	}
+	if err != nil {
+		return false, "", fmt.Errorf("column default %s.%s: %w", table, column, err)
+	}
+	return notNull, def, nil
+}
+
+// IndexExists reports whether an index with the given name exists in the public schema.
+func IndexExists(ctx context.Context, db *sql.DB, index string) (bool, error) {
+	return exists(ctx, db, `SELECT EXISTS(
+		SELECT 1 FROM pg_indexes
+		WHERE schemaname = 'public' AND indexname = $1)`, index)
+}
+
+// TriggerExists reports whether a trigger with the given name exists on table.
+func TriggerExists(ctx context.Context, db *sql.DB, table, trigger string) (bool, error) {
+	return exists(ctx, db, `SELECT EXISTS(
+		SELECT 1 FROM pg_trigger t
+		JOIN pg_class c ON c.oid = t.tgrelid
+		WHERE c.relname = $1 AND t.tgname = $2 AND NOT t.tgisinternal)`, table, trigger)
+}
+
+func exists(ctx context.Context, db *sql.DB, query string, args ...any) (bool, error) {
+	var ok bool
+	if err := db.QueryRowContext(ctx, query, args...).Scan(&ok); err != nil {
+		return false, fmt.Errorf("schema lookup: %w", err)
+	}
+	return ok, nil
+}
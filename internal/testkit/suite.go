@@ -6,6 +6,7 @@ import (
 	"os"
 	"sync"
 	"testing"
+	"time"
 )
 
 // Suite manages the lifecycle of test infrastructure (Postgres and Redis containers).
@@ -40,26 +41,91 @@ func (s *Suite) Setup(ctx context.Context) error {
 		return fmt.Errorf("suite already set up; call Shutdown first")
 	}
 
+	start := time.Now()
 	pg, err := StartPostgres(ctx, &s.cfg)
 	if err != nil {
 		return fmt.Errorf("setup postgres: %w", err)
 	}
 	s.pg = pg
+	logTiming("postgres start", start)
 
+	start = time.Now()
 	rdb, err := StartRedis(ctx, &s.cfg)
 	if err != nil {
 		// Clean up Postgres if Redis fails.
-		if !s.cfg.KeepContainers {
-			_ = pg.Terminate(ctx)
-		}
+		s.terminate(ctx, pg)
 		return fmt.Errorf("setup redis: %w", err)
 	}
 	s.redis = rdb
+	logTiming("redis start", start)
+
+	if err := s.waitReady(ctx); err != nil {
+		s.terminate(ctx, rdb)
+		s.terminate(ctx, pg)
+		return err
+	}
+
+	if s.cfg.ReuseContainers && s.cfg.PGDSN == "" {
+		if err := pg.UsePackageDatabase(ctx); err != nil {
+			s.terminate(ctx, rdb)
+			s.terminate(ctx, pg)
+			return fmt.Errorf("setup postgres: %w", err)
+		}
+	}
+
+	if s.cfg.needsSchemaReset() {
+		start = time.Now()
+		if err := ResetSchema(ctx, pg.DSN()); err != nil {
+			s.terminate(ctx, rdb)
+			s.terminate(ctx, pg)
+			return fmt.Errorf("setup postgres: %w", err)
+		}
+		logTiming("postgres schema reset", start)
+	}
+
 	s.ready = true
+	return nil
+}
 
+// WaitReady pings Postgres and Redis with real client connections until both
+// answer or the startup timeout elapses.
+func (s *Suite) WaitReady(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.waitReady(ctx)
+}
+
+func (s *Suite) waitReady(ctx context.Context) error {
+	start := time.Now()
+	if s.pg != nil {
+		if err := WaitPostgresReady(ctx, s.pg.DSN(), s.cfg.StartupTimeout); err != nil {
+			return fmt.Errorf("wait for postgres: %w", err)
+		}
+	}
+	if s.redis != nil {
+		if err := WaitRedisReady(ctx, s.redis.Addr(), s.cfg.StartupTimeout); err != nil {
+			return fmt.Errorf("wait for redis: %w", err)
+		}
+	}
+	logTiming("readiness check", start)
 	return nil
 }
 
+// terminator is implemented by the container modules.
+type terminator interface {
+	Terminate(ctx context.Context) error
+}
+
+// terminate stops a module's container unless containers are kept or reused.
+func (s *Suite) terminate(ctx context.Context, m terminator) {
+	if s.cfg.KeepContainers || s.cfg.ReuseContainers {
+		return
+	}
+	if err := m.Terminate(ctx); err != nil {
+		fmt.Println("warning: failed to terminate container:", err)
+	}
+}
+
 // Shutdown terminates all containers unless KEEP_CONTAINERS is set.
 func (s *Suite) Shutdown(ctx context.Context) {
 	s.mu.Lock()
@@ -69,8 +135,8 @@ func (s *Suite) Shutdown(ctx context.Context) {
 		return
 	}
 
-	if s.cfg.KeepContainers {
-		fmt.Println("KEEP_CONTAINERS=true — skipping container cleanup")
+	if s.cfg.KeepContainers || s.cfg.ReuseContainers {
+		fmt.Println("KEEP_CONTAINERS/TEST_REUSE_CONTAINERS set — skipping container cleanup")
 		if s.pg != nil {
 			fmt.Println("  Postgres DSN:", s.pg.DSN())
 		}
@@ -0,0 +1,75 @@
+package testkit
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+	"go.uber.org/zap"
+
+	"quoteservice/internal/repository"
+)
+
+// schemaDropTimeout bounds the cleanup that drops a test schema; the test's own
+// context is usually cancelled by then.
+const schemaDropTimeout = 10 * time.Second
+
+// NewTestSchema creates a Postgres schema named test_<random hex>, applies all
+// migrations inside it and returns a handle whose connections resolve unqualified
+// names to that schema only. The handle is closed and the schema dropped when t
+// finishes, so tests that use it instead of shared tables can call t.Parallel.
+//
+// Postgres NOTIFY channels are database-wide, so LISTEN-based tests still see
+// notifications from other schemas.
+func (s *Suite) NewTestSchema(ctx context.Context, t *testing.T) *sql.DB {
+	t.Helper()
+	db := s.NewEmptySchema(ctx, t)
+	if _, err := repository.RunMigrations(db, zap.NewNop().Sugar(), repository.MigrationOptions{}); err != nil {
+		t.Fatalf("testkit: migrate test schema: %v", err)
+	}
+	return db
+}
+
+// NewEmptySchema is NewTestSchema without the migrations, for tests of a database
+// whose schema is not set up yet.
+func (s *Suite) NewEmptySchema(ctx context.Context, t *testing.T) *sql.DB {
+	t.Helper()
+
+	dsn := s.PostgresDSN()
+	if dsn == "" {
+		t.Fatal("testkit: postgres is not set up")
+	}
+	schema := randomDBName()
+	ident := pgx.Identifier{schema}.Sanitize()
+
+	admin, err := sql.Open("pgx", dsn)
+	if err != nil {
+		t.Fatalf("testkit: open postgres: %v", err)
+	}
+	if _, err := admin.ExecContext(ctx, "CREATE SCHEMA "+ident); err != nil {
+		_ = admin.Close()
+		t.Fatalf("testkit: create schema %s: %v", schema, err)
+	}
+	t.Cleanup(func() {
+		defer func() { _ = admin.Close() }()
+		ctx, cancel := context.WithTimeout(context.Background(), schemaDropTimeout)
+		defer cancel()
+		if _, err := admin.ExecContext(ctx, "DROP SCHEMA IF EXISTS "+ident+" CASCADE"); err != nil {
+			t.Errorf("testkit: drop schema %s: %v", schema, err)
+		}
+	})
+
+	cfg, err := pgx.ParseConfig(dsn)
+	if err != nil {
+		t.Fatalf("testkit: parse postgres DSN: %v", err)
+	}
+	cfg.RuntimeParams["search_path"] = schema
+	db := stdlib.OpenDB(*cfg)
+	// Registered after the drop, so it runs first: the schema cannot be dropped
+	// while pooled connections still use it.
+	t.Cleanup(func() { _ = db.Close() })
+	return db
+}
@@ -0,0 +1,238 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"go.uber.org/zap"
+
+	"quoteservice/internal/logging/fields"
+	"quoteservice/internal/metrics"
+	"quoteservice/internal/rediskey"
+	"quoteservice/internal/service"
+)
+
+// Errors of ArchivedTasks.RetryArchived.
+var (
+	ErrTaskNotFound    = errors.New("task not found")
+	ErrTaskNotArchived = errors.New("task is not archived")
+)
+
+// QueueInfoReader is the part of asynq.Inspector ArchivedTaskCollector uses.
+type QueueInfoReader interface {
+	GetQueueInfo(queue string) (*asynq.QueueInfo, error)
+}
+
+// ArchivedTaskCollector periodically exports the number of archived tasks in each
+// update queue as metrics.WorkerArchivedTasks and logs a warning when it grows. Tasks
+// are archived once they exhaust their retries, which is otherwise silent.
+type ArchivedTaskCollector struct {
+	insp     QueueInfoReader
+	queues   []string
+	interval time.Duration
+	logger   *zap.SugaredLogger
+	counts   map[string]int // Last count per queue, to tell when it grows.
+}
+
+// NewArchivedTaskCollector creates an ArchivedTaskCollector for the update queues of
+// keys' namespace that refreshes the gauge every interval.
+func NewArchivedTaskCollector(insp QueueInfoReader, keys rediskey.Namespace, interval time.Duration,
+	logger *zap.SugaredLogger) *ArchivedTaskCollector {
+	queues := make([]string, 0, len(PriorityQueues))
+	for queue := range Queues(keys) {
+		queues = append(queues, queue)
+	}
+	sort.Strings(queues)
+	return &ArchivedTaskCollector{
+		insp:     insp,
+		queues:   queues,
+		interval: interval,
+		logger:   logger,
+	}
+}
+
+// Run collects immediately and then every interval until ctx is cancelled. Failures are
+// logged and the gauge keeps its previous values; Run always returns nil.
+func (c *ArchivedTaskCollector) Run(ctx context.Context) error {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+	for {
+		if err := c.Collect(); err != nil && ctx.Err() == nil {
+			c.logger.Warnw("Archived task collection failed", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Collect refreshes the gauge with one Inspector call per queue. The first collection
+// only records the counts; later ones warn about every queue whose count grew.
+func (c *ArchivedTaskCollector) Collect() error {
+	counts := make(map[string]int, len(c.queues))
+	for _, queue := range c.queues {
+		info, err := c.insp.GetQueueInfo(queue)
+		switch {
+		case errors.Is(err, asynq.ErrQueueNotFound):
+			// No task was ever enqueued to the queue.
+		case err != nil:
+			return fmt.Errorf("get %s queue info: %w", queue, err)
+		default:
+			counts[queue] = info.Archived
+		}
+	}
+
+	for _, queue := range c.queues {
+		n := counts[queue]
+		metrics.WorkerArchivedTasks.WithLabelValues(queue).Set(float64(n))
+		if prev, ok := c.counts[queue]; ok && n > prev {
+			c.logger.Warnw("Tasks were archived after exhausting their retries",
+				"queue", queue, "archived", n, "new", n-prev)
+		}
+	}
+	c.counts = counts
+	return nil
+}
+
+// ArchivedTaskInspector is the part of asynq.Inspector ArchivedTasks uses.
+type ArchivedTaskInspector interface {
+	Queues() ([]string, error)
+	ListArchivedTasks(queue string, opts ...asynq.ListOption) ([]*asynq.TaskInfo, error)
+	GetTaskInfo(queue, id string) (*asynq.TaskInfo, error)
+	RunTask(queue, id string) error
+}
+
+// UpdateResetter returns an update to PENDING before its task runs again; implemented
+// by *service.QuoteService.
+type UpdateResetter interface {
+	ResetUpdate(ctx context.Context, updateID string) error
+}
+
+// ArchivedTasks lists and retries the archived update tasks of a namespace.
+type ArchivedTasks struct {
+	insp     ArchivedTaskInspector
+	resetter UpdateResetter
+	queues   map[string]int
+	logger   *zap.SugaredLogger
+}
+
+// NewArchivedTasks creates an ArchivedTasks for the update queues of keys' namespace.
+func NewArchivedTasks(insp ArchivedTaskInspector, resetter UpdateResetter, keys rediskey.Namespace,
+	logger *zap.SugaredLogger) *ArchivedTasks {
+	return &ArchivedTasks{insp: insp, resetter: resetter, queues: Queues(keys), logger: logger}
+}
+
+// ListArchived returns up to limit archived update tasks after skipping the first
+// offset, queue by queue from the highest priority, and whether more follow. Tasks of
+// other types and payloads that cannot be decoded are skipped and not counted. Tasks
+// archived or retried between two calls shift the offsets, so paging through a queue
+// that changes meanwhile can skip or repeat a task.
+func (a *ArchivedTasks) ListArchived(ctx context.Context, offset, limit int) ([]QueuedTask, bool, error) {
+	existing, err := a.insp.Queues()
+	if err != nil {
+		return nil, false, fmt.Errorf("list queues: %w", err)
+	}
+	exists := make(map[string]bool, len(existing))
+	for _, queue := range existing {
+		exists[queue] = true
+	}
+
+	var tasks []QueuedTask
+	for _, queue := range a.orderedQueues() {
+		if !exists[queue] {
+			continue
+		}
+		for page := 1; (page-1)*inspectPageSize < maxInspectedPerState; page++ {
+			if err := ctx.Err(); err != nil {
+				return nil, false, err
+			}
+			infos, err := a.insp.ListArchivedTasks(queue, asynq.Page(page), asynq.PageSize(inspectPageSize))
+			if err != nil {
+				return nil, false, fmt.Errorf("list archived tasks in %s: %w", queue, err)
+			}
+			for _, info := range infos {
+				t, ok := updateTask(info, "archived")
+				switch {
+				case !ok:
+				case offset > 0:
+					offset--
+				case len(tasks) == limit:
+					return tasks, true, nil
+				default:
+					tasks = append(tasks, t)
+				}
+			}
+			if len(infos) < inspectPageSize {
+				break
+			}
+		}
+	}
+	return tasks, false, nil
+}
+
+// orderedQueues returns the update queues by descending priority, so every
+// ListArchived call walks them in the same order.
+func (a *ArchivedTasks) orderedQueues() []string {
+	queues := make([]string, 0, len(a.queues))
+	for queue := range a.queues {
+		queues = append(queues, queue)
+	}
+	sort.Slice(queues, func(i, j int) bool {
+		if pi, pj := a.queues[queues[i]], a.queues[queues[j]]; pi != pj {
+			return pi > pj
+		}
+		return queues[i] < queues[j]
+	})
+	return queues
+}
+
+// RetryArchived resets the update of the archived update task taskID to PENDING and
+// moves the task back to its queue. It returns ErrTaskNotFound if no update queue has
+// an update task with that ID, ErrTaskNotArchived if the task is not archived, and the
+// error of the reset, e.g. service.ErrAlreadyCompleted, without touching the task. An
+// update that no longer exists is not an error: the task then finds nothing to do.
+func (a *ArchivedTasks) RetryArchived(ctx context.Context, taskID string) (QueuedTask, error) {
+	info, err := a.findTask(taskID)
+	if err != nil {
+		return QueuedTask{}, err
+	}
+	task, ok := updateTask(info, "archived")
+	if !ok {
+		return QueuedTask{}, fmt.Errorf("%w: %s is not an update task", ErrTaskNotFound, taskID)
+	}
+	if info.State != asynq.TaskStateArchived {
+		return QueuedTask{}, fmt.Errorf("%w: %s is %s", ErrTaskNotArchived, taskID, info.State)
+	}
+
+	if err := a.resetter.ResetUpdate(ctx, task.Payload.UpdateID); err != nil && !errors.Is(err, service.ErrNotFound) {
+		return QueuedTask{}, err
+	}
+	if err := a.insp.RunTask(info.Queue, info.ID); err != nil {
+		return QueuedTask{}, fmt.Errorf("run task %s: %w", taskID, err)
+	}
+	a.logger.Infow("Retrying archived task", "task_id", taskID, "queue", info.Queue, fields.UpdateID(task.Payload.UpdateID))
+	task.State = "pending"
+	return task, nil
+}
+
+// findTask looks taskID up in every update queue.
+func (a *ArchivedTasks) findTask(taskID string) (*asynq.TaskInfo, error) {
+	for queue := range a.queues {
+		info, err := a.insp.GetTaskInfo(queue, taskID)
+		switch {
+		case err == nil:
+			return info, nil
+		case errors.Is(err, asynq.ErrTaskNotFound), errors.Is(err, asynq.ErrQueueNotFound):
+		default:
+			return nil, fmt.Errorf("get task %s: %w", taskID, err)
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrTaskNotFound, taskID)
+}
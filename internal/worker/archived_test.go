@@ -0,0 +1,195 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+
+	"quoteservice/internal/metrics"
+	"quoteservice/internal/service"
+)
+
+// archivedInspector is an ArchivedTaskInspector and QueueInfoReader over in-memory
+// archived tasks.
+type archivedInspector struct {
+	archived map[string][]*asynq.TaskInfo // By queue.
+	run      []string                     // Task IDs passed to RunTask.
+}
+
+// Queues lists the queues in no particular order, like Redis does.
+func (a *archivedInspector) Queues() ([]string, error) {
+	return []string{"low", "default", "high"}, nil
+}
+
+func (a *archivedInspector) GetQueueInfo(queue string) (*asynq.QueueInfo, error) {
+	tasks, ok := a.archived[queue]
+	if !ok {
+		return nil, asynq.ErrQueueNotFound
+	}
+	return &asynq.QueueInfo{Queue: queue, Archived: len(tasks)}, nil
+}
+
+func (a *archivedInspector) ListArchivedTasks(queue string, opts ...asynq.ListOption) ([]*asynq.TaskInfo, error) {
+	return a.archived[queue], nil
+}
+
+func (a *archivedInspector) GetTaskInfo(queue, id string) (*asynq.TaskInfo, error) {
+	for _, info := range a.archived[queue] {
+		if info.ID == id {
+			return info, nil
+		}
+	}
+	return nil, fmt.Errorf("asynq: %w", asynq.ErrTaskNotFound)
+}
+
+func (a *archivedInspector) RunTask(queue, id string) error {
+	a.run = append(a.run, id)
+	return nil
+}
+
+func archivedUpdate(id, queue string) *asynq.TaskInfo {
+	return &asynq.TaskInfo{
+		ID: id, Queue: queue, Type: service.TaskTypeUpdateQuote, State: asynq.TaskStateArchived,
+		Payload: []byte(`{"update_id":"` + id + `","base":"EUR","quote":"MXN"}`), LastErr: "provider timeout",
+	}
+}
+
+type fakeResetter struct {
+	err   error
+	reset []string
+}
+
+func (f *fakeResetter) ResetUpdate(_ context.Context, updateID string) error {
+	f.reset = append(f.reset, updateID)
+	return f.err
+}
+
+func TestArchivedTaskCollector_Collect(t *testing.T) {
+	t.Cleanup(metrics.WorkerArchivedTasks.Reset)
+	insp := &archivedInspector{archived: map[string][]*asynq.TaskInfo{
+		"default": {archivedUpdate("u1", "default")},
+		"high":    {},
+	}}
+	core, logs := observer.New(zap.WarnLevel)
+	c := NewArchivedTaskCollector(insp, "", time.Minute, zap.New(core).Sugar())
+
+	if err := c.Collect(); err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	if got := testutil.ToFloat64(metrics.WorkerArchivedTasks.WithLabelValues("default")); got != 1 {
+		t.Errorf("Expected 1 archived task in default, got %v", got)
+	}
+	if got := testutil.ToFloat64(metrics.WorkerArchivedTasks.WithLabelValues("low")); got != 0 {
+		t.Errorf("Expected 0 archived tasks in a queue that does not exist, got %v", got)
+	}
+	if logs.Len() != 0 {
+		t.Errorf("Expected the first collection not to warn, got %v", logs.All())
+	}
+
+	insp.archived["default"] = append(insp.archived["default"], archivedUpdate("u2", "default"), archivedUpdate("u3", "default"))
+	if err := c.Collect(); err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	if got := testutil.ToFloat64(metrics.WorkerArchivedTasks.WithLabelValues("default")); got != 3 {
+		t.Errorf("Expected 3 archived tasks in default, got %v", got)
+	}
+	warnings := logs.TakeAll()
+	if len(warnings) != 1 || warnings[0].ContextMap()["queue"] != "default" || warnings[0].ContextMap()["new"] != int64(2) {
+		t.Errorf("Expected one warning about 2 new tasks in default, got %v", warnings)
+	}
+
+	insp.archived["default"] = nil
+	if err := c.Collect(); err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	if logs.Len() != 0 {
+		t.Errorf("Expected no warning when the count drops, got %v", logs.All())
+	}
+}
+
+func TestArchivedTasks_ListArchived(t *testing.T) {
+	insp := &archivedInspector{archived: map[string][]*asynq.TaskInfo{
+		"high":    {archivedUpdate("u1", "high"), {ID: "other", Queue: "high", Type: "other:task"}},
+		"default": {archivedUpdate("u2", "default"), archivedUpdate("u3", "default")},
+	}}
+	a := NewArchivedTasks(insp, &fakeResetter{}, "", zap.NewNop().Sugar())
+
+	tasks, more, err := a.ListArchived(context.Background(), 0, 10)
+	if err != nil {
+		t.Fatalf("ListArchived: %v", err)
+	}
+	if more || len(tasks) != 3 || tasks[0].ID != "u1" || tasks[0].State != "archived" || tasks[0].LastErr != "provider timeout" ||
+		tasks[0].Payload.UpdateID != "u1" || tasks[2].Queue != "default" {
+		t.Errorf("Expected the 3 archived update tasks, got %+v", tasks)
+	}
+
+	// Pages walk the queues from the highest priority and skip non-update tasks.
+	var ids []string
+	for offset, pages := 0, 0; ; pages++ {
+		page, more, err := a.ListArchived(context.Background(), offset, 2)
+		if err != nil {
+			t.Fatalf("ListArchived at %d: %v", offset, err)
+		}
+		for _, task := range page {
+			ids = append(ids, task.ID)
+		}
+		if !more {
+			if pages != 1 {
+				t.Errorf("Expected 2 pages of at most 2 tasks, got %d", pages+1)
+			}
+			break
+		}
+		offset += len(page)
+	}
+	if want := []string{"u1", "u2", "u3"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("Expected tasks %v across the pages, got %v", want, ids)
+	}
+}
+
+func TestArchivedTasks_RetryArchived(t *testing.T) {
+	pending := archivedUpdate("u2", "default")
+	pending.State = asynq.TaskStatePending
+	insp := &archivedInspector{archived: map[string][]*asynq.TaskInfo{
+		"default": {archivedUpdate("u1", "default"), pending},
+	}}
+	resetter := &fakeResetter{}
+	a := NewArchivedTasks(insp, resetter, "", zap.NewNop().Sugar())
+
+	task, err := a.RetryArchived(context.Background(), "u1")
+	if err != nil {
+		t.Fatalf("RetryArchived: %v", err)
+	}
+	if task.State != "pending" || task.Queue != "default" || task.Payload.UpdateID != "u1" {
+		t.Errorf("Unexpected task %+v", task)
+	}
+	if len(resetter.reset) != 1 || resetter.reset[0] != "u1" || len(insp.run) != 1 || insp.run[0] != "u1" {
+		t.Errorf("Expected u1 to be reset and run, got reset %v run %v", resetter.reset, insp.run)
+	}
+
+	if _, err := a.RetryArchived(context.Background(), "missing"); !errors.Is(err, ErrTaskNotFound) {
+		t.Errorf("Expected ErrTaskNotFound, got %v", err)
+	}
+	if _, err := a.RetryArchived(context.Background(), "u2"); !errors.Is(err, ErrTaskNotArchived) {
+		t.Errorf("Expected ErrTaskNotArchived, got %v", err)
+	}
+
+	resetter.err = service.ErrAlreadyCompleted
+	if _, err := a.RetryArchived(context.Background(), "u1"); !errors.Is(err, service.ErrAlreadyCompleted) {
+		t.Errorf("Expected ErrAlreadyCompleted, got %v", err)
+	}
+	resetter.err = service.ErrNotFound
+	if _, err := a.RetryArchived(context.Background(), "u1"); err != nil {
+		t.Errorf("Expected a missing update record not to stop the retry, got %v", err)
+	}
+	if len(insp.run) != 2 {
+		t.Errorf("Expected the task not to run when the reset fails, got runs %v", insp.run)
+	}
+}
@@ -0,0 +1,42 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+
+	"quoteservice/internal/config"
+	"quoteservice/internal/rediskey"
+)
+
+// TaskTypeDiagnostics is the no-op task ProbeQueue enqueues. The worker registers
+// HandleDiagnosticsTask for it, so a probe task that could not be deleted is dropped
+// instead of going to the unknown-task handler.
+const TaskTypeDiagnostics = "diagnostics:noop"
+
+// diagnosticsDelay keeps a probe task scheduled long enough to be deleted before any
+// worker could pick it up.
+const diagnosticsDelay = time.Hour
+
+// ProbeQueue checks that tasks can be enqueued and removed: it schedules a no-op task
+// in the low-priority queue of keys' namespace and deletes it again.
+func ProbeQueue(ctx context.Context, client *asynq.Client, inspector *asynq.Inspector, keys rediskey.Namespace) error {
+	queue := keys.Queue(config.PriorityLow)
+	info, err := client.EnqueueContext(ctx, asynq.NewTask(TaskTypeDiagnostics, nil),
+		asynq.Queue(queue), asynq.TaskID(uuid.New().String()), asynq.ProcessIn(diagnosticsDelay), asynq.MaxRetry(0))
+	if err != nil {
+		return fmt.Errorf("enqueue: %w", err)
+	}
+	if err := inspector.DeleteTask(queue, info.ID); err != nil {
+		return fmt.Errorf("delete task %s: %w", info.ID, err)
+	}
+	return nil
+}
+
+// HandleDiagnosticsTask processes a leftover probe task by doing nothing.
+func HandleDiagnosticsTask(context.Context, *asynq.Task) error {
+	return nil
+}
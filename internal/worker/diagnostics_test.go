@@ -0,0 +1,37 @@
+package worker
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/hibiken/asynq"
+
+	"quoteservice/internal/rediskey"
+)
+
+func TestProbeQueue(t *testing.T) {
+	mr := miniredis.RunT(t)
+	redisOpt := asynq.RedisClientOpt{Addr: mr.Addr()}
+	client := asynq.NewClient(redisOpt)
+	t.Cleanup(func() { _ = client.Close() })
+	inspector := asynq.NewInspector(redisOpt)
+	t.Cleanup(func() { _ = inspector.Close() })
+	keys := rediskey.Namespace("staging")
+
+	if err := ProbeQueue(context.Background(), client, inspector, keys); err != nil {
+		t.Fatalf("ProbeQueue: %v", err)
+	}
+	info, err := inspector.GetQueueInfo(keys.Queue("low"))
+	if err != nil {
+		t.Fatalf("GetQueueInfo: %v", err)
+	}
+	if info.Size != 0 {
+		t.Errorf("Expected the probe task deleted, queue holds %d tasks", info.Size)
+	}
+
+	mr.Close()
+	if err := ProbeQueue(context.Background(), client, inspector, keys); err == nil {
+		t.Error("Expected an error with Redis down")
+	}
+}
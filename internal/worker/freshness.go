@@ -0,0 +1,88 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"quoteservice/internal/metrics"
+	"quoteservice/internal/repository"
+)
+
+// LatestSuccessReader is the part of repository.QuoteRepository FreshnessCollector uses.
+type LatestSuccessReader interface {
+	LatestSuccessTimes(ctx context.Context, pairs []repository.Pair) ([]repository.PairFreshness, error)
+}
+
+// FreshnessCollector periodically exports how old each pair's latest successful quote
+// is as metrics.QuoteLatestAgeSeconds, and how many pairs have none as
+// metrics.QuotePairsWithoutSuccess. Only one collector should run per process.
+type FreshnessCollector struct {
+	repo     LatestSuccessReader
+	pairs    []repository.Pair
+	interval time.Duration
+	logger   *zap.SugaredLogger
+	now      func() time.Time
+	exported map[string]bool // Pairs with an age series, to drop those that lose it.
+}
+
+// NewFreshnessCollector creates a FreshnessCollector for pairs, or for every pair with
+// a live update if pairs is empty, that refreshes the gauges every interval.
+func NewFreshnessCollector(repo LatestSuccessReader, pairs []repository.Pair, interval time.Duration,
+	logger *zap.SugaredLogger) *FreshnessCollector {
+	return &FreshnessCollector{
+		repo:     repo,
+		pairs:    pairs,
+		interval: interval,
+		logger:   logger,
+		now:      time.Now,
+	}
+}
+
+// Run collects immediately and then every interval until ctx is cancelled. Failures are
+// logged and the gauges keep their previous values; Run always returns nil.
+func (c *FreshnessCollector) Run(ctx context.Context) error {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+	for {
+		if err := c.Collect(ctx); err != nil && ctx.Err() == nil {
+			c.logger.Warnw("Quote freshness collection failed", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Collect refreshes the gauges with one repository query.
+func (c *FreshnessCollector) Collect(ctx context.Context) error {
+	rows, err := c.repo.LatestSuccessTimes(ctx, c.pairs)
+	if err != nil {
+		return err
+	}
+
+	now := c.now()
+	seen := make(map[string]bool, len(rows))
+	without := 0
+	for _, f := range rows {
+		if f.LatestSuccess == nil {
+			without++
+			continue
+		}
+		pair := f.Base + "/" + f.Quote
+		seen[pair] = true
+		metrics.QuoteLatestAgeSeconds.WithLabelValues(pair).Set(now.Sub(*f.LatestSuccess).Seconds())
+	}
+	for pair := range c.exported {
+		if !seen[pair] {
+			metrics.QuoteLatestAgeSeconds.DeleteLabelValues(pair)
+		}
+	}
+	c.exported = seen
+	metrics.QuotePairsWithoutSuccess.Set(float64(without))
+	return nil
+}
@@ -0,0 +1,76 @@
+package worker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.uber.org/zap"
+
+	"quoteservice/internal/metrics"
+	"quoteservice/internal/repository"
+)
+
+type fakeLatestSuccess struct {
+	rows  []repository.PairFreshness
+	calls int
+	pairs []repository.Pair
+}
+
+func (f *fakeLatestSuccess) LatestSuccessTimes(_ context.Context, pairs []repository.Pair) ([]repository.PairFreshness, error) {
+	f.calls++
+	f.pairs = pairs
+	return f.rows, nil
+}
+
+func TestFreshnessCollector_Collect(t *testing.T) {
+	t.Cleanup(metrics.QuoteLatestAgeSeconds.Reset)
+	now := time.Date(2025, 12, 1, 12, 0, 0, 0, time.UTC)
+	eurMXN := now.Add(-90 * time.Second)
+	eurUSD := now.Add(-2 * time.Hour)
+
+	tracked := []repository.Pair{{Base: "EUR", Quote: "MXN"}, {Base: "EUR", Quote: "USD"}, {Base: "GBP", Quote: "JPY"}}
+	repo := &fakeLatestSuccess{rows: []repository.PairFreshness{
+		{Pair: tracked[0], LatestSuccess: &eurMXN},
+		{Pair: tracked[1], LatestSuccess: &eurUSD},
+		{Pair: tracked[2]},
+	}}
+	c := NewFreshnessCollector(repo, tracked, time.Minute, zap.NewNop().Sugar())
+	c.now = func() time.Time { return now }
+
+	if err := c.Collect(context.Background()); err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	if repo.calls != 1 || len(repo.pairs) != len(tracked) {
+		t.Fatalf("expected one batch query for %d pairs, got %d calls with %v", len(tracked), repo.calls, repo.pairs)
+	}
+	if got := testutil.ToFloat64(metrics.QuoteLatestAgeSeconds.WithLabelValues("EUR/MXN")); got != 90 {
+		t.Errorf("EUR/MXN age = %v, want 90", got)
+	}
+	if got := testutil.ToFloat64(metrics.QuoteLatestAgeSeconds.WithLabelValues("EUR/USD")); got != 7200 {
+		t.Errorf("EUR/USD age = %v, want 7200", got)
+	}
+	if got := testutil.ToFloat64(metrics.QuotePairsWithoutSuccess); got != 1 {
+		t.Errorf("pairs without success = %v, want 1", got)
+	}
+	if n := testutil.CollectAndCount(metrics.QuoteLatestAgeSeconds); n != 2 {
+		t.Errorf("expected 2 age series, got %d", n)
+	}
+
+	// EUR/USD drops out (e.g. archived): its series is removed, EUR/MXN ages.
+	now = now.Add(time.Minute)
+	repo.rows = repo.rows[:1]
+	if err := c.Collect(context.Background()); err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	if n := testutil.CollectAndCount(metrics.QuoteLatestAgeSeconds); n != 1 {
+		t.Errorf("expected 1 age series after EUR/USD dropped out, got %d", n)
+	}
+	if got := testutil.ToFloat64(metrics.QuoteLatestAgeSeconds.WithLabelValues("EUR/MXN")); got != 150 {
+		t.Errorf("EUR/MXN age = %v, want 150", got)
+	}
+	if got := testutil.ToFloat64(metrics.QuotePairsWithoutSuccess); got != 0 {
+		t.Errorf("pairs without success = %v, want 0", got)
+	}
+}
@@ -0,0 +1,152 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hibiken/asynq"
+
+	"quoteservice/internal/rediskey"
+	"quoteservice/internal/service"
+)
+
+// inspectPageSize is the number of tasks read from Redis per Inspector call.
+const inspectPageSize = 200
+
+// maxInspectedPerState bounds how many tasks of one state and queue are scanned, so a
+// huge backlog cannot turn an admin request into a full Redis scan.
+const maxInspectedPerState = 10000
+
+// TaskInspector is the part of asynq.Inspector PairTaskLister uses.
+type TaskInspector interface {
+	Queues() ([]string, error)
+	ListPendingTasks(queue string, opts ...asynq.ListOption) ([]*asynq.TaskInfo, error)
+	ListScheduledTasks(queue string, opts ...asynq.ListOption) ([]*asynq.TaskInfo, error)
+	ListRetryTasks(queue string, opts ...asynq.ListOption) ([]*asynq.TaskInfo, error)
+	ListArchivedTasks(queue string, opts ...asynq.ListOption) ([]*asynq.TaskInfo, error)
+}
+
+// QueuedTask is an update task waiting in, or archived by, an Asynq queue.
+type QueuedTask struct {
+	ID            string
+	Queue         string
+	State         string // pending, scheduled, retry or archived.
+	Retried       int
+	MaxRetry      int
+	NextProcessAt time.Time // Zero for archived tasks.
+	LastErr       string
+	LastFailedAt  time.Time // Zero if the task never failed.
+	Payload       service.UpdateQuotePayload
+}
+
+// PairTaskLister finds the queued update tasks of a pair by decoding task payloads.
+type PairTaskLister struct {
+	insp   TaskInspector
+	queues map[string]int
+}
+
+// NewPairTaskLister creates a PairTaskLister reading the update queues of keys'
+// namespace from insp.
+func NewPairTaskLister(insp TaskInspector, keys rediskey.Namespace) *PairTaskLister {
+	return &PairTaskLister{insp: insp, queues: Queues(keys)}
+}
+
+// ListPairTasks returns the pending, scheduled, retry and archived update tasks for
+// pair in every update queue of the namespace, queue by queue in that state
+// order. Queues of other namespaces are not read. Tasks of other types
+// and payloads that cannot be decoded are skipped. Active tasks are not listed.
+func (l *PairTaskLister) ListPairTasks(ctx context.Context, pair service.Pair) ([]QueuedTask, error) {
+	queues, err := l.insp.Queues()
+	if err != nil {
+		return nil, fmt.Errorf("list queues: %w", err)
+	}
+
+	states := []struct {
+		name string
+		list func(string, ...asynq.ListOption) ([]*asynq.TaskInfo, error)
+	}{
+		{"pending", l.insp.ListPendingTasks},
+		{"scheduled", l.insp.ListScheduledTasks},
+		{"retry", l.insp.ListRetryTasks},
+		{"archived", l.insp.ListArchivedTasks},
+	}
+
+	var tasks []QueuedTask
+	for _, queue := range queues {
+		if _, ok := l.queues[queue]; !ok {
+			continue
+		}
+		for _, state := range states {
+			for page := 1; (page-1)*inspectPageSize < maxInspectedPerState; page++ {
+				if err := ctx.Err(); err != nil {
+					return nil, err
+				}
+				infos, err := state.list(queue, asynq.Page(page), asynq.PageSize(inspectPageSize))
+				if err != nil {
+					return nil, fmt.Errorf("list %s tasks in %s: %w", state.name, queue, err)
+				}
+				for _, info := range infos {
+					if t, ok := pairTask(info, state.name, pair); ok {
+						tasks = append(tasks, t)
+					}
+				}
+				if len(infos) < inspectPageSize {
+					break
+				}
+			}
+		}
+	}
+	return tasks, nil
+}
+
+func pairTask(info *asynq.TaskInfo, state string, pair service.Pair) (QueuedTask, bool) {
+	t, ok := updateTask(info, state)
+	if !ok || t.Payload.Pair != pair {
+		return QueuedTask{}, false
+	}
+	return t, true
+}
+
+// updateTask converts info if it is an update task with a payload that decodes.
+func updateTask(info *asynq.TaskInfo, state string) (QueuedTask, bool) {
+	if info.Type != service.TaskTypeUpdateQuote {
+		return QueuedTask{}, false
+	}
+	payload, err := DecodeUpdatePayload(info.Payload)
+	if err != nil {
+		return QueuedTask{}, false
+	}
+	return QueuedTask{
+		ID:            info.ID,
+		Queue:         info.Queue,
+		State:         state,
+		Retried:       info.Retried,
+		MaxRetry:      info.MaxRetry,
+		NextProcessAt: info.NextProcessAt,
+		LastErr:       info.LastErr,
+		LastFailedAt:  info.LastFailedAt,
+		Payload:       payload,
+	}, true
+}
+
+var errIncompletePayload = errors.New("update payload lacks update_id, base or quote")
+
+// DecodeUpdatePayload decodes an update task payload written by any release: payloads
+// enqueued before forced providers existed have no provider field, and unknown fields
+// from newer releases are ignored. Currency codes are upper-cased.
+func DecodeUpdatePayload(data []byte) (service.UpdateQuotePayload, error) {
+	var payload service.UpdateQuotePayload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return payload, err
+	}
+	if payload.UpdateID == "" || payload.Base == "" || payload.Quote == "" {
+		return payload, errIncompletePayload
+	}
+	payload.Base = strings.ToUpper(payload.Base)
+	payload.Quote = strings.ToUpper(payload.Quote)
+	return payload, nil
+}
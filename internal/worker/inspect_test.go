@@ -0,0 +1,107 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/hibiken/asynq"
+
+	"quoteservice/internal/rediskey"
+	"quoteservice/internal/service"
+)
+
+func TestDecodeUpdatePayload(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    service.UpdateQuotePayload
+		wantErr bool
+	}{
+		{
+			name: "without provider",
+			data: `{"update_id":"u1","base":"EUR","quote":"MXN"}`,
+			want: service.UpdateQuotePayload{UpdateID: "u1", Pair: service.Pair{Base: "EUR", Quote: "MXN"}},
+		},
+		{
+			name: "with provider and an unknown field",
+			data: `{"update_id":"u2","base":"eur","quote":"usd","provider":"frankfurter","attempt":2}`,
+			want: service.UpdateQuotePayload{UpdateID: "u2", Pair: service.Pair{Base: "EUR", Quote: "USD"}, Provider: "frankfurter"},
+		},
+		{name: "missing pair", data: `{"update_id":"u3"}`, wantErr: true},
+		{name: "not JSON", data: `{not json`, wantErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := DecodeUpdatePayload([]byte(tc.data))
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("Expected an error, got %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("DecodeUpdatePayload: %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("Expected %+v, got %+v", tc.want, got)
+			}
+		})
+	}
+}
+
+// queueInspector is a TaskInspector holding only pending tasks.
+type queueInspector struct {
+	pending map[string][]*asynq.TaskInfo
+}
+
+func (q queueInspector) Queues() ([]string, error) {
+	return slices.Sorted(maps.Keys(q.pending)), nil
+}
+
+func (q queueInspector) ListPendingTasks(queue string, _ ...asynq.ListOption) ([]*asynq.TaskInfo, error) {
+	return q.pending[queue], nil
+}
+
+func (queueInspector) ListScheduledTasks(string, ...asynq.ListOption) ([]*asynq.TaskInfo, error) {
+	return nil, nil
+}
+
+func (queueInspector) ListRetryTasks(string, ...asynq.ListOption) ([]*asynq.TaskInfo, error) {
+	return nil, nil
+}
+
+func (queueInspector) ListArchivedTasks(string, ...asynq.ListOption) ([]*asynq.TaskInfo, error) {
+	return nil, nil
+}
+
+func TestListPairTasks_Namespace(t *testing.T) {
+	task := func(id, queue string) *asynq.TaskInfo {
+		payload := fmt.Sprintf(`{"update_id":%q,"base":"EUR","quote":"MXN"}`, id)
+		return &asynq.TaskInfo{ID: id, Queue: queue, Type: service.TaskTypeUpdateQuote, Payload: []byte(payload)}
+	}
+	insp := queueInspector{pending: map[string][]*asynq.TaskInfo{
+		"default":         {task("prod", "default")},
+		"staging:default": {task("staging", "staging:default")},
+		"staging:other":   {task("other", "staging:other")},
+	}}
+
+	tests := []struct {
+		ns   rediskey.Namespace
+		want string
+	}{
+		{"", "prod"},
+		{"staging", "staging"},
+	}
+	for _, tc := range tests {
+		tasks, err := NewPairTaskLister(insp, tc.ns).ListPairTasks(context.Background(), service.Pair{Base: "EUR", Quote: "MXN"})
+		if err != nil {
+			t.Fatalf("ListPairTasks: %v", err)
+		}
+		if len(tasks) != 1 || tasks[0].ID != tc.want {
+			t.Errorf("Namespace %q: expected only task %s, got %+v", tc.ns, tc.want, tasks)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"go.uber.org/zap"
+
+	"quoteservice/internal/logging/fields"
+	"quoteservice/internal/metrics"
+	"quoteservice/internal/repository"
+	"quoteservice/internal/service"
+)
+
+// failTimeout bounds marking an update FAILED after its task panicked.
+const failTimeout = 5 * time.Second
+
+// UpdateFailer marks an unfinished update FAILED. It is implemented by
+// service.QuoteService.
+type UpdateFailer interface {
+	FailUpdate(ctx context.Context, updateID string, code repository.ErrorCode, reason string) error
+}
+
+// Recover returns a middleware that turns a panicking task into a failed one. It logs
+// the panic with its stack, counts it, marks the update of a quote update task FAILED
+// and returns an asynq.SkipRetry error: a task that panicked once is likely to panic
+// again. Without it asynq retries the task and the record stays RUNNING meanwhile.
+func Recover(updates UpdateFailer, logger *zap.SugaredLogger) asynq.MiddlewareFunc {
+	return func(h asynq.Handler) asynq.Handler {
+		return asynq.HandlerFunc(func(ctx context.Context, t *asynq.Task) (err error) {
+			defer func() {
+				r := recover()
+				if r == nil {
+					return
+				}
+				metrics.TaskPanicsTotal.WithLabelValues(t.Type()).Inc()
+				updateID := panickedUpdateID(t)
+				logger.Errorw("Task panicked", "type", t.Type(), fields.UpdateID(updateID),
+					"panic", r, "stack", string(debug.Stack()))
+				if updateID != "" {
+					// The task's context may be what ran out; the record must still be released.
+					fctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), failTimeout)
+					defer cancel()
+					if ferr := updates.FailUpdate(fctx, updateID, repository.ErrorCodeInternal, "internal error"); ferr != nil {
+						logger.Warnw("Failed to fail update after panic", fields.UpdateID(updateID), "error", ferr)
+					}
+				}
+				err = fmt.Errorf("task panicked: %v: %w", r, asynq.SkipRetry)
+			}()
+			return h.ProcessTask(ctx, t)
+		})
+	}
+}
+
+// panickedUpdateID returns the update of a quote update task, or "" for other tasks
+// and unreadable payloads.
+func panickedUpdateID(t *asynq.Task) string {
+	if t.Type() != service.TaskTypeUpdateQuote {
+		return ""
+	}
+	var payload service.UpdateQuotePayload
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		return ""
+	}
+	return payload.UpdateID
+}
+
+// CountTasks returns a middleware that counts every finished task in
+// metrics.WorkerTasksProcessedTotal under instanceID, the ID of this process.
+func CountTasks(instanceID string) asynq.MiddlewareFunc {
+	succeeded := metrics.WorkerTasksProcessedTotal.WithLabelValues(instanceID, "success")
+	failed := metrics.WorkerTasksProcessedTotal.WithLabelValues(instanceID, "failure")
+	return func(h asynq.Handler) asynq.Handler {
+		return asynq.HandlerFunc(func(ctx context.Context, t *asynq.Task) error {
+			err := h.ProcessTask(ctx, t)
+			if err != nil {
+				failed.Inc()
+			} else {
+				succeeded.Inc()
+			}
+			return err
+		})
+	}
+}
+
+// LogTasks returns a middleware that logs when every task starts and finishes, with
+// its duration and error, whatever the handler itself logs.
+func LogTasks(logger *zap.SugaredLogger) asynq.MiddlewareFunc {
+	return func(h asynq.Handler) asynq.Handler {
+		return asynq.HandlerFunc(func(ctx context.Context, t *asynq.Task) error {
+			id, _ := asynq.GetTaskID(ctx)
+			queue, _ := asynq.GetQueueName(ctx)
+			retry, _ := asynq.GetRetryCount(ctx)
+			log := logger.With("type", t.Type(), "task_id", id, "queue", queue, "retry", retry)
+
+			log.Infow("Task started")
+			start := time.Now()
+			err := h.ProcessTask(ctx, t)
+			if err != nil {
+				log.Warnw("Task finished", "duration", time.Since(start), "error", err)
+				return err
+			}
+			log.Infow("Task finished", "duration", time.Since(start))
+			return nil
+		})
+	}
+}
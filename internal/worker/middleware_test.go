@@ -0,0 +1,183 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/hibiken/asynq"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+
+	"quoteservice/internal/metrics"
+	"quoteservice/internal/repository"
+	"quoteservice/internal/service"
+)
+
+// panicOnceProvider panics on its first call, like a nil dereference on a malformed
+// provider response, and returns a rate afterwards.
+type panicOnceProvider struct{ calls atomic.Int32 }
+
+func (p *panicOnceProvider) GetRate(context.Context, string, string) (string, time.Time, error) {
+	if p.calls.Add(1) == 1 {
+		var resp *struct{ Rate string }
+		return resp.Rate, time.Now(), nil
+	}
+	return "18.7", time.Now(), nil
+}
+
+func (r *memoryQuoteRepo) status() repository.Status {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.rec.Status
+}
+
+func TestRecover_PanickingUpdateTask(t *testing.T) {
+	repo := &memoryQuoteRepo{}
+	svc := service.NewQuoteService(service.QuoteServiceDeps{Repo: repo, Provider: &panicOnceProvider{}})
+	logger := zap.NewNop().Sugar()
+
+	mux := asynq.NewServeMux()
+	mux.Use(LogTasks(logger), Recover(svc, logger))
+	mux.HandleFunc(service.TaskTypeUpdateQuote, NewQuoteUpdateHandler(svc, logger))
+
+	mr := miniredis.RunT(t)
+	redisOpt := asynq.RedisClientOpt{Addr: mr.Addr()}
+	srv := asynq.NewServer(redisOpt, asynq.Config{Concurrency: 1, TaskCheckInterval: 10 * time.Millisecond})
+	if err := srv.Start(mux); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	t.Cleanup(srv.Shutdown)
+	client := asynq.NewClient(redisOpt)
+	t.Cleanup(func() { _ = client.Close() })
+	inspector := asynq.NewInspector(redisOpt)
+	t.Cleanup(func() { _ = inspector.Close() })
+
+	enqueue := func(id string) {
+		t.Helper()
+		repo.mu.Lock()
+		repo.rec = repository.Quote{ID: id, Base: "EUR", Quote: "MXN", Status: repository.StatusPending, Version: repository.InitialVersion}
+		repo.mu.Unlock()
+		payload, err := json.Marshal(service.UpdateQuotePayload{UpdateID: id, Pair: service.Pair{Base: "EUR", Quote: "MXN"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := client.Enqueue(asynq.NewTask(service.TaskTypeUpdateQuote, payload), asynq.MaxRetry(5)); err != nil {
+			t.Fatalf("Enqueue: %v", err)
+		}
+	}
+	panics := testutil.ToFloat64(metrics.TaskPanicsTotal.WithLabelValues(service.TaskTypeUpdateQuote))
+
+	enqueue("update-1")
+	archived := waitForTask(t, func() ([]*asynq.TaskInfo, error) { return inspector.ListArchivedTasks("default") })
+	if archived.Retried != 0 {
+		t.Errorf("Expected the panicking task archived without retries, got %d", archived.Retried)
+	}
+	if got := repo.status(); got != repository.StatusFailed {
+		t.Fatalf("Expected the panicking update FAILED, got %s", got)
+	}
+	if got := testutil.ToFloat64(metrics.TaskPanicsTotal.WithLabelValues(service.TaskTypeUpdateQuote)) - panics; got != 1 {
+		t.Errorf("Expected 1 counted panic, got %v", got)
+	}
+
+	enqueue("update-2")
+	deadline := time.Now().Add(5 * time.Second)
+	for repo.status() != repository.StatusSuccess {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the next task to succeed, update is %s", repo.status())
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestRecover_OtherTaskTypes(t *testing.T) {
+	failer := &recordingFailer{}
+	h := Recover(failer, zap.NewNop().Sugar())(asynq.HandlerFunc(func(context.Context, *asynq.Task) error {
+		panic("boom")
+	}))
+
+	err := h.ProcessTask(context.Background(), asynq.NewTask("report:daily", nil))
+	if !errors.Is(err, asynq.SkipRetry) {
+		t.Fatalf("Expected a SkipRetry error, got %v", err)
+	}
+	if failer.calls != 0 {
+		t.Errorf("Expected no update failed for a task without one, got %d", failer.calls)
+	}
+}
+
+type recordingFailer struct{ calls int }
+
+func (f *recordingFailer) FailUpdate(context.Context, string, repository.ErrorCode, string) error {
+	f.calls++
+	return nil
+}
+
+func TestLogTasks(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	fail := false
+	h := LogTasks(zap.New(core).Sugar())(asynq.HandlerFunc(func(context.Context, *asynq.Task) error {
+		if fail {
+			return asynq.SkipRetry
+		}
+		return nil
+	}))
+	task := asynq.NewTask(service.TaskTypeUpdateQuote, nil)
+
+	_ = h.ProcessTask(context.Background(), task)
+	fail = true
+	_ = h.ProcessTask(context.Background(), task)
+
+	entries := logs.All()
+	want := []struct {
+		msg   string
+		level string
+	}{
+		{"Task started", "info"}, {"Task finished", "info"},
+		{"Task started", "info"}, {"Task finished", "warn"},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("Expected %d log lines, got %d", len(want), len(entries))
+	}
+	for i, w := range want {
+		if entries[i].Message != w.msg || entries[i].Level.String() != w.level {
+			t.Errorf("Line %d: expected %s %q, got %s %q", i, w.level, w.msg, entries[i].Level, entries[i].Message)
+		}
+		if entries[i].ContextMap()["type"] != service.TaskTypeUpdateQuote {
+			t.Errorf("Line %d: expected the task type logged, got %v", i, entries[i].ContextMap())
+		}
+	}
+	if _, ok := entries[3].ContextMap()["error"]; !ok {
+		t.Error("Expected the failed task's error logged")
+	}
+}
+
+func TestCountTasks(t *testing.T) {
+	t.Cleanup(metrics.WorkerTasksProcessedTotal.Reset)
+	fail := false
+	h := CountTasks("worker-1-a1b2c3")(asynq.HandlerFunc(func(context.Context, *asynq.Task) error {
+		if fail {
+			return errors.New("provider timeout")
+		}
+		return nil
+	}))
+	task := asynq.NewTask(service.TaskTypeUpdateQuote, nil)
+
+	_ = h.ProcessTask(context.Background(), task)
+	_ = h.ProcessTask(context.Background(), task)
+	fail = true
+	if err := h.ProcessTask(context.Background(), task); err == nil {
+		t.Error("Expected the handler's error returned")
+	}
+
+	if got := testutil.ToFloat64(metrics.WorkerTasksProcessedTotal.WithLabelValues("worker-1-a1b2c3", "success")); got != 2 {
+		t.Errorf("Expected 2 successful tasks, got %v", got)
+	}
+	if got := testutil.ToFloat64(metrics.WorkerTasksProcessedTotal.WithLabelValues("worker-1-a1b2c3", "failure")); got != 1 {
+		t.Errorf("Expected 1 failed task, got %v", got)
+	}
+}
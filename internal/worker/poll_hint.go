@@ -0,0 +1,202 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	"quoteservice/internal/rediskey"
+	"quoteservice/internal/service"
+)
+
+// taskDurationsKey is the Redis list of recent update task durations in milliseconds,
+// newest first.
+const taskDurationsKey = "quotesvc:task_durations_ms"
+
+// recordTimeout bounds the Redis write that records a task duration.
+const recordTimeout = time.Second
+
+// TaskDurations is a rolling window of update task durations kept in Redis, so every
+// worker contributes to it and every API process reads the same average.
+type TaskDurations struct {
+	rdb    redis.Cmdable
+	key    string
+	window int
+}
+
+// NewTaskDurations creates a TaskDurations keeping the last window durations under a
+// key in keys' namespace.
+func NewTaskDurations(rdb redis.Cmdable, window int, keys rediskey.Namespace) *TaskDurations {
+	return &TaskDurations{rdb: rdb, key: keys.Key(taskDurationsKey), window: window}
+}
+
+// Record adds d to the window, dropping the oldest duration once the window is full.
+func (t *TaskDurations) Record(ctx context.Context, d time.Duration) error {
+	pipe := t.rdb.Pipeline()
+	pipe.LPush(ctx, t.key, d.Milliseconds())
+	pipe.LTrim(ctx, t.key, 0, int64(t.window-1))
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// Average returns the mean duration in the window, or false if the window is empty.
+// Entries that are not integers are ignored.
+func (t *TaskDurations) Average(ctx context.Context) (time.Duration, bool, error) {
+	vals, err := t.rdb.LRange(ctx, t.key, 0, int64(t.window-1)).Result()
+	if err != nil {
+		return 0, false, err
+	}
+	var sum, n int64
+	for _, v := range vals {
+		ms, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || ms < 0 {
+			continue
+		}
+		sum += ms
+		n++
+	}
+	if n == 0 {
+		return 0, false, nil
+	}
+	return time.Duration(sum/n) * time.Millisecond, true, nil
+}
+
+// RecordDurations returns a handler that runs h and records how long each successful
+// task took in durations. Failed tasks are not recorded: a timeout or provider outage
+// says nothing about how soon the next update completes. Recording errors are logged.
+func RecordDurations(h asynq.Handler, durations *TaskDurations, logger *zap.SugaredLogger) asynq.Handler {
+	return asynq.HandlerFunc(func(ctx context.Context, t *asynq.Task) error {
+		start := time.Now()
+		if err := h.ProcessTask(ctx, t); err != nil {
+			return err
+		}
+		rctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), recordTimeout)
+		defer cancel()
+		if err := durations.Record(rctx, time.Since(start)); err != nil {
+			logger.Warnw("Failed to record task duration", "type", t.Type(), "error", err)
+		}
+		return nil
+	})
+}
+
+// QueueSizer is the part of asynq.Inspector PollHint uses.
+type QueueSizer interface {
+	Queues() ([]string, error)
+	GetQueueInfo(queue string) (*asynq.QueueInfo, error)
+}
+
+// PollHintConfig configures a PollHint.
+type PollHintConfig struct {
+	Default     time.Duration      // Returned while there are no task durations or Redis fails.
+	Min, Max    time.Duration      // Bounds of every estimate.
+	CacheFor    time.Duration      // How long an estimate is reused.
+	Concurrency int                // Tasks processed in parallel across the worker pool.
+	Namespace   rediskey.Namespace // Namespace of the update queues counted.
+}
+
+// PollHint estimates when a newly requested update is likely to be done from the depth
+// of the update queues and the average task duration. It implements
+// service.PollEstimator.
+type PollHint struct {
+	queues      QueueSizer
+	updateQueue map[string]int // Update queues of the namespace.
+	durations   *TaskDurations
+	cfg         PollHintConfig
+	log         *zap.SugaredLogger
+	now         func() time.Time
+
+	mu          sync.Mutex
+	estimate    time.Duration
+	estimatedAt time.Time
+}
+
+var _ service.PollEstimator = (*PollHint)(nil)
+
+// NewPollHint creates a PollHint reading queue sizes from queues and task durations
+// from durations.
+func NewPollHint(queues QueueSizer, durations *TaskDurations, cfg PollHintConfig, logger *zap.SugaredLogger) *PollHint {
+	return &PollHint{
+		queues:      queues,
+		updateQueue: Queues(cfg.Namespace),
+		durations:   durations,
+		cfg:         cfg,
+		log:         logger,
+		now:         time.Now,
+	}
+}
+
+// PollAfter returns the current estimate, computing a new one at most once per
+// CacheFor.
+func (p *PollHint) PollAfter(ctx context.Context) time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	if !p.estimatedAt.IsZero() && now.Sub(p.estimatedAt) < p.cfg.CacheFor {
+		return p.estimate
+	}
+	p.estimate, p.estimatedAt = p.compute(ctx), now
+	return p.estimate
+}
+
+func (p *PollHint) compute(ctx context.Context) time.Duration {
+	avg, ok, err := p.durations.Average(ctx)
+	if err != nil {
+		p.log.Warnw("Failed to read task durations, using the default poll hint", "error", err)
+		return p.cfg.Default
+	}
+	if !ok {
+		return p.cfg.Default
+	}
+	depth, err := p.queueDepth()
+	if err != nil {
+		p.log.Warnw("Failed to read queue sizes, using the default poll hint", "error", err)
+		return p.cfg.Default
+	}
+	return EstimatePollAfter(avg, depth, p.cfg.Concurrency, p.cfg.Min, p.cfg.Max)
+}
+
+// queueDepth returns the pending and active tasks of the namespace's update queues
+// that exist.
+func (p *PollHint) queueDepth() (int, error) {
+	queues, err := p.queues.Queues()
+	if err != nil {
+		return 0, fmt.Errorf("list queues: %w", err)
+	}
+	depth := 0
+	for _, q := range queues {
+		if _, ok := p.updateQueue[q]; !ok {
+			continue
+		}
+		info, err := p.queues.GetQueueInfo(q)
+		if errors.Is(err, asynq.ErrQueueNotFound) {
+			continue
+		}
+		if err != nil {
+			return 0, fmt.Errorf("queue %s: %w", q, err)
+		}
+		depth += info.Pending + info.Active
+	}
+	return depth, nil
+}
+
+// EstimatePollAfter returns how long an update enqueued behind depth tasks is likely to
+// take when concurrency tasks run at once and each takes avg: the tasks ahead drain in
+// ceil(depth/concurrency) rounds, then the update itself runs. Rounding up errs towards
+// fewer polls. The result is clamped to [minWait, maxWait].
+func EstimatePollAfter(avg time.Duration, depth, concurrency int, minWait, maxWait time.Duration) time.Duration {
+	concurrency = max(concurrency, 1)
+	rounds := (max(depth, 0) + concurrency - 1) / concurrency
+	// Compare in rounds first so a huge queue cannot overflow the multiplication.
+	if avg > 0 && int64(rounds+1) > int64(maxWait/avg) {
+		return maxWait
+	}
+	return min(max(avg*time.Duration(rounds+1), minWait), maxWait)
+}
@@ -0,0 +1,186 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/hibiken/asynq"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+func TestEstimatePollAfter(t *testing.T) {
+	const minWait, maxWait = 500 * time.Millisecond, 30 * time.Second
+	tests := []struct {
+		name        string
+		avg         time.Duration
+		depth       int
+		concurrency int
+		want        time.Duration
+	}{
+		{"empty queue waits one task", 2 * time.Second, 0, 4, 2 * time.Second},
+		{"partial round rounds up", 2 * time.Second, 1, 4, 4 * time.Second},
+		{"full rounds", 2 * time.Second, 8, 4, 6 * time.Second},
+		{"zero concurrency treated as one", time.Second, 3, 0, 4 * time.Second},
+		{"fast tasks raised to min", 50 * time.Millisecond, 0, 1, minWait},
+		{"deep queue capped", 2 * time.Second, 100, 1, maxWait},
+		{"huge queue does not overflow", time.Hour, 1 << 40, 1, maxWait},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := EstimatePollAfter(tc.avg, tc.depth, tc.concurrency, minWait, maxWait); got != tc.want {
+				t.Errorf("EstimatePollAfter(%v, %d, %d) = %v, want %v", tc.avg, tc.depth, tc.concurrency, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestTaskDurations(t *testing.T) {
+	mr := miniredis.RunT(t)
+	durations := NewTaskDurations(redis.NewClient(&redis.Options{Addr: mr.Addr()}), 3, "")
+	ctx := context.Background()
+
+	if _, ok, err := durations.Average(ctx); err != nil || ok {
+		t.Fatalf("expected no average for an empty window, got ok=%v err=%v", ok, err)
+	}
+	for _, d := range []time.Duration{10 * time.Second, time.Second, 2 * time.Second, 3 * time.Second} {
+		if err := durations.Record(ctx, d); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+	}
+	if n, _ := mr.List(taskDurationsKey); len(n) != 3 {
+		t.Fatalf("expected the window to keep 3 durations, got %v", n)
+	}
+	avg, ok, err := durations.Average(ctx)
+	if err != nil || !ok || avg != 2*time.Second {
+		t.Errorf("expected the oldest duration dropped and an average of 2s, got %v ok=%v err=%v", avg, ok, err)
+	}
+
+	mr.Lpush(taskDurationsKey, "garbage")
+	if avg, _, _ := durations.Average(ctx); avg != 2500*time.Millisecond {
+		t.Errorf("expected non-integer entries ignored, got %v", avg)
+	}
+}
+
+type fakeQueueSizer struct {
+	queues []string
+	infos  map[string]*asynq.QueueInfo
+	err    error
+	calls  int
+}
+
+func (f *fakeQueueSizer) Queues() ([]string, error) {
+	f.calls++
+	return f.queues, f.err
+}
+
+func (f *fakeQueueSizer) GetQueueInfo(queue string) (*asynq.QueueInfo, error) {
+	info, ok := f.infos[queue]
+	if !ok {
+		return nil, asynq.ErrQueueNotFound
+	}
+	return info, nil
+}
+
+func TestPollHint(t *testing.T) {
+	cfg := PollHintConfig{
+		Default:     2 * time.Second,
+		Min:         500 * time.Millisecond,
+		Max:         30 * time.Second,
+		CacheFor:    time.Second,
+		Concurrency: 2,
+	}
+	newHint := func(t *testing.T, queues *fakeQueueSizer, recorded ...time.Duration) (*PollHint, *miniredis.Miniredis) {
+		mr := miniredis.RunT(t)
+		durations := NewTaskDurations(redis.NewClient(&redis.Options{Addr: mr.Addr()}), 10, "")
+		for _, d := range recorded {
+			if err := durations.Record(context.Background(), d); err != nil {
+				t.Fatalf("Record: %v", err)
+			}
+		}
+		return NewPollHint(queues, durations, cfg, zap.NewNop().Sugar()), mr
+	}
+
+	t.Run("estimate from update queues", func(t *testing.T) {
+		queues := &fakeQueueSizer{
+			queues: []string{"high", "default", "low", "other"},
+			infos: map[string]*asynq.QueueInfo{
+				"high":    {Pending: 1, Active: 1},
+				"default": {Pending: 1, Scheduled: 50, Retry: 50},
+				"other":   {Pending: 100},
+			},
+		}
+		hint, _ := newHint(t, queues, time.Second)
+		// 3 tasks ahead on 2 workers drain in 2 rounds, then the update runs.
+		if got := hint.PollAfter(context.Background()); got != 3*time.Second {
+			t.Errorf("expected 3s, got %v", got)
+		}
+	})
+
+	t.Run("default without durations", func(t *testing.T) {
+		hint, _ := newHint(t, &fakeQueueSizer{})
+		if got := hint.PollAfter(context.Background()); got != cfg.Default {
+			t.Errorf("expected the default %v, got %v", cfg.Default, got)
+		}
+	})
+
+	t.Run("default when the inspector fails", func(t *testing.T) {
+		hint, _ := newHint(t, &fakeQueueSizer{err: errors.New("redis down")}, time.Second)
+		if got := hint.PollAfter(context.Background()); got != cfg.Default {
+			t.Errorf("expected the default %v, got %v", cfg.Default, got)
+		}
+	})
+
+	t.Run("default when redis fails", func(t *testing.T) {
+		hint, mr := newHint(t, &fakeQueueSizer{}, time.Second)
+		mr.SetError("LOADING")
+		if got := hint.PollAfter(context.Background()); got != cfg.Default {
+			t.Errorf("expected the default %v, got %v", cfg.Default, got)
+		}
+	})
+
+	t.Run("estimate cached", func(t *testing.T) {
+		queues := &fakeQueueSizer{queues: []string{"default"}, infos: map[string]*asynq.QueueInfo{"default": {}}}
+		hint, _ := newHint(t, queues, time.Second)
+		now := time.Now()
+		hint.now = func() time.Time { return now }
+
+		hint.PollAfter(context.Background())
+		queues.infos["default"].Pending = 10
+		if got := hint.PollAfter(context.Background()); got != time.Second || queues.calls != 1 {
+			t.Errorf("expected the cached 1s without a new reading, got %v after %d readings", got, queues.calls)
+		}
+		now = now.Add(cfg.CacheFor)
+		if got := hint.PollAfter(context.Background()); got != 6*time.Second {
+			t.Errorf("expected a fresh estimate of 6s, got %v", got)
+		}
+	})
+}
+
+func TestRecordDurations(t *testing.T) {
+	mr := miniredis.RunT(t)
+	durations := NewTaskDurations(redis.NewClient(&redis.Options{Addr: mr.Addr()}), 10, "")
+	taskErr := errors.New("provider timeout")
+	var fail bool
+	h := RecordDurations(asynq.HandlerFunc(func(context.Context, *asynq.Task) error {
+		if fail {
+			return taskErr
+		}
+		return nil
+	}), durations, zap.NewNop().Sugar())
+	task := asynq.NewTask("quote:update", nil)
+
+	if err := h.ProcessTask(context.Background(), task); err != nil {
+		t.Fatalf("ProcessTask: %v", err)
+	}
+	fail = true
+	if err := h.ProcessTask(context.Background(), task); !errors.Is(err, taskErr) {
+		t.Fatalf("expected the task error, got %v", err)
+	}
+	if got, _ := mr.List(taskDurationsKey); len(got) != 1 {
+		t.Errorf("expected only the successful task recorded, got %v", got)
+	}
+}
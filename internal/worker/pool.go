@@ -0,0 +1,272 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"go.uber.org/zap"
+
+	"quoteservice/internal/config"
+	"quoteservice/internal/metrics"
+	"quoteservice/internal/rediskey"
+)
+
+// PriorityQueues are the asynq queues update tasks are enqueued to, weighted so
+// higher-priority pairs are processed first without starving the others.
+var PriorityQueues = map[string]int{
+	config.PriorityHigh:    6,
+	config.PriorityDefault: 3,
+	config.PriorityLow:     1,
+}
+
+// Queues returns PriorityQueues named in keys' namespace, for asynq.Config.Queues.
+func Queues(keys rediskey.Namespace) map[string]int {
+	queues := make(map[string]int, len(PriorityQueues))
+	for name, weight := range PriorityQueues {
+		queues[keys.Queue(name)] = weight
+	}
+	return queues
+}
+
+// PoolConfig holds the worker settings that can be changed at runtime.
+type PoolConfig struct {
+	Concurrency int
+	TaskTimeout time.Duration
+}
+
+// Validate reports whether c can be applied to a Pool.
+func (c PoolConfig) Validate() error {
+	var errs []error
+	if c.Concurrency <= 0 {
+		errs = append(errs, fmt.Errorf("concurrency must be positive, got %d", c.Concurrency))
+	}
+	if c.TaskTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("task timeout must be positive, got %s", c.TaskTimeout))
+	}
+	return errors.Join(errs...)
+}
+
+// TaskTimeoutSetter is implemented by enqueuers whose per-task timeout follows the pool.
+type TaskTimeoutSetter interface {
+	SetTaskTimeout(d time.Duration)
+}
+
+// Server is the part of *asynq.Server a Pool drives.
+type Server interface {
+	Start(handler asynq.Handler) error
+	// Stop stops fetching new tasks; in-flight tasks keep running.
+	Stop()
+	// Shutdown waits for in-flight tasks, up to the server's ShutdownTimeout.
+	Shutdown()
+}
+
+// ServerFactory creates the Server a Pool runs.
+type ServerFactory func(redisOpt asynq.RedisConnOpt, cfg asynq.Config) Server
+
+func newAsynqServer(redisOpt asynq.RedisConnOpt, cfg asynq.Config) Server {
+	return asynq.NewServer(redisOpt, cfg)
+}
+
+// PoolOption configures optional Pool behavior.
+type PoolOption func(*Pool)
+
+// WithServerFactory replaces the asynq.Server the pool runs, for tests.
+func WithServerFactory(f ServerFactory) PoolOption {
+	return func(p *Pool) { p.newServer = f }
+}
+
+var errPoolStopped = errors.New("worker pool is stopped")
+
+// Pool runs an asynq.Server and can replace it with one using different settings.
+// Asynq cannot change concurrency of a running server, so Reconfigure shuts the
+// current server down, which waits for in-flight tasks (up to the server's
+// ShutdownTimeout), and only then starts the new one.
+//
+// A drained pool fetches no new tasks but lets in-flight ones finish, so an instance
+// can be taken out of rotation without stopping the process. Asynq cannot restart a
+// stopped server either, so Resume shuts it down and starts a new one.
+type Pool struct {
+	redisOpt  asynq.RedisConnOpt
+	base      asynq.Config
+	handler   asynq.Handler
+	enqueuer  TaskTimeoutSetter
+	logger    *zap.SugaredLogger
+	newServer ServerFactory
+
+	mu      sync.Mutex
+	cfg     PoolConfig
+	srv     Server
+	drained bool
+	stopped bool
+}
+
+// NewPool creates a Pool. base supplies every asynq setting except Concurrency, which
+// comes from cfg. Each task runs with a deadline of cfg.TaskTimeout; enqueuer, if not
+// nil, is kept in sync so newly enqueued tasks carry the same timeout.
+func NewPool(redisOpt asynq.RedisConnOpt, base asynq.Config, cfg PoolConfig, handler asynq.Handler,
+	enqueuer TaskTimeoutSetter, logger *zap.SugaredLogger, opts ...PoolOption) *Pool {
+	p := &Pool{
+		redisOpt:  redisOpt,
+		base:      base,
+		handler:   handler,
+		enqueuer:  enqueuer,
+		logger:    logger,
+		newServer: newAsynqServer,
+		cfg:       cfg,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Config returns the settings of the running server.
+func (p *Pool) Config() PoolConfig {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.cfg
+}
+
+// Start starts the server with the current settings. A pool drained before it was
+// started stays idle until Resume.
+func (p *Pool) Start() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.stopped {
+		return errPoolStopped
+	}
+	if p.drained {
+		return nil
+	}
+	return p.startLocked()
+}
+
+// Reconfigure drains the running server and starts a new one with cfg. If the new
+// server fails to start, the previous settings are restored. A drained pool only
+// records cfg, which Resume then starts with.
+func (p *Pool) Reconfigure(cfg PoolConfig) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.stopped {
+		return errPoolStopped
+	}
+	if p.drained {
+		p.cfg = cfg
+		return nil
+	}
+
+	prev := p.cfg
+	p.logger.Infow("Reconfiguring worker pool, draining in-flight tasks",
+		"concurrency", cfg.Concurrency, "task_timeout", cfg.TaskTimeout)
+	if p.srv != nil {
+		p.srv.Shutdown()
+		p.srv = nil
+	}
+
+	p.cfg = cfg
+	if err := p.startLocked(); err != nil {
+		p.cfg = prev
+		if rerr := p.startLocked(); rerr != nil {
+			return errors.Join(err, fmt.Errorf("restore previous worker config: %w", rerr))
+		}
+		return err
+	}
+	return nil
+}
+
+// Drain stops fetching new tasks and returns without waiting for in-flight ones.
+// Draining a drained pool does nothing.
+func (p *Pool) Drain() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.stopped {
+		return errPoolStopped
+	}
+	if p.drained {
+		return nil
+	}
+	if p.srv != nil {
+		p.srv.Stop()
+	}
+	p.drained = true
+	metrics.WorkerDrained.Set(1)
+	p.logger.Infow("Worker pool drained, in-flight tasks keep running")
+	return nil
+}
+
+// Resume starts fetching tasks again after Drain. It waits for tasks still running on
+// the drained server. Resuming a pool that is not drained does nothing.
+func (p *Pool) Resume() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.stopped {
+		return errPoolStopped
+	}
+	if !p.drained {
+		return nil
+	}
+	if p.srv != nil {
+		p.srv.Shutdown()
+		p.srv = nil
+	}
+	if err := p.startLocked(); err != nil {
+		return err
+	}
+	p.drained = false
+	metrics.WorkerDrained.Set(0)
+	return nil
+}
+
+// Drained reports whether the pool is drained.
+func (p *Pool) Drained() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.drained
+}
+
+// Shutdown drains the running server. The pool cannot be restarted afterwards.
+// Shutting down a drained pool waits for the tasks it is still running.
+func (p *Pool) Shutdown() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.stopped = true
+	if p.srv != nil {
+		p.srv.Shutdown()
+		p.srv = nil
+	}
+}
+
+// startLocked must be called with mu held.
+func (p *Pool) startLocked() error {
+	asynqCfg := p.base
+	asynqCfg.Concurrency = p.cfg.Concurrency
+
+	srv := p.newServer(p.redisOpt, asynqCfg)
+	if err := srv.Start(withTaskTimeout(p.handler, p.cfg.TaskTimeout)); err != nil {
+		return fmt.Errorf("start asynq server: %w", err)
+	}
+	p.srv = srv
+	if p.enqueuer != nil {
+		p.enqueuer.SetTaskTimeout(p.cfg.TaskTimeout)
+	}
+	p.logger.Infow("Worker pool started", "concurrency", p.cfg.Concurrency, "task_timeout", p.cfg.TaskTimeout)
+	return nil
+}
+
+// withTaskTimeout bounds each task by timeout, which also covers tasks enqueued
+// before the timeout was changed.
+func withTaskTimeout(h asynq.Handler, timeout time.Duration) asynq.Handler {
+	return asynq.HandlerFunc(func(ctx context.Context, t *asynq.Task) error {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		return h.ProcessTask(ctx, t)
+	})
+}
@@ -0,0 +1,114 @@
+package worker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/hibiken/asynq"
+	"go.uber.org/zap"
+)
+
+type recordingTimeoutSetter struct{ timeout time.Duration }
+
+func (r *recordingTimeoutSetter) SetTaskTimeout(d time.Duration) { r.timeout = d }
+
+func TestPool_Reconfigure(t *testing.T) {
+	mr := miniredis.RunT(t)
+	redisOpt := asynq.RedisClientOpt{Addr: mr.Addr()}
+
+	deadlines := make(chan time.Duration, 1)
+	handler := asynq.HandlerFunc(func(ctx context.Context, _ *asynq.Task) error {
+		dl, _ := ctx.Deadline()
+		deadlines <- time.Until(dl)
+		return nil
+	})
+	enq := &recordingTimeoutSetter{}
+	pool := NewPool(redisOpt, asynq.Config{TaskCheckInterval: 10 * time.Millisecond},
+		PoolConfig{Concurrency: 1, TaskTimeout: 30 * time.Second}, handler, enq, zap.NewNop().Sugar())
+	if err := pool.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	t.Cleanup(pool.Shutdown)
+	if enq.timeout != 30*time.Second {
+		t.Errorf("Expected enqueuer timeout 30s, got %s", enq.timeout)
+	}
+
+	want := PoolConfig{Concurrency: 3, TaskTimeout: 2 * time.Second}
+	if err := pool.Reconfigure(want); err != nil {
+		t.Fatalf("Reconfigure: %v", err)
+	}
+	if got := pool.Config(); got != want {
+		t.Errorf("Expected config %+v, got %+v", want, got)
+	}
+	if enq.timeout != 2*time.Second {
+		t.Errorf("Expected enqueuer timeout 2s, got %s", enq.timeout)
+	}
+
+	client := asynq.NewClient(redisOpt)
+	defer client.Close()
+	if _, err := client.Enqueue(asynq.NewTask("test", nil)); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	select {
+	case left := <-deadlines:
+		if left > 2*time.Second {
+			t.Errorf("Expected task deadline within 2s, got %s", left)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("task was not processed by the reconfigured pool")
+	}
+}
+
+func TestPool_ReconfigureRejectsInvalid(t *testing.T) {
+	pool := NewPool(asynq.RedisClientOpt{Addr: "127.0.0.1:0"}, asynq.Config{},
+		PoolConfig{Concurrency: 1, TaskTimeout: time.Second}, asynq.HandlerFunc(func(context.Context, *asynq.Task) error { return nil }),
+		nil, zap.NewNop().Sugar())
+
+	if err := pool.Reconfigure(PoolConfig{Concurrency: 0, TaskTimeout: time.Second}); err == nil {
+		t.Error("Expected error for zero concurrency")
+	}
+	if err := pool.Reconfigure(PoolConfig{Concurrency: 1}); err == nil {
+		t.Error("Expected error for zero task timeout")
+	}
+	if got := pool.Config(); got.Concurrency != 1 {
+		t.Errorf("Expected config unchanged, got %+v", got)
+	}
+}
+
+type countingServer struct{ starts, stops int }
+
+func (s *countingServer) Start(asynq.Handler) error { s.starts++; return nil }
+func (s *countingServer) Stop()                     { s.stops++ }
+func (s *countingServer) Shutdown()                 {}
+
+func TestPool_ReconfigureWhileDrained(t *testing.T) {
+	var configs []asynq.Config
+	enq := &recordingTimeoutSetter{}
+	pool := NewPool(asynq.RedisClientOpt{}, asynq.Config{}, PoolConfig{Concurrency: 1, TaskTimeout: time.Second},
+		asynq.HandlerFunc(nil), enq, zap.NewNop().Sugar(),
+		WithServerFactory(func(_ asynq.RedisConnOpt, cfg asynq.Config) Server {
+			configs = append(configs, cfg)
+			return &countingServer{}
+		}))
+	if err := pool.Drain(); err != nil {
+		t.Fatalf("Drain: %v", err)
+	}
+	if err := pool.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if err := pool.Reconfigure(PoolConfig{Concurrency: 4, TaskTimeout: 2 * time.Second}); err != nil {
+		t.Fatalf("Reconfigure: %v", err)
+	}
+	if len(configs) != 0 {
+		t.Fatalf("Expected a drained pool to start no server, started %d", len(configs))
+	}
+
+	if err := pool.Resume(); err != nil {
+		t.Fatalf("Resume: %v", err)
+	}
+	if len(configs) != 1 || configs[0].Concurrency != 4 || enq.timeout != 2*time.Second {
+		t.Errorf("Expected one server with the new settings, got %+v, enqueuer timeout %s", configs, enq.timeout)
+	}
+}
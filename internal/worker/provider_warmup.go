@@ -0,0 +1,138 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.uber.org/zap"
+
+	"quoteservice/internal/lock"
+	"quoteservice/internal/logging/fields"
+	"quoteservice/internal/provider"
+	"quoteservice/internal/repository"
+)
+
+// warmupQueueSize bounds the warm-ups waiting for Run; further triggers are dropped.
+const warmupQueueSize = 8
+
+type warmupRequest struct {
+	name     string
+	provider provider.RatesProvider
+}
+
+// ProviderWarmer refills a provider's rate cache for a fixed set of pairs after the
+// provider recovers from an outage, so the first tasks afterwards are served from the
+// cache instead of all calling the provider and running into its rate limit.
+//
+// Warm-ups are requested with Trigger and run one at a time by Run, pausing delay
+// between provider calls. A job lock per provider makes instances that recover at the
+// same time skip the warm-up another one is already doing.
+type ProviderWarmer struct {
+	locker   *lock.Locker
+	pairs    []repository.Pair
+	delay    time.Duration
+	logger   *zap.SugaredLogger
+	requests chan warmupRequest
+}
+
+// NewProviderWarmer creates a ProviderWarmer fetching pairs with delay between calls
+// and taking its locks through locker.
+func NewProviderWarmer(locker *lock.Locker, pairs []repository.Pair, delay time.Duration,
+	logger *zap.SugaredLogger) *ProviderWarmer {
+	return &ProviderWarmer{
+		locker:   locker,
+		pairs:    pairs,
+		delay:    delay,
+		logger:   logger,
+		requests: make(chan warmupRequest, warmupQueueSize),
+	}
+}
+
+// Trigger requests a warm-up of the named provider and returns without waiting for it.
+// p should be the provider's caching decorator, below its circuit breaker. It is safe
+// to call from a circuit breaker's OnClose hook.
+func (w *ProviderWarmer) Trigger(name string, p provider.RatesProvider) {
+	select {
+	case w.requests <- warmupRequest{name: name, provider: p}:
+	default:
+		w.logger.Warnw("Provider cache warm-up queue full, dropping warm-up", fields.Provider(name))
+	}
+}
+
+// Run performs the triggered warm-ups until ctx is cancelled, which also stops the one
+// in progress. It always returns nil.
+func (w *ProviderWarmer) Run(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case req := <-w.requests:
+			w.Warm(ctx, req.name, req.provider)
+		}
+	}
+}
+
+// Warm fetches every pair from p, unless another instance holds the provider's lock.
+// It stops early when ctx is cancelled or p fails with a retryable error, as the
+// provider is then likely down again; other errors only skip the pair. It returns the
+// number of pairs fetched.
+func (w *ProviderWarmer) Warm(ctx context.Context, name string, p provider.RatesProvider) int {
+	if len(w.pairs) == 0 {
+		return 0
+	}
+
+	var warmed int
+	err := w.locker.Do(ctx, "provider_warmup:"+name, 0, func(ctx context.Context) error {
+		warmed = w.warm(ctx, name, p)
+		return nil
+	})
+	switch {
+	case errors.Is(err, lock.ErrNotAcquired):
+		w.logger.Infow("Provider cache warm-up already running on another instance", fields.Provider(name))
+	case err != nil:
+		w.logger.Warnw("Skipping provider cache warm-up", fields.Provider(name), "error", err)
+	}
+	return warmed
+}
+
+func (w *ProviderWarmer) warm(ctx context.Context, name string, p provider.RatesProvider) int {
+	w.logger.Infow("Warming provider cache after recovery", fields.Provider(name), "pairs", len(w.pairs))
+	warmed := 0
+	for i, pair := range w.pairs {
+		if i > 0 && !sleep(ctx, w.delay) {
+			break
+		}
+		if _, _, err := p.GetRate(ctx, pair.Base, pair.Quote); err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			if provider.IsRetryable(err) {
+				w.logger.Warnw("Provider failing again, stopping cache warm-up", fields.Provider(name),
+					fields.Pair(pair.Base, pair.Quote), "error", err)
+				break
+			}
+			w.logger.Debugw("Skipping pair in provider cache warm-up", fields.Provider(name),
+				fields.Pair(pair.Base, pair.Quote), "error", err)
+			continue
+		}
+		warmed++
+	}
+	w.logger.Infow("Provider cache warm-up finished", fields.Provider(name), "pairs", len(w.pairs), "warmed", warmed)
+	return warmed
+}
+
+// sleep waits for d and reports whether ctx is still live.
+func sleep(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
@@ -0,0 +1,137 @@
+package worker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	"quoteservice/internal/lock"
+	"quoteservice/internal/provider"
+	"quoteservice/internal/repository"
+	"quoteservice/internal/testkit/fakeprovider"
+)
+
+var warmupPairs = []repository.Pair{
+	{Base: "EUR", Quote: "USD"},
+	{Base: "EUR", Quote: "XXX"}, // Unknown to the provider: skipped.
+	{Base: "GBP", Quote: "USD"},
+}
+
+// newWarmupFixture returns a fake Frankfurter server knowing EUR/USD and GBP/USD, the
+// caching decorator around a client of it, the miniredis holding cache and locks, and
+// a locker on it.
+func newWarmupFixture(t *testing.T) (*fakeprovider.Server, provider.RatesProvider, *miniredis.Miniredis, *lock.Locker) {
+	t.Helper()
+	srv := fakeprovider.New(fakeprovider.Frankfurter)
+	t.Cleanup(srv.Close)
+	srv.SetRate("EUR", "USD", "1.0850")
+	srv.SetRate("GBP", "USD", "1.2710")
+
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	cached := provider.NewCachedRatesProvider(provider.NewFrankfurterProvider(srv.URL(), 5), rdb, time.Hour, "frankfurter", "")
+	return srv, cached, mr, lock.NewLocker(rdb, "", lock.DefaultTTL, zap.NewNop().Sugar())
+}
+
+func TestProviderWarmer_Warm(t *testing.T) {
+	t.Run("fetches every pair with the delay in between", func(t *testing.T) {
+		srv, cached, mr, locker := newWarmupFixture(t)
+		delay := 30 * time.Millisecond
+		w := NewProviderWarmer(locker, warmupPairs, delay, zap.NewNop().Sugar())
+
+		start := time.Now()
+		warmed := w.Warm(context.Background(), "frankfurter", cached)
+		elapsed := time.Since(start)
+
+		if warmed != 2 {
+			t.Errorf("Expected 2 pairs warmed, got %d", warmed)
+		}
+		if got := srv.Calls(); got != len(warmupPairs) {
+			t.Errorf("Expected %d provider calls, got %d", len(warmupPairs), got)
+		}
+		if min := time.Duration(len(warmupPairs)-1) * delay; elapsed < min {
+			t.Errorf("Expected the calls to take at least %v, took %v", min, elapsed)
+		}
+		for _, key := range []string{"provider_cache:frankfurter:{EUR:USD}", "provider_cache:frankfurter:{GBP:USD}"} {
+			if !mr.Exists(key) {
+				t.Errorf("Expected %s to be cached", key)
+			}
+		}
+		if mr.Exists("lock:provider_warmup:frankfurter") {
+			t.Error("Expected the lock to be released")
+		}
+	})
+
+	t.Run("skipped while another instance holds the lock", func(t *testing.T) {
+		srv, cached, mr, locker := newWarmupFixture(t)
+		if err := mr.Set("lock:provider_warmup:frankfurter", "other-instance"); err != nil {
+			t.Fatal(err)
+		}
+		w := NewProviderWarmer(locker, warmupPairs, 0, zap.NewNop().Sugar())
+
+		if warmed := w.Warm(context.Background(), "frankfurter", cached); warmed != 0 {
+			t.Errorf("Expected no pairs warmed, got %d", warmed)
+		}
+		if got := srv.Calls(); got != 0 {
+			t.Errorf("Expected no provider calls, got %d", got)
+		}
+		if got, _ := mr.Get("lock:provider_warmup:frankfurter"); got != "other-instance" {
+			t.Errorf("Expected the other instance's lock to be kept, got %q", got)
+		}
+
+		// Another provider's warm-up is not blocked.
+		if warmed := w.Warm(context.Background(), "exchangerate_host", cached); warmed != 2 {
+			t.Errorf("Expected 2 pairs warmed for another provider, got %d", warmed)
+		}
+	})
+
+	t.Run("stops when the provider fails again", func(t *testing.T) {
+		srv, cached, _, locker := newWarmupFixture(t)
+		srv.SetFaults(fakeprovider.Faults{ErrorRate: 1})
+		w := NewProviderWarmer(locker, warmupPairs, 0, zap.NewNop().Sugar())
+
+		if warmed := w.Warm(context.Background(), "frankfurter", cached); warmed != 0 {
+			t.Errorf("Expected no pairs warmed, got %d", warmed)
+		}
+		if got := srv.Calls(); got != 1 {
+			t.Errorf("Expected the warm-up to stop after 1 call, got %d", got)
+		}
+	})
+}
+
+func TestProviderWarmer_RunCancelled(t *testing.T) {
+	srv, cached, mr, locker := newWarmupFixture(t)
+	w := NewProviderWarmer(locker, warmupPairs, time.Hour, zap.NewNop().Sugar())
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- w.Run(ctx) }()
+
+	w.Trigger("frankfurter", cached)
+	deadline := time.Now().Add(5 * time.Second)
+	for srv.Calls() < 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the triggered warm-up to call the provider")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	cancel() // During the hour-long delay before the second pair.
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Expected Run to return nil, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected Run to return after cancellation")
+	}
+	if got := srv.Calls(); got != 1 {
+		t.Errorf("Expected 1 provider call, got %d", got)
+	}
+	if mr.Exists("lock:provider_warmup:frankfurter") {
+		t.Error("Expected the lock to be released after cancellation")
+	}
+}
@@ -0,0 +1,149 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"go.uber.org/zap"
+
+	"quoteservice/internal/clock"
+	"quoteservice/internal/logging/fields"
+	"quoteservice/internal/rediskey"
+	"quoteservice/internal/repository"
+	"quoteservice/internal/service"
+)
+
+// reconcileFailReason is the error recorded on updates given up on by a reconciliation.
+const reconcileFailReason = "task lost"
+
+// PendingLister lists PENDING updates; implemented by repository.QuoteRepository.
+type PendingLister interface {
+	ListPendingOlderThan(ctx context.Context, before time.Time, limit int) ([]repository.Quote, error)
+}
+
+// UpdateRecoverer requeues or fails updates whose task is gone; implemented by
+// *service.QuoteService.
+type UpdateRecoverer interface {
+	RequeueUpdate(ctx context.Context, rec repository.Quote) error
+	FailUpdate(ctx context.Context, updateID string, code repository.ErrorCode, reason string) error
+}
+
+// TaskLookup is the part of asynq.Inspector the Reconciler uses.
+type TaskLookup interface {
+	GetTaskInfo(queue, id string) (*asynq.TaskInfo, error)
+}
+
+// ReconcileConfig holds the thresholds of a Reconciler.
+type ReconcileConfig struct {
+	// Grace leaves younger PENDING updates alone; their task may still be on its way.
+	Grace time.Duration
+	// GiveUp fails updates older than this whose task is gone instead of requeueing them.
+	GiveUp    time.Duration
+	BatchSize int // Updates checked per run.
+}
+
+// ReconcileSummary counts what a reconciliation run did with the updates it checked.
+type ReconcileSummary struct {
+	Checked  int
+	Queued   int // Still had a task, in any state.
+	Requeued int
+	Failed   int // Had no task and were older than the give-up threshold.
+	Errors   int // Could not be checked, requeued or failed; left for the next run.
+}
+
+// Reconciler finds PENDING updates whose asynq task is gone, e.g. because the process
+// crashed between creating the update and enqueueing it or Redis lost the task, and
+// requeues them or, once they are too old to be worth fetching, fails them. Task IDs
+// are update IDs, so a task is found by looking the update ID up in every update queue.
+type Reconciler struct {
+	pending   PendingLister
+	recoverer UpdateRecoverer
+	tasks     TaskLookup
+	queues    map[string]int
+	cfg       ReconcileConfig
+	logger    *zap.SugaredLogger
+	clock     clock.Clock
+
+	mu sync.Mutex // Serializes runs, e.g. the startup one and an admin trigger.
+}
+
+// NewReconciler creates a Reconciler for the update queues of keys' namespace.
+func NewReconciler(pending PendingLister, recoverer UpdateRecoverer, tasks TaskLookup, keys rediskey.Namespace,
+	cfg ReconcileConfig, logger *zap.SugaredLogger) *Reconciler {
+	return &Reconciler{
+		pending:   pending,
+		recoverer: recoverer,
+		tasks:     tasks,
+		queues:    Queues(keys),
+		cfg:       cfg,
+		logger:    logger,
+		clock:     clock.Real,
+	}
+}
+
+// Reconcile checks up to BatchSize PENDING updates older than the grace period, oldest
+// first, and logs a summary. It only fails if the updates cannot be listed. An update
+// that finishes while it is checked may be requeued; its task then finds it completed
+// and does nothing.
+func (r *Reconciler) Reconcile(ctx context.Context) (ReconcileSummary, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var sum ReconcileSummary
+	now := r.clock.Now()
+	updates, err := r.pending.ListPendingOlderThan(ctx, now.Add(-r.cfg.Grace), r.cfg.BatchSize)
+	if err != nil {
+		r.logger.Errorw("Failed to list pending updates for reconciliation", "error", err)
+		return sum, err
+	}
+
+	for _, rec := range updates {
+		sum.Checked++
+		found, err := r.hasTask(rec.ID)
+		if err != nil {
+			r.logger.Warnw("Failed to look up update task", fields.UpdateID(rec.ID), "error", err)
+			sum.Errors++
+			continue
+		}
+		switch {
+		case found:
+			sum.Queued++
+		case now.Sub(rec.RequestedAt) > r.cfg.GiveUp:
+			err := r.recoverer.FailUpdate(ctx, rec.ID, repository.ErrorCodeTaskLost, reconcileFailReason)
+			switch {
+			case err == nil:
+				sum.Failed++
+			case !errors.Is(err, service.ErrAlreadyCompleted):
+				sum.Errors++
+			}
+		default:
+			if err := r.recoverer.RequeueUpdate(ctx, rec); err != nil {
+				sum.Errors++
+				continue
+			}
+			sum.Requeued++
+		}
+	}
+
+	r.logger.Infow("Reconciled pending updates", "checked", sum.Checked, "queued", sum.Queued,
+		"requeued", sum.Requeued, "failed", sum.Failed, "errors", sum.Errors)
+	return sum, nil
+}
+
+// hasTask reports whether any update queue holds a task with the update's ID.
+func (r *Reconciler) hasTask(updateID string) (bool, error) {
+	for queue := range r.queues {
+		_, err := r.tasks.GetTaskInfo(queue, updateID)
+		switch {
+		case err == nil:
+			return true, nil
+		case errors.Is(err, asynq.ErrTaskNotFound), errors.Is(err, asynq.ErrQueueNotFound):
+		default:
+			return false, err
+		}
+	}
+	return false, nil
+}
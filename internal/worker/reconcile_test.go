@@ -0,0 +1,120 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"go.uber.org/zap"
+
+	"quoteservice/internal/repository"
+	"quoteservice/internal/service"
+	"quoteservice/internal/testkit/fakeclock"
+)
+
+type fakePendingLister struct {
+	updates []repository.Quote
+	before  time.Time
+	limit   int
+}
+
+func (l *fakePendingLister) ListPendingOlderThan(_ context.Context, before time.Time, limit int) ([]repository.Quote, error) {
+	l.before, l.limit = before, limit
+	return l.updates, nil
+}
+
+type fakeRecoverer struct {
+	requeued []string
+	failed   map[string]string // Update ID -> "code: reason".
+	failErr  error
+}
+
+func (r *fakeRecoverer) RequeueUpdate(_ context.Context, rec repository.Quote) error {
+	r.requeued = append(r.requeued, rec.ID)
+	return nil
+}
+
+func (r *fakeRecoverer) FailUpdate(_ context.Context, updateID string, code repository.ErrorCode, reason string) error {
+	if r.failErr != nil {
+		return r.failErr
+	}
+	if r.failed == nil {
+		r.failed = map[string]string{}
+	}
+	r.failed[updateID] = string(code) + ": " + reason
+	return nil
+}
+
+// fakeTaskLookup holds tasks by queue and ID; errs overrides lookups of an ID.
+type fakeTaskLookup struct {
+	tasks map[string]string // ID -> queue
+	errs  map[string]error
+}
+
+func (l *fakeTaskLookup) GetTaskInfo(queue, id string) (*asynq.TaskInfo, error) {
+	if err := l.errs[id]; err != nil {
+		return nil, err
+	}
+	if l.tasks[id] == queue {
+		return &asynq.TaskInfo{ID: id, Queue: queue}, nil
+	}
+	return nil, asynq.ErrTaskNotFound
+}
+
+func TestReconciler_Reconcile(t *testing.T) {
+	now := time.Date(2025, 12, 1, 12, 0, 0, 0, time.UTC)
+	pending := func(id string, age time.Duration) repository.Quote {
+		return repository.Quote{ID: id, Base: "EUR", Quote: "MXN", Status: repository.StatusPending, RequestedAt: now.Add(-age)}
+	}
+	lister := &fakePendingLister{updates: []repository.Quote{
+		pending("queued", 2*time.Hour),
+		pending("high-queued", 5*time.Minute),
+		pending("lost-old", 2*time.Hour),
+		pending("lost-recent", 5*time.Minute),
+		pending("lookup-error", 5*time.Minute),
+	}}
+	recoverer := &fakeRecoverer{}
+	tasks := &fakeTaskLookup{
+		tasks: map[string]string{"queued": "default", "high-queued": "high"},
+		errs:  map[string]error{"lookup-error": errors.New("redis down")},
+	}
+	r := NewReconciler(lister, recoverer, tasks, "", ReconcileConfig{Grace: time.Minute, GiveUp: time.Hour, BatchSize: 100},
+		zap.NewNop().Sugar())
+	r.clock = fakeclock.New(now)
+
+	sum, err := r.Reconcile(context.Background())
+	if err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	want := ReconcileSummary{Checked: 5, Queued: 2, Requeued: 1, Failed: 1, Errors: 1}
+	if sum != want {
+		t.Errorf("Expected summary %+v, got %+v", want, sum)
+	}
+	if !lister.before.Equal(now.Add(-time.Minute)) || lister.limit != 100 {
+		t.Errorf("Expected updates listed before %v with limit 100, got %v and %d", now.Add(-time.Minute), lister.before, lister.limit)
+	}
+	if len(recoverer.requeued) != 1 || recoverer.requeued[0] != "lost-recent" {
+		t.Errorf("Expected only lost-recent requeued, got %v", recoverer.requeued)
+	}
+	if want := "task_lost: " + reconcileFailReason; len(recoverer.failed) != 1 || recoverer.failed["lost-old"] != want {
+		t.Errorf("Expected only lost-old failed with %q, got %v", want, recoverer.failed)
+	}
+}
+
+func TestReconciler_AlreadyCompleted(t *testing.T) {
+	now := time.Now()
+	lister := &fakePendingLister{updates: []repository.Quote{{ID: "done", RequestedAt: now.Add(-2 * time.Hour)}}}
+	recoverer := &fakeRecoverer{failErr: service.ErrAlreadyCompleted}
+	r := NewReconciler(lister, recoverer, &fakeTaskLookup{}, "", ReconcileConfig{Grace: time.Minute, GiveUp: time.Hour, BatchSize: 10},
+		zap.NewNop().Sugar())
+
+	sum, err := r.Reconcile(context.Background())
+	if err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	if sum != (ReconcileSummary{Checked: 1}) {
+		t.Errorf("Expected an update completed meanwhile to be skipped, got %+v", sum)
+	}
+}
@@ -0,0 +1,93 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"go.uber.org/zap"
+
+	"quoteservice/internal/repository"
+	"quoteservice/internal/service"
+)
+
+// memoryQuoteRepo keeps a single update record in memory and applies the status
+// transitions ProcessUpdate makes with the same version checks as Postgres.
+type memoryQuoteRepo struct {
+	repository.QuoteRepository
+	mu  sync.Mutex
+	rec repository.Quote
+}
+
+func (r *memoryQuoteRepo) GetByID(_ context.Context, id string) (*repository.Quote, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if id != r.rec.ID {
+		return nil, nil
+	}
+	rec := r.rec
+	return &rec, nil
+}
+
+func (r *memoryQuoteRepo) transition(version int64, status repository.Status) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.rec.Version != version {
+		return &repository.VersionConflictError{ID: r.rec.ID, Expected: version, Actual: r.rec.Version, Status: r.rec.Status}
+	}
+	r.rec.Status = status
+	r.rec.Version++
+	return nil
+}
+
+func (r *memoryQuoteRepo) MarkRunning(_ context.Context, _ string, version int64, _ string) error {
+	return r.transition(version, repository.StatusRunning)
+}
+
+func (r *memoryQuoteRepo) MarkSuccess(_ context.Context, _ string, version int64, _ string, _ time.Time) error {
+	return r.transition(version, repository.StatusSuccess)
+}
+
+func (r *memoryQuoteRepo) MarkFailed(_ context.Context, _ string, version int64, _ repository.ErrorCode, _ string) error {
+	return r.transition(version, repository.StatusFailed)
+}
+
+func (r *memoryQuoteRepo) RecordFetch(context.Context, string, repository.Fetch) error {
+	return nil
+}
+
+type countingProvider struct{ calls int }
+
+func (p *countingProvider) GetRate(context.Context, string, string) (string, time.Time, error) {
+	p.calls++
+	return "18.7", time.Now(), nil
+}
+
+func TestQuoteUpdateHandler_ReplayedTask(t *testing.T) {
+	repo := &memoryQuoteRepo{rec: repository.Quote{
+		ID: "update-1", Base: "EUR", Quote: "MXN", Status: repository.StatusPending, Version: repository.InitialVersion,
+	}}
+	prov := &countingProvider{}
+	svc := service.NewQuoteService(service.QuoteServiceDeps{Repo: repo, Provider: prov})
+	h := NewQuoteUpdateHandler(svc, zap.NewNop().Sugar())
+
+	payload, err := json.Marshal(service.UpdateQuotePayload{UpdateID: "update-1", Pair: service.Pair{Base: "EUR", Quote: "MXN"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Asynq delivers at least once, so the same task can run again after it succeeded.
+	for _, run := range []string{"first delivery", "replay"} {
+		if err := h(context.Background(), asynq.NewTask(service.TaskTypeUpdateQuote, payload)); err != nil {
+			t.Fatalf("%s: %v", run, err)
+		}
+		if prov.calls != 1 {
+			t.Fatalf("After the %s expected 1 provider call, got %d", run, prov.calls)
+		}
+		if repo.rec.Status != repository.StatusSuccess {
+			t.Fatalf("After the %s expected SUCCESS, got %s", run, repo.rec.Status)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"quoteservice/internal/clock"
+	"quoteservice/internal/lock"
+	"quoteservice/internal/metrics"
+)
+
+// retentionLockJob names the lock that keeps retention runs to one instance.
+const retentionLockJob = "retention"
+
+// RetentionTable is a table the retention job prunes with its own horizon.
+type RetentionTable struct {
+	Name   string // Table name, the table label of metrics.RetentionRowsTotal.
+	MaxAge time.Duration
+	// Batch archives or deletes up to limit rows older than cutoff and returns how
+	// many, e.g. repository.QuoteArchiver.ArchiveBatch.
+	Batch func(ctx context.Context, cutoff time.Time, limit int) (int64, error)
+}
+
+// RetentionJob periodically archives or deletes the rows of each table older than its
+// horizon, in batches so no single statement holds locks on many rows.
+type RetentionJob struct {
+	tables    []RetentionTable
+	batchSize int
+	interval  time.Duration
+	locker    *lock.Locker
+	logger    *zap.SugaredLogger
+	clock     clock.Clock
+}
+
+// NewRetentionJob creates a RetentionJob for tables that runs every interval. With a
+// locker, each interval's run happens on only one of the instances sharing its Redis.
+func NewRetentionJob(tables []RetentionTable, batchSize int, interval time.Duration,
+	locker *lock.Locker, logger *zap.SugaredLogger) *RetentionJob {
+	return &RetentionJob{
+		tables:    tables,
+		batchSize: batchSize,
+		interval:  interval,
+		locker:    locker,
+		logger:    logger,
+		clock:     clock.Real,
+	}
+}
+
+// Run prunes immediately and then every interval until ctx is cancelled. Failures are
+// logged and retried on the next run; Run always returns nil.
+func (j *RetentionJob) Run(ctx context.Context) error {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+	for {
+		rows, err := j.runLocked(ctx)
+		switch {
+		case err != nil && ctx.Err() == nil:
+			j.logger.Errorw("Retention run failed", "rows", rows, "error", err)
+		case rows.total() > 0:
+			j.logger.Infow("Retention run finished", "rows", rows)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// RetentionRows is the number of rows a retention run archived or deleted, by table.
+type RetentionRows map[string]int64
+
+func (r RetentionRows) total() int64 {
+	var n int64
+	for _, rows := range r {
+		n += rows
+	}
+	return n
+}
+
+// runLocked runs RunOnce under the retention lock, which is kept for most of the
+// interval so the other instances skip their runs in it; it is released early enough for
+// the next tick of this instance to take it again. A run skipped because another instance
+// holds the lock prunes nothing.
+func (j *RetentionJob) runLocked(ctx context.Context) (RetentionRows, error) {
+	if j.locker == nil {
+		return j.RunOnce(ctx)
+	}
+	rows := RetentionRows{}
+	err := j.locker.Do(ctx, retentionLockJob, j.interval*9/10, func(ctx context.Context) (err error) {
+		rows, err = j.RunOnce(ctx)
+		return err
+	})
+	if errors.Is(err, lock.ErrNotAcquired) {
+		return RetentionRows{}, nil
+	}
+	return rows, err
+}
+
+// RunOnce prunes every table up to its own cutoff, one batch at a time, and returns how
+// many rows each lost. A failing table does not stop the others; the errors are joined.
+func (j *RetentionJob) RunOnce(ctx context.Context) (RetentionRows, error) {
+	now := j.clock.Now()
+	rows := make(RetentionRows, len(j.tables))
+	var errs []error
+	for _, table := range j.tables {
+		if err := ctx.Err(); err != nil {
+			return rows, err
+		}
+		n, err := j.pruneTable(ctx, table, now.Add(-table.MaxAge))
+		rows[table.Name] = n
+		metrics.RetentionRowsTotal.WithLabelValues(table.Name).Add(float64(n))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", table.Name, err))
+		}
+	}
+	return rows, errors.Join(errs...)
+}
+
+// pruneTable runs table's batches until one comes back short.
+func (j *RetentionJob) pruneTable(ctx context.Context, table RetentionTable, cutoff time.Time) (int64, error) {
+	var total int64
+	for {
+		n, err := table.Batch(ctx, cutoff, j.batchSize)
+		total += n
+		if err != nil {
+			return total, err
+		}
+		if n < int64(j.batchSize) {
+			return total, nil
+		}
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	"quoteservice/internal/lock"
+	"quoteservice/internal/metrics"
+	"quoteservice/internal/testkit/fakeclock"
+)
+
+type fakeArchiver struct {
+	batches []int64 // Rows archived by each successive call.
+	err     error
+	cutoffs []time.Time
+	limits  []int
+}
+
+func (f *fakeArchiver) ArchiveBatch(_ context.Context, cutoff time.Time, limit int) (int64, error) {
+	f.cutoffs = append(f.cutoffs, cutoff)
+	f.limits = append(f.limits, limit)
+	if len(f.batches) == 0 {
+		return 0, f.err
+	}
+	n := f.batches[0]
+	f.batches = f.batches[1:]
+	return n, nil
+}
+
+// quotesTable prunes the quotes table with archiver.
+func quotesTable(archiver *fakeArchiver, maxAge time.Duration) []RetentionTable {
+	return []RetentionTable{{Name: "quotes", MaxAge: maxAge, Batch: archiver.ArchiveBatch}}
+}
+
+func TestRetentionJob_RunOnce_BatchesUntilShortBatch(t *testing.T) {
+	now := time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC)
+	archiver := &fakeArchiver{batches: []int64{100, 100, 42}}
+	job := NewRetentionJob(quotesTable(archiver, 90*24*time.Hour), 100, time.Hour, nil, zap.NewNop().Sugar())
+	job.clock = fakeclock.New(now)
+
+	rows, err := job.RunOnce(context.Background())
+	if err != nil {
+		t.Fatalf("RunOnce: %v", err)
+	}
+	if rows["quotes"] != 242 {
+		t.Errorf("expected 242 archived, got %v", rows)
+	}
+	if len(archiver.cutoffs) != 3 {
+		t.Fatalf("expected 3 batches, got %d", len(archiver.cutoffs))
+	}
+	wantCutoff := now.Add(-90 * 24 * time.Hour)
+	for i, c := range archiver.cutoffs {
+		if !c.Equal(wantCutoff) || archiver.limits[i] != 100 {
+			t.Errorf("batch %d: cutoff %v limit %d, want %v and 100", i, c, archiver.limits[i], wantCutoff)
+		}
+	}
+}
+
+func TestRetentionJob_RunOnce_StopsOnError(t *testing.T) {
+	boom := errors.New("db down")
+	archiver := &fakeArchiver{batches: []int64{10}, err: boom}
+	job := NewRetentionJob(quotesTable(archiver, time.Hour), 10, time.Hour, nil, zap.NewNop().Sugar())
+
+	rows, err := job.RunOnce(context.Background())
+	if !errors.Is(err, boom) {
+		t.Fatalf("expected %v, got %v", boom, err)
+	}
+	if rows["quotes"] != 10 {
+		t.Errorf("expected the 10 rows archived before the error, got %v", rows)
+	}
+}
+
+func TestRetentionJob_RunOnce_TablesAreIndependent(t *testing.T) {
+	metrics.RetentionRowsTotal.Reset()
+	t.Cleanup(metrics.RetentionRowsTotal.Reset)
+	now := time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC)
+	boom := errors.New("db down")
+	quotes := &fakeArchiver{batches: []int64{10}, err: boom}
+	events := &fakeArchiver{batches: []int64{10, 10, 4}}
+	job := NewRetentionJob([]RetentionTable{
+		{Name: "quotes", MaxAge: 90 * 24 * time.Hour, Batch: quotes.ArchiveBatch},
+		{Name: "quote_status_events", MaxAge: 30 * 24 * time.Hour, Batch: events.ArchiveBatch},
+	}, 10, time.Hour, nil, zap.NewNop().Sugar())
+	job.clock = fakeclock.New(now)
+
+	rows, err := job.RunOnce(context.Background())
+	if !errors.Is(err, boom) {
+		t.Fatalf("expected %v, got %v", boom, err)
+	}
+	if rows["quotes"] != 10 || rows["quote_status_events"] != 24 {
+		t.Errorf("expected 10 quotes and 24 events, got %v", rows)
+	}
+	if len(events.cutoffs) != 3 || !events.cutoffs[0].Equal(now.Add(-30*24*time.Hour)) ||
+		!quotes.cutoffs[0].Equal(now.Add(-90*24*time.Hour)) {
+		t.Errorf("expected each table to use its own cutoff, got quotes %v events %v", quotes.cutoffs, events.cutoffs)
+	}
+	if got := testutil.ToFloat64(metrics.RetentionRowsTotal.WithLabelValues("quote_status_events")); got != 24 {
+		t.Errorf("expected 24 counted events, got %v", got)
+	}
+	if got := testutil.ToFloat64(metrics.RetentionRowsTotal.WithLabelValues("quotes")); got != 10 {
+		t.Errorf("expected 10 counted quotes, got %v", got)
+	}
+}
+
+func TestRetentionJob_RunLocked(t *testing.T) {
+	mr := miniredis.RunT(t)
+	locker := lock.NewLocker(redis.NewClient(&redis.Options{Addr: mr.Addr()}), "", lock.DefaultTTL, zap.NewNop().Sugar())
+
+	archiver := &fakeArchiver{batches: []int64{5}}
+	job := NewRetentionJob(quotesTable(archiver, time.Hour), 10, time.Hour, locker, zap.NewNop().Sugar())
+	rows, err := job.runLocked(context.Background())
+	if err != nil || rows["quotes"] != 5 {
+		t.Fatalf("expected 5 archived, got %v (%v)", rows, err)
+	}
+	// Kept for most of the interval, so the other instances skip this interval's run.
+	if ttl := mr.TTL("lock:retention"); ttl < 50*time.Minute || ttl > 54*time.Minute {
+		t.Errorf("expected the lock to be kept for about 54m, got %v", ttl)
+	}
+
+	other := NewRetentionJob(quotesTable(archiver, time.Hour), 10, time.Hour, locker, zap.NewNop().Sugar())
+	rows, err = other.runLocked(context.Background())
+	if err != nil || len(rows) != 0 {
+		t.Errorf("expected the locked run to be skipped, got %v (%v)", rows, err)
+	}
+	if len(archiver.cutoffs) != 1 {
+		t.Errorf("expected 1 archive call, got %d", len(archiver.cutoffs))
+	}
+}
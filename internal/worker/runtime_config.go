@@ -0,0 +1,63 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"quoteservice/internal/rediskey"
+)
+
+// RuntimeConfigKey is the Redis hash holding worker settings changed at runtime.
+const RuntimeConfigKey = "runtime_config"
+
+// RuntimeConfigStore persists PoolConfig overrides so they survive restarts. It should
+// use the durable (asynq) Redis: the cache instance may evict the key.
+type RuntimeConfigStore struct {
+	client *redis.Client
+	key    string
+}
+
+// NewRuntimeConfigStore creates a RuntimeConfigStore backed by client, keeping the
+// settings under RuntimeConfigKey in keys' namespace.
+func NewRuntimeConfigStore(client *redis.Client, keys rediskey.Namespace) *RuntimeConfigStore {
+	return &RuntimeConfigStore{client: client, key: keys.Key(RuntimeConfigKey)}
+}
+
+// Load returns the stored settings, or ok=false if none were saved.
+func (s *RuntimeConfigStore) Load(ctx context.Context) (cfg PoolConfig, ok bool, err error) {
+	vals, err := s.client.HGetAll(ctx, s.key).Result()
+	if err != nil {
+		return PoolConfig{}, false, fmt.Errorf("load %s: %w", s.key, err)
+	}
+	if len(vals) == 0 {
+		return PoolConfig{}, false, nil
+	}
+
+	concurrency, cErr := strconv.Atoi(vals["concurrency"])
+	timeoutSec, tErr := strconv.Atoi(vals["task_timeout_sec"])
+	if err := errors.Join(cErr, tErr); err != nil {
+		return PoolConfig{}, false, fmt.Errorf("parse %s: %w", s.key, err)
+	}
+	cfg = PoolConfig{Concurrency: concurrency, TaskTimeout: time.Duration(timeoutSec) * time.Second}
+	if err := cfg.Validate(); err != nil {
+		return PoolConfig{}, false, fmt.Errorf("invalid %s: %w", s.key, err)
+	}
+	return cfg, true, nil
+}
+
+// Save stores cfg, replacing any previous settings.
+func (s *RuntimeConfigStore) Save(ctx context.Context, cfg PoolConfig) error {
+	err := s.client.HSet(ctx, s.key,
+		"concurrency", cfg.Concurrency,
+		"task_timeout_sec", int(cfg.TaskTimeout/time.Second),
+	).Err()
+	if err != nil {
+		return fmt.Errorf("save %s: %w", s.key, err)
+	}
+	return nil
+}
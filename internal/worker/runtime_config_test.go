@@ -0,0 +1,44 @@
+package worker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestRuntimeConfigStore(t *testing.T) {
+	mr := miniredis.RunT(t)
+	store := NewRuntimeConfigStore(redis.NewClient(&redis.Options{Addr: mr.Addr()}), "")
+	ctx := context.Background()
+
+	if _, ok, err := store.Load(ctx); err != nil || ok {
+		t.Fatalf("Expected no stored config, got ok=%v err=%v", ok, err)
+	}
+
+	want := PoolConfig{Concurrency: 5, TaskTimeout: 60 * time.Second}
+	if err := store.Save(ctx, want); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if got := mr.HGet(RuntimeConfigKey, "concurrency"); got != "5" {
+		t.Errorf("Expected stored concurrency 5, got %q", got)
+	}
+	if got := mr.HGet(RuntimeConfigKey, "task_timeout_sec"); got != "60" {
+		t.Errorf("Expected stored task_timeout_sec 60, got %q", got)
+	}
+
+	got, ok, err := store.Load(ctx)
+	if err != nil || !ok {
+		t.Fatalf("Load: ok=%v err=%v", ok, err)
+	}
+	if got != want {
+		t.Errorf("Expected %+v, got %+v", want, got)
+	}
+
+	mr.HSet(RuntimeConfigKey, "concurrency", "0")
+	if _, _, err := store.Load(ctx); err == nil {
+		t.Error("Expected error for invalid stored config")
+	}
+}
@@ -0,0 +1,80 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"quoteservice/internal/logging/fields"
+	"quoteservice/internal/provider"
+	"quoteservice/internal/service"
+)
+
+// RateApplier persists rates received from a streaming provider.
+type RateApplier interface {
+	ApplyStreamedRate(ctx context.Context, pair service.Pair, rate string, receivedAt time.Time) error
+}
+
+// StreamingWorker consumes a StreamingRatesProvider and applies each pushed rate.
+type StreamingWorker struct {
+	provider     provider.StreamingRatesProvider
+	applier      RateApplier
+	pairs        []provider.PairKey
+	restartDelay time.Duration
+	logger       *zap.SugaredLogger
+}
+
+// NewStreamingWorker creates a StreamingWorker. restartDelay is how long to wait before
+// re-subscribing when the provider stream ends on its own (e.g. reconnects exhausted).
+func NewStreamingWorker(
+	prov provider.StreamingRatesProvider,
+	applier RateApplier,
+	pairs []provider.PairKey,
+	restartDelay time.Duration,
+	logger *zap.SugaredLogger) *StreamingWorker {
+	return &StreamingWorker{
+		provider:     prov,
+		applier:      applier,
+		pairs:        pairs,
+		restartDelay: restartDelay,
+		logger:       logger,
+	}
+}
+
+// Run streams and applies rates until ctx is cancelled. It always returns nil so that a
+// flaky upstream never takes the rest of the application down.
+func (w *StreamingWorker) Run(ctx context.Context) error {
+	for {
+		events, err := w.provider.StreamRates(ctx, w.pairs)
+		if err != nil {
+			w.logger.Errorw("Failed to start rate stream", "error", err)
+		} else {
+			w.logger.Infow("Rate stream started", "pairs", len(w.pairs))
+			w.consume(ctx, events)
+		}
+
+		if ctx.Err() != nil {
+			return nil
+		}
+		w.logger.Warnw("Rate stream ended, restarting", "delay", w.restartDelay)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(w.restartDelay):
+		}
+	}
+}
+
+func (w *StreamingWorker) consume(ctx context.Context, events <-chan provider.RateEvent) {
+	for ev := range events {
+		if ev.Error != nil {
+			w.logger.Warnw("Rate stream error", "error", ev.Error)
+			continue
+		}
+		pair := service.Pair{Base: ev.Base, Quote: ev.Quote}
+		if err := w.applier.ApplyStreamedRate(ctx, pair, ev.Rate, ev.ReceivedAt); err != nil {
+			w.logger.Errorw("Failed to apply streamed rate", fields.Pair(pair.Base, pair.Quote), "error", err)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"quoteservice/internal/provider"
+	"quoteservice/internal/service"
+)
+
+type fakeStream struct {
+	mu      sync.Mutex
+	streams [][]provider.RateEvent
+	starts  int
+}
+
+func (f *fakeStream) StreamRates(ctx context.Context, _ []provider.PairKey) (<-chan provider.RateEvent, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.starts++
+	if len(f.streams) == 0 {
+		return nil, errors.New("no more streams")
+	}
+	events := f.streams[0]
+	f.streams = f.streams[1:]
+
+	ch := make(chan provider.RateEvent, len(events))
+	for _, ev := range events {
+		ch <- ev
+	}
+	close(ch)
+	return ch, nil
+}
+
+func (f *fakeStream) Close() error { return nil }
+
+type appliedRate struct {
+	base, quote, rate string
+}
+
+type recordingApplier struct {
+	mu      sync.Mutex
+	applied []appliedRate
+}
+
+func (r *recordingApplier) ApplyStreamedRate(_ context.Context, pair service.Pair, rate string, _ time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.applied = append(r.applied, appliedRate{pair.Base, pair.Quote, rate})
+	return nil
+}
+
+func (r *recordingApplier) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.applied)
+}
+
+func TestStreamingWorker_AppliesEventsAndRestarts(t *testing.T) {
+	stream := &fakeStream{streams: [][]provider.RateEvent{
+		{
+			{Base: "EUR", Quote: "USD", Rate: "1.08", ReceivedAt: time.Now()},
+			{Error: errors.New("stream interrupted")},
+		},
+		{
+			{Base: "EUR", Quote: "USD", Rate: "1.09", ReceivedAt: time.Now()},
+		},
+	}}
+	applier := &recordingApplier{}
+	w := NewStreamingWorker(stream, applier, []provider.PairKey{{Base: "EUR", Quote: "USD"}}, time.Millisecond, zap.NewNop().Sugar())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- w.Run(ctx) }()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for applier.count() < 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected 2 applied rates, got %d", applier.count())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+
+	if err := <-done; err != nil {
+		t.Fatalf("Expected nil from Run, got %v", err)
+	}
+	if applier.applied[0].rate != "1.08" || applier.applied[1].rate != "1.09" {
+		t.Errorf("Unexpected applied rates: %+v", applier.applied)
+	}
+}
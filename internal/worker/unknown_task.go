@@ -0,0 +1,57 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/hibiken/asynq"
+	"go.uber.org/zap"
+
+	"quoteservice/internal/metrics"
+)
+
+// ErrUnknownTaskDeferred is returned for a task type this worker cannot handle when
+// such tasks are left for a newer worker. IsFailure reports false for it, so asynq
+// retries the task without using up its retries.
+var ErrUnknownTaskDeferred = errors.New("unknown task type deferred")
+
+// IsFailure is the asynq.Config.IsFailure of the worker server: deferred unknown
+// tasks are not failures.
+func IsFailure(err error) bool {
+	return err != nil && !errors.Is(err, ErrUnknownTaskDeferred)
+}
+
+// WithFallback routes tasks whose type matches a pattern registered on mux to mux,
+// and every other task to fallback.
+func WithFallback(mux *asynq.ServeMux, fallback asynq.Handler) asynq.Handler {
+	return asynq.HandlerFunc(func(ctx context.Context, t *asynq.Task) error {
+		h, pattern := mux.Handler(t)
+		if pattern == "" {
+			return fallback.ProcessTask(ctx, t)
+		}
+		return h.ProcessTask(ctx, t)
+	})
+}
+
+// NewUnknownTaskHandler returns the fallback for task types no handler is registered
+// for, e.g. a type enqueued by a newer API instance during a rolling deploy. It logs
+// the type and payload size and counts the task. By default it returns
+// asynq.SkipRetry, so the task is archived once instead of retried until exhaustion;
+// with deferTasks it returns ErrUnknownTaskDeferred so the task stays queued until a
+// worker that knows the type picks it up.
+func NewUnknownTaskHandler(deferTasks bool, logger *zap.SugaredLogger) asynq.Handler {
+	return asynq.HandlerFunc(func(_ context.Context, t *asynq.Task) error {
+		action := "skipped"
+		if deferTasks {
+			action = "deferred"
+		}
+		metrics.UnknownTasksTotal.WithLabelValues(t.Type(), action).Inc()
+		logger.Warnw("Unknown task type", "type", t.Type(), "payload_bytes", len(t.Payload()), "action", action)
+
+		if deferTasks {
+			return fmt.Errorf("%w: %q", ErrUnknownTaskDeferred, t.Type())
+		}
+		return fmt.Errorf("no handler for task type %q: %w", t.Type(), asynq.SkipRetry)
+	})
+}
@@ -0,0 +1,108 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/hibiken/asynq"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.uber.org/zap"
+
+	"quoteservice/internal/metrics"
+)
+
+// runFallbackOnly starts a server whose mux has no handlers, so every task goes to
+// the unknown-task fallback, and enqueues one task of taskType.
+func runFallbackOnly(t *testing.T, taskType string, deferTasks bool) *asynq.Inspector {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	redisOpt := asynq.RedisClientOpt{Addr: mr.Addr()}
+
+	srv := asynq.NewServer(redisOpt, asynq.Config{
+		Concurrency:       1,
+		TaskCheckInterval: 10 * time.Millisecond,
+		IsFailure:         IsFailure,
+	})
+	handler := WithFallback(asynq.NewServeMux(), NewUnknownTaskHandler(deferTasks, zap.NewNop().Sugar()))
+	if err := srv.Start(handler); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	t.Cleanup(srv.Shutdown)
+
+	client := asynq.NewClient(redisOpt)
+	t.Cleanup(func() { _ = client.Close() })
+	if _, err := client.Enqueue(asynq.NewTask(taskType, []byte(`{"pair":"EUR/USD"}`)), asynq.MaxRetry(5)); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+
+	inspector := asynq.NewInspector(redisOpt)
+	t.Cleanup(func() { _ = inspector.Close() })
+	return inspector
+}
+
+func waitForTask(t *testing.T, list func() ([]*asynq.TaskInfo, error)) *asynq.TaskInfo {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		tasks, err := list()
+		if err != nil {
+			t.Fatalf("list tasks: %v", err)
+		}
+		if len(tasks) == 1 {
+			return tasks[0]
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatal("task did not reach the expected state")
+	return nil
+}
+
+func TestUnknownTask_SkipsRetry(t *testing.T) {
+	const taskType = "quote:test_skip"
+	inspector := runFallbackOnly(t, taskType, false)
+
+	task := waitForTask(t, func() ([]*asynq.TaskInfo, error) { return inspector.ListArchivedTasks("default") })
+	if task.Type != taskType {
+		t.Errorf("Expected type %s, got %s", taskType, task.Type)
+	}
+	if task.Retried != 0 {
+		t.Errorf("Expected no retries, got %d", task.Retried)
+	}
+	if got := testutil.ToFloat64(metrics.UnknownTasksTotal.WithLabelValues(taskType, "skipped")); got != 1 {
+		t.Errorf("Expected unknown_tasks_total 1, got %v", got)
+	}
+}
+
+func TestUnknownTask_Deferred(t *testing.T) {
+	const taskType = "quote:test_defer"
+	inspector := runFallbackOnly(t, taskType, true)
+
+	task := waitForTask(t, func() ([]*asynq.TaskInfo, error) { return inspector.ListRetryTasks("default") })
+	if task.Retried != 0 {
+		t.Errorf("Expected the deferral not to count as a retry, got %d", task.Retried)
+	}
+	if got := testutil.ToFloat64(metrics.UnknownTasksTotal.WithLabelValues(taskType, "deferred")); got != 1 {
+		t.Errorf("Expected unknown_tasks_total 1, got %v", got)
+	}
+}
+
+func TestWithFallback_RoutesRegisteredTypes(t *testing.T) {
+	mux := asynq.NewServeMux()
+	var handled string
+	mux.HandleFunc("quote:update", func(_ context.Context, t *asynq.Task) error {
+		handled = t.Type()
+		return nil
+	})
+	errFallback := errors.New("fallback")
+	h := WithFallback(mux, asynq.HandlerFunc(func(context.Context, *asynq.Task) error { return errFallback }))
+
+	if err := h.ProcessTask(context.Background(), asynq.NewTask("quote:update", nil)); err != nil || handled != "quote:update" {
+		t.Errorf("Expected registered handler, got err=%v handled=%q", err, handled)
+	}
+	if err := h.ProcessTask(context.Background(), asynq.NewTask("quote:other", nil)); !errors.Is(err, errFallback) {
+		t.Errorf("Expected fallback, got %v", err)
+	}
+}
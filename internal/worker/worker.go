@@ -4,8 +4,15 @@ package worker
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"sync/atomic"
 	"time"
 
+	"quoteservice/internal/config"
+	"quoteservice/internal/logging/fields"
+	"quoteservice/internal/provider"
+	"quoteservice/internal/rediskey"
 	"quoteservice/internal/service"
 
 	"github.com/hibiken/asynq"
@@ -21,45 +28,112 @@ func NewQuoteUpdateHandler(svc service.QuoteServiceInterface, logger *zap.Sugare
 			return nil
 		}
 
-		err := svc.ProcessUpdate(ctx, payload.UpdateID, payload.Base, payload.Quote)
-		if err != nil {
-			logger.Errorw("Task processing failed", "update_id", payload.UpdateID, "error", err)
+		err := svc.ProcessUpdate(ctx, payload)
+		switch {
+		case errors.Is(err, service.ErrAlreadyCompleted):
+			// A duplicate or replayed task for an update another task already finished.
+			logger.Infow("Update already completed, skipping task", fields.UpdateID(payload.UpdateID), "error", err)
+			return nil
+		case errors.Is(err, service.ErrUpdateConflict):
+			// Retrying cannot help: another task owns the update.
+			logger.Warnw("Dropping task for update changed elsewhere", fields.UpdateID(payload.UpdateID), "error", err)
+			return fmt.Errorf("%w: %w", err, asynq.SkipRetry)
+		case errors.Is(err, service.ErrNotFound):
+			// The record was deleted, e.g. by retention, after the task was enqueued.
+			logger.Warnw("Dropping task for missing update", fields.UpdateID(payload.UpdateID), "error", err)
+			return fmt.Errorf("%w: %w", err, asynq.SkipRetry)
+		case errors.Is(err, provider.ErrSourceMismatch):
+			// The provider's plan ignores the requested base; only reconfiguring helps.
+			logger.Errorw("Provider answered for another base currency, failing task", fields.UpdateID(payload.UpdateID), "error", err)
+			return fmt.Errorf("%w: %w", err, asynq.SkipRetry)
+		case errors.Is(err, service.ErrUnknownProvider):
+			// The forced provider was removed from the configuration after enqueueing.
+			logger.Warnw("Forced provider is not configured, failing task", fields.UpdateID(payload.UpdateID), "error", err)
+			return fmt.Errorf("%w: %w", err, asynq.SkipRetry)
+		case err != nil:
+			logger.Errorw("Task processing failed", fields.UpdateID(payload.UpdateID), "error", err)
 			return err
 		}
 
-		logger.Infow("Task completed", "update_id", payload.UpdateID)
+		logger.Infow("Task completed", fields.UpdateID(payload.UpdateID))
 		return nil
 	}
 }
 
+// ErrEnqueueTimeout is returned when a task could not be enqueued within the enqueue timeout.
+var ErrEnqueueTimeout = errors.New("enqueue timed out")
+
 // AsynqEnqueuer is responsible for enqueuing tasks to an Asynq queue with specific configurations for retries and timeouts.
 type AsynqEnqueuer struct {
-	client   *asynq.Client
-	maxRetry int
-	timeout  time.Duration
+	client         *asynq.Client
+	maxRetry       int
+	timeout        atomic.Int64 // time.Duration; changed by SetTaskTimeout.
+	enqueueTimeout time.Duration
+	keys           rediskey.Namespace
 }
 
-// NewAsynqEnqueuer creates a new AsynqEnqueuer with the given client, retry limit, and task timeout duration.
-func NewAsynqEnqueuer(client *asynq.Client, maxRetry int, timeout time.Duration) *AsynqEnqueuer {
-	return &AsynqEnqueuer{
-		client:   client,
-		maxRetry: maxRetry,
-		timeout:  timeout,
+// NewAsynqEnqueuer creates a new AsynqEnqueuer with the given client, retry limit, task timeout
+// duration and the maximum time a single enqueue may take. Tasks go to queues in keys' namespace.
+func NewAsynqEnqueuer(client *asynq.Client, maxRetry int, timeout, enqueueTimeout time.Duration, keys rediskey.Namespace) *AsynqEnqueuer {
+	e := &AsynqEnqueuer{
+		client:         client,
+		maxRetry:       maxRetry,
+		enqueueTimeout: enqueueTimeout,
+		keys:           keys,
 	}
+	e.timeout.Store(int64(timeout))
+	return e
+}
+
+// SetTaskTimeout changes the timeout attached to tasks enqueued from now on.
+func (e *AsynqEnqueuer) SetTaskTimeout(d time.Duration) {
+	e.timeout.Store(int64(d))
 }
 
-// EnqueueUpdateTask enqueues a quote update task with the specified payload and context using Asynq.
-func (e *AsynqEnqueuer) EnqueueUpdateTask(ctx context.Context, payload service.UpdateQuotePayload) error {
+// EnqueueUpdateTask enqueues a quote update task with the specified payload and context using Asynq,
+// on opts.Queue, or the default queue if unset, in the enqueuer's namespace. It returns ErrEnqueueTimeout if Redis does not accept the task within the
+// enqueue timeout.
+//
+// The task ID is the update ID, so whether an update still has a task can be looked up,
+// and enqueueing an update that already has one is a no-op.
+func (e *AsynqEnqueuer) EnqueueUpdateTask(ctx context.Context, payload service.UpdateQuotePayload, opts service.TaskOptions) error {
 	data, err := json.Marshal(payload)
 	if err != nil {
 		return err
 	}
 
+	queue := opts.Queue
+	if queue == "" {
+		queue = config.PriorityDefault
+	}
 	task := asynq.NewTask(service.TaskTypeUpdateQuote, data,
 		asynq.MaxRetry(e.maxRetry),
-		asynq.Timeout(e.timeout),
+		asynq.Timeout(time.Duration(e.timeout.Load())),
+		asynq.Queue(e.keys.Queue(queue)),
+		asynq.TaskID(payload.UpdateID),
 	)
 
-	_, err = e.client.EnqueueContext(ctx, task)
-	return err
+	ctx, cancel := context.WithTimeout(ctx, e.enqueueTimeout)
+	defer cancel()
+
+	// The Asynq client does not reliably abort a blocked Redis write on cancellation,
+	// so wait for the result in a separate goroutine and give up once ctx expires.
+	done := make(chan error, 1)
+	go func() {
+		_, err := e.client.EnqueueContext(ctx, task)
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if errors.Is(err, asynq.ErrTaskIDConflict) {
+			return nil
+		}
+		return err
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return ErrEnqueueTimeout
+		}
+		return ctx.Err()
+	}
 }
@@ -0,0 +1,247 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/hibiken/asynq"
+	"go.uber.org/zap"
+
+	"quoteservice/internal/provider"
+	"quoteservice/internal/service"
+)
+
+func TestAsynqEnqueuer_EnqueueUpdateTask(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("failed to start miniredis: %v", err)
+	}
+	defer mr.Close()
+
+	redisOpt := asynq.RedisClientOpt{Addr: mr.Addr()}
+	client := asynq.NewClient(redisOpt)
+	defer client.Close()
+	inspector := asynq.NewInspector(redisOpt)
+	defer inspector.Close()
+
+	payload := service.UpdateQuotePayload{
+		UpdateID: "123e4567-e89b-12d3-a456-426614174000",
+		Pair:     service.Pair{Base: "EUR", Quote: "USD"},
+		Provider: "frankfurter",
+	}
+	enqueuer := NewAsynqEnqueuer(client, 4, 45*time.Second, time.Second, "")
+	if err := enqueuer.EnqueueUpdateTask(context.Background(), payload, service.TaskOptions{}); err != nil {
+		t.Fatalf("EnqueueUpdateTask: %v", err)
+	}
+
+	tasks, err := inspector.ListPendingTasks("default")
+	if err != nil {
+		t.Fatalf("ListPendingTasks: %v", err)
+	}
+	if len(tasks) != 1 {
+		t.Fatalf("Expected 1 pending task, got %d", len(tasks))
+	}
+	task := tasks[0]
+	if task.Type != service.TaskTypeUpdateQuote {
+		t.Errorf("Expected type %s, got %s", service.TaskTypeUpdateQuote, task.Type)
+	}
+	if task.MaxRetry != 4 {
+		t.Errorf("Expected MaxRetry 4, got %d", task.MaxRetry)
+	}
+	if task.Timeout != 45*time.Second {
+		t.Errorf("Expected Timeout 45s, got %v", task.Timeout)
+	}
+	var got service.UpdateQuotePayload
+	if err := json.Unmarshal(task.Payload, &got); err != nil {
+		t.Fatalf("Failed to decode payload: %v", err)
+	}
+	if got != payload {
+		t.Errorf("Expected payload %+v, got %+v", payload, got)
+	}
+	if task.ID != payload.UpdateID {
+		t.Errorf("Expected task ID %s, got %s", payload.UpdateID, task.ID)
+	}
+
+	// The update already has a task, so enqueueing it again adds nothing.
+	if err := enqueuer.EnqueueUpdateTask(context.Background(), payload, service.TaskOptions{}); err != nil {
+		t.Fatalf("EnqueueUpdateTask again: %v", err)
+	}
+	if tasks, _ := inspector.ListPendingTasks("default"); len(tasks) != 1 {
+		t.Errorf("Expected still 1 pending task, got %d", len(tasks))
+	}
+}
+
+// newBlockingRedis starts a TCP server that accepts connections but never answers,
+// holding each one open for 3 seconds.
+func newBlockingRedis(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				time.Sleep(3 * time.Second)
+				_ = conn.Close()
+			}()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func TestAsynqEnqueuer_EnqueueTimeout(t *testing.T) {
+	client := asynq.NewClient(asynq.RedisClientOpt{Addr: newBlockingRedis(t)})
+	defer client.Close()
+
+	enqueuer := NewAsynqEnqueuer(client, 3, 30*time.Second, 100*time.Millisecond, "")
+	payload := service.UpdateQuotePayload{UpdateID: "123e4567-e89b-12d3-a456-426614174000", Pair: service.Pair{Base: "EUR", Quote: "USD"}}
+
+	start := time.Now()
+	err := enqueuer.EnqueueUpdateTask(context.Background(), payload, service.TaskOptions{})
+	elapsed := time.Since(start)
+
+	if !errors.Is(err, ErrEnqueueTimeout) {
+		t.Fatalf("Expected ErrEnqueueTimeout, got %v", err)
+	}
+	if elapsed > time.Second {
+		t.Errorf("Expected enqueue to give up after ~100ms, took %v", elapsed)
+	}
+}
+
+func TestAsynqEnqueuer_EnqueueUpdateTask_Queue(t *testing.T) {
+	mr := miniredis.RunT(t)
+
+	redisOpt := asynq.RedisClientOpt{Addr: mr.Addr()}
+	client := asynq.NewClient(redisOpt)
+	defer client.Close()
+	inspector := asynq.NewInspector(redisOpt)
+	defer inspector.Close()
+
+	payload := service.UpdateQuotePayload{UpdateID: "123e4567-e89b-12d3-a456-426614174000", Pair: service.Pair{Base: "EUR", Quote: "USD"}}
+	enqueuer := NewAsynqEnqueuer(client, 3, 30*time.Second, time.Second, "")
+	if err := enqueuer.EnqueueUpdateTask(context.Background(), payload, service.TaskOptions{Queue: "high"}); err != nil {
+		t.Fatalf("EnqueueUpdateTask: %v", err)
+	}
+
+	tasks, err := inspector.ListPendingTasks("high")
+	if err != nil {
+		t.Fatalf("ListPendingTasks: %v", err)
+	}
+	if len(tasks) != 1 {
+		t.Fatalf("Expected 1 pending task on queue high, got %d", len(tasks))
+	}
+}
+
+func TestAsynqEnqueuer_EnqueueUpdateTask_Namespace(t *testing.T) {
+	mr := miniredis.RunT(t)
+
+	redisOpt := asynq.RedisClientOpt{Addr: mr.Addr()}
+	client := asynq.NewClient(redisOpt)
+	defer client.Close()
+	inspector := asynq.NewInspector(redisOpt)
+	defer inspector.Close()
+
+	payload := service.UpdateQuotePayload{UpdateID: "123e4567-e89b-12d3-a456-426614174000", Pair: service.Pair{Base: "EUR", Quote: "USD"}}
+	enqueuer := NewAsynqEnqueuer(client, 3, 30*time.Second, time.Second, "staging")
+	for _, queue := range []string{"high", ""} {
+		if err := enqueuer.EnqueueUpdateTask(context.Background(), payload, service.TaskOptions{Queue: queue}); err != nil {
+			t.Fatalf("EnqueueUpdateTask on %q: %v", queue, err)
+		}
+	}
+
+	queues, err := inspector.Queues()
+	if err != nil {
+		t.Fatalf("Queues: %v", err)
+	}
+	slices.Sort(queues)
+	if want := []string{"staging:default", "staging:high"}; !slices.Equal(queues, want) {
+		t.Errorf("Expected queues %v, got %v", want, queues)
+	}
+	for queue := range Queues("staging") {
+		if _, ok := Queues("")[queue]; ok {
+			t.Errorf("Expected the server queue %s to be namespaced", queue)
+		}
+	}
+}
+
+// processUpdateStub is a QuoteServiceInterface whose ProcessUpdate returns err and,
+// if seen is set, records the payload it was called with.
+type processUpdateStub struct {
+	service.QuoteServiceInterface
+	err  error
+	seen *service.UpdateQuotePayload
+}
+
+func (s processUpdateStub) ProcessUpdate(_ context.Context, payload service.UpdateQuotePayload) error {
+	if s.seen != nil {
+		*s.seen = payload
+	}
+	return s.err
+}
+
+func TestQuoteUpdateHandler_PassesProvider(t *testing.T) {
+	payload, err := json.Marshal(service.UpdateQuotePayload{UpdateID: "x", Pair: service.Pair{Base: "EUR", Quote: "USD"}, Provider: "frankfurter"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var seen service.UpdateQuotePayload
+	h := NewQuoteUpdateHandler(processUpdateStub{seen: &seen}, zap.NewNop().Sugar())
+	if err := h(context.Background(), asynq.NewTask(service.TaskTypeUpdateQuote, payload)); err != nil {
+		t.Fatalf("handler: %v", err)
+	}
+	if seen.Provider != "frankfurter" {
+		t.Errorf("Expected provider frankfurter to reach ProcessUpdate, got %+v", seen)
+	}
+}
+
+func TestQuoteUpdateHandler_Conflicts(t *testing.T) {
+	providerErr := errors.New("provider error")
+	tests := []struct {
+		name      string
+		err       error
+		wantNil   bool
+		skipRetry bool
+	}{
+		{name: "success", err: nil, wantNil: true},
+		{name: "already completed", err: fmt.Errorf("%w: update x is SUCCESS", service.ErrAlreadyCompleted), wantNil: true},
+		{name: "concurrent update", err: fmt.Errorf("%w: conflict", service.ErrUpdateConflict), skipRetry: true},
+		{name: "not found", err: service.ErrNotFound, skipRetry: true},
+		{name: "forced provider removed", err: &service.UnknownProviderError{Name: "ecb"}, skipRetry: true},
+		{name: "provider answered for another base", err: fmt.Errorf("%w: %w", service.ErrProviderUnavailable,
+			&provider.ProviderError{Code: 200, Message: "source USD instead of EUR", Err: provider.ErrSourceMismatch}), skipRetry: true},
+		{name: "provider error", err: providerErr},
+	}
+
+	payload, err := json.Marshal(service.UpdateQuotePayload{UpdateID: "x", Pair: service.Pair{Base: "EUR", Quote: "USD"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewQuoteUpdateHandler(processUpdateStub{err: tt.err}, zap.NewNop().Sugar())
+			got := h(context.Background(), asynq.NewTask(service.TaskTypeUpdateQuote, payload))
+			if tt.wantNil != (got == nil) {
+				t.Fatalf("Expected nil=%v, got %v", tt.wantNil, got)
+			}
+			if errors.Is(got, asynq.SkipRetry) != tt.skipRetry {
+				t.Errorf("Expected SkipRetry=%v, got %v", tt.skipRetry, got)
+			}
+			if got != nil && !errors.Is(got, tt.err) {
+				t.Errorf("Expected %v to wrap %v", got, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,204 @@
+// Package client is a Go client for the exchange rate quote service HTTP API.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	headerAPIKey   = "X-API-Key"
+	defaultTimeout = 10 * time.Second
+)
+
+// Client calls the quote service HTTP API. It is safe for concurrent use.
+type Client struct {
+	baseURL    *url.URL
+	apiKey     string
+	httpClient *http.Client
+	// signatureKeys verify quote responses when set.
+	signatureKeys map[string][]byte
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithAPIKey sends key in the X-API-Key header on every request.
+func WithAPIKey(key string) Option {
+	return func(c *Client) { c.apiKey = key }
+}
+
+// WithHTTPClient replaces the underlying http.Client, e.g. to customise its transport.
+// Options apply in order, so a later WithTimeout sets the timeout on a copy of hc.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) { c.httpClient = hc }
+}
+
+// WithTimeout sets the per-request timeout. The default is 10 seconds.
+func WithTimeout(d time.Duration) Option {
+	return func(c *Client) {
+		hc := *c.httpClient
+		hc.Timeout = d
+		c.httpClient = &hc
+	}
+}
+
+// New creates a Client for the API served at baseURL (e.g. "http://localhost:8080").
+func New(baseURL string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(strings.TrimRight(baseURL, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid base URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid base URL %q: scheme must be http or https", baseURL)
+	}
+
+	c := &Client{
+		baseURL:    u,
+		httpClient: &http.Client{Timeout: defaultTimeout},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// RequestUpdate asks the service to fetch a fresh rate for pair ("EUR/USD") and returns
+// the update ID to poll. If an update for the pair is already in flight, its ID is returned.
+func (c *Client) RequestUpdate(ctx context.Context, pair string) (string, error) {
+	var resp UpdateResponse
+	if err := c.do(ctx, http.MethodPost, "/quotes/update", nil, UpdateRequest{Pair: pair}, &resp); err != nil {
+		return "", err
+	}
+	return resp.UpdateID, nil
+}
+
+// GetResult returns the current state of an update.
+func (c *Client) GetResult(ctx context.Context, updateID string) (*QuoteResponse, error) {
+	return c.getResult(ctx, updateID, nil)
+}
+
+// GetResultWithEvents is GetResult with the update's status timeline, oldest first.
+func (c *Client) GetResultWithEvents(ctx context.Context, updateID string) (*QuoteResponse, error) {
+	return c.getResult(ctx, updateID, url.Values{"include_events": {"true"}})
+}
+
+func (c *Client) getResult(ctx context.Context, updateID string, query url.Values) (*QuoteResponse, error) {
+	var resp QuoteResponse
+	h, err := c.send(ctx, http.MethodGet, "/quotes/"+url.PathEscape(updateID), query, nil, &resp)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.verifyQuote(h, resp.Base, resp.Quote, derefStr(resp.Price), derefStr(resp.UpdatedAt)); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// GetLatest returns the most recent successful quote for the pair.
+func (c *Client) GetLatest(ctx context.Context, base, quote string) (*LatestResponse, error) {
+	query := url.Values{"base": {base}, "quote": {quote}}
+	var resp LatestResponse
+	h, err := c.send(ctx, http.MethodGet, "/quotes/latest", query, nil, &resp)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.verifyQuote(h, resp.Base, resp.Quote, resp.Price, resp.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// WaitForResult polls GetResult every pollInterval until the update is SUCCESS or FAILED,
+// or ctx is done. A FAILED update is returned without an error; check Status.
+func (c *Client) WaitForResult(ctx context.Context, updateID string, pollInterval time.Duration) (*QuoteResponse, error) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		res, err := c.GetResult(ctx, updateID)
+		if err != nil {
+			return nil, err
+		}
+		if res.Done() {
+			return res, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, out any) error {
+	_, err := c.send(ctx, method, path, query, body, out)
+	return err
+}
+
+// send is do returning the response headers.
+func (c *Client) send(ctx context.Context, method, path string, query url.Values, body, out any) (http.Header, error) {
+	u := c.baseURL.JoinPath(path)
+	u.RawQuery = query.Encode()
+
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("encode request: %w", err)
+		}
+		reqBody = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("build request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.apiKey != "" {
+		req.Header.Set(headerAPIKey, c.apiKey)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, newAPIError(resp)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+	return resp.Header, nil
+}
+
+func newAPIError(resp *http.Response) error {
+	apiErr := &APIError{StatusCode: resp.StatusCode}
+
+	var body ErrorResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&body); err == nil {
+		apiErr.Message = body.Error
+		apiErr.Code = body.Code
+	} else if !errors.Is(err, io.EOF) {
+		apiErr.Message = http.StatusText(resp.StatusCode)
+	}
+	if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		apiErr.RetryAfter = time.Duration(secs) * time.Second
+	}
+	return apiErr
+}
@@ -0,0 +1,217 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testUpdateID = "123e4567-e89b-12d3-a456-426614174000"
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func newTestClient(t *testing.T, handler http.HandlerFunc, opts ...Option) *Client {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	c, err := New(srv.URL, opts...)
+	require.NoError(t, err)
+	return c
+}
+
+func TestNew_InvalidBaseURL(t *testing.T) {
+	for _, raw := range []string{"", "localhost:8080", "ftp://example.com", "http://[::1"} {
+		_, err := New(raw)
+		assert.Error(t, err, raw)
+	}
+}
+
+func TestRequestUpdate(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/quotes/update", r.URL.Path)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.Equal(t, "secret", r.Header.Get("X-API-Key"))
+
+		var req UpdateRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "EUR/USD", req.Pair)
+
+		writeJSON(w, http.StatusAccepted, UpdateResponse{UpdateID: testUpdateID})
+	}, WithAPIKey("secret"))
+
+	id, err := c.RequestUpdate(context.Background(), "EUR/USD")
+	require.NoError(t, err)
+	assert.Equal(t, testUpdateID, id)
+}
+
+func TestGetResult(t *testing.T) {
+	price, ts := "1.085", "2025-12-01T10:15:30Z"
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/quotes/"+testUpdateID, r.URL.Path)
+		assert.Empty(t, r.Header.Get("X-API-Key"))
+		writeJSON(w, http.StatusOK, QuoteResponse{
+			UpdateID: testUpdateID, Base: "EUR", Quote: "USD", Status: StatusSuccess,
+			Price: &price, UpdatedAt: &ts, RateTimestamp: &ts,
+		})
+	})
+
+	res, err := c.GetResult(context.Background(), testUpdateID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusSuccess, res.Status)
+	assert.True(t, res.Done())
+	require.NotNil(t, res.Price)
+	assert.Equal(t, price, *res.Price)
+}
+
+func TestGetResultWithEvents(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/quotes/"+testUpdateID, r.URL.Path)
+		assert.Equal(t, "true", r.URL.Query().Get("include_events"))
+		writeJSON(w, http.StatusOK, QuoteResponse{
+			UpdateID: testUpdateID, Base: "EUR", Quote: "USD", Status: StatusRunning,
+			Events: []StatusEvent{
+				{Status: StatusPending, At: "2025-12-01T10:15:29Z"},
+				{Status: StatusRunning, At: "2025-12-01T10:15:30Z"},
+			},
+		})
+	})
+
+	res, err := c.GetResultWithEvents(context.Background(), testUpdateID)
+	require.NoError(t, err)
+	require.Len(t, res.Events, 2)
+	assert.Equal(t, StatusRunning, res.Events[1].Status)
+}
+
+func TestGetLatest(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/quotes/latest", r.URL.Path)
+		assert.Equal(t, "EUR", r.URL.Query().Get("base"))
+		assert.Equal(t, "MXN", r.URL.Query().Get("quote"))
+		writeJSON(w, http.StatusOK, LatestResponse{Base: "EUR", Quote: "MXN", Price: "18.7543", UpdatedAt: "2025-12-01T10:15:30Z"})
+	})
+
+	res, err := c.GetLatest(context.Background(), "EUR", "MXN")
+	require.NoError(t, err)
+	assert.Equal(t, "18.7543", res.Price)
+}
+
+func TestErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		body       string
+		retryAfter string
+		wantErr    error
+		wantMsg    string
+		wantRetry  time.Duration
+		wantCode   int
+	}{
+		{"bad request", http.StatusBadRequest, `{"error":"invalid currency code format","code":4001}`, "", ErrBadRequest, "invalid currency code format", 0, 4001},
+		{"unauthorized", http.StatusUnauthorized, `{"error":"missing API key"}`, "", ErrUnauthorized, "missing API key", 0, 0},
+		{"forbidden", http.StatusForbidden, `{"error":"insufficient scope"}`, "", ErrForbidden, "insufficient scope", 0, 0},
+		{"not found", http.StatusNotFound, `{"error":"Unknown update_id","code":4041}`, "", ErrNotFound, "Unknown update_id", 0, 4041},
+		{"quota exceeded", http.StatusTooManyRequests, `{"error":"monthly quota exceeded","code":4291,"limit":100,"reset_at":"2025-12-01T00:00:00Z"}`, "3600", ErrQuotaExceeded, "monthly quota exceeded", time.Hour, 4291},
+		{"queue unavailable", http.StatusServiceUnavailable, `{"error":"Task queue unavailable, retry later","code":5031}`, "5", ErrUnavailable, "Task queue unavailable, retry later", 5 * time.Second, 5031},
+		{"internal", http.StatusInternalServerError, `{"error":"Internal error","code":5001}`, "", ErrInternal, "Internal error", 0, 5001},
+		{"non-JSON body", http.StatusBadGateway, "<html>bad gateway</html>", "", ErrUnexpected, "Bad Gateway", 0, 0},
+		{"empty body", http.StatusTeapot, "", "", ErrUnexpected, "", 0, 0},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c := newTestClient(t, func(w http.ResponseWriter, _ *http.Request) {
+				if tc.retryAfter != "" {
+					w.Header().Set("Retry-After", tc.retryAfter)
+				}
+				w.WriteHeader(tc.status)
+				_, _ = w.Write([]byte(tc.body))
+			})
+
+			_, err := c.GetResult(context.Background(), testUpdateID)
+			require.ErrorIs(t, err, tc.wantErr)
+
+			var apiErr *APIError
+			require.ErrorAs(t, err, &apiErr)
+			assert.Equal(t, tc.status, apiErr.StatusCode)
+			assert.Equal(t, tc.wantMsg, apiErr.Message)
+			assert.Equal(t, tc.wantRetry, apiErr.RetryAfter)
+			assert.Equal(t, tc.wantCode, apiErr.Code)
+		})
+	}
+}
+
+func TestWaitForResult(t *testing.T) {
+	var calls atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, _ *http.Request) {
+		status := StatusPending
+		switch calls.Add(1) {
+		case 1:
+		case 2:
+			status = StatusRunning
+		default:
+			status = StatusFailed
+		}
+		writeJSON(w, http.StatusOK, QuoteResponse{UpdateID: testUpdateID, Base: "EUR", Quote: "USD", Status: status})
+	})
+
+	res, err := c.WaitForResult(context.Background(), testUpdateID, time.Millisecond)
+	require.NoError(t, err)
+	assert.Equal(t, StatusFailed, res.Status)
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestWaitForResult_ContextDone(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, http.StatusOK, QuoteResponse{UpdateID: testUpdateID, Status: StatusPending})
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err := c.WaitForResult(ctx, testUpdateID, 5*time.Millisecond)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded), "got %v", err)
+}
+
+func TestWithTimeout(t *testing.T) {
+	block := make(chan struct{})
+	c := newTestClient(t, func(http.ResponseWriter, *http.Request) { <-block }, WithTimeout(20*time.Millisecond))
+	// Registered after the server's cleanup so it runs first and lets Close return.
+	t.Cleanup(func() { close(block) })
+
+	_, err := c.GetLatest(context.Background(), "EUR", "USD")
+	require.Error(t, err)
+	var apiErr *APIError
+	assert.False(t, errors.As(err, &apiErr), "timeout should not be reported as an API error")
+}
+
+func TestWithHTTPClient(t *testing.T) {
+	var used atomic.Bool
+	hc := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		used.Store(true)
+		return http.DefaultTransport.RoundTrip(r)
+	})}
+	c := newTestClient(t, func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, http.StatusOK, LatestResponse{Base: "EUR", Quote: "USD", Price: "1.08"})
+	}, WithHTTPClient(hc))
+
+	_, err := c.GetLatest(context.Background(), "EUR", "USD")
+	require.NoError(t, err)
+	assert.True(t, used.Load())
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
@@ -0,0 +1,62 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Sentinel errors matching the API's status codes. Every *APIError unwraps to one of
+// them, so callers can use errors.Is(err, client.ErrNotFound).
+var (
+	ErrBadRequest    = errors.New("bad request")
+	ErrUnauthorized  = errors.New("unauthorized")
+	ErrForbidden     = errors.New("forbidden")
+	ErrNotFound      = errors.New("not found")
+	ErrQuotaExceeded = errors.New("quota exceeded")
+	ErrUnavailable   = errors.New("service unavailable")
+	ErrInternal      = errors.New("internal server error")
+	ErrUnexpected    = errors.New("unexpected response")
+)
+
+// ErrInvalidSignature is returned for a quote response whose signature is missing or
+// does not verify; see WithSignatureKeys.
+var ErrInvalidSignature = errors.New("invalid quote signature")
+
+// APIError is returned for any non-2xx response.
+type APIError struct {
+	StatusCode int
+	Message    string        // The "error" field of the response body, if any.
+	Code       int           // The machine-readable "code" field, e.g. 4041; 0 if absent.
+	RetryAfter time.Duration // Parsed from the Retry-After header on 429 and 503 responses.
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("quote service: HTTP %d", e.StatusCode)
+	}
+	return fmt.Sprintf("quote service: HTTP %d: %s", e.StatusCode, e.Message)
+}
+
+// Unwrap returns the sentinel error for the status code.
+func (e *APIError) Unwrap() error {
+	switch e.StatusCode {
+	case http.StatusBadRequest:
+		return ErrBadRequest
+	case http.StatusUnauthorized:
+		return ErrUnauthorized
+	case http.StatusForbidden:
+		return ErrForbidden
+	case http.StatusNotFound:
+		return ErrNotFound
+	case http.StatusTooManyRequests:
+		return ErrQuotaExceeded
+	case http.StatusServiceUnavailable:
+		return ErrUnavailable
+	case http.StatusInternalServerError:
+		return ErrInternal
+	default:
+		return ErrUnexpected
+	}
+}
@@ -0,0 +1,50 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"quoteservice/pkg/client"
+)
+
+func Example() {
+	c, err := client.New("http://localhost:8080", client.WithAPIKey("my-key"), client.WithTimeout(5*time.Second))
+	if err != nil {
+		log.Fatal(err)
+	}
+	ctx := context.Background()
+
+	id, err := c.RequestUpdate(ctx, "EUR/USD")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	res, err := c.WaitForResult(ctx, id, 500*time.Millisecond)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if res.Status == client.StatusFailed {
+		log.Fatalf("update failed: %s", *res.Error)
+	}
+	fmt.Println(res.Base, res.Quote, *res.Price)
+}
+
+func ExampleClient_GetLatest() {
+	c, err := client.New("http://localhost:8080")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	latest, err := c.GetLatest(context.Background(), "EUR", "MXN")
+	switch {
+	case errors.Is(err, client.ErrNotFound):
+		fmt.Println("no quote yet")
+	case err != nil:
+		log.Fatal(err)
+	default:
+		fmt.Println(latest.Price, latest.RateTimestamp)
+	}
+}
@@ -0,0 +1,66 @@
+package client
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Headers carrying the signature of GET /quotes/latest and GET /quotes/{update_id}
+// responses when the service has signing enabled.
+const (
+	HeaderSignature      = "X-Quote-Signature"
+	HeaderSignatureKeyID = "X-Quote-Signature-Key-Id"
+)
+
+// WithSignatureKeys makes GetLatest, GetResult, GetResultWithEvents and WaitForResult
+// verify the response signature and fail with ErrInvalidSignature if it is missing or
+// wrong. keys maps a key id to its secret; list the next key before the service
+// switches to it and drop the previous one once it has.
+func WithSignatureKeys(keys map[string][]byte) Option {
+	return func(c *Client) { c.signatureKeys = keys }
+}
+
+// VerifySignature checks the signature headers h of a quote response against the
+// response's base, quote, price and updated_at fields, as decoded (fields the
+// response omits are ""). The signature is the hex HMAC-SHA256 of the fields joined by
+// "|", with the secret keys maps the X-Quote-Signature-Key-Id header to. Every
+// failure wraps ErrInvalidSignature.
+func VerifySignature(h http.Header, keys map[string][]byte, base, quote, price, updatedAt string) error {
+	sig, keyID := h.Get(HeaderSignature), h.Get(HeaderSignatureKeyID)
+	if sig == "" {
+		return fmt.Errorf("%w: no %s header", ErrInvalidSignature, HeaderSignature)
+	}
+	key, ok := keys[keyID]
+	if !ok {
+		return fmt.Errorf("%w: unknown key id %q", ErrInvalidSignature, keyID)
+	}
+	got, err := hex.DecodeString(sig)
+	if err != nil {
+		return fmt.Errorf("%w: signature is not hex", ErrInvalidSignature)
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(strings.Join([]string{base, quote, price, updatedAt}, "|")))
+	if !hmac.Equal(got, mac.Sum(nil)) {
+		return fmt.Errorf("%w: signature does not match key %q", ErrInvalidSignature, keyID)
+	}
+	return nil
+}
+
+// verifyQuote verifies a quote response when signature keys are configured.
+func (c *Client) verifyQuote(h http.Header, base, quote, price, updatedAt string) error {
+	if c.signatureKeys == nil {
+		return nil
+	}
+	return VerifySignature(h, c.signatureKeys, base, quote, price, updatedAt)
+}
+
+func derefStr(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
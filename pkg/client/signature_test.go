@@ -0,0 +1,77 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The vectors match internal/api's TestQuoteSigner_Canonical, so the client keeps
+// verifying what the service signs.
+const (
+	testSignature        = "2e92dd5fa5f6aee2e7f065d820a8d50c2348b91a397ab4ded7d2c0ccf5217b46"
+	testSignatureNoPrice = "863e5a8f5692a8e64e0686be1bfbeaac2681b903d7484c03ed4ca00948db7128"
+)
+
+func signedHeader(sig, keyID string) http.Header {
+	h := http.Header{}
+	h.Set(HeaderSignature, sig)
+	h.Set(HeaderSignatureKeyID, keyID)
+	return h
+}
+
+func TestVerifySignature(t *testing.T) {
+	keys := map[string][]byte{"2026-10": []byte("test-secret"), "2026-04": []byte("old-secret")}
+
+	require.NoError(t, VerifySignature(signedHeader(testSignature, "2026-10"), keys, "EUR", "MXN", "18.7543", "2025-12-01T10:15:30Z"))
+	require.NoError(t, VerifySignature(signedHeader(testSignatureNoPrice, "2026-10"), keys, "EUR", "MXN", "", ""))
+
+	tests := map[string]struct {
+		header http.Header
+		price  string
+	}{
+		"tampered price":  {signedHeader(testSignature, "2026-10"), "18.7544"},
+		"other key":       {signedHeader(testSignature, "2026-04"), "18.7543"},
+		"unknown key id":  {signedHeader(testSignature, "2025-01"), "18.7543"},
+		"missing":         {http.Header{}, "18.7543"},
+		"not hex":         {signedHeader("zz", "2026-10"), "18.7543"},
+		"truncated":       {signedHeader(testSignature[:10], "2026-10"), "18.7543"},
+		"missing key id":  {signedHeader(testSignature, ""), "18.7543"},
+		"other signature": {signedHeader(testSignatureNoPrice, "2026-10"), "18.7543"},
+	}
+	for name, tc := range tests {
+		err := VerifySignature(tc.header, keys, "EUR", "MXN", tc.price, "2025-12-01T10:15:30Z")
+		assert.ErrorIs(t, err, ErrInvalidSignature, name)
+	}
+}
+
+func TestWithSignatureKeys(t *testing.T) {
+	price, updatedAt := "18.7543", "2025-12-01T10:15:30Z"
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(HeaderSignature, testSignature)
+		w.Header().Set(HeaderSignatureKeyID, "2026-10")
+		if r.URL.Path == "/quotes/latest" {
+			writeJSON(w, http.StatusOK, LatestResponse{Base: "EUR", Quote: "MXN", Price: price, UpdatedAt: updatedAt})
+			return
+		}
+		writeJSON(w, http.StatusOK, QuoteResponse{Base: "EUR", Quote: "MXN", Status: StatusSuccess, Price: &price, UpdatedAt: &updatedAt})
+	}
+	c := newTestClient(t, handler, WithSignatureKeys(map[string][]byte{"2026-10": []byte("test-secret")}))
+
+	_, err := c.GetLatest(context.Background(), "EUR", "MXN")
+	require.NoError(t, err)
+	_, err = c.GetResult(context.Background(), testUpdateID)
+	require.NoError(t, err)
+
+	price = "18.7544" // Altered in transit.
+	_, err = c.GetLatest(context.Background(), "EUR", "MXN")
+	assert.ErrorIs(t, err, ErrInvalidSignature)
+	_, err = c.GetResult(context.Background(), testUpdateID)
+	assert.ErrorIs(t, err, ErrInvalidSignature)
+
+	_, err = newTestClient(t, handler).GetLatest(context.Background(), "EUR", "MXN")
+	assert.NoError(t, err, "unsigned clients do not verify")
+}
@@ -0,0 +1,86 @@
+package client
+
+// The types below mirror the response DTOs in internal/api. They are duplicated on
+// purpose so that importing the client does not pull in server dependencies;
+// TestTypesMatchServer keeps the JSON shapes in sync.
+
+// Quote update statuses reported by the API.
+const (
+	StatusPending = "PENDING"
+	StatusRunning = "RUNNING"
+	StatusSuccess = "SUCCESS"
+	StatusFailed  = "FAILED"
+)
+
+// UpdateRequest is the body of POST /quotes/update.
+type UpdateRequest struct {
+	Pair     string `json:"pair"`
+	Provider string `json:"provider,omitempty"` // Forces a single provider; needs the admin scope.
+}
+
+// UpdateResponse is returned by POST /quotes/update.
+type UpdateResponse struct {
+	UpdateID string `json:"update_id"`
+	// Reason is set when an existing update was returned: "pending_exists" or "cooldown_active".
+	Reason               string `json:"reason,omitempty"`
+	CooldownRemainingSec int    `json:"cooldown_remaining_sec,omitempty"`
+	PollAfterMs          int    `json:"poll_after_ms,omitempty"` // Suggested wait before the first GetResult.
+}
+
+// QuoteResponse is returned by GET /quotes/{update_id}.
+type QuoteResponse struct {
+	UpdateID      string  `json:"update_id,omitempty"`
+	Base          string  `json:"base"`
+	Quote         string  `json:"quote"`
+	Status        string  `json:"status"`
+	Price         *string `json:"price,omitempty"`
+	UpdatedAt     *string `json:"updated_at,omitempty"`
+	RateTimestamp *string `json:"rate_timestamp,omitempty"`
+	Error         *string `json:"error,omitempty"`
+	ErrorCode     string  `json:"error_code,omitempty"` // e.g. "timeout"; empty for old failures.
+	Origin        string  `json:"origin,omitempty"`     // e.g. "api" or "stream".
+	// Events and InstanceID are only set when requested with GetResultWithEvents.
+	Events     []StatusEvent `json:"events,omitempty"`
+	InstanceID string        `json:"instance_id,omitempty"` // Instance that last ran the update.
+	// Verification is only sent for requests with include_verification=true.
+	Verification *Verification `json:"verification,omitempty"`
+}
+
+// Verification is the spread of the rates all providers reported for an update.
+type Verification struct {
+	MinPrice  string `json:"min_price"`
+	MaxPrice  string `json:"max_price"`
+	SpreadPct string `json:"spread_pct"`
+	Providers int    `json:"providers"`
+}
+
+// StatusEvent records when an update entered a status.
+type StatusEvent struct {
+	Status string  `json:"status"`
+	At     string  `json:"at"`
+	Detail *string `json:"detail,omitempty"`
+	// InstanceID is the service instance that made a RUNNING transition.
+	InstanceID string `json:"instance_id,omitempty"`
+}
+
+// Done reports whether the update has reached a terminal status.
+func (q *QuoteResponse) Done() bool {
+	return q.Status == StatusSuccess || q.Status == StatusFailed
+}
+
+// LatestResponse is returned by GET /quotes/latest.
+type LatestResponse struct {
+	UpdateID      string `json:"update_id,omitempty"` // For GetResult; empty for some streamed prices.
+	Base          string `json:"base"`
+	Quote         string `json:"quote"`
+	Price         string `json:"price"`
+	UpdatedAt     string `json:"updated_at"`
+	RateTimestamp string `json:"rate_timestamp"`
+	Derived       string `json:"derived,omitempty"` // "inverse" for a price computed from the inverse pair.
+}
+
+// ErrorResponse is the body of every non-2xx API response.
+type ErrorResponse struct {
+	Error string `json:"error"`
+	Code  int    `json:"code"`
+}
@@ -0,0 +1,72 @@
+package client
+
+import (
+	"reflect"
+	"testing"
+
+	"quoteservice/internal/api"
+)
+
+// TestTypesMatchServer fails when a server response DTO gains, loses or retypes a JSON
+// field without the client copy following.
+func TestTypesMatchServer(t *testing.T) {
+	pairs := []struct {
+		client, server any
+	}{
+		{UpdateRequest{}, api.UpdateRequest{}},
+		{UpdateResponse{}, api.UpdateResponse{}},
+		{QuoteResponse{}, api.QuoteResponse{}},
+		{StatusEvent{}, api.StatusEventResponse{}},
+		{Verification{}, api.VerificationResponse{}},
+		{LatestResponse{}, api.LatestResponse{}},
+		{ErrorResponse{}, api.ErrorResponse{}},
+	}
+
+	for _, p := range pairs {
+		ct, st := reflect.TypeOf(p.client), reflect.TypeOf(p.server)
+		t.Run(ct.Name(), func(t *testing.T) {
+			cf, sf := jsonFields(ct), jsonFields(st)
+			for tag, typ := range sf {
+				got, ok := cf[tag]
+				if !ok {
+					t.Errorf("client %s is missing field %q", ct.Name(), tag)
+					continue
+				}
+				if got != typ {
+					t.Errorf("field %q: client type %s, server type %s", tag, got, typ)
+				}
+			}
+			for tag := range cf {
+				if _, ok := sf[tag]; !ok {
+					t.Errorf("client %s has field %q that the server does not send", ct.Name(), tag)
+				}
+			}
+		})
+	}
+}
+
+// jsonFields maps each field's full json tag (name and options) to its Go type.
+func jsonFields(t reflect.Type) map[string]string {
+	fields := make(map[string]string, t.NumField())
+	for i := range t.NumField() {
+		f := t.Field(i)
+		fields[f.Tag.Get("json")] = fieldType(f.Type)
+	}
+	return fields
+}
+
+// fieldType describes t for comparison. Nested structs are declared separately on
+// each side, so only their kind is compared here; their fields are checked through
+// their own entry in pairs.
+func fieldType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Slice:
+		return "[]" + fieldType(t.Elem())
+	case reflect.Pointer:
+		return "*" + fieldType(t.Elem())
+	case reflect.Struct:
+		return "struct"
+	default:
+		return t.String()
+	}
+}